@@ -201,9 +201,20 @@ func StartApi(net *gracenet.Net, exitChan chan struct{}, config *config.SqleOpti
 		// workflow template
 		v1OpProjectRouter.PATCH("/:project_name/workflow_template", v1.UpdateWorkflowTemplate)
 
+		// execution freeze
+		v1OpProjectRouter.POST("/:project_name/execution_freeze_calendars", v1.CreateExecutionFreezeCalendarV1)
+		v1OpProjectRouter.PUT("/:project_name/execution_freeze_calendars/:execution_freeze_calendar_id/", v1.UpdateExecutionFreezeCalendarV1)
+		v1OpProjectRouter.DELETE("/:project_name/execution_freeze_calendars/:execution_freeze_calendar_id/", v1.DeleteExecutionFreezeCalendarV1)
+		v1OpProjectRouter.POST("/:project_name/workflows/:workflow_id/freeze_override/approve", v1.ApproveWorkflowFreezeOverrideV1)
+
 		// report push
 		v1OpProjectRouter.PUT("/:project_name/report_push_configs/:report_push_config_id/", v1.UpdateReportPushConfig)
 
+		// sql manage priority
+		v1OpProjectRouter.PUT("/:project_name/sql_manage_priority_rules", v1.UpdateSqlManagePriorityRulesV1)
+		v1OpProjectRouter.PUT("/:project_name/sql_manage_lifecycle", v1.UpdateSqlManageLifecycleV1)
+		v1OpProjectRouter.PUT("/:project_name/sql_manage_application_mappings", v1.UpdateSqlManageApplicationMappingsV1)
+
 		// sql version
 		v1OpProjectRouter.POST("/:project_name/sql_versions", v1.CreateSqlVersion)
 		v1OpProjectRouter.PATCH("/:project_name/sql_versions/:sql_version_id/", v1.UpdateSqlVersion)
@@ -234,6 +245,23 @@ func StartApi(net *gracenet.Net, exitChan chan struct{}, config *config.SqleOpti
 		v1ProjectOpRouter.GET("/:project_name/workflows/:workflow_id/backup_sqls", v1.GetBackupSqlList)
 		v1ProjectOpRouter.POST("/:project_name/workflows/:workflow_id/create_rollback_workflow", v1.CreateRollbackWorkflow)
 
+		// workflow approval delegate
+		v1ProjectOpRouter.POST("/:project_name/workflow_approval_delegates", v1.CreateWorkflowApprovalDelegateV1)
+		v1ProjectOpRouter.DELETE("/:project_name/workflow_approval_delegates/:workflow_approval_delegate_id/", v1.DeleteWorkflowApprovalDelegateV1)
+		v1ProjectOpRouter.POST("/:project_name/workflows/:workflow_id/freeze_override", v1.ApplyWorkflowFreezeOverrideV1)
+		v1ProjectOpRouter.GET("/:project_name/workflows/:workflow_id/tasks/:task_id/binlog_flashback", v1.GetTaskBinlogFlashbackV1)
+		v1ProjectOpRouter.POST("/:project_name/workflows/:workflow_id/tasks/:task_id/dry_run", v1.DryRunTaskV1)
+		v1ProjectOpRouter.PUT("/:project_name/workflows/:workflow_id/exec_checks", v1.UpdateWorkflowExecChecksV1)
+		v1ProjectOpRouter.PUT("/:project_name/workflows/:workflow_id/exec_plan", v1.UpdateWorkflowExecPlanV1)
+		v1ProjectOpRouter.DELETE("/:project_name/workflows/:workflow_id/exec_plan", v1.DeleteWorkflowExecPlanV1)
+		v1ProjectOpRouter.POST("/:project_name/workflows/:workflow_id/exec_plan/resume", v1.ResumeWorkflowExecutionV1)
+		v1ProjectOpRouter.POST("/:project_name/workflows/:workflow_id/tasks/:task_id/manual_execution", v1.CreateTaskManualExecutionV1)
+		v1ProjectOpRouter.POST("/:project_name/workflows/:workflow_id/tasks/:task_id/manual_execution/verify", v1.VerifyTaskManualExecutionV1)
+		v1ProjectOpRouter.POST("/:project_name/workflows/:workflow_id/tasks/:task_id/rollback_runs", v1.CreateRollbackRunV1)
+		v1ProjectOpRouter.POST("/:project_name/workflows/:workflow_id/tasks/:task_id/dml_chunk_executions/:dml_chunk_execution_id/pause", v1.PauseDMLChunkExecutionV1)
+		v1ProjectOpRouter.POST("/:project_name/workflows/:workflow_id/tasks/:task_id/dml_chunk_executions/:dml_chunk_execution_id/resume", v1.ResumeDMLChunkExecutionV1)
+		v1ProjectOpRouter.POST("/:project_name/workflows/:workflow_id/tasks/:task_id/dml_chunk_executions/:dml_chunk_execution_id/terminate", v1.TerminateDMLChunkExecutionV1)
+
 		// sql version
 		v1ProjectOpRouter.POST("/:project_name/sql_versions/:sql_version_id/batch_release_workflows", v1.BatchReleaseWorkflows)
 		v1ProjectOpRouter.POST("/:project_name/sql_versions/:sql_version_id/batch_execute_workflows", v1.BatchExecuteWorkflows)
@@ -323,8 +351,16 @@ func StartApi(net *gracenet.Net, exitChan chan struct{}, config *config.SqleOpti
 
 		// workflow template
 		v1ProjectViewRouter.GET("/:project_name/workflow_template", v1.GetWorkflowTemplate)
+		v1ProjectViewRouter.GET("/:project_name/workflow_approval_delegates", v1.GetWorkflowApprovalDelegatesV1)
+		v1ProjectViewRouter.GET("/:project_name/execution_freeze_calendars", v1.GetExecutionFreezeCalendarsV1)
 		v1ProjectViewRouter.GET("/:project_name/workflows/:workflow_name/", DeprecatedBy(apiV2))
 		v1ProjectViewRouter.GET("/:project_name/workflows", v1.GetWorkflowsV1)
+		v1ProjectViewRouter.GET("/:project_name/workflows/:workflow_id/tasks/:task_id/rollback_runs", v1.GetRollbackRunsV1)
+		v1ProjectViewRouter.GET("/:project_name/workflows/:workflow_id/tasks/:task_id/dml_chunk_executions", v1.GetDMLChunkExecutionsV1)
+		v1ProjectViewRouter.GET("/:project_name/workflows/:workflow_id/exec_checks", v1.GetWorkflowExecChecksV1)
+		v1ProjectViewRouter.GET("/:project_name/workflows/:workflow_id/exec_plan", v1.GetWorkflowExecPlanV1)
+		v1ProjectViewRouter.GET("/:project_name/workflows/:workflow_id/tasks/:task_id/manual_execution", v1.GetTaskManualExecutionV1)
+		v1ProjectViewRouter.GET("/:project_name/workflows/:workflow_id/tasks/:task_id/manual_execution/evidence_file", v1.DownloadTaskManualExecutionEvidenceFileV1)
 		v1ProjectViewRouter.GET("/:project_name/workflows/:workflow_name/tasks", DeprecatedBy(apiV2))
 		v1ProjectViewRouter.GET("/:project_name/workflows/exports", v1.ExportWorkflowV1)
 		v1ProjectViewRouter.GET("/:project_name/workflows/:workflow_id/tasks/:task_id/attachment", v1.GetWorkflowTaskAuditFile)
@@ -354,8 +390,16 @@ func StartApi(net *gracenet.Net, exitChan chan struct{}, config *config.SqleOpti
 		v1ProjectViewRouter.GET("/:project_name/sql_manages/rule_tips", v1.GetSqlManageRuleTips)
 		v1ProjectViewRouter.GET("/:project_name/sql_manages/:sql_manage_id/sql_analysis", v1.GetSqlManageSqlAnalysisV1)
 		v1ProjectViewRouter.GET("/:project_name/sql_manages/:sql_manage_id/sql_analysis_chart", v1.GetSqlManageSqlAnalysisChartV1)
+		v1ProjectViewRouter.GET("/:project_name/sql_manages/:sql_manage_id/metric_history", v1.GetSqlManageMetricHistoryV1)
+		v1ProjectViewRouter.GET("/:project_name/sql_manage_priority_rules", v1.GetSqlManagePriorityRulesV1)
+		v1ProjectViewRouter.GET("/:project_name/sql_manage_lifecycle", v1.GetSqlManageLifecycleV1)
+		v1ProjectViewRouter.GET("/:project_name/sql_manages/:sql_manage_id/activities", v1.GetSqlManageActivitiesV1)
+		v1ProjectViewRouter.GET("/:project_name/sql_manage_application_mappings", v1.GetSqlManageApplicationMappingsV1)
+		v1ProjectViewRouter.GET("/:project_name/sql_manage_applications", v1.GetSqlManageApplicationsV1)
+		v1ProjectViewRouter.GET("/:project_name/sql_manage_applications/exports", v1.ExportSqlManageApplicationV1)
 		v1ProjectViewRouter.POST("/:project_name/sql_manages/send", v1.SendSqlManage)
 		v1ProjectViewRouter.GET("/:project_name/sql_manages/abnormal_audit_plan_instance", v1.GetAbnormalInstanceAuditPlans)
+		v1ProjectViewRouter.GET("/:project_name/sql_manages/queue_status", v1.GetSQLManageQueueStatusV1)
 
 		// sql dev records
 		v1ProjectViewRouter.GET("/:project_name/sql_dev_records", v1.GetSqlDEVRecordList)
@@ -377,6 +421,9 @@ func StartApi(net *gracenet.Net, exitChan chan struct{}, config *config.SqleOpti
 
 		v1ProjectViewRouter.GET("/:project_name/instance_audit_plans/:instance_audit_plan_id/audit_plans/:audit_plan_id/sqls", v1.GetInstanceAuditPlanSQLs) // 弃用
 		v1ProjectViewRouter.GET("/:project_name/instance_audit_plans/:instance_audit_plan_id/audit_plans/:audit_plan_id/sql_meta", v1.GetInstanceAuditPlanSQLMeta)
+		v1ProjectViewRouter.GET("/:project_name/instance_audit_plans/:instance_audit_plan_id/audit_plans/:audit_plan_id/schema_drifts", v1.GetSchemaMetaDriftsV1)
+		v1ProjectViewRouter.GET("/:project_name/instance_audit_plans/:instance_audit_plan_id/audit_plans/:audit_plan_id/schema_snapshots", v1.GetSchemaMetaSnapshotsV1)
+		v1ProjectViewRouter.GET("/:project_name/instance_audit_plans/:instance_audit_plan_id/audit_plans/:audit_plan_id/collection_records", v1.GetAuditPlanCollectionRecordsV1)
 		v1ProjectViewRouter.GET("/:project_name/instance_audit_plans/:instance_audit_plan_id/sqls/:id/analysis", v1.GetAuditPlanSqlAnalysisData)
 
 		v1ProjectViewRouter.GET("/:project_name/sql_versions", v1.GetSqlVersionList)
@@ -419,6 +466,7 @@ func StartApi(net *gracenet.Net, exitChan chan struct{}, config *config.SqleOpti
 	{
 		v2ProjectViewRouter.GET("/:project_name/workflows/:workflow_id/", v2.GetWorkflowV2)
 		v2ProjectViewRouter.GET("/:project_name/workflows/:workflow_id/tasks", v2.GetSummaryOfWorkflowTasksV2)
+		v2ProjectViewRouter.GET("/:project_name/workflows/:workflow_id/records/diff", v2.GetWorkflowAuditDiffV2)
 		// instance
 		v2ProjectViewRouter.GET("/:project_name/instances/:instance_name/", v2.GetInstance)
 		// audit plan; 智能扫描任务
@@ -473,9 +521,11 @@ func StartApi(net *gracenet.Net, exitChan chan struct{}, config *config.SqleOpti
 		v1Router.GET("/tasks/audits/:task_id/audit_file", v1.DownloadAuditFile)
 		v1Router.GET("/tasks/audits/:task_id/sql_content", v1.GetAuditTaskSQLContent)
 		v1Router.PATCH("/tasks/audits/:task_id/sqls/:number", v1.UpdateAuditTaskSQLs)
+		v1Router.PATCH("/tasks/audits/:task_id/exec_policy", v1.UpdateTaskExecPolicy)
 		v1Router.GET("/tasks/audits/:task_id/sqls/:number/analysis", v1.GetTaskAnalysisData)
 		v2Router.GET("/tasks/audits/:task_id/sqls/:number/analysis", v2.GetTaskAnalysisData)
 		v1Router.POST("/projects/:project_name/task_groups", v1.CreateAuditTasksGroupV1)
+		v1Router.POST("/projects/:project_name/task_groups/fan_out", v1.CreateFanOutAuditTasksGroupV1)
 		v1Router.POST("/task_groups/audit", v1.AuditTaskGroupV1)
 		v1Router.GET("/tasks/file_order_methods", v1.GetSqlFileOrderMethodV1)
 		v1Router.POST("/tasks/audits/:task_id/sqls/:number/rewrite", v1.RewriteSQL)
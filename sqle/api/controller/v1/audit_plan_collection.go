@@ -0,0 +1,121 @@
+package v1
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/actiontech/sqle/sqle/api/controller"
+	"github.com/actiontech/sqle/sqle/model"
+	"github.com/actiontech/sqle/sqle/server/auditplan"
+
+	"github.com/labstack/echo/v4"
+)
+
+type GetAuditPlanCollectionRecordsReqV1 struct {
+	PageIndex uint32 `json:"page_index" query:"page_index" valid:"required"`
+	PageSize  uint32 `json:"page_size" query:"page_size" valid:"required"`
+}
+
+type AuditPlanCollectionRecord struct {
+	Id     uint   `json:"id"`
+	Status string `json:"status" enums:"success,failed,deferred,dropped"`
+	// the depth of the sql manage queue when the collection started
+	QueueDepth      int64     `json:"queue_depth"`
+	SQLCount        int       `json:"sql_count"`
+	DroppedSQLCount int       `json:"dropped_sql_count"`
+	ErrorMessage    string    `json:"error_message"`
+	StartAt         time.Time `json:"start_at"`
+	EndAt           time.Time `json:"end_at"`
+	DurationMs      int64     `json:"duration_ms"`
+}
+
+type GetAuditPlanCollectionRecordsResV1 struct {
+	controller.BaseRes
+	Data      []*AuditPlanCollectionRecord `json:"data"`
+	TotalNums uint64                       `json:"total_nums"`
+}
+
+// GetAuditPlanCollectionRecordsV1
+// @Summary 获取扫描任务的采集记录
+// @Description get the collection records of the audit plan, the latest record is the first
+// @Id getAuditPlanCollectionRecordsV1
+// @Tags instance_audit_plan
+// @Security ApiKeyAuth
+// @Param project_name path string true "project name"
+// @Param instance_audit_plan_id path string true "instance audit plan id"
+// @Param audit_plan_id path string true "audit plan id"
+// @Param page_index query uint32 true "page index"
+// @Param page_size query uint32 true "size of per page"
+// @Success 200 {object} v1.GetAuditPlanCollectionRecordsResV1
+// @router /v1/projects/{project_name}/instance_audit_plans/{instance_audit_plan_id}/audit_plans/{audit_plan_id}/collection_records [get]
+func GetAuditPlanCollectionRecordsV1(c echo.Context) error {
+	req := new(GetAuditPlanCollectionRecordsReqV1)
+	if err := controller.BindAndValidateReq(c, req); err != nil {
+		return err
+	}
+	auditPlanId, err := getViewableAuditPlanId(c)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	limit, offset := controller.GetLimitAndOffset(req.PageIndex, req.PageSize)
+	records, count, err := model.GetStorage().ListAuditPlanCollectionRecords(auditPlanId, int(limit), int(offset))
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	data := make([]*AuditPlanCollectionRecord, 0, len(records))
+	for _, record := range records {
+		data = append(data, &AuditPlanCollectionRecord{
+			Id:              record.ID,
+			Status:          record.Status,
+			QueueDepth:      record.QueueDepth,
+			SQLCount:        record.SQLCount,
+			DroppedSQLCount: record.DroppedSQLCount,
+			ErrorMessage:    record.ErrorMessage,
+			StartAt:         record.StartAt,
+			EndAt:           record.EndAt,
+			DurationMs:      record.DurationMs,
+		})
+	}
+	return c.JSON(http.StatusOK, &GetAuditPlanCollectionRecordsResV1{
+		BaseRes:   controller.NewBaseReq(nil),
+		Data:      data,
+		TotalNums: count,
+	})
+}
+
+type SQLManageQueueStatus struct {
+	// the number of the collected SQLs waiting to be aggregated
+	QueueDepth    int64 `json:"queue_depth"`
+	MaxQueueDepth int64 `json:"max_queue_depth"`
+	// defer skips the collections until the queue is drained but keeps the SQLs already collected or uploaded, drop collects the SQLs but drops them
+	QueueFullPolicy string `json:"queue_full_policy" enums:"defer,drop"`
+}
+
+type GetSQLManageQueueStatusResV1 struct {
+	controller.BaseRes
+	Data *SQLManageQueueStatus `json:"data"`
+}
+
+// GetSQLManageQueueStatusV1
+// @Summary 获取管控SQL采集队列的积压情况
+// @Description get the depth of the queue of the collected SQLs, the collections are deferred or dropped when the queue is full
+// @Id getSQLManageQueueStatusV1
+// @Tags SqlManage
+// @Security ApiKeyAuth
+// @Param project_name path string true "project name"
+// @Success 200 {object} v1.GetSQLManageQueueStatusResV1
+// @router /v1/projects/{project_name}/sql_manages/queue_status [get]
+func GetSQLManageQueueStatusV1(c echo.Context) error {
+	depth, maxDepth, policy, err := auditplan.GetSQLManageQueueStatus(model.GetStorage())
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	return c.JSON(http.StatusOK, &GetSQLManageQueueStatusResV1{
+		BaseRes: controller.NewBaseReq(nil),
+		Data: &SQLManageQueueStatus{
+			QueueDepth:      depth,
+			MaxQueueDepth:   maxDepth,
+			QueueFullPolicy: policy,
+		},
+	})
+}
@@ -0,0 +1,75 @@
+package v1
+
+import (
+	"context"
+	"net/http"
+
+	dmsV1 "github.com/actiontech/dms/pkg/dms-common/api/dms/v1"
+	"github.com/actiontech/sqle/sqle/api/controller"
+	"github.com/actiontech/sqle/sqle/dms"
+	"github.com/actiontech/sqle/sqle/log"
+	"github.com/actiontech/sqle/sqle/model"
+	"github.com/actiontech/sqle/sqle/server"
+
+	"github.com/labstack/echo/v4"
+)
+
+type GetTaskBinlogFlashbackResV1 struct {
+	controller.BaseRes
+	Data []*TaskBinlogFlashbackResV1 `json:"data"`
+}
+
+type TaskBinlogFlashbackResV1 struct {
+	// the SQLs executed in one transaction are flashed back together
+	ExecSqlIds    []uint   `json:"exec_sql_ids"`
+	ExecSQLs      []string `json:"exec_sqls"`
+	FlashbackSQLs []string `json:"flashback_sqls"`
+}
+
+// GetTaskBinlogFlashbackV1
+// @Summary 获取工单任务基于binlog的闪回SQL
+// @Description generate the flashback SQLs of the executed SQLs of the MySQL workflow task from the row events in the recorded binlog coordinates
+// @Id getTaskBinlogFlashbackV1
+// @Tags workflow
+// @Security ApiKeyAuth
+// @Param project_name path string true "project name"
+// @Param workflow_id path string true "workflow id"
+// @Param task_id path string true "task id"
+// @Success 200 {object} v1.GetTaskBinlogFlashbackResV1
+// @router /v1/projects/{project_name}/workflows/{workflow_id}/tasks/{task_id}/binlog_flashback [get]
+func GetTaskBinlogFlashbackV1(c echo.Context) error {
+	projectUid, err := dms.GetPorjectUIDByName(context.TODO(), c.Param("project_name"))
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	s := model.GetStorage()
+	workflow, err := dms.GetWorkflowDetailByWorkflowId(projectUid, c.Param("workflow_id"), s.GetWorkflowDetailWithoutInstancesByWorkflowID)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	if err := CheckCurrentUserCanOperateWorkflow(c, projectUid, workflow, []dmsV1.OpPermissionType{}); err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	task, err := getWorkflowTaskDetail(c.Request().Context(), workflow, c.Param("task_id"))
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+
+	flashbacks, err := server.GenerateBinlogFlashback(log.NewEntry(), task)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	data := make([]*TaskBinlogFlashbackResV1, 0, len(flashbacks))
+	for _, flashback := range flashbacks {
+		res := &TaskBinlogFlashbackResV1{FlashbackSQLs: flashback.FlashbackSQL}
+		for _, sql := range flashback.ExecuteSQLs {
+			res.ExecSqlIds = append(res.ExecSqlIds, sql.ID)
+			res.ExecSQLs = append(res.ExecSQLs, sql.Content)
+		}
+		data = append(data, res)
+	}
+	return c.JSON(http.StatusOK, &GetTaskBinlogFlashbackResV1{
+		BaseRes: controller.NewBaseReq(nil),
+		Data:    data,
+	})
+}
@@ -0,0 +1,202 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	dmsV1 "github.com/actiontech/dms/pkg/dms-common/api/dms/v1"
+	"github.com/actiontech/sqle/sqle/api/controller"
+	"github.com/actiontech/sqle/sqle/dms"
+	"github.com/actiontech/sqle/sqle/errors"
+	"github.com/actiontech/sqle/sqle/model"
+
+	"github.com/labstack/echo/v4"
+)
+
+type GetDMLChunkExecutionsResV1 struct {
+	controller.BaseRes
+	Data []*DMLChunkExecutionResV1 `json:"data"`
+}
+
+type DMLChunkExecutionResV1 struct {
+	Id           uint             `json:"id"`
+	ExecuteSQLId uint             `json:"exec_sql_id"`
+	Schema       string           `json:"schema"`
+	Table        string           `json:"table"`
+	PrimaryKey   string           `json:"primary_key"`
+	ChunkSize    uint             `json:"chunk_size"`
+	Status       string           `json:"status" enums:"running,paused,terminating,terminated,succeeded,failed"`
+	ChunkCount   uint             `json:"chunk_count"`
+	RowAffects   int64            `json:"row_affects"`
+	LastKey      string           `json:"last_key"`
+	ExecResult   string           `json:"exec_result"`
+	Chunks       []*DMLChunkResV1 `json:"chunks"`
+}
+
+type DMLChunkResV1 struct {
+	Number      uint       `json:"number"`
+	LowerKey    string     `json:"lower_key"`
+	UpperKey    string     `json:"upper_key"`
+	RowAffects  int64      `json:"row_affects"`
+	ExecStartAt *time.Time `json:"exec_start_at"`
+	ExecEndAt   *time.Time `json:"exec_end_at"`
+	ExecStatus  string     `json:"exec_status" enums:"succeeded,failed"`
+	ExecResult  string     `json:"exec_result"`
+}
+
+// GetDMLChunkExecutionsV1
+// @Summary 获取工单任务中分批执行的DML进度
+// @Description get the progress of the DMLs executed in chunks of primary key range in the workflow task
+// @Id getDMLChunkExecutionsV1
+// @Tags workflow
+// @Security ApiKeyAuth
+// @Param project_name path string true "project name"
+// @Param workflow_id path string true "workflow id"
+// @Param task_id path string true "task id"
+// @Success 200 {object} v1.GetDMLChunkExecutionsResV1
+// @router /v1/projects/{project_name}/workflows/{workflow_id}/tasks/{task_id}/dml_chunk_executions [get]
+func GetDMLChunkExecutionsV1(c echo.Context) error {
+	projectUid, err := dms.GetPorjectUIDByName(context.TODO(), c.Param("project_name"))
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	s := model.GetStorage()
+	workflow, err := dms.GetWorkflowDetailByWorkflowId(projectUid, c.Param("workflow_id"), s.GetWorkflowDetailWithoutInstancesByWorkflowID)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	if err := CheckCurrentUserCanViewWorkflow(c, projectUid, workflow, []dmsV1.OpPermissionType{dmsV1.OpPermissionTypeViewOthersWorkflow}); err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	task, err := getWorkflowTaskDetail(c.Request().Context(), workflow, c.Param("task_id"))
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	executions, err := s.GetDMLChunkExecutionsByTaskId(task.ID)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	data := make([]*DMLChunkExecutionResV1, 0, len(executions))
+	for _, execution := range executions {
+		res := &DMLChunkExecutionResV1{
+			Id:           execution.ID,
+			ExecuteSQLId: execution.ExecuteSQLId,
+			Schema:       execution.Schema,
+			Table:        execution.Table,
+			PrimaryKey:   execution.PrimaryKey,
+			ChunkSize:    execution.ChunkSize,
+			Status:       execution.Status,
+			ChunkCount:   execution.ChunkCount,
+			RowAffects:   execution.RowAffects,
+			LastKey:      execution.LastKey,
+			ExecResult:   execution.ExecResult,
+			Chunks:       make([]*DMLChunkResV1, 0, len(execution.Chunks)),
+		}
+		for _, chunk := range execution.Chunks {
+			res.Chunks = append(res.Chunks, &DMLChunkResV1{
+				Number:      chunk.Number,
+				LowerKey:    chunk.LowerKey,
+				UpperKey:    chunk.UpperKey,
+				RowAffects:  chunk.RowAffects,
+				ExecStartAt: chunk.ExecStartAt,
+				ExecEndAt:   chunk.ExecEndAt,
+				ExecStatus:  chunk.ExecStatus,
+				ExecResult:  chunk.ExecResult,
+			})
+		}
+		data = append(data, res)
+	}
+	return c.JSON(http.StatusOK, &GetDMLChunkExecutionsResV1{
+		BaseRes: controller.NewBaseReq(nil),
+		Data:    data,
+	})
+}
+
+// PauseDMLChunkExecutionV1
+// @Summary 暂停分批执行的DML
+// @Description pause the DML executed in chunks after the current chunk
+// @Id pauseDMLChunkExecutionV1
+// @Tags workflow
+// @Security ApiKeyAuth
+// @Param project_name path string true "project name"
+// @Param workflow_id path string true "workflow id"
+// @Param task_id path string true "task id"
+// @Param dml_chunk_execution_id path string true "dml chunk execution id"
+// @Success 200 {object} controller.BaseRes
+// @router /v1/projects/{project_name}/workflows/{workflow_id}/tasks/{task_id}/dml_chunk_executions/{dml_chunk_execution_id}/pause [post]
+func PauseDMLChunkExecutionV1(c echo.Context) error {
+	return updateDMLChunkExecutionStatus(c, []string{model.DMLChunkExecutionStatusRunning}, model.DMLChunkExecutionStatusPaused)
+}
+
+// ResumeDMLChunkExecutionV1
+// @Summary 恢复分批执行的DML
+// @Description resume the paused DML executed in chunks
+// @Id resumeDMLChunkExecutionV1
+// @Tags workflow
+// @Security ApiKeyAuth
+// @Param project_name path string true "project name"
+// @Param workflow_id path string true "workflow id"
+// @Param task_id path string true "task id"
+// @Param dml_chunk_execution_id path string true "dml chunk execution id"
+// @Success 200 {object} controller.BaseRes
+// @router /v1/projects/{project_name}/workflows/{workflow_id}/tasks/{task_id}/dml_chunk_executions/{dml_chunk_execution_id}/resume [post]
+func ResumeDMLChunkExecutionV1(c echo.Context) error {
+	return updateDMLChunkExecutionStatus(c, []string{model.DMLChunkExecutionStatusPaused}, model.DMLChunkExecutionStatusRunning)
+}
+
+// TerminateDMLChunkExecutionV1
+// @Summary 终止分批执行的DML
+// @Description terminate the DML executed in chunks after the current chunk, the executed chunks are not rolled back and the task fails
+// @Id terminateDMLChunkExecutionV1
+// @Tags workflow
+// @Security ApiKeyAuth
+// @Param project_name path string true "project name"
+// @Param workflow_id path string true "workflow id"
+// @Param task_id path string true "task id"
+// @Param dml_chunk_execution_id path string true "dml chunk execution id"
+// @Success 200 {object} controller.BaseRes
+// @router /v1/projects/{project_name}/workflows/{workflow_id}/tasks/{task_id}/dml_chunk_executions/{dml_chunk_execution_id}/terminate [post]
+func TerminateDMLChunkExecutionV1(c echo.Context) error {
+	return updateDMLChunkExecutionStatus(c, []string{model.DMLChunkExecutionStatusRunning, model.DMLChunkExecutionStatusPaused}, model.DMLChunkExecutionStatusTerminating)
+}
+
+func updateDMLChunkExecutionStatus(c echo.Context, from []string, to string) error {
+	projectUid, err := dms.GetPorjectUIDByName(context.TODO(), c.Param("project_name"))
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	s := model.GetStorage()
+	workflow, err := dms.GetWorkflowDetailByWorkflowId(projectUid, c.Param("workflow_id"), s.GetWorkflowDetailWithoutInstancesByWorkflowID)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	if err := CheckCurrentUserCanOperateWorkflow(c, projectUid, workflow, []dmsV1.OpPermissionType{}); err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	task, err := getWorkflowTaskDetail(c.Request().Context(), workflow, c.Param("task_id"))
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	id, err := strconv.Atoi(c.Param("dml_chunk_execution_id"))
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, errors.NewDataInvalidErr("invalid dml chunk execution id"))
+	}
+	execution, exist, err := s.GetDMLChunkExecutionById(task.ID, uint(id))
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	if !exist {
+		return controller.JSONBaseErrorReq(c, errors.NewDataNotExistErr("dml chunk execution not exist"))
+	}
+	updated, err := s.UpdateDMLChunkExecutionStatus(execution, from, to)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	if !updated {
+		return controller.JSONBaseErrorReq(c, errors.New(errors.DataConflict, fmt.Errorf("the dml chunk execution is %v", execution.Status)))
+	}
+	return controller.JSONBaseErrorReq(c, nil)
+}
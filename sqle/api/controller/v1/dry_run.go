@@ -0,0 +1,105 @@
+package v1
+
+import (
+	"context"
+	"net/http"
+
+	dmsV1 "github.com/actiontech/dms/pkg/dms-common/api/dms/v1"
+	"github.com/actiontech/sqle/sqle/api/controller"
+	"github.com/actiontech/sqle/sqle/dms"
+	"github.com/actiontech/sqle/sqle/errors"
+	"github.com/actiontech/sqle/sqle/log"
+	"github.com/actiontech/sqle/sqle/model"
+	"github.com/actiontech/sqle/sqle/server"
+
+	"github.com/labstack/echo/v4"
+)
+
+type DryRunTaskReqV1 struct {
+	// the MySQL instance of the project to create the shadow schemas, it can't be the instance of the task
+	SandboxInstanceName string `json:"sandbox_instance_name" form:"sandbox_instance_name" valid:"required"`
+}
+
+type DryRunTaskResV1 struct {
+	controller.BaseRes
+	Data *DryRunTaskResultV1 `json:"data"`
+}
+
+type DryRunTaskResultV1 struct {
+	ClonedTables []string          `json:"cloned_tables"`
+	SQLs         []*DryRunSQLResV1 `json:"sqls"`
+}
+
+type DryRunSQLResV1 struct {
+	ExecSqlId  uint   `json:"exec_sql_id"`
+	Number     uint   `json:"number"`
+	ExecSQL    string `json:"exec_sql"`
+	Status     string `json:"status" enums:"succeeded,failed,skipped"`
+	ExecResult string `json:"exec_result"`
+}
+
+// DryRunTaskV1
+// @Summary 在沙箱实例上试执行工单任务
+// @Description clone the structures of the tables used by the SQLs of the MySQL workflow task into temporary shadow schemas on the sandbox instance, execute the SQLs there in order and report the result of each SQL, the shadow schemas are dropped afterwards
+// @Id dryRunTaskV1
+// @Tags workflow
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param project_name path string true "project name"
+// @Param workflow_id path string true "workflow id"
+// @Param task_id path string true "task id"
+// @Param dry_run body v1.DryRunTaskReqV1 true "dry run request"
+// @Success 200 {object} v1.DryRunTaskResV1
+// @router /v1/projects/{project_name}/workflows/{workflow_id}/tasks/{task_id}/dry_run [post]
+func DryRunTaskV1(c echo.Context) error {
+	req := new(DryRunTaskReqV1)
+	if err := controller.BindAndValidateReq(c, req); err != nil {
+		return err
+	}
+	projectUid, err := dms.GetPorjectUIDByName(context.TODO(), c.Param("project_name"))
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	s := model.GetStorage()
+	workflow, err := dms.GetWorkflowDetailByWorkflowId(projectUid, c.Param("workflow_id"), s.GetWorkflowDetailWithoutInstancesByWorkflowID)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	if err := CheckCurrentUserCanOperateWorkflow(c, projectUid, workflow, []dmsV1.OpPermissionType{}); err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	task, err := getWorkflowTaskDetail(c.Request().Context(), workflow, c.Param("task_id"))
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	sandbox, exist, err := dms.GetInstanceInProjectByName(c.Request().Context(), projectUid, req.SandboxInstanceName)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	if !exist {
+		return controller.JSONBaseErrorReq(c, errors.NewDataNotExistErr("sandbox instance %v not exist", req.SandboxInstanceName))
+	}
+
+	result, err := server.DryRunTask(log.NewEntry(), task, sandbox)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	data := &DryRunTaskResultV1{
+		ClonedTables: result.ClonedTables,
+		SQLs:         make([]*DryRunSQLResV1, 0, len(result.SQLs)),
+	}
+	for _, sql := range result.SQLs {
+		data.SQLs = append(data.SQLs, &DryRunSQLResV1{
+			ExecSqlId:  sql.ExecuteSQL.ID,
+			Number:     sql.ExecuteSQL.Number,
+			ExecSQL:    sql.ExecuteSQL.Content,
+			Status:     sql.Status,
+			ExecResult: sql.ExecResult,
+		})
+	}
+	return c.JSON(http.StatusOK, &DryRunTaskResV1{
+		BaseRes: controller.NewBaseReq(nil),
+		Data:    data,
+	})
+}
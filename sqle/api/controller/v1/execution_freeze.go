@@ -0,0 +1,290 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	dmsV1 "github.com/actiontech/dms/pkg/dms-common/api/dms/v1"
+	"github.com/actiontech/sqle/sqle/api/controller"
+	"github.com/actiontech/sqle/sqle/dms"
+	"github.com/actiontech/sqle/sqle/errors"
+	"github.com/actiontech/sqle/sqle/model"
+
+	"github.com/labstack/echo/v4"
+)
+
+type ExecutionFreezeCalendarReqV1 struct {
+	Name string `json:"name" valid:"required"`
+	Desc string `json:"desc"`
+	// the businesses of the instances which the freeze applies to, the freeze applies to all instances of the project if it is empty
+	InstanceGroups []string   `json:"instance_group_list"`
+	StartTime      *time.Time `json:"start_time"`
+	EndTime        *time.Time `json:"end_time"`
+	// the freeze starts by the cron and lasts the duration, the freeze is a one-off time range from start time to end time if the cron is empty
+	RecurrenceCron            string `json:"recurrence_cron" example:"0 0 25 12 *" valid:"omitempty,cron"`
+	RecurrenceDurationMinutes uint   `json:"recurrence_duration_minutes"`
+	Enabled                   bool   `json:"enabled"`
+}
+
+func (req *ExecutionFreezeCalendarReqV1) fillModel(calendar *model.ExecutionFreezeCalendar) {
+	calendar.Name = req.Name
+	calendar.Desc = req.Desc
+	calendar.InstanceGroups = req.InstanceGroups
+	calendar.StartTime = req.StartTime
+	calendar.EndTime = req.EndTime
+	calendar.RecurrenceCron = req.RecurrenceCron
+	calendar.RecurrenceDurationMinutes = req.RecurrenceDurationMinutes
+	calendar.Enabled = req.Enabled
+}
+
+// CreateExecutionFreezeCalendarV1
+// @Summary 创建上线冻结期
+// @Description create the execution freeze calendar of project, the workflows can't be executed in the freeze period
+// @Id createExecutionFreezeCalendarV1
+// @Tags workflow
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param project_name path string true "project name"
+// @Param calendar body v1.ExecutionFreezeCalendarReqV1 true "create execution freeze calendar request"
+// @Success 200 {object} controller.BaseRes
+// @router /v1/projects/{project_name}/execution_freeze_calendars [post]
+func CreateExecutionFreezeCalendarV1(c echo.Context) error {
+	req := new(ExecutionFreezeCalendarReqV1)
+	if err := controller.BindAndValidateReq(c, req); err != nil {
+		return err
+	}
+	projectUid, err := dms.GetPorjectUIDByName(context.TODO(), c.Param("project_name"))
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	calendar := &model.ExecutionFreezeCalendar{ProjectId: model.ProjectUID(projectUid)}
+	req.fillModel(calendar)
+	return controller.JSONBaseErrorReq(c, model.GetStorage().CreateExecutionFreezeCalendar(calendar))
+}
+
+// UpdateExecutionFreezeCalendarV1
+// @Summary 更新上线冻结期
+// @Description update the execution freeze calendar of project
+// @Id updateExecutionFreezeCalendarV1
+// @Tags workflow
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param project_name path string true "project name"
+// @Param execution_freeze_calendar_id path string true "execution freeze calendar id"
+// @Param calendar body v1.ExecutionFreezeCalendarReqV1 true "update execution freeze calendar request"
+// @Success 200 {object} controller.BaseRes
+// @router /v1/projects/{project_name}/execution_freeze_calendars/{execution_freeze_calendar_id}/ [put]
+func UpdateExecutionFreezeCalendarV1(c echo.Context) error {
+	req := new(ExecutionFreezeCalendarReqV1)
+	if err := controller.BindAndValidateReq(c, req); err != nil {
+		return err
+	}
+	calendar, err := getExecutionFreezeCalendar(c)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	req.fillModel(calendar)
+	return controller.JSONBaseErrorReq(c, model.GetStorage().UpdateExecutionFreezeCalendar(calendar))
+}
+
+// DeleteExecutionFreezeCalendarV1
+// @Summary 删除上线冻结期
+// @Description delete the execution freeze calendar of project
+// @Id deleteExecutionFreezeCalendarV1
+// @Tags workflow
+// @Security ApiKeyAuth
+// @Param project_name path string true "project name"
+// @Param execution_freeze_calendar_id path string true "execution freeze calendar id"
+// @Success 200 {object} controller.BaseRes
+// @router /v1/projects/{project_name}/execution_freeze_calendars/{execution_freeze_calendar_id}/ [delete]
+func DeleteExecutionFreezeCalendarV1(c echo.Context) error {
+	calendar, err := getExecutionFreezeCalendar(c)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	return controller.JSONBaseErrorReq(c, model.GetStorage().DeleteExecutionFreezeCalendar(calendar))
+}
+
+func getExecutionFreezeCalendar(c echo.Context) (*model.ExecutionFreezeCalendar, error) {
+	projectUid, err := dms.GetPorjectUIDByName(context.TODO(), c.Param("project_name"))
+	if err != nil {
+		return nil, err
+	}
+	id, err := strconv.ParseUint(c.Param("execution_freeze_calendar_id"), 10, 64)
+	if err != nil {
+		return nil, errors.New(errors.DataInvalid, fmt.Errorf("parse execution freeze calendar id failed: %v", err))
+	}
+	calendar, exist, err := model.GetStorage().GetExecutionFreezeCalendarById(model.ProjectUID(projectUid), uint(id))
+	if err != nil {
+		return nil, err
+	}
+	if !exist {
+		return nil, errors.NewDataNotExistErr("execution freeze calendar %v is not exist", id)
+	}
+	return calendar, nil
+}
+
+type GetExecutionFreezeCalendarsResV1 struct {
+	controller.BaseRes
+	Data []*ExecutionFreezeCalendarResV1 `json:"data"`
+}
+
+type ExecutionFreezeCalendarResV1 struct {
+	Id                        uint       `json:"execution_freeze_calendar_id"`
+	Name                      string     `json:"name"`
+	Desc                      string     `json:"desc"`
+	InstanceGroups            []string   `json:"instance_group_list"`
+	StartTime                 *time.Time `json:"start_time,omitempty"`
+	EndTime                   *time.Time `json:"end_time,omitempty"`
+	RecurrenceCron            string     `json:"recurrence_cron,omitempty"`
+	RecurrenceDurationMinutes uint       `json:"recurrence_duration_minutes,omitempty"`
+	Enabled                   bool       `json:"enabled"`
+	Frozen                    bool       `json:"frozen"`
+}
+
+// GetExecutionFreezeCalendarsV1
+// @Summary 获取上线冻结期列表
+// @Description get the execution freeze calendars of project
+// @Id getExecutionFreezeCalendarsV1
+// @Tags workflow
+// @Security ApiKeyAuth
+// @Param project_name path string true "project name"
+// @Success 200 {object} v1.GetExecutionFreezeCalendarsResV1
+// @router /v1/projects/{project_name}/execution_freeze_calendars [get]
+func GetExecutionFreezeCalendarsV1(c echo.Context) error {
+	projectUid, err := dms.GetPorjectUIDByName(context.TODO(), c.Param("project_name"))
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	calendars, err := model.GetStorage().GetExecutionFreezeCalendarsByProject(model.ProjectUID(projectUid))
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	now := time.Now()
+	data := make([]*ExecutionFreezeCalendarResV1, 0, len(calendars))
+	for _, calendar := range calendars {
+		data = append(data, &ExecutionFreezeCalendarResV1{
+			Id:                        calendar.ID,
+			Name:                      calendar.Name,
+			Desc:                      calendar.Desc,
+			InstanceGroups:            calendar.InstanceGroups,
+			StartTime:                 calendar.StartTime,
+			EndTime:                   calendar.EndTime,
+			RecurrenceCron:            calendar.RecurrenceCron,
+			RecurrenceDurationMinutes: calendar.RecurrenceDurationMinutes,
+			Enabled:                   calendar.Enabled,
+			Frozen:                    calendar.IsFrozen(now),
+		})
+	}
+	return c.JSON(http.StatusOK, &GetExecutionFreezeCalendarsResV1{
+		BaseRes: controller.NewBaseReq(nil),
+		Data:    data,
+	})
+}
+
+type ApplyWorkflowFreezeOverrideReqV1 struct {
+	Reason string `json:"reason" valid:"required"`
+}
+
+// ApplyWorkflowFreezeOverrideV1
+// @Summary 申请冻结期内紧急上线
+// @Description apply for the emergency override to execute the workflow in the freeze period, the override takes effect after approved by another project admin
+// @Id applyWorkflowFreezeOverrideV1
+// @Tags workflow
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param project_name path string true "project name"
+// @Param workflow_id path string true "workflow id"
+// @Param override body v1.ApplyWorkflowFreezeOverrideReqV1 true "apply workflow freeze override request"
+// @Success 200 {object} controller.BaseRes
+// @router /v1/projects/{project_name}/workflows/{workflow_id}/freeze_override [post]
+func ApplyWorkflowFreezeOverrideV1(c echo.Context) error {
+	req := new(ApplyWorkflowFreezeOverrideReqV1)
+	if err := controller.BindAndValidateReq(c, req); err != nil {
+		return err
+	}
+	projectUid, err := dms.GetPorjectUIDByName(context.TODO(), c.Param("project_name"))
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	s := model.GetStorage()
+	workflow, err := dms.GetWorkflowDetailByWorkflowId(projectUid, c.Param("workflow_id"), s.GetWorkflowDetailWithoutInstancesByWorkflowID)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	if err := CheckCurrentUserCanOperateWorkflow(c, projectUid, workflow, []dmsV1.OpPermissionType{}); err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	if workflow.Record.Status != model.WorkflowStatusWaitForExecution {
+		return controller.JSONBaseErrorReq(c, errors.NewDataInvalidErr("the workflow is not waiting for execution"))
+	}
+
+	override, exist, err := s.GetWorkflowFreezeOverride(workflow.WorkflowId, workflow.Record.ID)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	if exist && override.IsApproved() {
+		return controller.JSONBaseErrorReq(c, errors.New(errors.DataConflict, fmt.Errorf("the freeze override of workflow has been approved")))
+	}
+	if !exist {
+		override = &model.WorkflowFreezeOverride{
+			ProjectId:        model.ProjectUID(projectUid),
+			WorkflowId:       workflow.WorkflowId,
+			WorkflowRecordId: workflow.Record.ID,
+		}
+	}
+	override.Reason = req.Reason
+	override.ApplyUserId = controller.GetUserID(c)
+	return controller.JSONBaseErrorReq(c, s.SaveWorkflowFreezeOverride(override))
+}
+
+// ApproveWorkflowFreezeOverrideV1
+// @Summary 审批冻结期内紧急上线
+// @Description approve the emergency override to execute the workflow in the freeze period, the applicant can't approve the override
+// @Id approveWorkflowFreezeOverrideV1
+// @Tags workflow
+// @Security ApiKeyAuth
+// @Param project_name path string true "project name"
+// @Param workflow_id path string true "workflow id"
+// @Success 200 {object} controller.BaseRes
+// @router /v1/projects/{project_name}/workflows/{workflow_id}/freeze_override/approve [post]
+func ApproveWorkflowFreezeOverrideV1(c echo.Context) error {
+	projectUid, err := dms.GetPorjectUIDByName(context.TODO(), c.Param("project_name"))
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	s := model.GetStorage()
+	workflow, err := dms.GetWorkflowDetailByWorkflowId(projectUid, c.Param("workflow_id"), s.GetWorkflowDetailWithoutInstancesByWorkflowID)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	override, exist, err := s.GetWorkflowFreezeOverride(workflow.WorkflowId, workflow.Record.ID)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	if !exist {
+		return controller.JSONBaseErrorReq(c, errors.NewDataNotExistErr("the freeze override of workflow is not applied"))
+	}
+	if override.IsApproved() {
+		return controller.JSONBaseErrorReq(c, errors.New(errors.DataConflict, fmt.Errorf("the freeze override of workflow has been approved")))
+	}
+	userId := controller.GetUserID(c)
+	if override.ApplyUserId == userId {
+		return controller.JSONBaseErrorReq(c, errors.NewDataInvalidErr("the freeze override should be approved by another user"))
+	}
+
+	now := time.Now()
+	override.ApproveUserId = userId
+	override.ApprovedAt = &now
+	if err := s.SaveWorkflowFreezeOverride(override); err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	// the scheduled execution blocked by the freeze is retried immediately
+	return controller.JSONBaseErrorReq(c, s.UpdateWorkflowScheduleBlocked(override.WorkflowRecordId, "", nil))
+}
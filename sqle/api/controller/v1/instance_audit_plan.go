@@ -172,6 +172,9 @@ func CreateInstanceAuditPlan(c echo.Context) error {
 		if err != nil {
 			return controller.JSONBaseErrorReq(c, errors.New(errors.DataConflict, err))
 		}
+		if err := auditplan.ValidateParams(auditPlan.Type, inst.DbType, ps); err != nil {
+			return controller.JSONBaseErrorReq(c, errors.New(errors.DataInvalid, err))
+		}
 
 		hpc, err := checkAndGenerateHighPriorityParams(auditPlan.Type, inst.DbType, auditPlan.HighPriorityConditions)
 		if err != nil {
@@ -341,6 +344,9 @@ func UpdateInstanceAuditPlan(c echo.Context) error {
 				return controller.JSONBaseErrorReq(c, errors.New(errors.DataConflict, err))
 			}
 		}
+		if err := auditplan.ValidateParams(auditPlanReq.Type, inst.DbType, ps); err != nil {
+			return controller.JSONBaseErrorReq(c, errors.New(errors.DataInvalid, err))
+		}
 
 		hpc, err := checkAndGenerateHighPriorityParams(auditPlanReq.Type, inst.DbType, auditPlanReq.HighPriorityConditions)
 		if err != nil {
@@ -760,6 +766,8 @@ type InstanceAuditPlanInfo struct {
 	LastCollectionTime   *time.Time             `json:"last_collection_time"`
 	ActiveStatus         string                 `json:"active_status" enums:"normal,disabled"`
 	LastCollectionStatus string                 `json:"last_collection_status" enums:"normal,abnormal"`
+	// the audit plan is disabled automatically when the consecutive collection failures reach the limit
+	ConsecutiveCollectionFailures uint `json:"consecutive_collection_failures"`
 }
 
 // @Summary 获取实例扫描任务概览
@@ -827,6 +835,7 @@ func GetInstanceAuditPlanOverview(c echo.Context) error {
 		}
 		if v.AuditPlanTaskInfo != nil {
 			resAuditPlan.LastCollectionTime = v.AuditPlanTaskInfo.LastCollectionTime
+			resAuditPlan.ConsecutiveCollectionFailures = v.AuditPlanTaskInfo.ConsecutiveCollectionFailures
 		}
 		resAuditPlans = append(resAuditPlans, resAuditPlan)
 	}
@@ -979,9 +988,10 @@ func UpdateAuditPlanStatus(c echo.Context) error {
 		return controller.JSONBaseErrorReq(c, errors.NewAuditPlanNotExistErr())
 	}
 	auditPlan.ActiveStatus = req.Active
-	// 重启扫描任务时，重置最后采集状态
+	// 重启扫描任务时，重置最后采集状态和连续采集失败次数
 	if req.Active == model.ActiveStatusNormal {
 		auditPlan.AuditPlanTaskInfo.LastCollectionStatus = ""
+		auditPlan.AuditPlanTaskInfo.ConsecutiveCollectionFailures = 0
 		err = s.Save(auditPlan.AuditPlanTaskInfo)
 		if err != nil {
 			return controller.JSONBaseErrorReq(c, err)
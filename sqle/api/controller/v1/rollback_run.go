@@ -0,0 +1,181 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	dmsV1 "github.com/actiontech/dms/pkg/dms-common/api/dms/v1"
+	"github.com/actiontech/sqle/sqle/api/controller"
+	"github.com/actiontech/sqle/sqle/dms"
+	"github.com/actiontech/sqle/sqle/errors"
+	"github.com/actiontech/sqle/sqle/model"
+	"github.com/actiontech/sqle/sqle/server"
+
+	"github.com/labstack/echo/v4"
+)
+
+// getWorkflowTaskDetail returns the task of the workflow with its SQLs and instance.
+func getWorkflowTaskDetail(ctx context.Context, workflow *model.Workflow, taskId string) (*model.Task, error) {
+	inWorkflow := false
+	for _, ir := range workflow.Record.InstanceRecords {
+		if fmt.Sprintf("%d", ir.TaskId) == taskId {
+			inWorkflow = true
+		}
+	}
+	if !inWorkflow {
+		return nil, errors.NewTaskNoExistOrNoAccessErr()
+	}
+	task, exist, err := model.GetStorage().GetTaskDetailById(taskId)
+	if err != nil {
+		return nil, err
+	}
+	if !exist {
+		return nil, errors.NewTaskNoExistOrNoAccessErr()
+	}
+	instance, exist, err := dms.GetInstancesById(ctx, fmt.Sprintf("%d", task.InstanceId))
+	if err != nil {
+		return nil, err
+	}
+	if !exist {
+		return nil, errors.NewDataNotExistErr("instance not exist")
+	}
+	task.Instance = instance
+	return task, nil
+}
+
+type CreateRollbackRunReqV1 struct {
+	// the rollback SQLs to roll back, all the rollback SQLs of the task are rolled back if it is empty
+	RollbackSQLIds []uint `json:"rollback_sql_ids"`
+}
+
+// CreateRollbackRunV1
+// @Summary 执行工单任务的回滚SQL
+// @Description roll back the executed task by the selected rollback SQLs in the reverse order of execution, the rollback SQLs are executed like the task: the adjacent DMLs in one transaction, the SQLs of a batch together in sql file mode, and stop at the first failure
+// @Id createRollbackRunV1
+// @Tags workflow
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param project_name path string true "project name"
+// @Param workflow_id path string true "workflow id"
+// @Param task_id path string true "task id"
+// @Param rollback_run body v1.CreateRollbackRunReqV1 true "create rollback run request"
+// @Success 200 {object} controller.BaseRes
+// @router /v1/projects/{project_name}/workflows/{workflow_id}/tasks/{task_id}/rollback_runs [post]
+func CreateRollbackRunV1(c echo.Context) error {
+	req := new(CreateRollbackRunReqV1)
+	if err := controller.BindAndValidateReq(c, req); err != nil {
+		return err
+	}
+	projectUid, err := dms.GetPorjectUIDByName(context.TODO(), c.Param("project_name"))
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	s := model.GetStorage()
+	workflow, err := dms.GetWorkflowDetailByWorkflowId(projectUid, c.Param("workflow_id"), s.GetWorkflowDetailWithoutInstancesByWorkflowID)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	if err := CheckCurrentUserCanOperateWorkflow(c, projectUid, workflow, []dmsV1.OpPermissionType{}); err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	task, err := getWorkflowTaskDetail(c.Request().Context(), workflow, c.Param("task_id"))
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	err = server.GetSqled().AddRollbackTask(projectUid, task.GetIDStr(), req.RollbackSQLIds, controller.GetUserID(c))
+	return controller.JSONBaseErrorReq(c, err)
+}
+
+type GetRollbackRunsResV1 struct {
+	controller.BaseRes
+	Data []*RollbackRunResV1 `json:"data"`
+}
+
+type RollbackRunResV1 struct {
+	Id           uint                   `json:"id"`
+	CreateUserId string                 `json:"create_user_id"`
+	ExecMode     string                 `json:"exec_mode"`
+	Status       string                 `json:"status" enums:"running,succeeded,failed"`
+	ExecResult   string                 `json:"exec_result"`
+	StartAt      *time.Time             `json:"start_at"`
+	EndAt        *time.Time             `json:"end_at"`
+	SQLs         []*RollbackRunSQLResV1 `json:"sqls"`
+}
+
+type RollbackRunSQLResV1 struct {
+	Number        uint   `json:"number"`
+	RollbackSQLId uint   `json:"rollback_sql_id"`
+	SQL           string `json:"sql"`
+	SQLType       string `json:"sql_type"`
+	ExecBatchId   uint64 `json:"exec_batch_id"`
+	ExecStatus    string `json:"exec_status" enums:"initialized,succeeded,failed"`
+	ExecResult    string `json:"exec_result"`
+	RowAffects    int64  `json:"row_affects"`
+}
+
+// GetRollbackRunsV1
+// @Summary 获取工单任务的回滚执行记录
+// @Description get the rollback runs of the workflow task with the result of each SQL, the SQLs after the failed one are not executed
+// @Id getRollbackRunsV1
+// @Tags workflow
+// @Security ApiKeyAuth
+// @Param project_name path string true "project name"
+// @Param workflow_id path string true "workflow id"
+// @Param task_id path string true "task id"
+// @Success 200 {object} v1.GetRollbackRunsResV1
+// @router /v1/projects/{project_name}/workflows/{workflow_id}/tasks/{task_id}/rollback_runs [get]
+func GetRollbackRunsV1(c echo.Context) error {
+	projectUid, err := dms.GetPorjectUIDByName(context.TODO(), c.Param("project_name"))
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	s := model.GetStorage()
+	workflow, err := dms.GetWorkflowDetailByWorkflowId(projectUid, c.Param("workflow_id"), s.GetWorkflowDetailWithoutInstancesByWorkflowID)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	if err := CheckCurrentUserCanViewWorkflow(c, projectUid, workflow, []dmsV1.OpPermissionType{dmsV1.OpPermissionTypeViewOthersWorkflow}); err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	task, err := getWorkflowTaskDetail(c.Request().Context(), workflow, c.Param("task_id"))
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	runs, err := s.GetRollbackRunsByTaskId(task.ID)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	data := make([]*RollbackRunResV1, 0, len(runs))
+	for _, run := range runs {
+		res := &RollbackRunResV1{
+			Id:           run.ID,
+			CreateUserId: run.CreateUserId,
+			ExecMode:     run.ExecMode,
+			Status:       run.Status,
+			ExecResult:   run.ExecResult,
+			StartAt:      run.StartAt,
+			EndAt:        run.EndAt,
+			SQLs:         make([]*RollbackRunSQLResV1, 0, len(run.SQLs)),
+		}
+		for _, sql := range run.SQLs {
+			res.SQLs = append(res.SQLs, &RollbackRunSQLResV1{
+				Number:        sql.Number,
+				RollbackSQLId: sql.RollbackSQLId,
+				SQL:           sql.Content,
+				SQLType:       sql.SQLType,
+				ExecBatchId:   sql.ExecBatchId,
+				ExecStatus:    sql.ExecStatus,
+				ExecResult:    sql.ExecResult,
+				RowAffects:    sql.RowAffects,
+			})
+		}
+		data = append(data, res)
+	}
+	return c.JSON(http.StatusOK, &GetRollbackRunsResV1{
+		BaseRes: controller.NewBaseReq(nil),
+		Data:    data,
+	})
+}
@@ -0,0 +1,218 @@
+package v1
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "github.com/actiontech/dms/pkg/dms-common/api/dms/v1"
+	"github.com/actiontech/sqle/sqle/api/controller"
+	dms "github.com/actiontech/sqle/sqle/dms"
+	"github.com/actiontech/sqle/sqle/errors"
+	"github.com/actiontech/sqle/sqle/model"
+	"github.com/actiontech/sqle/sqle/server/auditplan"
+
+	"github.com/labstack/echo/v4"
+)
+
+// getViewableAuditPlanId returns the id of the audit plan in the path if the current user can view it.
+func getViewableAuditPlanId(c echo.Context) (uint, error) {
+	instanceAuditPlanID := c.Param("instance_audit_plan_id")
+	projectUID, err := dms.GetPorjectUIDByName(c.Request().Context(), c.Param("project_name"), true)
+	if err != nil {
+		return 0, err
+	}
+	_, exist, err := GetInstanceAuditPlanIfCurrentUserCanView(c, projectUID, instanceAuditPlanID, v1.OpPermissionTypeViewOtherAuditPlan)
+	if err != nil {
+		return 0, err
+	}
+	if !exist {
+		return 0, errors.NewInstanceAuditPlanNotExistErr()
+	}
+	auditPlanId, err := strconv.Atoi(c.Param("audit_plan_id"))
+	if err != nil {
+		return 0, errors.NewAuditPlanNotExistErr()
+	}
+	apDetail, exist, err := model.GetStorage().GetAuditPlanDetailByIDExist(uint(auditPlanId))
+	if err != nil {
+		return 0, err
+	}
+	if !exist || strconv.FormatUint(uint64(apDetail.InstanceAuditPlanID), 10) != instanceAuditPlanID {
+		return 0, errors.NewAuditPlanNotExistErr()
+	}
+	return apDetail.ID, nil
+}
+
+type GetSchemaMetaDriftsReqV1 struct {
+	FilterUnapproved bool   `json:"filter_unapproved" query:"filter_unapproved"`
+	PageIndex        uint32 `json:"page_index" query:"page_index" valid:"required"`
+	PageSize         uint32 `json:"page_size" query:"page_size" valid:"required"`
+}
+
+type SchemaMetaChange struct {
+	// column, index, constraint, table_options or definition
+	Object string `json:"object"`
+	Name   string `json:"name"`
+	// added, dropped or modified
+	Action string `json:"action"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+type SchemaMetaDrift struct {
+	Id         uint   `json:"id"`
+	SchemaName string `json:"schema_name"`
+	MetaType   string `json:"meta_type"`
+	MetaName   string `json:"meta_name"`
+	// created, altered or dropped
+	ChangeType  string              `json:"change_type"`
+	FromVersion uint                `json:"from_version"`
+	ToVersion   uint                `json:"to_version"`
+	Changes     []*SchemaMetaChange `json:"changes"`
+	// approved means the object is changed by the SQLs executed through the workflows
+	Approved bool `json:"approved"`
+	// partially approved means only some of the changes are made by the SQLs executed through the workflows
+	PartiallyApproved bool      `json:"partially_approved"`
+	MatchedTaskIds    []string  `json:"matched_task_ids"`
+	DetectedAt        time.Time `json:"detected_at"`
+}
+
+type GetSchemaMetaDriftsResV1 struct {
+	controller.BaseRes
+	Data      []*SchemaMetaDrift `json:"data"`
+	TotalNums uint64             `json:"total_nums"`
+}
+
+// GetSchemaMetaDriftsV1
+// @Summary 获取库表结构扫描任务发现的结构变更
+// @Description get the schema changes found by the schema meta audit plan between collections, the changes not made through any workflow are unapproved
+// @Id getSchemaMetaDriftsV1
+// @Tags instance_audit_plan
+// @Security ApiKeyAuth
+// @Param project_name path string true "project name"
+// @Param instance_audit_plan_id path string true "instance audit plan id"
+// @Param audit_plan_id path string true "audit plan id"
+// @Param filter_unapproved query bool false "only the unapproved changes"
+// @Param page_index query uint32 true "page index"
+// @Param page_size query uint32 true "size of per page"
+// @Success 200 {object} v1.GetSchemaMetaDriftsResV1
+// @router /v1/projects/{project_name}/instance_audit_plans/{instance_audit_plan_id}/audit_plans/{audit_plan_id}/schema_drifts [get]
+func GetSchemaMetaDriftsV1(c echo.Context) error {
+	req := new(GetSchemaMetaDriftsReqV1)
+	if err := controller.BindAndValidateReq(c, req); err != nil {
+		return err
+	}
+	auditPlanId, err := getViewableAuditPlanId(c)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	limit, offset := controller.GetLimitAndOffset(req.PageIndex, req.PageSize)
+	drifts, count, err := model.GetStorage().GetSchemaMetaDrifts(auditPlanId, req.FilterUnapproved, int(limit), int(offset))
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	data := make([]*SchemaMetaDrift, 0, len(drifts))
+	for _, drift := range drifts {
+		changes := []*auditplan.SchemaMetaChange{}
+		if len(drift.Diff) > 0 {
+			if err := json.Unmarshal(drift.Diff, &changes); err != nil {
+				return controller.JSONBaseErrorReq(c, err)
+			}
+		}
+		item := &SchemaMetaDrift{
+			Id:                drift.ID,
+			SchemaName:        drift.SchemaName,
+			MetaType:          drift.MetaType,
+			MetaName:          drift.MetaName,
+			ChangeType:        drift.ChangeType,
+			FromVersion:       drift.FromVersion,
+			ToVersion:         drift.ToVersion,
+			Changes:           make([]*SchemaMetaChange, 0, len(changes)),
+			Approved:          drift.Approved,
+			PartiallyApproved: drift.PartiallyApproved,
+			MatchedTaskIds:    []string{},
+			DetectedAt:        drift.DetectedAt,
+		}
+		for _, change := range changes {
+			item.Changes = append(item.Changes, &SchemaMetaChange{
+				Object: change.Object,
+				Name:   change.Name,
+				Action: change.Action,
+				Before: change.Before,
+				After:  change.After,
+			})
+		}
+		if drift.MatchedTaskIds != "" {
+			item.MatchedTaskIds = strings.Split(drift.MatchedTaskIds, ",")
+		}
+		data = append(data, item)
+	}
+	return c.JSON(http.StatusOK, &GetSchemaMetaDriftsResV1{
+		BaseRes:   controller.NewBaseReq(nil),
+		Data:      data,
+		TotalNums: count,
+	})
+}
+
+type GetSchemaMetaSnapshotsReqV1 struct {
+	SchemaName string `json:"schema_name" query:"schema_name" valid:"required"`
+	MetaType   string `json:"meta_type" query:"meta_type" valid:"required,oneof=table view"`
+	MetaName   string `json:"meta_name" query:"meta_name" valid:"required"`
+}
+
+type SchemaMetaSnapshot struct {
+	Version uint   `json:"version"`
+	Content string `json:"content"`
+	// dropped means the object is dropped in this version
+	Dropped     bool      `json:"dropped"`
+	CollectedAt time.Time `json:"collected_at"`
+}
+
+type GetSchemaMetaSnapshotsResV1 struct {
+	controller.BaseRes
+	Data []*SchemaMetaSnapshot `json:"data"`
+}
+
+// GetSchemaMetaSnapshotsV1
+// @Summary 获取库表结构扫描任务采集的对象定义历史版本
+// @Description get the versions of the object definition collected by the schema meta audit plan, the latest version is the first
+// @Id getSchemaMetaSnapshotsV1
+// @Tags instance_audit_plan
+// @Security ApiKeyAuth
+// @Param project_name path string true "project name"
+// @Param instance_audit_plan_id path string true "instance audit plan id"
+// @Param audit_plan_id path string true "audit plan id"
+// @Param schema_name query string true "schema name"
+// @Param meta_type query string true "meta type" Enums(table,view)
+// @Param meta_name query string true "meta name"
+// @Success 200 {object} v1.GetSchemaMetaSnapshotsResV1
+// @router /v1/projects/{project_name}/instance_audit_plans/{instance_audit_plan_id}/audit_plans/{audit_plan_id}/schema_snapshots [get]
+func GetSchemaMetaSnapshotsV1(c echo.Context) error {
+	req := new(GetSchemaMetaSnapshotsReqV1)
+	if err := controller.BindAndValidateReq(c, req); err != nil {
+		return err
+	}
+	auditPlanId, err := getViewableAuditPlanId(c)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	snapshots, err := model.GetStorage().GetSchemaMetaSnapshots(auditPlanId, req.SchemaName, req.MetaType, req.MetaName)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	data := make([]*SchemaMetaSnapshot, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		data = append(data, &SchemaMetaSnapshot{
+			Version:     snapshot.Version,
+			Content:     snapshot.Content,
+			Dropped:     snapshot.Dropped,
+			CollectedAt: snapshot.CreatedAt,
+		})
+	}
+	return c.JSON(http.StatusOK, &GetSchemaMetaSnapshotsResV1{
+		BaseRes: controller.NewBaseReq(nil),
+		Data:    data,
+	})
+}
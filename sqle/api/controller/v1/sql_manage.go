@@ -21,7 +21,7 @@ type GetSqlManageListReq struct {
 	FilterDbType                 *string `query:"filter_db_type" json:"filter_db_type,omitempty"`
 	FilterRuleName               *string `query:"filter_rule_name" json:"filter_rule_name,omitempty"`
 	FilterBusiness               *string `query:"filter_business" json:"filter_business,omitempty"`
-	FilterPriority               *string `query:"filter_priority" json:"filter_priority,omitempty" enums:"high,low"`
+	FilterPriority               *string `query:"filter_priority" json:"filter_priority,omitempty" enums:"high,low,P0,P1,P2,P3"`
 	FuzzySearchEndpoint          *string `query:"fuzzy_search_endpoint" json:"fuzzy_search_endpoint,omitempty"`
 	FuzzySearchSchemaName        *string `query:"fuzzy_search_schema_name" json:"fuzzy_search_schema_name,omitempty"`
 	SortField                    *string `query:"sort_field" json:"sort_field,omitempty" valid:"omitempty,oneof=first_appear_timestamp last_receive_timestamp fp_count" enums:"first_appear_timestamp,last_receive_timestamp,fp_count"`
@@ -104,7 +104,7 @@ func GetSqlManageList(c echo.Context) error {
 type BatchUpdateSqlManageReq struct {
 	SqlManageIdList []*uint64 `json:"sql_manage_id_list"`
 	Status          *string   `json:"status" enums:"solved,ignored,manual_audited"`
-	Priority        *string   `json:"priority" enums:",high"`
+	Priority        *string   `json:"priority" enums:",high,P0,P1,P2,P3"`
 	Assignees       []string  `json:"assignees"`
 	Remark          *string   `json:"remark"`
 }
@@ -178,7 +178,7 @@ type ExportSqlManagesReq struct {
 	FilterStatus                 *string `query:"filter_status" json:"filter_status,omitempty"`
 	FilterDbType                 *string `query:"filter_db_type" json:"filter_db_type,omitempty"`
 	FilterRuleName               *string `query:"filter_rule_name" json:"filter_rule_name,omitempty"`
-	FilterPriority               *string `query:"filter_priority" json:"filter_priority,omitempty" enums:"high,low"`
+	FilterPriority               *string `query:"filter_priority" json:"filter_priority,omitempty" enums:"high,low,P0,P1,P2,P3"`
 	FuzzySearchEndpoint          *string `query:"fuzzy_search_endpoint" json:"fuzzy_search_endpoint,omitempty"`
 	FuzzySearchSchemaName        *string `query:"fuzzy_search_schema_name" json:"fuzzy_search_schema_name,omitempty"`
 	SortField                    *string `query:"sort_field" json:"sort_field,omitempty" valid:"omitempty,oneof=first_appear_timestamp last_receive_timestamp fp_count" enums:"first_appear_timestamp,last_receive_timestamp,fp_count"`
@@ -0,0 +1,234 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/actiontech/sqle/sqle/api/controller"
+	"github.com/actiontech/sqle/sqle/dms"
+	"github.com/actiontech/sqle/sqle/locale"
+	"github.com/actiontech/sqle/sqle/model"
+	"github.com/actiontech/sqle/sqle/server/auditplan"
+	"github.com/actiontech/sqle/sqle/utils"
+
+	"github.com/labstack/echo/v4"
+)
+
+type SqlManageApplicationMapping struct {
+	// the client endpoint of the SQLs, which is an IP, a CIDR or a host name matched fuzzily
+	Endpoint    string `json:"endpoint" valid:"required"`
+	Application string `json:"application" valid:"required"`
+	Desc        string `json:"desc"`
+}
+
+type GetSqlManageApplicationMappingsResV1 struct {
+	controller.BaseRes
+	Data []*SqlManageApplicationMapping `json:"data"`
+}
+
+// GetSqlManageApplicationMappingsV1
+// @Summary 获取SQL管控应用映射
+// @Description get the mappings from the client endpoints to the applications of the managed SQLs in project
+// @Id getSqlManageApplicationMappingsV1
+// @Tags SqlManage
+// @Security ApiKeyAuth
+// @Param project_name path string true "project name"
+// @Success 200 {object} v1.GetSqlManageApplicationMappingsResV1
+// @router /v1/projects/{project_name}/sql_manage_application_mappings [get]
+func GetSqlManageApplicationMappingsV1(c echo.Context) error {
+	projectUid, err := dms.GetPorjectUIDByName(context.TODO(), c.Param("project_name"))
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	mappings, err := model.GetStorage().GetSQLManageApplicationMappings(projectUid)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	data := make([]*SqlManageApplicationMapping, 0, len(mappings))
+	for _, mapping := range mappings {
+		data = append(data, &SqlManageApplicationMapping{
+			Endpoint:    mapping.Endpoint,
+			Application: mapping.Application,
+			Desc:        mapping.Desc,
+		})
+	}
+	return c.JSON(http.StatusOK, &GetSqlManageApplicationMappingsResV1{
+		BaseRes: controller.NewBaseReq(nil),
+		Data:    data,
+	})
+}
+
+type UpdateSqlManageApplicationMappingsReqV1 struct {
+	Mappings []*SqlManageApplicationMapping `json:"mappings" valid:"dive"`
+}
+
+// UpdateSqlManageApplicationMappingsV1
+// @Summary 更新SQL管控应用映射
+// @Description replace the mappings from the client endpoints to the applications of the managed SQLs in project. The application tagged in the SQL comments (e.g. sqlcommenter /*application='x',route='y'*/) is preferred, otherwise the application of the first mapping matched by the endpoints of the SQL is used
+// @Id updateSqlManageApplicationMappingsV1
+// @Tags SqlManage
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param project_name path string true "project name"
+// @Param mappings body v1.UpdateSqlManageApplicationMappingsReqV1 true "update application mappings request"
+// @Success 200 {object} controller.BaseRes
+// @router /v1/projects/{project_name}/sql_manage_application_mappings [put]
+func UpdateSqlManageApplicationMappingsV1(c echo.Context) error {
+	req := new(UpdateSqlManageApplicationMappingsReqV1)
+	if err := controller.BindAndValidateReq(c, req); err != nil {
+		return err
+	}
+	projectUid, err := dms.GetPorjectUIDByName(context.TODO(), c.Param("project_name"))
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	mappings := make([]*model.SQLManageApplicationMapping, 0, len(req.Mappings))
+	for i, mapping := range req.Mappings {
+		mappings = append(mappings, &model.SQLManageApplicationMapping{
+			ProjectId:   projectUid,
+			Sort:        uint(i),
+			Endpoint:    mapping.Endpoint,
+			Application: mapping.Application,
+			Desc:        mapping.Desc,
+		})
+	}
+	if err := model.GetStorage().ReplaceSQLManageApplicationMappings(projectUid, mappings); err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	return c.JSON(http.StatusOK, controller.NewBaseReq(nil))
+}
+
+type SqlManageApplicationStatistic struct {
+	// empty means the SQLs not attributed to any application
+	Application    string `json:"application"`
+	SQLCount       uint64 `json:"sql_count"`
+	UnhandledCount uint64 `json:"unhandled_count"`
+	ErrorCount     uint64 `json:"error_count"`
+	WarnCount      uint64 `json:"warn_count"`
+}
+
+type GetSqlManageApplicationsResV1 struct {
+	controller.BaseRes
+	Data []*SqlManageApplicationStatistic `json:"data"`
+}
+
+// GetSqlManageApplicationsV1
+// @Summary 按应用分组获取SQL管控统计
+// @Description get the statistics of the managed SQLs grouped by the application in project
+// @Id getSqlManageApplicationsV1
+// @Tags SqlManage
+// @Security ApiKeyAuth
+// @Param project_name path string true "project name"
+// @Success 200 {object} v1.GetSqlManageApplicationsResV1
+// @router /v1/projects/{project_name}/sql_manage_applications [get]
+func GetSqlManageApplicationsV1(c echo.Context) error {
+	projectUid, err := dms.GetPorjectUIDByName(context.TODO(), c.Param("project_name"))
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	statistics, err := model.GetStorage().GetSQLManageApplicationStatistics(projectUid)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	data := make([]*SqlManageApplicationStatistic, 0, len(statistics))
+	for _, statistic := range statistics {
+		data = append(data, &SqlManageApplicationStatistic{
+			Application:    statistic.Application,
+			SQLCount:       statistic.SQLCount,
+			UnhandledCount: statistic.UnhandledCount,
+			ErrorCount:     statistic.ErrorCount,
+			WarnCount:      statistic.WarnCount,
+		})
+	}
+	return c.JSON(http.StatusOK, &GetSqlManageApplicationsResV1{
+		BaseRes: controller.NewBaseReq(nil),
+		Data:    data,
+	})
+}
+
+type ExportSqlManageApplicationReqV1 struct {
+	// empty means the SQLs not attributed to any application
+	Application  string `json:"application" query:"application"`
+	FilterStatus string `json:"filter_status" query:"filter_status" valid:"omitempty,oneof=unhandled solved ignored manual_audited sent"`
+}
+
+// ExportSqlManageApplicationV1
+// @Summary 按应用导出SQL管控
+// @Description export the managed SQLs of the application in project as CSV
+// @Id exportSqlManageApplicationV1
+// @Tags SqlManage
+// @Security ApiKeyAuth
+// @Param project_name path string true "project name"
+// @Param application query string false "application, empty means the SQLs not attributed to any application"
+// @Param filter_status query string false "status" Enums(unhandled,solved,ignored,manual_audited,sent)
+// @Success 200 {file} file "export sql manage of application"
+// @router /v1/projects/{project_name}/sql_manage_applications/exports [get]
+func ExportSqlManageApplicationV1(c echo.Context) error {
+	req := new(ExportSqlManageApplicationReqV1)
+	if err := controller.BindAndValidateReq(c, req); err != nil {
+		return err
+	}
+	projectUid, err := dms.GetPorjectUIDByName(context.TODO(), c.Param("project_name"))
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	records, err := model.GetStorage().GetSQLManageRecordsByApplication(projectUid, req.Application, req.FilterStatus)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+
+	ctx := c.Request().Context()
+	csvBuilder := utils.NewCSVBuilder()
+	err = csvBuilder.WriteHeader([]string{
+		locale.Bundle.LocalizeMsgByCtx(ctx, locale.SMExportApplication),
+		locale.Bundle.LocalizeMsgByCtx(ctx, locale.SMExportRoute),
+		locale.Bundle.LocalizeMsgByCtx(ctx, locale.SMExportSQLFingerprint),
+		locale.Bundle.LocalizeMsgByCtx(ctx, locale.SMExportSQL),
+		locale.Bundle.LocalizeMsgByCtx(ctx, locale.SMExportSource),
+		locale.Bundle.LocalizeMsgByCtx(ctx, locale.SMExportSCHEMA),
+		locale.Bundle.LocalizeMsgByCtx(ctx, locale.SMExportAuditResult),
+		locale.Bundle.LocalizeMsgByCtx(ctx, locale.SMExportEndpoint),
+		locale.Bundle.LocalizeMsgByCtx(ctx, locale.SMExportPersonInCharge),
+		locale.Bundle.LocalizeMsgByCtx(ctx, locale.SMExportState),
+		locale.Bundle.LocalizeMsgByCtx(ctx, locale.SMExportRemarks),
+	})
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	for _, record := range records {
+		info, err := record.Info.OriginValue()
+		if err != nil {
+			return controller.JSONBaseErrorReq(c, err)
+		}
+		metrics := auditplan.LoadMetrics(info, []string{auditplan.MetricNameApplication, auditplan.MetricNameRoute, auditplan.MetricNameEndpoints})
+		auditResult := ""
+		if record.AuditResults != nil {
+			auditResult = record.AuditResults.String(ctx)
+		}
+		err = csvBuilder.WriteRow([]string{
+			metrics.Get(auditplan.MetricNameApplication).String(),
+			metrics.Get(auditplan.MetricNameRoute).String(),
+			record.SqlFingerprint,
+			record.SqlText,
+			record.Source,
+			record.SchemaName,
+			auditResult,
+			strings.Join(metrics.Get(auditplan.MetricNameEndpoints).StringArray(), ","),
+			record.SQLManager.Assignees,
+			string(record.SQLManager.Status),
+			record.SQLManager.Remark,
+		})
+		if err != nil {
+			return controller.JSONBaseErrorReq(c, err)
+		}
+	}
+
+	fileName := fmt.Sprintf("sql_manage_application_%s.csv", time.Now().Format("20060102150405"))
+	c.Response().Header().Set(echo.HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": fileName}))
+	return c.Blob(http.StatusOK, "text/csv", csvBuilder.FlushAndGetBuffer().Bytes())
+}
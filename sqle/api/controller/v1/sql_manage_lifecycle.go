@@ -0,0 +1,215 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/actiontech/sqle/sqle/api/controller"
+	"github.com/actiontech/sqle/sqle/dms"
+	"github.com/actiontech/sqle/sqle/errors"
+	"github.com/actiontech/sqle/sqle/model"
+
+	"github.com/labstack/echo/v4"
+)
+
+type SqlManageAssignmentRule struct {
+	MatchType string `json:"match_type" enums:"schema,table,endpoint" valid:"required,oneof=schema table endpoint"`
+	// the pattern matched case-insensitively with the schema, the table (table or schema.table) or the endpoint of the SQL, * and ? wildcards are supported
+	Pattern   string   `json:"pattern" valid:"required"`
+	Assignees []string `json:"assignees" valid:"required,min=1"`
+	Desc      string   `json:"desc"`
+}
+
+type SqlManageLifecycle struct {
+	// the SQLs not collected for the days are marked as solved, 0 means never
+	AutoSolveUnseenDays uint `json:"auto_solve_unseen_days"`
+	// reopen the solved or ignored SQLs collected again with worse metrics
+	ReopenEnabled bool `json:"reopen_enabled"`
+	// the metrics are worse if they exceed the factor times of the metrics before the SQL was closed, default 1
+	ReopenMetricFactor float64 `json:"reopen_metric_factor" valid:"omitempty,gte=0"`
+	// the unassigned SQLs are assigned to the assignees of the first rule matched in order
+	AssignmentRules []*SqlManageAssignmentRule `json:"assignment_rules" valid:"dive"`
+}
+
+type GetSqlManageLifecycleResV1 struct {
+	controller.BaseRes
+	Data *SqlManageLifecycle `json:"data"`
+}
+
+// GetSqlManageLifecycleV1
+// @Summary 获取SQL管控自动流转配置
+// @Description get the assignment rules, auto solve and reopen config of the managed SQLs in project
+// @Id getSqlManageLifecycleV1
+// @Tags SqlManage
+// @Security ApiKeyAuth
+// @Param project_name path string true "project name"
+// @Success 200 {object} v1.GetSqlManageLifecycleResV1
+// @router /v1/projects/{project_name}/sql_manage_lifecycle [get]
+func GetSqlManageLifecycleV1(c echo.Context) error {
+	projectUid, err := dms.GetPorjectUIDByName(context.TODO(), c.Param("project_name"))
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	s := model.GetStorage()
+	config, exist, err := s.GetSQLManageLifecycleConfig(projectUid)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	rules, err := s.GetSQLManageAssignmentRules(projectUid)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+
+	data := &SqlManageLifecycle{
+		ReopenMetricFactor: 1,
+		AssignmentRules:    make([]*SqlManageAssignmentRule, 0, len(rules)),
+	}
+	if exist {
+		data.AutoSolveUnseenDays = config.AutoSolveUnseenDays
+		data.ReopenEnabled = config.ReopenEnabled
+		data.ReopenMetricFactor = config.ReopenMetricFactor
+	}
+	for _, rule := range rules {
+		data.AssignmentRules = append(data.AssignmentRules, &SqlManageAssignmentRule{
+			MatchType: rule.MatchType,
+			Pattern:   rule.Pattern,
+			Assignees: splitSqlManageAssignees(rule.Assignees),
+			Desc:      rule.Desc,
+		})
+	}
+	return c.JSON(http.StatusOK, &GetSqlManageLifecycleResV1{
+		BaseRes: controller.NewBaseReq(nil),
+		Data:    data,
+	})
+}
+
+// UpdateSqlManageLifecycleV1
+// @Summary 更新SQL管控自动流转配置
+// @Description update the assignment rules, auto solve and reopen config of the managed SQLs in project, the assignment rules are replaced
+// @Id updateSqlManageLifecycleV1
+// @Tags SqlManage
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param project_name path string true "project name"
+// @Param lifecycle body v1.SqlManageLifecycle true "update sql manage lifecycle request"
+// @Success 200 {object} controller.BaseRes
+// @router /v1/projects/{project_name}/sql_manage_lifecycle [put]
+func UpdateSqlManageLifecycleV1(c echo.Context) error {
+	req := new(SqlManageLifecycle)
+	if err := controller.BindAndValidateReq(c, req); err != nil {
+		return err
+	}
+	projectUid, err := dms.GetPorjectUIDByName(context.TODO(), c.Param("project_name"))
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+
+	s := model.GetStorage()
+	config, exist, err := s.GetSQLManageLifecycleConfig(projectUid)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	if !exist {
+		config = &model.SQLManageLifecycleConfig{ProjectId: projectUid}
+	}
+	config.AutoSolveUnseenDays = req.AutoSolveUnseenDays
+	config.ReopenEnabled = req.ReopenEnabled
+	config.ReopenMetricFactor = req.ReopenMetricFactor
+	if config.ReopenMetricFactor == 0 {
+		config.ReopenMetricFactor = 1
+	}
+
+	rules := make([]*model.SQLManageAssignmentRule, 0, len(req.AssignmentRules))
+	for i, rule := range req.AssignmentRules {
+		if _, err := path.Match(rule.Pattern, ""); err != nil {
+			return controller.JSONBaseErrorReq(c, errors.New(errors.DataInvalid, fmt.Errorf("invalid pattern %v: %v", rule.Pattern, err)))
+		}
+		rules = append(rules, &model.SQLManageAssignmentRule{
+			ProjectId: projectUid,
+			Sort:      uint(i),
+			MatchType: rule.MatchType,
+			Pattern:   rule.Pattern,
+			Assignees: strings.Join(rule.Assignees, ","),
+			Desc:      rule.Desc,
+		})
+	}
+	if err := s.SaveSQLManageLifecycle(config, rules); err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	return c.JSON(http.StatusOK, controller.NewBaseReq(nil))
+}
+
+type SqlManageActivity struct {
+	Action        string    `json:"action" enums:"assigned,auto_solved,reopened"`
+	Operator      string    `json:"operator"`
+	FromStatus    string    `json:"from_status"`
+	ToStatus      string    `json:"to_status"`
+	FromAssignees []string  `json:"from_assignees"`
+	ToAssignees   []string  `json:"to_assignees"`
+	Detail        string    `json:"detail"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type GetSqlManageActivitiesResV1 struct {
+	controller.BaseRes
+	Data []*SqlManageActivity `json:"data"`
+}
+
+// GetSqlManageActivitiesV1
+// @Summary 获取SQL管控SQL处理动态
+// @Description get the activities of the managed SQL, the latest first
+// @Id getSqlManageActivitiesV1
+// @Tags SqlManage
+// @Security ApiKeyAuth
+// @Param project_name path string true "project name"
+// @Param sql_manage_id path string true "sql manage id"
+// @Success 200 {object} v1.GetSqlManageActivitiesResV1
+// @router /v1/projects/{project_name}/sql_manages/{sql_manage_id}/activities [get]
+func GetSqlManageActivitiesV1(c echo.Context) error {
+	projectUid, err := dms.GetPorjectUIDByName(context.TODO(), c.Param("project_name"))
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	s := model.GetStorage()
+	sqlManage, exist, err := s.GetManageSQLById(c.Param("sql_manage_id"))
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	if !exist || sqlManage.ProjectId != projectUid {
+		return controller.JSONBaseErrorReq(c, errors.New(errors.DataNotExist, fmt.Errorf("sql manage is not exist")))
+	}
+	activities, err := s.GetSQLManageRecordActivities(sqlManage.ID)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+
+	data := make([]*SqlManageActivity, 0, len(activities))
+	for _, activity := range activities {
+		data = append(data, &SqlManageActivity{
+			Action:        activity.Action,
+			Operator:      activity.Operator,
+			FromStatus:    activity.FromStatus,
+			ToStatus:      activity.ToStatus,
+			FromAssignees: splitSqlManageAssignees(activity.FromAssignees),
+			ToAssignees:   splitSqlManageAssignees(activity.ToAssignees),
+			Detail:        activity.Detail,
+			CreatedAt:     activity.CreatedAt,
+		})
+	}
+	return c.JSON(http.StatusOK, &GetSqlManageActivitiesResV1{
+		BaseRes: controller.NewBaseReq(nil),
+		Data:    data,
+	})
+}
+
+func splitSqlManageAssignees(assignees string) []string {
+	if assignees == "" {
+		return []string{}
+	}
+	return strings.Split(assignees, ",")
+}
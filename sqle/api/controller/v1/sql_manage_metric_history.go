@@ -0,0 +1,145 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/actiontech/sqle/sqle/api/controller"
+	"github.com/actiontech/sqle/sqle/dms"
+	"github.com/actiontech/sqle/sqle/errors"
+	"github.com/actiontech/sqle/sqle/model"
+	"github.com/actiontech/sqle/sqle/server/auditplan"
+
+	"github.com/labstack/echo/v4"
+)
+
+type GetSqlManageMetricHistoryReqV1 struct {
+	StartTime   *string `json:"start_time" query:"start_time"`
+	EndTime     *string `json:"end_time" query:"end_time"`
+	MetricNames *string `json:"metric_names" query:"metric_names"`
+}
+
+type SqlManageMetricBucket struct {
+	RecordBeginAt  time.Time          `json:"record_begin_at"`
+	RecordEndAt    time.Time          `json:"record_end_at"`
+	ExecutionCount int                `json:"execution_count"`
+	Metrics        map[string]float64 `json:"metrics"`
+}
+
+type SqlManageRegression struct {
+	MetricName    string    `json:"metric_name"`
+	BaselineValue float64   `json:"baseline_value"`
+	CurrentValue  float64   `json:"current_value"`
+	Ratio         float64   `json:"ratio"`
+	DetectedAt    time.Time `json:"detected_at"`
+}
+
+type SqlManageMetricHistory struct {
+	SQLID       string                   `json:"sql_id"`
+	Buckets     []*SqlManageMetricBucket `json:"buckets"`
+	Regressions []*SqlManageRegression   `json:"regressions"`
+}
+
+type GetSqlManageMetricHistoryResV1 struct {
+	controller.BaseRes
+	Data *SqlManageMetricHistory `json:"data"`
+}
+
+// GetSqlManageMetricHistoryV1
+// @Summary 获取SQL管控SQL执行指标历史
+// @Description get the metrics of the managed SQL collected in every collection cycle and the regressions not resolved
+// @Id getSqlManageMetricHistoryV1
+// @Tags SqlManage
+// @Param project_name path string true "project name"
+// @Param sql_manage_id path string true "sql manage id"
+// @Param start_time query string false "start time, RFC3339 format, default 7 days ago"
+// @Param end_time query string false "end time, RFC3339 format, default now"
+// @Param metric_names query string false "metric names separated by comma, default query_time_avg,query_time_max,row_examined_avg,lock_time_avg"
+// @Security ApiKeyAuth
+// @Success 200 {object} v1.GetSqlManageMetricHistoryResV1
+// @Router /v1/projects/{project_name}/sql_manages/{sql_manage_id}/metric_history [get]
+func GetSqlManageMetricHistoryV1(c echo.Context) error {
+	req := new(GetSqlManageMetricHistoryReqV1)
+	if err := controller.BindAndValidateReq(c, req); err != nil {
+		return err
+	}
+	projectUid, err := dms.GetPorjectUIDByName(context.TODO(), c.Param("project_name"))
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+
+	endTime := time.Now()
+	if req.EndTime != nil && *req.EndTime != "" {
+		endTime, err = time.Parse(time.RFC3339, *req.EndTime)
+		if err != nil {
+			return controller.JSONBaseErrorReq(c, errors.New(errors.DataInvalid, fmt.Errorf("invalid end time: %v", err)))
+		}
+	}
+	startTime := endTime.AddDate(0, 0, -7)
+	if req.StartTime != nil && *req.StartTime != "" {
+		startTime, err = time.Parse(time.RFC3339, *req.StartTime)
+		if err != nil {
+			return controller.JSONBaseErrorReq(c, errors.New(errors.DataInvalid, fmt.Errorf("invalid start time: %v", err)))
+		}
+	}
+	if startTime.After(endTime) {
+		return controller.JSONBaseErrorReq(c, errors.New(errors.DataInvalid, fmt.Errorf("start time is after end time")))
+	}
+	metricNames := auditplan.SQLMetricHistoryMetrics
+	if req.MetricNames != nil && *req.MetricNames != "" {
+		metricNames = strings.Split(*req.MetricNames, ",")
+	}
+
+	s := model.GetStorage()
+	sqlManage, exist, err := s.GetManageSQLById(c.Param("sql_manage_id"))
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	if !exist || sqlManage.ProjectId != projectUid {
+		return controller.JSONBaseErrorReq(c, errors.New(errors.DataNotExist, fmt.Errorf("sql manage is not exist")))
+	}
+
+	records, err := s.GetSqlManageMetricRecordsWithValues(sqlManage.SQLID, metricNames, startTime, endTime)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	regressions, err := s.GetUnresolvedSQLManageRegressions(sqlManage.SQLID)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+
+	data := &SqlManageMetricHistory{
+		SQLID:       sqlManage.SQLID,
+		Buckets:     make([]*SqlManageMetricBucket, 0, len(records)),
+		Regressions: make([]*SqlManageRegression, 0, len(regressions)),
+	}
+	for _, record := range records {
+		bucket := &SqlManageMetricBucket{
+			RecordBeginAt:  record.RecordBeginAt,
+			RecordEndAt:    record.RecordEndAt,
+			ExecutionCount: record.ExecutionCount,
+			Metrics:        make(map[string]float64, len(record.MetricValues)),
+		}
+		for _, value := range record.MetricValues {
+			bucket.Metrics[value.MetricName] = value.MetricValue
+		}
+		data.Buckets = append(data.Buckets, bucket)
+	}
+	for _, regression := range regressions {
+		data.Regressions = append(data.Regressions, &SqlManageRegression{
+			MetricName:    regression.MetricName,
+			BaselineValue: regression.BaselineValue,
+			CurrentValue:  regression.CurrentValue,
+			Ratio:         regression.Ratio,
+			DetectedAt:    regression.DetectedAt,
+		})
+	}
+
+	return c.JSON(http.StatusOK, &GetSqlManageMetricHistoryResV1{
+		BaseRes: controller.NewBaseReq(nil),
+		Data:    data,
+	})
+}
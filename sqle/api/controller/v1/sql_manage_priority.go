@@ -0,0 +1,122 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/actiontech/sqle/sqle/api/controller"
+	"github.com/actiontech/sqle/sqle/dms"
+	"github.com/actiontech/sqle/sqle/errors"
+	"github.com/actiontech/sqle/sqle/model"
+	"github.com/actiontech/sqle/sqle/server/auditplan"
+
+	"github.com/labstack/echo/v4"
+)
+
+type SqlManagePriorityRule struct {
+	Priority string `json:"priority" enums:"P0,P1,P2,P3" valid:"required,oneof=P0 P1 P2 P3"`
+	// the boolean expression over audit_level, regression_factor and the metrics of the SQL, e.g. audit_level >= warn AND (query_time_avg > 1 OR row_examined_avg BETWEEN 10000 AND 1000000)
+	Expression string `json:"expression" valid:"required"`
+	// the unhandled SQLs of the priority are escalated by the sql_manage_sla report push after the minutes, 0 means no SLA
+	SLAMinutes uint   `json:"sla_minutes"`
+	Desc       string `json:"desc"`
+}
+
+type GetSqlManagePriorityRulesResV1 struct {
+	controller.BaseRes
+	Data []*SqlManagePriorityRule `json:"data"`
+}
+
+// GetSqlManagePriorityRulesV1
+// @Summary 获取SQL管控优先级规则
+// @Description get the priority rules of the managed SQLs in project
+// @Id getSqlManagePriorityRulesV1
+// @Tags SqlManage
+// @Security ApiKeyAuth
+// @Param project_name path string true "project name"
+// @Success 200 {object} v1.GetSqlManagePriorityRulesResV1
+// @router /v1/projects/{project_name}/sql_manage_priority_rules [get]
+func GetSqlManagePriorityRulesV1(c echo.Context) error {
+	projectUid, err := dms.GetPorjectUIDByName(context.TODO(), c.Param("project_name"))
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	rules, err := model.GetStorage().GetSQLManagePriorityRulesByProjectId(projectUid)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	data := make([]*SqlManagePriorityRule, 0, len(rules))
+	for _, rule := range rules {
+		data = append(data, &SqlManagePriorityRule{
+			Priority:   rule.Priority,
+			Expression: rule.Expression,
+			SLAMinutes: rule.SLAMinutes,
+			Desc:       rule.Desc,
+		})
+	}
+	return c.JSON(http.StatusOK, &GetSqlManagePriorityRulesResV1{
+		BaseRes: controller.NewBaseReq(nil),
+		Data:    data,
+	})
+}
+
+type UpdateSqlManagePriorityRulesReqV1 struct {
+	Rules []*SqlManagePriorityRule `json:"rules" valid:"dive"`
+}
+
+// UpdateSqlManagePriorityRulesV1
+// @Summary 更新SQL管控优先级规则
+// @Description replace the priority rules of the managed SQLs in project. The SQL gets the priority of the first rule matched from P0 to P3, the SQL matching no rule gets the high priority if it matches the high priority params of the audit plan
+// @Id updateSqlManagePriorityRulesV1
+// @Tags SqlManage
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param project_name path string true "project name"
+// @Param rules body v1.UpdateSqlManagePriorityRulesReqV1 true "update priority rules request"
+// @Success 200 {object} controller.BaseRes
+// @router /v1/projects/{project_name}/sql_manage_priority_rules [put]
+func UpdateSqlManagePriorityRulesV1(c echo.Context) error {
+	req := new(UpdateSqlManagePriorityRulesReqV1)
+	if err := controller.BindAndValidateReq(c, req); err != nil {
+		return err
+	}
+	projectUid, err := dms.GetPorjectUIDByName(context.TODO(), c.Param("project_name"))
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+
+	rules := make([]*model.SQLManagePriorityRule, 0, len(req.Rules))
+	priorities := map[string]struct{}{}
+	hasSLA := false
+	for _, rule := range req.Rules {
+		if _, ok := priorities[rule.Priority]; ok {
+			return controller.JSONBaseErrorReq(c, errors.New(errors.DataInvalid, fmt.Errorf("priority %v is duplicated", rule.Priority)))
+		}
+		priorities[rule.Priority] = struct{}{}
+		if _, err := auditplan.ParsePriorityExpression(rule.Expression); err != nil {
+			return controller.JSONBaseErrorReq(c, errors.New(errors.DataInvalid, fmt.Errorf("invalid expression of priority %v: %v", rule.Priority, err)))
+		}
+		hasSLA = hasSLA || rule.SLAMinutes > 0
+		rules = append(rules, &model.SQLManagePriorityRule{
+			ProjectId:  projectUid,
+			Priority:   rule.Priority,
+			Expression: rule.Expression,
+			SLAMinutes: rule.SLAMinutes,
+			Desc:       rule.Desc,
+		})
+	}
+
+	s := model.GetStorage()
+	if err := s.ReplaceSQLManagePriorityRules(projectUid, rules); err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	if hasSLA {
+		// the SLA is escalated by the report push config of the project
+		if err := s.CreateMissingReportPushConfigInProject(projectUid); err != nil {
+			return controller.JSONBaseErrorReq(c, err)
+		}
+	}
+	return c.JSON(http.StatusOK, controller.NewBaseReq(nil))
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"mime"
 	"mime/multipart"
+	"net"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
@@ -66,6 +67,7 @@ type AuditTaskResV1 struct {
 	BackupMaxRows              uint64          `json:"backup_max_rows,omitempty"`
 	BackupConflictWithInstance bool            `json:"backup_conflict_with_instance"` // 当数据源备份开启，工单备份关闭，则需要提示审核人工单备份策略与数据源备份策略不一致
 	AuditFiles                 []AuditFileResp `json:"audit_files,omitempty"`
+	ExecPolicy                 *TaskExecPolicy `json:"exec_policy"`
 }
 
 type AuditFileResp struct {
@@ -91,6 +93,7 @@ func convertTaskToRes(task *model.Task) *AuditTaskResV1 {
 		BackupConflictWithInstance: server.BackupService{}.IsBackupConflictWithInstance(task.EnableBackup, task.InstanceEnableBackup),
 		FileOrderMethod:            task.FileOrderMethod,
 		AuditFiles:                 convertToAuditFileResp(task.AuditFiles),
+		ExecPolicy:                 convertTaskExecPolicyToRes(task.ExecPolicy),
 	}
 }
 func convertToAuditFileResp(files []*model.AuditFile) []AuditFileResp {
@@ -617,16 +620,20 @@ func DownloadTaskSQLReportFile(c echo.Context) error {
 
 	ctx := c.Request().Context()
 	csvBuilder := utils.NewCSVBuilder()
-	err = csvBuilder.WriteHeader([]string{
-		locale.Bundle.LocalizeMsgByCtx(ctx, locale.TaskSQLReportIndex),       // "序号",
-		locale.Bundle.LocalizeMsgByCtx(ctx, locale.TaskSQLReportSQL),         // "SQL",
-		locale.Bundle.LocalizeMsgByCtx(ctx, locale.TaskSQLReportAuditStatus), // "SQL审核状态",
-		locale.Bundle.LocalizeMsgByCtx(ctx, locale.TaskSQLReportAuditResult), // "SQL审核结果",
-		locale.Bundle.LocalizeMsgByCtx(ctx, locale.TaskSQLReportExecStatus),  // "SQL执行状态",
-		locale.Bundle.LocalizeMsgByCtx(ctx, locale.TaskSQLReportExecResult),  // "SQL执行结果",
-		locale.Bundle.LocalizeMsgByCtx(ctx, locale.TaskSQLReportRollbackSQL), // "SQL对应的回滚语句",
-		locale.Bundle.LocalizeMsgByCtx(ctx, locale.TaskSQLReportDescription), // "SQL描述",
-	})
+	reportHeader := []string{
+		locale.Bundle.LocalizeMsgByCtx(ctx, locale.TaskSQLReportIndex),         // "序号",
+		locale.Bundle.LocalizeMsgByCtx(ctx, locale.TaskSQLReportSQL),           // "SQL",
+		locale.Bundle.LocalizeMsgByCtx(ctx, locale.TaskSQLReportAuditStatus),   // "SQL审核状态",
+		locale.Bundle.LocalizeMsgByCtx(ctx, locale.TaskSQLReportAuditResult),   // "SQL审核结果",
+		locale.Bundle.LocalizeMsgByCtx(ctx, locale.TaskSQLReportExecStatus),    // "SQL执行状态",
+		locale.Bundle.LocalizeMsgByCtx(ctx, locale.TaskSQLReportExecResult),    // "SQL执行结果",
+		locale.Bundle.LocalizeMsgByCtx(ctx, locale.TaskSQLReportRollbackSQL),   // "SQL对应的回滚语句",
+		locale.Bundle.LocalizeMsgByCtx(ctx, locale.TaskSQLReportDescription),   // "SQL描述",
+		locale.Bundle.LocalizeMsgByCtx(ctx, locale.TaskSQLReportExecDuration),  // "SQL执行耗时(毫秒)",
+		locale.Bundle.LocalizeMsgByCtx(ctx, locale.TaskSQLReportExecWarnings),  // "SQL执行告警",
+		locale.Bundle.LocalizeMsgByCtx(ctx, locale.TaskSQLReportResultPreview), // "SQL返回结果预览",
+	}
+	err = csvBuilder.WriteHeader(reportHeader)
 	if err != nil {
 		return controller.JSONBaseErrorReq(c, errors.New(errors.WriteDataToTheFileError, err))
 	}
@@ -649,11 +656,26 @@ func DownloadTaskSQLReportFile(c echo.Context) error {
 			td.ExecResult,
 			strings.Join(rollbackSqlMap[taskSql.ID], "\n"),
 			td.Description,
+			strconv.FormatUint(td.ExecDuration, 10),
+			td.ExecWarnings.String(),
+			td.ResultPreview.String(),
 		})
 		if err != nil {
 			return controller.JSONBaseErrorReq(c, errors.New(errors.WriteDataToTheFileError, err))
 		}
 	}
+	if task.Status == model.TaskStatusManuallyExecuted {
+		execution, exist, err := s.GetTaskManualExecutionByTaskId(task.ID)
+		if err != nil {
+			return controller.JSONBaseErrorReq(c, err)
+		}
+		if exist {
+			err = csvBuilder.WriteRows(convertTaskManualExecutionToReportRows(ctx, execution, len(reportHeader)))
+			if err != nil {
+				return controller.JSONBaseErrorReq(c, errors.New(errors.WriteDataToTheFileError, err))
+			}
+		}
+	}
 	fileName := fmt.Sprintf("SQL_audit_report_%v_%v.csv", task.InstanceName(), taskId)
 	c.Response().Header().Set(echo.HeaderContentDisposition,
 		mime.FormatMediaType("attachment", map[string]string{"filename": fileName}))
@@ -781,6 +803,92 @@ func UpdateAuditTaskSQLs(c echo.Context) error {
 	return controller.JSONBaseErrorReq(c, err)
 }
 
+type TaskExecPolicy struct {
+	// the timeout of executing a SQL, a whole transaction or a whole batch of SQLs, the execution is killed when timed out
+	// and the following SQLs of the task are not executed
+	StatementTimeoutSeconds uint `json:"statement_timeout_seconds"`
+	// the lock_wait_timeout of the MySQL session
+	LockWaitTimeoutSeconds uint `json:"lock_wait_timeout_seconds"`
+	// the innodb_lock_wait_timeout of the MySQL session
+	InnodbLockWaitTimeoutSeconds uint `json:"innodb_lock_wait_timeout_seconds"`
+	// the times to retry the SQL failed by deadlock or lock wait timeout, the delay between retries doubles from retry_delay_seconds
+	RetryAttempts     uint `json:"retry_attempts" valid:"lte=10"`
+	RetryDelaySeconds uint `json:"retry_delay_seconds"`
+	// the DDL is not executed if there are sessions holding a transaction or running a query for at least the seconds
+	BlockingSessionSeconds uint `json:"blocking_session_seconds"`
+	// execute the single table UPDATE/DELETE in chunks of primary key range, each chunk scans chunk_size rows (1000 by default),
+	// and sleeps chunk_sleep_milliseconds after each chunk, the next chunk waits until the replication lag of replicas is less than max_replication_lag_seconds
+	ChunkedDML               bool `json:"chunked_dml"`
+	ChunkSize                uint `json:"chunk_size"`
+	ChunkSleepMilliseconds   uint `json:"chunk_sleep_milliseconds"`
+	MaxReplicationLagSeconds uint `json:"max_replication_lag_seconds"`
+	// the addresses (host:port) of the replicas to check the replication lag, the addresses reported by the replicas
+	// registered to the instance are used if it is empty. The chunked execution is paused if the lag can't be checked
+	ReplicaAddresses []string `json:"replica_addresses" example:"10.0.0.2:3306"`
+}
+
+func convertTaskExecPolicyToRes(policy model.TaskExecPolicy) *TaskExecPolicy {
+	return &TaskExecPolicy{
+		StatementTimeoutSeconds:      policy.StatementTimeoutSeconds,
+		LockWaitTimeoutSeconds:       policy.LockWaitTimeoutSeconds,
+		InnodbLockWaitTimeoutSeconds: policy.InnodbLockWaitTimeoutSeconds,
+		RetryAttempts:                policy.RetryAttempts,
+		RetryDelaySeconds:            policy.RetryDelaySeconds,
+		BlockingSessionSeconds:       policy.BlockingSessionSeconds,
+		ChunkedDML:                   policy.ChunkedDML,
+		ChunkSize:                    policy.ChunkSize,
+		ChunkSleepMilliseconds:       policy.ChunkSleepMilliseconds,
+		MaxReplicationLagSeconds:     policy.MaxReplicationLagSeconds,
+		ReplicaAddresses:             policy.ReplicaAddresses,
+	}
+}
+
+// @Summary 修改任务的上线执行策略
+// @Description update the execution policy of the task, such as statement timeout, lock wait timeout, retry on lock conflict and blocking sessions check before DDL
+// @Tags task
+// @Id updateTaskExecPolicyV1
+// @Accept json
+// @Param task_id path string true "task id"
+// @Param exec_policy body v1.TaskExecPolicy true "task execution policy"
+// @Security ApiKeyAuth
+// @Success 200 {object} controller.BaseRes
+// @router /v1/tasks/audits/{task_id}/exec_policy [patch]
+func UpdateTaskExecPolicy(c echo.Context) error {
+	req := new(TaskExecPolicy)
+	if err := controller.BindAndValidateReq(c, req); err != nil {
+		return err
+	}
+	task, err := getTaskById(c.Request().Context(), c.Param("task_id"))
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	if err := CheckCurrentUserCanOpTask(c, task); err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	if task.Status != model.TaskStatusInit && task.Status != model.TaskStatusAudited {
+		return controller.JSONBaseErrorReq(c, errors.NewDataInvalidErr("the execution policy can't be updated after the task is executed"))
+	}
+	for _, addr := range req.ReplicaAddresses {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			return controller.JSONBaseErrorReq(c, errors.NewDataInvalidErr("invalid replica address %v: %v", addr, err))
+		}
+	}
+	err = model.GetStorage().UpdateTaskExecPolicy(task, model.TaskExecPolicy{
+		StatementTimeoutSeconds:      req.StatementTimeoutSeconds,
+		LockWaitTimeoutSeconds:       req.LockWaitTimeoutSeconds,
+		InnodbLockWaitTimeoutSeconds: req.InnodbLockWaitTimeoutSeconds,
+		RetryAttempts:                req.RetryAttempts,
+		RetryDelaySeconds:            req.RetryDelaySeconds,
+		BlockingSessionSeconds:       req.BlockingSessionSeconds,
+		ChunkedDML:                   req.ChunkedDML,
+		ChunkSize:                    req.ChunkSize,
+		ChunkSleepMilliseconds:       req.ChunkSleepMilliseconds,
+		MaxReplicationLagSeconds:     req.MaxReplicationLagSeconds,
+		ReplicaAddresses:             req.ReplicaAddresses,
+	})
+	return controller.JSONBaseErrorReq(c, err)
+}
+
 func CheckCurrentUserCanViewTask(c echo.Context, task *model.Task) (err error) {
 	return checkCurrentUserCanViewTask(c, task, []dmsV1.OpPermissionType{dmsV1.OpPermissionTypeViewOthersWorkflow})
 }
@@ -1022,6 +1130,10 @@ func AuditTaskGroupV1(c echo.Context) error {
 
 	// 因为这个接口数据源必然相同，所以只取第一个实例的DbType即可
 	dbType := instances[0].DbType
+	instanceMap := make(map[uint64]*model.Instance)
+	for _, instance := range instances {
+		instanceMap[instance.ID] = instance
+	}
 
 	if req.Sql != "" {
 		sqls = getSQLFromFileResp{
@@ -1047,10 +1159,6 @@ func AuditTaskGroupV1(c echo.Context) error {
 			return controller.JSONBaseErrorReq(c, err)
 		}
 		defer plugin.Close(context.TODO())
-		instanceMap := make(map[uint64]*model.Instance)
-		for _, instance := range instances {
-			instanceMap[instance.ID] = instance
-		}
 
 		for _, task := range tasks {
 			task.SQLSource = sqls.SourceType
@@ -1104,15 +1212,33 @@ func AuditTaskGroupV1(c echo.Context) error {
 		return controller.JSONBaseErrorReq(c, err)
 	}
 
+	// the tasks fanned out to the schemas with the same shape are audited once by the same rule template
+	auditedShapes := map[string]*model.Task{}
 	for i, task := range tasks {
 		if task.Status != model.TaskStatusInit {
 			continue
 		}
 
+		shapeKey := ""
+		if server.CanShareAuditResult(task) {
+			if instance, exist := instanceMap[task.InstanceId]; exist {
+				shapeKey = fmt.Sprintf("%v:%v", instance.RuleTemplateName, task.SchemaShape)
+			}
+		}
+		if audited, exist := auditedShapes[shapeKey]; exist && shapeKey != "" {
+			if err := server.CopyTaskAuditResult(audited, task); err != nil {
+				return controller.JSONBaseErrorReq(c, err)
+			}
+			continue
+		}
+
 		tasks[i], err = server.GetSqled().AddTaskWaitResult(projectId, fmt.Sprintf("%d", task.ID), server.ActionTypeAudit)
 		if err != nil {
 			return controller.JSONBaseErrorReq(c, err)
 		}
+		if shapeKey != "" {
+			auditedShapes[shapeKey] = tasks[i]
+		}
 	}
 
 	tasksRes := make([]*AuditTaskResV1, len(tasks))
@@ -0,0 +1,223 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/actiontech/sqle/sqle/api/controller"
+	"github.com/actiontech/sqle/sqle/common"
+	"github.com/actiontech/sqle/sqle/dms"
+	"github.com/actiontech/sqle/sqle/errors"
+	"github.com/actiontech/sqle/sqle/log"
+	"github.com/actiontech/sqle/sqle/model"
+	"github.com/actiontech/sqle/sqle/server"
+	"github.com/actiontech/sqle/sqle/utils"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sirupsen/logrus"
+)
+
+// MaximumFanOutTaskNum is the max number of the schemas a fan-out task group can be expanded to.
+const MaximumFanOutTaskNum = 1000
+
+type FanOutTargetV1 struct {
+	InstanceName string `json:"instance_name" valid:"required"`
+	// the schemas matching the pattern are targeted, '%' matches any characters and '_' matches one character, such as tenant_%
+	SchemaPattern string `json:"schema_pattern"`
+	// the schemas targeted explicitly
+	Schemas []string `json:"schemas"`
+}
+
+type CreateFanOutAuditTasksGroupReqV1 struct {
+	Targets         []*FanOutTargetV1 `json:"targets" valid:"required,dive,required"`
+	ExecMode        string            `json:"exec_mode" enums:"sql_file,sqls"`
+	FileOrderMethod string            `json:"file_order_method"`
+}
+
+type CreateFanOutAuditTasksGroupResV1 struct {
+	controller.BaseRes
+	Data *FanOutAuditTasksGroupResV1 `json:"data"`
+}
+
+type FanOutAuditTasksGroupResV1 struct {
+	TaskGroupId uint `json:"task_group_id"`
+	// the number of distinct schema shapes, the tasks with the same schema shape are audited once
+	SchemaShapeCount int                `json:"schema_shape_count"`
+	Tasks            []*FanOutTaskResV1 `json:"tasks"`
+}
+
+type FanOutTaskResV1 struct {
+	TaskId         uint   `json:"task_id"`
+	InstanceName   string `json:"instance_name"`
+	InstanceSchema string `json:"instance_schema"`
+	SchemaShape    string `json:"schema_shape"`
+}
+
+// CreateFanOutAuditTasksGroupV1
+// @Summary 创建分发到多个schema的审核任务组
+// @Description create a task group targeting the schemas matching the pattern or listed explicitly across instances, a task is created for each schema, audit the task group by the same SQLs with /v1/task_groups/audit
+// @Accept json
+// @Produce json
+// @Tags task
+// @Id createFanOutAuditTasksGroupV1
+// @Security ApiKeyAuth
+// @Param project_name path string true "project name"
+// @Param req body v1.CreateFanOutAuditTasksGroupReqV1 true "parameters for creating fan-out audit tasks group"
+// @Success 200 {object} v1.CreateFanOutAuditTasksGroupResV1
+// @router /v1/projects/{project_name}/task_groups/fan_out [post]
+func CreateFanOutAuditTasksGroupV1(c echo.Context) error {
+	req := new(CreateFanOutAuditTasksGroupReqV1)
+	if err := controller.BindAndValidateReq(c, req); err != nil {
+		return err
+	}
+	projectUid, err := dms.GetPorjectUIDByName(context.TODO(), c.Param("project_name"))
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	user, err := controller.GetCurrentUser(c, dms.GetUser)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+
+	l := log.NewEntry()
+	type target struct {
+		instance *model.Instance
+		schema   string
+		shape    string
+	}
+	targets := []*target{}
+	distinct := map[string]struct{}{}
+	shapes := map[string]struct{}{}
+	for _, reqTarget := range req.Targets {
+		if reqTarget.SchemaPattern == "" && len(reqTarget.Schemas) == 0 {
+			return controller.JSONBaseErrorReq(c, errors.New(errors.DataInvalid,
+				fmt.Errorf("the schema pattern and schemas of instance %v are both empty", reqTarget.InstanceName)))
+		}
+		instance, exist, err := dms.GetInstanceInProjectByName(c.Request().Context(), projectUid, reqTarget.InstanceName)
+		if err != nil {
+			return controller.JSONBaseErrorReq(c, err)
+		}
+		if !exist {
+			return controller.JSONBaseErrorReq(c, ErrInstanceNoAccess)
+		}
+		can, err := CheckCurrentUserCanOpInstances(c.Request().Context(), projectUid, user.GetIDStr(), []*model.Instance{instance})
+		if err != nil {
+			return controller.JSONBaseErrorReq(c, err)
+		}
+		if !can {
+			return controller.JSONBaseErrorReq(c, ErrInstanceNoAccess)
+		}
+		if err := common.CheckInstanceIsConnectable(instance); err != nil {
+			return controller.JSONBaseErrorReq(c, err)
+		}
+
+		schemas, err := expandFanOutSchemas(l, instance, reqTarget)
+		if err != nil {
+			return controller.JSONBaseErrorReq(c, err)
+		}
+		instanceShapes, err := server.GetSchemaShapes(l, instance, schemas)
+		if err != nil {
+			return controller.JSONBaseErrorReq(c, err)
+		}
+		for _, schema := range schemas {
+			key := fmt.Sprintf("%v.%v", instance.Name, schema)
+			if _, ok := distinct[key]; ok {
+				continue
+			}
+			distinct[key] = struct{}{}
+			shape := instanceShapes[schema]
+			if shape != "" {
+				shapes[shape] = struct{}{}
+			} else {
+				shapes[key] = struct{}{}
+			}
+			targets = append(targets, &target{instance: instance, schema: schema, shape: shape})
+		}
+	}
+	if len(targets) == 0 {
+		return controller.JSONBaseErrorReq(c, errors.New(errors.DataInvalid, fmt.Errorf("no schema is matched")))
+	}
+	if len(targets) > MaximumFanOutTaskNum {
+		return controller.JSONBaseErrorReq(c, errors.New(errors.DataConflict,
+			fmt.Errorf("%v schemas are matched, the number of schemas must be less than %v", len(targets), MaximumFanOutTaskNum)))
+	}
+
+	tasks := make([]*model.Task, 0, len(targets))
+	for _, t := range targets {
+		task := &model.Task{
+			Schema:          t.schema,
+			InstanceId:      t.instance.ID,
+			CreateUserId:    uint64(user.ID),
+			DBType:          t.instance.DbType,
+			ExecMode:        req.ExecMode,
+			FileOrderMethod: req.FileOrderMethod,
+			SchemaShape:     t.shape,
+		}
+		task.CreatedAt = time.Now()
+		tasks = append(tasks, task)
+	}
+	taskGroup := model.TaskGroup{FanOut: true, Tasks: tasks}
+	if err := model.GetStorage().Save(&taskGroup); err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+
+	data := &FanOutAuditTasksGroupResV1{
+		TaskGroupId:      taskGroup.ID,
+		SchemaShapeCount: len(shapes),
+		Tasks:            make([]*FanOutTaskResV1, 0, len(tasks)),
+	}
+	for i, task := range tasks {
+		data.Tasks = append(data.Tasks, &FanOutTaskResV1{
+			TaskId:         task.ID,
+			InstanceName:   targets[i].instance.Name,
+			InstanceSchema: task.Schema,
+			SchemaShape:    task.SchemaShape,
+		})
+	}
+	return c.JSON(http.StatusOK, &CreateFanOutAuditTasksGroupResV1{
+		BaseRes: controller.NewBaseReq(nil),
+		Data:    data,
+	})
+}
+
+func expandFanOutSchemas(l *logrus.Entry, instance *model.Instance, target *FanOutTargetV1) ([]string, error) {
+	if target.SchemaPattern == "" {
+		return utils.RemoveDuplicate(target.Schemas), nil
+	}
+	plugin, err := common.NewDriverManagerWithoutAudit(l, instance, "")
+	if err != nil {
+		return nil, err
+	}
+	defer plugin.Close(context.TODO())
+	all, err := plugin.Schemas(context.TODO())
+	if err != nil {
+		return nil, err
+	}
+	matched, err := server.MatchSchemas(target.SchemaPattern, all)
+	if err != nil {
+		return nil, errors.New(errors.DataInvalid, err)
+	}
+	return utils.RemoveDuplicate(append(matched, target.Schemas...)), nil
+}
+
+func isFanOutTasks(s *model.Storage, tasks []*model.Task) (bool, error) {
+	groupIds := make([]uint, 0, len(tasks))
+	for _, task := range tasks {
+		if task.GroupId == 0 {
+			return false, nil
+		}
+		groupIds = append(groupIds, task.GroupId)
+	}
+	taskGroups, err := s.GetTaskGroupsByIds(utils.RemoveDuplicateUint(groupIds))
+	if err != nil {
+		return false, err
+	}
+	for _, taskGroup := range taskGroups {
+		if !taskGroup.FanOut {
+			return false, nil
+		}
+	}
+	return len(taskGroups) > 0, nil
+}
@@ -0,0 +1,420 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	dmsV1 "github.com/actiontech/dms/pkg/dms-common/api/dms/v1"
+	"github.com/actiontech/sqle/sqle/api/controller"
+	"github.com/actiontech/sqle/sqle/config"
+	"github.com/actiontech/sqle/sqle/dms"
+	"github.com/actiontech/sqle/sqle/errors"
+	"github.com/actiontech/sqle/sqle/locale"
+	"github.com/actiontech/sqle/sqle/log"
+	"github.com/actiontech/sqle/sqle/model"
+	"github.com/actiontech/sqle/sqle/server"
+	"github.com/actiontech/sqle/sqle/utils"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	InputManualExecutionEvidenceFile = "evidence_file"
+)
+
+// CreateTaskManualExecutionV1
+// @Summary 提交数据源手动上线凭证
+// @Description mark the task executed outside SQLE as manually executed with the evidence, the execution log or the evidence file is required. The changes of the schema can be verified after the evidence is saved
+// @Accept mpfd
+// @Produce json
+// @Tags workflow
+// @Id createTaskManualExecutionV1
+// @Security ApiKeyAuth
+// @Param project_name path string true "project name"
+// @Param workflow_id path string true "workflow id"
+// @Param task_id path string true "task id"
+// @Param executed_at formData string true "the time the SQLs are executed, RFC3339 format"
+// @Param executor formData string true "the person who executed the SQLs"
+// @Param remark formData string false "remark"
+// @Param execution_log formData string false "the execution log or output"
+// @Param evidence_file formData file false "the execution log or output file"
+// @Param verify formData bool false "verify the changes of the schema after the evidence is saved, only support MySQL"
+// @Success 200 {object} v1.GetTaskManualExecutionResV1
+// @router /v1/projects/{project_name}/workflows/{workflow_id}/tasks/{task_id}/manual_execution [post]
+func CreateTaskManualExecutionV1(c echo.Context) error {
+	executor := strings.TrimSpace(c.FormValue("executor"))
+	if executor == "" {
+		return controller.JSONBaseErrorReq(c, errors.New(errors.DataInvalid, fmt.Errorf("executor is required")))
+	}
+	executedAt, err := time.Parse(time.RFC3339, c.FormValue("executed_at"))
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, errors.New(errors.DataInvalid, fmt.Errorf("invalid executed_at: %v", err)))
+	}
+	if executedAt.After(time.Now()) {
+		return controller.JSONBaseErrorReq(c, errors.New(errors.DataInvalid, fmt.Errorf("executed_at can't be later than now")))
+	}
+	verify := false
+	if v := c.FormValue("verify"); v != "" {
+		verify, err = strconv.ParseBool(v)
+		if err != nil {
+			return controller.JSONBaseErrorReq(c, errors.New(errors.DataInvalid, fmt.Errorf("invalid verify: %v", err)))
+		}
+	}
+	executionLog := c.FormValue("execution_log")
+	fileHeader, err := c.FormFile(InputManualExecutionEvidenceFile)
+	if err != nil && err != http.ErrMissingFile {
+		return controller.JSONBaseErrorReq(c, errors.New(errors.ReadUploadFileError, err))
+	}
+	if fileHeader == nil && strings.TrimSpace(executionLog) == "" {
+		return controller.JSONBaseErrorReq(c, errors.New(errors.DataInvalid, fmt.Errorf("the execution log or the evidence file is required")))
+	}
+
+	projectUid, err := dms.GetPorjectUIDByName(context.TODO(), c.Param("project_name"))
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	s := model.GetStorage()
+	workflow, err := dms.GetWorkflowDetailByWorkflowId(projectUid, c.Param("workflow_id"), s.GetWorkflowDetailWithoutInstancesByWorkflowID)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	if err := CheckCurrentUserCanOperateWorkflow(c, projectUid, workflow, []dmsV1.OpPermissionType{}); err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	user, err := controller.GetCurrentUser(c, dms.GetUser)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	if workflow.Record.Status != model.WorkflowStatusWaitForExecution && workflow.Record.Status != model.WorkflowStatusExecFailed {
+		return controller.JSONBaseErrorReq(c, errors.New(errors.DataInvalid,
+			fmt.Errorf("workflow status is %s, not allow operate it", workflow.Record.Status)))
+	}
+
+	// 和直接标记工单完成一样，只有项目管理员和最后一个节点的处理人可以标记手动上线
+	lastStep := workflow.Record.Steps[len(workflow.Record.Steps)-1]
+	up, err := dms.NewUserPermission(user.GetIDStr(), projectUid)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	canMarkExecuted := up.CanOpProject()
+	if !canMarkExecuted {
+		for _, assignee := range strings.Split(lastStep.Assignees, ",") {
+			if assignee == user.GetIDStr() {
+				canMarkExecuted = true
+				break
+			}
+		}
+	}
+	if !canMarkExecuted {
+		return controller.JSONBaseErrorReq(c, errors.New(errors.UserNotPermission, fmt.Errorf("the current user does not have permission to mark the task manually executed")))
+	}
+
+	taskId, err := FormatStringToInt(c.Param("task_id"))
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	var record *model.WorkflowInstanceRecord
+	for _, r := range workflow.Record.InstanceRecords {
+		if r.TaskId == uint(taskId) {
+			record = r
+			break
+		}
+	}
+	if record == nil || record.Task == nil {
+		return controller.JSONBaseErrorReq(c, errors.NewTaskNoExistOrNoAccessErr())
+	}
+	if record.Task.Status != model.TaskStatusAudited && record.Task.Status != model.TaskStatusExecuteFailed {
+		return controller.JSONBaseErrorReq(c, errors.New(errors.DataInvalid,
+			fmt.Errorf("task status is %s, not allow to mark it manually executed", record.Task.Status)))
+	}
+	_, exist, err := s.GetTaskManualExecutionByTaskId(record.TaskId)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	if exist {
+		return controller.JSONBaseErrorReq(c, errors.New(errors.DataExist, fmt.Errorf("the task has been marked manually executed")))
+	}
+
+	execution := &model.TaskManualExecution{
+		TaskId:       record.TaskId,
+		WorkflowId:   workflow.WorkflowId,
+		ExecutedAt:   &executedAt,
+		Executor:     executor,
+		RecordUserId: user.GetIDStr(),
+		Remark:       c.FormValue("remark"),
+		ExecutionLog: executionLog,
+	}
+	if fileHeader != nil {
+		multipartFile, err := fileHeader.Open()
+		if err != nil {
+			return controller.JSONBaseErrorReq(c, errors.New(errors.ReadUploadFileError, err))
+		}
+		defer multipartFile.Close()
+		if err := utils.EnsureFilePathWithPermission(model.FixFilePath, utils.OwnerPrivilegedAccessMode); err != nil {
+			return controller.JSONBaseErrorReq(c, err)
+		}
+		uniqueName := model.GenUniqueFileName()
+		if err := utils.SaveFile(multipartFile, model.DefaultFilePath(uniqueName)); err != nil {
+			return controller.JSONBaseErrorReq(c, err)
+		}
+		execution.EvidenceFileName = fileHeader.Filename
+		execution.EvidenceUniqueName = uniqueName
+		execution.EvidenceFileHost = config.GetOptions().SqleOptions.ReportHost
+	}
+
+	record.IsSQLExecuted = true
+	record.ExecutionUserId = user.GetIDStr()
+	record.Task.Status = model.TaskStatusManuallyExecuted
+	var operateStep *model.WorkflowStep
+	allExecuted, anyFailed := true, false
+	for _, r := range workflow.Record.InstanceRecords {
+		if !r.IsSQLExecuted {
+			allExecuted = false
+		}
+		if r.Task != nil && r.Task.Status == model.TaskStatusExecuteFailed {
+			anyFailed = true
+		}
+	}
+	if allExecuted {
+		if workflow.Record.Status == model.WorkflowStatusWaitForExecution {
+			now := time.Now()
+			lastStep.State = model.WorkflowStepStateApprove
+			lastStep.OperationUserId = user.GetIDStr()
+			lastStep.OperateAt = &now
+			operateStep = lastStep
+		}
+		workflow.Record.CurrentWorkflowStepId = 0
+		workflow.Record.Status = model.WorkflowStatusFinish
+		if anyFailed {
+			workflow.Record.Status = model.WorkflowStatusExecFailed
+		}
+	}
+
+	execResult := fmt.Sprintf("manually executed by %v at %v", executor, executedAt.Format(time.RFC3339))
+	if execution.EvidenceFileName != "" {
+		execResult = fmt.Sprintf("%v, evidence file: %v", execResult, execution.EvidenceFileName)
+	}
+	if err := s.CreateTaskManualExecution(workflow, operateStep, record, execution, execResult); err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+
+	if verify {
+		if err := verifyTaskManualExecution(c.Request().Context(), execution); err != nil {
+			return controller.JSONBaseErrorReq(c, err)
+		}
+	}
+	return c.JSON(http.StatusOK, &GetTaskManualExecutionResV1{
+		BaseRes: controller.NewBaseReq(nil),
+		Data:    convertTaskManualExecutionToRes(execution),
+	})
+}
+
+// verifyTaskManualExecution verifies the changes of the schema and saves the result, the failure of the
+// verification such as the instance is not connectable is saved as the verify result too.
+func verifyTaskManualExecution(ctx context.Context, execution *model.TaskManualExecution) error {
+	s := model.GetStorage()
+	task, exist, err := s.GetTaskDetailById(strconv.Itoa(int(execution.TaskId)))
+	if err != nil {
+		return err
+	}
+	if !exist {
+		return errors.NewTaskNoExistOrNoAccessErr()
+	}
+	instance, exist, err := dms.GetInstancesById(ctx, fmt.Sprintf("%d", task.InstanceId))
+	if err != nil {
+		return err
+	}
+	if exist {
+		task.Instance = instance
+	}
+
+	status, result, err := server.VerifyManualExecution(log.NewEntry(), task)
+	if err != nil {
+		status, result = model.ManualExecutionVerifyStatusFailed, fmt.Sprintf("verify failed: %v", err)
+	}
+	now := time.Now()
+	execution.VerifyStatus = status
+	execution.VerifyResult = result
+	execution.VerifiedAt = &now
+	return s.UpdateTaskManualExecutionVerifyResult(execution)
+}
+
+type GetTaskManualExecutionResV1 struct {
+	controller.BaseRes
+	Data *TaskManualExecutionResV1 `json:"data"`
+}
+
+type TaskManualExecutionResV1 struct {
+	TaskId           uint       `json:"task_id"`
+	ExecutedAt       *time.Time `json:"executed_at"`
+	Executor         string     `json:"executor"`
+	RecordUserName   string     `json:"record_user_name"`
+	RecordedAt       time.Time  `json:"recorded_at"`
+	Remark           string     `json:"remark"`
+	ExecutionLog     string     `json:"execution_log"`
+	EvidenceFileName string     `json:"evidence_file_name"`
+	VerifyStatus     string     `json:"verify_status" enums:"passed,failed,skipped"`
+	VerifyResult     string     `json:"verify_result"`
+	VerifiedAt       *time.Time `json:"verified_at"`
+}
+
+func convertTaskManualExecutionToRes(execution *model.TaskManualExecution) *TaskManualExecutionResV1 {
+	return &TaskManualExecutionResV1{
+		TaskId:           execution.TaskId,
+		ExecutedAt:       execution.ExecutedAt,
+		Executor:         execution.Executor,
+		RecordUserName:   dms.GetUserNameWithDelTag(execution.RecordUserId),
+		RecordedAt:       execution.CreatedAt,
+		Remark:           execution.Remark,
+		ExecutionLog:     execution.ExecutionLog,
+		EvidenceFileName: execution.EvidenceFileName,
+		VerifyStatus:     execution.VerifyStatus,
+		VerifyResult:     execution.VerifyResult,
+		VerifiedAt:       execution.VerifiedAt,
+	}
+}
+
+// convertTaskManualExecutionToReportRows converts the evidence to the rows appended to the SQL report of the task,
+// the rows are padded to the columns of the report.
+func convertTaskManualExecutionToReportRows(ctx context.Context, execution *model.TaskManualExecution, columnCount int) [][]string {
+	formatTime := func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.Format(time.RFC3339)
+	}
+	rows := [][]string{
+		{locale.Bundle.LocalizeMsgByCtx(ctx, locale.TaskSQLReportManualExecution)},
+		{locale.Bundle.LocalizeMsgByCtx(ctx, locale.TaskSQLReportManualExecutor), execution.Executor},
+		{locale.Bundle.LocalizeMsgByCtx(ctx, locale.TaskSQLReportManualExecutedAt), formatTime(execution.ExecutedAt)},
+		{locale.Bundle.LocalizeMsgByCtx(ctx, locale.TaskSQLReportManualRecordUser), dms.GetUserNameWithDelTag(execution.RecordUserId)},
+		{locale.Bundle.LocalizeMsgByCtx(ctx, locale.TaskSQLReportManualRemark), execution.Remark},
+		{locale.Bundle.LocalizeMsgByCtx(ctx, locale.TaskSQLReportManualExecutionLog), execution.ExecutionLog},
+		{locale.Bundle.LocalizeMsgByCtx(ctx, locale.TaskSQLReportManualEvidenceFile), execution.EvidenceFileName},
+		{locale.Bundle.LocalizeMsgByCtx(ctx, locale.TaskSQLReportManualVerifyStatus), execution.VerifyStatus},
+		{locale.Bundle.LocalizeMsgByCtx(ctx, locale.TaskSQLReportManualVerifyResult), execution.VerifyResult},
+	}
+	// split the evidence from the SQLs by an empty row
+	rows = append([][]string{{}}, rows...)
+	for i, row := range rows {
+		for len(row) < columnCount {
+			row = append(row, "")
+		}
+		rows[i] = row
+	}
+	return rows
+}
+
+// getTaskManualExecution returns the manual execution of the task in the workflow, the current user is checked
+// whether can view or operate the workflow.
+func getTaskManualExecution(c echo.Context, operate bool) (*model.TaskManualExecution, error) {
+	projectUid, err := dms.GetPorjectUIDByName(context.TODO(), c.Param("project_name"))
+	if err != nil {
+		return nil, err
+	}
+	s := model.GetStorage()
+	workflow, err := dms.GetWorkflowDetailByWorkflowId(projectUid, c.Param("workflow_id"), s.GetWorkflowDetailWithoutInstancesByWorkflowID)
+	if err != nil {
+		return nil, err
+	}
+	if operate {
+		err = CheckCurrentUserCanOperateWorkflow(c, projectUid, workflow, []dmsV1.OpPermissionType{})
+	} else {
+		err = CheckCurrentUserCanViewWorkflow(c, projectUid, workflow, []dmsV1.OpPermissionType{dmsV1.OpPermissionTypeViewOthersWorkflow})
+	}
+	if err != nil {
+		return nil, err
+	}
+	taskId, err := FormatStringToInt(c.Param("task_id"))
+	if err != nil {
+		return nil, err
+	}
+	execution, exist, err := s.GetTaskManualExecutionByTaskId(uint(taskId))
+	if err != nil {
+		return nil, err
+	}
+	if !exist || execution.WorkflowId != workflow.WorkflowId {
+		return nil, errors.NewDataNotExistErr("manual execution of task %v is not exist", taskId)
+	}
+	return execution, nil
+}
+
+// GetTaskManualExecutionV1
+// @Summary 获取数据源手动上线凭证
+// @Description get the evidence of the task manually executed
+// @Tags workflow
+// @Id getTaskManualExecutionV1
+// @Security ApiKeyAuth
+// @Param project_name path string true "project name"
+// @Param workflow_id path string true "workflow id"
+// @Param task_id path string true "task id"
+// @Success 200 {object} v1.GetTaskManualExecutionResV1
+// @router /v1/projects/{project_name}/workflows/{workflow_id}/tasks/{task_id}/manual_execution [get]
+func GetTaskManualExecutionV1(c echo.Context) error {
+	execution, err := getTaskManualExecution(c, false)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	return c.JSON(http.StatusOK, &GetTaskManualExecutionResV1{
+		BaseRes: controller.NewBaseReq(nil),
+		Data:    convertTaskManualExecutionToRes(execution),
+	})
+}
+
+// DownloadTaskManualExecutionEvidenceFileV1
+// @Summary 下载数据源手动上线凭证文件
+// @Description download the evidence file of the task manually executed
+// @Tags workflow
+// @Id downloadTaskManualExecutionEvidenceFileV1
+// @Security ApiKeyAuth
+// @Param project_name path string true "project name"
+// @Param workflow_id path string true "workflow id"
+// @Param task_id path string true "task id"
+// @Success 200 {file} file "evidence file"
+// @router /v1/projects/{project_name}/workflows/{workflow_id}/tasks/{task_id}/manual_execution/evidence_file [get]
+func DownloadTaskManualExecutionEvidenceFileV1(c echo.Context) error {
+	execution, err := getTaskManualExecution(c, false)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	if execution.EvidenceUniqueName == "" {
+		return controller.JSONBaseErrorReq(c, errors.NewDataNotExistErr("there is no evidence file of task %v", execution.TaskId))
+	}
+	if execution.EvidenceFileHost != config.GetOptions().SqleOptions.ReportHost {
+		log.NewEntry().Debugf("try to reverse to sqle due to file host %v this host %v", execution.EvidenceFileHost, config.GetOptions().SqleOptions.ReportHost)
+		if err := ReverseToSqle(c, c.Request().URL.Path, execution.EvidenceFileHost); err != nil {
+			return controller.JSONBaseErrorReq(c, err)
+		}
+		return nil
+	}
+	return c.Attachment(model.DefaultFilePath(execution.EvidenceUniqueName), execution.EvidenceFileName)
+}
+
+// VerifyTaskManualExecutionV1
+// @Summary 校验数据源手动上线的变更
+// @Description verify the tables, columns and indexes changed by the DDLs of the task manually executed exist or not, only support MySQL
+// @Tags workflow
+// @Id verifyTaskManualExecutionV1
+// @Security ApiKeyAuth
+// @Param project_name path string true "project name"
+// @Param workflow_id path string true "workflow id"
+// @Param task_id path string true "task id"
+// @Success 200 {object} v1.GetTaskManualExecutionResV1
+// @router /v1/projects/{project_name}/workflows/{workflow_id}/tasks/{task_id}/manual_execution/verify [post]
+func VerifyTaskManualExecutionV1(c echo.Context) error {
+	execution, err := getTaskManualExecution(c, true)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	if err := verifyTaskManualExecution(c.Request().Context(), execution); err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	return c.JSON(http.StatusOK, &GetTaskManualExecutionResV1{
+		BaseRes: controller.NewBaseReq(nil),
+		Data:    convertTaskManualExecutionToRes(execution),
+	})
+}
@@ -2,6 +2,7 @@ package v1
 
 import (
 	"context"
+	"database/sql"
 	e "errors"
 	"fmt"
 	"mime"
@@ -55,12 +56,58 @@ type WorkflowTemplateDetailResV1 struct {
 }
 
 type WorkFlowStepTemplateResV1 struct {
-	Number               int      `json:"number"`
-	Typ                  string   `json:"type"`
-	Desc                 string   `json:"desc,omitempty"`
-	ApprovedByAuthorized bool     `json:"approved_by_authorized"`
-	ExecuteByAuthorized  bool     `json:"execute_by_authorized"`
-	Users                []string `json:"assignee_user_id_list"`
+	Number               int                        `json:"number"`
+	Typ                  string                     `json:"type"`
+	Desc                 string                     `json:"desc,omitempty"`
+	ApprovedByAuthorized bool                       `json:"approved_by_authorized"`
+	ExecuteByAuthorized  bool                       `json:"execute_by_authorized"`
+	Users                []string                   `json:"assignee_user_id_list"`
+	Condition            *WorkflowStepConditionV1   `json:"condition,omitempty"`
+	ApprovalMinCount     uint                       `json:"approval_min_count,omitempty"`
+	ApprovalGroups       []*WorkflowApprovalGroupV1 `json:"approval_group_list,omitempty"`
+	// the step is escalated to the escalation users when it waits for review more than the timeout
+	EscalationTimeoutMinutes uint     `json:"escalation_timeout_minutes,omitempty"`
+	EscalationUsers          []string `json:"escalation_user_id_list,omitempty"`
+}
+
+// WorkflowStepConditionV1 is the condition of the review step, the step applies only when all of the set conditions hold.
+type WorkflowStepConditionV1 struct {
+	AuditLevel              string   `json:"audit_level,omitempty" enums:"normal,notice,warn,error"`
+	SQLTypes                []string `json:"sql_type_list,omitempty" enums:"ddl,dml,dql"`
+	InstanceEnvironmentTags []string `json:"instance_environment_tag_list,omitempty"`
+	AffectedRowsMoreThan    *int64   `json:"affected_rows_more_than,omitempty"`
+}
+
+// WorkflowApprovalGroupV1 is approved when MinApprovals users of the group have approved the step,
+// the groups of a step are approved in parallel.
+type WorkflowApprovalGroupV1 struct {
+	Name         string   `json:"name"`
+	Users        []string `json:"assignee_user_id_list"`
+	MinApprovals uint     `json:"min_approvals"`
+}
+
+func convertWorkflowStepConditionToRes(condition *model.WorkflowStepCondition) *WorkflowStepConditionV1 {
+	if condition == nil || condition.IsEmpty() {
+		return nil
+	}
+	return &WorkflowStepConditionV1{
+		AuditLevel:              condition.AuditLevel,
+		SQLTypes:                condition.SQLTypes,
+		InstanceEnvironmentTags: condition.InstanceEnvironmentTags,
+		AffectedRowsMoreThan:    condition.AffectedRowsMoreThan,
+	}
+}
+
+func convertWorkflowApprovalGroupsToRes(groups model.WorkflowApprovalGroups) []*WorkflowApprovalGroupV1 {
+	res := make([]*WorkflowApprovalGroupV1, 0, len(groups))
+	for _, group := range groups {
+		res = append(res, &WorkflowApprovalGroupV1{
+			Name:         group.Name,
+			Users:        group.Users,
+			MinApprovals: group.MinApprovals,
+		})
+	}
+	return res
 }
 
 // @Summary 获取审批流程模板详情
@@ -85,11 +132,18 @@ func convertWorkflowTemplateToRes(template *model.WorkflowTemplate) *WorkflowTem
 	stepsRes := make([]*WorkFlowStepTemplateResV1, 0, len(template.Steps))
 	for _, step := range template.Steps {
 		stepRes := &WorkFlowStepTemplateResV1{
-			Number:               int(step.Number),
-			ApprovedByAuthorized: step.ApprovedByAuthorized.Bool,
-			ExecuteByAuthorized:  step.ExecuteByAuthorized.Bool,
-			Typ:                  step.Typ,
-			Desc:                 step.Desc,
+			Number:                   int(step.Number),
+			ApprovedByAuthorized:     step.ApprovedByAuthorized.Bool,
+			ExecuteByAuthorized:      step.ExecuteByAuthorized.Bool,
+			Typ:                      step.Typ,
+			Desc:                     step.Desc,
+			Condition:                convertWorkflowStepConditionToRes(step.Condition),
+			ApprovalMinCount:         step.ApprovalMinCount,
+			ApprovalGroups:           convertWorkflowApprovalGroupsToRes(step.ApprovalGroups),
+			EscalationTimeoutMinutes: step.EscalationTimeoutMinutes,
+		}
+		if step.EscalationUsers != "" {
+			stepRes.EscalationUsers = strings.Split(step.EscalationUsers, ",")
 		}
 		stepRes.Users = make([]string, 0)
 		if step.Users != "" {
@@ -113,6 +167,74 @@ type WorkFlowStepTemplateReqV1 struct {
 	ApprovedByAuthorized bool     `json:"approved_by_authorized"`
 	ExecuteByAuthorized  bool     `json:"execute_by_authorized"`
 	Users                []string `json:"assignee_user_id_list" form:"assignee_user_id_list"`
+	// the condition, the multiple approvals and the escalation are only supported by sql_review step
+	Condition                *WorkflowStepConditionV1   `json:"condition"`
+	ApprovalMinCount         uint                       `json:"approval_min_count"`
+	ApprovalGroups           []*WorkflowApprovalGroupV1 `json:"approval_group_list"`
+	EscalationTimeoutMinutes uint                       `json:"escalation_timeout_minutes"`
+	EscalationUsers          []string                   `json:"escalation_user_id_list"`
+}
+
+// convertWorkflowStepTemplatesReqToModel converts the steps of the request to the step templates numbered by the order,
+// the last step should be the only sql_execute step. It is used by the workflow template update of the enterprise edition.
+func convertWorkflowStepTemplatesReqToModel(steps []*WorkFlowStepTemplateReqV1) ([]*model.WorkflowStepTemplate, error) {
+	if len(steps) == 0 || steps[len(steps)-1].Type != model.WorkflowStepTypeSQLExecute {
+		return nil, fmt.Errorf("the last step of workflow template should be %v", model.WorkflowStepTypeSQLExecute)
+	}
+	stepTemplates := make([]*model.WorkflowStepTemplate, 0, len(steps))
+	for i, step := range steps {
+		if step.Type == model.WorkflowStepTypeSQLExecute && i != len(steps)-1 {
+			return nil, fmt.Errorf("only the last step of workflow template can be %v", model.WorkflowStepTypeSQLExecute)
+		}
+		if len(step.Users) == 0 && len(step.ApprovalGroups) == 0 && !step.ApprovedByAuthorized && !step.ExecuteByAuthorized {
+			return nil, fmt.Errorf("the assignees of step %v are required", i+1)
+		}
+		stepTemplate := &model.WorkflowStepTemplate{
+			Number:                   uint(i + 1),
+			Typ:                      step.Type,
+			Desc:                     step.Desc,
+			ApprovedByAuthorized:     sql.NullBool{Bool: step.ApprovedByAuthorized, Valid: true},
+			ExecuteByAuthorized:      sql.NullBool{Bool: step.ExecuteByAuthorized, Valid: true},
+			Users:                    strings.Join(step.Users, ","),
+			Condition:                convertWorkflowStepConditionReqToModel(step.Condition),
+			ApprovalMinCount:         step.ApprovalMinCount,
+			ApprovalGroups:           convertWorkflowApprovalGroupsReqToModel(step.ApprovalGroups),
+			EscalationTimeoutMinutes: step.EscalationTimeoutMinutes,
+			EscalationUsers:          strings.Join(step.EscalationUsers, ","),
+		}
+		if err := model.ValidateWorkflowStepTemplate(stepTemplate); err != nil {
+			return nil, fmt.Errorf("step %v is invalid: %v", i+1, err)
+		}
+		stepTemplates = append(stepTemplates, stepTemplate)
+	}
+	return stepTemplates, nil
+}
+
+func convertWorkflowStepConditionReqToModel(condition *WorkflowStepConditionV1) *model.WorkflowStepCondition {
+	if condition == nil {
+		return nil
+	}
+	return &model.WorkflowStepCondition{
+		AuditLevel:              condition.AuditLevel,
+		SQLTypes:                condition.SQLTypes,
+		InstanceEnvironmentTags: condition.InstanceEnvironmentTags,
+		AffectedRowsMoreThan:    condition.AffectedRowsMoreThan,
+	}
+}
+
+func convertWorkflowApprovalGroupsReqToModel(groups []*WorkflowApprovalGroupV1) model.WorkflowApprovalGroups {
+	if len(groups) == 0 {
+		return nil
+	}
+	res := make(model.WorkflowApprovalGroups, 0, len(groups))
+	for _, group := range groups {
+		res = append(res, &model.WorkflowApprovalGroup{
+			Name:         group.Name,
+			Users:        group.Users,
+			MinApprovals: group.MinApprovals,
+		})
+	}
+	return res
 }
 
 type UpdateWorkflowTemplateReqV1 struct {
@@ -144,7 +266,7 @@ type WorkflowStepResV1 struct {
 	Users         []string   `json:"assignee_user_name_list,omitempty"`
 	OperationUser string     `json:"operation_user_name,omitempty"`
 	OperationTime *time.Time `json:"operation_time,omitempty"`
-	State         string     `json:"state,omitempty" enums:"initialized,approved,rejected"`
+	State         string     `json:"state,omitempty" enums:"initialized,approved,rejected,skipped"`
 	Reason        string     `json:"reason,omitempty"`
 }
 
@@ -520,8 +642,8 @@ func CheckWorkflowCreationPrerequisites(c echo.Context, projectName string, task
 	}
 	// check task exist
 	taskIds := utils.RemoveDuplicateUint(taskIdsToBindWithWorkflow)
-	if len(taskIds) > MaximumDataSourceNum {
-		return nil, errors.New(errors.DataConflict, fmt.Errorf("the max task count of a workflow is %v", MaximumDataSourceNum))
+	if len(taskIds) > MaximumFanOutTaskNum {
+		return nil, errors.New(errors.DataConflict, fmt.Errorf("the max task count of a workflow is %v", MaximumFanOutTaskNum))
 	}
 	tasks, foundAllTasks, err := s.GetTasksByIds(taskIds)
 	if err != nil {
@@ -530,6 +652,16 @@ func CheckWorkflowCreationPrerequisites(c echo.Context, projectName string, task
 	if !foundAllTasks {
 		return nil, errors.NewTaskNoExistOrNoAccessErr()
 	}
+	// the tasks fanned out to the schemas are not limited by MaximumDataSourceNum
+	if len(taskIds) > MaximumDataSourceNum {
+		fanOut, err := isFanOutTasks(s, tasks)
+		if err != nil {
+			return nil, err
+		}
+		if !fanOut {
+			return nil, errors.New(errors.DataConflict, fmt.Errorf("the max task count of a workflow is %v", MaximumDataSourceNum))
+		}
+	}
 	// check instances exist
 	instanceIdsOfWorkflowTasks := make([]uint64, 0, len(tasks))
 	for _, task := range tasks {
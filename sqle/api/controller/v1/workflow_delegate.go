@@ -0,0 +1,169 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/actiontech/sqle/sqle/api/controller"
+	"github.com/actiontech/sqle/sqle/dms"
+	"github.com/actiontech/sqle/sqle/errors"
+	"github.com/actiontech/sqle/sqle/model"
+
+	"github.com/labstack/echo/v4"
+)
+
+type CreateWorkflowApprovalDelegateReqV1 struct {
+	DelegateUserId string    `json:"delegate_user_id" valid:"required"`
+	StartTime      time.Time `json:"start_time" valid:"required"`
+	EndTime        time.Time `json:"end_time" valid:"required"`
+	Reason         string    `json:"reason"`
+}
+
+// CreateWorkflowApprovalDelegateV1
+// @Summary 登记不在岗期间的工单审批委托人
+// @Description register the delegate user who reviews the workflows on behalf of the current user during the period
+// @Id createWorkflowApprovalDelegateV1
+// @Tags workflow
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param project_name path string true "project name"
+// @Param delegate body v1.CreateWorkflowApprovalDelegateReqV1 true "create workflow approval delegate request"
+// @Success 200 {object} controller.BaseRes
+// @router /v1/projects/{project_name}/workflow_approval_delegates [post]
+func CreateWorkflowApprovalDelegateV1(c echo.Context) error {
+	req := new(CreateWorkflowApprovalDelegateReqV1)
+	if err := controller.BindAndValidateReq(c, req); err != nil {
+		return err
+	}
+	projectUid, err := dms.GetPorjectUIDByName(context.TODO(), c.Param("project_name"))
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	userId := controller.GetUserID(c)
+	if req.DelegateUserId == userId {
+		return controller.JSONBaseErrorReq(c, errors.NewDataInvalidErr("the delegate user should not be yourself"))
+	}
+	if !req.EndTime.After(req.StartTime) {
+		return controller.JSONBaseErrorReq(c, errors.NewDataInvalidErr("the end time should be after the start time"))
+	}
+	if _, err := dms.GetUser(c.Request().Context(), req.DelegateUserId, controller.GetDMSServerAddress()); err != nil {
+		return controller.JSONBaseErrorReq(c, errors.NewDataNotExistErr("delegate user %v is not exist", req.DelegateUserId))
+	}
+
+	err = model.GetStorage().CreateWorkflowApprovalDelegate(&model.WorkflowApprovalDelegate{
+		ProjectId:      model.ProjectUID(projectUid),
+		UserId:         userId,
+		DelegateUserId: req.DelegateUserId,
+		StartTime:      req.StartTime,
+		EndTime:        req.EndTime,
+		Reason:         req.Reason,
+	})
+	return controller.JSONBaseErrorReq(c, err)
+}
+
+type GetWorkflowApprovalDelegatesResV1 struct {
+	controller.BaseRes
+	Data []*WorkflowApprovalDelegateResV1 `json:"data"`
+}
+
+type WorkflowApprovalDelegateResV1 struct {
+	Id               uint      `json:"workflow_approval_delegate_id"`
+	UserName         string    `json:"user_name"`
+	DelegateUserName string    `json:"delegate_user_name"`
+	StartTime        time.Time `json:"start_time"`
+	EndTime          time.Time `json:"end_time"`
+	Reason           string    `json:"reason"`
+	Active           bool      `json:"active"`
+}
+
+// GetWorkflowApprovalDelegatesV1
+// @Summary 获取工单审批委托列表
+// @Description get the workflow approval delegates of the current user, the project admin gets the delegates of all users
+// @Id getWorkflowApprovalDelegatesV1
+// @Tags workflow
+// @Security ApiKeyAuth
+// @Param project_name path string true "project name"
+// @Success 200 {object} v1.GetWorkflowApprovalDelegatesResV1
+// @router /v1/projects/{project_name}/workflow_approval_delegates [get]
+func GetWorkflowApprovalDelegatesV1(c echo.Context) error {
+	projectUid, err := dms.GetPorjectUIDByName(context.TODO(), c.Param("project_name"))
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	userId := controller.GetUserID(c)
+	up, err := dms.NewUserPermission(userId, projectUid)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	filterUserId := userId
+	if up.CanOpProject() {
+		filterUserId = ""
+	}
+
+	delegates, err := model.GetStorage().GetWorkflowApprovalDelegates(model.ProjectUID(projectUid), filterUserId)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	now := time.Now()
+	data := make([]*WorkflowApprovalDelegateResV1, 0, len(delegates))
+	for _, delegate := range delegates {
+		data = append(data, &WorkflowApprovalDelegateResV1{
+			Id:               delegate.ID,
+			UserName:         dms.GetUserNameWithDelTag(delegate.UserId),
+			DelegateUserName: dms.GetUserNameWithDelTag(delegate.DelegateUserId),
+			StartTime:        delegate.StartTime,
+			EndTime:          delegate.EndTime,
+			Reason:           delegate.Reason,
+			Active:           delegate.IsActive(now),
+		})
+	}
+	return c.JSON(http.StatusOK, &GetWorkflowApprovalDelegatesResV1{
+		BaseRes: controller.NewBaseReq(nil),
+		Data:    data,
+	})
+}
+
+// DeleteWorkflowApprovalDelegateV1
+// @Summary 删除工单审批委托
+// @Description delete the workflow approval delegate, the steps which have been delegated are not changed
+// @Id deleteWorkflowApprovalDelegateV1
+// @Tags workflow
+// @Security ApiKeyAuth
+// @Param project_name path string true "project name"
+// @Param workflow_approval_delegate_id path string true "workflow approval delegate id"
+// @Success 200 {object} controller.BaseRes
+// @router /v1/projects/{project_name}/workflow_approval_delegates/{workflow_approval_delegate_id}/ [delete]
+func DeleteWorkflowApprovalDelegateV1(c echo.Context) error {
+	projectUid, err := dms.GetPorjectUIDByName(context.TODO(), c.Param("project_name"))
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	id, err := strconv.ParseUint(c.Param("workflow_approval_delegate_id"), 10, 64)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, errors.New(errors.DataInvalid, fmt.Errorf("parse workflow approval delegate id failed: %v", err)))
+	}
+
+	s := model.GetStorage()
+	delegate, exist, err := s.GetWorkflowApprovalDelegateById(model.ProjectUID(projectUid), uint(id))
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	if !exist {
+		return controller.JSONBaseErrorReq(c, errors.NewDataNotExistErr("workflow approval delegate %v is not exist", id))
+	}
+	userId := controller.GetUserID(c)
+	if delegate.UserId != userId {
+		up, err := dms.NewUserPermission(userId, projectUid)
+		if err != nil {
+			return controller.JSONBaseErrorReq(c, err)
+		}
+		if !up.CanOpProject() {
+			return controller.JSONBaseErrorReq(c, errors.NewUserNotPermissionError("delete workflow approval delegate"))
+		}
+	}
+	return controller.JSONBaseErrorReq(c, s.DeleteWorkflowApprovalDelegate(delegate))
+}
@@ -0,0 +1,290 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	dmsV1 "github.com/actiontech/dms/pkg/dms-common/api/dms/v1"
+	"github.com/actiontech/sqle/sqle/api/controller"
+	"github.com/actiontech/sqle/sqle/dms"
+	"github.com/actiontech/sqle/sqle/errors"
+	"github.com/actiontech/sqle/sqle/model"
+	"github.com/actiontech/sqle/sqle/server"
+
+	"github.com/labstack/echo/v4"
+)
+
+type WorkflowExecCheckV1 struct {
+	Stage string `json:"stage" enums:"pre,post" valid:"required,oneof=pre post"`
+	Type  string `json:"type" enums:"sql_assertion,webhook,long_transaction" valid:"required,oneof=sql_assertion webhook long_transaction"`
+	Name  string `json:"name" valid:"required"`
+	// the instance of the workflow to check, all the instances of the workflow are checked if it is empty
+	InstanceName string `json:"instance_name"`
+	// sql_assertion: the first column of the first row returned by the query is compared with the expected value
+	SQL      string `json:"sql"`
+	Operator string `json:"operator" enums:"=,!=,>,>=,<,<="`
+	Expected string `json:"expected"`
+	// webhook: the check is passed if the POST request to the URL responds 2xx
+	URL string `json:"url"`
+	// long_transaction: the check is passed if there is no transaction lasting at least the seconds, only supports MySQL
+	MaxTransactionSeconds uint `json:"max_transaction_seconds"`
+	// roll back the task if the post check fails
+	RollbackOnFailure bool `json:"rollback_on_failure"`
+}
+
+// validate checks the check is valid and can be run on the instances of the workflow, instanceDBTypes is the db
+// type of the instances of the workflow by the instance name.
+func (c *WorkflowExecCheckV1) validate(instanceDBTypes map[string]string) error {
+	if c.InstanceName != "" {
+		if _, ok := instanceDBTypes[c.InstanceName]; !ok {
+			return fmt.Errorf("the instance %v of check %v is not in the workflow", c.InstanceName, c.Name)
+		}
+	}
+	for instanceName, dbType := range instanceDBTypes {
+		if c.InstanceName != "" && c.InstanceName != instanceName {
+			continue
+		}
+		if err := server.CheckExecCheckTypeSupported(c.Type, dbType); err != nil {
+			return fmt.Errorf("check %v can't be run on instance %v: %v", c.Name, instanceName, err)
+		}
+		if c.Type == model.WorkflowExecCheckTypeSQLAssertion && c.SQL != "" {
+			if err := server.CheckExecCheckSQLAssertion(dbType, c.SQL); err != nil {
+				return fmt.Errorf("the sql of check %v is invalid on instance %v: %v", c.Name, instanceName, err)
+			}
+		}
+	}
+	switch c.Type {
+	case model.WorkflowExecCheckTypeSQLAssertion:
+		if c.SQL == "" {
+			return fmt.Errorf("the sql of check %v is empty", c.Name)
+		}
+		switch c.Operator {
+		case "=", "!=", ">", ">=", "<", "<=":
+		default:
+			return fmt.Errorf("the operator %v of check %v is invalid", c.Operator, c.Name)
+		}
+	case model.WorkflowExecCheckTypeWebhook:
+		if c.URL == "" {
+			return fmt.Errorf("the url of check %v is empty", c.Name)
+		}
+		if err := server.CheckExecCheckWebhookURL(c.URL); err != nil {
+			return fmt.Errorf("the url of check %v is not allowed: %v", c.Name, err)
+		}
+	case model.WorkflowExecCheckTypeLongTransaction:
+		if c.MaxTransactionSeconds == 0 {
+			return fmt.Errorf("the max transaction seconds of check %v is zero", c.Name)
+		}
+	}
+	if c.RollbackOnFailure && c.Stage != model.WorkflowExecCheckStagePost {
+		return fmt.Errorf("only the post check %v can roll back the task", c.Name)
+	}
+	return nil
+}
+
+type UpdateWorkflowExecChecksReqV1 struct {
+	Checks []*WorkflowExecCheckV1 `json:"checks" valid:"dive"`
+}
+
+// UpdateWorkflowExecChecksV1
+// @Summary 更新工单上线前后的检查
+// @Description replace the pre-execution and post-execution checks of the workflow before the workflow is approved, a failed pre check blocks the execution of the task, a failed post check marks the task and rolls back the task if required. The webhook url must be in the allowed hosts of the config
+// @Id updateWorkflowExecChecksV1
+// @Tags workflow
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param project_name path string true "project name"
+// @Param workflow_id path string true "workflow id"
+// @Param exec_checks body v1.UpdateWorkflowExecChecksReqV1 true "update workflow exec checks request"
+// @Success 200 {object} controller.BaseRes
+// @router /v1/projects/{project_name}/workflows/{workflow_id}/exec_checks [put]
+func UpdateWorkflowExecChecksV1(c echo.Context) error {
+	req := new(UpdateWorkflowExecChecksReqV1)
+	if err := controller.BindAndValidateReq(c, req); err != nil {
+		return err
+	}
+	projectUid, err := dms.GetPorjectUIDByName(context.TODO(), c.Param("project_name"))
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	s := model.GetStorage()
+	workflow, err := dms.GetWorkflowDetailByWorkflowId(projectUid, c.Param("workflow_id"), s.GetWorkflowDetailWithoutInstancesByWorkflowID)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	if err := CheckCurrentUserCanOperateWorkflow(c, projectUid, workflow, []dmsV1.OpPermissionType{}); err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	// the checks are approved with the workflow, so they are locked once any step of the workflow is approved
+	if err := checkWorkflowExecChecksEditable(workflow); err != nil {
+		return controller.JSONBaseErrorReq(c, errors.New(errors.DataInvalid, err))
+	}
+	instanceDBTypes, err := getWorkflowInstanceDBTypes(c.Request().Context(), projectUid, workflow)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	for _, check := range req.Checks {
+		if err := check.validate(instanceDBTypes); err != nil {
+			return controller.JSONBaseErrorReq(c, errors.New(errors.DataInvalid, err))
+		}
+	}
+
+	checks := make([]*model.WorkflowExecCheck, 0, len(req.Checks))
+	for _, check := range req.Checks {
+		checks = append(checks, &model.WorkflowExecCheck{
+			Stage:                 check.Stage,
+			Type:                  check.Type,
+			Name:                  check.Name,
+			InstanceName:          check.InstanceName,
+			SQL:                   check.SQL,
+			Operator:              check.Operator,
+			Expected:              check.Expected,
+			URL:                   check.URL,
+			MaxTransactionSeconds: check.MaxTransactionSeconds,
+			RollbackOnFailure:     check.RollbackOnFailure,
+		})
+	}
+	return controller.JSONBaseErrorReq(c, s.ReplaceWorkflowExecChecks(workflow.WorkflowId, checks))
+}
+
+func checkWorkflowExecChecksEditable(workflow *model.Workflow) error {
+	switch workflow.Record.Status {
+	case model.WorkflowStatusWaitForAudit:
+		for _, step := range workflow.Record.Steps {
+			if step.State == model.WorkflowStepStateApprove || step.ApprovedUsers != "" {
+				return fmt.Errorf("the checks can't be changed after the workflow is approved")
+			}
+		}
+	case model.WorkflowStatusReject:
+	default:
+		return fmt.Errorf("the checks can't be changed when the workflow is %v", workflow.Record.Status)
+	}
+	return nil
+}
+
+func getWorkflowInstanceDBTypes(ctx context.Context, projectUid string, workflow *model.Workflow) (map[string]string, error) {
+	instances, err := dms.GetInstancesInProject(ctx, projectUid)
+	if err != nil {
+		return nil, err
+	}
+	instanceMap := make(map[uint64]*model.Instance, len(instances))
+	for _, instance := range instances {
+		instanceMap[instance.ID] = instance
+	}
+	dbTypes := make(map[string]string, len(workflow.Record.InstanceRecords))
+	for _, record := range workflow.Record.InstanceRecords {
+		if instance, ok := instanceMap[record.InstanceId]; ok {
+			dbTypes[instance.Name] = instance.DbType
+		}
+	}
+	return dbTypes, nil
+}
+
+type GetWorkflowExecChecksResV1 struct {
+	controller.BaseRes
+	Data *WorkflowExecChecksResV1 `json:"data"`
+}
+
+type WorkflowExecChecksResV1 struct {
+	Checks []*WorkflowExecCheckV1           `json:"checks"`
+	Tasks  []*WorkflowTaskExecCheckResultV1 `json:"tasks"`
+}
+
+type WorkflowTaskExecCheckResultV1 struct {
+	TaskId          uint                         `json:"task_id"`
+	ExecCheckStatus string                       `json:"exec_check_status" enums:"passed,pre_check_failed,post_check_failed"`
+	Results         []*WorkflowExecCheckResultV1 `json:"results"`
+}
+
+type WorkflowExecCheckResultV1 struct {
+	Stage     string    `json:"stage" enums:"pre,post"`
+	Type      string    `json:"type" enums:"sql_assertion,webhook,long_transaction"`
+	Name      string    `json:"name"`
+	Passed    bool      `json:"passed"`
+	Message   string    `json:"message"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// GetWorkflowExecChecksV1
+// @Summary 获取工单上线前后的检查及检查结果
+// @Description get the pre-execution and post-execution checks of the workflow and the check results of the tasks
+// @Id getWorkflowExecChecksV1
+// @Tags workflow
+// @Security ApiKeyAuth
+// @Param project_name path string true "project name"
+// @Param workflow_id path string true "workflow id"
+// @Success 200 {object} v1.GetWorkflowExecChecksResV1
+// @router /v1/projects/{project_name}/workflows/{workflow_id}/exec_checks [get]
+func GetWorkflowExecChecksV1(c echo.Context) error {
+	projectUid, err := dms.GetPorjectUIDByName(context.TODO(), c.Param("project_name"))
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	s := model.GetStorage()
+	workflow, err := dms.GetWorkflowDetailByWorkflowId(projectUid, c.Param("workflow_id"), s.GetWorkflowDetailWithoutInstancesByWorkflowID)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	if err := CheckCurrentUserCanViewWorkflow(c, projectUid, workflow, []dmsV1.OpPermissionType{dmsV1.OpPermissionTypeViewOthersWorkflow}); err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+
+	checks, err := s.GetWorkflowExecChecks(workflow.WorkflowId)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	data := &WorkflowExecChecksResV1{
+		Checks: make([]*WorkflowExecCheckV1, 0, len(checks)),
+		Tasks:  make([]*WorkflowTaskExecCheckResultV1, 0, len(workflow.Record.InstanceRecords)),
+	}
+	for _, check := range checks {
+		data.Checks = append(data.Checks, &WorkflowExecCheckV1{
+			Stage:                 check.Stage,
+			Type:                  check.Type,
+			Name:                  check.Name,
+			InstanceName:          check.InstanceName,
+			SQL:                   check.SQL,
+			Operator:              check.Operator,
+			Expected:              check.Expected,
+			URL:                   check.URL,
+			MaxTransactionSeconds: check.MaxTransactionSeconds,
+			RollbackOnFailure:     check.RollbackOnFailure,
+		})
+	}
+
+	taskIds := make([]uint, 0, len(workflow.Record.InstanceRecords))
+	tasks := make(map[uint]*WorkflowTaskExecCheckResultV1, len(workflow.Record.InstanceRecords))
+	for _, record := range workflow.Record.InstanceRecords {
+		task := &WorkflowTaskExecCheckResultV1{TaskId: record.TaskId, Results: []*WorkflowExecCheckResultV1{}}
+		if record.Task != nil {
+			task.ExecCheckStatus = record.Task.ExecCheckStatus
+		}
+		taskIds = append(taskIds, record.TaskId)
+		tasks[record.TaskId] = task
+		data.Tasks = append(data.Tasks, task)
+	}
+	results, err := s.GetWorkflowExecCheckResultsByTaskIds(taskIds)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	for _, result := range results {
+		task, ok := tasks[result.TaskId]
+		if !ok {
+			continue
+		}
+		task.Results = append(task.Results, &WorkflowExecCheckResultV1{
+			Stage:     result.Stage,
+			Type:      result.Type,
+			Name:      result.Name,
+			Passed:    result.Passed,
+			Message:   result.Message,
+			CheckedAt: result.CheckedAt,
+		})
+	}
+	return c.JSON(http.StatusOK, &GetWorkflowExecChecksResV1{
+		BaseRes: controller.NewBaseReq(nil),
+		Data:    data,
+	})
+}
@@ -0,0 +1,224 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	dmsV1 "github.com/actiontech/dms/pkg/dms-common/api/dms/v1"
+	"github.com/actiontech/sqle/sqle/api/controller"
+	"github.com/actiontech/sqle/sqle/dms"
+	"github.com/actiontech/sqle/sqle/errors"
+	"github.com/actiontech/sqle/sqle/model"
+	"github.com/actiontech/sqle/sqle/server"
+
+	"github.com/labstack/echo/v4"
+)
+
+type WorkflowTaskDependencyV1 struct {
+	TaskId    uint   `json:"task_id" valid:"required"`
+	DependsOn []uint `json:"depends_on"`
+}
+
+type UpdateWorkflowExecPlanReqV1 struct {
+	// the max number of the tasks executed at the same time, 0 means no limit
+	MaxConcurrency uint `json:"max_concurrency"`
+	// don't start any other task after a task fails, otherwise only the tasks depending on the failed task are not started
+	StopOnFailure    bool                        `json:"stop_on_failure"`
+	TaskDependencies []*WorkflowTaskDependencyV1 `json:"task_dependencies" valid:"dive"`
+}
+
+// UpdateWorkflowExecPlanV1
+// @Summary 更新工单上线编排
+// @Description set the execution plan of the workflow, the tasks are executed after the tasks they depend on are executed successfully, and at most max_concurrency tasks are executed at the same time
+// @Id updateWorkflowExecPlanV1
+// @Tags workflow
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param project_name path string true "project name"
+// @Param workflow_id path string true "workflow id"
+// @Param exec_plan body v1.UpdateWorkflowExecPlanReqV1 true "update workflow exec plan request"
+// @Success 200 {object} controller.BaseRes
+// @router /v1/projects/{project_name}/workflows/{workflow_id}/exec_plan [put]
+func UpdateWorkflowExecPlanV1(c echo.Context) error {
+	req := new(UpdateWorkflowExecPlanReqV1)
+	if err := controller.BindAndValidateReq(c, req); err != nil {
+		return err
+	}
+	projectUid, err := dms.GetPorjectUIDByName(context.TODO(), c.Param("project_name"))
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	s := model.GetStorage()
+	workflow, err := dms.GetWorkflowDetailByWorkflowId(projectUid, c.Param("workflow_id"), s.GetWorkflowDetailWithoutInstancesByWorkflowID)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	if err := CheckCurrentUserCanOperateWorkflow(c, projectUid, workflow, []dmsV1.OpPermissionType{}); err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	switch workflow.Record.Status {
+	case model.WorkflowStatusWaitForAudit, model.WorkflowStatusWaitForExecution, model.WorkflowStatusReject:
+	default:
+		return controller.JSONBaseErrorReq(c, errors.New(errors.DataInvalid,
+			fmt.Errorf("the execution plan can't be changed when the workflow is %v", workflow.Record.Status)))
+	}
+
+	plan := &model.WorkflowExecPlan{
+		WorkflowId:       workflow.WorkflowId,
+		MaxConcurrency:   req.MaxConcurrency,
+		StopOnFailure:    req.StopOnFailure,
+		TaskDependencies: make(model.WorkflowTaskDependencies, 0, len(req.TaskDependencies)),
+	}
+	for _, dependency := range req.TaskDependencies {
+		plan.TaskDependencies = append(plan.TaskDependencies, model.WorkflowTaskDependency{
+			TaskId:    dependency.TaskId,
+			DependsOn: dependency.DependsOn,
+		})
+	}
+	if err := server.ValidateWorkflowExecPlan(workflow, plan); err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	return controller.JSONBaseErrorReq(c, s.SaveWorkflowExecPlan(plan))
+}
+
+// DeleteWorkflowExecPlanV1
+// @Summary 删除工单上线编排
+// @Description delete the execution plan of the workflow, the tasks are executed as before
+// @Id deleteWorkflowExecPlanV1
+// @Tags workflow
+// @Security ApiKeyAuth
+// @Param project_name path string true "project name"
+// @Param workflow_id path string true "workflow id"
+// @Success 200 {object} controller.BaseRes
+// @router /v1/projects/{project_name}/workflows/{workflow_id}/exec_plan [delete]
+func DeleteWorkflowExecPlanV1(c echo.Context) error {
+	projectUid, err := dms.GetPorjectUIDByName(context.TODO(), c.Param("project_name"))
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	s := model.GetStorage()
+	workflow, err := dms.GetWorkflowDetailByWorkflowId(projectUid, c.Param("workflow_id"), s.GetWorkflowDetailWithoutInstancesByWorkflowID)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	if err := CheckCurrentUserCanOperateWorkflow(c, projectUid, workflow, []dmsV1.OpPermissionType{}); err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	if workflow.Record.Status == model.WorkflowStatusExecuting {
+		return controller.JSONBaseErrorReq(c, errors.New(errors.DataInvalid,
+			fmt.Errorf("the execution plan can't be deleted when the workflow is executing")))
+	}
+	return controller.JSONBaseErrorReq(c, s.DeleteWorkflowExecPlan(workflow.WorkflowId))
+}
+
+type GetWorkflowExecPlanResV1 struct {
+	controller.BaseRes
+	Data *WorkflowExecPlanResV1 `json:"data"`
+}
+
+type WorkflowExecPlanResV1 struct {
+	MaxConcurrency uint                         `json:"max_concurrency"`
+	StopOnFailure  bool                         `json:"stop_on_failure"`
+	Tasks          []*WorkflowExecPlanTaskResV1 `json:"tasks"`
+}
+
+type WorkflowExecPlanTaskResV1 struct {
+	TaskId       uint   `json:"task_id"`
+	InstanceName string `json:"instance_name"`
+	Status       string `json:"status" enums:"wait_for_audit,wait_for_execution,exec_scheduled,exec_failed,exec_succeeded,executing,manually_executed,terminating,terminate_succeeded,terminate_failed"`
+	DependsOn    []uint `json:"depends_on"`
+}
+
+// GetWorkflowExecPlanV1
+// @Summary 获取工单上线编排
+// @Description get the execution plan of the workflow and the execution status of the tasks
+// @Id getWorkflowExecPlanV1
+// @Tags workflow
+// @Security ApiKeyAuth
+// @Param project_name path string true "project name"
+// @Param workflow_id path string true "workflow id"
+// @Success 200 {object} v1.GetWorkflowExecPlanResV1
+// @router /v1/projects/{project_name}/workflows/{workflow_id}/exec_plan [get]
+func GetWorkflowExecPlanV1(c echo.Context) error {
+	projectUid, err := dms.GetPorjectUIDByName(context.TODO(), c.Param("project_name"))
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	s := model.GetStorage()
+	workflow, err := dms.GetWorkflowDetailByWorkflowId(projectUid, c.Param("workflow_id"), s.GetWorkflowDetailWithoutInstancesByWorkflowID)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	if err := CheckCurrentUserCanViewWorkflow(c, projectUid, workflow, []dmsV1.OpPermissionType{dmsV1.OpPermissionTypeViewOthersWorkflow}); err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+
+	plan, exist, err := s.GetWorkflowExecPlan(workflow.WorkflowId)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	if !exist {
+		return controller.JSONBaseErrorReq(c, errors.NewDataNotExistErr("execution plan of workflow %v is not exist", workflow.WorkflowId))
+	}
+
+	data := &WorkflowExecPlanResV1{
+		MaxConcurrency: plan.MaxConcurrency,
+		StopOnFailure:  plan.StopOnFailure,
+		Tasks:          make([]*WorkflowExecPlanTaskResV1, 0, len(workflow.Record.InstanceRecords)),
+	}
+	for _, record := range workflow.Record.InstanceRecords {
+		task := &WorkflowExecPlanTaskResV1{
+			TaskId:    record.TaskId,
+			DependsOn: plan.DependsOn(record.TaskId),
+		}
+		if record.Instance != nil {
+			task.InstanceName = record.Instance.Name
+		}
+		if record.Task != nil {
+			task.Status = GetTaskStatusRes(workflow.Record.Status, record.Task.Status, record.ScheduledAt)
+		}
+		if task.DependsOn == nil {
+			task.DependsOn = []uint{}
+		}
+		data.Tasks = append(data.Tasks, task)
+	}
+	return c.JSON(http.StatusOK, &GetWorkflowExecPlanResV1{
+		BaseRes: controller.NewBaseReq(nil),
+		Data:    data,
+	})
+}
+
+// ResumeWorkflowExecutionV1
+// @Summary 从失败处继续上线工单
+// @Description resume the execution of the workflow with an execution plan, the failed tasks are executed from the failed SQLs, then the tasks not started are executed by the plan
+// @Id resumeWorkflowExecutionV1
+// @Tags workflow
+// @Security ApiKeyAuth
+// @Param project_name path string true "project name"
+// @Param workflow_id path string true "workflow id"
+// @Success 200 {object} controller.BaseRes
+// @router /v1/projects/{project_name}/workflows/{workflow_id}/exec_plan/resume [post]
+func ResumeWorkflowExecutionV1(c echo.Context) error {
+	projectUid, err := dms.GetPorjectUIDByName(context.TODO(), c.Param("project_name"))
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	s := model.GetStorage()
+	workflow, err := dms.GetWorkflowDetailByWorkflowId(projectUid, c.Param("workflow_id"), s.GetWorkflowDetailWithoutInstancesByWorkflowID)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	if err := CheckCurrentUserCanOperateWorkflow(c, projectUid, workflow, []dmsV1.OpPermissionType{dmsV1.OpPermissionTypeExecuteWorkflow}); err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	user, err := controller.GetCurrentUser(c, dms.GetUser)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	if _, err := server.ResumeWorkflowExecution(workflow, user); err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	return c.JSON(http.StatusOK, controller.NewBaseReq(nil))
+}
@@ -0,0 +1,77 @@
+package v1
+
+import (
+	"testing"
+
+	"github.com/actiontech/sqle/sqle/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertWorkflowStepTemplatesReqToModel(t *testing.T) {
+	affectedRows := int64(1000)
+	req := []*WorkFlowStepTemplateReqV1{
+		{
+			Type:  model.WorkflowStepTypeSQLReview,
+			Users: []string{"1001"},
+			Condition: &WorkflowStepConditionV1{
+				AuditLevel:           "error",
+				SQLTypes:             []string{"ddl"},
+				AffectedRowsMoreThan: &affectedRows,
+			},
+			ApprovalMinCount:         1,
+			EscalationTimeoutMinutes: 30,
+			EscalationUsers:          []string{"1002", "1003"},
+		},
+		{
+			Type: model.WorkflowStepTypeSQLReview,
+			ApprovalGroups: []*WorkflowApprovalGroupV1{
+				{Name: "dba", Users: []string{"1004", "1005"}, MinApprovals: 2},
+				{Name: "owner", Users: []string{"1006"}, MinApprovals: 1},
+			},
+		},
+		{
+			Type:                model.WorkflowStepTypeSQLExecute,
+			ExecuteByAuthorized: true,
+		},
+	}
+	steps, err := convertWorkflowStepTemplatesReqToModel(req)
+	assert.NoError(t, err)
+	assert.Len(t, steps, 3)
+	assert.Equal(t, uint(1), steps[0].Number)
+	assert.Equal(t, "error", steps[0].Condition.AuditLevel)
+	assert.Equal(t, uint(30), steps[0].EscalationTimeoutMinutes)
+	assert.Equal(t, "1002,1003", steps[0].EscalationUsers)
+	assert.Len(t, steps[1].ApprovalGroups, 2)
+	assert.Equal(t, uint(3), steps[2].Number)
+	assert.True(t, steps[2].ExecuteByAuthorized.Bool)
+
+	// the saved steps are returned as they are requested
+	res := convertWorkflowTemplateToRes(&model.WorkflowTemplate{Steps: steps})
+	assert.Len(t, res.Steps, 3)
+	assert.Equal(t, req[0].Condition, res.Steps[0].Condition)
+	assert.Equal(t, req[0].EscalationTimeoutMinutes, res.Steps[0].EscalationTimeoutMinutes)
+	assert.Equal(t, req[0].EscalationUsers, res.Steps[0].EscalationUsers)
+	assert.Equal(t, req[0].ApprovalMinCount, res.Steps[0].ApprovalMinCount)
+	assert.Equal(t, req[1].ApprovalGroups, res.Steps[1].ApprovalGroups)
+	assert.Equal(t, []string{"1001"}, res.Steps[0].Users)
+}
+
+func TestConvertWorkflowStepTemplatesReqToModelInvalid(t *testing.T) {
+	execute := &WorkFlowStepTemplateReqV1{Type: model.WorkflowStepTypeSQLExecute, ExecuteByAuthorized: true}
+	for _, steps := range [][]*WorkFlowStepTemplateReqV1{
+		{},
+		// the last step is not sql_execute
+		{{Type: model.WorkflowStepTypeSQLReview, Users: []string{"1001"}}},
+		// more than one sql_execute step
+		{execute, execute},
+		// no assignee
+		{{Type: model.WorkflowStepTypeSQLReview}, execute},
+		// escalation without escalation users
+		{{Type: model.WorkflowStepTypeSQLReview, Users: []string{"1001"}, EscalationTimeoutMinutes: 10}, execute},
+		// escalation of sql_execute step
+		{{Type: model.WorkflowStepTypeSQLExecute, Users: []string{"1001"}, EscalationTimeoutMinutes: 10, EscalationUsers: []string{"1002"}}},
+	} {
+		_, err := convertWorkflowStepTemplatesReqToModel(steps)
+		assert.Error(t, err)
+	}
+}
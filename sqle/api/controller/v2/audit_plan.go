@@ -534,8 +534,10 @@ func UploadInstanceAuditPlanSQLs(c echo.Context) error {
 	}
 
 	l := log.NewEntry()
+	auditPlan := auditplan.ConvertModelToAuditPlanV2(ap)
+	run := auditplan.NewCollectionRun(auditPlan)
 	defer func() {
-		auditplan.ProcessAuditPlanStatusAndLogError(l, ap.ID, ap.InstanceID, ap.Type, err)
+		run.Finish(l, err)
 	}()
 	// 当scannerd执行出现错误时，将任务状态改为异常并日志打印错误信息
 	if req.ErrorMessage != "" && len(req.SQLs) == 0 {
@@ -547,7 +549,7 @@ func UploadInstanceAuditPlanSQLs(c echo.Context) error {
 		return controller.JSONBaseErrorReq(c, err)
 	}
 	if exist {
-		ap.Instance = instance
+		auditPlan.Instance = instance
 	} else {
 		l.Errorf("instance not found, instance id: %s", ap.InstanceID)
 	}
@@ -560,11 +562,12 @@ func UploadInstanceAuditPlanSQLs(c echo.Context) error {
 	if err != nil {
 		return controller.JSONBaseErrorReq(c, err)
 	}
+	run.SetSQLCount(len(sqls))
 	err = s.UpdateAuditPlanLastCollectionTime(ap.ID, time.Now())
 	if err != nil {
 		l.Errorf("update audit plan last collection time failed, error : %v", err)
 	}
-	err = auditplan.UploadSQLsV2(l, auditplan.ConvertModelToAuditPlanV2(ap), sqls)
+	err = auditplan.UploadSQLsV2(l, auditPlan, sqls)
 	if err != nil {
 		return controller.JSONBaseErrorReq(c, errors.NewAuditPlanExecuteExtractErr(err, ap.InstanceID, ap.Type))
 	}
@@ -47,6 +47,33 @@ type AuditTaskSQLResV2 struct {
 	BackupStatus                string                        `json:"backup_status" enums:"waiting_for_execution,executing,failed,succeed"`
 	BackupResult                string                        `json:"backup_result"`
 	AssociatedRollbackWorkflows []*AssociatedRollbackWorkflow `json:"associated_rollback_workflows"`
+	Binlog                      *TaskSQLBinlogResV2           `json:"binlog,omitempty"`
+	// execution duration in milliseconds, the SQLs executed in a transaction or batch share the duration
+	ExecDuration  uint64                     `json:"exec_duration"`
+	ExecWarnings  []*TaskSQLExecWarningResV2 `json:"exec_warnings,omitempty"`
+	ResultPreview *TaskSQLResultPreviewResV2 `json:"result_preview,omitempty"`
+}
+
+type TaskSQLExecWarningResV2 struct {
+	Level   string `json:"level"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// TaskSQLResultPreviewResV2 is the first rows returned by the executed SQL
+type TaskSQLResultPreviewResV2 struct {
+	Columns   []string   `json:"columns"`
+	Rows      [][]string `json:"rows"`
+	Truncated bool       `json:"truncated"`
+}
+
+type TaskSQLBinlogResV2 struct {
+	StartBinlogFile string `json:"start_binlog_file"`
+	StartBinlogPos  int64  `json:"start_binlog_pos"`
+	StartGtidSet    string `json:"start_gtid_set"`
+	EndBinlogFile   string `json:"end_binlog_file"`
+	EndBinlogPos    int64  `json:"end_binlog_pos"`
+	EndGtidSet      string `json:"end_gtid_set"`
 }
 
 type AssociatedRollbackWorkflow struct {
@@ -158,6 +185,31 @@ func GetTaskSQLs(c echo.Context) error {
 			BackupStatus:                backupTaskMap.GetBackupStatus(taskSQL.Id),
 			BackupResult:                backupTaskMap.GetBackupResult(taskSQL.Id),
 			AssociatedRollbackWorkflows: associatedRollbackWorkflowsMap[taskSQL.Id],
+			ExecDuration:                taskSQL.ExecDuration,
+		}
+		for _, warning := range taskSQL.ExecWarnings {
+			taskSQLRes.ExecWarnings = append(taskSQLRes.ExecWarnings, &TaskSQLExecWarningResV2{
+				Level:   warning.Level,
+				Code:    warning.Code,
+				Message: warning.Message,
+			})
+		}
+		if len(taskSQL.ResultPreview.Columns) > 0 {
+			taskSQLRes.ResultPreview = &TaskSQLResultPreviewResV2{
+				Columns:   taskSQL.ResultPreview.Columns,
+				Rows:      taskSQL.ResultPreview.Rows,
+				Truncated: taskSQL.ResultPreview.Truncated,
+			}
+		}
+		if taskSQL.StartBinlogFile.String != "" {
+			taskSQLRes.Binlog = &TaskSQLBinlogResV2{
+				StartBinlogFile: taskSQL.StartBinlogFile.String,
+				StartBinlogPos:  taskSQL.StartBinlogPos.Int64,
+				StartGtidSet:    taskSQL.StartGtidSet.String,
+				EndBinlogFile:   taskSQL.EndBinlogFile.String,
+				EndBinlogPos:    taskSQL.EndBinlogPos.Int64,
+				EndGtidSet:      taskSQL.EndGtidSet.String,
+			}
 		}
 		for i := range taskSQL.AuditResults {
 			ar := taskSQL.AuditResults[i]
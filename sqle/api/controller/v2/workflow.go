@@ -14,6 +14,7 @@ import (
 	v1 "github.com/actiontech/sqle/sqle/api/controller/v1"
 	"github.com/actiontech/sqle/sqle/dms"
 	"github.com/actiontech/sqle/sqle/errors"
+	"github.com/actiontech/sqle/sqle/log"
 	"github.com/actiontech/sqle/sqle/model"
 	"github.com/actiontech/sqle/sqle/notification"
 	"github.com/actiontech/sqle/sqle/pkg/im"
@@ -35,8 +36,28 @@ type WorkflowStepResV2 struct {
 	Users         []string   `json:"assignee_user_name_list,omitempty"`
 	OperationUser string     `json:"operation_user_name,omitempty"`
 	OperationTime *time.Time `json:"operation_time,omitempty"`
-	State         string     `json:"state,omitempty" enums:"initialized,approved,rejected"`
+	State         string     `json:"state,omitempty" enums:"initialized,approved,rejected,skipped"`
 	Reason        string     `json:"reason,omitempty"`
+	// the users who have approved the step which requires multiple approvals
+	ApprovedUsers    []string                       `json:"approved_user_name_list,omitempty"`
+	ApprovalMinCount uint                           `json:"approval_min_count,omitempty"`
+	ApprovalGroups   []*WorkflowStepApprovalGroupV2 `json:"approval_group_list,omitempty"`
+	// the delegation and escalation events of the step
+	Events []*WorkflowStepEventResV2 `json:"event_list,omitempty"`
+}
+
+type WorkflowStepEventResV2 struct {
+	Type      string    `json:"type" enums:"delegate,escalate"`
+	FromUsers []string  `json:"from_user_name_list"`
+	ToUsers   []string  `json:"to_user_name_list"`
+	Time      time.Time `json:"time"`
+}
+
+type WorkflowStepApprovalGroupV2 struct {
+	Name          string   `json:"name"`
+	Users         []string `json:"assignee_user_name_list"`
+	MinApprovals  uint     `json:"min_approvals"`
+	ApprovedUsers []string `json:"approved_user_name_list"`
 }
 
 // @Summary 审批通过
@@ -90,6 +111,10 @@ func ApproveWorkflowV2(c echo.Context) error {
 	if err := server.ApproveWorkflowProcess(workflow, user, s); err != nil {
 		return controller.JSONBaseErrorReq(c, err)
 	}
+	// the step which requires more approvals is still waiting for the others
+	if uint(stepId) == workflow.Record.CurrentWorkflowStepId {
+		return c.JSON(http.StatusOK, controller.NewBaseReq(nil))
+	}
 
 	go im.UpdateApprove(workflow.WorkflowId, user, model.ApproveStatusAgree, "")
 
@@ -471,6 +496,7 @@ type GetWorkflowTasksResV2 struct {
 type GetWorkflowTasksItemV2 struct {
 	TaskId                   uint                       `json:"task_id"`
 	InstanceName             string                     `json:"instance_name"`
+	InstanceSchema           string                     `json:"instance_schema"`
 	Status                   string                     `json:"status" enums:"wait_for_audit,wait_for_execution,exec_scheduled,exec_failed,exec_succeeded,executing,manually_executed,terminating,terminate_succeeded,terminate_failed"`
 	ExecStartTime            *time.Time                 `json:"exec_start_time,omitempty"`
 	ExecEndTime              *time.Time                 `json:"exec_end_time,omitempty"`
@@ -567,6 +593,7 @@ func convertWorkflowToTasksSummaryRes(taskDetails []*model.WorkflowTasksSummaryD
 		res[i] = &GetWorkflowTasksItemV2{
 			TaskId:                   taskDetail.TaskId,
 			InstanceName:             utils.AddDelTag(taskDetail.InstanceDeletedAt, taskDetail.InstanceName),
+			InstanceSchema:           taskDetail.TaskSchema,
 			Status:                   v1.GetTaskStatusRes(taskDetail.WorkflowRecordStatus, taskDetail.TaskStatus, taskDetail.InstanceScheduledAt),
 			ExecStartTime:            taskDetail.TaskExecStartAt,
 			ExecEndTime:              taskDetail.TaskExecEndAt,
@@ -627,9 +654,13 @@ func CreateWorkflowV2(c echo.Context) error {
 			return controller.JSONBaseErrorReq(c, err)
 		}
 	}
+	conditionInfo, err := server.GetWorkflowConditionInfo(log.NewEntry(), w.Tasks, w.StepTemplates)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
 	s := model.GetStorage()
 	// create workflow with checking op permission in task
-	err = s.CreateWorkflowV2(req.Subject, w.WorkflowId, req.Desc, w.User, w.Tasks, w.StepTemplates, w.ProjectId, req.SqlVersionID, nil, nil, w.GetOpExecUser)
+	err = s.CreateWorkflowV2(req.Subject, w.WorkflowId, req.Desc, w.User, w.Tasks, w.StepTemplates, conditionInfo, w.ProjectId, req.SqlVersionID, nil, nil, w.GetOpExecUser)
 	if err != nil {
 		return controller.JSONBaseErrorReq(c, err)
 	}
@@ -792,7 +823,16 @@ func UpdateWorkflowV2(c echo.Context) error {
 		return controller.JSONBaseErrorReq(c, err)
 	}
 
-	err = s.UpdateWorkflowRecord(workflow, tasks)
+	stepTemplates := make([]*model.WorkflowStepTemplate, 0, len(workflow.Record.Steps))
+	for _, step := range workflow.Record.Steps {
+		stepTemplates = append(stepTemplates, step.Template)
+	}
+	conditionInfo, err := server.GetWorkflowConditionInfo(log.NewEntry(), tasks, stepTemplates)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+
+	err = s.UpdateWorkflowRecord(workflow, tasks, conditionInfo)
 	if err != nil {
 		return c.JSON(http.StatusOK, controller.NewBaseReq(err))
 	}
@@ -903,6 +943,13 @@ func UpdateWorkflowScheduleV2(c echo.Context) error {
 		return controller.JSONBaseErrorReq(c, v1.ErrWorkflowExecuteTimeIncorrect)
 	}
 
+	if req.ScheduleTime != nil {
+		err = server.CheckWorkflowExecutionFreeze(workflow, map[uint]string{curTaskRecord.TaskId: user.GetIDStr()}, *req.ScheduleTime)
+		if err != nil {
+			return controller.JSONBaseErrorReq(c, err)
+		}
+	}
+
 	executable, reason, err := sqlversion.CheckWorkflowExecutable(c.Request().Context(), projectUid, workflowId)
 	if err != nil {
 		return controller.JSONBaseErrorReq(c, err)
@@ -991,6 +1038,7 @@ type WorkflowTaskItem struct {
 }
 
 type WorkflowRecordResV2 struct {
+	WorkflowRecordId  uint                 `json:"workflow_record_id"`
 	Tasks             []*WorkflowTaskItem  `json:"tasks"`
 	CurrentStepNumber uint                 `json:"current_step_number,omitempty"`
 	Status            string               `json:"status" enums:"wait_for_audit,wait_for_execution,rejected,canceled,exec_failed,executing,finished"`
@@ -1012,6 +1060,27 @@ type WorkflowResV2 struct {
 	RecordHistory               []*WorkflowRecordResV2        `json:"record_history_list,omitempty"`
 	AssociatedStageWorkflows    []*AssociatedStageWorkflows   `json:"associated_stage_workflows,omitempty"`
 	AssociatedRollbackWorkflows []*AssociatedRollbackWorkflow `json:"associated_rollback_workflows,omitempty"`
+	ExecutionFreeze             *WorkflowExecutionFreezeResV2 `json:"execution_freeze,omitempty"`
+}
+
+// WorkflowExecutionFreezeResV2 is the freeze calendars blocking the execution of the workflow now
+type WorkflowExecutionFreezeResV2 struct {
+	Frozen         bool                         `json:"frozen"`
+	Reason         string                       `json:"reason,omitempty"`
+	FreezeNames    []string                     `json:"freeze_name_list"`
+	FreezeOverride *WorkflowFreezeOverrideResV2 `json:"freeze_override,omitempty"`
+	// the scheduled execution is blocked by the freeze and retried at schedule_retry_at
+	ScheduleBlocked       bool       `json:"schedule_blocked"`
+	ScheduleBlockedReason string     `json:"schedule_blocked_reason,omitempty"`
+	ScheduleRetryAt       *time.Time `json:"schedule_retry_at,omitempty"`
+}
+
+type WorkflowFreezeOverrideResV2 struct {
+	Reason          string     `json:"reason"`
+	ApplyUserName   string     `json:"apply_user_name"`
+	ApproveUserName string     `json:"approve_user_name,omitempty"`
+	ApprovedAt      *time.Time `json:"approved_at,omitempty"`
+	Approved        bool       `json:"approved"`
 }
 
 type AssociatedStageWorkflows struct {
@@ -1089,12 +1158,99 @@ func GetWorkflowV2(c echo.Context) error {
 	if err != nil {
 		return controller.JSONBaseErrorReq(c, err)
 	}
+	recordIds := []uint{workflow.Record.ID}
+	for _, record := range workflow.RecordHistory {
+		recordIds = append(recordIds, record.ID)
+	}
+	stepEvents, err := s.GetWorkflowStepEventsByRecordIds(recordIds)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	freeze, err := server.GetWorkflowExecutionFreeze(workflow, nil, time.Now())
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	workflowRes := convertWorkflowToRes(c.Request().Context(), workflow, sqlVersion, associatedWorkflows, associatedRollbackWorkflows)
+	fillWorkflowStepEventsRes(workflowRes, stepEvents)
+	fillWorkflowExecutionFreezeRes(workflowRes, freeze, workflow.Record)
 	return c.JSON(http.StatusOK, &GetWorkflowResV2{
 		BaseRes: controller.NewBaseReq(nil),
-		Data:    convertWorkflowToRes(c.Request().Context(), workflow, sqlVersion, associatedWorkflows, associatedRollbackWorkflows),
+		Data:    workflowRes,
 	})
 }
 
+// fillWorkflowExecutionFreezeRes shows the freeze blocking the workflow, and the workflow is not executable until the freeze ends
+// or the emergency override is approved. The blocked state of the scheduled execution is recorded on the workflow record.
+func fillWorkflowExecutionFreezeRes(workflowRes *WorkflowResV2, freeze *server.WorkflowExecutionFreeze, record *model.WorkflowRecord) {
+	if !freeze.IsFrozen() && freeze.Override == nil && record.ScheduleBlockedReason == "" {
+		return
+	}
+	freezeRes := &WorkflowExecutionFreezeResV2{
+		Frozen:                freeze.IsFrozen(),
+		FreezeNames:           make([]string, 0, len(freeze.Calendars)),
+		ScheduleBlocked:       record.ScheduleBlockedReason != "",
+		ScheduleBlockedReason: record.ScheduleBlockedReason,
+		ScheduleRetryAt:       record.ScheduleRetryAt,
+	}
+	for _, calendar := range freeze.Calendars {
+		freezeRes.FreezeNames = append(freezeRes.FreezeNames, calendar.Name)
+	}
+	if freeze.IsFrozen() {
+		freezeRes.Reason = freeze.Reason()
+	}
+	if override := freeze.Override; override != nil {
+		freezeRes.FreezeOverride = &WorkflowFreezeOverrideResV2{
+			Reason:        override.Reason,
+			ApplyUserName: dms.GetUserNameWithDelTag(override.ApplyUserId),
+			ApprovedAt:    override.ApprovedAt,
+			Approved:      override.IsApproved(),
+		}
+		if override.ApproveUserId != "" {
+			freezeRes.FreezeOverride.ApproveUserName = dms.GetUserNameWithDelTag(override.ApproveUserId)
+		}
+	}
+	workflowRes.ExecutionFreeze = freezeRes
+	if freeze.IsBlocked() && workflowRes.Record.Executable {
+		workflowRes.Record.Executable = false
+		workflowRes.Record.ExecutableReason = freeze.Reason()
+	}
+}
+
+// fillWorkflowStepEventsRes appends the delegation and escalation events to the steps they happened on.
+func fillWorkflowStepEventsRes(workflowRes *WorkflowResV2, events []*model.WorkflowStepEvent) {
+	stepsRes := map[uint]*WorkflowStepResV2{}
+	for _, record := range append([]*WorkflowRecordResV2{workflowRes.Record}, workflowRes.RecordHistory...) {
+		for _, step := range record.Steps {
+			if step.Id != 0 {
+				stepsRes[step.Id] = step
+			}
+		}
+	}
+	for _, event := range events {
+		stepRes, ok := stepsRes[event.WorkflowStepId]
+		if !ok {
+			continue
+		}
+		eventRes := &WorkflowStepEventResV2{
+			Type:      event.Type,
+			FromUsers: []string{},
+			ToUsers:   []string{},
+			Time:      event.CreatedAt,
+		}
+		for _, id := range strings.Split(event.FromUsers, ",") {
+			if id != "" {
+				eventRes.FromUsers = append(eventRes.FromUsers, dms.GetUserNameWithDelTag(id))
+			}
+		}
+		for _, id := range strings.Split(event.ToUsers, ",") {
+			if id != "" {
+				eventRes.ToUsers = append(eventRes.ToUsers, dms.GetUserNameWithDelTag(id))
+			}
+		}
+		stepRes.Events = append(stepRes.Events, eventRes)
+	}
+}
+
 func convertWorkflowToRes(ctx context.Context, workflow *model.Workflow, sqlVersion *model.SqlVersion, associatedWorkflows []*model.AssociatedStageWorkflow, associatedRollbackWorkflows []*model.RollbackWorkflowOriginalWorkflowsRelation) *WorkflowResV2 {
 	workflowRes := &WorkflowResV2{
 		Name:                        workflow.Subject,
@@ -1204,6 +1360,7 @@ func convertWorkflowRecordToRes(ctx context.Context, workflow *model.Workflow, r
 	}
 
 	return &WorkflowRecordResV2{
+		WorkflowRecordId:  record.ID,
 		Tasks:             tasksRes,
 		CurrentStepNumber: currentStepNum,
 		Status:            record.Status,
@@ -1225,6 +1382,28 @@ func convertWorkflowStepToRes(step *model.WorkflowStep) *WorkflowStepResV2 {
 		OperationUser: dms.GetUserNameWithDelTag(step.OperationUserId),
 	}
 	stepRes.Users = append(stepRes.Users, strings.Split(step.Assignees, ",")...)
+
+	approvedUserIds := map[string]struct{}{}
+	for _, id := range step.ApprovedUserIds() {
+		approvedUserIds[id] = struct{}{}
+		stepRes.ApprovedUsers = append(stepRes.ApprovedUsers, dms.GetUserNameWithDelTag(id))
+	}
+	stepRes.ApprovalMinCount = step.Template.ApprovalMinCount
+	for _, group := range step.Template.ApprovalGroups {
+		groupRes := &WorkflowStepApprovalGroupV2{
+			Name:          group.Name,
+			Users:         make([]string, 0, len(group.Users)),
+			MinApprovals:  group.MinApprovals,
+			ApprovedUsers: []string{},
+		}
+		for _, id := range group.Users {
+			groupRes.Users = append(groupRes.Users, dms.GetUserNameWithDelTag(id))
+			if _, ok := approvedUserIds[id]; ok {
+				groupRes.ApprovedUsers = append(groupRes.ApprovedUsers, dms.GetUserNameWithDelTag(id))
+			}
+		}
+		stepRes.ApprovalGroups = append(stepRes.ApprovalGroups, groupRes)
+	}
 	return stepRes
 }
 
@@ -0,0 +1,183 @@
+package v2
+
+import (
+	"net/http"
+
+	dmsV1 "github.com/actiontech/dms/pkg/dms-common/api/dms/v1"
+	"github.com/actiontech/sqle/sqle/api/controller"
+	v1 "github.com/actiontech/sqle/sqle/api/controller/v1"
+	"github.com/actiontech/sqle/sqle/dms"
+	"github.com/actiontech/sqle/sqle/locale"
+	"github.com/actiontech/sqle/sqle/log"
+	"github.com/actiontech/sqle/sqle/model"
+	"github.com/actiontech/sqle/sqle/server"
+
+	"github.com/labstack/echo/v4"
+	"golang.org/x/text/language"
+)
+
+type GetWorkflowAuditDiffReqV2 struct {
+	BaseRecordId   uint `json:"base_record_id" query:"base_record_id"`
+	TargetRecordId uint `json:"target_record_id" query:"target_record_id"`
+}
+
+type GetWorkflowAuditDiffResV2 struct {
+	controller.BaseRes
+	Data *WorkflowAuditDiffResV2 `json:"data"`
+}
+
+type WorkflowAuditDiffResV2 struct {
+	BaseRecordId   uint                `json:"base_record_id"`
+	TargetRecordId uint                `json:"target_record_id"`
+	Tasks          []*TaskAuditDiffRes `json:"tasks"`
+}
+
+type TaskAuditDiffRes struct {
+	InstanceName string                  `json:"instance_name"`
+	Schema       string                  `json:"instance_schema"`
+	BaseTaskId   uint                    `json:"base_task_id,omitempty"`
+	TargetTaskId uint                    `json:"target_task_id,omitempty"`
+	Summary      *SQLAuditDiffSummaryRes `json:"summary"`
+	SQLs         []*SQLAuditDiffRes      `json:"sqls"`
+}
+
+type SQLAuditDiffSummaryRes struct {
+	AddedCount                 int `json:"added_count"`
+	RemovedCount               int `json:"removed_count"`
+	ModifiedCount              int `json:"modified_count"`
+	UnchangedCount             int `json:"unchanged_count"`
+	IntroducedAuditResultCount int `json:"introduced_audit_result_count"`
+	ResolvedAuditResultCount   int `json:"resolved_audit_result_count"`
+}
+
+type SQLAuditDiffRes struct {
+	DiffType               string         `json:"diff_type" enums:"added,removed,modified,unchanged"`
+	BaseNumber             uint           `json:"base_number,omitempty"`
+	TargetNumber           uint           `json:"target_number,omitempty"`
+	BaseSQL                string         `json:"base_sql,omitempty"`
+	TargetSQL              string         `json:"target_sql,omitempty"`
+	BaseAuditLevel         string         `json:"base_audit_level,omitempty"`
+	TargetAuditLevel       string         `json:"target_audit_level,omitempty"`
+	IntroducedAuditResults []*AuditResult `json:"introduced_audit_results"`
+	ResolvedAuditResults   []*AuditResult `json:"resolved_audit_results"`
+}
+
+// GetWorkflowAuditDiffV2
+// @Summary 对比工单两个版本的SQL及审核结果
+// @Description compare the SQLs and audit results between two records of the workflow, the target record is the current record and the base record is the previous one of the target by default
+// @Tags workflow
+// @Id getWorkflowAuditDiffV2
+// @Security ApiKeyAuth
+// @Param workflow_id path string true "workflow id"
+// @Param project_name path string true "project name"
+// @Param base_record_id query uint false "base workflow record id"
+// @Param target_record_id query uint false "target workflow record id"
+// @Success 200 {object} v2.GetWorkflowAuditDiffResV2
+// @router /v2/projects/{project_name}/workflows/{workflow_id}/records/diff [get]
+func GetWorkflowAuditDiffV2(c echo.Context) error {
+	req := new(GetWorkflowAuditDiffReqV2)
+	if err := controller.BindAndValidateReq(c, req); err != nil {
+		return err
+	}
+	projectUid, err := dms.GetPorjectUIDByName(c.Request().Context(), c.Param("project_name"))
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	workflowID := c.Param("workflow_id")
+
+	s := model.GetStorage()
+	workflow, err := dms.GetWorkflowDetailByWorkflowId(projectUid, workflowID, s.GetWorkflowDetailWithoutInstancesByWorkflowID)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+	err = v1.CheckCurrentUserCanViewWorkflow(c, projectUid, workflow, []dmsV1.OpPermissionType{dmsV1.OpPermissionTypeViewOthersWorkflow})
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+
+	diff, err := server.DiffWorkflowRecords(log.NewEntry(), workflow, req.BaseRecordId, req.TargetRecordId)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+
+	instanceIds := make([]uint64, 0, len(diff.Tasks))
+	for _, task := range diff.Tasks {
+		instanceIds = append(instanceIds, task.InstanceId)
+	}
+	instanceNames, err := dms.GetInstanceIdNameMapByIds(c.Request().Context(), instanceIds)
+	if err != nil {
+		return controller.JSONBaseErrorReq(c, err)
+	}
+
+	lang := locale.Bundle.GetLangTagFromCtx(c.Request().Context())
+	tasksRes := make([]*TaskAuditDiffRes, 0, len(diff.Tasks))
+	for _, task := range diff.Tasks {
+		taskRes := &TaskAuditDiffRes{
+			InstanceName: instanceNames[task.InstanceId],
+			Schema:       task.Schema,
+			Summary: &SQLAuditDiffSummaryRes{
+				AddedCount:                 task.DiffSummary.Added,
+				RemovedCount:               task.DiffSummary.Removed,
+				ModifiedCount:              task.DiffSummary.Modified,
+				UnchangedCount:             task.DiffSummary.Unchanged,
+				IntroducedAuditResultCount: task.DiffSummary.IntroducedAuditResults,
+				ResolvedAuditResultCount:   task.DiffSummary.ResolvedAuditResults,
+			},
+			SQLs: make([]*SQLAuditDiffRes, 0, len(task.SQLs)),
+		}
+		var dbType string
+		if task.BaseTask != nil {
+			taskRes.BaseTaskId = task.BaseTask.ID
+			dbType = task.BaseTask.DBType
+		}
+		if task.TargetTask != nil {
+			taskRes.TargetTaskId = task.TargetTask.ID
+			dbType = task.TargetTask.DBType
+		}
+		for _, sql := range task.SQLs {
+			sqlRes := &SQLAuditDiffRes{
+				DiffType:               sql.DiffType,
+				IntroducedAuditResults: convertAuditResultsToRes(sql.IntroducedAuditResults, dbType, lang),
+				ResolvedAuditResults:   convertAuditResultsToRes(sql.ResolvedAuditResults, dbType, lang),
+			}
+			if sql.BaseSQL != nil {
+				sqlRes.BaseNumber = sql.BaseSQL.Number
+				sqlRes.BaseSQL = sql.BaseSQL.Content
+				sqlRes.BaseAuditLevel = sql.BaseSQL.AuditLevel
+			}
+			if sql.TargetSQL != nil {
+				sqlRes.TargetNumber = sql.TargetSQL.Number
+				sqlRes.TargetSQL = sql.TargetSQL.Content
+				sqlRes.TargetAuditLevel = sql.TargetSQL.AuditLevel
+			}
+			taskRes.SQLs = append(taskRes.SQLs, sqlRes)
+		}
+		tasksRes = append(tasksRes, taskRes)
+	}
+
+	return c.JSON(http.StatusOK, &GetWorkflowAuditDiffResV2{
+		BaseRes: controller.NewBaseReq(nil),
+		Data: &WorkflowAuditDiffResV2{
+			BaseRecordId:   diff.BaseRecordId,
+			TargetRecordId: diff.TargetRecordId,
+			Tasks:          tasksRes,
+		},
+	})
+}
+
+func convertAuditResultsToRes(results model.AuditResults, dbType string, lang language.Tag) []*AuditResult {
+	res := make([]*AuditResult, 0, len(results))
+	for i := range results {
+		ar := results[i]
+		res = append(res, &AuditResult{
+			Level:               ar.Level,
+			ExecutionFailed:     ar.ExecutionFailed,
+			ErrorInfo:           ar.GetAuditErrorMsgByLangTag(lang),
+			Message:             ar.GetAuditMsgByLangTag(lang),
+			RuleName:            ar.RuleName,
+			DbType:              dbType,
+			I18nAuditResultInfo: ar.I18nAuditResultInfo,
+		})
+	}
+	return res
+}
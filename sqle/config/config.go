@@ -44,15 +44,19 @@ type SqleOptions struct {
 }
 
 type SeviceOpts struct {
-	EnableClusterMode  bool           `yaml:"enable_cluster_mode"`
-	AutoMigrateTable   bool           `yaml:"auto_migrate_table"`
-	DebugLog           bool           `yaml:"debug_log"`
-	LogPath            string         `yaml:"log_path"`
-	LogMaxSizeMB       int            `yaml:"log_max_size_mb"`
-	LogMaxBackupNumber int            `yaml:"log_max_backup_number"`
-	PluginPath         string         `yaml:"plugin_path"`
-	Database           Database       `yaml:"database"`
-	PluginConfig       []PluginConfig `yaml:"plugin_config"`
+	EnableClusterMode   bool                `yaml:"enable_cluster_mode"`
+	AutoMigrateTable    bool                `yaml:"auto_migrate_table"`
+	DebugLog            bool                `yaml:"debug_log"`
+	LogPath             string              `yaml:"log_path"`
+	LogMaxSizeMB        int                 `yaml:"log_max_size_mb"`
+	LogMaxBackupNumber  int                 `yaml:"log_max_backup_number"`
+	PluginPath          string              `yaml:"plugin_path"`
+	Database            Database            `yaml:"database"`
+	PluginConfig        []PluginConfig      `yaml:"plugin_config"`
+	AuditConcurrency    AuditConcurrency    `yaml:"audit_concurrency"`
+	BinlogFlashback     BinlogFlashback     `yaml:"binlog_flashback"`
+	AuditPlanCollection AuditPlanCollection `yaml:"audit_plan_collection"`
+	WorkflowExecCheck   WorkflowExecCheck   `yaml:"workflow_exec_check"`
 }
 
 type Database struct {
@@ -69,6 +73,50 @@ type PluginConfig struct {
 	CMD        string `yaml:"cmd"`
 }
 
+// AuditConcurrency controls how the SQLs of a large task are audited concurrently.
+type AuditConcurrency struct {
+	// ChunkSize is the number of SQLs audited by one plugin instance in a single call.
+	ChunkSize int `yaml:"chunk_size"`
+	// MaxPluginInstances is the max number of plugin instances used to audit one task.
+	MaxPluginInstances int `yaml:"max_plugin_instances"`
+	// MaxConcurrencyPerInstance is the max number of concurrent audit calls against one
+	// database instance, it protects the target database from online audit overload.
+	MaxConcurrencyPerInstance int `yaml:"max_concurrency_per_instance"`
+}
+
+// BinlogFlashback controls how the binlog events are read to generate the flashback SQLs.
+type BinlogFlashback struct {
+	// LocalBinlogDir is the directory of the binlog files copied from the instances, the binlog files of
+	// an instance are in the sub directory named by the instance name. The binlog events are read from
+	// the instance by the replication protocol if it is empty.
+	LocalBinlogDir string `yaml:"local_binlog_dir"`
+	// ReadTimeoutSeconds is the max time to wait for the binlog events from the instance.
+	ReadTimeoutSeconds int `yaml:"read_timeout_seconds"`
+}
+
+// AuditPlanCollection controls the health of the SQL collection of the audit plans.
+type AuditPlanCollection struct {
+	// MaxQueueDepth is the max number of the collected SQLs waiting in the queue to be aggregated, the queue is
+	// full when the collectors outpace the aggregation and auditing.
+	MaxQueueDepth int64 `yaml:"max_queue_depth"`
+	// QueueFullPolicy is how the collection is handled when the queue is full, "defer" skips the collection
+	// until the queue is drained but keeps the SQLs already collected or uploaded, and "drop" collects the SQLs
+	// but drops them.
+	QueueFullPolicy string `yaml:"queue_full_policy"`
+	// MaxConsecutiveFailures is the number of the consecutive failed collections after which the audit plan is
+	// disabled and its creator is notified.
+	MaxConsecutiveFailures uint `yaml:"max_consecutive_failures"`
+	// RecordRetentionDays is how long the collection records of an audit plan are kept.
+	RecordRetentionDays int `yaml:"record_retention_days"`
+}
+
+// WorkflowExecCheck controls the pre-execution and post-execution checks of the workflows.
+type WorkflowExecCheck struct {
+	// WebhookAllowedHosts is the hosts the webhook checks can request, an entry is a host name, a host:port or a
+	// wildcard like "*.example.com". The webhook checks are not allowed if it is empty.
+	WebhookAllowedHosts []string `yaml:"webhook_allowed_hosts"`
+}
+
 type OptimizationConfig struct {
 	OptimizationKey string `yaml:"optimization_key"`
 	OptimizationURL string `yaml:"optimization_url"`
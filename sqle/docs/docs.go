@@ -3044,6 +3044,174 @@ var doc = `{
                 }
             }
         },
+        "/v1/projects/{project_name}/execution_freeze_calendars": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "get the execution freeze calendars of project",
+                "tags": [
+                    "workflow"
+                ],
+                "summary": "获取上线冻结期列表",
+                "operationId": "getExecutionFreezeCalendarsV1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "project name",
+                        "name": "project_name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.GetExecutionFreezeCalendarsResV1"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "create the execution freeze calendar of project, the workflows can't be executed in the freeze period",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workflow"
+                ],
+                "summary": "创建上线冻结期",
+                "operationId": "createExecutionFreezeCalendarV1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "project name",
+                        "name": "project_name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "create execution freeze calendar request",
+                        "name": "calendar",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.ExecutionFreezeCalendarReqV1"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.BaseRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/projects/{project_name}/execution_freeze_calendars/{execution_freeze_calendar_id}/": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "update the execution freeze calendar of project",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workflow"
+                ],
+                "summary": "更新上线冻结期",
+                "operationId": "updateExecutionFreezeCalendarV1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "project name",
+                        "name": "project_name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "execution freeze calendar id",
+                        "name": "execution_freeze_calendar_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "update execution freeze calendar request",
+                        "name": "calendar",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.ExecutionFreezeCalendarReqV1"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.BaseRes"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "delete the execution freeze calendar of project",
+                "tags": [
+                    "workflow"
+                ],
+                "summary": "删除上线冻结期",
+                "operationId": "deleteExecutionFreezeCalendarV1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "project name",
+                        "name": "project_name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "execution freeze calendar id",
+                        "name": "execution_freeze_calendar_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.BaseRes"
+                        }
+                    }
+                }
+            }
+        },
         "/v1/projects/{project_name}/instance_audit_plans": {
             "get": {
                 "security": [
@@ -3522,19 +3690,19 @@ var doc = `{
                 }
             }
         },
-        "/v1/projects/{project_name}/instance_audit_plans/{instance_audit_plan_id}/audit_plans/{audit_plan_id}/sql_data": {
-            "post": {
+        "/v1/projects/{project_name}/instance_audit_plans/{instance_audit_plan_id}/audit_plans/{audit_plan_id}/collection_records": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "get audit plan SQLs",
+                "description": "get the collection records of the audit plan, the latest record is the first",
                 "tags": [
                     "instance_audit_plan"
                 ],
-                "summary": "获取指定扫描任务的SQL列表",
-                "operationId": "getInstanceAuditPlanSQLDataV1",
+                "summary": "获取扫描任务的采集记录",
+                "operationId": "getAuditPlanCollectionRecordsV1",
                 "parameters": [
                     {
                         "type": "string",
@@ -3558,38 +3726,43 @@ var doc = `{
                         "required": true
                     },
                     {
-                        "description": "audit plan sql data request",
-                        "name": "audit_plan_sql_request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/v1.GetAuditPlanSQLDataReqV1"
-                        }
+                        "type": "integer",
+                        "description": "page index",
+                        "name": "page_index",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "size of per page",
+                        "name": "page_size",
+                        "in": "query",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/v1.GetAuditPlanSQLDataResV1"
+                            "$ref": "#/definitions/v1.GetAuditPlanCollectionRecordsResV1"
                         }
                     }
                 }
             }
         },
-        "/v1/projects/{project_name}/instance_audit_plans/{instance_audit_plan_id}/audit_plans/{audit_plan_id}/sql_export": {
-            "post": {
+        "/v1/projects/{project_name}/instance_audit_plans/{instance_audit_plan_id}/audit_plans/{audit_plan_id}/schema_drifts": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "export audit plan SQL report as CSV",
+                "description": "get the schema changes found by the schema meta audit plan between collections, the changes not made through any workflow are unapproved",
                 "tags": [
                     "instance_audit_plan"
                 ],
-                "summary": "导出指定扫描任务的 SQL CSV 列表",
-                "operationId": "getInstanceAuditPlanSQLExportV1",
+                "summary": "获取库表结构扫描任务发现的结构变更",
+                "operationId": "getSchemaMetaDriftsV1",
                 "parameters": [
                     {
                         "type": "string",
@@ -3613,38 +3786,49 @@ var doc = `{
                         "required": true
                     },
                     {
-                        "description": "audit plan sql export request",
-                        "name": "audit_plan_sql_request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/v1.GetAuditPlanSQLExportReqV1"
-                        }
+                        "type": "boolean",
+                        "description": "only the unapproved changes",
+                        "name": "filter_unapproved",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page index",
+                        "name": "page_index",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "size of per page",
+                        "name": "page_size",
+                        "in": "query",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "export audit plan sql report",
+                        "description": "OK",
                         "schema": {
-                            "type": "file"
+                            "$ref": "#/definitions/v1.GetSchemaMetaDriftsResV1"
                         }
                     }
                 }
             }
         },
-        "/v1/projects/{project_name}/instance_audit_plans/{instance_audit_plan_id}/audit_plans/{audit_plan_id}/sql_meta": {
+        "/v1/projects/{project_name}/instance_audit_plans/{instance_audit_plan_id}/audit_plans/{audit_plan_id}/schema_snapshots": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "get audit plan SQL meta",
+                "description": "get the versions of the object definition collected by the schema meta audit plan, the latest version is the first",
                 "tags": [
                     "instance_audit_plan"
                 ],
-                "summary": "获取指定扫描任务的SQL列表元信息",
-                "operationId": "getInstanceAuditPlanSQLMetaV1",
+                "summary": "获取库表结构扫描任务采集的对象定义历史版本",
+                "operationId": "getSchemaMetaSnapshotsV1",
                 "parameters": [
                     {
                         "type": "string",
@@ -3666,20 +3850,45 @@ var doc = `{
                         "name": "audit_plan_id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "schema name",
+                        "name": "schema_name",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "table",
+                            "view"
+                        ],
+                        "type": "string",
+                        "description": "meta type",
+                        "name": "meta_type",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "meta name",
+                        "name": "meta_name",
+                        "in": "query",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/v1.GetAuditPlanSQLMetaResV1"
+                            "$ref": "#/definitions/v1.GetSchemaMetaSnapshotsResV1"
                         }
                     }
                 }
             }
         },
-        "/v1/projects/{project_name}/instance_audit_plans/{instance_audit_plan_id}/audit_plans/{audit_plan_id}/sqls": {
-            "get": {
+        "/v1/projects/{project_name}/instance_audit_plans/{instance_audit_plan_id}/audit_plans/{audit_plan_id}/sql_data": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
@@ -3689,8 +3898,8 @@ var doc = `{
                 "tags": [
                     "instance_audit_plan"
                 ],
-                "summary": "获取指定扫描任务的SQLs信息",
-                "operationId": "getInstanceAuditPlanSQLsV1",
+                "summary": "获取指定扫描任务的SQL列表",
+                "operationId": "getInstanceAuditPlanSQLDataV1",
                 "parameters": [
                     {
                         "type": "string",
@@ -3714,32 +3923,188 @@ var doc = `{
                         "required": true
                     },
                     {
-                        "type": "integer",
-                        "description": "page index",
-                        "name": "page_index",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "size of per page",
-                        "name": "page_size",
-                        "in": "query",
-                        "required": true
+                        "description": "audit plan sql data request",
+                        "name": "audit_plan_sql_request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.GetAuditPlanSQLDataReqV1"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/v1.GetAuditPlanSQLsResV1"
+                            "$ref": "#/definitions/v1.GetAuditPlanSQLDataResV1"
                         }
                     }
                 }
             }
         },
-        "/v1/projects/{project_name}/instance_audit_plans/{instance_audit_plan_id}/sqls/{id}/analysis": {
-            "get": {
+        "/v1/projects/{project_name}/instance_audit_plans/{instance_audit_plan_id}/audit_plans/{audit_plan_id}/sql_export": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "export audit plan SQL report as CSV",
+                "tags": [
+                    "instance_audit_plan"
+                ],
+                "summary": "导出指定扫描任务的 SQL CSV 列表",
+                "operationId": "getInstanceAuditPlanSQLExportV1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "project name",
+                        "name": "project_name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "instance audit plan id",
+                        "name": "instance_audit_plan_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "audit plan id",
+                        "name": "audit_plan_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "audit plan sql export request",
+                        "name": "audit_plan_sql_request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.GetAuditPlanSQLExportReqV1"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "export audit plan sql report",
+                        "schema": {
+                            "type": "file"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/projects/{project_name}/instance_audit_plans/{instance_audit_plan_id}/audit_plans/{audit_plan_id}/sql_meta": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "get audit plan SQL meta",
+                "tags": [
+                    "instance_audit_plan"
+                ],
+                "summary": "获取指定扫描任务的SQL列表元信息",
+                "operationId": "getInstanceAuditPlanSQLMetaV1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "project name",
+                        "name": "project_name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "instance audit plan id",
+                        "name": "instance_audit_plan_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "audit plan id",
+                        "name": "audit_plan_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.GetAuditPlanSQLMetaResV1"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/projects/{project_name}/instance_audit_plans/{instance_audit_plan_id}/audit_plans/{audit_plan_id}/sqls": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "get audit plan SQLs",
+                "tags": [
+                    "instance_audit_plan"
+                ],
+                "summary": "获取指定扫描任务的SQLs信息",
+                "operationId": "getInstanceAuditPlanSQLsV1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "project name",
+                        "name": "project_name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "instance audit plan id",
+                        "name": "instance_audit_plan_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "audit plan id",
+                        "name": "audit_plan_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page index",
+                        "name": "page_index",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "size of per page",
+                        "name": "page_size",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.GetAuditPlanSQLsResV1"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/projects/{project_name}/instance_audit_plans/{instance_audit_plan_id}/sqls/{id}/analysis": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
@@ -5181,20 +5546,19 @@ var doc = `{
                 }
             }
         },
-        "/v1/projects/{project_name}/sql_manages": {
+        "/v1/projects/{project_name}/sql_manage_application_mappings": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "get sql manage list",
+                "description": "get the mappings from the client endpoints to the applications of the managed SQLs in project",
                 "tags": [
                     "SqlManage"
                 ],
-                "summary": "获取管控sql列表",
-                "operationId": "GetSqlManageList",
-                "deprecated": true,
+                "summary": "获取SQL管控应用映射",
+                "operationId": "getSqlManageApplicationMappingsV1",
                 "parameters": [
                     {
                         "type": "string",
@@ -5202,48 +5566,363 @@ var doc = `{
                         "name": "project_name",
                         "in": "path",
                         "required": true
-                    },
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.GetSqlManageApplicationMappingsResV1"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
                     {
-                        "type": "string",
-                        "description": "fuzzy search sql fingerprint",
-                        "name": "fuzzy_search_sql_fingerprint",
-                        "in": "query"
-                    },
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "replace the mappings from the client endpoints to the applications of the managed SQLs in project. The application tagged in the SQL comments (e.g. sqlcommenter /*application='x',route='y'*/) is preferred, otherwise the application of the first mapping matched by the endpoints of the SQL is used",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SqlManage"
+                ],
+                "summary": "更新SQL管控应用映射",
+                "operationId": "updateSqlManageApplicationMappingsV1",
+                "parameters": [
                     {
                         "type": "string",
-                        "description": "assignee",
-                        "name": "filter_assignee",
-                        "in": "query"
+                        "description": "project name",
+                        "name": "project_name",
+                        "in": "path",
+                        "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "instance name",
-                        "name": "filter_instance_name",
-                        "in": "query"
-                    },
+                        "description": "update application mappings request",
+                        "name": "mappings",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.UpdateSqlManageApplicationMappingsReqV1"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.BaseRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/projects/{project_name}/sql_manage_applications": {
+            "get": {
+                "security": [
                     {
-                        "enum": [
-                            "audit_plan",
-                            "sql_audit_record"
-                        ],
-                        "type": "string",
-                        "description": "source",
-                        "name": "filter_source",
-                        "in": "query"
-                    },
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "get the statistics of the managed SQLs grouped by the application in project",
+                "tags": [
+                    "SqlManage"
+                ],
+                "summary": "按应用分组获取SQL管控统计",
+                "operationId": "getSqlManageApplicationsV1",
+                "parameters": [
                     {
-                        "enum": [
-                            "normal",
-                            "notice",
-                            "warn",
-                            "error"
-                        ],
                         "type": "string",
-                        "description": "audit level",
-                        "name": "filter_audit_level",
-                        "in": "query"
-                    },
-                    {
+                        "description": "project name",
+                        "name": "project_name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.GetSqlManageApplicationsResV1"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/projects/{project_name}/sql_manage_applications/exports": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "export the managed SQLs of the application in project as CSV",
+                "tags": [
+                    "SqlManage"
+                ],
+                "summary": "按应用导出SQL管控",
+                "operationId": "exportSqlManageApplicationV1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "project name",
+                        "name": "project_name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "application, empty means the SQLs not attributed to any application",
+                        "name": "application",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "unhandled",
+                            "solved",
+                            "ignored",
+                            "manual_audited",
+                            "sent"
+                        ],
+                        "type": "string",
+                        "description": "status",
+                        "name": "filter_status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "export sql manage of application",
+                        "schema": {
+                            "type": "file"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/projects/{project_name}/sql_manage_lifecycle": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "get the assignment rules, auto solve and reopen config of the managed SQLs in project",
+                "tags": [
+                    "SqlManage"
+                ],
+                "summary": "获取SQL管控自动流转配置",
+                "operationId": "getSqlManageLifecycleV1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "project name",
+                        "name": "project_name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.GetSqlManageLifecycleResV1"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "update the assignment rules, auto solve and reopen config of the managed SQLs in project, the assignment rules are replaced",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SqlManage"
+                ],
+                "summary": "更新SQL管控自动流转配置",
+                "operationId": "updateSqlManageLifecycleV1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "project name",
+                        "name": "project_name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "update sql manage lifecycle request",
+                        "name": "lifecycle",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.SqlManageLifecycle"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.BaseRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/projects/{project_name}/sql_manage_priority_rules": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "get the priority rules of the managed SQLs in project",
+                "tags": [
+                    "SqlManage"
+                ],
+                "summary": "获取SQL管控优先级规则",
+                "operationId": "getSqlManagePriorityRulesV1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "project name",
+                        "name": "project_name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.GetSqlManagePriorityRulesResV1"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "replace the priority rules of the managed SQLs in project. The SQL gets the priority of the first rule matched from P0 to P3, the SQL matching no rule gets the high priority if it matches the high priority params of the audit plan",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "SqlManage"
+                ],
+                "summary": "更新SQL管控优先级规则",
+                "operationId": "updateSqlManagePriorityRulesV1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "project name",
+                        "name": "project_name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "update priority rules request",
+                        "name": "rules",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.UpdateSqlManagePriorityRulesReqV1"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.BaseRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/projects/{project_name}/sql_manages": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "get sql manage list",
+                "tags": [
+                    "SqlManage"
+                ],
+                "summary": "获取管控sql列表",
+                "operationId": "GetSqlManageList",
+                "deprecated": true,
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "project name",
+                        "name": "project_name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "fuzzy search sql fingerprint",
+                        "name": "fuzzy_search_sql_fingerprint",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "assignee",
+                        "name": "filter_assignee",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "instance name",
+                        "name": "filter_instance_name",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "audit_plan",
+                            "sql_audit_record"
+                        ],
+                        "type": "string",
+                        "description": "source",
+                        "name": "filter_source",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "normal",
+                            "notice",
+                            "warn",
+                            "error"
+                        ],
+                        "type": "string",
+                        "description": "audit level",
+                        "name": "filter_audit_level",
+                        "in": "query"
+                    },
+                    {
                         "type": "string",
                         "description": "last audit start time from",
                         "name": "filter_last_audit_start_time_from",
@@ -5567,14 +6246,46 @@ var doc = `{
                 }
             }
         },
-        "/v1/projects/{project_name}/sql_manages/rule_tips": {
+        "/v1/projects/{project_name}/sql_manages/queue_status": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "get sql manage rule tips",
+                "description": "get the depth of the queue of the collected SQLs, the collections are deferred or dropped when the queue is full",
+                "tags": [
+                    "SqlManage"
+                ],
+                "summary": "获取管控SQL采集队列的积压情况",
+                "operationId": "getSQLManageQueueStatusV1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "project name",
+                        "name": "project_name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.GetSQLManageQueueStatusResV1"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/projects/{project_name}/sql_manages/rule_tips": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "get sql manage rule tips",
                 "tags": [
                     "SqlManage"
                 ],
@@ -5640,6 +6351,102 @@ var doc = `{
                 }
             }
         },
+        "/v1/projects/{project_name}/sql_manages/{sql_manage_id}/activities": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "get the activities of the managed SQL, the latest first",
+                "tags": [
+                    "SqlManage"
+                ],
+                "summary": "获取SQL管控SQL处理动态",
+                "operationId": "getSqlManageActivitiesV1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "project name",
+                        "name": "project_name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "sql manage id",
+                        "name": "sql_manage_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.GetSqlManageActivitiesResV1"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/projects/{project_name}/sql_manages/{sql_manage_id}/metric_history": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "get the metrics of the managed SQL collected in every collection cycle and the regressions not resolved",
+                "tags": [
+                    "SqlManage"
+                ],
+                "summary": "获取SQL管控SQL执行指标历史",
+                "operationId": "getSqlManageMetricHistoryV1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "project name",
+                        "name": "project_name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "sql manage id",
+                        "name": "sql_manage_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "start time, RFC3339 format, default 7 days ago",
+                        "name": "start_time",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "end time, RFC3339 format, default now",
+                        "name": "end_time",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "metric names separated by comma, default query_time_avg,query_time_max,row_examined_avg,lock_time_avg",
+                        "name": "metric_names",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.GetSqlManageMetricHistoryResV1"
+                        }
+                    }
+                }
+            }
+        },
         "/v1/projects/{project_name}/sql_manages/{sql_manage_id}/sql_analysis": {
             "get": {
                 "security": [
@@ -7022,6 +7829,53 @@ var doc = `{
                 }
             }
         },
+        "/v1/projects/{project_name}/task_groups/fan_out": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "create a task group targeting the schemas matching the pattern or listed explicitly across instances, a task is created for each schema, audit the task group by the same SQLs with /v1/task_groups/audit",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "task"
+                ],
+                "summary": "创建分发到多个schema的审核任务组",
+                "operationId": "createFanOutAuditTasksGroupV1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "project name",
+                        "name": "project_name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "parameters for creating fan-out audit tasks group",
+                        "name": "req",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.CreateFanOutAuditTasksGroupReqV1"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.CreateFanOutAuditTasksGroupResV1"
+                        }
+                    }
+                }
+            }
+        },
         "/v1/projects/{project_name}/tasks/audits": {
             "post": {
                 "security": [
@@ -7130,6 +7984,122 @@ var doc = `{
                 }
             }
         },
+        "/v1/projects/{project_name}/workflow_approval_delegates": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "get the workflow approval delegates of the current user, the project admin gets the delegates of all users",
+                "tags": [
+                    "workflow"
+                ],
+                "summary": "获取工单审批委托列表",
+                "operationId": "getWorkflowApprovalDelegatesV1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "project name",
+                        "name": "project_name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.GetWorkflowApprovalDelegatesResV1"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "register the delegate user who reviews the workflows on behalf of the current user during the period",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workflow"
+                ],
+                "summary": "登记不在岗期间的工单审批委托人",
+                "operationId": "createWorkflowApprovalDelegateV1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "project name",
+                        "name": "project_name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "create workflow approval delegate request",
+                        "name": "delegate",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.CreateWorkflowApprovalDelegateReqV1"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.BaseRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/projects/{project_name}/workflow_approval_delegates/{workflow_approval_delegate_id}/": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "delete the workflow approval delegate, the steps which have been delegated are not changed",
+                "tags": [
+                    "workflow"
+                ],
+                "summary": "删除工单审批委托",
+                "operationId": "deleteWorkflowApprovalDelegateV1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "project name",
+                        "name": "project_name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "workflow approval delegate id",
+                        "name": "workflow_approval_delegate_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.BaseRes"
+                        }
+                    }
+                }
+            }
+        },
         "/v1/projects/{project_name}/workflow_template": {
             "get": {
                 "security": [
@@ -7670,18 +8640,985 @@ var doc = `{
                 "tags": [
                     "workflow"
                 ],
-                "summary": "创建回滚工单",
-                "operationId": "CreateRollbackWorkflow",
+                "summary": "创建回滚工单",
+                "operationId": "CreateRollbackWorkflow",
+                "parameters": [
+                    {
+                        "description": "create rollback workflow request",
+                        "name": "instance",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.CreateRollbackWorkflowReq"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "project name",
+                        "name": "project_name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "origin workflow id to rollback",
+                        "name": "workflow_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.CreateRollbackWorkflowRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/projects/{project_name}/workflows/{workflow_id}/exec_checks": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "get the pre-execution and post-execution checks of the workflow and the check results of the tasks",
+                "tags": [
+                    "workflow"
+                ],
+                "summary": "获取工单上线前后的检查及检查结果",
+                "operationId": "getWorkflowExecChecksV1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "project name",
+                        "name": "project_name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "workflow id",
+                        "name": "workflow_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.GetWorkflowExecChecksResV1"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "replace the pre-execution and post-execution checks of the workflow before the workflow is approved, a failed pre check blocks the execution of the task, a failed post check marks the task and rolls back the task if required. The webhook url must be in the allowed hosts of the config",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workflow"
+                ],
+                "summary": "更新工单上线前后的检查",
+                "operationId": "updateWorkflowExecChecksV1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "project name",
+                        "name": "project_name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "workflow id",
+                        "name": "workflow_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "update workflow exec checks request",
+                        "name": "exec_checks",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.UpdateWorkflowExecChecksReqV1"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.BaseRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/projects/{project_name}/workflows/{workflow_id}/exec_plan": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "get the execution plan of the workflow and the execution status of the tasks",
+                "tags": [
+                    "workflow"
+                ],
+                "summary": "获取工单上线编排",
+                "operationId": "getWorkflowExecPlanV1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "project name",
+                        "name": "project_name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "workflow id",
+                        "name": "workflow_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.GetWorkflowExecPlanResV1"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "set the execution plan of the workflow, the tasks are executed after the tasks they depend on are executed successfully, and at most max_concurrency tasks are executed at the same time",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workflow"
+                ],
+                "summary": "更新工单上线编排",
+                "operationId": "updateWorkflowExecPlanV1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "project name",
+                        "name": "project_name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "workflow id",
+                        "name": "workflow_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "update workflow exec plan request",
+                        "name": "exec_plan",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.UpdateWorkflowExecPlanReqV1"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.BaseRes"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "delete the execution plan of the workflow, the tasks are executed as before",
+                "tags": [
+                    "workflow"
+                ],
+                "summary": "删除工单上线编排",
+                "operationId": "deleteWorkflowExecPlanV1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "project name",
+                        "name": "project_name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "workflow id",
+                        "name": "workflow_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.BaseRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/projects/{project_name}/workflows/{workflow_id}/exec_plan/resume": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "resume the execution of the workflow with an execution plan, the failed tasks are executed from the failed SQLs, then the tasks not started are executed by the plan",
+                "tags": [
+                    "workflow"
+                ],
+                "summary": "从失败处继续上线工单",
+                "operationId": "resumeWorkflowExecutionV1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "project name",
+                        "name": "project_name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "workflow id",
+                        "name": "workflow_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.BaseRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/projects/{project_name}/workflows/{workflow_id}/freeze_override": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "apply for the emergency override to execute the workflow in the freeze period, the override takes effect after approved by another project admin",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workflow"
+                ],
+                "summary": "申请冻结期内紧急上线",
+                "operationId": "applyWorkflowFreezeOverrideV1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "project name",
+                        "name": "project_name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "workflow id",
+                        "name": "workflow_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "apply workflow freeze override request",
+                        "name": "override",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.ApplyWorkflowFreezeOverrideReqV1"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.BaseRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/projects/{project_name}/workflows/{workflow_id}/freeze_override/approve": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "approve the emergency override to execute the workflow in the freeze period, the applicant can't approve the override",
+                "tags": [
+                    "workflow"
+                ],
+                "summary": "审批冻结期内紧急上线",
+                "operationId": "approveWorkflowFreezeOverrideV1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "project name",
+                        "name": "project_name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "workflow id",
+                        "name": "workflow_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.BaseRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/projects/{project_name}/workflows/{workflow_id}/tasks/terminate": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "terminate multiple task by project and workflow",
+                "tags": [
+                    "workflow"
+                ],
+                "summary": "终止工单下多个上线任务",
+                "operationId": "terminateMultipleTaskByWorkflowV1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "workflow id",
+                        "name": "workflow_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "project name",
+                        "name": "project_name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.BaseRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/projects/{project_name}/workflows/{workflow_id}/tasks/{task_id}/attachment": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "get workflow attachment",
+                "tags": [
+                    "workflow"
+                ],
+                "summary": "获取工单的task附件",
+                "operationId": "getWorkflowAttachment",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "project name",
+                        "name": "project_name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "workflow id",
+                        "name": "workflow_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "task id",
+                        "name": "task_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "get workflow attachment",
+                        "schema": {
+                            "type": "file"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/projects/{project_name}/workflows/{workflow_id}/tasks/{task_id}/backup_files/download": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "download SQL back up file for the audit task",
+                "tags": [
+                    "task"
+                ],
+                "summary": "下载工单中的SQL备份",
+                "operationId": "downloadBackupFileV1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "workflow id",
+                        "name": "workflow_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "project name",
+                        "name": "project_name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "task id",
+                        "name": "task_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "sql file",
+                        "schema": {
+                            "type": "file"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/projects/{project_name}/workflows/{workflow_id}/tasks/{task_id}/binlog_flashback": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "generate the flashback SQLs of the executed SQLs of the MySQL workflow task from the row events in the recorded binlog coordinates",
+                "tags": [
+                    "workflow"
+                ],
+                "summary": "获取工单任务基于binlog的闪回SQL",
+                "operationId": "getTaskBinlogFlashbackV1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "project name",
+                        "name": "project_name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "workflow id",
+                        "name": "workflow_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "task id",
+                        "name": "task_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.GetTaskBinlogFlashbackResV1"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/projects/{project_name}/workflows/{workflow_id}/tasks/{task_id}/dml_chunk_executions": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "get the progress of the DMLs executed in chunks of primary key range in the workflow task",
+                "tags": [
+                    "workflow"
+                ],
+                "summary": "获取工单任务中分批执行的DML进度",
+                "operationId": "getDMLChunkExecutionsV1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "project name",
+                        "name": "project_name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "workflow id",
+                        "name": "workflow_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "task id",
+                        "name": "task_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.GetDMLChunkExecutionsResV1"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/projects/{project_name}/workflows/{workflow_id}/tasks/{task_id}/dml_chunk_executions/{dml_chunk_execution_id}/pause": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "pause the DML executed in chunks after the current chunk",
+                "tags": [
+                    "workflow"
+                ],
+                "summary": "暂停分批执行的DML",
+                "operationId": "pauseDMLChunkExecutionV1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "project name",
+                        "name": "project_name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "workflow id",
+                        "name": "workflow_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "task id",
+                        "name": "task_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "dml chunk execution id",
+                        "name": "dml_chunk_execution_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.BaseRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/projects/{project_name}/workflows/{workflow_id}/tasks/{task_id}/dml_chunk_executions/{dml_chunk_execution_id}/resume": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "resume the paused DML executed in chunks",
+                "tags": [
+                    "workflow"
+                ],
+                "summary": "恢复分批执行的DML",
+                "operationId": "resumeDMLChunkExecutionV1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "project name",
+                        "name": "project_name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "workflow id",
+                        "name": "workflow_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "task id",
+                        "name": "task_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "dml chunk execution id",
+                        "name": "dml_chunk_execution_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.BaseRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/projects/{project_name}/workflows/{workflow_id}/tasks/{task_id}/dml_chunk_executions/{dml_chunk_execution_id}/terminate": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "terminate the DML executed in chunks after the current chunk, the executed chunks are not rolled back and the task fails",
+                "tags": [
+                    "workflow"
+                ],
+                "summary": "终止分批执行的DML",
+                "operationId": "terminateDMLChunkExecutionV1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "project name",
+                        "name": "project_name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "workflow id",
+                        "name": "workflow_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "task id",
+                        "name": "task_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "dml chunk execution id",
+                        "name": "dml_chunk_execution_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.BaseRes"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/projects/{project_name}/workflows/{workflow_id}/tasks/{task_id}/dry_run": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "clone the structures of the tables used by the SQLs of the MySQL workflow task into temporary shadow schemas on the sandbox instance, execute the SQLs there in order and report the result of each SQL, the shadow schemas are dropped afterwards",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workflow"
+                ],
+                "summary": "在沙箱实例上试执行工单任务",
+                "operationId": "dryRunTaskV1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "project name",
+                        "name": "project_name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "workflow id",
+                        "name": "workflow_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "task id",
+                        "name": "task_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "dry run request",
+                        "name": "dry_run",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.DryRunTaskReqV1"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.DryRunTaskResV1"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/projects/{project_name}/workflows/{workflow_id}/tasks/{task_id}/manual_execution": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "get the evidence of the task manually executed",
+                "tags": [
+                    "workflow"
+                ],
+                "summary": "获取数据源手动上线凭证",
+                "operationId": "getTaskManualExecutionV1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "project name",
+                        "name": "project_name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "workflow id",
+                        "name": "workflow_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "task id",
+                        "name": "task_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.GetTaskManualExecutionResV1"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "mark the task executed outside SQLE as manually executed with the evidence, the execution log or the evidence file is required. The changes of the schema can be verified after the evidence is saved",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workflow"
+                ],
+                "summary": "提交数据源手动上线凭证",
+                "operationId": "createTaskManualExecutionV1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "project name",
+                        "name": "project_name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "workflow id",
+                        "name": "workflow_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "task id",
+                        "name": "task_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "the time the SQLs are executed, RFC3339 format",
+                        "name": "executed_at",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "the person who executed the SQLs",
+                        "name": "executor",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "remark",
+                        "name": "remark",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "the execution log or output",
+                        "name": "execution_log",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "file",
+                        "description": "the execution log or output file",
+                        "name": "evidence_file",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "verify the changes of the schema after the evidence is saved, only support MySQL",
+                        "name": "verify",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v1.GetTaskManualExecutionResV1"
+                        }
+                    }
+                }
+            }
+        },
+        "/v1/projects/{project_name}/workflows/{workflow_id}/tasks/{task_id}/manual_execution/evidence_file": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "download the evidence file of the task manually executed",
+                "tags": [
+                    "workflow"
+                ],
+                "summary": "下载数据源手动上线凭证文件",
+                "operationId": "downloadTaskManualExecutionEvidenceFileV1",
                 "parameters": [
-                    {
-                        "description": "create rollback workflow request",
-                        "name": "instance",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/v1.CreateRollbackWorkflowReq"
-                        }
-                    },
                     {
                         "type": "string",
                         "description": "project name",
@@ -7691,36 +9628,50 @@ var doc = `{
                     },
                     {
                         "type": "string",
-                        "description": "origin workflow id to rollback",
+                        "description": "workflow id",
                         "name": "workflow_id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "task id",
+                        "name": "task_id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "evidence file",
                         "schema": {
-                            "$ref": "#/definitions/v1.CreateRollbackWorkflowRes"
+                            "type": "file"
                         }
                     }
                 }
             }
         },
-        "/v1/projects/{project_name}/workflows/{workflow_id}/tasks/terminate": {
+        "/v1/projects/{project_name}/workflows/{workflow_id}/tasks/{task_id}/manual_execution/verify": {
             "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "terminate multiple task by project and workflow",
+                "description": "verify the tables, columns and indexes changed by the DDLs of the task manually executed exist or not, only support MySQL",
                 "tags": [
                     "workflow"
                 ],
-                "summary": "终止工单下多个上线任务",
-                "operationId": "terminateMultipleTaskByWorkflowV1",
+                "summary": "校验数据源手动上线的变更",
+                "operationId": "verifyTaskManualExecutionV1",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "project name",
+                        "name": "project_name",
+                        "in": "path",
+                        "required": true
+                    },
                     {
                         "type": "string",
                         "description": "workflow id",
@@ -7730,8 +9681,8 @@ var doc = `{
                     },
                     {
                         "type": "string",
-                        "description": "project name",
-                        "name": "project_name",
+                        "description": "task id",
+                        "name": "task_id",
                         "in": "path",
                         "required": true
                     }
@@ -7740,25 +9691,31 @@ var doc = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/controller.BaseRes"
+                            "$ref": "#/definitions/v1.GetTaskManualExecutionResV1"
                         }
                     }
                 }
             }
         },
-        "/v1/projects/{project_name}/workflows/{workflow_id}/tasks/{task_id}/attachment": {
-            "get": {
+        "/v1/projects/{project_name}/workflows/{workflow_id}/tasks/{task_id}/order_file": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "get workflow attachment",
+                "description": "update sql file order",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "workflow"
+                    "task"
                 ],
-                "summary": "获取工单的task附件",
-                "operationId": "getWorkflowAttachment",
+                "summary": "修改文件上线顺序",
+                "operationId": "updateSqlFileOrderV1",
                 "parameters": [
                     {
                         "type": "string",
@@ -7780,43 +9737,52 @@ var doc = `{
                         "name": "task_id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "instance body v1.UpdateSqlFileOrderV1Req true",
+                        "name": "instance",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.UpdateSqlFileOrderV1Req"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "get workflow attachment",
+                        "description": "OK",
                         "schema": {
-                            "type": "file"
+                            "$ref": "#/definitions/v1.GetSqlFileOrderMethodResV1"
                         }
                     }
                 }
             }
         },
-        "/v1/projects/{project_name}/workflows/{workflow_id}/tasks/{task_id}/backup_files/download": {
+        "/v1/projects/{project_name}/workflows/{workflow_id}/tasks/{task_id}/rollback_runs": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "download SQL back up file for the audit task",
+                "description": "get the rollback runs of the workflow task with the result of each SQL, the SQLs after the failed one are not executed",
                 "tags": [
-                    "task"
+                    "workflow"
                 ],
-                "summary": "下载工单中的SQL备份",
-                "operationId": "downloadBackupFileV1",
+                "summary": "获取工单任务的回滚执行记录",
+                "operationId": "getRollbackRunsV1",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "workflow id",
-                        "name": "workflow_id",
+                        "description": "project name",
+                        "name": "project_name",
                         "in": "path",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "project name",
-                        "name": "project_name",
+                        "description": "workflow id",
+                        "name": "workflow_id",
                         "in": "path",
                         "required": true
                     },
@@ -7830,22 +9796,20 @@ var doc = `{
                 ],
                 "responses": {
                     "200": {
-                        "description": "sql file",
+                        "description": "OK",
                         "schema": {
-                            "type": "file"
+                            "$ref": "#/definitions/v1.GetRollbackRunsResV1"
                         }
                     }
                 }
-            }
-        },
-        "/v1/projects/{project_name}/workflows/{workflow_id}/tasks/{task_id}/order_file": {
+            },
             "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "update sql file order",
+                "description": "roll back the executed task by the selected rollback SQLs in the reverse order of execution, the rollback SQLs are executed like the task: the adjacent DMLs in one transaction, the SQLs of a batch together in sql file mode, and stop at the first failure",
                 "consumes": [
                     "application/json"
                 ],
@@ -7853,10 +9817,10 @@ var doc = `{
                     "application/json"
                 ],
                 "tags": [
-                    "task"
+                    "workflow"
                 ],
-                "summary": "修改文件上线顺序",
-                "operationId": "updateSqlFileOrderV1",
+                "summary": "执行工单任务的回滚SQL",
+                "operationId": "createRollbackRunV1",
                 "parameters": [
                     {
                         "type": "string",
@@ -7880,12 +9844,12 @@ var doc = `{
                         "required": true
                     },
                     {
-                        "description": "instance body v1.UpdateSqlFileOrderV1Req true",
-                        "name": "instance",
+                        "description": "create rollback run request",
+                        "name": "rollback_run",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/v1.UpdateSqlFileOrderV1Req"
+                            "$ref": "#/definitions/v1.CreateRollbackRunReqV1"
                         }
                     }
                 ],
@@ -7893,7 +9857,7 @@ var doc = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/v1.GetSqlFileOrderMethodResV1"
+                            "$ref": "#/definitions/controller.BaseRes"
                         }
                     }
                 }
@@ -9701,6 +11665,50 @@ var doc = `{
                 }
             }
         },
+        "/v1/tasks/audits/{task_id}/exec_policy": {
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "update the execution policy of the task, such as statement timeout, lock wait timeout, retry on lock conflict and blocking sessions check before DDL",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "task"
+                ],
+                "summary": "修改任务的上线执行策略",
+                "operationId": "updateTaskExecPolicyV1",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "task id",
+                        "name": "task_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "task execution policy",
+                        "name": "exec_policy",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/v1.TaskExecPolicy"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/controller.BaseRes"
+                        }
+                    }
+                }
+            }
+        },
         "/v1/tasks/audits/{task_id}/origin_file": {
             "get": {
                 "security": [
@@ -11070,6 +13078,57 @@ var doc = `{
                 }
             }
         },
+        "/v2/projects/{project_name}/workflows/{workflow_id}/records/diff": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "compare the SQLs and audit results between two records of the workflow, the target record is the current record and the base record is the previous one of the target by default",
+                "tags": [
+                    "workflow"
+                ],
+                "summary": "对比工单两个版本的SQL及审核结果",
+                "operationId": "getWorkflowAuditDiffV2",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "workflow id",
+                        "name": "workflow_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "project name",
+                        "name": "project_name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "base workflow record id",
+                        "name": "base_record_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "target workflow record id",
+                        "name": "target_record_id",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/v2.GetWorkflowAuditDiffResV2"
+                        }
+                    }
+                }
+            }
+        },
         "/v2/projects/{project_name}/workflows/{workflow_id}/steps/{workflow_step_id}/approve": {
             "post": {
                 "security": [
@@ -11728,6 +13787,14 @@ var doc = `{
                 }
             }
         },
+        "v1.ApplyWorkflowFreezeOverrideReqV1": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
         "v1.AssociateWorkflows": {
             "type": "object",
             "properties": {
@@ -11790,6 +13857,45 @@ var doc = `{
                 }
             }
         },
+        "v1.AuditPlanCollectionRecord": {
+            "type": "object",
+            "properties": {
+                "dropped_sql_count": {
+                    "type": "integer"
+                },
+                "duration_ms": {
+                    "type": "integer"
+                },
+                "end_at": {
+                    "type": "string"
+                },
+                "error_message": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "queue_depth": {
+                    "description": "the depth of the sql manage queue when the collection started",
+                    "type": "integer"
+                },
+                "sql_count": {
+                    "type": "integer"
+                },
+                "start_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "success",
+                        "failed",
+                        "deferred",
+                        "dropped"
+                    ]
+                }
+            }
+        },
         "v1.AuditPlanCount": {
             "type": "object",
             "properties": {
@@ -12292,6 +14398,10 @@ var doc = `{
                 "exec_mode": {
                     "type": "string"
                 },
+                "exec_policy": {
+                    "type": "object",
+                    "$ref": "#/definitions/v1.TaskExecPolicy"
+                },
                 "exec_start_time": {
                     "type": "string"
                 },
@@ -12603,7 +14713,11 @@ var doc = `{
                     "type": "string",
                     "enum": [
                         "",
-                        "high"
+                        "high",
+                        "P0",
+                        "P1",
+                        "P2",
+                        "P3"
                     ]
                 },
                 "remark": {
@@ -12959,15 +15073,53 @@ var doc = `{
                 "rule_script": {
                     "type": "string"
                 },
-                "tags": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "type": {
+                    "type": "string",
+                    "example": "DDL规则"
+                }
+            }
+        },
+        "v1.CreateFanOutAuditTasksGroupReqV1": {
+            "type": "object",
+            "properties": {
+                "exec_mode": {
+                    "type": "string",
+                    "enum": [
+                        "sql_file",
+                        "sqls"
+                    ]
+                },
+                "file_order_method": {
+                    "type": "string"
+                },
+                "targets": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.FanOutTargetV1"
+                    }
+                }
+            }
+        },
+        "v1.CreateFanOutAuditTasksGroupResV1": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "data": {
+                    "type": "object",
+                    "$ref": "#/definitions/v1.FanOutAuditTasksGroupResV1"
                 },
-                "type": {
+                "message": {
                     "type": "string",
-                    "example": "DDL规则"
+                    "example": "ok"
                 }
             }
         },
@@ -13050,6 +15202,18 @@ var doc = `{
                 }
             }
         },
+        "v1.CreateRollbackRunReqV1": {
+            "type": "object",
+            "properties": {
+                "rollback_sql_ids": {
+                    "description": "the rollback SQLs to roll back, all the rollback SQLs of the task are rolled back if it is empty",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
         "v1.CreateRollbackWorkflowReq": {
             "type": "object",
             "properties": {
@@ -13213,6 +15377,23 @@ var doc = `{
                 }
             }
         },
+        "v1.CreateWorkflowApprovalDelegateReqV1": {
+            "type": "object",
+            "properties": {
+                "delegate_user_id": {
+                    "type": "string"
+                },
+                "end_time": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "start_time": {
+                    "type": "string"
+                }
+            }
+        },
         "v1.CreateWorkflowReqV1": {
             "type": "object",
             "properties": {
@@ -13321,6 +15502,91 @@ var doc = `{
                 }
             }
         },
+        "v1.DMLChunkExecutionResV1": {
+            "type": "object",
+            "properties": {
+                "chunk_count": {
+                    "type": "integer"
+                },
+                "chunk_size": {
+                    "type": "integer"
+                },
+                "chunks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.DMLChunkResV1"
+                    }
+                },
+                "exec_result": {
+                    "type": "string"
+                },
+                "exec_sql_id": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_key": {
+                    "type": "string"
+                },
+                "primary_key": {
+                    "type": "string"
+                },
+                "row_affects": {
+                    "type": "integer"
+                },
+                "schema": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "running",
+                        "paused",
+                        "terminating",
+                        "terminated",
+                        "succeeded",
+                        "failed"
+                    ]
+                },
+                "table": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.DMLChunkResV1": {
+            "type": "object",
+            "properties": {
+                "exec_end_at": {
+                    "type": "string"
+                },
+                "exec_result": {
+                    "type": "string"
+                },
+                "exec_start_at": {
+                    "type": "string"
+                },
+                "exec_status": {
+                    "type": "string",
+                    "enum": [
+                        "succeeded",
+                        "failed"
+                    ]
+                },
+                "lower_key": {
+                    "type": "string"
+                },
+                "number": {
+                    "type": "integer"
+                },
+                "row_affects": {
+                    "type": "integer"
+                },
+                "upper_key": {
+                    "type": "string"
+                }
+            }
+        },
         "v1.DashboardResV1": {
             "type": "object",
             "properties": {
@@ -13646,6 +15912,74 @@ var doc = `{
                 }
             }
         },
+        "v1.DryRunSQLResV1": {
+            "type": "object",
+            "properties": {
+                "exec_result": {
+                    "type": "string"
+                },
+                "exec_sql": {
+                    "type": "string"
+                },
+                "exec_sql_id": {
+                    "type": "integer"
+                },
+                "number": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "succeeded",
+                        "failed",
+                        "skipped"
+                    ]
+                }
+            }
+        },
+        "v1.DryRunTaskReqV1": {
+            "type": "object",
+            "properties": {
+                "sandbox_instance_name": {
+                    "description": "the MySQL instance of the project to create the shadow schemas, it can't be the instance of the task",
+                    "type": "string"
+                }
+            }
+        },
+        "v1.DryRunTaskResV1": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "data": {
+                    "type": "object",
+                    "$ref": "#/definitions/v1.DryRunTaskResultV1"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
+        "v1.DryRunTaskResultV1": {
+            "type": "object",
+            "properties": {
+                "cloned_tables": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "sqls": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.DryRunSQLResV1"
+                    }
+                }
+            }
+        },
         "v1.EdgeResponse": {
             "type": "object",
             "properties": {
@@ -13678,43 +16012,170 @@ var doc = `{
                 }
             }
         },
-        "v1.ExplainClassicResult": {
+        "v1.ExecutionFreezeCalendarReqV1": {
+            "type": "object",
+            "properties": {
+                "desc": {
+                    "type": "string"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "end_time": {
+                    "type": "string"
+                },
+                "instance_group_list": {
+                    "description": "the businesses of the instances which the freeze applies to, the freeze applies to all instances of the project if it is empty",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "recurrence_cron": {
+                    "description": "the freeze starts by the cron and lasts the duration, the freeze is a one-off time range from start time to end time if the cron is empty",
+                    "type": "string",
+                    "example": "0 0 25 12 *"
+                },
+                "recurrence_duration_minutes": {
+                    "type": "integer"
+                },
+                "start_time": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.ExecutionFreezeCalendarResV1": {
+            "type": "object",
+            "properties": {
+                "desc": {
+                    "type": "string"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "end_time": {
+                    "type": "string"
+                },
+                "execution_freeze_calendar_id": {
+                    "type": "integer"
+                },
+                "frozen": {
+                    "type": "boolean"
+                },
+                "instance_group_list": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                },
+                "recurrence_cron": {
+                    "type": "string"
+                },
+                "recurrence_duration_minutes": {
+                    "type": "integer"
+                },
+                "start_time": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.ExplainClassicResult": {
+            "type": "object",
+            "properties": {
+                "head": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.TableMetaItemHeadResV1"
+                    }
+                },
+                "rows": {
+                    "type": "array",
+                    "items": {
+                        "type": "object",
+                        "additionalProperties": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "v1.ExplainValidationDetail": {
+            "type": "object",
+            "properties": {
+                "after_cost": {
+                    "type": "number"
+                },
+                "after_plan": {
+                    "type": "string"
+                },
+                "before_cost": {
+                    "type": "number"
+                },
+                "before_plan": {
+                    "type": "string"
+                },
+                "perform_improve_per": {
+                    "type": "number"
+                }
+            }
+        },
+        "v1.FanOutAuditTasksGroupResV1": {
             "type": "object",
             "properties": {
-                "head": {
+                "schema_shape_count": {
+                    "description": "the number of distinct schema shapes, the tasks with the same schema shape are audited once",
+                    "type": "integer"
+                },
+                "task_group_id": {
+                    "type": "integer"
+                },
+                "tasks": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/v1.TableMetaItemHeadResV1"
+                        "$ref": "#/definitions/v1.FanOutTaskResV1"
                     }
+                }
+            }
+        },
+        "v1.FanOutTargetV1": {
+            "type": "object",
+            "properties": {
+                "instance_name": {
+                    "type": "string"
                 },
-                "rows": {
+                "schema_pattern": {
+                    "description": "the schemas matching the pattern are targeted, '%' matches any characters and '_' matches one character, such as tenant_%",
+                    "type": "string"
+                },
+                "schemas": {
+                    "description": "the schemas targeted explicitly",
                     "type": "array",
                     "items": {
-                        "type": "object",
-                        "additionalProperties": {
-                            "type": "string"
-                        }
+                        "type": "string"
                     }
                 }
             }
         },
-        "v1.ExplainValidationDetail": {
+        "v1.FanOutTaskResV1": {
             "type": "object",
             "properties": {
-                "after_cost": {
-                    "type": "number"
-                },
-                "after_plan": {
+                "instance_name": {
                     "type": "string"
                 },
-                "before_cost": {
-                    "type": "number"
+                "instance_schema": {
+                    "type": "string"
                 },
-                "before_plan": {
+                "schema_shape": {
                     "type": "string"
                 },
-                "perform_improve_per": {
-                    "type": "number"
+                "task_id": {
+                    "type": "integer"
                 }
             }
         },
@@ -13895,6 +16356,28 @@ var doc = `{
                 }
             }
         },
+        "v1.GetAuditPlanCollectionRecordsResV1": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.AuditPlanCollectionRecord"
+                    }
+                },
+                "message": {
+                    "type": "string",
+                    "example": "ok"
+                },
+                "total_nums": {
+                    "type": "integer"
+                }
+            }
+        },
         "v1.GetAuditPlanMetasResV1": {
             "type": "object",
             "properties": {
@@ -14372,6 +16855,25 @@ var doc = `{
                 }
             }
         },
+        "v1.GetDMLChunkExecutionsResV1": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.DMLChunkExecutionResV1"
+                    }
+                },
+                "message": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
         "v1.GetDashboardResV1": {
             "type": "object",
             "properties": {
@@ -14527,6 +17029,25 @@ var doc = `{
                 }
             }
         },
+        "v1.GetExecutionFreezeCalendarsResV1": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.ExecutionFreezeCalendarResV1"
+                    }
+                },
+                "message": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
         "v1.GetFeishuAuditConfigurationResV1": {
             "type": "object",
             "properties": {
@@ -15216,6 +17737,25 @@ var doc = `{
                 }
             }
         },
+        "v1.GetRollbackRunsResV1": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.RollbackRunResV1"
+                    }
+                },
+                "message": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
         "v1.GetRuleCategoryStatisticResV1": {
             "type": "object",
             "properties": {
@@ -15424,6 +17964,64 @@ var doc = `{
                 }
             }
         },
+        "v1.GetSQLManageQueueStatusResV1": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "data": {
+                    "type": "object",
+                    "$ref": "#/definitions/v1.SQLManageQueueStatus"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
+        "v1.GetSchemaMetaDriftsResV1": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.SchemaMetaDrift"
+                    }
+                },
+                "message": {
+                    "type": "string",
+                    "example": "ok"
+                },
+                "total_nums": {
+                    "type": "integer"
+                }
+            }
+        },
+        "v1.GetSchemaMetaSnapshotsResV1": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.SchemaMetaSnapshot"
+                    }
+                },
+                "message": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
         "v1.GetSqlAverageExecutionTimeResV1": {
             "type": "object",
             "properties": {
@@ -15501,6 +18099,80 @@ var doc = `{
                 }
             }
         },
+        "v1.GetSqlManageActivitiesResV1": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.SqlManageActivity"
+                    }
+                },
+                "message": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
+        "v1.GetSqlManageApplicationMappingsResV1": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.SqlManageApplicationMapping"
+                    }
+                },
+                "message": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
+        "v1.GetSqlManageApplicationsResV1": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.SqlManageApplicationStatistic"
+                    }
+                },
+                "message": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
+        "v1.GetSqlManageLifecycleResV1": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "data": {
+                    "type": "object",
+                    "$ref": "#/definitions/v1.SqlManageLifecycle"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
         "v1.GetSqlManageListResp": {
             "type": "object",
             "properties": {
@@ -15529,6 +18201,42 @@ var doc = `{
                 }
             }
         },
+        "v1.GetSqlManageMetricHistoryResV1": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "data": {
+                    "type": "object",
+                    "$ref": "#/definitions/v1.SqlManageMetricHistory"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
+        "v1.GetSqlManagePriorityRulesResV1": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.SqlManagePriorityRule"
+                    }
+                },
+                "message": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
         "v1.GetSqlManageRuleTipsResp": {
             "type": "object",
             "properties": {
@@ -15688,6 +18396,42 @@ var doc = `{
                 }
             }
         },
+        "v1.GetTaskBinlogFlashbackResV1": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.TaskBinlogFlashbackResV1"
+                    }
+                },
+                "message": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
+        "v1.GetTaskManualExecutionResV1": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "data": {
+                    "type": "object",
+                    "$ref": "#/definitions/v1.TaskManualExecutionResV1"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
         "v1.GetUserTipsResV1": {
             "type": "object",
             "properties": {
@@ -15724,6 +18468,25 @@ var doc = `{
                 }
             }
         },
+        "v1.GetWorkflowApprovalDelegatesResV1": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.WorkflowApprovalDelegateResV1"
+                    }
+                },
+                "message": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
         "v1.GetWorkflowAuditPassPercentResV1": {
             "type": "object",
             "properties": {
@@ -15809,6 +18572,40 @@ var doc = `{
                 }
             }
         },
+        "v1.GetWorkflowExecChecksResV1": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "data": {
+                    "type": "object",
+                    "$ref": "#/definitions/v1.WorkflowExecChecksResV1"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
+        "v1.GetWorkflowExecPlanResV1": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "data": {
+                    "type": "object",
+                    "$ref": "#/definitions/v1.WorkflowExecPlanResV1"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
         "v1.GetWorkflowPassPercentResV1": {
             "type": "object",
             "properties": {
@@ -16288,6 +19085,10 @@ var doc = `{
                     "type": "object",
                     "$ref": "#/definitions/v1.AuditPlanTypeResBase"
                 },
+                "consecutive_collection_failures": {
+                    "description": "the audit plan is disabled automatically when the consecutive collection failures reach the limit",
+                    "type": "integer"
+                },
                 "exec_cmd": {
                     "type": "string",
                     "example": "./scanner xxx"
@@ -17359,6 +20160,77 @@ var doc = `{
                 }
             }
         },
+        "v1.RollbackRunResV1": {
+            "type": "object",
+            "properties": {
+                "create_user_id": {
+                    "type": "string"
+                },
+                "end_at": {
+                    "type": "string"
+                },
+                "exec_mode": {
+                    "type": "string"
+                },
+                "exec_result": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "sqls": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.RollbackRunSQLResV1"
+                    }
+                },
+                "start_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "running",
+                        "succeeded",
+                        "failed"
+                    ]
+                }
+            }
+        },
+        "v1.RollbackRunSQLResV1": {
+            "type": "object",
+            "properties": {
+                "exec_batch_id": {
+                    "type": "integer"
+                },
+                "exec_result": {
+                    "type": "string"
+                },
+                "exec_status": {
+                    "type": "string",
+                    "enum": [
+                        "initialized",
+                        "succeeded",
+                        "failed"
+                    ]
+                },
+                "number": {
+                    "type": "integer"
+                },
+                "rollback_sql_id": {
+                    "type": "integer"
+                },
+                "row_affects": {
+                    "type": "integer"
+                },
+                "sql": {
+                    "type": "string"
+                },
+                "sql_type": {
+                    "type": "string"
+                }
+            }
+        },
         "v1.RuleInfo": {
             "type": "object",
             "properties": {
@@ -17723,6 +20595,26 @@ var doc = `{
                 }
             }
         },
+        "v1.SQLManageQueueStatus": {
+            "type": "object",
+            "properties": {
+                "max_queue_depth": {
+                    "type": "integer"
+                },
+                "queue_depth": {
+                    "description": "the number of the collected SQLs waiting to be aggregated",
+                    "type": "integer"
+                },
+                "queue_full_policy": {
+                    "description": "defer skips the collections until the queue is drained but keeps the SQLs already collected or uploaded, drop collects the SQLs but drops them",
+                    "type": "string",
+                    "enum": [
+                        "defer",
+                        "drop"
+                    ]
+                }
+            }
+        },
         "v1.SQLQueryConfigResV1": {
             "type": "object",
             "properties": {
@@ -17801,6 +20693,96 @@ var doc = `{
                 }
             }
         },
+        "v1.SchemaMetaChange": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "description": "added, dropped or modified",
+                    "type": "string"
+                },
+                "after": {
+                    "type": "string"
+                },
+                "before": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "object": {
+                    "description": "column, index, constraint, table_options or definition",
+                    "type": "string"
+                }
+            }
+        },
+        "v1.SchemaMetaDrift": {
+            "type": "object",
+            "properties": {
+                "approved": {
+                    "description": "approved means the object is changed by the SQLs executed through the workflows",
+                    "type": "boolean"
+                },
+                "change_type": {
+                    "description": "created, altered or dropped",
+                    "type": "string"
+                },
+                "changes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.SchemaMetaChange"
+                    }
+                },
+                "detected_at": {
+                    "type": "string"
+                },
+                "from_version": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "matched_task_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "meta_name": {
+                    "type": "string"
+                },
+                "meta_type": {
+                    "type": "string"
+                },
+                "partially_approved": {
+                    "description": "partially approved means only some of the changes are made by the SQLs executed through the workflows",
+                    "type": "boolean"
+                },
+                "schema_name": {
+                    "type": "string"
+                },
+                "to_version": {
+                    "type": "integer"
+                }
+            }
+        },
+        "v1.SchemaMetaSnapshot": {
+            "type": "object",
+            "properties": {
+                "collected_at": {
+                    "type": "string"
+                },
+                "content": {
+                    "type": "string"
+                },
+                "dropped": {
+                    "description": "dropped means the object is dropped in this version",
+                    "type": "boolean"
+                },
+                "version": {
+                    "type": "integer"
+                }
+            }
+        },
         "v1.SchemaObject": {
             "type": "object",
             "properties": {
@@ -18035,21 +21017,61 @@ var doc = `{
                     "type": "object",
                     "$ref": "#/definitions/v1.Source"
                 },
-                "sql": {
+                "sql": {
+                    "type": "string"
+                },
+                "sql_fingerprint": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "unhandled",
+                        "solved",
+                        "ignored",
+                        "manual_audited",
+                        "sent"
+                    ]
+                }
+            }
+        },
+        "v1.SqlManageActivity": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "enum": [
+                        "assigned",
+                        "auto_solved",
+                        "reopened"
+                    ]
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "detail": {
+                    "type": "string"
+                },
+                "from_assignees": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "from_status": {
                     "type": "string"
                 },
-                "sql_fingerprint": {
+                "operator": {
                     "type": "string"
                 },
-                "status": {
-                    "type": "string",
-                    "enum": [
-                        "unhandled",
-                        "solved",
-                        "ignored",
-                        "manual_audited",
-                        "sent"
-                    ]
+                "to_assignees": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "to_status": {
+                    "type": "string"
                 }
             }
         },
@@ -18070,6 +21092,68 @@ var doc = `{
                 }
             }
         },
+        "v1.SqlManageApplicationMapping": {
+            "type": "object",
+            "properties": {
+                "application": {
+                    "type": "string"
+                },
+                "desc": {
+                    "type": "string"
+                },
+                "endpoint": {
+                    "description": "the client endpoint of the SQLs, which is an IP, a CIDR or a host name matched fuzzily",
+                    "type": "string"
+                }
+            }
+        },
+        "v1.SqlManageApplicationStatistic": {
+            "type": "object",
+            "properties": {
+                "application": {
+                    "description": "empty means the SQLs not attributed to any application",
+                    "type": "string"
+                },
+                "error_count": {
+                    "type": "integer"
+                },
+                "sql_count": {
+                    "type": "integer"
+                },
+                "unhandled_count": {
+                    "type": "integer"
+                },
+                "warn_count": {
+                    "type": "integer"
+                }
+            }
+        },
+        "v1.SqlManageAssignmentRule": {
+            "type": "object",
+            "properties": {
+                "assignees": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "desc": {
+                    "type": "string"
+                },
+                "match_type": {
+                    "type": "string",
+                    "enum": [
+                        "schema",
+                        "table",
+                        "endpoint"
+                    ]
+                },
+                "pattern": {
+                    "description": "the pattern matched case-insensitively with the schema, the table (table or schema.table) or the endpoint of the SQL, * and ? wildcards are supported",
+                    "type": "string"
+                }
+            }
+        },
         "v1.SqlManageCodingReq": {
             "type": "object",
             "properties": {
@@ -18103,6 +21187,115 @@ var doc = `{
                 }
             }
         },
+        "v1.SqlManageLifecycle": {
+            "type": "object",
+            "properties": {
+                "assignment_rules": {
+                    "description": "the unassigned SQLs are assigned to the assignees of the first rule matched in order",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.SqlManageAssignmentRule"
+                    }
+                },
+                "auto_solve_unseen_days": {
+                    "description": "the SQLs not collected for the days are marked as solved, 0 means never",
+                    "type": "integer"
+                },
+                "reopen_enabled": {
+                    "description": "reopen the solved or ignored SQLs collected again with worse metrics",
+                    "type": "boolean"
+                },
+                "reopen_metric_factor": {
+                    "description": "the metrics are worse if they exceed the factor times of the metrics before the SQL was closed, default 1",
+                    "type": "number"
+                }
+            }
+        },
+        "v1.SqlManageMetricBucket": {
+            "type": "object",
+            "properties": {
+                "execution_count": {
+                    "type": "integer"
+                },
+                "metrics": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number"
+                    }
+                },
+                "record_begin_at": {
+                    "type": "string"
+                },
+                "record_end_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.SqlManageMetricHistory": {
+            "type": "object",
+            "properties": {
+                "buckets": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.SqlManageMetricBucket"
+                    }
+                },
+                "regressions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.SqlManageRegression"
+                    }
+                },
+                "sql_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "v1.SqlManagePriorityRule": {
+            "type": "object",
+            "properties": {
+                "desc": {
+                    "type": "string"
+                },
+                "expression": {
+                    "description": "the boolean expression over audit_level, regression_factor and the metrics of the SQL, e.g. audit_level \u003e= warn AND (query_time_avg \u003e 1 OR row_examined_avg BETWEEN 10000 AND 1000000)",
+                    "type": "string"
+                },
+                "priority": {
+                    "type": "string",
+                    "enum": [
+                        "P0",
+                        "P1",
+                        "P2",
+                        "P3"
+                    ]
+                },
+                "sla_minutes": {
+                    "description": "the unhandled SQLs of the priority are escalated by the sql_manage_sla report push after the minutes, 0 means no SLA",
+                    "type": "integer"
+                }
+            }
+        },
+        "v1.SqlManageRegression": {
+            "type": "object",
+            "properties": {
+                "baseline_value": {
+                    "type": "number"
+                },
+                "current_value": {
+                    "type": "number"
+                },
+                "detected_at": {
+                    "type": "string"
+                },
+                "metric_name": {
+                    "type": "string"
+                },
+                "ratio": {
+                    "type": "number"
+                }
+            }
+        },
         "v1.SqlVersionDetailResV1": {
             "type": "object",
             "properties": {
@@ -18396,6 +21589,124 @@ var doc = `{
                 }
             }
         },
+        "v1.TaskBinlogFlashbackResV1": {
+            "type": "object",
+            "properties": {
+                "exec_sql_ids": {
+                    "description": "the SQLs executed in one transaction are flashed back together",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "exec_sqls": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "flashback_sqls": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "v1.TaskExecPolicy": {
+            "type": "object",
+            "properties": {
+                "blocking_session_seconds": {
+                    "description": "the DDL is not executed if there are sessions holding a transaction or running a query for at least the seconds",
+                    "type": "integer"
+                },
+                "chunk_size": {
+                    "type": "integer"
+                },
+                "chunk_sleep_milliseconds": {
+                    "type": "integer"
+                },
+                "chunked_dml": {
+                    "description": "execute the single table UPDATE/DELETE in chunks of primary key range, each chunk scans chunk_size rows (1000 by default),\nand sleeps chunk_sleep_milliseconds after each chunk, the next chunk waits until the replication lag of replicas is less than max_replication_lag_seconds",
+                    "type": "boolean"
+                },
+                "innodb_lock_wait_timeout_seconds": {
+                    "description": "the innodb_lock_wait_timeout of the MySQL session",
+                    "type": "integer"
+                },
+                "lock_wait_timeout_seconds": {
+                    "description": "the lock_wait_timeout of the MySQL session",
+                    "type": "integer"
+                },
+                "max_replication_lag_seconds": {
+                    "type": "integer"
+                },
+                "replica_addresses": {
+                    "description": "the addresses (host:port) of the replicas to check the replication lag, the addresses reported by the replicas\nregistered to the instance are used if it is empty. The chunked execution is paused if the lag can't be checked",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "10.0.0.2:3306"
+                    ]
+                },
+                "retry_attempts": {
+                    "description": "the times to retry the SQL failed by deadlock or lock wait timeout, the delay between retries doubles from retry_delay_seconds",
+                    "type": "integer"
+                },
+                "retry_delay_seconds": {
+                    "type": "integer"
+                },
+                "statement_timeout_seconds": {
+                    "description": "the timeout of executing a SQL, a whole transaction or a whole batch of SQLs, the execution is killed when timed out\nand the following SQLs of the task are not executed",
+                    "type": "integer"
+                }
+            }
+        },
+        "v1.TaskManualExecutionResV1": {
+            "type": "object",
+            "properties": {
+                "evidence_file_name": {
+                    "type": "string"
+                },
+                "executed_at": {
+                    "type": "string"
+                },
+                "execution_log": {
+                    "type": "string"
+                },
+                "executor": {
+                    "type": "string"
+                },
+                "record_user_name": {
+                    "type": "string"
+                },
+                "recorded_at": {
+                    "type": "string"
+                },
+                "remark": {
+                    "type": "string"
+                },
+                "task_id": {
+                    "type": "integer"
+                },
+                "verified_at": {
+                    "type": "string"
+                },
+                "verify_result": {
+                    "type": "string"
+                },
+                "verify_status": {
+                    "type": "string",
+                    "enum": [
+                        "passed",
+                        "failed",
+                        "skipped"
+                    ]
+                }
+            }
+        },
         "v1.TestAuditPlanNotifyConfigResDataV1": {
             "type": "object",
             "properties": {
@@ -19016,6 +22327,28 @@ var doc = `{
                 }
             }
         },
+        "v1.UpdateSqlManageApplicationMappingsReqV1": {
+            "type": "object",
+            "properties": {
+                "mappings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.SqlManageApplicationMapping"
+                    }
+                }
+            }
+        },
+        "v1.UpdateSqlManagePriorityRulesReqV1": {
+            "type": "object",
+            "properties": {
+                "rules": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.SqlManagePriorityRule"
+                    }
+                }
+            }
+        },
         "v1.UpdateSqlVersionReqV1": {
             "type": "object",
             "properties": {
@@ -19094,20 +22427,50 @@ var doc = `{
                 }
             }
         },
-        "v1.UpdateWechatConfigurationReqV1": {
+        "v1.UpdateWechatConfigurationReqV1": {
+            "type": "object",
+            "required": [
+                "is_wechat_notification_enabled"
+            ],
+            "properties": {
+                "corp_id": {
+                    "type": "string"
+                },
+                "corp_secret": {
+                    "type": "string"
+                },
+                "is_wechat_notification_enabled": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "v1.UpdateWorkflowExecChecksReqV1": {
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.WorkflowExecCheckV1"
+                    }
+                }
+            }
+        },
+        "v1.UpdateWorkflowExecPlanReqV1": {
             "type": "object",
-            "required": [
-                "is_wechat_notification_enabled"
-            ],
             "properties": {
-                "corp_id": {
-                    "type": "string"
-                },
-                "corp_secret": {
-                    "type": "string"
+                "max_concurrency": {
+                    "description": "the max number of the tasks executed at the same time, 0 means no limit",
+                    "type": "integer"
                 },
-                "is_wechat_notification_enabled": {
+                "stop_on_failure": {
+                    "description": "don't start any other task after a task fails, otherwise only the tasks depending on the failed task are not started",
                     "type": "boolean"
+                },
+                "task_dependencies": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.WorkflowTaskDependencyV1"
+                    }
                 }
             }
         },
@@ -19192,6 +22555,15 @@ var doc = `{
         "v1.WorkFlowStepTemplateReqV1": {
             "type": "object",
             "properties": {
+                "approval_group_list": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.WorkflowApprovalGroupV1"
+                    }
+                },
+                "approval_min_count": {
+                    "type": "integer"
+                },
                 "approved_by_authorized": {
                     "type": "boolean"
                 },
@@ -19201,9 +22573,23 @@ var doc = `{
                         "type": "string"
                     }
                 },
+                "condition": {
+                    "description": "the condition, the multiple approvals and the escalation are only supported by sql_review step",
+                    "type": "object",
+                    "$ref": "#/definitions/v1.WorkflowStepConditionV1"
+                },
                 "desc": {
                     "type": "string"
                 },
+                "escalation_timeout_minutes": {
+                    "type": "integer"
+                },
+                "escalation_user_id_list": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "execute_by_authorized": {
                     "type": "boolean"
                 },
@@ -19219,6 +22605,15 @@ var doc = `{
         "v1.WorkFlowStepTemplateResV1": {
             "type": "object",
             "properties": {
+                "approval_group_list": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.WorkflowApprovalGroupV1"
+                    }
+                },
+                "approval_min_count": {
+                    "type": "integer"
+                },
                 "approved_by_authorized": {
                     "type": "boolean"
                 },
@@ -19228,9 +22623,23 @@ var doc = `{
                         "type": "string"
                     }
                 },
+                "condition": {
+                    "type": "object",
+                    "$ref": "#/definitions/v1.WorkflowStepConditionV1"
+                },
                 "desc": {
                     "type": "string"
                 },
+                "escalation_timeout_minutes": {
+                    "description": "the step is escalated to the escalation users when it waits for review more than the timeout",
+                    "type": "integer"
+                },
+                "escalation_user_id_list": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "execute_by_authorized": {
                     "type": "boolean"
                 },
@@ -19242,6 +22651,49 @@ var doc = `{
                 }
             }
         },
+        "v1.WorkflowApprovalDelegateResV1": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "delegate_user_name": {
+                    "type": "string"
+                },
+                "end_time": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "start_time": {
+                    "type": "string"
+                },
+                "user_name": {
+                    "type": "string"
+                },
+                "workflow_approval_delegate_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "v1.WorkflowApprovalGroupV1": {
+            "type": "object",
+            "properties": {
+                "assignee_user_id_list": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "min_approvals": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
         "v1.WorkflowAuditPassPercentV1": {
             "type": "object",
             "properties": {
@@ -19396,6 +22848,161 @@ var doc = `{
                 }
             }
         },
+        "v1.WorkflowExecCheckResultV1": {
+            "type": "object",
+            "properties": {
+                "checked_at": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "passed": {
+                    "type": "boolean"
+                },
+                "stage": {
+                    "type": "string",
+                    "enum": [
+                        "pre",
+                        "post"
+                    ]
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "sql_assertion",
+                        "webhook",
+                        "long_transaction"
+                    ]
+                }
+            }
+        },
+        "v1.WorkflowExecCheckV1": {
+            "type": "object",
+            "properties": {
+                "expected": {
+                    "type": "string"
+                },
+                "instance_name": {
+                    "description": "the instance of the workflow to check, all the instances of the workflow are checked if it is empty",
+                    "type": "string"
+                },
+                "max_transaction_seconds": {
+                    "description": "long_transaction: the check is passed if there is no transaction lasting at least the seconds, only supports MySQL",
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "operator": {
+                    "type": "string",
+                    "enum": [
+                        "=",
+                        "!=",
+                        "\u003e",
+                        "\u003e=",
+                        "\u003c",
+                        "\u003c="
+                    ]
+                },
+                "rollback_on_failure": {
+                    "description": "roll back the task if the post check fails",
+                    "type": "boolean"
+                },
+                "sql": {
+                    "description": "sql_assertion: the first column of the first row returned by the query is compared with the expected value",
+                    "type": "string"
+                },
+                "stage": {
+                    "type": "string",
+                    "enum": [
+                        "pre",
+                        "post"
+                    ]
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "sql_assertion",
+                        "webhook",
+                        "long_transaction"
+                    ]
+                },
+                "url": {
+                    "description": "webhook: the check is passed if the POST request to the URL responds 2xx",
+                    "type": "string"
+                }
+            }
+        },
+        "v1.WorkflowExecChecksResV1": {
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.WorkflowExecCheckV1"
+                    }
+                },
+                "tasks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.WorkflowTaskExecCheckResultV1"
+                    }
+                }
+            }
+        },
+        "v1.WorkflowExecPlanResV1": {
+            "type": "object",
+            "properties": {
+                "max_concurrency": {
+                    "type": "integer"
+                },
+                "stop_on_failure": {
+                    "type": "boolean"
+                },
+                "tasks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.WorkflowExecPlanTaskResV1"
+                    }
+                }
+            }
+        },
+        "v1.WorkflowExecPlanTaskResV1": {
+            "type": "object",
+            "properties": {
+                "depends_on": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "instance_name": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "wait_for_audit",
+                        "wait_for_execution",
+                        "exec_scheduled",
+                        "exec_failed",
+                        "exec_succeeded",
+                        "executing",
+                        "manually_executed",
+                        "terminating",
+                        "terminate_succeeded",
+                        "terminate_failed"
+                    ]
+                },
+                "task_id": {
+                    "type": "integer"
+                }
+            }
+        },
         "v1.WorkflowPassPercentV1": {
             "type": "object",
             "properties": {
@@ -19597,6 +23204,40 @@ var doc = `{
                 }
             }
         },
+        "v1.WorkflowStepConditionV1": {
+            "type": "object",
+            "properties": {
+                "affected_rows_more_than": {
+                    "type": "integer"
+                },
+                "audit_level": {
+                    "type": "string",
+                    "enum": [
+                        "normal",
+                        "notice",
+                        "warn",
+                        "error"
+                    ]
+                },
+                "instance_environment_tag_list": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "sql_type_list": {
+                    "type": "array",
+                    "items": {
+                        "type": "string",
+                        "enum": [
+                            "ddl",
+                            "dml",
+                            "dql"
+                        ]
+                    }
+                }
+            }
+        },
         "v1.WorkflowStepResV1": {
             "type": "object",
             "properties": {
@@ -19626,7 +23267,8 @@ var doc = `{
                     "enum": [
                         "initialized",
                         "approved",
-                        "rejected"
+                        "rejected",
+                        "skipped"
                     ]
                 },
                 "type": {
@@ -19643,6 +23285,42 @@ var doc = `{
                 }
             }
         },
+        "v1.WorkflowTaskDependencyV1": {
+            "type": "object",
+            "properties": {
+                "depends_on": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "task_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "v1.WorkflowTaskExecCheckResultV1": {
+            "type": "object",
+            "properties": {
+                "exec_check_status": {
+                    "type": "string",
+                    "enum": [
+                        "passed",
+                        "pre_check_failed",
+                        "post_check_failed"
+                    ]
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v1.WorkflowExecCheckResultV1"
+                    }
+                },
+                "task_id": {
+                    "type": "integer"
+                }
+            }
+        },
         "v1.WorkflowTaskItem": {
             "type": "object",
             "properties": {
@@ -20252,9 +23930,17 @@ var doc = `{
                 "backup_strategy_tip": {
                     "type": "string"
                 },
+                "binlog": {
+                    "type": "object",
+                    "$ref": "#/definitions/v2.TaskSQLBinlogResV2"
+                },
                 "description": {
                     "type": "string"
                 },
+                "exec_duration": {
+                    "description": "execution duration in milliseconds, the SQLs executed in a transaction or batch share the duration",
+                    "type": "integer"
+                },
                 "exec_result": {
                     "type": "string"
                 },
@@ -20267,9 +23953,19 @@ var doc = `{
                 "exec_status": {
                     "type": "string"
                 },
+                "exec_warnings": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v2.TaskSQLExecWarningResV2"
+                    }
+                },
                 "number": {
                     "type": "integer"
                 },
+                "result_preview": {
+                    "type": "object",
+                    "$ref": "#/definitions/v2.TaskSQLResultPreviewResV2"
+                },
                 "rollback_sqls": {
                     "type": "array",
                     "items": {
@@ -20699,6 +24395,23 @@ var doc = `{
                 }
             }
         },
+        "v2.GetWorkflowAuditDiffResV2": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "integer",
+                    "example": 0
+                },
+                "data": {
+                    "type": "object",
+                    "$ref": "#/definitions/v2.WorkflowAuditDiffResV2"
+                },
+                "message": {
+                    "type": "string",
+                    "example": "ok"
+                }
+            }
+        },
         "v2.GetWorkflowResV2": {
             "type": "object",
             "properties": {
@@ -20743,6 +24456,9 @@ var doc = `{
                 "instance_name": {
                     "type": "string"
                 },
+                "instance_schema": {
+                    "type": "string"
+                },
                 "schedule_time": {
                     "type": "string"
                 },
@@ -20882,6 +24598,73 @@ var doc = `{
                 }
             }
         },
+        "v2.SQLAuditDiffRes": {
+            "type": "object",
+            "properties": {
+                "base_audit_level": {
+                    "type": "string"
+                },
+                "base_number": {
+                    "type": "integer"
+                },
+                "base_sql": {
+                    "type": "string"
+                },
+                "diff_type": {
+                    "type": "string",
+                    "enum": [
+                        "added",
+                        "removed",
+                        "modified",
+                        "unchanged"
+                    ]
+                },
+                "introduced_audit_results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v2.AuditResult"
+                    }
+                },
+                "resolved_audit_results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v2.AuditResult"
+                    }
+                },
+                "target_audit_level": {
+                    "type": "string"
+                },
+                "target_number": {
+                    "type": "integer"
+                },
+                "target_sql": {
+                    "type": "string"
+                }
+            }
+        },
+        "v2.SQLAuditDiffSummaryRes": {
+            "type": "object",
+            "properties": {
+                "added_count": {
+                    "type": "integer"
+                },
+                "introduced_audit_result_count": {
+                    "type": "integer"
+                },
+                "modified_count": {
+                    "type": "integer"
+                },
+                "removed_count": {
+                    "type": "integer"
+                },
+                "resolved_audit_result_count": {
+                    "type": "integer"
+                },
+                "unchanged_count": {
+                    "type": "integer"
+                }
+            }
+        },
         "v2.SQLExplain": {
             "type": "object",
             "properties": {
@@ -21015,6 +24798,93 @@ var doc = `{
                 }
             }
         },
+        "v2.TaskAuditDiffRes": {
+            "type": "object",
+            "properties": {
+                "base_task_id": {
+                    "type": "integer"
+                },
+                "instance_name": {
+                    "type": "string"
+                },
+                "instance_schema": {
+                    "type": "string"
+                },
+                "sqls": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v2.SQLAuditDiffRes"
+                    }
+                },
+                "summary": {
+                    "type": "object",
+                    "$ref": "#/definitions/v2.SQLAuditDiffSummaryRes"
+                },
+                "target_task_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "v2.TaskSQLBinlogResV2": {
+            "type": "object",
+            "properties": {
+                "end_binlog_file": {
+                    "type": "string"
+                },
+                "end_binlog_pos": {
+                    "type": "integer"
+                },
+                "end_gtid_set": {
+                    "type": "string"
+                },
+                "start_binlog_file": {
+                    "type": "string"
+                },
+                "start_binlog_pos": {
+                    "type": "integer"
+                },
+                "start_gtid_set": {
+                    "type": "string"
+                }
+            }
+        },
+        "v2.TaskSQLExecWarningResV2": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "level": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "v2.TaskSQLResultPreviewResV2": {
+            "type": "object",
+            "properties": {
+                "columns": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "rows": {
+                    "type": "array",
+                    "items": {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        }
+                    }
+                },
+                "truncated": {
+                    "type": "boolean"
+                }
+            }
+        },
         "v2.UpdateWorkflowReqV2": {
             "type": "object",
             "properties": {
@@ -21058,6 +24928,74 @@ var doc = `{
                 }
             }
         },
+        "v2.WorkflowAuditDiffResV2": {
+            "type": "object",
+            "properties": {
+                "base_record_id": {
+                    "type": "integer"
+                },
+                "target_record_id": {
+                    "type": "integer"
+                },
+                "tasks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v2.TaskAuditDiffRes"
+                    }
+                }
+            }
+        },
+        "v2.WorkflowExecutionFreezeResV2": {
+            "type": "object",
+            "properties": {
+                "freeze_name_list": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "freeze_override": {
+                    "type": "object",
+                    "$ref": "#/definitions/v2.WorkflowFreezeOverrideResV2"
+                },
+                "frozen": {
+                    "type": "boolean"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "schedule_blocked": {
+                    "description": "the scheduled execution is blocked by the freeze and retried at schedule_retry_at",
+                    "type": "boolean"
+                },
+                "schedule_blocked_reason": {
+                    "type": "string"
+                },
+                "schedule_retry_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "v2.WorkflowFreezeOverrideResV2": {
+            "type": "object",
+            "properties": {
+                "apply_user_name": {
+                    "type": "string"
+                },
+                "approve_user_name": {
+                    "type": "string"
+                },
+                "approved": {
+                    "type": "boolean"
+                },
+                "approved_at": {
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                }
+            }
+        },
         "v2.WorkflowRecordResV2": {
             "type": "object",
             "properties": {
@@ -21088,6 +25026,9 @@ var doc = `{
                         "$ref": "#/definitions/v2.WorkflowTaskItem"
                     }
                 },
+                "workflow_record_id": {
+                    "type": "integer"
+                },
                 "workflow_step_list": {
                     "type": "array",
                     "items": {
@@ -21127,6 +25068,10 @@ var doc = `{
                         "sqls"
                     ]
                 },
+                "execution_freeze": {
+                    "type": "object",
+                    "$ref": "#/definitions/v2.WorkflowExecutionFreezeResV2"
+                },
                 "mode": {
                     "type": "string",
                     "enum": [
@@ -21156,9 +25101,75 @@ var doc = `{
                 }
             }
         },
+        "v2.WorkflowStepApprovalGroupV2": {
+            "type": "object",
+            "properties": {
+                "approved_user_name_list": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "assignee_user_name_list": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "min_approvals": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "v2.WorkflowStepEventResV2": {
+            "type": "object",
+            "properties": {
+                "from_user_name_list": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "time": {
+                    "type": "string"
+                },
+                "to_user_name_list": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "type": {
+                    "type": "string",
+                    "enum": [
+                        "delegate",
+                        "escalate"
+                    ]
+                }
+            }
+        },
         "v2.WorkflowStepResV2": {
             "type": "object",
             "properties": {
+                "approval_group_list": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v2.WorkflowStepApprovalGroupV2"
+                    }
+                },
+                "approval_min_count": {
+                    "type": "integer"
+                },
+                "approved_user_name_list": {
+                    "description": "the users who have approved the step which requires multiple approvals",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "assignee_user_name_list": {
                     "type": "array",
                     "items": {
@@ -21168,6 +25179,13 @@ var doc = `{
                 "desc": {
                     "type": "string"
                 },
+                "event_list": {
+                    "description": "the delegation and escalation events of the step",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v2.WorkflowStepEventResV2"
+                    }
+                },
                 "number": {
                     "type": "integer"
                 },
@@ -21185,7 +25203,8 @@ var doc = `{
                     "enum": [
                         "initialized",
                         "approved",
-                        "rejected"
+                        "rejected",
+                        "skipped"
                     ]
                 },
                 "type": {
@@ -0,0 +1,125 @@
+package mysql
+
+import (
+	"context"
+	_driver "database/sql/driver"
+	"fmt"
+
+	"github.com/actiontech/sqle/sqle/driver"
+	"github.com/actiontech/sqle/sqle/driver/mysql/executor"
+)
+
+var _ driver.ExecOutputCollector = (*MysqlDriverImpl)(nil)
+
+// ShowWarnings returns the warnings of the last SQL executed on the connection of the driver.
+func (i *MysqlDriverImpl) ShowWarnings(ctx context.Context) ([]*driver.ExecWarning, error) {
+	if i.IsOfflineAudit() {
+		return nil, nil
+	}
+	conn, err := i.getDbConn()
+	if err != nil {
+		return nil, err
+	}
+	_, rows, err := conn.Db.QueryWithContext(ctx, "SHOW WARNINGS")
+	if err != nil {
+		return nil, err
+	}
+	warnings := make([]*driver.ExecWarning, 0, len(rows))
+	for _, row := range rows {
+		// the columns of SHOW WARNINGS are Level, Code and Message
+		if len(row) < 3 {
+			continue
+		}
+		warnings = append(warnings, &driver.ExecWarning{
+			Level:   row[0].String,
+			Code:    row[1].String,
+			Message: row[2].String,
+		})
+	}
+	return warnings, nil
+}
+
+// QueryPreview executes the SQL returning rows on the connection of the driver and returns at most maxRows rows.
+func (i *MysqlDriverImpl) QueryPreview(ctx context.Context, sql string, maxRows int) (*driver.QueryPreviewResult, error) {
+	if i.IsOfflineAudit() {
+		return &driver.QueryPreviewResult{}, nil
+	}
+	conn, err := i.getDbConn()
+	if err != nil {
+		return nil, err
+	}
+	columns, rows, truncated, err := conn.Db.QueryWithLimit(ctx, maxRows, sql)
+	if err != nil {
+		return nil, err
+	}
+	result := &driver.QueryPreviewResult{
+		Columns:   columns,
+		Rows:      make([][]string, 0, len(rows)),
+		Truncated: truncated,
+	}
+	for _, row := range rows {
+		values := make([]string, 0, len(row))
+		for _, value := range row {
+			if !value.Valid {
+				values = append(values, "NULL")
+				continue
+			}
+			values = append(values, value.String)
+		}
+		result.Rows = append(result.Rows, values)
+	}
+	return result, nil
+}
+
+// TxWithWarnings executes the SQLs in a transaction and returns the warnings of each SQL, the warnings are
+// queried in the transaction.
+func (i *MysqlDriverImpl) TxWithWarnings(ctx context.Context, queries ...string) ([]_driver.Result, [][]*driver.ExecWarning, error) {
+	if i.IsOfflineAudit() {
+		return nil, nil, nil
+	}
+	conn, err := i.getDbConn()
+	if err != nil {
+		return nil, nil, err
+	}
+	results, records, err := conn.Db.TransactWithWarnings(queries...)
+	warnings := make([][]*driver.ExecWarning, 0, len(records))
+	for _, record := range records {
+		warnings = append(warnings, convertWarningsRecords(record))
+	}
+	return results, warnings, err
+}
+
+// ExecBatchWithWarnings executes the SQLs one by one like ExecBatch and returns the warnings of each SQL, the SQL
+// is not failed if its warnings can't be queried.
+func (i *MysqlDriverImpl) ExecBatchWithWarnings(ctx context.Context, queries ...string) ([]_driver.Result, [][]*driver.ExecWarning, error) {
+	results := make([]_driver.Result, 0, len(queries))
+	warnings := make([][]*driver.ExecWarning, 0, len(queries))
+	for _, sql := range queries {
+		result, err := i.Exec(ctx, sql)
+		results = append(results, result)
+		if err != nil {
+			return results, warnings, fmt.Errorf("exec sql failed: \n%s \n%v", sql, err)
+		}
+		sqlWarnings, err := i.ShowWarnings(ctx)
+		if err != nil {
+			i.log.Warnf("show warnings of sql %v failed: %v", sql, err)
+		}
+		warnings = append(warnings, sqlWarnings)
+	}
+	return results, warnings, nil
+}
+
+func convertWarningsRecords(records []*executor.WarningsRecord) []*driver.ExecWarning {
+	if records == nil {
+		return nil
+	}
+	warnings := make([]*driver.ExecWarning, 0, len(records))
+	for _, record := range records {
+		warnings = append(warnings, &driver.ExecWarning{
+			Level:   record.Level,
+			Code:    record.Code,
+			Message: record.Message,
+		})
+	}
+	return warnings
+}
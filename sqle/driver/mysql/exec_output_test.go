@@ -0,0 +1,55 @@
+package mysql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/actiontech/sqle/sqle/driver"
+	"github.com/actiontech/sqle/sqle/driver/mysql/executor"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMysqlDriverImpl_TxWithWarnings(t *testing.T) {
+	e, handler, err := executor.NewMockExecutor()
+	assert.NoError(t, err)
+	inspect := NewMockInspect(e)
+	inspect.isConnected = true
+
+	handler.ExpectBegin()
+	handler.ExpectExec("UPDATE t1 SET c1 = 'abc'").WillReturnResult(sqlmock.NewResult(0, 1))
+	handler.ExpectQuery("SHOW WARNINGS").WillReturnRows(sqlmock.NewRows([]string{"Level", "Code", "Message"}).
+		AddRow("Warning", "1265", "Data truncated for column 'c1' at row 1"))
+	handler.ExpectExec("DELETE FROM t1").WillReturnResult(sqlmock.NewResult(0, 2))
+	handler.ExpectQuery("SHOW WARNINGS").WillReturnRows(sqlmock.NewRows([]string{"Level", "Code", "Message"}))
+	handler.ExpectCommit()
+
+	results, warnings, err := inspect.TxWithWarnings(context.TODO(), "UPDATE t1 SET c1 = 'abc'", "DELETE FROM t1")
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+	assert.Equal(t, [][]*driver.ExecWarning{
+		{{Level: "Warning", Code: "1265", Message: "Data truncated for column 'c1' at row 1"}},
+		{},
+	}, warnings)
+	assert.NoError(t, handler.ExpectationsWereMet())
+}
+
+func TestMysqlDriverImpl_ExecBatchWithWarnings(t *testing.T) {
+	e, handler, err := executor.NewMockExecutor()
+	assert.NoError(t, err)
+	inspect := NewMockInspect(e)
+	inspect.isConnected = true
+
+	handler.ExpectExec("UPDATE t1 SET c1 = 'abc'").WillReturnResult(sqlmock.NewResult(0, 1))
+	handler.ExpectQuery("SHOW WARNINGS").WillReturnRows(sqlmock.NewRows([]string{"Level", "Code", "Message"}).
+		AddRow("Warning", "1265", "Data truncated for column 'c1' at row 1"))
+	handler.ExpectExec("DELETE FROM t1").WillReturnError(assert.AnError)
+
+	results, warnings, err := inspect.ExecBatchWithWarnings(context.TODO(), "UPDATE t1 SET c1 = 'abc'", "DELETE FROM t1")
+	assert.Error(t, err)
+	assert.Len(t, results, 2)
+	assert.Equal(t, [][]*driver.ExecWarning{
+		{{Level: "Warning", Code: "1265", Message: "Data truncated for column 'c1' at row 1"}},
+	}, warnings)
+	assert.NoError(t, handler.ExpectationsWereMet())
+}
@@ -24,8 +24,10 @@ type Db interface {
 	Ping() error
 	Exec(query string) (driver.Result, error)
 	Transact(qs ...string) ([]driver.Result, error)
+	TransactWithWarnings(qs ...string) ([]driver.Result, [][]*WarningsRecord, error)
 	Query(query string, args ...interface{}) ([]map[string]sql.NullString, error)
 	QueryWithContext(ctx context.Context, query string, args ...interface{}) (column []string, row [][]sql.NullString, err error)
+	QueryWithLimit(ctx context.Context, maxRows int, query string, args ...interface{}) (column []string, row [][]sql.NullString, truncated bool, err error)
 	Logger() *logrus.Entry
 	GetConnectionID() string
 }
@@ -138,6 +140,32 @@ func (c *BaseConn) Exec(query string) (driver.Result, error) {
 }
 
 func (c *BaseConn) Transact(qs ...string) ([]driver.Result, error) {
+	return c.transact(qs, nil)
+}
+
+// TransactWithWarnings executes the SQLs in a transaction like Transact, the warnings of each SQL are queried
+// in the transaction right after the SQL is executed. The SQL is not failed if its warnings can't be queried.
+func (c *BaseConn) TransactWithWarnings(qs ...string) ([]driver.Result, [][]*WarningsRecord, error) {
+	warnings := make([][]*WarningsRecord, 0, len(qs))
+	results, err := c.transact(qs, func(tx *sql.Tx) {
+		rows, err := tx.Query("SHOW WARNINGS")
+		if err != nil {
+			c.Logger().Warnf("show warnings failed, error: %s", err)
+			warnings = append(warnings, nil)
+			return
+		}
+		defer rows.Close()
+		records, err := scanWarningsRecords(rows)
+		if err != nil {
+			c.Logger().Warnf("show warnings failed, error: %s", err)
+		}
+		warnings = append(warnings, records)
+	})
+	return results, warnings, err
+}
+
+// transact executes the SQLs in a transaction, afterExec is called after each SQL is executed successfully.
+func (c *BaseConn) transact(qs []string, afterExec func(tx *sql.Tx)) ([]driver.Result, error) {
 	var err error
 	var tx *sql.Tx
 	var results []driver.Result
@@ -177,16 +205,25 @@ func (c *BaseConn) Transact(qs ...string) ([]driver.Result, error) {
 		} else {
 			results = append(results, txResult)
 			c.Logger().Infof("exec sql success, query: %s", query)
+			if afterExec != nil {
+				afterExec(tx)
+			}
 		}
 	}
 	return results, nil
 }
 func (c *BaseConn) QueryWithContext(ctx context.Context, query string, args ...interface{}) (column []string, row [][]sql.NullString, err error) {
+	column, row, _, err = c.QueryWithLimit(ctx, 0, query, args...)
+	return column, row, err
+}
+
+// QueryWithLimit returns at most maxRows rows of the query, truncated is true if there are more rows. maxRows 0 means no limit.
+func (c *BaseConn) QueryWithLimit(ctx context.Context, maxRows int, query string, args ...interface{}) (column []string, row [][]sql.NullString, truncated bool, err error) {
 	rows, err := c.conn.QueryContext(ctx, query, args...)
 	if err != nil {
 		c.Logger().Errorf("query sql failed; host: %s, port: %s, user: %s, query: %s, error: %s\n",
 			c.host, c.port, c.user, query, err.Error())
-		return nil, nil, errors.New(errors.ConnectRemoteDatabaseError, err)
+		return nil, nil, false, errors.New(errors.ConnectRemoteDatabaseError, err)
 	} else {
 		c.Logger().Infof("query sql success; host: %s, port: %s, user: %s, query: %s\n",
 			c.host, c.port, c.user, query)
@@ -196,10 +233,14 @@ func (c *BaseConn) QueryWithContext(ctx context.Context, query string, args ...i
 	if err != nil {
 		// unknown error
 		c.Logger().Error(err)
-		return nil, nil, err
+		return nil, nil, false, err
 	}
 	result := make([][]sql.NullString, 0)
 	for rows.Next() {
+		if maxRows > 0 && len(result) >= maxRows {
+			truncated = true
+			break
+		}
 		buf := make([]interface{}, len(columns))
 		data := make([]sql.NullString, len(columns))
 		for i := range buf {
@@ -207,7 +248,7 @@ func (c *BaseConn) QueryWithContext(ctx context.Context, query string, args ...i
 		}
 		if err := rows.Scan(buf...); err != nil {
 			c.Logger().Error(err)
-			return nil, nil, err
+			return nil, nil, false, err
 		}
 		value := make([]sql.NullString, len(columns))
 		for i := 0; i < len(columns); i++ {
@@ -216,9 +257,9 @@ func (c *BaseConn) QueryWithContext(ctx context.Context, query string, args ...i
 		result = append(result, value)
 	}
 	if err = rows.Err(); err != nil {
-		return nil, nil, err
+		return nil, nil, false, err
 	}
-	return columns, result, nil
+	return columns, result, truncated, nil
 }
 
 func (c *BaseConn) Query(query string, args ...interface{}) ([]map[string]sql.NullString, error) {
@@ -617,6 +658,19 @@ func (c *Executor) ShowWarningsRecord() ([]*WarningsRecord, error) {
 	return ret, nil
 }
 
+// scanWarningsRecords scans the rows of SHOW WARNINGS, the columns are Level, Code and Message.
+func scanWarningsRecords(rows *sql.Rows) ([]*WarningsRecord, error) {
+	records := []*WarningsRecord{}
+	for rows.Next() {
+		record := &WarningsRecord{}
+		if err := rows.Scan(&record.Level, &record.Code, &record.Message); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
+
 func (c *Executor) GetExplainRecord(query string) ([]*ExplainRecord, error) {
 	columns, rows, err := c.Explain(query)
 	if err != nil {
@@ -668,20 +722,81 @@ func (c *Executor) ShowMasterStatus() ([]map[string]sql.NullString, error) {
 }
 
 func (c *Executor) FetchMasterBinlogPos() (string, int64, error) {
+	status, err := c.FetchMasterBinlogStatus()
+	if err != nil || status == nil {
+		return "", 0, err
+	}
+	return status.File, status.Pos, nil
+}
+
+// BinlogStatus is the current binlog coordinates and the executed GTID set of the server.
+type BinlogStatus struct {
+	File    string
+	Pos     int64
+	GtidSet string
+}
+
+// FetchMasterBinlogStatus returns nil if the binlog is disabled.
+func (c *Executor) FetchMasterBinlogStatus() (*BinlogStatus, error) {
 	result, err := c.ShowMasterStatus()
 	if err != nil {
-		return "", 0, err
+		return nil, err
 	}
 	if len(result) == 0 {
-		return "", 0, nil
+		return nil, nil
 	}
-	file := result[0]["File"].String
 	pos, err := strconv.ParseInt(result[0]["Position"].String, 10, 64)
 	if err != nil {
 		c.Db.Logger().Error(err)
-		return "", 0, err
+		return nil, err
+	}
+	return &BinlogStatus{
+		File:    result[0]["File"].String,
+		Pos:     pos,
+		GtidSet: result[0]["Executed_Gtid_Set"].String,
+	}, nil
+}
+
+// BlockingSession is a session which holds a transaction or runs a query, it may block the DDL by metadata lock.
+type BlockingSession struct {
+	Id         string
+	User       string
+	Host       string
+	DB         string
+	Command    string
+	Time       string
+	State      string
+	Info       string
+	TrxStarted string
+}
+
+// ShowBlockingSessions returns the other sessions whose transaction or query has lasted at least minSeconds.
+func (c *Executor) ShowBlockingSessions(minSeconds uint) ([]*BlockingSession, error) {
+	query := `SELECT p.ID, p.USER, p.HOST, p.DB, p.COMMAND, p.TIME, p.STATE, LEFT(p.INFO, 1024) AS INFO, t.trx_started AS TRX_STARTED
+FROM information_schema.PROCESSLIST AS p
+LEFT JOIN information_schema.INNODB_TRX AS t ON t.trx_mysql_thread_id = p.ID
+WHERE p.ID <> CONNECTION_ID()
+AND (t.trx_started <= NOW() - INTERVAL ? SECOND OR (p.COMMAND = 'Query' AND p.TIME >= ?))
+ORDER BY p.TIME DESC`
+	records, err := c.Db.Query(query, minSeconds, minSeconds)
+	if err != nil {
+		return nil, err
 	}
-	return file, pos, nil
+	ret := make([]*BlockingSession, len(records))
+	for i, record := range records {
+		ret[i] = &BlockingSession{
+			Id:         record["ID"].String,
+			User:       record["USER"].String,
+			Host:       record["HOST"].String,
+			DB:         record["DB"].String,
+			Command:    record["COMMAND"].String,
+			Time:       record["TIME"].String,
+			State:      record["STATE"].String,
+			Info:       record["INFO"].String,
+			TrxStarted: record["TRX_STARTED"].String,
+		}
+	}
+	return ret, nil
 }
 
 func (c *Executor) ShowTableSizeMB(schema, table string) (float64, error) {
@@ -779,6 +894,7 @@ type TableIndexesInfo struct {
 	NonUnique   string
 	SeqInIndex  string
 	Cardinality string
+	SubPart     string
 	Null        string
 	IndexType   string
 	Comment     string
@@ -803,6 +919,7 @@ func (c *Executor) GetTableIndexesInfo(schema, tableName string) ([]*TableIndexe
 			NonUnique:   record["Non_unique"].String,
 			SeqInIndex:  record["Seq_in_index"].String,
 			Cardinality: record["Cardinality"].String,
+			SubPart:     record["Sub_part"].String,
 			Null:        record["Null"].String,
 			IndexType:   record["Index_type"].String,
 			Comment:     record["Comment"].String,
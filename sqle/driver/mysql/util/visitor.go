@@ -133,6 +133,23 @@ func (te *TableNameExtractor) Leave(in ast.Node) (node ast.Node, ok bool) {
 	return in, true
 }
 
+// TableNameListExtractor implements ast.Visitor interface, unlike TableNameExtractor it keeps all the table names,
+// including the tables with the same name in different databases.
+type TableNameListExtractor struct {
+	TableNames []*ast.TableName
+}
+
+func (te *TableNameListExtractor) Enter(in ast.Node) (node ast.Node, skipChildren bool) {
+	if stmt, ok := in.(*ast.TableName); ok {
+		te.TableNames = append(te.TableNames, stmt)
+	}
+	return in, false
+}
+
+func (te *TableNameListExtractor) Leave(in ast.Node) (node ast.Node, ok bool) {
+	return in, true
+}
+
 type SelectStmtExtractor struct {
 	SelectStmts []*ast.SelectStmt
 }
@@ -53,6 +53,32 @@ type Plugin interface {
 	RecommendBackupStrategy(ctx context.Context, sql string) (*RecommendBackupStrategyRes, error)
 }
 
+// ExecOutputCollector is optionally implemented by Plugin, it collects the output of the SQL executed
+// on the session of the plugin. Only the built-in MySQL plugin implements it now.
+type ExecOutputCollector interface {
+	// ShowWarnings returns the warnings of the last SQL executed by the plugin.
+	ShowWarnings(ctx context.Context) ([]*ExecWarning, error)
+	// QueryPreview executes the SQL returning rows and returns at most maxRows rows.
+	QueryPreview(ctx context.Context, sql string, maxRows int) (*QueryPreviewResult, error)
+	// TxWithWarnings executes the SQLs in a transaction like Tx and returns the warnings of each SQL executed.
+	TxWithWarnings(ctx context.Context, queries ...string) ([]driver.Result, [][]*ExecWarning, error)
+	// ExecBatchWithWarnings executes the SQLs like ExecBatch and returns the warnings of each SQL executed.
+	ExecBatchWithWarnings(ctx context.Context, queries ...string) ([]driver.Result, [][]*ExecWarning, error)
+}
+
+type ExecWarning struct {
+	Level   string
+	Code    string
+	Message string
+}
+
+type QueryPreviewResult struct {
+	Columns []string
+	Rows    [][]string
+	// Truncated is true if the SQL returns more than maxRows rows
+	Truncated bool
+}
+
 type RecommendBackupStrategyRes struct {
 	BackupStrategy    string
 	BackupStrategyTip string
@@ -54,14 +54,27 @@ var (
 	SQLExecuteStatusManuallyExecuted = &i18n.Message{ID: "SQLExecuteStatusManuallyExecuted", Other: "人工执行"}
 	SQLExecuteStatusUnknown          = &i18n.Message{ID: "SQLExecuteStatusUnknown", Other: "未知"}
 
-	TaskSQLReportIndex       = &i18n.Message{ID: "TaskSQLReportIndex", Other: "序号"}
-	TaskSQLReportSQL         = &i18n.Message{ID: "TaskSQLReportSQL", Other: "SQL"}
-	TaskSQLReportAuditStatus = &i18n.Message{ID: "TaskSQLReportAuditStatus", Other: "SQL审核状态"}
-	TaskSQLReportAuditResult = &i18n.Message{ID: "TaskSQLReportAuditResult", Other: "SQL审核结果"}
-	TaskSQLReportExecStatus  = &i18n.Message{ID: "TaskSQLReportExecStatus", Other: "SQL执行状态"}
-	TaskSQLReportExecResult  = &i18n.Message{ID: "TaskSQLReportExecResult", Other: "SQL执行结果"}
-	TaskSQLReportRollbackSQL = &i18n.Message{ID: "TaskSQLReportRollbackSQL", Other: "SQL对应的回滚语句"}
-	TaskSQLReportDescription = &i18n.Message{ID: "TaskSQLReportDescription", Other: "SQL描述"}
+	TaskSQLReportIndex         = &i18n.Message{ID: "TaskSQLReportIndex", Other: "序号"}
+	TaskSQLReportSQL           = &i18n.Message{ID: "TaskSQLReportSQL", Other: "SQL"}
+	TaskSQLReportAuditStatus   = &i18n.Message{ID: "TaskSQLReportAuditStatus", Other: "SQL审核状态"}
+	TaskSQLReportAuditResult   = &i18n.Message{ID: "TaskSQLReportAuditResult", Other: "SQL审核结果"}
+	TaskSQLReportExecStatus    = &i18n.Message{ID: "TaskSQLReportExecStatus", Other: "SQL执行状态"}
+	TaskSQLReportExecResult    = &i18n.Message{ID: "TaskSQLReportExecResult", Other: "SQL执行结果"}
+	TaskSQLReportRollbackSQL   = &i18n.Message{ID: "TaskSQLReportRollbackSQL", Other: "SQL对应的回滚语句"}
+	TaskSQLReportDescription   = &i18n.Message{ID: "TaskSQLReportDescription", Other: "SQL描述"}
+	TaskSQLReportExecDuration  = &i18n.Message{ID: "TaskSQLReportExecDuration", Other: "SQL执行耗时(毫秒)"}
+	TaskSQLReportExecWarnings  = &i18n.Message{ID: "TaskSQLReportExecWarnings", Other: "SQL执行告警"}
+	TaskSQLReportResultPreview = &i18n.Message{ID: "TaskSQLReportResultPreview", Other: "SQL返回结果预览"}
+
+	TaskSQLReportManualExecution    = &i18n.Message{ID: "TaskSQLReportManualExecution", Other: "人工上线凭证"}
+	TaskSQLReportManualExecutor     = &i18n.Message{ID: "TaskSQLReportManualExecutor", Other: "执行人"}
+	TaskSQLReportManualExecutedAt   = &i18n.Message{ID: "TaskSQLReportManualExecutedAt", Other: "执行时间"}
+	TaskSQLReportManualRecordUser   = &i18n.Message{ID: "TaskSQLReportManualRecordUser", Other: "登记人"}
+	TaskSQLReportManualRemark       = &i18n.Message{ID: "TaskSQLReportManualRemark", Other: "备注"}
+	TaskSQLReportManualExecutionLog = &i18n.Message{ID: "TaskSQLReportManualExecutionLog", Other: "执行日志"}
+	TaskSQLReportManualEvidenceFile = &i18n.Message{ID: "TaskSQLReportManualEvidenceFile", Other: "凭证文件"}
+	TaskSQLReportManualVerifyStatus = &i18n.Message{ID: "TaskSQLReportManualVerifyStatus", Other: "校验状态"}
+	TaskSQLReportManualVerifyResult = &i18n.Message{ID: "TaskSQLReportManualVerifyResult", Other: "校验结果"}
 )
 
 // workflow
@@ -150,6 +163,8 @@ var (
 	SMExportPersonInCharge = &i18n.Message{ID: "SMExportPersonInCharge", Other: "负责人"}
 	SMExportState          = &i18n.Message{ID: "SMExportState", Other: "状态"}
 	SMExportRemarks        = &i18n.Message{ID: "SMExportRemarks", Other: "备注"}
+	SMExportApplication    = &i18n.Message{ID: "SMExportApplication", Other: "应用"}
+	SMExportRoute          = &i18n.Message{ID: "SMExportRoute", Other: "请求路由"}
 
 	SQLManageSourceSqlAuditRecord = &i18n.Message{ID: "SQLManageSourceSqlAuditRecord", Other: "SQL审核"}
 	SQLManageSourceAuditPlan      = &i18n.Message{ID: "SQLManageSourceAuditPlan", Other: "智能扫描"}
@@ -332,30 +347,54 @@ var (
 	ApMetricNameFirstQueryAt         = &i18n.Message{ID: "ApMetricNameFirstQueryAt", Other: "首次执行时间"}
 	ApMetricNameLastQueryAt          = &i18n.Message{ID: "ApMetricNameLastQueryAt", Other: "最后执行时间"}
 	ApMetricNameMaxQueryTime         = &i18n.Message{ID: "ApMetricNameMaxQueryTime", Other: "最长执行时间"}
+	ApMetricNameApplication          = &i18n.Message{ID: "ApMetricNameApplication", Other: "应用"}
+	ApMetricNameLockType             = &i18n.Message{ID: "ApMetricNameLockType", Other: "锁事件类型"}
+	ApMetricNameLockObject           = &i18n.Message{ID: "ApMetricNameLockObject", Other: "锁对象"}
+	ApMetricNameBlockerSQL           = &i18n.Message{ID: "ApMetricNameBlockerSQL", Other: "阻塞者SQL"}
+	ApMetricNameLockWaitTimeMax      = &i18n.Message{ID: "ApMetricNameLockWaitTimeMax", Other: "最长等待/事务持续时间(s)"}
+
+	ApLockTypeRowLockWait      = &i18n.Message{ID: "ApLockTypeRowLockWait", Other: "行锁等待"}
+	ApLockTypeMetadataLockWait = &i18n.Message{ID: "ApLockTypeMetadataLockWait", Other: "元数据锁等待"}
+	ApLockTypeLongTransaction  = &i18n.Message{ID: "ApLockTypeLongTransaction", Other: "长事务"}
+
+	ApMetricNameTableName       = &i18n.Message{ID: "ApMetricNameTableName", Other: "表名"}
+	ApMetricNameIndexName       = &i18n.Message{ID: "ApMetricNameIndexName", Other: "索引名"}
+	ApMetricNameIndexAdviceType = &i18n.Message{ID: "ApMetricNameIndexAdviceType", Other: "建议类型"}
+	ApMetricNameCoveringIndex   = &i18n.Message{ID: "ApMetricNameCoveringIndex", Other: "可替代的索引"}
+	ApMetricNameIndexSize       = &i18n.Message{ID: "ApMetricNameIndexSize", Other: "预估节省空间(MB)"}
+
+	ApIndexAdviceTypeUnused    = &i18n.Message{ID: "ApIndexAdviceTypeUnused", Other: "未使用索引"}
+	ApIndexAdviceTypeRedundant = &i18n.Message{ID: "ApIndexAdviceTypeRedundant", Other: "冗余索引"}
+	ApIndexAdviceTypeDuplicate = &i18n.Message{ID: "ApIndexAdviceTypeDuplicate", Other: "重复索引"}
 
 	ApMetricNameCounterMoreThan        = &i18n.Message{ID: "ApMetricNameCounterMoreThan", Other: "出现次数 > "}
 	ApMetricNameQueryTimeAvgMoreThan   = &i18n.Message{ID: "ApMetricNameQueryTimeAvgMoreThan", Other: "平均执行时间 > "}
 	ApMetricNameRowExaminedAvgMoreThan = &i18n.Message{ID: "ApMetricNameRowExaminedAvgMoreThan", Other: "平均扫描行数 > "}
 
-	ApMetaCustom                = &i18n.Message{ID: "ApMetaCustom", Other: "自定义"}
-	ApMetaMySQLSchemaMeta       = &i18n.Message{ID: "ApMetaMySQLSchemaMeta", Other: "库表元数据"}
-	ApMetaMySQLProcesslist      = &i18n.Message{ID: "ApMetaMySQLProcesslist", Other: "processlist 列表"}
-	ApMetaAliRdsMySQLSlowLog    = &i18n.Message{ID: "ApMetaAliRdsMySQLSlowLog", Other: "阿里RDS MySQL慢日志"}
-	ApMetaAliRdsMySQLAuditLog   = &i18n.Message{ID: "ApMetaAliRdsMySQLAuditLog", Other: "阿里RDS MySQL审计日志"}
-	ApMetaBaiduRdsMySQLSlowLog  = &i18n.Message{ID: "ApMetaBaiduRdsMySQLSlowLog", Other: "百度云RDS MySQL慢日志"}
-	ApMetaHuaweiRdsMySQLSlowLog = &i18n.Message{ID: "ApMetaHuaweiRdsMySQLSlowLog", Other: "华为云RDS MySQL慢日志"}
-	ApMetaOracleTopSQL          = &i18n.Message{ID: "ApMetaOracleTopSQL", Other: "Oracle TOP SQL"}
-	ApMetaAllAppExtract         = &i18n.Message{ID: "ApMetaAllAppExtract", Other: "应用程序SQL抓取"}
-	ApMetaTiDBAuditLog          = &i18n.Message{ID: "ApMetaTiDBAuditLog", Other: "TiDB审计日志"}
-	ApMetaSlowLog               = &i18n.Message{ID: "ApMetaSlowLog", Other: "慢日志"}
-	ApMetaTopSQL                = &i18n.Message{ID: "ApMetaTopSQL", Other: "Top SQL"}
-	ApMetaDB2TopSQL             = &i18n.Message{ID: "ApMetaDB2TopSQL", Other: "DB2 Top SQL"}
-	ApMetaSchemaMeta            = &i18n.Message{ID: "ApMetaSchemaMeta", Other: "库表元数据"}
-	ApMetaDmTopSQL              = &i18n.Message{ID: "ApMetaDmTopSQL", Other: "DM TOP SQL"}
-	ApMetaObForOracleTopSQL     = &i18n.Message{ID: "ApMetaObForOracleTopSQL", Other: "OceanBase For Oracle TOP SQL"}
-	ApMetaPostgreSQLTopSQL      = &i18n.Message{ID: "ApMetaPostgreSQLTopSQL", Other: "TOP SQL"}
-	ApMetricQueryTimeAvg        = &i18n.Message{ID: "ApMetricQueryTimeAvg", Other: "平均查询时间"}
-	ApMetricRowExaminedAvg      = &i18n.Message{ID: "ApMetricRowExaminedAvg", Other: "平均扫描行数"}
+	ApMetaCustom                 = &i18n.Message{ID: "ApMetaCustom", Other: "自定义"}
+	ApMetaMySQLSchemaMeta        = &i18n.Message{ID: "ApMetaMySQLSchemaMeta", Other: "库表元数据"}
+	ApMetaMySQLProcesslist       = &i18n.Message{ID: "ApMetaMySQLProcesslist", Other: "processlist 列表"}
+	ApMetaMySQLLockWait          = &i18n.Message{ID: "ApMetaMySQLLockWait", Other: "锁等待与长事务"}
+	ApMetaMySQLIndexAdvice       = &i18n.Message{ID: "ApMetaMySQLIndexAdvice", Other: "无用与冗余索引"}
+	ApMetaGenericQuery           = &i18n.Message{ID: "ApMetaGenericQuery", Other: "自定义查询采集"}
+	ApMetaAliRdsMySQLSlowLog     = &i18n.Message{ID: "ApMetaAliRdsMySQLSlowLog", Other: "阿里RDS MySQL慢日志"}
+	ApMetaAliRdsMySQLAuditLog    = &i18n.Message{ID: "ApMetaAliRdsMySQLAuditLog", Other: "阿里RDS MySQL审计日志"}
+	ApMetaBaiduRdsMySQLSlowLog   = &i18n.Message{ID: "ApMetaBaiduRdsMySQLSlowLog", Other: "百度云RDS MySQL慢日志"}
+	ApMetaHuaweiRdsMySQLSlowLog  = &i18n.Message{ID: "ApMetaHuaweiRdsMySQLSlowLog", Other: "华为云RDS MySQL慢日志"}
+	ApMetaTencentCdbMySQLSlowLog = &i18n.Message{ID: "ApMetaTencentCdbMySQLSlowLog", Other: "腾讯云CDB MySQL慢日志"}
+	ApMetaAwsRdsMySQLSlowLog     = &i18n.Message{ID: "ApMetaAwsRdsMySQLSlowLog", Other: "AWS RDS/Aurora MySQL慢日志"}
+	ApMetaOracleTopSQL           = &i18n.Message{ID: "ApMetaOracleTopSQL", Other: "Oracle TOP SQL"}
+	ApMetaAllAppExtract          = &i18n.Message{ID: "ApMetaAllAppExtract", Other: "应用程序SQL抓取"}
+	ApMetaTiDBAuditLog           = &i18n.Message{ID: "ApMetaTiDBAuditLog", Other: "TiDB审计日志"}
+	ApMetaSlowLog                = &i18n.Message{ID: "ApMetaSlowLog", Other: "慢日志"}
+	ApMetaTopSQL                 = &i18n.Message{ID: "ApMetaTopSQL", Other: "Top SQL"}
+	ApMetaDB2TopSQL              = &i18n.Message{ID: "ApMetaDB2TopSQL", Other: "DB2 Top SQL"}
+	ApMetaSchemaMeta             = &i18n.Message{ID: "ApMetaSchemaMeta", Other: "库表元数据"}
+	ApMetaDmTopSQL               = &i18n.Message{ID: "ApMetaDmTopSQL", Other: "DM TOP SQL"}
+	ApMetaObForOracleTopSQL      = &i18n.Message{ID: "ApMetaObForOracleTopSQL", Other: "OceanBase For Oracle TOP SQL"}
+	ApMetaPostgreSQLTopSQL       = &i18n.Message{ID: "ApMetaPostgreSQLTopSQL", Other: "TOP SQL"}
+	ApMetricQueryTimeAvg         = &i18n.Message{ID: "ApMetricQueryTimeAvg", Other: "平均查询时间"}
+	ApMetricRowExaminedAvg       = &i18n.Message{ID: "ApMetricRowExaminedAvg", Other: "平均扫描行数"}
 
 	ApPriorityHigh = &i18n.Message{ID: "ApPriorityHigh", Other: "高优先级"}
 
@@ -370,6 +409,18 @@ var (
 	ParamOrderByColumnGeneric            = &i18n.Message{ID: "ParamOrderByColumnGeneric", Other: "排序字段"}
 	ParamCollectIntervalSecond           = &i18n.Message{ID: "ParamCollectIntervalSecond", Other: "采集周期（秒）"}
 	ParamSQLMinSecond                    = &i18n.Message{ID: "ParamSQLMinSecond", Other: "SQL 最小执行时间（秒）"}
+	ParamLockWaitMinSecond               = &i18n.Message{ID: "ParamLockWaitMinSecond", Other: "锁等待最小时间（秒）"}
+	ParamLongTrxMinSecond                = &i18n.Message{ID: "ParamLongTrxMinSecond", Other: "长事务最小持续时间（秒）"}
+	ParamUnusedIndexObservationDays      = &i18n.Message{ID: "ParamUnusedIndexObservationDays", Other: "未使用索引观察天数（数据库运行时间不足时不检测未使用索引）"}
+	ParamCollectCron                     = &i18n.Message{ID: "ParamCollectCron", Other: "采集周期（cron 表达式）"}
+	ParamCollectSQL                      = &i18n.Message{ID: "ParamCollectSQL", Other: "采集SQL（每行结果为一条SQL，统计值应为采集周期内的增量）"}
+	ParamColumnSQLText                   = &i18n.Message{ID: "ParamColumnSQLText", Other: "SQL文本列名"}
+	ParamColumnSchema                    = &i18n.Message{ID: "ParamColumnSchema", Other: "schema列名（可选）"}
+	ParamColumnCounter                   = &i18n.Message{ID: "ParamColumnCounter", Other: "执行次数列名（可选）"}
+	ParamColumnQueryTimeAvg              = &i18n.Message{ID: "ParamColumnQueryTimeAvg", Other: "平均执行时间列名（可选，单位：秒）"}
+	ParamColumnQueryTimeMax              = &i18n.Message{ID: "ParamColumnQueryTimeMax", Other: "最长执行时间列名（可选，单位：秒）"}
+	ParamColumnRowExaminedAvg            = &i18n.Message{ID: "ParamColumnRowExaminedAvg", Other: "平均扫描行数列名（可选）"}
+	ParamColumnDBUser                    = &i18n.Message{ID: "ParamColumnDBUser", Other: "用户列名（可选）"}
 	ParamCollectView                     = &i18n.Message{ID: "ParamCollectView", Other: "是否采集视图信息"}
 	ParamDBInstanceId                    = &i18n.Message{ID: "ParamDBInstanceId", Other: "实例ID"}
 	ParamAccessKeyId                     = &i18n.Message{ID: "ParamAccessKeyId", Other: "Access Key ID"}
@@ -378,6 +429,9 @@ var (
 	ParamRdsPath                         = &i18n.Message{ID: "ParamRdsPath", Other: "RDS Open API地址"}
 	ParamProjectId                       = &i18n.Message{ID: "ParamProjectId", Other: "项目ID"}
 	ParamRegion                          = &i18n.Message{ID: "ParamRegion", Other: "当前RDS实例所在的地区（示例：cn-east-2）"}
+	ParamTencentCloudRegion              = &i18n.Message{ID: "ParamTencentCloudRegion", Other: "当前CDB实例所在的地域（示例：ap-guangzhou）"}
+	ParamAwsRegion                       = &i18n.Message{ID: "ParamAwsRegion", Other: "当前RDS实例所在的区域（示例：us-east-1）"}
+	ParamAwsRdsPath                      = &i18n.Message{ID: "ParamAwsRdsPath", Other: "RDS API地址（为空时使用 rds.<区域>.amazonaws.com）"}
 
 	EnumSlowLogFileSource  = &i18n.Message{ID: "EnumSlowLogFileSource", Other: "从slow.log 文件采集,需要适配scanner"}
 	EnumSlowLogTableSource = &i18n.Message{ID: "EnumSlowLogTableSource", Other: "从mysql.slow_log 表采集"}
@@ -386,7 +440,8 @@ var (
 	OperatorEqualTo     = &i18n.Message{ID: "OperatorEqualTo", Other: "等于"}
 	OperatorLessThan    = &i18n.Message{ID: "OperatorLessThan", Other: "小于"}
 
-	OperationParamAuditLevel = &i18n.Message{ID: "OperationParamAuditLevel", Other: "触发审核级别"}
+	OperationParamAuditLevel       = &i18n.Message{ID: "OperationParamAuditLevel", Other: "触发审核级别"}
+	OperationParamRegressionFactor = &i18n.Message{ID: "OperationParamRegressionFactor", Other: "执行指标劣化倍数"}
 )
 
 var (
@@ -402,11 +457,25 @@ var (
 	NotifyWorkflowNotifyTypeExecuteSuccess = &i18n.Message{ID: "WorkflowNotifyTypeExecuteSuccess", Other: "SQL工单上线成功"}
 	NotifyWorkflowNotifyTypeExecuteFail    = &i18n.Message{ID: "WorkflowNotifyTypeExecuteFail", Other: "SQL工单上线失败"}
 	NotifyWorkflowNotifyTypeDefault        = &i18n.Message{ID: "WorkflowNotifyTypeDefault", Other: "SQL工单未知请求"}
+	NotifyWorkflowNotifyTypeDelegate       = &i18n.Message{ID: "WorkflowNotifyTypeDelegate", Other: "SQL工单审批已委托，待审批"}
+	NotifyWorkflowNotifyTypeEscalate       = &i18n.Message{ID: "WorkflowNotifyTypeEscalate", Other: "SQL工单审批超时已升级，待审批"}
 
 	NotifyAuditPlanSubject  = &i18n.Message{ID: "NotifyAuditPlanSubject", Other: "SQLE扫描任务[%v]扫描结果[%v]"}
 	NotifyAuditPlanBody     = &i18n.Message{ID: "NotifyAuditPlanBody", Other: "\n- 扫描任务: %v\n- 审核时间: %v\n- 审核类型: %v\n- 数据源: %v\n- 数据库名: %v\n- 审核得分: %v\n- 审核通过率：%v\n- 审核结果等级: %v%v"}
 	NotifyAuditPlanBodyLink = &i18n.Message{ID: "NotifyAuditPlanBodyLink", Other: "\n- 扫描任务链接: %v"}
 
+	NotifySQLRegressionSubject = &i18n.Message{ID: "NotifySQLRegressionSubject", Other: "SQLE扫描任务[%v]发现SQL执行指标劣化"}
+	NotifySQLRegressionBody    = &i18n.Message{ID: "NotifySQLRegressionBody", Other: "\n- 数据源: %v\n- 扫描任务类型: %v\n- SQL ID: %v\n- SQL指纹: %v\n- 指标: %v\n- 基线值: %v\n- 当前值: %v\n- 劣化倍数: %v"}
+
+	NotifyAuditPlanDisabledSubject = &i18n.Message{ID: "NotifyAuditPlanDisabledSubject", Other: "SQLE扫描任务[%v]连续%v次采集失败，已自动停用"}
+	NotifyAuditPlanDisabledBody    = &i18n.Message{ID: "NotifyAuditPlanDisabledBody", Other: "\n- 数据源: %v\n- 扫描任务类型: %v\n- 连续失败次数: %v\n- 最后一次错误: %v\n- 排查问题后请重新启用扫描任务"}
+
+	NotifySQLManageSLASubject    = &i18n.Message{ID: "NotifySQLManageSLASubject", Other: "SQL管控[%v]优先级SQL超过%v分钟未处理"}
+	NotifySQLManageSLABodyRecord = &i18n.Message{ID: "NotifySQLManageSLABodyRecord", Other: "\n- SQL ID: %v\n- SQL: %v\n- 采集时间: %v\n- 处理人: %v\n================================"}
+
+	NotifySchemaMetaDriftSubject    = &i18n.Message{ID: "NotifySchemaMetaDriftSubject", Other: "SQLE扫描任务发现数据源[%v]存在未经工单审批的库表结构变更"}
+	NotifySchemaMetaDriftBodyRecord = &i18n.Message{ID: "NotifySchemaMetaDriftBodyRecord", Other: "\n- 对象: %v.%v(%v)\n- 变更类型: %v\n- 版本: %v -> %v\n- 发现时间: %v\n================================"}
+
 	NotifyManageRecordSubject    = &i18n.Message{ID: "NotifyManageRecordSubject", Other: "SQL管控记录"}
 	NotifyManageRecordBodyLink   = &i18n.Message{ID: "NotifyManageRecordBodyLink", Other: "\n- SQL管控记录链接: %v\n"}
 	NotifyManageRecordBodyRecord = &i18n.Message{ID: "NotifyManageRecordBodyRecord", Other: "- SQL ID: %v\n- 所在数据源名称: %v\n- 所属业务: %v\n- SQL: %v\n- 触发规则级别: %v\n- SQL审核建议: %v\n================================"}
@@ -0,0 +1,90 @@
+package model
+
+import (
+	"time"
+
+	"github.com/actiontech/sqle/sqle/errors"
+
+	"gorm.io/gorm"
+)
+
+// 扫描任务采集记录的状态
+const (
+	CollectionRecordStatusSuccess = "success"
+	CollectionRecordStatusFailed  = "failed"
+	// 管控SQL队列积压时推迟采集，采集器的采集位置不变，下次采集时补采
+	CollectionRecordStatusDeferred = "deferred"
+	// 管控SQL队列积压时丢弃采集到的SQL
+	CollectionRecordStatusDropped = "dropped"
+)
+
+// AuditPlanCollectionRecord 扫描任务的一次采集记录
+type AuditPlanCollectionRecord struct {
+	Model
+	AuditPlanID     uint      `json:"audit_plan_id" gorm:"not null;index:idx_audit_plan_id_start_at,priority:1"`
+	Status          string    `json:"status" gorm:"type:varchar(25);not null"`
+	StartAt         time.Time `json:"start_at" gorm:"type:datetime(3);not null;index:idx_audit_plan_id_start_at,priority:2"`
+	EndAt           time.Time `json:"end_at" gorm:"type:datetime(3);not null"`
+	DurationMs      int64     `json:"duration_ms" gorm:"not null;default:0"`
+	SQLCount        int       `json:"sql_count" gorm:"not null;default:0;comment:采集到的SQL数量"`
+	DroppedSQLCount int       `json:"dropped_sql_count" gorm:"not null;default:0;comment:因管控SQL队列积压丢弃的SQL数量"`
+	QueueDepth      int64     `json:"queue_depth" gorm:"not null;default:0;comment:采集开始时管控SQL队列的积压数量"`
+	ErrorMessage    string    `json:"error_message" gorm:"type:text"`
+}
+
+func (AuditPlanCollectionRecord) TableName() string {
+	return "audit_plan_collection_records"
+}
+
+func (s *Storage) ListAuditPlanCollectionRecords(auditPlanID uint, limit, offset int) ([]*AuditPlanCollectionRecord, uint64, error) {
+	records := []*AuditPlanCollectionRecord{}
+	var count int64
+	query := s.db.Model(&AuditPlanCollectionRecord{}).Where("audit_plan_id = ?", auditPlanID)
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, errors.New(errors.ConnectStorageError, err)
+	}
+	err := query.Order("start_at DESC, id DESC").Limit(limit).Offset(offset).Find(&records).Error
+	return records, uint64(count), errors.New(errors.ConnectStorageError, err)
+}
+
+func (s *Storage) DeleteAuditPlanCollectionRecordsBefore(auditPlanID uint, before time.Time) error {
+	err := s.db.Unscoped().Where("audit_plan_id = ? AND start_at < ?", auditPlanID, before).
+		Delete(&AuditPlanCollectionRecord{}).Error
+	return errors.New(errors.ConnectStorageError, err)
+}
+
+// IncreaseAuditPlanCollectionFailures 增加扫描任务连续采集失败的次数，并返回增加后的次数
+func (s *Storage) IncreaseAuditPlanCollectionFailures(auditPlanID uint) (uint, error) {
+	taskInfo := &AuditPlanTaskInfo{}
+	err := s.Tx(func(tx *gorm.DB) error {
+		err := tx.Model(&AuditPlanTaskInfo{}).Where("audit_plan_id = ?", auditPlanID).
+			Update("consecutive_collection_failures", gorm.Expr("consecutive_collection_failures + 1")).Error
+		if err != nil {
+			return err
+		}
+		return tx.Where("audit_plan_id = ?", auditPlanID).First(taskInfo).Error
+	})
+	if err == gorm.ErrRecordNotFound {
+		return 0, nil
+	}
+	return taskInfo.ConsecutiveCollectionFailures, errors.New(errors.ConnectStorageError, err)
+}
+
+func (s *Storage) ResetAuditPlanCollectionFailures(auditPlanID uint) error {
+	err := s.db.Model(&AuditPlanTaskInfo{}).Where("audit_plan_id = ? AND consecutive_collection_failures > 0", auditPlanID).
+		Update("consecutive_collection_failures", 0).Error
+	return errors.New(errors.ConnectStorageError, err)
+}
+
+// DisableAuditPlanByID 停用扫描任务，扫描任务管理器根据更新时间同步任务时停止采集
+func (s *Storage) DisableAuditPlanByID(auditPlanID uint) error {
+	err := s.db.Model(&AuditPlanV2{}).Where("id = ?", auditPlanID).
+		Update("active_status", ActiveStatusDisabled).Error
+	return errors.New(errors.ConnectStorageError, err)
+}
+
+func (s *Storage) CountSQLManageQueue() (int64, error) {
+	var count int64
+	err := s.db.Model(&SQLManageQueue{}).Count(&count).Error
+	return count, errors.New(errors.ConnectStorageError, err)
+}
@@ -0,0 +1,94 @@
+package model
+
+import (
+	"time"
+
+	"github.com/actiontech/sqle/sqle/errors"
+
+	"gorm.io/gorm"
+)
+
+const (
+	DMLChunkExecutionStatusRunning     = "running"
+	DMLChunkExecutionStatusPaused      = "paused"
+	DMLChunkExecutionStatusTerminating = "terminating"
+	DMLChunkExecutionStatusTerminated  = "terminated"
+	DMLChunkExecutionStatusSucceeded   = "succeeded"
+	DMLChunkExecutionStatusFailed      = "failed"
+)
+
+// DMLChunkExecution 单表UPDATE/DELETE按主键范围分批执行的进度，执行中可以暂停、恢复和终止
+type DMLChunkExecution struct {
+	Model
+	TaskId       uint   `json:"task_id" gorm:"index;not null"`
+	ExecuteSQLId uint   `json:"execute_sql_id" gorm:"index;not null"`
+	Schema       string `json:"schema" gorm:"type:varchar(255)"`
+	Table        string `json:"table" gorm:"type:varchar(255)"`
+	PrimaryKey   string `json:"primary_key" gorm:"type:varchar(255)"`
+	ChunkSize    uint   `json:"chunk_size"`
+	Status       string `json:"status" gorm:"type:varchar(255)"`
+	ChunkCount   uint   `json:"chunk_count"`
+	RowAffects   int64  `json:"row_affects"`
+	// 已执行的最后一批的主键上界
+	LastKey    string      `json:"last_key" gorm:"type:varchar(255)"`
+	ExecResult string      `json:"exec_result" gorm:"type:text"`
+	Chunks     []*DMLChunk `json:"-" gorm:"foreignkey:DMLChunkExecutionId"`
+}
+
+// DMLChunk 分批执行中的一批，主键范围为(LowerKey, UpperKey]，LowerKey为空时表示没有下界
+type DMLChunk struct {
+	Model
+	DMLChunkExecutionId uint   `json:"dml_chunk_execution_id" gorm:"index;not null"`
+	Number              uint   `json:"number"`
+	LowerKey            string `json:"lower_key" gorm:"type:varchar(255)"`
+	UpperKey            string `json:"upper_key" gorm:"type:varchar(255)"`
+	RowAffects          int64  `json:"row_affects"`
+	ExecStartAt         *time.Time
+	ExecEndAt           *time.Time
+	ExecStatus          string `json:"exec_status" gorm:"default:\"initialized\""`
+	ExecResult          string `json:"exec_result" gorm:"type:text"`
+}
+
+func (s *Storage) CreateDMLChunkExecution(execution *DMLChunkExecution) error {
+	return errors.New(errors.ConnectStorageError, s.db.Create(execution).Error)
+}
+
+func (s *Storage) UpdateDMLChunkExecution(execution *DMLChunkExecution, attrs map[string]interface{}) error {
+	return errors.New(errors.ConnectStorageError, s.db.Model(&DMLChunkExecution{}).Where("id = ?", execution.ID).Updates(attrs).Error)
+}
+
+// UpdateDMLChunkExecutionStatus changes the status only if the execution is in one of the from status.
+func (s *Storage) UpdateDMLChunkExecutionStatus(execution *DMLChunkExecution, from []string, to string) (bool, error) {
+	db := s.db.Model(&DMLChunkExecution{}).Where("id = ? AND status IN (?)", execution.ID, from).Update("status", to)
+	if db.Error != nil {
+		return false, errors.New(errors.ConnectStorageError, db.Error)
+	}
+	return db.RowsAffected > 0, nil
+}
+
+func (s *Storage) GetDMLChunkExecutionStatus(id uint) (string, error) {
+	execution := &DMLChunkExecution{}
+	err := s.db.Select("status").Where("id = ?", id).First(execution).Error
+	return execution.Status, errors.New(errors.ConnectStorageError, err)
+}
+
+func (s *Storage) SaveDMLChunk(chunk *DMLChunk) error {
+	return errors.New(errors.ConnectStorageError, s.db.Save(chunk).Error)
+}
+
+func (s *Storage) GetDMLChunkExecutionById(taskId, id uint) (*DMLChunkExecution, bool, error) {
+	execution := &DMLChunkExecution{}
+	err := s.db.Where("task_id = ? AND id = ?", taskId, id).First(execution).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, false, nil
+	}
+	return execution, true, errors.New(errors.ConnectStorageError, err)
+}
+
+func (s *Storage) GetDMLChunkExecutionsByTaskId(taskId uint) ([]*DMLChunkExecution, error) {
+	executions := []*DMLChunkExecution{}
+	err := s.db.Where("task_id = ?", taskId).
+		Preload("Chunks", func(db *gorm.DB) *gorm.DB { return db.Order("number ASC") }).
+		Order("id ASC").Find(&executions).Error
+	return executions, errors.New(errors.ConnectStorageError, err)
+}
@@ -0,0 +1,171 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/actiontech/sqle/sqle/errors"
+
+	"github.com/robfig/cron/v3"
+	"gorm.io/gorm"
+)
+
+// ExecutionFreezeCalendar 项目的变更冻结期，冻结期内禁止工单立即上线和定时上线。
+// 冻结期为一次性的时间范围[StartTime, EndTime)，或按 RecurrenceCron 周期性开始、持续 RecurrenceDurationMinutes 分钟，
+// 周期性冻结期设置了 StartTime 或 EndTime 时仅在该范围内生效。
+type ExecutionFreezeCalendar struct {
+	Model
+	ProjectId ProjectUID `gorm:"index; not null; type:varchar(255)"`
+	Name      string     `gorm:"not null; type:varchar(255)"`
+	Desc      string     `gorm:"type:varchar(512)"`
+	// 冻结生效的数据源分组，即数据源在DMS中所属的业务，为空时对项目下的所有数据源生效
+	InstanceGroups            Strings `gorm:"type:json"`
+	StartTime                 *time.Time
+	EndTime                   *time.Time
+	RecurrenceCron            string `gorm:"type:varchar(255)"`
+	RecurrenceDurationMinutes uint
+	Enabled                   bool
+}
+
+// Validate checks the time range and the recurrence of the freeze calendar.
+func (c *ExecutionFreezeCalendar) Validate() error {
+	if c.StartTime != nil && c.EndTime != nil && !c.EndTime.After(*c.StartTime) {
+		return fmt.Errorf("the end time of freeze should be after the start time")
+	}
+	if c.RecurrenceCron == "" {
+		if c.StartTime == nil || c.EndTime == nil {
+			return fmt.Errorf("the start time and end time are required by the freeze without recurrence")
+		}
+		return nil
+	}
+	if _, err := cron.ParseStandard(c.RecurrenceCron); err != nil {
+		return fmt.Errorf("invalid recurrence cron of freeze: %v", err)
+	}
+	if c.RecurrenceDurationMinutes == 0 {
+		return fmt.Errorf("the recurrence duration is required by the recurring freeze")
+	}
+	return nil
+}
+
+// AppliesTo reports whether the freeze calendar applies to the instance.
+func (c *ExecutionFreezeCalendar) AppliesTo(inst *Instance) bool {
+	if len(c.InstanceGroups) == 0 {
+		return true
+	}
+	if inst == nil {
+		return false
+	}
+	for _, group := range c.InstanceGroups {
+		if group == inst.Business {
+			return true
+		}
+	}
+	return false
+}
+
+// IsFrozen reports whether the time is in the freeze period.
+func (c *ExecutionFreezeCalendar) IsFrozen(t time.Time) bool {
+	if !c.Enabled {
+		return false
+	}
+	if c.StartTime != nil && t.Before(*c.StartTime) {
+		return false
+	}
+	if c.EndTime != nil && !t.Before(*c.EndTime) {
+		return false
+	}
+	if c.RecurrenceCron == "" {
+		return c.StartTime != nil && c.EndTime != nil
+	}
+	schedule, err := cron.ParseStandard(c.RecurrenceCron)
+	if err != nil {
+		return false
+	}
+	// the time is frozen if a recurrence starts within the duration before it
+	duration := time.Duration(c.RecurrenceDurationMinutes) * time.Minute
+	next := schedule.Next(t.Add(-duration))
+	return !next.After(t)
+}
+
+// FrozenUntil returns the end of the freeze period which the time is in, the time is returned if it isn't frozen.
+func (c *ExecutionFreezeCalendar) FrozenUntil(t time.Time) time.Time {
+	if !c.IsFrozen(t) {
+		return t
+	}
+	if c.RecurrenceCron == "" {
+		return *c.EndTime
+	}
+	schedule, err := cron.ParseStandard(c.RecurrenceCron)
+	if err != nil {
+		return t
+	}
+	duration := time.Duration(c.RecurrenceDurationMinutes) * time.Minute
+	end := schedule.Next(t.Add(-duration)).Add(duration)
+	if c.EndTime != nil && c.EndTime.Before(end) {
+		end = *c.EndTime
+	}
+	return end
+}
+
+func (s *Storage) CreateExecutionFreezeCalendar(calendar *ExecutionFreezeCalendar) error {
+	if err := calendar.Validate(); err != nil {
+		return errors.New(errors.DataInvalid, err)
+	}
+	return errors.New(errors.ConnectStorageError, s.db.Create(calendar).Error)
+}
+
+func (s *Storage) UpdateExecutionFreezeCalendar(calendar *ExecutionFreezeCalendar) error {
+	if err := calendar.Validate(); err != nil {
+		return errors.New(errors.DataInvalid, err)
+	}
+	return errors.New(errors.ConnectStorageError, s.db.Save(calendar).Error)
+}
+
+func (s *Storage) DeleteExecutionFreezeCalendar(calendar *ExecutionFreezeCalendar) error {
+	return errors.New(errors.ConnectStorageError, s.db.Delete(calendar).Error)
+}
+
+func (s *Storage) GetExecutionFreezeCalendarById(projectId ProjectUID, id uint) (*ExecutionFreezeCalendar, bool, error) {
+	calendar := &ExecutionFreezeCalendar{}
+	err := s.db.Where("project_id = ? AND id = ?", projectId, id).First(calendar).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, false, nil
+	}
+	return calendar, true, errors.New(errors.ConnectStorageError, err)
+}
+
+func (s *Storage) GetExecutionFreezeCalendarsByProject(projectId ProjectUID) ([]*ExecutionFreezeCalendar, error) {
+	calendars := []*ExecutionFreezeCalendar{}
+	err := s.db.Where("project_id = ?", projectId).Order("id DESC").Find(&calendars).Error
+	return calendars, errors.New(errors.ConnectStorageError, err)
+}
+
+// WorkflowFreezeOverride 冻结期内紧急上线的申请，需要申请人以外的项目管理员审批通过后，工单当前版本才可在冻结期内上线
+type WorkflowFreezeOverride struct {
+	Model
+	ProjectId        ProjectUID `gorm:"index; not null; type:varchar(255)"`
+	WorkflowId       string     `gorm:"index; not null; type:varchar(255)"`
+	WorkflowRecordId uint       `gorm:"index; not null"`
+	Reason           string     `gorm:"type:varchar(512)"`
+	ApplyUserId      string     `gorm:"type:varchar(255)"`
+	ApproveUserId    string     `gorm:"type:varchar(255)"`
+	ApprovedAt       *time.Time
+}
+
+func (o *WorkflowFreezeOverride) IsApproved() bool {
+	return o != nil && o.ApprovedAt != nil
+}
+
+// GetWorkflowFreezeOverride returns the override applied for the workflow record.
+func (s *Storage) GetWorkflowFreezeOverride(workflowId string, recordId uint) (*WorkflowFreezeOverride, bool, error) {
+	override := &WorkflowFreezeOverride{}
+	err := s.db.Where("workflow_id = ? AND workflow_record_id = ?", workflowId, recordId).Last(override).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, false, nil
+	}
+	return override, true, errors.New(errors.ConnectStorageError, err)
+}
+
+func (s *Storage) SaveWorkflowFreezeOverride(override *WorkflowFreezeOverride) error {
+	return errors.New(errors.ConnectStorageError, s.db.Save(override).Error)
+}
@@ -0,0 +1,70 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExecutionFreezeCalendar_IsFrozen(t *testing.T) {
+	start := time.Date(2024, 3, 25, 0, 0, 0, 0, time.Local)
+	end := time.Date(2024, 4, 1, 0, 0, 0, 0, time.Local)
+
+	// one-off date range
+	calendar := &ExecutionFreezeCalendar{StartTime: &start, EndTime: &end, Enabled: true}
+	assert.NoError(t, calendar.Validate())
+	assert.True(t, calendar.IsFrozen(start))
+	assert.True(t, calendar.IsFrozen(end.Add(-time.Minute)))
+	assert.False(t, calendar.IsFrozen(end))
+	assert.False(t, calendar.IsFrozen(start.Add(-time.Minute)))
+
+	calendar.Enabled = false
+	assert.False(t, calendar.IsFrozen(start))
+
+	// every saturday and sunday
+	calendar = &ExecutionFreezeCalendar{RecurrenceCron: "0 0 * * 6", RecurrenceDurationMinutes: 48 * 60, Enabled: true}
+	assert.NoError(t, calendar.Validate())
+	saturday := time.Date(2024, 3, 23, 0, 0, 0, 0, time.Local)
+	assert.True(t, calendar.IsFrozen(saturday))
+	assert.True(t, calendar.IsFrozen(saturday.Add(47*time.Hour)))
+	assert.False(t, calendar.IsFrozen(saturday.Add(48*time.Hour)))
+	assert.False(t, calendar.IsFrozen(saturday.Add(-time.Minute)))
+
+	// recurrence is limited by the time range
+	calendar.StartTime = &start
+	assert.False(t, calendar.IsFrozen(saturday))
+	assert.True(t, calendar.IsFrozen(saturday.Add(7*24*time.Hour)))
+
+	assert.Error(t, (&ExecutionFreezeCalendar{StartTime: &start}).Validate())
+	assert.Error(t, (&ExecutionFreezeCalendar{RecurrenceCron: "0 0 * * 6"}).Validate())
+	assert.Error(t, (&ExecutionFreezeCalendar{RecurrenceCron: "invalid", RecurrenceDurationMinutes: 1}).Validate())
+}
+
+func TestExecutionFreezeCalendar_FrozenUntil(t *testing.T) {
+	start := time.Date(2024, 3, 25, 0, 0, 0, 0, time.Local)
+	end := time.Date(2024, 4, 1, 0, 0, 0, 0, time.Local)
+
+	calendar := &ExecutionFreezeCalendar{StartTime: &start, EndTime: &end, Enabled: true}
+	assert.Equal(t, end, calendar.FrozenUntil(start.Add(time.Hour)))
+	// the time isn't frozen
+	assert.Equal(t, end, calendar.FrozenUntil(end))
+
+	// every saturday and sunday
+	calendar = &ExecutionFreezeCalendar{RecurrenceCron: "0 0 * * 6", RecurrenceDurationMinutes: 48 * 60, Enabled: true}
+	saturday := time.Date(2024, 3, 23, 0, 0, 0, 0, time.Local)
+	assert.Equal(t, saturday.Add(48*time.Hour), calendar.FrozenUntil(saturday.Add(30*time.Hour)))
+
+	// the recurrence is limited by the end time
+	limit := saturday.Add(24 * time.Hour)
+	calendar.EndTime = &limit
+	assert.Equal(t, limit, calendar.FrozenUntil(saturday.Add(time.Hour)))
+}
+
+func TestExecutionFreezeCalendar_AppliesTo(t *testing.T) {
+	calendar := &ExecutionFreezeCalendar{}
+	assert.True(t, calendar.AppliesTo(&Instance{Business: "pay"}))
+	calendar.InstanceGroups = []string{"order"}
+	assert.False(t, calendar.AppliesTo(&Instance{Business: "pay"}))
+	assert.True(t, calendar.AppliesTo(&Instance{Business: "order"}))
+}
@@ -145,6 +145,8 @@ type AuditPlanTaskInfo struct {
 	AuditPlanID          uint       `json:"audit_plan_id" gorm:"not null"`
 	LastCollectionTime   *time.Time `json:"last_collection_time" gorm:"type:datetime(3)"`
 	LastCollectionStatus string     `json:"last_collection_status" gorm:"type:varchar(25)"`
+	// 连续采集失败的次数，采集成功或重新启用扫描任务时清零
+	ConsecutiveCollectionFailures uint `json:"consecutive_collection_failures" gorm:"not null;default:0"`
 }
 
 func (a AuditPlanV2) TableName() string {
@@ -278,7 +280,7 @@ func (s *Storage) GetManagerSqlMetricTipsByAuditPlan(auditPlanId uint, metricNam
 		Joins(`
 			JOIN audit_plans_v2 ON sql_manage_records.source = audit_plans_v2.type 
 			AND sql_manage_records.source_id = CONCAT(audit_plans_v2.instance_audit_plan_id, '')`).
-		Where("audit_plans_v2.id = ? AND sql_manage_records.info->>'$.db_user' IS NOT NULL", auditPlanId).
+		Where(fmt.Sprintf("audit_plans_v2.id = ? AND sql_manage_records.info->>'$.%s' IS NOT NULL", metricName), auditPlanId).
 		Scan(&metricValueTips).Error
 	return metricValueTips, errors.New(errors.ConnectStorageError, err)
 }
@@ -309,6 +311,8 @@ type SQLManageRecordProcess struct {
 	Assignees string        `json:"assignees" gorm:"type:varchar(2000)"`
 	Status    ProcessStatus `json:"status" gorm:"default:\"unhandled\""`
 	Remark    string        `json:"remark" gorm:"type:varchar(4000)"`
+	// 待处理超过优先级SLA后升级推送的时间
+	EscalatedAt *time.Time `json:"escalated_at" gorm:"type:datetime(3)"`
 }
 
 type ProcessStatus string
@@ -137,6 +137,11 @@ const (
 	FilterQueryTimeAvg             FilterName = "query_time_avg"
 	FilterRowExaminedAvg           FilterName = "row_examined_avg"
 	FilterPriority                 FilterName = "priority"
+	FilterApplication              FilterName = "application"
+	FilterLockType                 FilterName = "lock_type"
+	FilterLockObject               FilterName = "lock_object"
+	FilterIndexAdviceType          FilterName = "index_advice_type"
+	FilterExcludeRecordDeleted     FilterName = "exclude_record_deleted"
 )
 
 type FilterType string
@@ -157,6 +162,11 @@ var FilterMap = map[FilterName]FilterType{
 	FilterQueryTimeAvg:             FilterTypeCommon,
 	FilterRowExaminedAvg:           FilterTypeCommon,
 	FilterPriority:                 FilterTypeCommon,
+	FilterApplication:              FilterTypeCommon,
+	FilterLockType:                 FilterTypeCommon,
+	FilterLockObject:               FilterTypeCommon,
+	FilterIndexAdviceType:          FilterTypeCommon,
+	FilterExcludeRecordDeleted:     FilterTypeCommon,
 }
 
 var OrderByMap = map[string] /* field name */ string /* field name with table*/ {
@@ -165,6 +175,8 @@ var OrderByMap = map[string] /* field name */ string /* field name with table*/
 	"query_time_avg":         "audit_plan_sqls.info->'$.query_time_avg'",
 	"query_time_max":         "audit_plan_sqls.info->'$.query_time_max'",
 	"row_examined_avg":       "audit_plan_sqls.info->'$.row_examined_avg'",
+	"lock_wait_time_max":     "audit_plan_sqls.info->'$.lock_wait_time_max'",
+	"index_size":             "audit_plan_sqls.info->'$.index_size'",
 }
 
 var instanceAuditPlanSQLQueryTpl = `
@@ -258,6 +270,26 @@ AND JSON_EXTRACT(audit_plan_sqls.info, '$.row_examined_avg') >= :row_examined_av
 AND audit_plan_sqls.priority = :priority
 {{- end}}
 
+{{- if .application }}
+AND JSON_UNQUOTE(JSON_EXTRACT(audit_plan_sqls.info, '$.application')) = :application
+{{- end}}
+
+{{- if .lock_type }}
+AND JSON_UNQUOTE(JSON_EXTRACT(audit_plan_sqls.info, '$.lock_type')) = :lock_type
+{{- end}}
+
+{{- if .lock_object }}
+AND JSON_UNQUOTE(JSON_EXTRACT(audit_plan_sqls.info, '$.lock_object')) = :lock_object
+{{- end}}
+
+{{- if .index_advice_type }}
+AND JSON_UNQUOTE(JSON_EXTRACT(audit_plan_sqls.info, '$.index_advice_type')) = :index_advice_type
+{{- end}}
+
+{{- if .exclude_record_deleted }}
+AND (JSON_EXTRACT(audit_plan_sqls.info, '$.record_deleted') IS NULL OR JSON_EXTRACT(audit_plan_sqls.info, '$.record_deleted') <> CAST('true' AS JSON))
+{{- end}}
+
 {{ end }}
 `
 
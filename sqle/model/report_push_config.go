@@ -38,6 +38,8 @@ const (
 	// 推送报告类型
 	TypeWorkflow  = "workflow"
 	TypeSQLManage = "sql_manage"
+	// 待处理SQL超过优先级SLA的升级推送
+	TypeSQLManageSLA = "sql_manage_sla"
 
 	// 推送报告触发类型
 	TriggerTypeImmediately = "immediately"
@@ -48,9 +50,10 @@ const (
 	PushUserTypePermissionMatch = "permission_match"
 )
 
-// 新增项目需要新增的配置
-func (s Storage) InitReportPushConfigInProject(projectID string) error {
-	var defaultPushConfigs = []ReportPushConfig{
+const DefaultSQLManageSLAPushCron = "*/10 * * * *"
+
+func defaultReportPushConfigs(projectID string) []ReportPushConfig {
+	return []ReportPushConfig{
 		{
 			ProjectId:              projectID,
 			Type:                   TypeWorkflow,
@@ -69,15 +72,50 @@ func (s Storage) InitReportPushConfigInProject(projectID string) error {
 			PushUserList:           []string{},
 			Enabled:                false,
 			ReportPushConfigRecord: ReportPushConfigRecord{},
+		}, {
+			ProjectId:              projectID,
+			Type:                   TypeSQLManageSLA,
+			TriggerType:            TriggerTypeTiming,
+			PushFrequencyCron:      DefaultSQLManageSLAPushCron,
+			PushUserType:           PushUserTypeFixed,
+			PushUserList:           []string{},
+			Enabled:                false,
+			ReportPushConfigRecord: ReportPushConfigRecord{},
 		},
 	}
-	err := s.db.Save(defaultPushConfigs).Error
+}
+
+// 新增项目需要新增的配置
+func (s Storage) InitReportPushConfigInProject(projectID string) error {
+	err := s.db.Save(defaultReportPushConfigs(projectID)).Error
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
+// CreateMissingReportPushConfigInProject creates the default configs of the types added after the project is created.
+func (s Storage) CreateMissingReportPushConfigInProject(projectID string) error {
+	configs, err := s.GetReportPushConfigListInProject(projectID)
+	if err != nil {
+		return errors.New(errors.ConnectStorageError, err)
+	}
+	exists := make(map[string]struct{}, len(configs))
+	for _, config := range configs {
+		exists[config.Type] = struct{}{}
+	}
+	missing := []ReportPushConfig{}
+	for _, config := range defaultReportPushConfigs(projectID) {
+		if _, ok := exists[config.Type]; !ok {
+			missing = append(missing, config)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	return errors.New(errors.ConnectStorageError, s.db.Save(missing).Error)
+}
+
 func (s *Storage) GetReportPushConfigByProjectId(projectId ProjectUID) (*ReportPushConfig, bool, error) {
 	ReportPushConfig := &ReportPushConfig{}
 	err := s.db.Where("project_id = ?", projectId).First(ReportPushConfig).Error
@@ -0,0 +1,71 @@
+package model
+
+import (
+	"time"
+
+	"github.com/actiontech/sqle/sqle/errors"
+
+	"gorm.io/gorm"
+)
+
+const (
+	RollbackRunStatusRunning   = "running"
+	RollbackRunStatusSucceeded = "succeeded"
+	RollbackRunStatusFailed    = "failed"
+)
+
+// RollbackRun 任务的一次回滚执行记录，可以只回滚任务中选中的回滚SQL
+type RollbackRun struct {
+	Model
+	TaskId       uint   `json:"task_id" gorm:"index;not null"`
+	CreateUserId string `json:"create_user_id" gorm:"type:varchar(255)"`
+	ExecMode     string `json:"exec_mode" gorm:"type:varchar(255)"`
+	Status       string `json:"status" gorm:"type:varchar(255)"`
+	ExecResult   string `json:"exec_result" gorm:"type:text"`
+	StartAt      *time.Time
+	EndAt        *time.Time
+	SQLs         []*RollbackRunSQL `json:"-" gorm:"foreignkey:RollbackRunId"`
+}
+
+// RollbackRunSQL 回滚执行中的单条SQL，一条回滚SQL可能包含多条语句。
+// 执行失败后不再执行后续的SQL，未执行的SQL保持初始状态
+type RollbackRunSQL struct {
+	Model
+	RollbackRunId uint   `json:"rollback_run_id" gorm:"index;not null"`
+	RollbackSQLId uint   `json:"rollback_sql_id" gorm:"index;not null"`
+	Number        uint   `json:"number"`
+	Content       string `json:"content" gorm:"type:longtext"`
+	SQLType       string `json:"sql_type" gorm:"type:varchar(255)"`
+	ExecBatchId   uint64 `json:"exec_batch_id"`
+	ExecStatus    string `json:"exec_status" gorm:"default:\"initialized\""`
+	ExecResult    string `json:"exec_result" gorm:"type:text"`
+	RowAffects    int64  `json:"row_affects"`
+}
+
+// CreateRollbackRun creates the rollback run with its SQLs.
+func (s *Storage) CreateRollbackRun(run *RollbackRun) error {
+	return errors.New(errors.ConnectStorageError, s.db.Create(run).Error)
+}
+
+func (s *Storage) UpdateRollbackRun(run *RollbackRun, attrs map[string]interface{}) error {
+	return errors.New(errors.ConnectStorageError, s.db.Model(&RollbackRun{}).Where("id = ?", run.ID).Updates(attrs).Error)
+}
+
+func (s *Storage) SaveRollbackRunSQLs(sqls []*RollbackRunSQL) error {
+	return s.Tx(func(txDB *gorm.DB) error {
+		for _, sql := range sqls {
+			if err := txDB.Save(sql).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Storage) GetRollbackRunsByTaskId(taskId uint) ([]*RollbackRun, error) {
+	runs := []*RollbackRun{}
+	err := s.db.Where("task_id = ?", taskId).
+		Preload("SQLs", func(db *gorm.DB) *gorm.DB { return db.Order("number ASC") }).
+		Order("id DESC").Find(&runs).Error
+	return runs, errors.New(errors.ConnectStorageError, err)
+}
@@ -0,0 +1,134 @@
+package model
+
+import (
+	"time"
+
+	"github.com/actiontech/sqle/sqle/errors"
+
+	"gorm.io/gorm"
+)
+
+// SchemaMetaSnapshot 库表结构扫描任务采集到的对象定义，对象定义变化或对象被删除时追加一个新版本
+type SchemaMetaSnapshot struct {
+	Model
+	AuditPlanId uint   `json:"audit_plan_id" gorm:"not null;index:idx_audit_plan_id_meta"`
+	ProjectId   string `json:"project_id" gorm:"type:varchar(255);not null"`
+	InstanceId  string `json:"instance_id" gorm:"type:varchar(255);not null"`
+	SchemaName  string `json:"schema_name" gorm:"type:varchar(255);not null;index:idx_audit_plan_id_meta"`
+	MetaType    string `json:"meta_type" gorm:"type:varchar(255);not null;index:idx_audit_plan_id_meta"`
+	MetaName    string `json:"meta_name" gorm:"type:varchar(255);not null;index:idx_audit_plan_id_meta"`
+	Version     uint   `json:"version" gorm:"not null"`
+	// 对象的SHOW CREATE语句，已去除AUTO_INCREMENT等不属于结构的部分，对象被删除时为空
+	Content    string `json:"content" gorm:"type:mediumtext"`
+	ContentMD5 string `json:"content_md5" gorm:"type:char(32)"`
+	Dropped    bool   `json:"dropped" gorm:"not null;default:false"`
+}
+
+func (SchemaMetaSnapshot) TableName() string {
+	return "schema_meta_snapshots"
+}
+
+const (
+	SchemaMetaDriftChangeTypeCreated = "created"
+	SchemaMetaDriftChangeTypeAltered = "altered"
+	SchemaMetaDriftChangeTypeDropped = "dropped"
+)
+
+// SchemaMetaDrift 相邻两个版本的对象定义之间的结构变化，能匹配到SQLE工单中已执行的DDL时视为已审批的变更
+type SchemaMetaDrift struct {
+	Model
+	AuditPlanId uint   `json:"audit_plan_id" gorm:"not null;index"`
+	ProjectId   string `json:"project_id" gorm:"type:varchar(255);not null;index"`
+	InstanceId  string `json:"instance_id" gorm:"type:varchar(255);not null"`
+	SchemaName  string `json:"schema_name" gorm:"type:varchar(255);not null"`
+	MetaType    string `json:"meta_type" gorm:"type:varchar(255);not null"`
+	MetaName    string `json:"meta_name" gorm:"type:varchar(255);not null"`
+	ChangeType  string `json:"change_type" gorm:"type:varchar(255);not null"`
+	// 对象新建时为0
+	FromVersion uint `json:"from_version" gorm:"not null"`
+	ToVersion   uint `json:"to_version" gorm:"not null"`
+	// 结构变化明细，JSON格式
+	Diff     JSON `json:"diff" gorm:"type:json"`
+	Approved bool `json:"approved" gorm:"not null;default:false"`
+	// 只有部分结构变化能匹配到工单中已执行的DDL
+	PartiallyApproved bool `json:"partially_approved" gorm:"not null;default:false"`
+	// 变更了该对象的任务，多个以逗号分隔
+	MatchedTaskIds string    `json:"matched_task_ids" gorm:"type:varchar(1024)"`
+	DetectedAt     time.Time `json:"detected_at" gorm:"not null"`
+}
+
+func (SchemaMetaDrift) TableName() string {
+	return "schema_meta_drifts"
+}
+
+// GetLatestSchemaMetaSnapshots returns the latest version of every object collected by the audit plan.
+func (s *Storage) GetLatestSchemaMetaSnapshots(auditPlanId uint) ([]*SchemaMetaSnapshot, error) {
+	snapshots := []*SchemaMetaSnapshot{}
+	latestIds := s.db.Model(&SchemaMetaSnapshot{}).Select("MAX(id)").
+		Where("audit_plan_id = ?", auditPlanId).
+		Group("schema_name, meta_type, meta_name")
+	err := s.db.Where("id IN (?)", latestIds).Find(&snapshots).Error
+	return snapshots, errors.New(errors.ConnectStorageError, err)
+}
+
+func (s *Storage) GetSchemaMetaSnapshots(auditPlanId uint, schemaName, metaType, metaName string) ([]*SchemaMetaSnapshot, error) {
+	snapshots := []*SchemaMetaSnapshot{}
+	err := s.db.Where("audit_plan_id = ? AND schema_name = ? AND meta_type = ? AND meta_name = ?",
+		auditPlanId, schemaName, metaType, metaName).
+		Order("version DESC").Find(&snapshots).Error
+	return snapshots, errors.New(errors.ConnectStorageError, err)
+}
+
+func (s *Storage) SaveSchemaMetaSnapshotsAndDrifts(snapshots []*SchemaMetaSnapshot, drifts []*SchemaMetaDrift) error {
+	return errors.New(errors.ConnectStorageError, s.Tx(func(tx *gorm.DB) error {
+		if len(snapshots) > 0 {
+			if err := tx.CreateInBatches(snapshots, 100).Error; err != nil {
+				return err
+			}
+		}
+		if len(drifts) > 0 {
+			if err := tx.CreateInBatches(drifts, 100).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
+
+func (s *Storage) GetSchemaMetaDrifts(auditPlanId uint, onlyUnapproved bool, limit, offset int) ([]*SchemaMetaDrift, uint64, error) {
+	drifts := []*SchemaMetaDrift{}
+	query := s.db.Model(&SchemaMetaDrift{}).Where("audit_plan_id = ?", auditPlanId)
+	if onlyUnapproved {
+		query = query.Where("approved = ?", false)
+	}
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, errors.New(errors.ConnectStorageError, err)
+	}
+	err := query.Order("id DESC").Limit(limit).Offset(offset).Find(&drifts).Error
+	return drifts, uint64(count), errors.New(errors.ConnectStorageError, err)
+}
+
+// InstanceExecutedSQL 在数据源上执行成功的工单SQL
+type InstanceExecutedSQL struct {
+	TaskId     uint      `json:"task_id"`
+	Schema     string    `json:"schema"`
+	Content    string    `json:"content"`
+	ExecutedAt time.Time `json:"executed_at"`
+}
+
+// GetInstanceExecutedSQLs returns the SQLs of the tasks executed successfully on the instance after the time, including
+// the SQLs executed manually outside SQLE which have been recorded in the task.
+func (s *Storage) GetInstanceExecutedSQLs(instanceId uint64, after time.Time) ([]*InstanceExecutedSQL, error) {
+	sqls := []*InstanceExecutedSQL{}
+	err := s.db.Table("execute_sql_detail esd").
+		Select("esd.task_id, t.instance_schema AS `schema`, esd.content, COALESCE(tme.executed_at, t.exec_end_at) AS executed_at").
+		Joins("JOIN tasks t ON t.id = esd.task_id AND t.deleted_at IS NULL").
+		Joins("LEFT JOIN task_manual_executions tme ON tme.task_id = t.id AND tme.deleted_at IS NULL").
+		Where("t.instance_id = ? AND esd.deleted_at IS NULL AND esd.exec_status IN (?)",
+			instanceId, []string{SQLExecuteStatusSucceeded, SQLExecuteStatusManuallyExecuted}).
+		Where("COALESCE(tme.executed_at, t.exec_end_at) >= ?", after).
+		Order("esd.task_id, esd.number").
+		Scan(&sqls).Error
+	return sqls, errors.New(errors.ConnectStorageError, err)
+}
@@ -0,0 +1,83 @@
+package model
+
+import (
+	"github.com/actiontech/sqle/sqle/errors"
+
+	"gorm.io/gorm"
+)
+
+// SQLManageApplicationMapping 将下发SQL的客户端地址映射为应用，SQL注释中未标记应用时按顺序匹配第一条映射
+type SQLManageApplicationMapping struct {
+	Model
+	ProjectId string `json:"project_id" gorm:"type:varchar(255);not null;index"`
+	// 映射顺序，越小越先匹配
+	Sort uint `json:"sort" gorm:"not null;default:0"`
+	// 支持IP、CIDR和主机名，主机名模糊匹配
+	Endpoint    string `json:"endpoint" gorm:"type:varchar(512);not null"`
+	Application string `json:"application" gorm:"type:varchar(255);not null"`
+	Desc        string `json:"desc" gorm:"type:varchar(512)"`
+}
+
+func (SQLManageApplicationMapping) TableName() string {
+	return "sql_manage_application_mappings"
+}
+
+func (s *Storage) GetSQLManageApplicationMappings(projectId string) ([]*SQLManageApplicationMapping, error) {
+	mappings := []*SQLManageApplicationMapping{}
+	err := s.db.Where("project_id = ?", projectId).Order("sort, id").Find(&mappings).Error
+	return mappings, errors.New(errors.ConnectStorageError, err)
+}
+
+func (s *Storage) ReplaceSQLManageApplicationMappings(projectId string, mappings []*SQLManageApplicationMapping) error {
+	return errors.New(errors.ConnectStorageError, s.Tx(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("project_id = ?", projectId).Delete(&SQLManageApplicationMapping{}).Error; err != nil {
+			return err
+		}
+		if len(mappings) == 0 {
+			return nil
+		}
+		return tx.Create(mappings).Error
+	}))
+}
+
+// SQLManageApplicationStatistic 按应用分组的SQL管控统计，未标记应用的SQL应用名为空
+type SQLManageApplicationStatistic struct {
+	Application    string `json:"application"`
+	SQLCount       uint64 `json:"sql_count"`
+	UnhandledCount uint64 `json:"unhandled_count"`
+	ErrorCount     uint64 `json:"error_count"`
+	WarnCount      uint64 `json:"warn_count"`
+}
+
+const sqlManageApplicationColumn = "COALESCE(JSON_UNQUOTE(JSON_EXTRACT(smr.info, '$.application')), '')"
+
+func (s *Storage) GetSQLManageApplicationStatistics(projectId string) ([]*SQLManageApplicationStatistic, error) {
+	statistics := []*SQLManageApplicationStatistic{}
+	err := s.db.Table("sql_manage_records smr").
+		Select(sqlManageApplicationColumn+" AS application, COUNT(*) AS sql_count, "+
+			"SUM(CASE WHEN smrp.status = ? THEN 1 ELSE 0 END) AS unhandled_count, "+
+			"SUM(CASE WHEN smr.audit_level = 'error' THEN 1 ELSE 0 END) AS error_count, "+
+			"SUM(CASE WHEN smr.audit_level = 'warn' THEN 1 ELSE 0 END) AS warn_count", ProcessStatusUnhandled).
+		Joins("LEFT JOIN sql_manage_record_processes smrp ON smr.id = smrp.sql_manage_record_id AND smrp.deleted_at IS NULL").
+		Where("smr.project_id = ? AND smr.deleted_at IS NULL", projectId).
+		Group("application").
+		Order("sql_count DESC").
+		Scan(&statistics).Error
+	return statistics, errors.New(errors.ConnectStorageError, err)
+}
+
+// GetSQLManageRecordsByApplication returns the SQLs of the application in the project, the SQLs without application
+// are returned if the application is empty. The SQLs are filtered by the status if it is not empty.
+func (s *Storage) GetSQLManageRecordsByApplication(projectId, application, status string) ([]*SQLManageRecord, error) {
+	records := []*SQLManageRecord{}
+	query := s.db.Table("sql_manage_records smr").
+		Select("smr.*").
+		Joins("LEFT JOIN sql_manage_record_processes smrp ON smr.id = smrp.sql_manage_record_id AND smrp.deleted_at IS NULL").
+		Where("smr.project_id = ? AND smr.deleted_at IS NULL", projectId).
+		Where(sqlManageApplicationColumn+" = ?", application)
+	if status != "" {
+		query = query.Where("smrp.status = ?", status)
+	}
+	err := query.Preload("SQLManager").Order("smr.id").Find(&records).Error
+	return records, errors.New(errors.ConnectStorageError, err)
+}
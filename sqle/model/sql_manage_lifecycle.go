@@ -0,0 +1,185 @@
+package model
+
+import (
+	"time"
+
+	"github.com/actiontech/sqle/sqle/errors"
+
+	"gorm.io/gorm"
+)
+
+// SQLManageLifecycleConfig 项目内SQL管控记录的自动流转配置
+type SQLManageLifecycleConfig struct {
+	Model
+	ProjectId string `json:"project_id" gorm:"type:varchar(255);not null;unique"`
+	// 超过该天数未再采集到的SQL自动标记为已解决，为0时不自动解决
+	AutoSolveUnseenDays uint `json:"auto_solve_unseen_days" gorm:"not null;default:0"`
+	// 已解决或已忽略的SQL再次采集到且指标劣化时重新打开
+	ReopenEnabled bool `json:"reopen_enabled"`
+	// 新采集的指标超过关闭前指标的该倍数时视为劣化
+	ReopenMetricFactor float64 `json:"reopen_metric_factor" gorm:"type:decimal(10,2);not null;default:1"`
+}
+
+func (SQLManageLifecycleConfig) TableName() string {
+	return "sql_manage_lifecycle_configs"
+}
+
+const (
+	AssignmentMatchTypeSchema   = "schema"
+	AssignmentMatchTypeTable    = "table"
+	AssignmentMatchTypeEndpoint = "endpoint"
+)
+
+// SQLManageAssignmentRule 未分配处理人的SQL按顺序匹配第一条规则，分配给规则的处理人
+type SQLManageAssignmentRule struct {
+	Model
+	ProjectId string `json:"project_id" gorm:"type:varchar(255);not null;index"`
+	// 规则顺序，越小越先匹配
+	Sort      uint   `json:"sort" gorm:"not null;default:0"`
+	MatchType string `json:"match_type" gorm:"type:varchar(255);not null"`
+	// 支持*和?通配符，大小写不敏感
+	Pattern   string `json:"pattern" gorm:"type:varchar(512);not null"`
+	Assignees string `json:"assignees" gorm:"type:varchar(2000);not null"`
+	Desc      string `json:"desc" gorm:"type:varchar(512)"`
+}
+
+func (SQLManageAssignmentRule) TableName() string {
+	return "sql_manage_assignment_rules"
+}
+
+const (
+	SQLManageActivityActionAssigned   = "assigned"
+	SQLManageActivityActionAutoSolved = "auto_solved"
+	SQLManageActivityActionReopened   = "reopened"
+
+	// 系统自动流转时的操作人
+	SQLManageActivityOperatorSystem = "system"
+)
+
+// SQLManageRecordActivity SQL管控记录的处理动态
+type SQLManageRecordActivity struct {
+	Model
+	SQLManageRecordID uint   `json:"sql_manage_record_id" gorm:"not null;index"`
+	Action            string `json:"action" gorm:"type:varchar(255);not null"`
+	Operator          string `json:"operator" gorm:"type:varchar(255);not null"`
+	FromStatus        string `json:"from_status" gorm:"type:varchar(255)"`
+	ToStatus          string `json:"to_status" gorm:"type:varchar(255)"`
+	FromAssignees     string `json:"from_assignees" gorm:"type:varchar(2000)"`
+	ToAssignees       string `json:"to_assignees" gorm:"type:varchar(2000)"`
+	Detail            string `json:"detail" gorm:"type:text"`
+}
+
+func (SQLManageRecordActivity) TableName() string {
+	return "sql_manage_record_activities"
+}
+
+func (s *Storage) GetSQLManageLifecycleConfig(projectId string) (*SQLManageLifecycleConfig, bool, error) {
+	config := &SQLManageLifecycleConfig{}
+	err := s.db.Where("project_id = ?", projectId).First(config).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, false, nil
+	}
+	return config, true, errors.New(errors.ConnectStorageError, err)
+}
+
+func (s *Storage) GetSQLManageLifecycleConfigs() ([]*SQLManageLifecycleConfig, error) {
+	configs := []*SQLManageLifecycleConfig{}
+	err := s.db.Find(&configs).Error
+	return configs, errors.New(errors.ConnectStorageError, err)
+}
+
+func (s *Storage) GetSQLManageAssignmentRules(projectId string) ([]*SQLManageAssignmentRule, error) {
+	rules := []*SQLManageAssignmentRule{}
+	err := s.db.Where("project_id = ?", projectId).Order("sort, id").Find(&rules).Error
+	return rules, errors.New(errors.ConnectStorageError, err)
+}
+
+// GetProjectIdsWithSQLManageAssignmentRules returns the projects which have assignment rules.
+func (s *Storage) GetProjectIdsWithSQLManageAssignmentRules() ([]string, error) {
+	projectIds := []string{}
+	err := s.db.Model(&SQLManageAssignmentRule{}).Distinct("project_id").Pluck("project_id", &projectIds).Error
+	return projectIds, errors.New(errors.ConnectStorageError, err)
+}
+
+// SaveSQLManageLifecycle saves the lifecycle config and replaces the assignment rules of the project.
+func (s *Storage) SaveSQLManageLifecycle(config *SQLManageLifecycleConfig, rules []*SQLManageAssignmentRule) error {
+	return errors.New(errors.ConnectStorageError, s.Tx(func(tx *gorm.DB) error {
+		if err := tx.Save(config).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("project_id = ?", config.ProjectId).Delete(&SQLManageAssignmentRule{}).Error; err != nil {
+			return err
+		}
+		if len(rules) == 0 {
+			return nil
+		}
+		return tx.Create(rules).Error
+	}))
+}
+
+// GetUnassignedSQLManageRecords returns the unhandled SQLs without assignees in the project after the id, the
+// records are paged by the id since the SQLs matching no assignment rule are kept unassigned.
+func (s *Storage) GetUnassignedSQLManageRecords(projectId string, afterId uint, limit int) ([]*SQLManageRecord, error) {
+	records := []*SQLManageRecord{}
+	err := s.db.Model(&SQLManageRecord{}).
+		Joins("JOIN sql_manage_record_processes smrp ON sql_manage_records.id = smrp.sql_manage_record_id AND smrp.deleted_at IS NULL").
+		Where("sql_manage_records.project_id = ? AND sql_manage_records.id > ?", projectId, afterId).
+		Where("smrp.status = ? AND (smrp.assignees IS NULL OR smrp.assignees = '')", ProcessStatusUnhandled).
+		Preload("SQLManager").
+		Order("sql_manage_records.id").Limit(limit).
+		Find(&records).Error
+	return records, errors.New(errors.ConnectStorageError, err)
+}
+
+// GetSQLManageRecordsUnseenSince returns the SQLs not solved or ignored in the project which are not collected
+// since the time. The time the SQL is last collected is the last_receive_timestamp in the info, which is saved
+// in RFC3339 format, the record without it falls back to the update time.
+func (s *Storage) GetSQLManageRecordsUnseenSince(projectId string, since time.Time, limit int) ([]*SQLManageRecord, error) {
+	records := []*SQLManageRecord{}
+	err := s.db.Model(&SQLManageRecord{}).
+		Joins("JOIN sql_manage_record_processes smrp ON sql_manage_records.id = smrp.sql_manage_record_id AND smrp.deleted_at IS NULL").
+		Where("sql_manage_records.project_id = ?", projectId).
+		Where("JSON_UNQUOTE(JSON_EXTRACT(sql_manage_records.info, '$.last_receive_timestamp')) < ? OR "+
+			"(JSON_EXTRACT(sql_manage_records.info, '$.last_receive_timestamp') IS NULL AND sql_manage_records.updated_at < ?)",
+			since.Format(time.RFC3339), since).
+		Where("smrp.status NOT IN (?)", []string{ProcessStatusSolved, ProcessStatusIgnored}).
+		Preload("SQLManager").
+		Order("sql_manage_records.id").Limit(limit).
+		Find(&records).Error
+	return records, errors.New(errors.ConnectStorageError, err)
+}
+
+// GetClosedSQLManageRecordsBySQLIds returns the solved or ignored SQLs of the SQL IDs.
+func (s *Storage) GetClosedSQLManageRecordsBySQLIds(sqlIds []string) ([]*SQLManageRecord, error) {
+	records := []*SQLManageRecord{}
+	if len(sqlIds) == 0 {
+		return records, nil
+	}
+	err := s.db.Model(&SQLManageRecord{}).
+		Joins("JOIN sql_manage_record_processes smrp ON sql_manage_records.id = smrp.sql_manage_record_id AND smrp.deleted_at IS NULL").
+		Where("sql_manage_records.sql_id IN (?) AND smrp.status IN (?)", sqlIds, []string{ProcessStatusSolved, ProcessStatusIgnored}).
+		Preload("SQLManager").
+		Find(&records).Error
+	return records, errors.New(errors.ConnectStorageError, err)
+}
+
+// UpdateSQLManageRecordProcessWithActivity updates the process of the SQL and records the activity in the transaction.
+func UpdateSQLManageRecordProcessWithActivity(tx *gorm.DB, activity *SQLManageRecordActivity, attrs map[string]interface{}) error {
+	err := tx.Model(&SQLManageRecordProcess{}).Where("sql_manage_record_id = ?", activity.SQLManageRecordID).Updates(attrs).Error
+	if err != nil {
+		return err
+	}
+	return tx.Create(activity).Error
+}
+
+func (s *Storage) UpdateSQLManageRecordProcessWithActivity(activity *SQLManageRecordActivity, attrs map[string]interface{}) error {
+	return errors.New(errors.ConnectStorageError, s.Tx(func(tx *gorm.DB) error {
+		return UpdateSQLManageRecordProcessWithActivity(tx, activity, attrs)
+	}))
+}
+
+func (s *Storage) GetSQLManageRecordActivities(sqlManageRecordId uint) ([]*SQLManageRecordActivity, error) {
+	activities := []*SQLManageRecordActivity{}
+	err := s.db.Where("sql_manage_record_id = ?", sqlManageRecordId).Order("id DESC").Find(&activities).Error
+	return activities, errors.New(errors.ConnectStorageError, err)
+}
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStorage_GetUnassignedSQLManageRecords(t *testing.T) {
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	mock.ExpectQuery("SELECT VERSION()").WillReturnRows(sqlmock.NewRows([]string{"VERSION()"}).AddRow("5.7"))
+	InitMockStorage(mockDB)
+	mock.ExpectQuery("SELECT `sql_manage_records`.`id`,`sql_manage_records`.`created_at`,`sql_manage_records`.`updated_at`,`sql_manage_records`.`deleted_at`,`sql_manage_records`.`source`,`sql_manage_records`.`source_id`,`sql_manage_records`.`project_id`,`sql_manage_records`.`instance_id`,`sql_manage_records`.`schema_name`,`sql_manage_records`.`sql_fingerprint`,`sql_manage_records`.`sql_text`,`sql_manage_records`.`info`,`sql_manage_records`.`audit_level`,`sql_manage_records`.`audit_results`,`sql_manage_records`.`sql_id`,`sql_manage_records`.`priority` FROM `sql_manage_records` JOIN sql_manage_record_processes smrp ON sql_manage_records.id = smrp.sql_manage_record_id AND smrp.deleted_at IS NULL WHERE (sql_manage_records.project_id = ? AND sql_manage_records.id > ?) AND (smrp.status = ? AND (smrp.assignees IS NULL OR smrp.assignees = '')) AND `sql_manage_records`.`deleted_at` IS NULL ORDER BY sql_manage_records.id LIMIT 10").
+		WithArgs("project_1", 100, ProcessStatusUnhandled).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectClose()
+	records, err := GetStorage().GetUnassignedSQLManageRecords("project_1", 100, 10)
+	assert.NoError(t, err)
+	assert.Len(t, records, 0)
+	mockDB.Close()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStorage_GetSQLManageRecordsUnseenSince(t *testing.T) {
+	mockDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	mock.ExpectQuery("SELECT VERSION()").WillReturnRows(sqlmock.NewRows([]string{"VERSION()"}).AddRow("5.7"))
+	InitMockStorage(mockDB)
+	since := time.Date(2024, 3, 25, 0, 0, 0, 0, time.Local)
+	mock.ExpectQuery("SELECT `sql_manage_records`.`id`,`sql_manage_records`.`created_at`,`sql_manage_records`.`updated_at`,`sql_manage_records`.`deleted_at`,`sql_manage_records`.`source`,`sql_manage_records`.`source_id`,`sql_manage_records`.`project_id`,`sql_manage_records`.`instance_id`,`sql_manage_records`.`schema_name`,`sql_manage_records`.`sql_fingerprint`,`sql_manage_records`.`sql_text`,`sql_manage_records`.`info`,`sql_manage_records`.`audit_level`,`sql_manage_records`.`audit_results`,`sql_manage_records`.`sql_id`,`sql_manage_records`.`priority` FROM `sql_manage_records` JOIN sql_manage_record_processes smrp ON sql_manage_records.id = smrp.sql_manage_record_id AND smrp.deleted_at IS NULL WHERE sql_manage_records.project_id = ? AND (JSON_UNQUOTE(JSON_EXTRACT(sql_manage_records.info, '$.last_receive_timestamp')) < ? OR (JSON_EXTRACT(sql_manage_records.info, '$.last_receive_timestamp') IS NULL AND sql_manage_records.updated_at < ?)) AND smrp.status NOT IN (?,?) AND `sql_manage_records`.`deleted_at` IS NULL ORDER BY sql_manage_records.id LIMIT 10").
+		WithArgs("project_1", since.Format(time.RFC3339), since, ProcessStatusSolved, ProcessStatusIgnored).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectClose()
+	records, err := GetStorage().GetSQLManageRecordsUnseenSince("project_1", since, 10)
+	assert.NoError(t, err)
+	assert.Len(t, records, 0)
+	mockDB.Close()
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
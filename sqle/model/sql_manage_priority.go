@@ -0,0 +1,68 @@
+package model
+
+import (
+	"time"
+
+	"github.com/actiontech/sqle/sqle/errors"
+
+	"gorm.io/gorm"
+)
+
+// SQL管控的分级优先级，P0最高
+const (
+	PriorityP0 = "P0"
+	PriorityP1 = "P1"
+	PriorityP2 = "P2"
+	PriorityP3 = "P3"
+)
+
+// SQLManagePriorities are the levels of the priority rules, from the highest to the lowest.
+var SQLManagePriorities = []string{PriorityP0, PriorityP1, PriorityP2, PriorityP3}
+
+// SQLManagePriorityRule 项目内SQL管控的优先级规则，SQL按P0到P3的顺序匹配第一个满足表达式的优先级
+type SQLManagePriorityRule struct {
+	Model
+	ProjectId  string `json:"project_id" gorm:"type:varchar(255);not null;uniqueIndex:uniq_project_id_priority"`
+	Priority   string `json:"priority" gorm:"type:varchar(255);not null;uniqueIndex:uniq_project_id_priority"`
+	Expression string `json:"expression" gorm:"type:text;not null"`
+	// 待处理的SQL超过该时长未处理时升级推送，为0时不升级
+	SLAMinutes uint   `json:"sla_minutes" gorm:"not null;default:0"`
+	Desc       string `json:"desc" gorm:"type:varchar(512)"`
+}
+
+func (SQLManagePriorityRule) TableName() string {
+	return "sql_manage_priority_rules"
+}
+
+func (s *Storage) GetSQLManagePriorityRulesByProjectId(projectId string) ([]*SQLManagePriorityRule, error) {
+	rules := []*SQLManagePriorityRule{}
+	err := s.db.Where("project_id = ?", projectId).Order("priority").Find(&rules).Error
+	return rules, errors.New(errors.ConnectStorageError, err)
+}
+
+// ReplaceSQLManagePriorityRules replaces all the priority rules of the project.
+func (s *Storage) ReplaceSQLManagePriorityRules(projectId string, rules []*SQLManagePriorityRule) error {
+	return errors.New(errors.ConnectStorageError, s.Tx(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("project_id = ?", projectId).Delete(&SQLManagePriorityRule{}).Error; err != nil {
+			return err
+		}
+		if len(rules) == 0 {
+			return nil
+		}
+		return tx.Create(rules).Error
+	}))
+}
+
+// GetSQLManageRecordsBreachingSLA returns the unhandled SQLs of the priority in the project which are collected
+// before the deadline and not escalated yet.
+func (s *Storage) GetSQLManageRecordsBreachingSLA(projectId, priority string, deadline time.Time) ([]*SQLManageRecord, error) {
+	records := []*SQLManageRecord{}
+	err := s.db.Model(&SQLManageRecord{}).
+		Joins("JOIN sql_manage_record_processes smrp ON sql_manage_records.id = smrp.sql_manage_record_id AND smrp.deleted_at IS NULL").
+		Where("sql_manage_records.project_id = ? AND sql_manage_records.priority = ?", projectId, priority).
+		Where("sql_manage_records.created_at < ? AND smrp.status = ? AND smrp.escalated_at IS NULL", deadline, ProcessStatusUnhandled).
+		Preload("SQLManager").
+		Order("sql_manage_records.id").
+		Find(&records).Error
+	return records, errors.New(errors.ConnectStorageError, err)
+}
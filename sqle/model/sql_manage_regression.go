@@ -0,0 +1,94 @@
+package model
+
+import (
+	"time"
+
+	"github.com/actiontech/sqle/sqle/errors"
+
+	"gorm.io/gorm"
+)
+
+// SQLManageRegression SQL的执行指标相对基线的劣化，同一SQL的同一指标只保留最近一次检测到的劣化
+type SQLManageRegression struct {
+	Model
+	SQLID         string    `json:"sql_id" gorm:"type:varchar(255);not null;uniqueIndex:uniq_sql_id_metric_name"`
+	MetricName    string    `json:"metric_name" gorm:"type:varchar(255);not null;uniqueIndex:uniq_sql_id_metric_name"`
+	BaselineValue float64   `json:"baseline_value" gorm:"type:decimal(20,4);not null;comment:劣化前一段时间内的加权平均值"`
+	CurrentValue  float64   `json:"current_value" gorm:"type:decimal(20,4);not null"`
+	Ratio         float64   `json:"ratio" gorm:"type:decimal(20,4);not null;comment:当前值相对基线的倍数"`
+	DetectedAt    time.Time `json:"detected_at" gorm:"not null"`
+	// 指标恢复后记录恢复时间，为空时表示劣化仍在持续
+	ResolvedAt *time.Time `json:"resolved_at"`
+}
+
+func (SQLManageRegression) TableName() string {
+	return "sql_manage_regressions"
+}
+
+func (s *Storage) GetSQLManageRegression(sqlId, metricName string) (*SQLManageRegression, bool, error) {
+	regression := &SQLManageRegression{}
+	err := s.db.Where("sql_id = ? AND metric_name = ?", sqlId, metricName).First(regression).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, false, nil
+	}
+	return regression, true, errors.New(errors.ConnectStorageError, err)
+}
+
+func (s *Storage) GetUnresolvedSQLManageRegressions(sqlId string) ([]*SQLManageRegression, error) {
+	regressions := []*SQLManageRegression{}
+	err := s.db.Where("sql_id = ? AND resolved_at IS NULL", sqlId).Order("metric_name").Find(&regressions).Error
+	return regressions, errors.New(errors.ConnectStorageError, err)
+}
+
+func (s *Storage) ResolveSQLManageRegression(sqlId, metricName string, resolvedAt time.Time) error {
+	err := s.db.Model(&SQLManageRegression{}).
+		Where("sql_id = ? AND metric_name = ? AND resolved_at IS NULL", sqlId, metricName).
+		Update("resolved_at", resolvedAt).Error
+	return errors.New(errors.ConnectStorageError, err)
+}
+
+// CreateSqlManageMetricRecordWithValues saves the metrics of the SQL collected in one collection cycle.
+func (s *Storage) CreateSqlManageMetricRecordWithValues(record *SqlManageMetricRecord) error {
+	return errors.New(errors.ConnectStorageError, s.Tx(func(tx *gorm.DB) error {
+		if err := tx.Omit("MetricValues", "SqlManageMetricExecutePlanRecords").Create(record).Error; err != nil {
+			return err
+		}
+		if len(record.MetricValues) == 0 {
+			return nil
+		}
+		for _, value := range record.MetricValues {
+			value.SqlManageMetricRecordID = record.ID
+		}
+		return tx.Create(record.MetricValues).Error
+	}))
+}
+
+// GetSqlManageMetricWeightedAvg returns the average of the metric of the SQL weighted by the execution count of
+// the records ended in [begin, end), and the number of the records.
+func (s *Storage) GetSqlManageMetricWeightedAvg(sqlId, metricName string, begin, end time.Time) (float64, int64, error) {
+	var result struct {
+		Avg   float64
+		Count int64
+	}
+	err := s.db.Table("sql_manage_metric_records AS r").
+		Select("COALESCE(SUM(v.metric_value * r.execution_count) / NULLIF(SUM(r.execution_count), 0), 0) AS avg, COUNT(*) AS count").
+		Joins("JOIN sql_manage_metric_values AS v ON v.sql_manage_metric_record_id = r.id").
+		Where("r.sql_id = ? AND v.metric_name = ? AND r.record_end_at >= ? AND r.record_end_at < ? AND r.deleted_at IS NULL",
+			sqlId, metricName, begin, end).
+		Scan(&result).Error
+	if err != nil {
+		return 0, 0, errors.New(errors.ConnectStorageError, err)
+	}
+	return result.Avg, result.Count, nil
+}
+
+// GetSqlManageMetricRecordsWithValues returns the metric records of the SQL ended in [begin, end] in time order,
+// only the values of the metrics given are loaded.
+func (s *Storage) GetSqlManageMetricRecordsWithValues(sqlId string, metricNames []string, begin, end time.Time) ([]*SqlManageMetricRecord, error) {
+	records := []*SqlManageMetricRecord{}
+	err := s.db.Preload("MetricValues", "metric_name IN (?)", metricNames).
+		Where("sql_id = ? AND record_end_at >= ? AND record_end_at <= ?", sqlId, begin, end).
+		Where("EXISTS (SELECT 1 FROM sql_manage_metric_values v WHERE v.sql_manage_metric_record_id = sql_manage_metric_records.id AND v.metric_name IN (?))", metricNames).
+		Order("record_end_at").Find(&records).Error
+	return records, errors.New(errors.ConnectStorageError, err)
+}
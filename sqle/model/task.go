@@ -65,6 +65,9 @@ type Task struct {
 	BackupMaxRows        uint64         `json:"backup_max_rows" gorm:"column:backup_max_rows;not null;default:0"`
 	InstanceEnableBackup bool           `gorm:"column:instance_enable_backup"` // 用于记录创建task时，instance备份开关的状态
 	FileOrderMethod      string         `json:"file_order_method" gorm:"column:file_order_method;type:varchar(255)"`
+	ExecPolicy           TaskExecPolicy `json:"exec_policy" gorm:"type:json"`
+	ExecCheckStatus      string         `json:"exec_check_status" gorm:"type:varchar(255)"` // 工单上线前后检查的结果，没有检查时为空
+	SchemaShape          string         `json:"schema_shape" gorm:"type:char(32)"`          // 分发到多个schema时schema表结构的指纹，指纹相同的任务只审核一次
 	Instance             *Instance      `json:"-" gorm:"-"`
 	RuleTemplate         *RuleTemplate  `json:"-" gorm:"foreignkey:RuleTemplateID"`
 	ExecuteSQLs          []*ExecuteSQL  `json:"-" gorm:"foreignkey:TaskId"`
@@ -139,6 +142,8 @@ type BaseSQL struct {
 	StartBinlogPos  int64  `json:"start_binlog_pos"`
 	EndBinlogFile   string `json:"end_binlog_file" gorm:"type:varchar(255)"`
 	EndBinlogPos    int64  `json:"end_binlog_pos"`
+	StartGtidSet    string `json:"start_gtid_set" gorm:"type:text"`
+	EndGtidSet      string `json:"end_gtid_set" gorm:"type:text"`
 	RowAffects      int64  `json:"row_affects"`
 	ExecStatus      string `json:"exec_status" gorm:"default:\"initialized\""`
 	ExecResult      string `json:"exec_result" gorm:"type:text"`
@@ -337,8 +342,109 @@ type ExecuteSQL struct {
 	// it used for deduplication in one audit task.
 	AuditFingerprint string `json:"audit_fingerprint" gorm:"index;type:char(32)"`
 	// AuditLevel has four level: error, warn, notice, normal.
-	AuditLevel string      `json:"audit_level" gorm:"type:varchar(255)"`
-	BackupTask *BackupTask `json:"-" gorm:"foreignkey:execute_sql_id"`
+	AuditLevel string `json:"audit_level" gorm:"type:varchar(255)"`
+	// ExecDuration 执行耗时(毫秒)，在同一事务或批次中执行的SQL记录整个事务或批次的耗时
+	ExecDuration  uint64           `json:"exec_duration" gorm:"not null;default:0"`
+	ExecWarnings  ExecWarnings     `json:"exec_warnings" gorm:"type:json"`
+	ResultPreview SQLResultPreview `json:"result_preview" gorm:"type:json"` // 返回结果集的SQL执行后保存的前若干行结果
+	BackupTask    *BackupTask      `json:"-" gorm:"foreignkey:execute_sql_id"`
+}
+
+// ExecWarning SQL执行后 SHOW WARNINGS 返回的告警
+type ExecWarning struct {
+	Level   string `json:"level"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type ExecWarnings []ExecWarning
+
+// Scan impl sql.Scanner interface
+func (w *ExecWarnings) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return fmt.Errorf("failed to unmarshal json value: %v", value)
+	}
+	if len(bytes) == 0 {
+		return nil
+	}
+	result := ExecWarnings{}
+	err := json.Unmarshal(bytes, &result)
+	*w = result
+	return err
+}
+
+// Value impl sql.driver.Valuer interface
+func (w ExecWarnings) Value() (driver.Value, error) {
+	if len(w) == 0 {
+		return nil, nil
+	}
+	v, err := json.Marshal(w)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal json value: %v", v)
+	}
+	return v, err
+}
+
+func (w ExecWarnings) String() string {
+	lines := make([]string, 0, len(w))
+	for _, warning := range w {
+		lines = append(lines, fmt.Sprintf("%v %v: %v", warning.Level, warning.Code, warning.Message))
+	}
+	return strings.Join(lines, "\n")
+}
+
+type SQLResultPreview struct {
+	Columns []string   `json:"columns"`
+	Rows    [][]string `json:"rows"`
+	// Truncated 结果集行数超过保存的行数
+	Truncated bool `json:"truncated"`
+}
+
+// String returns the preview in the format of TSV, the first line is the columns.
+func (p SQLResultPreview) String() string {
+	if len(p.Columns) == 0 {
+		return ""
+	}
+	lines := make([]string, 0, len(p.Rows)+2)
+	lines = append(lines, strings.Join(p.Columns, "\t"))
+	for _, row := range p.Rows {
+		lines = append(lines, strings.Join(row, "\t"))
+	}
+	if p.Truncated {
+		lines = append(lines, "...")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Scan impl sql.Scanner interface
+func (p *SQLResultPreview) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return fmt.Errorf("failed to unmarshal json value: %v", value)
+	}
+	if len(bytes) == 0 {
+		return nil
+	}
+	return json.Unmarshal(bytes, p)
+}
+
+// Value impl sql.driver.Valuer interface
+func (p SQLResultPreview) Value() (driver.Value, error) {
+	if len(p.Columns) == 0 {
+		return nil, nil
+	}
+	v, err := json.Marshal(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal json value: %v", v)
+	}
+	return v, err
 }
 
 func (s ExecuteSQL) TableName() string {
@@ -511,6 +617,25 @@ func (s *Storage) GetTaskExecuteSQLContent(taskId string) ([]byte, error) {
 	return buff.Bytes(), nil
 }
 
+// GetExecuteSQLsByTaskIdsAndSQLTypes returns the execute SQLs of the tasks, only the SQLs of the types are returned if sqlTypes is not empty.
+func (s *Storage) GetExecuteSQLsByTaskIdsAndSQLTypes(taskIds []uint, sqlTypes []string) ([]*ExecuteSQL, error) {
+	sqls := []*ExecuteSQL{}
+	query := s.db.Model(&ExecuteSQL{}).Select("id, task_id, number, content, sql_type").Where("task_id IN (?)", taskIds)
+	if len(sqlTypes) > 0 {
+		query = query.Where("sql_type IN (?)", sqlTypes)
+	}
+	err := query.Order("task_id, number").Find(&sqls).Error
+	return sqls, errors.New(errors.ConnectStorageError, err)
+}
+
+// GetSQLTypesByTaskIds returns the distinct SQL types of the tasks, such as "ddl", "dml".
+func (s *Storage) GetSQLTypesByTaskIds(taskIds []uint) ([]string, error) {
+	sqlTypes := []string{}
+	err := s.db.Model(&ExecuteSQL{}).Where("task_id IN (?) AND sql_type <> ''", taskIds).
+		Distinct().Pluck("sql_type", &sqlTypes).Error
+	return sqlTypes, errors.New(errors.ConnectStorageError, err)
+}
+
 func (s *Storage) UpdateTask(task *Task, attrs interface{}) error {
 	err := s.db.Table("tasks").Where("id = ?", task.ID).Updates(attrs).Error
 
@@ -630,6 +755,17 @@ type TaskSQLDetail struct {
 	ExecStatus    string         `json:"exec_status"`
 	RollbackSQL   sql.NullString `json:"rollback_sql"`
 	SQLType       sql.NullString `json:"sql_type"`
+	// binlog coordinates of the MySQL instance before and after the SQL was executed
+	StartBinlogFile sql.NullString `json:"start_binlog_file"`
+	StartBinlogPos  sql.NullInt64  `json:"start_binlog_pos"`
+	StartGtidSet    sql.NullString `json:"start_gtid_set"`
+	EndBinlogFile   sql.NullString `json:"end_binlog_file"`
+	EndBinlogPos    sql.NullInt64  `json:"end_binlog_pos"`
+	EndGtidSet      sql.NullString `json:"end_gtid_set"`
+	// output of the execution
+	ExecDuration  uint64           `json:"exec_duration"`
+	ExecWarnings  ExecWarnings     `json:"exec_warnings"`
+	ResultPreview SQLResultPreview `json:"result_preview"`
 }
 
 func (t *TaskSQLDetail) GetAuditResults(ctx context.Context) string {
@@ -641,7 +777,9 @@ func (t *TaskSQLDetail) GetAuditResults(ctx context.Context) string {
 }
 
 var taskSQLsQueryTpl = `SELECT e_sql.id,e_sql.number, e_sql.description, e_sql.content AS exec_sql,  e_sql.source_file AS sql_source_file, e_sql.start_line AS sql_start_line, e_sql.sql_type,
-e_sql.audit_results, e_sql.audit_level, e_sql.audit_status, e_sql.exec_result, e_sql.exec_status
+e_sql.audit_results, e_sql.audit_level, e_sql.audit_status, e_sql.exec_result, e_sql.exec_status,
+e_sql.start_binlog_file, e_sql.start_binlog_pos, e_sql.start_gtid_set, e_sql.end_binlog_file, e_sql.end_binlog_pos, e_sql.end_gtid_set,
+e_sql.exec_duration, e_sql.exec_warnings, e_sql.result_preview
 
 {{- template "body" . -}}
 
@@ -750,7 +888,15 @@ func (s *Storage) GetTaskSQLCountByTaskID(taskId uint) (int64, error) {
 
 type TaskGroup struct {
 	Model
-	Tasks []*Task `json:"tasks" gorm:"foreignkey:GroupId"`
+	// FanOut 同一SQL分发到多个数据源的多个schema，工单的任务数不受 MaximumDataSourceNum 限制
+	FanOut bool    `json:"fan_out"`
+	Tasks  []*Task `json:"tasks" gorm:"foreignkey:GroupId"`
+}
+
+func (s *Storage) GetTaskGroupsByIds(groupIds []uint) ([]*TaskGroup, error) {
+	taskGroups := []*TaskGroup{}
+	err := s.db.Where("id IN (?)", groupIds).Find(&taskGroups).Error
+	return taskGroups, errors.New(errors.ConnectStorageError, err)
 }
 
 func (s *Storage) GetTaskGroupByGroupId(groupId uint) (*TaskGroup, error) {
@@ -0,0 +1,95 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// TaskExecPolicy 任务的上线执行策略，字段为零值时不生效
+type TaskExecPolicy struct {
+	// SQL的执行超时时间，超时后终止执行。事务或批量执行的SQL整体计算超时时间
+	StatementTimeoutSeconds uint `json:"statement_timeout_seconds"`
+	// MySQL会话的 lock_wait_timeout，即等待元数据锁的超时时间
+	LockWaitTimeoutSeconds uint `json:"lock_wait_timeout_seconds"`
+	// MySQL会话的 innodb_lock_wait_timeout，即等待行锁的超时时间
+	InnodbLockWaitTimeoutSeconds uint `json:"innodb_lock_wait_timeout_seconds"`
+	// SQL因死锁或锁等待超时执行失败后的重试次数，重试间隔从 RetryDelaySeconds 开始逐次翻倍
+	RetryAttempts     uint `json:"retry_attempts"`
+	RetryDelaySeconds uint `json:"retry_delay_seconds"`
+	// 执行DDL前检查持有事务或执行查询达到该时长的会话，存在时不执行该DDL
+	BlockingSessionSeconds uint `json:"blocking_session_seconds"`
+	// 单表的UPDATE/DELETE按主键范围分批执行，每批扫描 ChunkSize 行，批次之间间隔 ChunkSleepMilliseconds 毫秒，
+	// 从库复制延迟超过 MaxReplicationLagSeconds 秒时等待延迟恢复后再执行下一批
+	ChunkedDML               bool `json:"chunked_dml"`
+	ChunkSize                uint `json:"chunk_size"`
+	ChunkSleepMilliseconds   uint `json:"chunk_sleep_milliseconds"`
+	MaxReplicationLagSeconds uint `json:"max_replication_lag_seconds"`
+	// 检查复制延迟的从库地址（host:port），为空时使用注册到实例的从库上报的地址
+	ReplicaAddresses []string `json:"replica_addresses,omitempty"`
+}
+
+const defaultDMLChunkSize = 1000
+
+func (p TaskExecPolicy) DMLChunkSize() uint {
+	if p.ChunkSize == 0 {
+		return defaultDMLChunkSize
+	}
+	return p.ChunkSize
+}
+
+func (p TaskExecPolicy) StatementTimeout() time.Duration {
+	return time.Duration(p.StatementTimeoutSeconds) * time.Second
+}
+
+func (p TaskExecPolicy) RetryDelay() time.Duration {
+	if p.RetryDelaySeconds == 0 {
+		return time.Second
+	}
+	return time.Duration(p.RetryDelaySeconds) * time.Second
+}
+
+// SessionVariableSQLs returns the SQLs to override the lock wait timeout of the MySQL session.
+func (p TaskExecPolicy) SessionVariableSQLs() []string {
+	sqls := []string{}
+	if p.LockWaitTimeoutSeconds > 0 {
+		sqls = append(sqls, fmt.Sprintf("SET SESSION lock_wait_timeout = %d", p.LockWaitTimeoutSeconds))
+	}
+	if p.InnodbLockWaitTimeoutSeconds > 0 {
+		sqls = append(sqls, fmt.Sprintf("SET SESSION innodb_lock_wait_timeout = %d", p.InnodbLockWaitTimeoutSeconds))
+	}
+	return sqls
+}
+
+// Scan impl sql.Scanner interface
+func (p *TaskExecPolicy) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return fmt.Errorf("failed to unmarshal json value: %v", value)
+	}
+	if len(bytes) == 0 {
+		return nil
+	}
+	result := TaskExecPolicy{}
+	err := json.Unmarshal(bytes, &result)
+	*p = result
+	return err
+}
+
+// Value impl sql.driver.Valuer interface
+func (p TaskExecPolicy) Value() (driver.Value, error) {
+	v, err := json.Marshal(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal json value: %v", v)
+	}
+	return v, err
+}
+
+func (s *Storage) UpdateTaskExecPolicy(task *Task, policy TaskExecPolicy) error {
+	task.ExecPolicy = policy
+	return s.UpdateTask(task, map[string]interface{}{"exec_policy": policy})
+}
@@ -0,0 +1,86 @@
+package model
+
+import (
+	"time"
+
+	"github.com/actiontech/sqle/sqle/errors"
+	"github.com/actiontech/sqle/sqle/log"
+
+	"gorm.io/gorm"
+)
+
+const (
+	ManualExecutionVerifyStatusPassed = "passed"
+	ManualExecutionVerifyStatusFailed = "failed"
+	// 任务没有可以校验的schema变更
+	ManualExecutionVerifyStatusSkipped = "skipped"
+)
+
+// TaskManualExecution 在SQLE外手动上线的任务的上线凭证
+type TaskManualExecution struct {
+	Model
+	TaskId     uint       `json:"task_id" gorm:"not null;unique"`
+	WorkflowId string     `json:"workflow_id" gorm:"type:varchar(255);not null;index"`
+	ExecutedAt *time.Time `json:"executed_at" gorm:"not null"`
+	// 实际执行上线的人，可以不是SQLE的用户
+	Executor     string `json:"executor" gorm:"type:varchar(255);not null"`
+	RecordUserId string `json:"record_user_id" gorm:"type:varchar(255)"`
+	Remark       string `json:"remark" gorm:"type:text"`
+	// 执行日志或输出，和凭证文件至少有一个
+	ExecutionLog       string `json:"execution_log" gorm:"type:longtext"`
+	EvidenceFileName   string `json:"evidence_file_name" gorm:"type:varchar(255)"`
+	EvidenceUniqueName string `json:"evidence_unique_name" gorm:"type:varchar(255)"`
+	EvidenceFileHost   string `json:"evidence_file_host" gorm:"type:varchar(255)"`
+	// 上线后对比schema确认变更已生效的结果，未校验时为空
+	VerifyStatus string     `json:"verify_status" gorm:"type:varchar(255)"`
+	VerifyResult string     `json:"verify_result" gorm:"type:text"`
+	VerifiedAt   *time.Time `json:"verified_at"`
+}
+
+func (s *Storage) GetTaskManualExecutionByTaskId(taskId uint) (*TaskManualExecution, bool, error) {
+	execution := &TaskManualExecution{}
+	err := s.db.Where("task_id = ?", taskId).First(execution).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, false, nil
+	}
+	return execution, true, errors.New(errors.ConnectStorageError, err)
+}
+
+func (s *Storage) UpdateTaskManualExecutionVerifyResult(execution *TaskManualExecution) error {
+	err := s.db.Model(&TaskManualExecution{}).Where("id = ?", execution.ID).Updates(map[string]interface{}{
+		"verify_status": execution.VerifyStatus,
+		"verify_result": execution.VerifyResult,
+		"verified_at":   execution.VerifiedAt,
+	}).Error
+	return errors.New(errors.ConnectStorageError, err)
+}
+
+// CreateTaskManualExecution saves the evidence and marks the task of the instance record manually executed,
+// the workflow status and the operated step are updated in the same transaction.
+func (s *Storage) CreateTaskManualExecution(w *Workflow, operateStep *WorkflowStep, record *WorkflowInstanceRecord, execution *TaskManualExecution, execResult string) error {
+	return errors.New(errors.ConnectStorageError, s.Tx(func(tx *gorm.DB) error {
+		if err := tx.Create(execution).Error; err != nil {
+			return err
+		}
+		err := tx.Exec("UPDATE execute_sql_detail SET exec_status = ?, exec_result = ? WHERE task_id = ? AND exec_status IN (?)",
+			SQLExecuteStatusManuallyExecuted, execResult, record.TaskId, []string{SQLExecuteStatusFailed, SQLExecuteStatusInitialized}).Error
+		if err != nil {
+			return err
+		}
+		if err := updateTaskStatusById(tx, record.TaskId, TaskStatusManuallyExecuted); err != nil {
+			return err
+		}
+		if err := updateWorkflowStatus(tx, w); err != nil {
+			return err
+		}
+		if operateStep != nil {
+			if err := updateWorkflowStep(tx, operateStep); err != nil {
+				return err
+			}
+			if err := s.UpdateStageWorkflowExecTimeIfNeed(w.WorkflowId); err != nil {
+				log.NewEntry().Errorf("update workflow execute time for version stage error: %v", err)
+			}
+		}
+		return updateWorkflowInstanceRecordById(tx, []*WorkflowInstanceRecord{record})
+	}))
+}
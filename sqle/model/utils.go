@@ -144,6 +144,18 @@ var autoMigrateList = []interface{}{
 	&WorkflowRecord{},
 	&WorkflowStepTemplate{},
 	&WorkflowStep{},
+	&WorkflowStepEvent{},
+	&WorkflowApprovalDelegate{},
+	&ExecutionFreezeCalendar{},
+	&WorkflowFreezeOverride{},
+	&RollbackRun{},
+	&RollbackRunSQL{},
+	&DMLChunkExecution{},
+	&DMLChunk{},
+	&WorkflowExecCheck{},
+	&WorkflowExecCheckResult{},
+	&WorkflowExecPlan{},
+	&TaskManualExecution{},
 	&WorkflowTemplate{},
 	&Workflow{},
 	&SqlQueryExecutionSql{},
@@ -168,12 +180,21 @@ var autoMigrateList = []interface{}{
 	&InstanceAuditPlan{},
 	&AuditPlanV2{},
 	&AuditPlanTaskInfo{},
+	&AuditPlanCollectionRecord{},
 	&SQLManageRecord{},
 	&SQLManageRecordProcess{},
 	&SQLManageQueue{},
 	&SqlManageMetricRecord{},
 	&SqlManageMetricValue{},
 	&SqlManageMetricExecutePlanRecord{},
+	&SQLManageRegression{},
+	&SQLManagePriorityRule{},
+	&SQLManageLifecycleConfig{},
+	&SQLManageAssignmentRule{},
+	&SQLManageRecordActivity{},
+	&SQLManageApplicationMapping{},
+	&SchemaMetaSnapshot{},
+	&SchemaMetaDrift{},
 	&ReportPushConfig{},
 	&ReportPushConfigRecord{},
 	&SqlVersion{},
@@ -756,8 +777,9 @@ func (s *Storage) CreateDefaultReportPushConfigIfNotExist(projectUId string) err
 		if err != nil {
 			return err
 		}
+		return nil
 	}
-	return nil
+	return s.CreateMissingReportPushConfigInProject(projectUId)
 }
 
 // func (s *Storage) CreateAdminUser() error {
@@ -46,6 +46,16 @@ type WorkflowStepTemplate struct {
 	ExecuteByAuthorized  sql.NullBool `gorm:"column:execute_by_authorized"`
 
 	Users string `gorm:"type:varchar(255)"` // `gorm:"many2many:workflow_step_template_user"` // dms-todo: 调整存储格式
+
+	// 审核步骤的生效条件，不满足条件时工单跳过该步骤
+	Condition *WorkflowStepCondition `gorm:"column:step_condition;type:json"`
+	// 审核步骤需要至少 ApprovalMinCount 个待操作人审批通过，小于等于1时任一待操作人审批通过即可
+	ApprovalMinCount uint `gorm:"column:approval_min_count"`
+	// 审核步骤由多个审批组并行审批，所有审批组均通过后步骤通过
+	ApprovalGroups WorkflowApprovalGroups `gorm:"type:json"`
+	// 审核步骤等待超过 EscalationTimeoutMinutes 分钟未完成审批时，升级至 EscalationUsers 审批，为0时不升级
+	EscalationTimeoutMinutes uint   `gorm:"column:escalation_timeout_minutes"`
+	EscalationUsers          string `gorm:"type:varchar(2000)"`
 }
 
 func DefaultWorkflowTemplate(projectId string) *WorkflowTemplate {
@@ -114,6 +124,11 @@ func (s *Storage) GetWorkflowStepsDetailByTemplateId(id uint) ([]*WorkflowStepTe
 }
 
 func (s *Storage) SaveWorkflowTemplate(template *WorkflowTemplate) error {
+	for _, step := range template.Steps {
+		if err := ValidateWorkflowStepTemplate(step); err != nil {
+			return errors.New(errors.DataInvalid, err)
+		}
+	}
 	return s.TxExec(func(tx *sql.Tx) error {
 		_, err := saveWorkflowTemplate(template, tx)
 		return err
@@ -132,8 +147,8 @@ func saveWorkflowTemplate(template *WorkflowTemplate, tx *sql.Tx) (templateId in
 	}
 	template.ID = uint(templateId)
 	for _, step := range template.Steps {
-		result, err = tx.Exec("INSERT INTO workflow_step_templates (step_number, workflow_template_id, type, users, `desc`, approved_by_authorized,execute_by_authorized) values (?,?,?,?,?,?,?)",
-			step.Number, templateId, step.Typ, step.Users, step.Desc, step.ApprovedByAuthorized, step.ExecuteByAuthorized)
+		result, err = tx.Exec("INSERT INTO workflow_step_templates (step_number, workflow_template_id, type, users, `desc`, approved_by_authorized,execute_by_authorized, step_condition, approval_min_count, approval_groups, escalation_timeout_minutes, escalation_users) values (?,?,?,?,?,?,?,?,?,?,?,?)",
+			step.Number, templateId, step.Typ, step.Users, step.Desc, step.ApprovedByAuthorized, step.ExecuteByAuthorized, step.Condition, step.ApprovalMinCount, step.ApprovalGroups, step.EscalationTimeoutMinutes, step.EscalationUsers)
 		if err != nil {
 			return 0, err
 		}
@@ -147,6 +162,11 @@ func saveWorkflowTemplate(template *WorkflowTemplate, tx *sql.Tx) (templateId in
 }
 
 func (s *Storage) UpdateWorkflowTemplateSteps(templateId uint, steps []*WorkflowStepTemplate) error {
+	for _, step := range steps {
+		if err := ValidateWorkflowStepTemplate(step); err != nil {
+			return errors.New(errors.DataInvalid, err)
+		}
+	}
 	return s.TxExec(func(tx *sql.Tx) error {
 		_, err := tx.Exec("UPDATE workflow_step_templates SET workflow_template_id = NULL WHERE workflow_template_id = ?",
 			templateId)
@@ -154,8 +174,8 @@ func (s *Storage) UpdateWorkflowTemplateSteps(templateId uint, steps []*Workflow
 			return err
 		}
 		for _, step := range steps {
-			result, err := tx.Exec("INSERT INTO workflow_step_templates (step_number, workflow_template_id, type,users, `desc`, approved_by_authorized,execute_by_authorized) values (?,?,?,?,?,?,?)",
-				step.Number, templateId, step.Typ, step.Users, step.Desc, step.ApprovedByAuthorized, step.ExecuteByAuthorized)
+			result, err := tx.Exec("INSERT INTO workflow_step_templates (step_number, workflow_template_id, type,users, `desc`, approved_by_authorized,execute_by_authorized, step_condition, approval_min_count, approval_groups, escalation_timeout_minutes, escalation_users) values (?,?,?,?,?,?,?,?,?,?,?,?)",
+				step.Number, templateId, step.Typ, step.Users, step.Desc, step.ApprovedByAuthorized, step.ExecuteByAuthorized, step.Condition, step.ApprovalMinCount, step.ApprovalGroups, step.EscalationTimeoutMinutes, step.EscalationUsers)
 			if err != nil {
 				return err
 			}
@@ -230,6 +250,10 @@ type WorkflowRecord struct {
 	// 当workflow只有部分数据源已上线时，current step仍处于"sql_execute"步骤
 	CurrentStep *WorkflowStep   `gorm:"foreignkey:CurrentWorkflowStepId"`
 	Steps       []*WorkflowStep `gorm:"foreignkey:WorkflowRecordId"`
+
+	// 定时上线被变更冻结期阻塞的原因，阻塞期间到ScheduleRetryAt前不再尝试定时上线
+	ScheduleBlockedReason string `gorm:"type:text"`
+	ScheduleRetryAt       *time.Time
 }
 
 const (
@@ -300,6 +324,8 @@ const (
 	WorkflowStepStateInit    = "initialized"
 	WorkflowStepStateApprove = "approved"
 	WorkflowStepStateReject  = "rejected"
+	// 审核步骤的生效条件不满足时，步骤被跳过
+	WorkflowStepStateSkip = "skipped"
 )
 
 type WorkflowStep struct {
@@ -314,6 +340,13 @@ type WorkflowStep struct {
 
 	Assignees string                `gorm:"type:varchar(2000)"` // `gorm:"many2many:workflow_step_user"`
 	Template  *WorkflowStepTemplate `gorm:"foreignkey:WorkflowStepTemplateId"`
+	// 需要多人审批的步骤中已审批通过的用户
+	ApprovedUsers string `gorm:"type:varchar(2000)"`
+	// 待操作人不在岗时委托的用户，格式为"委托人:被委托人"
+	Delegates string `gorm:"type:varchar(2000)"`
+	// 步骤超时升级的用户和升级时间
+	EscalatedUsers string `gorm:"type:varchar(2000)"`
+	EscalatedAt    *time.Time
 	// OperationUser string                // `gorm:"foreignkey:OperationUserId"`
 }
 
@@ -324,13 +357,17 @@ func (ws *WorkflowStep) OperationTime() string {
 	return ws.OperateAt.Format("2006-01-02 15:04:05")
 }
 
-func generateWorkflowStepByTemplate(stepsTemplate []*WorkflowStepTemplate, allInspector []*User, allExecutor []*User) []*WorkflowStep {
+func generateWorkflowStepByTemplate(stepsTemplate []*WorkflowStepTemplate, allInspector []*User, allExecutor []*User, conditionInfo *WorkflowConditionInfo) []*WorkflowStep {
 	steps := make([]*WorkflowStep, 0, len(stepsTemplate))
 	for i, st := range stepsTemplate {
 
 		step := &WorkflowStep{
 			WorkflowStepTemplateId: st.ID,
 			Assignees:              st.Users,
+			State:                  getInitStepState(st, conditionInfo),
+		}
+		if len(st.ApprovalGroups) > 0 {
+			step.Assignees = strings.Join(st.ApprovalGroups.Assignees(), ",")
 		}
 		if st.ApprovedByAuthorized.Bool {
 			step.Assignees = genIdsByUsers(allInspector)
@@ -344,13 +381,22 @@ func generateWorkflowStepByTemplate(stepsTemplate []*WorkflowStepTemplate, allIn
 	return steps
 }
 
-func (w *Workflow) cloneWorkflowStep() []*WorkflowStep {
+// getInitStepState returns the init state of the step, the review step is skipped when its condition doesn't hold.
+func getInitStepState(st *WorkflowStepTemplate, conditionInfo *WorkflowConditionInfo) string {
+	if st.Typ == WorkflowStepTypeSQLReview && !st.Condition.Match(conditionInfo) {
+		return WorkflowStepStateSkip
+	}
+	return WorkflowStepStateInit
+}
+
+func (w *Workflow) cloneWorkflowStep(conditionInfo *WorkflowConditionInfo) []*WorkflowStep {
 	steps := make([]*WorkflowStep, 0, len(w.Record.Steps))
 	for _, step := range w.Record.Steps {
 		steps = append(steps, &WorkflowStep{
 			WorkflowStepTemplateId: step.Template.ID,
 			WorkflowId:             w.WorkflowId,
 			Assignees:              step.Assignees,
+			State:                  getInitStepState(step.Template, conditionInfo),
 		})
 	}
 	return steps
@@ -377,7 +423,7 @@ func (w *Workflow) NextStep() *WorkflowStep {
 		}
 	}
 	if nextIndex <= len(w.Record.Steps)-1 {
-		return w.Record.Steps[nextIndex]
+		return firstAppliedStep(w.Record.Steps[nextIndex:])
 	}
 	return nil
 }
@@ -474,7 +520,7 @@ func (w *Workflow) GetNeedSendOATaskIds(entry *logrus.Entry) ([]uint, error) {
 	return taskIds, nil
 }
 
-func (s *Storage) CreateWorkflowV2(subject, workflowId, desc string, user *User, tasks []*Task, stepTemplates []*WorkflowStepTemplate, projectId ProjectUID, sqlVersionId, versionStageId *uint, workflowStageSequence *int, getOpExecUser func([]*Task) (canAuditUsers [][]*User, canExecUsers [][]*User)) error {
+func (s *Storage) CreateWorkflowV2(subject, workflowId, desc string, user *User, tasks []*Task, stepTemplates []*WorkflowStepTemplate, conditionInfo *WorkflowConditionInfo, projectId ProjectUID, sqlVersionId, versionStageId *uint, workflowStageSequence *int, getOpExecUser func([]*Task) (canAuditUsers [][]*User, canExecUsers [][]*User)) error {
 	if len(tasks) <= 0 {
 		return errors.New(errors.DataConflict, fmt.Errorf("there is no task for creating workflow"))
 	}
@@ -505,9 +551,6 @@ func (s *Storage) CreateWorkflowV2(subject, workflowId, desc string, user *User,
 	tx := s.db.Begin()
 
 	record := new(WorkflowRecord)
-	if len(stepTemplates) == 1 {
-		record.Status = WorkflowStatusWaitForExecution
-	}
 
 	err := tx.Save(record).Error
 	if err != nil {
@@ -573,7 +616,7 @@ func (s *Storage) CreateWorkflowV2(subject, workflowId, desc string, user *User,
 	}
 
 	{
-		steps := generateWorkflowStepByTemplate(stepTemplates, canOptUsers, canExecUsers)
+		steps := generateWorkflowStepByTemplate(stepTemplates, canOptUsers, canExecUsers, conditionInfo)
 
 		for _, step := range steps {
 			currentStep := step
@@ -586,8 +629,13 @@ func (s *Storage) CreateWorkflowV2(subject, workflowId, desc string, user *User,
 			}
 		}
 
-		if len(steps) > 0 {
-			err = tx.Model(record).Update("current_workflow_step_id", steps[0].ID).Error
+		if firstStep := firstAppliedStep(steps); firstStep != nil {
+			attrs := map[string]interface{}{"current_workflow_step_id": firstStep.ID}
+			// 所有审核步骤均被跳过时，工单直接进入上线步骤
+			if firstStep == steps[len(steps)-1] {
+				attrs["status"] = WorkflowStatusWaitForExecution
+			}
+			err = tx.Model(record).Updates(attrs).Error
 			if err != nil {
 				tx.Rollback()
 				return errors.New(errors.ConnectStorageError, err)
@@ -663,7 +711,7 @@ func UpdateInstanceRecord(stepTemplates []*WorkflowStepTemplate, tasks []*Task,
 	return instanceRecords
 }
 
-func (s *Storage) UpdateWorkflowRecord(w *Workflow, tasks []*Task) error {
+func (s *Storage) UpdateWorkflowRecord(w *Workflow, tasks []*Task, conditionInfo *WorkflowConditionInfo) error {
 	instRecords := w.Record.InstanceRecords
 	if len(instRecords) != len(tasks) {
 		return e.New("task and instRecord are not equal in length")
@@ -682,8 +730,9 @@ func (s *Storage) UpdateWorkflowRecord(w *Workflow, tasks []*Task) error {
 		InstanceRecords: instanceRecords,
 	}
 
-	steps := w.cloneWorkflowStep()
-	if len(steps) == 1 {
+	steps := w.cloneWorkflowStep(conditionInfo)
+	firstStep := firstAppliedStep(steps)
+	if firstStep != nil && firstStep == steps[len(steps)-1] {
 		record.Status = WorkflowStatusWaitForExecution
 	}
 
@@ -703,8 +752,8 @@ func (s *Storage) UpdateWorkflowRecord(w *Workflow, tasks []*Task) error {
 			return errors.New(errors.ConnectStorageError, err)
 		}
 	}
-	if len(steps) > 0 {
-		err = tx.Model(record).Update("current_workflow_step_id", steps[0].ID).Error
+	if firstStep != nil {
+		err = tx.Model(record).Update("current_workflow_step_id", firstStep.ID).Error
 		if err != nil {
 			tx.Rollback()
 			return errors.New(errors.ConnectStorageError, err)
@@ -745,6 +794,19 @@ func (s *Storage) UpdateWorkflowStep(w *Workflow, operateStep *WorkflowStep) err
 	})
 }
 
+// UpdateWorkflowStepApprovedUsers, 记录未完成审批的步骤的审批人，通过数据库的特性保证审批人不会被并发覆盖
+func (s *Storage) UpdateWorkflowStepApprovedUsers(operateStep *WorkflowStep, originApprovedUsers string) error {
+	db := s.db.Exec("UPDATE workflow_steps SET approved_users = ? WHERE id = ? AND COALESCE(approved_users, '') = ? AND operation_user_id = ?",
+		operateStep.ApprovedUsers, operateStep.ID, originApprovedUsers, "")
+	if db.Error != nil {
+		return errors.New(errors.ConnectStorageError, db.Error)
+	}
+	if db.RowsAffected == 0 {
+		return fmt.Errorf("update workflow step %d failed, it appears to have been modified by another process", operateStep.ID)
+	}
+	return nil
+}
+
 // UpdateWorkflowExecInstanceRecord， 用于更新SQL上线状态
 func (s *Storage) UpdateWorkflowExecInstanceRecord(w *Workflow, operateStep *WorkflowStep, needExecInstanceRecords []*WorkflowInstanceRecord) error {
 	return s.Tx(func(tx *gorm.DB) error {
@@ -773,8 +835,8 @@ func updateWorkflowStatus(tx *gorm.DB, w *Workflow) error {
 
 func updateWorkflowStep(tx *gorm.DB, operateStep *WorkflowStep) error {
 	// 必须保证更新前的操作用户未填写，通过数据库的特性保证数据不会重复写
-	db := tx.Exec("UPDATE workflow_steps SET operation_user_id = ?, operate_at = ?, state = ?, reason = ? WHERE id = ? AND operation_user_id = ?",
-		operateStep.OperationUserId, operateStep.OperateAt, operateStep.State, operateStep.Reason, operateStep.ID, "")
+	db := tx.Exec("UPDATE workflow_steps SET operation_user_id = ?, operate_at = ?, state = ?, reason = ?, approved_users = ? WHERE id = ? AND operation_user_id = ?",
+		operateStep.OperationUserId, operateStep.OperateAt, operateStep.State, operateStep.Reason, operateStep.ApprovedUsers, operateStep.ID, "")
 	if db.Error != nil {
 		return db.Error
 	}
@@ -1053,6 +1115,35 @@ func (s *Storage) GetWorkflowHistoryById(id uint) ([]*WorkflowRecord, error) {
 	return records, nil
 }
 
+// GetWorkflowRecordIds returns the ids of all records of the workflow, ordered from the oldest to the current one.
+func (s *Storage) GetWorkflowRecordIds(w *Workflow) ([]uint, error) {
+	ids := []uint{}
+	err := s.db.Table("workflow_record_history").Where("workflow_id = ?", w.ID).
+		Order("workflow_record_id").Pluck("workflow_record_id", &ids).Error
+	if err != nil {
+		return nil, errors.New(errors.ConnectStorageError, err)
+	}
+	return append(ids, w.WorkflowRecordId), nil
+}
+
+// GetWorkflowRecordWithTasksById returns the workflow record with its tasks and the SQLs of the tasks.
+func (s *Storage) GetWorkflowRecordWithTasksById(id uint) (*WorkflowRecord, bool, error) {
+	record := &WorkflowRecord{}
+	err := s.db.Where("id = ?", id).First(record).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, errors.New(errors.ConnectStorageError, err)
+	}
+	instanceRecords, err := s.getWorkflowInstanceRecordsByRecordId(id)
+	if err != nil {
+		return nil, false, err
+	}
+	record.InstanceRecords = instanceRecords
+	return record, true, nil
+}
+
 func (s *Storage) GetWorkflowRecordCountByTaskIds(ids []uint) (int64, error) {
 	var count int64
 	err := s.db.Model(&WorkflowInstanceRecord{}).Where("workflow_instance_records.task_id IN (?)", ids).Count(&count).Error
@@ -1140,17 +1231,29 @@ func (s *Storage) GetExpiredWorkflows(start time.Time) ([]*Workflow, error) {
 
 func (s *Storage) GetNeedScheduledWorkflows() ([]*Workflow, error) {
 	workflows := []*Workflow{}
+	now := time.Now()
 	err := s.db.Model(&Workflow{}).Select("workflows.id,workflows.workflow_id, workflows.workflow_record_id").
 		Joins("LEFT JOIN workflow_records ON workflows.workflow_record_id = workflow_records.id").
 		Joins("LEFT JOIN workflow_instance_records ON workflow_records.id = workflow_instance_records.workflow_record_id").
 		Where("workflow_records.status = 'wait_for_execution' "+
 			"AND workflow_instance_records.scheduled_at IS NOT NULL "+
 			"AND workflow_instance_records.scheduled_at <= ? "+
-			"AND workflow_instance_records.is_sql_executed = false", time.Now()).
+			"AND workflow_instance_records.is_sql_executed = false "+
+			"AND (workflow_records.schedule_retry_at IS NULL OR workflow_records.schedule_retry_at <= ?)", now, now).
 		Scan(&workflows).Error
 	return workflows, errors.New(errors.ConnectStorageError, err)
 }
 
+// UpdateWorkflowScheduleBlocked records the reason why the scheduled execution of the workflow record is blocked, the
+// workflow is not scheduled until the retry time. The blocked state is cleared if the reason is empty.
+func (s *Storage) UpdateWorkflowScheduleBlocked(recordId uint, reason string, retryAt *time.Time) error {
+	err := s.db.Model(&WorkflowRecord{}).Where("id = ?", recordId).Updates(map[string]interface{}{
+		"schedule_blocked_reason": reason,
+		"schedule_retry_at":       retryAt,
+	}).Error
+	return errors.New(errors.ConnectStorageError, err)
+}
+
 func (s *Storage) GetWorkflowBySubject(subject string) (*Workflow, bool, error) {
 	workflow := &Workflow{Subject: subject}
 	err := s.db.Where(*workflow).First(workflow).Error
@@ -1365,6 +1468,7 @@ type WorkflowTasksSummaryDetail struct {
 	TaskPassRate               float64        `json:"task_pass_rate"`
 	TaskScore                  int32          `json:"task_score"`
 	TaskStatus                 string         `json:"task_status"`
+	TaskSchema                 string         `json:"task_schema"`
 	InstanceId                 uint64         `json:"instance_id"`
 	InstanceName               string         `json:"instance_name"`
 	InstanceDeletedAt          *time.Time     `json:"instance_deleted_at"`
@@ -1382,6 +1486,7 @@ SELECT wr.status                                                     AS workflow
        tasks.pass_rate                                               AS task_pass_rate,
        tasks.score                                                   AS task_score,
        tasks.status                                                  AS task_status,
+       tasks.instance_schema                                         AS task_schema,
        tasks.instance_id                                             AS instance_id,
        wir.scheduled_at                                              AS instance_scheduled_at,
        wir.execution_user_id			                             AS execution_user_id,
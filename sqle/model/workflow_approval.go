@@ -0,0 +1,316 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	driverV2 "github.com/actiontech/sqle/sqle/driver/v2"
+)
+
+// WorkflowStepCondition is the condition of a review step, the step applies only when all of the
+// set conditions hold. The step without condition always applies.
+type WorkflowStepCondition struct {
+	// the highest audit level of the workflow tasks is not less than AuditLevel
+	AuditLevel string `json:"audit_level,omitempty"`
+	// the workflow contains any SQL of the types, such as "ddl", "dml"
+	SQLTypes []string `json:"sql_types,omitempty"`
+	// any instance of the workflow has one of the environment tags, the environment tag of instance
+	// is the business of the instance in DMS.
+	InstanceEnvironmentTags []string `json:"instance_environment_tags,omitempty"`
+	// the estimated affected rows of the workflow is more than AffectedRowsMoreThan
+	AffectedRowsMoreThan *int64 `json:"affected_rows_more_than,omitempty"`
+}
+
+func (c *WorkflowStepCondition) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return fmt.Errorf("failed to unmarshal json value: %v", value)
+	}
+	if len(bytes) == 0 {
+		return nil
+	}
+	return json.Unmarshal(bytes, c)
+}
+
+func (c *WorkflowStepCondition) Value() (driver.Value, error) {
+	if c == nil || c.IsEmpty() {
+		return nil, nil
+	}
+	v, err := json.Marshal(c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal json value: %v", v)
+	}
+	return v, err
+}
+
+func (c *WorkflowStepCondition) IsEmpty() bool {
+	return c.AuditLevel == "" && len(c.SQLTypes) == 0 && len(c.InstanceEnvironmentTags) == 0 && c.AffectedRowsMoreThan == nil
+}
+
+// WorkflowConditionInfo is the facts of the workflow which the step conditions are evaluated on.
+type WorkflowConditionInfo struct {
+	AuditLevel              string
+	SQLTypes                map[string]struct{}
+	InstanceEnvironmentTags map[string]struct{}
+	AffectedRows            int64
+	// AffectedRowsUnknown is true when the affected rows of some SQLs can't be estimated,
+	// the affected rows condition is regarded as satisfied in this case.
+	AffectedRowsUnknown bool
+}
+
+// Match reports whether the step applies to the workflow. The nil condition or nil info always matches.
+func (c *WorkflowStepCondition) Match(info *WorkflowConditionInfo) bool {
+	if c == nil || info == nil {
+		return true
+	}
+	if c.AuditLevel != "" && !driverV2.RuleLevel(info.AuditLevel).MoreOrEqual(driverV2.RuleLevel(c.AuditLevel)) {
+		return false
+	}
+	if len(c.SQLTypes) > 0 && !containsAnyKey(info.SQLTypes, c.SQLTypes) {
+		return false
+	}
+	if len(c.InstanceEnvironmentTags) > 0 && !containsAnyKey(info.InstanceEnvironmentTags, c.InstanceEnvironmentTags) {
+		return false
+	}
+	if c.AffectedRowsMoreThan != nil && !info.AffectedRowsUnknown && info.AffectedRows <= *c.AffectedRowsMoreThan {
+		return false
+	}
+	return true
+}
+
+func containsAnyKey(m map[string]struct{}, keys []string) bool {
+	for _, key := range keys {
+		if _, ok := m[strings.ToLower(key)]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// WorkflowApprovalGroup is a group of users of a review step, the group is approved when
+// MinApprovals users of the group have approved the step.
+type WorkflowApprovalGroup struct {
+	Name         string   `json:"name"`
+	Users        []string `json:"users"`
+	MinApprovals uint     `json:"min_approvals"`
+}
+
+// WorkflowApprovalGroups are approved in parallel, the step is approved when all of the groups are approved.
+type WorkflowApprovalGroups []*WorkflowApprovalGroup
+
+func (g *WorkflowApprovalGroups) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return fmt.Errorf("failed to unmarshal json value: %v", value)
+	}
+	if len(bytes) == 0 {
+		return nil
+	}
+	result := WorkflowApprovalGroups{}
+	err := json.Unmarshal(bytes, &result)
+	*g = result
+	return err
+}
+
+func (g WorkflowApprovalGroups) Value() (driver.Value, error) {
+	if len(g) == 0 {
+		return nil, nil
+	}
+	v, err := json.Marshal(g)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal json value: %v", v)
+	}
+	return v, err
+}
+
+// Assignees returns the distinct users of all groups.
+func (g WorkflowApprovalGroups) Assignees() []string {
+	users := []string{}
+	exist := map[string]struct{}{}
+	for _, group := range g {
+		for _, user := range group.Users {
+			if _, ok := exist[user]; ok {
+				continue
+			}
+			exist[user] = struct{}{}
+			users = append(users, user)
+		}
+	}
+	return users
+}
+
+// approvalGroups returns the groups of the step which must be approved. The step without approval
+// groups is regarded as one group of its assignees, which needs ApprovalMinCount approvals.
+func (ws *WorkflowStep) approvalGroups() WorkflowApprovalGroups {
+	if ws.Template != nil && len(ws.Template.ApprovalGroups) > 0 {
+		return ws.Template.ApprovalGroups
+	}
+	// the delegates and the escalated users are not counted as the assignees of the step
+	excluded := map[string]struct{}{}
+	for _, pair := range splitUserIds(ws.Delegates) {
+		if _, to, ok := strings.Cut(pair, ":"); ok {
+			excluded[to] = struct{}{}
+		}
+	}
+	for _, user := range splitUserIds(ws.EscalatedUsers) {
+		excluded[user] = struct{}{}
+	}
+	group := &WorkflowApprovalGroup{
+		Users:        []string{},
+		MinApprovals: 1,
+	}
+	for _, user := range splitUserIds(ws.Assignees) {
+		if _, ok := excluded[user]; !ok {
+			group.Users = append(group.Users, user)
+		}
+	}
+	if ws.Template != nil && ws.Template.ApprovalMinCount > 1 {
+		group.MinApprovals = ws.Template.ApprovalMinCount
+	}
+	return WorkflowApprovalGroups{group}
+}
+
+func splitUserIds(ids string) []string {
+	users := []string{}
+	for _, id := range strings.Split(ids, ",") {
+		if id != "" {
+			users = append(users, id)
+		}
+	}
+	return users
+}
+
+func (ws *WorkflowStep) ApprovedUserIds() []string {
+	return splitUserIds(ws.ApprovedUsers)
+}
+
+func (ws *WorkflowStep) HasApproved(userId string) bool {
+	for _, id := range ws.ApprovedUserIds() {
+		if id == userId {
+			return true
+		}
+	}
+	return false
+}
+
+// AddApproval records the approval of the user, and reports whether the step is completely approved.
+func (ws *WorkflowStep) AddApproval(userId string) (completed bool) {
+	if !ws.HasApproved(userId) {
+		ws.ApprovedUsers = strings.Join(append(ws.ApprovedUserIds(), userId), ",")
+	}
+	return ws.IsApprovalCompleted()
+}
+
+// IsApprovalCompleted reports whether every approval group of the step has got enough approvals.
+// The required approvals are capped by the number of users in group, so that the step will not be
+// blocked forever when there are not enough users. The approval of a delegate counts for the user
+// who delegates, and the approval of any escalated user completes the step.
+func (ws *WorkflowStep) IsApprovalCompleted() bool {
+	escalatedUsers := map[string]struct{}{}
+	if ws.EscalatedAt != nil {
+		for _, user := range splitUserIds(ws.EscalatedUsers) {
+			escalatedUsers[user] = struct{}{}
+		}
+	}
+	approved := map[string]struct{}{}
+	for _, id := range ws.ApprovedUserIds() {
+		if _, ok := escalatedUsers[id]; ok {
+			return true
+		}
+		approved[id] = struct{}{}
+		for _, delegator := range ws.delegatorsOf(id) {
+			approved[delegator] = struct{}{}
+		}
+	}
+	for _, group := range ws.approvalGroups() {
+		required := int(group.MinApprovals)
+		if required < 1 {
+			required = 1
+		}
+		if required > len(group.Users) {
+			required = len(group.Users)
+		}
+		count := 0
+		for _, user := range group.Users {
+			if _, ok := approved[user]; ok {
+				count++
+			}
+		}
+		if count < required {
+			return false
+		}
+	}
+	return true
+}
+
+// isSkipped reports whether the step doesn't apply to the workflow for its condition.
+func (ws *WorkflowStep) isSkipped() bool {
+	return ws.State == WorkflowStepStateSkip
+}
+
+// firstAppliedStep returns the first step which applies to the workflow.
+func firstAppliedStep(steps []*WorkflowStep) *WorkflowStep {
+	for _, step := range steps {
+		if !step.isSkipped() {
+			return step
+		}
+	}
+	return nil
+}
+
+// ValidateWorkflowStepTemplate checks the condition and approval settings of the step template.
+func ValidateWorkflowStepTemplate(st *WorkflowStepTemplate) error {
+	if st.Typ != WorkflowStepTypeSQLReview {
+		if (st.Condition != nil && !st.Condition.IsEmpty()) || st.ApprovalMinCount > 1 || len(st.ApprovalGroups) > 0 ||
+			st.EscalationTimeoutMinutes > 0 {
+			return fmt.Errorf("condition, multiple approvals and escalation are only supported by %v step", WorkflowStepTypeSQLReview)
+		}
+		return nil
+	}
+	if st.EscalationTimeoutMinutes > 0 && len(splitUserIds(st.EscalationUsers)) == 0 {
+		return fmt.Errorf("escalation users are required when escalation timeout is set")
+	}
+	if c := st.Condition; c != nil {
+		switch driverV2.RuleLevel(c.AuditLevel) {
+		case driverV2.RuleLevelNull, driverV2.RuleLevelNormal, driverV2.RuleLevelNotice, driverV2.RuleLevelWarn, driverV2.RuleLevelError:
+		default:
+			return fmt.Errorf("invalid audit level %v in step condition", c.AuditLevel)
+		}
+		for _, sqlType := range c.SQLTypes {
+			switch strings.ToLower(sqlType) {
+			case driverV2.SQLTypeDDL, driverV2.SQLTypeDML, driverV2.SQLTypeDQL:
+			default:
+				return fmt.Errorf("invalid sql type %v in step condition", sqlType)
+			}
+		}
+		if c.AffectedRowsMoreThan != nil && *c.AffectedRowsMoreThan < 0 {
+			return fmt.Errorf("affected rows in step condition must not be negative")
+		}
+	}
+	if len(st.ApprovalGroups) > 0 {
+		if st.ApprovedByAuthorized.Bool || st.ApprovalMinCount > 1 {
+			return fmt.Errorf("approval groups can't be used with approved by authorized or approval min count")
+		}
+		for _, group := range st.ApprovalGroups {
+			if len(group.Users) == 0 {
+				return fmt.Errorf("approval group %v has no user", group.Name)
+			}
+			if group.MinApprovals < 1 || int(group.MinApprovals) > len(group.Users) {
+				return fmt.Errorf("min approvals of approval group %v should be between 1 and the number of its users", group.Name)
+			}
+		}
+		return nil
+	}
+	if !st.ApprovedByAuthorized.Bool && int(st.ApprovalMinCount) > len(splitUserIds(st.Users)) {
+		return fmt.Errorf("approval min count is more than the number of assignees")
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkflowStepCondition_Match(t *testing.T) {
+	rows := int64(1000)
+	condition := &WorkflowStepCondition{
+		AuditLevel:              "warn",
+		SQLTypes:                []string{"DDL"},
+		InstanceEnvironmentTags: []string{"prod"},
+		AffectedRowsMoreThan:    &rows,
+	}
+	info := &WorkflowConditionInfo{
+		AuditLevel:              "error",
+		SQLTypes:                map[string]struct{}{"ddl": {}, "dml": {}},
+		InstanceEnvironmentTags: map[string]struct{}{"prod": {}},
+		AffectedRows:            1001,
+	}
+	assert.True(t, condition.Match(info))
+
+	info.AuditLevel = "notice"
+	assert.False(t, condition.Match(info))
+	info.AuditLevel = "warn"
+
+	info.AffectedRows = 1000
+	assert.False(t, condition.Match(info))
+	info.AffectedRowsUnknown = true
+	assert.True(t, condition.Match(info))
+
+	info.InstanceEnvironmentTags = map[string]struct{}{"test": {}}
+	assert.False(t, condition.Match(info))
+
+	var nilCondition *WorkflowStepCondition
+	assert.True(t, nilCondition.Match(info))
+
+	data, err := condition.Value()
+	assert.NoError(t, err)
+	scanned := &WorkflowStepCondition{}
+	assert.NoError(t, scanned.Scan(data))
+	assert.Equal(t, condition, scanned)
+}
+
+func TestWorkflowStep_AddApproval(t *testing.T) {
+	// any one of assignees
+	step := &WorkflowStep{Assignees: "1,2,3", Template: &WorkflowStepTemplate{}}
+	assert.True(t, step.AddApproval("2"))
+
+	// 2 of 3 assignees
+	step = &WorkflowStep{Assignees: "1,2,3", Template: &WorkflowStepTemplate{ApprovalMinCount: 2}}
+	assert.False(t, step.AddApproval("1"))
+	assert.False(t, step.AddApproval("1"))
+	assert.True(t, step.AddApproval("3"))
+	assert.Equal(t, []string{"1", "3"}, step.ApprovedUserIds())
+
+	// parallel groups
+	step = &WorkflowStep{Assignees: "1,2,3,4", Template: &WorkflowStepTemplate{
+		ApprovalGroups: WorkflowApprovalGroups{
+			{Name: "dba", Users: []string{"1", "2"}, MinApprovals: 1},
+			{Name: "dev", Users: []string{"3", "4"}, MinApprovals: 2},
+		},
+	}}
+	assert.False(t, step.AddApproval("3"))
+	assert.False(t, step.AddApproval("1"))
+	assert.True(t, step.AddApproval("4"))
+}
+
+func TestWorkflowStep_DelegateAndEscalate(t *testing.T) {
+	// the approval of the delegate counts for the user who delegates
+	step := &WorkflowStep{Assignees: "1,2", Template: &WorkflowStepTemplate{ApprovalMinCount: 2}}
+	assert.True(t, step.AddDelegate("1", "3"))
+	assert.False(t, step.AddDelegate("2", "3"))
+	assert.Equal(t, "1,2,3", step.Assignees)
+	assert.False(t, step.AddApproval("3"))
+	assert.True(t, step.AddApproval("2"))
+
+	// the approval of any escalated user completes the step
+	step = &WorkflowStep{Assignees: "1,2", Template: &WorkflowStepTemplate{ApprovalMinCount: 2}}
+	step.Escalate([]string{"2", "4"}, time.Now())
+	assert.Equal(t, "1,2,4", step.Assignees)
+	assert.False(t, step.AddApproval("1"))
+	assert.True(t, step.AddApproval("4"))
+}
+
+func TestFirstAppliedStep(t *testing.T) {
+	steps := []*WorkflowStep{
+		{Model: Model{ID: 1}, State: WorkflowStepStateSkip},
+		{Model: Model{ID: 2}, State: WorkflowStepStateInit},
+		{Model: Model{ID: 3}, State: WorkflowStepStateInit},
+	}
+	assert.Equal(t, uint(2), firstAppliedStep(steps).ID)
+	assert.Nil(t, firstAppliedStep(steps[:1]))
+}
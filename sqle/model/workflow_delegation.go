@@ -0,0 +1,194 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/actiontech/sqle/sqle/errors"
+
+	"gorm.io/gorm"
+)
+
+// WorkflowApprovalDelegate 用户在时间范围内不在岗时，将项目中工单的审批委托给其他用户
+type WorkflowApprovalDelegate struct {
+	Model
+	ProjectId      ProjectUID `gorm:"index; not null; type:varchar(255)"`
+	UserId         string     `gorm:"index; not null; type:varchar(255)"`
+	DelegateUserId string     `gorm:"not null; type:varchar(255)"`
+	StartTime      time.Time  `gorm:"not null"`
+	EndTime        time.Time  `gorm:"not null"`
+	Reason         string     `gorm:"type:varchar(255)"`
+}
+
+func (d *WorkflowApprovalDelegate) IsActive(now time.Time) bool {
+	return !now.Before(d.StartTime) && now.Before(d.EndTime)
+}
+
+// CreateWorkflowApprovalDelegate creates the delegate, the delegate periods of the same user must not overlap.
+func (s *Storage) CreateWorkflowApprovalDelegate(delegate *WorkflowApprovalDelegate) error {
+	var count int64
+	err := s.db.Model(&WorkflowApprovalDelegate{}).
+		Where("project_id = ? AND user_id = ? AND start_time < ? AND end_time > ?",
+			delegate.ProjectId, delegate.UserId, delegate.EndTime, delegate.StartTime).
+		Count(&count).Error
+	if err != nil {
+		return errors.New(errors.ConnectStorageError, err)
+	}
+	if count > 0 {
+		return errors.New(errors.DataConflict, fmt.Errorf("the delegate period overlaps with an existing delegate"))
+	}
+	return errors.New(errors.ConnectStorageError, s.db.Create(delegate).Error)
+}
+
+func (s *Storage) GetWorkflowApprovalDelegateById(projectId ProjectUID, id uint) (*WorkflowApprovalDelegate, bool, error) {
+	delegate := &WorkflowApprovalDelegate{}
+	err := s.db.Where("project_id = ? AND id = ?", projectId, id).First(delegate).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, false, nil
+	}
+	return delegate, true, errors.New(errors.ConnectStorageError, err)
+}
+
+// GetWorkflowApprovalDelegates returns the delegates in project, all users' delegates are returned if userId is empty.
+func (s *Storage) GetWorkflowApprovalDelegates(projectId ProjectUID, userId string) ([]*WorkflowApprovalDelegate, error) {
+	delegates := []*WorkflowApprovalDelegate{}
+	query := s.db.Where("project_id = ?", projectId)
+	if userId != "" {
+		query = query.Where("user_id = ?", userId)
+	}
+	err := query.Order("start_time DESC").Find(&delegates).Error
+	return delegates, errors.New(errors.ConnectStorageError, err)
+}
+
+// GetActiveWorkflowApprovalDelegates returns the delegate user of the users who are away at the time, keyed by user id.
+func (s *Storage) GetActiveWorkflowApprovalDelegates(projectId ProjectUID, userIds []string, now time.Time) (map[string]string, error) {
+	delegates := []*WorkflowApprovalDelegate{}
+	err := s.db.Where("project_id = ? AND user_id IN (?) AND start_time <= ? AND end_time > ?", projectId, userIds, now, now).
+		Find(&delegates).Error
+	if err != nil {
+		return nil, errors.New(errors.ConnectStorageError, err)
+	}
+	res := make(map[string]string, len(delegates))
+	for _, delegate := range delegates {
+		res[delegate.UserId] = delegate.DelegateUserId
+	}
+	return res, nil
+}
+
+func (s *Storage) DeleteWorkflowApprovalDelegate(delegate *WorkflowApprovalDelegate) error {
+	return errors.New(errors.ConnectStorageError, s.db.Delete(delegate).Error)
+}
+
+const (
+	WorkflowStepEventTypeDelegate = "delegate"
+	WorkflowStepEventTypeEscalate = "escalate"
+)
+
+// WorkflowStepEvent 记录审批步骤的委托和超时升级，在工单历史中展示
+type WorkflowStepEvent struct {
+	Model
+	WorkflowId       string `gorm:"index; not null; type:varchar(255)"`
+	WorkflowRecordId uint   `gorm:"index; not null"`
+	WorkflowStepId   uint   `gorm:"index; not null"`
+	Type             string `gorm:"not null; type:varchar(255)"`
+	FromUsers        string `gorm:"type:varchar(2000)"`
+	ToUsers          string `gorm:"type:varchar(2000)"`
+}
+
+func (s *Storage) GetWorkflowStepEventsByRecordIds(recordIds []uint) ([]*WorkflowStepEvent, error) {
+	events := []*WorkflowStepEvent{}
+	err := s.db.Where("workflow_record_id IN (?)", recordIds).Order("id").Find(&events).Error
+	return events, errors.New(errors.ConnectStorageError, err)
+}
+
+// UpdateWorkflowStepAssignees saves the assignees of the step changed by delegation or escalation, and records the events.
+// The step must not be operated or changed by another process.
+func (s *Storage) UpdateWorkflowStepAssignees(step *WorkflowStep, originAssignees string, events []*WorkflowStepEvent) error {
+	return s.Tx(func(tx *gorm.DB) error {
+		db := tx.Exec("UPDATE workflow_steps SET assignees = ?, delegates = ?, escalated_users = ?, escalated_at = ? WHERE id = ? AND assignees = ? AND operation_user_id = ?",
+			step.Assignees, step.Delegates, step.EscalatedUsers, step.EscalatedAt, step.ID, originAssignees, "")
+		if db.Error != nil {
+			return db.Error
+		}
+		if db.RowsAffected == 0 {
+			return fmt.Errorf("update workflow step %d failed, it appears to have been modified by another process", step.ID)
+		}
+		for _, event := range events {
+			if err := tx.Create(event).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetWorkflowsWaitForAudit returns the workflows waiting for review, only the id fields are filled.
+func (s *Storage) GetWorkflowsWaitForAudit() ([]*Workflow, error) {
+	workflows := []*Workflow{}
+	err := s.db.Model(&Workflow{}).Select("workflows.id, workflows.workflow_id, workflows.project_id, workflows.workflow_record_id").
+		Joins("LEFT JOIN workflow_records ON workflows.workflow_record_id = workflow_records.id").
+		Where("workflow_records.status = ?", WorkflowStatusWaitForAudit).
+		Scan(&workflows).Error
+	return workflows, errors.New(errors.ConnectStorageError, err)
+}
+
+// delegatorsOf returns the users who the user approves on behalf of.
+func (ws *WorkflowStep) delegatorsOf(userId string) []string {
+	users := []string{}
+	for _, pair := range splitUserIds(ws.Delegates) {
+		from, to, ok := strings.Cut(pair, ":")
+		if ok && to == userId {
+			users = append(users, from)
+		}
+	}
+	return users
+}
+
+// AddDelegate adds the delegate user to the assignees of the step, it returns false if the delegate user is already an assignee.
+func (ws *WorkflowStep) AddDelegate(userId, delegateUserId string) bool {
+	assignees := splitUserIds(ws.Assignees)
+	for _, assignee := range assignees {
+		if assignee == delegateUserId {
+			return false
+		}
+	}
+	ws.Assignees = strings.Join(append(assignees, delegateUserId), ",")
+	ws.Delegates = strings.Join(append(splitUserIds(ws.Delegates), userId+":"+delegateUserId), ",")
+	return true
+}
+
+// Escalate adds the fallback users to the assignees of the step, any of the users newly added can approve
+// the step alone since then, the fallback users who are already assignees keep their original approval rules.
+func (ws *WorkflowStep) Escalate(users []string, now time.Time) {
+	assignees := splitUserIds(ws.Assignees)
+	exist := map[string]struct{}{}
+	for _, assignee := range assignees {
+		exist[assignee] = struct{}{}
+	}
+	added := []string{}
+	for _, user := range users {
+		if _, ok := exist[user]; ok {
+			continue
+		}
+		exist[user] = struct{}{}
+		added = append(added, user)
+	}
+	ws.Assignees = strings.Join(append(assignees, added...), ",")
+	ws.EscalatedUsers = strings.Join(added, ",")
+	ws.EscalatedAt = &now
+}
+
+// CurrentStepStartTime returns the time when the current step starts to wait for operation.
+func (w *Workflow) CurrentStepStartTime() time.Time {
+	start := w.Record.CreatedAt
+	for _, step := range w.Record.Steps {
+		if step.ID == w.Record.CurrentWorkflowStepId {
+			break
+		}
+		if step.OperateAt != nil && step.OperateAt.After(start) {
+			start = *step.OperateAt
+		}
+	}
+	return start
+}
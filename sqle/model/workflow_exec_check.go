@@ -0,0 +1,96 @@
+package model
+
+import (
+	"time"
+
+	"github.com/actiontech/sqle/sqle/errors"
+
+	"gorm.io/gorm"
+)
+
+const (
+	WorkflowExecCheckStagePre  = "pre"
+	WorkflowExecCheckStagePost = "post"
+
+	WorkflowExecCheckTypeSQLAssertion    = "sql_assertion"
+	WorkflowExecCheckTypeWebhook         = "webhook"
+	WorkflowExecCheckTypeLongTransaction = "long_transaction"
+)
+
+const (
+	TaskExecCheckStatusPassed          = "passed"
+	TaskExecCheckStatusPreCheckFailed  = "pre_check_failed"
+	TaskExecCheckStatusPostCheckFailed = "post_check_failed"
+)
+
+// WorkflowExecCheck 工单上线前后的检查，上线前检查失败时不执行任务，上线后检查失败时标记任务并可以回滚任务
+type WorkflowExecCheck struct {
+	Model
+	WorkflowId string `json:"workflow_id" gorm:"index;not null;type:varchar(255)"`
+	Stage      string `json:"stage" gorm:"type:varchar(255);not null"`
+	Type       string `json:"type" gorm:"type:varchar(255);not null"`
+	Name       string `json:"name" gorm:"type:varchar(255)"`
+	// 检查的数据源，为空时检查工单的所有任务
+	InstanceName string `json:"instance_name" gorm:"type:varchar(255)"`
+	// SQL断言：在任务的数据源上查询SQL，第一行第一列的值与期望值比较
+	SQL      string `json:"sql" gorm:"type:text"`
+	Operator string `json:"operator" gorm:"type:varchar(255)"`
+	Expected string `json:"expected" gorm:"type:varchar(255)"`
+	// webhook：POST请求URL，返回2xx时检查通过
+	URL string `json:"url" gorm:"type:varchar(1024)"`
+	// 长事务：不存在持续时间达到该秒数的事务时检查通过，只支持MySQL
+	MaxTransactionSeconds uint `json:"max_transaction_seconds"`
+	// 上线后检查失败时回滚任务
+	RollbackOnFailure bool `json:"rollback_on_failure"`
+}
+
+// WorkflowExecCheckResult 任务的检查结果，记录检查的名称和类型以免检查修改后无法查看
+type WorkflowExecCheckResult struct {
+	Model
+	WorkflowExecCheckId uint      `json:"workflow_exec_check_id" gorm:"index;not null"`
+	TaskId              uint      `json:"task_id" gorm:"index;not null"`
+	Stage               string    `json:"stage" gorm:"type:varchar(255)"`
+	Type                string    `json:"type" gorm:"type:varchar(255)"`
+	Name                string    `json:"name" gorm:"type:varchar(255)"`
+	Passed              bool      `json:"passed"`
+	Message             string    `json:"message" gorm:"type:text"`
+	CheckedAt           time.Time `json:"checked_at"`
+}
+
+func (s *Storage) GetWorkflowExecChecks(workflowId string) ([]*WorkflowExecCheck, error) {
+	checks := []*WorkflowExecCheck{}
+	err := s.db.Where("workflow_id = ?", workflowId).Order("id ASC").Find(&checks).Error
+	return checks, errors.New(errors.ConnectStorageError, err)
+}
+
+func (s *Storage) GetWorkflowExecChecksByStage(workflowId, stage string) ([]*WorkflowExecCheck, error) {
+	checks := []*WorkflowExecCheck{}
+	err := s.db.Where("workflow_id = ? AND stage = ?", workflowId, stage).Order("id ASC").Find(&checks).Error
+	return checks, errors.New(errors.ConnectStorageError, err)
+}
+
+// ReplaceWorkflowExecChecks replaces all the checks of the workflow.
+func (s *Storage) ReplaceWorkflowExecChecks(workflowId string, checks []*WorkflowExecCheck) error {
+	return s.Tx(func(tx *gorm.DB) error {
+		if err := tx.Where("workflow_id = ?", workflowId).Delete(&WorkflowExecCheck{}).Error; err != nil {
+			return err
+		}
+		for _, check := range checks {
+			check.WorkflowId = workflowId
+			if err := tx.Create(check).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Storage) CreateWorkflowExecCheckResult(result *WorkflowExecCheckResult) error {
+	return errors.New(errors.ConnectStorageError, s.db.Create(result).Error)
+}
+
+func (s *Storage) GetWorkflowExecCheckResultsByTaskIds(taskIds []uint) ([]*WorkflowExecCheckResult, error) {
+	results := []*WorkflowExecCheckResult{}
+	err := s.db.Where("task_id IN (?)", taskIds).Order("id ASC").Find(&results).Error
+	return results, errors.New(errors.ConnectStorageError, err)
+}
@@ -0,0 +1,92 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+
+	"github.com/actiontech/sqle/sqle/errors"
+
+	"gorm.io/gorm"
+)
+
+// WorkflowExecPlan 工单多个任务的上线编排，任务在依赖的任务上线成功后才上线，同时上线的任务数不超过 MaxConcurrency
+type WorkflowExecPlan struct {
+	Model
+	WorkflowId string `json:"workflow_id" gorm:"type:varchar(255);not null;unique"`
+	// 同时上线的任务数，为0时不限制
+	MaxConcurrency uint `json:"max_concurrency"`
+	// 有任务上线失败后不再开始上线其他任务，否则只有依赖失败任务的任务不上线
+	StopOnFailure    bool                     `json:"stop_on_failure"`
+	TaskDependencies WorkflowTaskDependencies `json:"task_dependencies" gorm:"type:json"`
+}
+
+type WorkflowTaskDependency struct {
+	TaskId    uint   `json:"task_id"`
+	DependsOn []uint `json:"depends_on"`
+}
+
+type WorkflowTaskDependencies []WorkflowTaskDependency
+
+// Scan impl sql.Scanner interface
+func (d *WorkflowTaskDependencies) Scan(value interface{}) error {
+	if value == nil {
+		return nil
+	}
+	bytes, ok := value.([]byte)
+	if !ok {
+		return fmt.Errorf("failed to unmarshal json value: %v", value)
+	}
+	if len(bytes) == 0 {
+		return nil
+	}
+	result := WorkflowTaskDependencies{}
+	err := json.Unmarshal(bytes, &result)
+	*d = result
+	return err
+}
+
+// Value impl sql.driver.Valuer interface
+func (d WorkflowTaskDependencies) Value() (driver.Value, error) {
+	v, err := json.Marshal(d)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal json value: %v", v)
+	}
+	return v, err
+}
+
+// DependsOn returns the tasks which the task depends on.
+func (p *WorkflowExecPlan) DependsOn(taskId uint) []uint {
+	for _, dependency := range p.TaskDependencies {
+		if dependency.TaskId == taskId {
+			return dependency.DependsOn
+		}
+	}
+	return nil
+}
+
+func (s *Storage) GetWorkflowExecPlan(workflowId string) (*WorkflowExecPlan, bool, error) {
+	plan := &WorkflowExecPlan{}
+	err := s.db.Where("workflow_id = ?", workflowId).First(plan).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, false, nil
+	}
+	return plan, true, errors.New(errors.ConnectStorageError, err)
+}
+
+// SaveWorkflowExecPlan creates or replaces the execution plan of the workflow.
+func (s *Storage) SaveWorkflowExecPlan(plan *WorkflowExecPlan) error {
+	origin, exist, err := s.GetWorkflowExecPlan(plan.WorkflowId)
+	if err != nil {
+		return err
+	}
+	if exist {
+		plan.ID = origin.ID
+		plan.CreatedAt = origin.CreatedAt
+	}
+	return errors.New(errors.ConnectStorageError, s.db.Save(plan).Error)
+}
+
+func (s *Storage) DeleteWorkflowExecPlan(workflowId string) error {
+	return errors.New(errors.ConnectStorageError, s.db.Unscoped().Where("workflow_id = ?", workflowId).Delete(&WorkflowExecPlan{}).Error)
+}
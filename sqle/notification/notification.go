@@ -50,6 +50,8 @@ const (
 	WorkflowNotifyTypeReject
 	WorkflowNotifyTypeExecuteSuccess
 	WorkflowNotifyTypeExecuteFail
+	WorkflowNotifyTypeDelegate
+	WorkflowNotifyTypeEscalate
 )
 
 func getWorkflowNotifyTypeAction(wt WorkflowNotifyType) string {
@@ -64,6 +66,10 @@ func getWorkflowNotifyTypeAction(wt WorkflowNotifyType) string {
 		return "exec_success"
 	case WorkflowNotifyTypeExecuteFail:
 		return "exec_failed"
+	case WorkflowNotifyTypeDelegate:
+		return "delegate"
+	case WorkflowNotifyTypeEscalate:
+		return "escalate"
 	}
 	return "unknown"
 }
@@ -101,6 +107,10 @@ func (w *WorkflowNotification) NotificationSubject() i18nPkg.I18nStr {
 		return locale.Bundle.LocalizeAll(locale.NotifyWorkflowNotifyTypeExecuteSuccess)
 	case WorkflowNotifyTypeExecuteFail:
 		return locale.Bundle.LocalizeAll(locale.NotifyWorkflowNotifyTypeExecuteFail)
+	case WorkflowNotifyTypeDelegate:
+		return locale.Bundle.LocalizeAll(locale.NotifyWorkflowNotifyTypeDelegate)
+	case WorkflowNotifyTypeEscalate:
+		return locale.Bundle.LocalizeAll(locale.NotifyWorkflowNotifyTypeEscalate)
 	default:
 		return locale.Bundle.LocalizeAll(locale.NotifyWorkflowNotifyTypeDefault)
 	}
@@ -193,7 +203,7 @@ func (w *WorkflowNotification) buildNotifyBody(task *model.Task) i18nPkg.I18nStr
 
 func (w *WorkflowNotification) notifyUser() []string {
 	switch w.notifyType {
-	case WorkflowNotifyTypeApprove, WorkflowNotifyTypeCreate:
+	case WorkflowNotifyTypeApprove, WorkflowNotifyTypeCreate, WorkflowNotifyTypeDelegate, WorkflowNotifyTypeEscalate:
 		return w.workflow.CurrentAssigneeUser()
 
 	// if workflow is rejected, the creator needs to be notified.
@@ -325,6 +335,133 @@ func (a *AuditPlanNotification) NotificationBody() i18nPkg.I18nStr {
 	return body
 }
 
+type SQLRegressionNotification struct {
+	instanceName  string
+	auditPlanType string
+	sqlId         string
+	fingerprint   string
+	regression    *model.SQLManageRegression
+}
+
+func NewSQLRegressionNotification(instanceName, auditPlanType, sqlId, fingerprint string, regression *model.SQLManageRegression) *SQLRegressionNotification {
+	return &SQLRegressionNotification{
+		instanceName:  instanceName,
+		auditPlanType: auditPlanType,
+		sqlId:         sqlId,
+		fingerprint:   fingerprint,
+		regression:    regression,
+	}
+}
+
+func (n *SQLRegressionNotification) NotificationSubject() i18nPkg.I18nStr {
+	return locale.Bundle.LocalizeAllWithArgs(locale.NotifySQLRegressionSubject, n.auditPlanType)
+}
+
+func (n *SQLRegressionNotification) NotificationBody() i18nPkg.I18nStr {
+	return locale.Bundle.LocalizeAllWithArgs(locale.NotifySQLRegressionBody,
+		n.instanceName,
+		n.auditPlanType,
+		n.sqlId,
+		n.fingerprint,
+		n.regression.MetricName,
+		fmt.Sprintf("%.4f", n.regression.BaselineValue),
+		fmt.Sprintf("%.4f", n.regression.CurrentValue),
+		fmt.Sprintf("%.2f", n.regression.Ratio),
+	)
+}
+
+type AuditPlanDisabledNotification struct {
+	instanceName  string
+	auditPlanType string
+	failures      uint
+	lastError     string
+}
+
+func NewAuditPlanDisabledNotification(instanceName, auditPlanType string, failures uint, lastError string) *AuditPlanDisabledNotification {
+	return &AuditPlanDisabledNotification{
+		instanceName:  instanceName,
+		auditPlanType: auditPlanType,
+		failures:      failures,
+		lastError:     lastError,
+	}
+}
+
+func (n *AuditPlanDisabledNotification) NotificationSubject() i18nPkg.I18nStr {
+	return locale.Bundle.LocalizeAllWithArgs(locale.NotifyAuditPlanDisabledSubject, n.auditPlanType, n.failures)
+}
+
+func (n *AuditPlanDisabledNotification) NotificationBody() i18nPkg.I18nStr {
+	return locale.Bundle.LocalizeAllWithArgs(locale.NotifyAuditPlanDisabledBody,
+		n.instanceName,
+		n.auditPlanType,
+		n.failures,
+		n.lastError,
+	)
+}
+
+type SQLManageSLANotification struct {
+	priority   string
+	slaMinutes uint
+	records    []*model.SQLManageRecord
+}
+
+func NewSQLManageSLANotification(priority string, slaMinutes uint, records []*model.SQLManageRecord) *SQLManageSLANotification {
+	return &SQLManageSLANotification{
+		priority:   priority,
+		slaMinutes: slaMinutes,
+		records:    records,
+	}
+}
+
+func (n *SQLManageSLANotification) NotificationSubject() i18nPkg.I18nStr {
+	return locale.Bundle.LocalizeAllWithArgs(locale.NotifySQLManageSLASubject, n.priority, n.slaMinutes)
+}
+
+func (n *SQLManageSLANotification) NotificationBody() i18nPkg.I18nStr {
+	bodies := make([]i18nPkg.I18nStr, 0, len(n.records))
+	for _, record := range n.records {
+		bodies = append(bodies, locale.Bundle.LocalizeAllWithArgs(locale.NotifySQLManageSLABodyRecord,
+			record.SQLID,
+			record.SqlText,
+			record.CreatedAt.Format(time.RFC3339),
+			record.SQLManager.Assignees,
+		))
+	}
+	return locale.Bundle.JoinI18nStr(bodies, "")
+}
+
+type SchemaMetaDriftNotification struct {
+	instanceName string
+	drifts       []*model.SchemaMetaDrift
+}
+
+func NewSchemaMetaDriftNotification(instanceName string, drifts []*model.SchemaMetaDrift) *SchemaMetaDriftNotification {
+	return &SchemaMetaDriftNotification{
+		instanceName: instanceName,
+		drifts:       drifts,
+	}
+}
+
+func (n *SchemaMetaDriftNotification) NotificationSubject() i18nPkg.I18nStr {
+	return locale.Bundle.LocalizeAllWithArgs(locale.NotifySchemaMetaDriftSubject, n.instanceName)
+}
+
+func (n *SchemaMetaDriftNotification) NotificationBody() i18nPkg.I18nStr {
+	bodies := make([]i18nPkg.I18nStr, 0, len(n.drifts))
+	for _, drift := range n.drifts {
+		bodies = append(bodies, locale.Bundle.LocalizeAllWithArgs(locale.NotifySchemaMetaDriftBodyRecord,
+			drift.SchemaName,
+			drift.MetaName,
+			drift.MetaType,
+			drift.ChangeType,
+			drift.FromVersion,
+			drift.ToVersion,
+			drift.DetectedAt.Format(time.RFC3339),
+		))
+	}
+	return locale.Bundle.JoinI18nStr(bodies, "")
+}
+
 type TestNotify struct {
 }
 
@@ -46,7 +46,8 @@ func HookAudit(l *logrus.Entry, task *model.Task, hook AuditHook, projectId *mod
 	if task.Instance == nil {
 		task.Instance = &model.Instance{ProjectId: string(*projectId)}
 	}
-	return hookAudit(l, task, plugin, hook, string(*projectId), customRules)
+	opener := newAuditPluginOpener(l, task.Instance, task.Schema, task.DBType, rules)
+	return hookAudit(l, task, plugin, opener, hook, string(*projectId), customRules)
 }
 
 const AuditSchema = "AuditSchema"
@@ -69,7 +70,8 @@ func DirectAuditByInstance(l *logrus.Entry, sql, schemaName string, instance *mo
 	}
 	task.Instance = instance
 
-	return task, audit(instance.ProjectId, l, task, plugin, customRules)
+	opener := newAuditPluginOpener(l, instance, schemaName, instance.DbType, rules)
+	return task, hookAudit(l, task, plugin, opener, &EmptyAuditHook{}, instance.ProjectId, customRules)
 }
 
 func AuditSQLByDBType(l *logrus.Entry, sql string, dbType string, projectId string, ruleTemplateName string) (*model.Task, error) {
@@ -133,7 +135,7 @@ func convertSQLsToTask(sql string, p driver.Plugin) (*model.Task, error) {
 }
 
 func audit(projectId string, l *logrus.Entry, task *model.Task, p driver.Plugin, customRules []*model.CustomRule) (err error) {
-	return hookAudit(l, task, p, &EmptyAuditHook{}, projectId, customRules)
+	return hookAudit(l, task, p, nil, &EmptyAuditHook{}, projectId, customRules)
 }
 
 type AuditHook interface {
@@ -147,7 +149,9 @@ func (e *EmptyAuditHook) BeforeAudit(sql *model.ExecuteSQL) {}
 
 func (e *EmptyAuditHook) AfterAudit(sql *model.ExecuteSQL) {}
 
-func hookAudit(l *logrus.Entry, task *model.Task, p driver.Plugin, hook AuditHook, projectId string, customRules []*model.CustomRule) (err error) {
+// hookAudit audits the SQLs of task by plugin p. If opener is not nil, large task is split to chunks
+// and audited concurrently by the plugin instances opened by opener.
+func hookAudit(l *logrus.Entry, task *model.Task, p driver.Plugin, opener auditPluginOpener, hook AuditHook, projectId string, customRules []*model.CustomRule) (err error) {
 	defer func() {
 		if errRecover := recover(); errRecover != nil {
 			debug.PrintStack()
@@ -220,7 +224,7 @@ func hookAudit(l *logrus.Entry, task *model.Task, p driver.Plugin, hook AuditHoo
 			hook.BeforeAudit(sql)
 		}
 
-		results, err := p.Audit(context.TODO(), sqls)
+		results, err := auditSQLs(l, task.Instance, p, opener, sqls, nodes)
 		if err != nil {
 			return err
 		}
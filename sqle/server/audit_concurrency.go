@@ -0,0 +1,247 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/actiontech/sqle/sqle/config"
+	"github.com/actiontech/sqle/sqle/driver"
+	driverV2 "github.com/actiontech/sqle/sqle/driver/v2"
+	"github.com/actiontech/sqle/sqle/model"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultAuditChunkSize                 = 1000
+	defaultAuditMaxPluginInstances        = 4
+	defaultAuditMaxConcurrencyPerInstance = 8
+)
+
+func getAuditConcurrencyConfig() (chunkSize, maxPluginInstances, maxConcurrencyPerInstance int) {
+	opts := config.GetOptions().SqleOptions.Service.AuditConcurrency
+	chunkSize, maxPluginInstances, maxConcurrencyPerInstance = opts.ChunkSize, opts.MaxPluginInstances, opts.MaxConcurrencyPerInstance
+	if chunkSize <= 0 {
+		chunkSize = defaultAuditChunkSize
+	}
+	if maxPluginInstances <= 0 {
+		maxPluginInstances = defaultAuditMaxPluginInstances
+	}
+	if maxConcurrencyPerInstance <= 0 {
+		maxConcurrencyPerInstance = defaultAuditMaxConcurrencyPerInstance
+	}
+	return
+}
+
+// auditPluginOpener opens a new plugin which has the same config as the plugin used to audit the task.
+type auditPluginOpener func() (driver.Plugin, error)
+
+func newAuditPluginOpener(l *logrus.Entry, inst *model.Instance, database string, dbType string, modelRules []*model.Rule) auditPluginOpener {
+	return func() (driver.Plugin, error) {
+		return newDriverManagerWithAudit(l, inst, database, dbType, modelRules)
+	}
+}
+
+// auditLimiter limits the concurrent audit calls against the same database instance,
+// the limit is shared by all tasks.
+type auditLimiter struct {
+	sync.Mutex
+	slots map[string]*auditSlots
+}
+
+var instanceAuditLimiter = &auditLimiter{slots: map[string]*auditSlots{}}
+
+// acquire blocks until there is a free slot for the instance, the returned function releases the slot.
+// Audit without instance (offline audit) does not touch the database, so it is not limited.
+func (l *auditLimiter) acquire(inst *model.Instance, limit int) (release func()) {
+	if inst == nil || inst.ID == 0 {
+		return func() {}
+	}
+	key := fmt.Sprintf("%d", inst.ID)
+
+	l.Lock()
+	slots, ok := l.slots[key]
+	if !ok {
+		slots = newAuditSlots()
+		l.slots[key] = slots
+	}
+	l.Unlock()
+
+	slots.acquire(limit)
+	return slots.release
+}
+
+// auditSlots is the audit slots of one instance. The limit can be changed while the slots are held,
+// the held slots are counted against the new limit.
+type auditSlots struct {
+	mu    sync.Mutex
+	cond  *sync.Cond
+	used  int
+	limit int
+}
+
+func newAuditSlots() *auditSlots {
+	s := &auditSlots{}
+	s.cond = sync.NewCond(&s.mu)
+	return s
+}
+
+func (s *auditSlots) acquire(limit int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.limit != limit {
+		s.limit = limit
+		// the waiters may get a slot under the raised limit
+		s.cond.Broadcast()
+	}
+	for s.used >= s.limit {
+		s.cond.Wait()
+	}
+	s.used++
+}
+
+func (s *auditSlots) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.used--
+	s.cond.Signal()
+}
+
+type auditChunk struct {
+	start int
+	end   int
+	// contextBefore is the number of schema context sensitive SQLs (DDL, USE, SET...) before the chunk,
+	// contextAfter is the number including the context sensitive SQLs in the chunk.
+	contextBefore int
+	contextAfter  int
+}
+
+// isContextSensitiveNode reports whether the SQL may change the context (schema, table structure,
+// session variables) of the following SQLs.
+func isContextSensitiveNode(node driverV2.Node) bool {
+	return node.Type != driverV2.SQLTypeDML && node.Type != driverV2.SQLTypeDQL
+}
+
+// splitAuditChunks splits the SQLs to chunks which can be audited concurrently, and returns the
+// context sensitive SQLs in order. The context sensitive SQLs before a chunk are audited ahead of the
+// chunk in the same plugin instance, so that the chunk is audited with the same context as the serial audit.
+//
+// It returns one chunk when the SQLs should be audited serially, e.g. there are too many context
+// sensitive SQLs which have to be replayed for the chunks.
+func splitAuditChunks(sqls []string, nodes []driverV2.Node, chunkSize int) ([]auditChunk, []string) {
+	if chunkSize <= 0 || len(sqls) <= chunkSize {
+		return []auditChunk{{start: 0, end: len(sqls)}}, nil
+	}
+
+	contextSQLs := []string{}
+	chunks := []auditChunk{}
+	for start := 0; start < len(sqls); start += chunkSize {
+		end := start + chunkSize
+		if end > len(sqls) {
+			end = len(sqls)
+		}
+		chunk := auditChunk{start: start, end: end, contextBefore: len(contextSQLs)}
+		for i := start; i < end; i++ {
+			if isContextSensitiveNode(nodes[i]) {
+				contextSQLs = append(contextSQLs, sqls[i])
+			}
+		}
+		chunk.contextAfter = len(contextSQLs)
+		chunks = append(chunks, chunk)
+	}
+	if len(contextSQLs) > chunkSize {
+		return []auditChunk{{start: 0, end: len(sqls)}}, nil
+	}
+	return chunks, contextSQLs
+}
+
+// auditSQLs audits SQLs by plugin. Large SQL list is split to chunks and audited concurrently
+// across a bounded pool of plugin instances opened by opener, the results keep the order of SQLs.
+func auditSQLs(l *logrus.Entry, inst *model.Instance, p driver.Plugin, opener auditPluginOpener, sqls []string, nodes []driverV2.Node) ([]*driverV2.AuditResults, error) {
+	chunkSize, maxPluginInstances, maxConcurrencyPerInstance := getAuditConcurrencyConfig()
+
+	chunks := []auditChunk{{start: 0, end: len(sqls)}}
+	var contextSQLs []string
+	if opener != nil {
+		chunks, contextSQLs = splitAuditChunks(sqls, nodes, chunkSize)
+	}
+	if len(chunks) == 1 {
+		release := instanceAuditLimiter.acquire(inst, maxConcurrencyPerInstance)
+		defer release()
+		return p.Audit(context.TODO(), sqls)
+	}
+
+	workerNum := maxPluginInstances
+	if workerNum > len(chunks) {
+		workerNum = len(chunks)
+	}
+	l.Infof("audit %d SQLs in %d chunks with %d plugin instances", len(sqls), len(chunks), workerNum)
+
+	results := make([]*driverV2.AuditResults, len(sqls))
+	chunkCh := make(chan auditChunk, len(chunks))
+	for _, chunk := range chunks {
+		chunkCh <- chunk
+	}
+	close(chunkCh)
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	var wg sync.WaitGroup
+	var errOnce sync.Once
+	var auditErr error
+	setErr := func(err error) {
+		errOnce.Do(func() {
+			auditErr = err
+			cancel()
+		})
+	}
+	for i := 0; i < workerNum; i++ {
+		wg.Add(1)
+		go func(workerId int) {
+			defer wg.Done()
+			// the first worker reuses the plugin of the task, others open new plugin instances.
+			plugin := p
+			if workerId > 0 {
+				var err error
+				plugin, err = opener()
+				if err != nil {
+					setErr(fmt.Errorf("open plugin for chunk audit failed: %v", err))
+					return
+				}
+				defer plugin.Close(context.TODO())
+			}
+			// applied is the number of context sensitive SQLs the plugin instance has audited,
+			// chunks are received in order, so only the missing context SQLs need to be replayed.
+			applied := 0
+			for chunk := range chunkCh {
+				if ctx.Err() != nil {
+					return
+				}
+				replaySQLs := contextSQLs[applied:chunk.contextBefore]
+				chunkSQLs := append(append([]string{}, replaySQLs...), sqls[chunk.start:chunk.end]...)
+
+				release := instanceAuditLimiter.acquire(inst, maxConcurrencyPerInstance)
+				chunkResults, err := plugin.Audit(ctx, chunkSQLs)
+				release()
+				if err != nil {
+					setErr(err)
+					return
+				}
+				if len(chunkResults) != len(chunkSQLs) {
+					setErr(fmt.Errorf("audit results [%d] does not match the number of SQL [%d]", len(chunkResults), len(chunkSQLs)))
+					return
+				}
+				// the results of replayed SQLs are discarded, they belong to the previous chunks.
+				copy(results[chunk.start:chunk.end], chunkResults[len(replaySQLs):])
+				applied = chunk.contextAfter
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if auditErr != nil {
+		return nil, auditErr
+	}
+	return results, nil
+}
@@ -0,0 +1,161 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/actiontech/sqle/sqle/driver"
+	driverV2 "github.com/actiontech/sqle/sqle/driver/v2"
+	"github.com/actiontech/sqle/sqle/log"
+	"github.com/actiontech/sqle/sqle/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitAuditChunks(t *testing.T) {
+	sqls := []string{"use db1", "insert 1", "insert 2", "create table t1", "insert 3", "insert 4", "insert 5"}
+	nodes := []driverV2.Node{
+		{Type: driverV2.SQLTypeDDL}, {Type: driverV2.SQLTypeDML}, {Type: driverV2.SQLTypeDML},
+		{Type: driverV2.SQLTypeDDL}, {Type: driverV2.SQLTypeDML}, {Type: driverV2.SQLTypeDML}, {Type: driverV2.SQLTypeDML},
+	}
+
+	chunks, contextSQLs := splitAuditChunks(sqls, nodes, 10)
+	assert.Equal(t, []auditChunk{{start: 0, end: 7}}, chunks)
+	assert.Nil(t, contextSQLs)
+
+	chunks, contextSQLs = splitAuditChunks(sqls, nodes, 3)
+	assert.Equal(t, []auditChunk{
+		{start: 0, end: 3, contextBefore: 0, contextAfter: 1},
+		{start: 3, end: 6, contextBefore: 1, contextAfter: 2},
+		{start: 6, end: 7, contextBefore: 2, contextAfter: 2},
+	}, chunks)
+	assert.Equal(t, []string{"use db1", "create table t1"}, contextSQLs)
+
+	// too many context sensitive SQLs, audit serially
+	chunks, _ = splitAuditChunks(sqls, nodes, 1)
+	assert.Equal(t, []auditChunk{{start: 0, end: 7}}, chunks)
+}
+
+type recordAuditDriver struct {
+	mockDriver
+	sync.Mutex
+	audited [][]string
+}
+
+func (d *recordAuditDriver) Audit(ctx context.Context, sqls []string) ([]*driverV2.AuditResults, error) {
+	d.Lock()
+	d.audited = append(d.audited, sqls)
+	d.Unlock()
+	results := make([]*driverV2.AuditResults, len(sqls))
+	for i, sql := range sqls {
+		results[i] = &driverV2.AuditResults{Results: []*driverV2.AuditResult{{RuleName: sql}}}
+	}
+	return results, nil
+}
+
+func TestAuditSQLs(t *testing.T) {
+	sqls := []string{}
+	nodes := []driverV2.Node{}
+	for i := 0; i < 3500; i++ {
+		typ := driverV2.SQLTypeDML
+		if i%1000 == 0 {
+			typ = driverV2.SQLTypeDDL
+		}
+		sqls = append(sqls, fmt.Sprintf("sql %d", i))
+		nodes = append(nodes, driverV2.Node{Type: typ})
+	}
+
+	p := &recordAuditDriver{}
+	opened := []*recordAuditDriver{p}
+	var mu sync.Mutex
+	opener := func() (driver.Plugin, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		np := &recordAuditDriver{}
+		opened = append(opened, np)
+		return np, nil
+	}
+
+	results, err := auditSQLs(log.NewEntry(), &model.Instance{}, p, opener, sqls, nodes)
+	assert.NoError(t, err)
+	assert.Len(t, results, len(sqls))
+	for i := range sqls {
+		assert.Equal(t, sqls[i], results[i].Results[0].RuleName)
+	}
+
+	var auditedNum int
+	for _, d := range opened {
+		for _, audited := range d.audited {
+			auditedNum += len(audited)
+		}
+	}
+	// every SQL is audited once, the context sensitive SQLs may be replayed for the other chunks.
+	assert.GreaterOrEqual(t, auditedNum, len(sqls))
+	assert.LessOrEqual(t, auditedNum, len(sqls)+3*len(opened))
+}
+
+func TestAuditLimiterChangeLimit(t *testing.T) {
+	l := &auditLimiter{slots: map[string]*auditSlots{}}
+	inst := &model.Instance{ID: 1}
+	acquire := func(limit int) <-chan func() {
+		ch := make(chan func(), 1)
+		go func() { ch <- l.acquire(inst, limit) }()
+		return ch
+	}
+	wait := func(ch <-chan func()) func() {
+		select {
+		case release := <-ch:
+			return release
+		case <-time.After(time.Second):
+			t.Fatal("the slot is not acquired")
+			return nil
+		}
+	}
+	assertBlocked := func(ch <-chan func()) {
+		select {
+		case <-ch:
+			t.Fatal("the slot is acquired over the limit")
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	used := func() int {
+		slots := l.slots["1"]
+		slots.mu.Lock()
+		defer slots.mu.Unlock()
+		return slots.used
+	}
+
+	release1 := wait(acquire(2))
+	release2 := wait(acquire(2))
+
+	// the held slots are counted against the raised limit
+	release3 := wait(acquire(3))
+	blocked1 := acquire(3)
+	assertBlocked(blocked1)
+
+	// the lowered limit blocks until the held slots are released below it
+	blocked2 := acquire(1)
+	assertBlocked(blocked2)
+	release1()
+	release2()
+	assertBlocked(blocked1)
+	assertBlocked(blocked2)
+	release3()
+
+	var release func()
+	select {
+	case release = <-blocked1:
+		blocked1 = blocked2
+	case release = <-blocked2:
+	case <-time.After(time.Second):
+		t.Fatal("the slot is not acquired")
+	}
+	assertBlocked(blocked1)
+	assert.Equal(t, 1, used())
+	release()
+	wait(blocked1)()
+	assert.Equal(t, 0, used())
+}
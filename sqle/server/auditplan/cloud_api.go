@@ -0,0 +1,131 @@
+package auditplan
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// the cloud APIs without the SDK vendored are requested by the http client, the requests are signed as the
+// documents of the clouds.
+
+const cloudAPITimeout = time.Minute
+
+var cloudAPIClient = &http.Client{Timeout: cloudAPITimeout}
+
+// parseCloudAPIEndpoint parses the endpoint of the cloud API, the https is used if the scheme is omitted. The endpoint
+// can be replaced by a local http service for testing.
+func parseCloudAPIEndpoint(endpoint string) (*url.URL, error) {
+	endpoint = strings.TrimSpace(endpoint)
+	if endpoint == "" {
+		return nil, fmt.Errorf("endpoint is empty")
+	}
+	if !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("parse endpoint %s failed, error: %v", endpoint, err)
+	}
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	return u, nil
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// signTencentCloudTC3 signs the request by TC3-HMAC-SHA256 of the API 3.0 of tencent cloud, the request must be a POST
+// with the JSON body.
+func signTencentCloudTC3(req *http.Request, body []byte, service, secretId, secretKey string, now time.Time) {
+	timestamp := now.Unix()
+	date := now.UTC().Format("2006-01-02")
+	contentType := req.Header.Get("Content-Type")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		fmt.Sprintf("content-type:%s\nhost:%s\n", contentType, req.URL.Host),
+		"content-type;host",
+		sha256Hex(body),
+	}, "\n")
+	credentialScope := fmt.Sprintf("%s/%s/tc3_request", date, service)
+	stringToSign := strings.Join([]string{
+		"TC3-HMAC-SHA256",
+		fmt.Sprintf("%d", timestamp),
+		credentialScope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	secretDate := hmacSHA256([]byte("TC3"+secretKey), date)
+	secretService := hmacSHA256(secretDate, service)
+	secretSigning := hmacSHA256(secretService, "tc3_request")
+	signature := hex.EncodeToString(hmacSHA256(secretSigning, stringToSign))
+
+	req.Header.Set("X-TC-Timestamp", fmt.Sprintf("%d", timestamp))
+	req.Header.Set("Authorization", fmt.Sprintf("TC3-HMAC-SHA256 Credential=%s/%s, SignedHeaders=content-type;host, Signature=%s",
+		secretId, credentialScope, signature))
+}
+
+// signAWSV4 signs the request by the signature version 4, the request must be a POST with the form body.
+// https://docs.aws.amazon.com/IAM/latest/UserGuide/create-signed-request.html
+func signAWSV4(req *http.Request, body []byte, service, region, accessKeyId, secretAccessKey string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := now.UTC().Format("20060102")
+	contentType := req.Header.Get("Content-Type")
+	req.Header.Set("X-Amz-Date", amzDate)
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		fmt.Sprintf("content-type:%s\nhost:%s\nx-amz-date:%s\n", contentType, req.URL.Host, amzDate),
+		"content-type;host;x-amz-date",
+		sha256Hex(body),
+	}, "\n")
+	credentialScope := fmt.Sprintf("%s/%s/%s/aws4_request", date, region, service)
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		credentialScope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	kDate := hmacSHA256([]byte("AWS4"+secretAccessKey), date)
+	kRegion := hmacSHA256(kDate, region)
+	kService := hmacSHA256(kRegion, service)
+	kSigning := hmacSHA256(kService, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(kSigning, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=content-type;host;x-amz-date, Signature=%s",
+		accessKeyId, credentialScope, signature))
+}
+
+// downloadCloudFile downloads the file by the url returned by the cloud API, e.g. the download url of the slow log.
+func downloadCloudFile(fileURL string) (io.ReadCloser, error) {
+	resp, err := cloudAPIClient.Get(fileURL)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("download file failed, status: %s", resp.Status)
+	}
+	return resp.Body, nil
+}
@@ -0,0 +1,172 @@
+package auditplan
+
+import (
+	e "errors"
+	"fmt"
+	"time"
+
+	"github.com/actiontech/sqle/sqle/config"
+	"github.com/actiontech/sqle/sqle/log"
+	"github.com/actiontech/sqle/sqle/model"
+	"github.com/actiontech/sqle/sqle/notification"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	QueueFullPolicyDefer = "defer"
+	QueueFullPolicyDrop  = "drop"
+)
+
+const (
+	defaultMaxSQLManageQueueDepth        = 100000
+	defaultMaxConsecutiveFailures        = 10
+	defaultCollectionRecordRetentionDays = 30
+)
+
+// ErrSQLManageQueueFull means the collected SQLs can't be pushed to the queue, the collection isn't counted as a failure.
+var ErrSQLManageQueueFull = e.New("sql manage queue is full")
+
+func getAuditPlanCollectionConfig() config.AuditPlanCollection {
+	opts := config.GetOptions().SqleOptions.Service.AuditPlanCollection
+	if opts.MaxQueueDepth <= 0 {
+		opts.MaxQueueDepth = defaultMaxSQLManageQueueDepth
+	}
+	if opts.QueueFullPolicy != QueueFullPolicyDrop {
+		opts.QueueFullPolicy = QueueFullPolicyDefer
+	}
+	if opts.MaxConsecutiveFailures == 0 {
+		opts.MaxConsecutiveFailures = defaultMaxConsecutiveFailures
+	}
+	if opts.RecordRetentionDays <= 0 {
+		opts.RecordRetentionDays = defaultCollectionRecordRetentionDays
+	}
+	return opts
+}
+
+// GetSQLManageQueueStatus returns the depth of the SQL manage queue, and the config of the queue.
+func GetSQLManageQueueStatus(persist *model.Storage) (depth, maxDepth int64, policy string, err error) {
+	opts := getAuditPlanCollectionConfig()
+	depth, err = persist.CountSQLManageQueue()
+	return depth, opts.MaxQueueDepth, opts.QueueFullPolicy, err
+}
+
+// checkSQLManageQueueDepth returns the depth of the queue, the error is ErrSQLManageQueueFull if the queue is full.
+func checkSQLManageQueueDepth(persist *model.Storage) (int64, error) {
+	depth, maxDepth, _, err := GetSQLManageQueueStatus(persist)
+	if err != nil {
+		return 0, err
+	}
+	if depth >= maxDepth {
+		return depth, fmt.Errorf("%w, depth: %d, max depth: %d", ErrSQLManageQueueFull, depth, maxDepth)
+	}
+	return depth, nil
+}
+
+// checkSQLManageQueueBeforePush returns ErrSQLManageQueueFull if the queue is full under the drop policy, the SQLs
+// are pushed to the full queue under the defer policy.
+func checkSQLManageQueueBeforePush(l *logrus.Entry, persist *model.Storage, count int) error {
+	_, err := checkSQLManageQueueDepth(persist)
+	if e.Is(err, ErrSQLManageQueueFull) && getAuditPlanCollectionConfig().QueueFullPolicy == QueueFullPolicyDefer {
+		l.Warnf("push %d collected sqls to the full queue, %v", count, err)
+		return nil
+	}
+	return err
+}
+
+// CollectionRun is one collection of the audit plan, it's recorded when it is finished.
+type CollectionRun struct {
+	ap     *AuditPlan
+	record *model.AuditPlanCollectionRecord
+}
+
+func NewCollectionRun(ap *AuditPlan) *CollectionRun {
+	return &CollectionRun{
+		ap: ap,
+		record: &model.AuditPlanCollectionRecord{
+			AuditPlanID: ap.ID,
+			StartAt:     time.Now(),
+		},
+	}
+}
+
+func (r *CollectionRun) SetQueueDepth(depth int64) {
+	r.record.QueueDepth = depth
+}
+
+func (r *CollectionRun) SetSQLCount(count int) {
+	r.record.SQLCount = count
+}
+
+// Defer marks the collection is skipped because the queue is full.
+func (r *CollectionRun) Defer() {
+	r.record.Status = model.CollectionRecordStatusDeferred
+}
+
+// Finish records the collection. The audit plan is disabled if the consecutive failures reach the max, the collection
+// is not counted as a failure if the SQLs are dropped because the queue is full.
+func (r *CollectionRun) Finish(l *logrus.Entry, err error) {
+	r.record.EndAt = time.Now()
+	r.record.DurationMs = r.record.EndAt.Sub(r.record.StartAt).Milliseconds()
+	switch {
+	case e.Is(err, ErrSQLManageQueueFull):
+		l.Warnf("drop %d collected sqls, %v", r.record.SQLCount, err)
+		r.record.Status = model.CollectionRecordStatusDropped
+		r.record.DroppedSQLCount = r.record.SQLCount
+		r.record.ErrorMessage = err.Error()
+		err = nil
+	case err != nil:
+		r.record.Status = model.CollectionRecordStatusFailed
+		r.record.ErrorMessage = err.Error()
+	case r.record.Status == "":
+		r.record.Status = model.CollectionRecordStatusSuccess
+	}
+
+	s := model.GetStorage()
+	opts := getAuditPlanCollectionConfig()
+	if createErr := s.Create(r.record); createErr != nil {
+		l.Errorf("create audit plan collection record failed, error: %v", createErr)
+	}
+	if deleteErr := s.DeleteAuditPlanCollectionRecordsBefore(r.ap.ID, r.record.StartAt.AddDate(0, 0, -opts.RecordRetentionDays)); deleteErr != nil {
+		l.Errorf("delete expired audit plan collection records failed, error: %v", deleteErr)
+	}
+	// 推迟的采集没有执行，不影响采集状态
+	if r.record.Status == model.CollectionRecordStatusDeferred {
+		return
+	}
+
+	ProcessAuditPlanStatusAndLogError(l, r.ap.ID, r.ap.InstanceID, r.ap.Type, err)
+	if err == nil {
+		if resetErr := s.ResetAuditPlanCollectionFailures(r.ap.ID); resetErr != nil {
+			l.Errorf("reset audit plan collection failures failed, error: %v", resetErr)
+		}
+		return
+	}
+	failures, increaseErr := s.IncreaseAuditPlanCollectionFailures(r.ap.ID)
+	if increaseErr != nil {
+		l.Errorf("increase audit plan collection failures failed, error: %v", increaseErr)
+		return
+	}
+	if failures < opts.MaxConsecutiveFailures {
+		return
+	}
+	if disableErr := s.DisableAuditPlanByID(r.ap.ID); disableErr != nil {
+		l.Errorf("disable audit plan after %d consecutive collection failures failed, error: %v", failures, disableErr)
+		return
+	}
+	l.Warnf("audit plan is disabled after %d consecutive collection failures", failures)
+	notifyAuditPlanDisabled(r.ap, failures, err)
+}
+
+func notifyAuditPlanDisabled(ap *AuditPlan, failures uint, lastErr error) {
+	if ap.CreateUserID == "" {
+		return
+	}
+	instanceName := ap.InstanceID
+	if ap.Instance != nil {
+		instanceName = ap.Instance.Name
+	}
+	n := notification.NewAuditPlanDisabledNotification(instanceName, ap.Type, failures, lastErr.Error())
+	if err := notification.Notify(n, []string{ap.CreateUserID}); err != nil {
+		log.Logger().Errorf("notify disabled audit plan %v failed: %v", ap.ID, err)
+	}
+}
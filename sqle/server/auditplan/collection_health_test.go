@@ -0,0 +1,88 @@
+package auditplan
+
+import (
+	e "errors"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/actiontech/sqle/sqle/config"
+	"github.com/actiontech/sqle/sqle/log"
+	"github.com/actiontech/sqle/sqle/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func setAuditPlanCollectionConfig(t *testing.T, opts config.AuditPlanCollection) {
+	origin := config.GetOptions().SqleOptions.Service.AuditPlanCollection
+	config.GetOptions().SqleOptions.Service.AuditPlanCollection = opts
+	t.Cleanup(func() {
+		config.GetOptions().SqleOptions.Service.AuditPlanCollection = origin
+	})
+}
+
+func TestGetAuditPlanCollectionConfig(t *testing.T) {
+	setAuditPlanCollectionConfig(t, config.AuditPlanCollection{QueueFullPolicy: "unknown"})
+	opts := getAuditPlanCollectionConfig()
+	assert.Equal(t, int64(defaultMaxSQLManageQueueDepth), opts.MaxQueueDepth)
+	assert.Equal(t, QueueFullPolicyDefer, opts.QueueFullPolicy)
+	assert.Equal(t, uint(defaultMaxConsecutiveFailures), opts.MaxConsecutiveFailures)
+	assert.Equal(t, defaultCollectionRecordRetentionDays, opts.RecordRetentionDays)
+
+	setAuditPlanCollectionConfig(t, config.AuditPlanCollection{
+		MaxQueueDepth:          10,
+		QueueFullPolicy:        QueueFullPolicyDrop,
+		MaxConsecutiveFailures: 3,
+		RecordRetentionDays:    7,
+	})
+	opts = getAuditPlanCollectionConfig()
+	assert.Equal(t, int64(10), opts.MaxQueueDepth)
+	assert.Equal(t, QueueFullPolicyDrop, opts.QueueFullPolicy)
+	assert.Equal(t, uint(3), opts.MaxConsecutiveFailures)
+	assert.Equal(t, 7, opts.RecordRetentionDays)
+}
+
+func TestCheckSQLManageQueueDepth(t *testing.T) {
+	setAuditPlanCollectionConfig(t, config.AuditPlanCollection{MaxQueueDepth: 10})
+
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+	mock.ExpectQuery("SELECT VERSION()").WillReturnRows(sqlmock.NewRows([]string{"VERSION()"}).AddRow("5.7"))
+	model.InitMockStorage(mockDB)
+
+	countSQL := "SELECT count\\(\\*\\) FROM `sql_manage_queues`"
+	mock.ExpectQuery(countSQL).WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(9))
+	depth, err := checkSQLManageQueueDepth(model.GetStorage())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(9), depth)
+
+	mock.ExpectQuery(countSQL).WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(10))
+	depth, err = checkSQLManageQueueDepth(model.GetStorage())
+	assert.True(t, e.Is(err, ErrSQLManageQueueFull))
+	assert.Equal(t, int64(10), depth)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCheckSQLManageQueueBeforePush(t *testing.T) {
+	mockDB, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer mockDB.Close()
+	mock.ExpectQuery("SELECT VERSION()").WillReturnRows(sqlmock.NewRows([]string{"VERSION()"}).AddRow("5.7"))
+	model.InitMockStorage(mockDB)
+	countSQL := "SELECT count\\(\\*\\) FROM `sql_manage_queues`"
+
+	// the collected sqls are kept under the defer policy
+	setAuditPlanCollectionConfig(t, config.AuditPlanCollection{MaxQueueDepth: 10, QueueFullPolicy: QueueFullPolicyDefer})
+	mock.ExpectQuery(countSQL).WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(10))
+	assert.NoError(t, checkSQLManageQueueBeforePush(log.NewEntry(), model.GetStorage(), 1))
+
+	setAuditPlanCollectionConfig(t, config.AuditPlanCollection{MaxQueueDepth: 10, QueueFullPolicy: QueueFullPolicyDrop})
+	mock.ExpectQuery(countSQL).WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(10))
+	err = checkSQLManageQueueBeforePush(log.NewEntry(), model.GetStorage(), 1)
+	assert.True(t, e.Is(err, ErrSQLManageQueueFull))
+
+	mock.ExpectQuery(countSQL).WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(9))
+	assert.NoError(t, checkSQLManageQueueBeforePush(log.NewEntry(), model.GetStorage(), 1))
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
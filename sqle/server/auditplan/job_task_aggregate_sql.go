@@ -30,6 +30,11 @@ func (j *AuditPlanAggregateSQLJob) AggregateSQL(entry *logrus.Entry) {
 	if len(queues) == 0 {
 		return
 	}
+	// the closed SQLs are compared with the persisted metrics before they are merged
+	reopens, err := sqlManageRecordsToReopen(s, queues)
+	if err != nil {
+		entry.Warnf("check sqls to reopen failed, error: %v", err)
+	}
 	cache := NewSQLV2CacheWithPersist(s)
 	for _, sql := range queues {
 		sqlV2 := ConvertMangerSQLQueueToSQLV2(sql)
@@ -50,6 +55,7 @@ func (j *AuditPlanAggregateSQLJob) AggregateSQL(entry *logrus.Entry) {
 			// todo: 有错误咋处理
 			continue
 		}
+		mergeSQLApplication(cache, sqlV2)
 
 	}
 
@@ -78,6 +84,11 @@ func (j *AuditPlanAggregateSQLJob) AggregateSQL(entry *logrus.Entry) {
 			}
 		}
 
+		if err := reopenSQLManageRecords(txDB, reopens); err != nil {
+			entry.Warnf("reopen manager sql failed, error: %v", err)
+			return err
+		}
+
 		for _, sql := range queues {
 			err := s.RemoveSQLFromQueue(txDB, sql)
 			if err != nil {
@@ -127,6 +127,7 @@ func SetSQLPriority(sqlList []*model.SQLManageRecord) ([]*model.SQLManageRecord,
 	s := model.GetStorage()
 	// SQL聚合
 	auditPlanMap := make(map[string]*model.AuditPlanV2, 0)
+	priorityRulesMap := make(map[string][]*model.SQLManagePriorityRule, 0)
 
 	for i, sql_ := range sqlList {
 		sourceId := sql_.SourceId
@@ -143,13 +144,21 @@ func SetSQLPriority(sqlList []*model.SQLManageRecord) ([]*model.SQLManageRecord,
 			}
 			auditPlanMap[sourceId] = auditPlan
 		}
-		priority, _, err := GetSingleSQLPriorityWithReasons(context.TODO(), auditPlan, sql_)
+		rules, ok := priorityRulesMap[sql_.ProjectId]
+		if !ok {
+			rules, err = s.GetSQLManagePriorityRulesByProjectId(sql_.ProjectId)
+			if err != nil {
+				return nil, err
+			}
+			priorityRulesMap[sql_.ProjectId] = rules
+		}
+		priority, _, err := getSQLPriorityWithReasons(context.TODO(), auditPlan, rules, sql_)
 		if err != nil {
 			return nil, err
 		}
-		if priority == model.PriorityHigh {
+		if priority != "" {
 			sqlList[i].Priority = sql.NullString{
-				String: model.PriorityHigh,
+				String: priority,
 				Valid:  true,
 			}
 		}
@@ -157,15 +166,45 @@ func SetSQLPriority(sqlList []*model.SQLManageRecord) ([]*model.SQLManageRecord,
 	return sqlList, nil
 }
 
-// 获取SQL的优先级以及优先级触发的原因，只有高优先级或者无优先级，若是高优先级，则返回model.PriorityHigh=high,如果无优先级则返回空字符串
+// 获取SQL的优先级以及优先级触发的原因。项目的优先级规则和扫描任务的高优先级条件都会参与判断：
+// 匹配到优先级规则时返回第一个匹配的P0~P3优先级，原因包含匹配的规则和高优先级条件；
+// 否则若满足高优先级条件，则返回model.PriorityHigh=high,如果无优先级则返回空字符串
 func GetSingleSQLPriorityWithReasons(ctx context.Context, auditPlan *model.AuditPlanV2, sql *model.SQLManageRecord) (priority string, reasons []string, err error) {
 	if auditPlan == nil || sql == nil {
 		return "", reasons, nil
 	}
-	info, err := sql.Info.OriginValue()
+	rules, err := model.GetStorage().GetSQLManagePriorityRulesByProjectId(sql.ProjectId)
+	if err != nil {
+		return "", nil, err
+	}
+	return getSQLPriorityWithReasons(ctx, auditPlan, rules, sql)
+}
+
+func getSQLPriorityWithReasons(ctx context.Context, auditPlan *model.AuditPlanV2, rules []*model.SQLManagePriorityRule, sql *model.SQLManageRecord) (priority string, reasons []string, err error) {
+	if auditPlan == nil || sql == nil {
+		return "", reasons, nil
+	}
+	priority, reasons, err = getSQLPriorityByRules(rules, sql)
+	if err != nil {
+		return "", nil, err
+	}
+	highPriorityReasons, err := getSQLHighPriorityReasons(ctx, auditPlan, sql)
 	if err != nil {
 		return "", nil, err
 	}
+	reasons = append(reasons, highPriorityReasons...)
+	if priority == "" && len(highPriorityReasons) > 0 {
+		priority = model.PriorityHigh
+	}
+	return priority, reasons, nil
+}
+
+// getSQLHighPriorityReasons returns the high priority params of the audit plan matched by the SQL.
+func getSQLHighPriorityReasons(ctx context.Context, auditPlan *model.AuditPlanV2, sql *model.SQLManageRecord) (reasons []string, err error) {
+	info, err := sql.Info.OriginValue()
+	if err != nil {
+		return nil, err
+	}
 	toAuditLevel := func(valueToBeCompared string) string {
 		switch valueToBeCompared {
 		case "0":
@@ -198,6 +237,16 @@ func GetSingleSQLPriorityWithReasons(ctx context.Context, auditPlan *model.Audit
 			default:
 				valueToBeCompared = "0"
 			}
+		} else if highPriorityCondition.Key == OperationParamRegressionFactor {
+			// 劣化倍数取该SQL未恢复的劣化中最大的倍数
+			ratio, exist, err := getSQLRegressionRatio(model.GetStorage(), sql.SQLID)
+			if err != nil {
+				return nil, err
+			}
+			if !exist {
+				continue
+			}
+			valueToBeCompared = formatRegressionRatio(ratio)
 		} else {
 			// 获取信息中的相应字段值
 			infoV, ok := info[highPriorityCondition.Key]
@@ -222,8 +271,5 @@ func GetSingleSQLPriorityWithReasons(ctx context.Context, auditPlan *model.Audit
 			)
 		}
 	}
-	if len(reasons) > 0 {
-		return model.PriorityHigh, reasons, nil
-	}
-	return "", reasons, nil
+	return reasons, nil
 }
@@ -30,18 +30,23 @@ type MetaBuilder struct {
 }
 
 const (
-	TypeDefault               = "default"
-	TypeMySQLSlowLog          = scannerCmd.TypeMySQLSlowLog
-	TypeMySQLMybatis          = scannerCmd.TypeMySQLMybatis
-	TypeMySQLSchemaMeta       = "mysql_schema_meta"
-	TypeMySQLProcesslist      = "mysql_processlist"
-	TypeAliRdsMySQLSlowLog    = "ali_rds_mysql_slow_log"
-	TypeAliRdsMySQLAuditLog   = "ali_rds_mysql_audit_log"
-	TypeHuaweiRdsMySQLSlowLog = "huawei_rds_mysql_slow_log"
-	TypeOracleTopSQL          = "oracle_top_sql"
-	TypeAllAppExtract         = "all_app_extract"
-	TypeBaiduRdsMySQLSlowLog  = "baidu_rds_mysql_slow_log"
-	TypeSQLFile               = scannerCmd.TypeSQLFile
+	TypeDefault                = "default"
+	TypeMySQLSlowLog           = scannerCmd.TypeMySQLSlowLog
+	TypeMySQLMybatis           = scannerCmd.TypeMySQLMybatis
+	TypeMySQLSchemaMeta        = "mysql_schema_meta"
+	TypeMySQLProcesslist       = "mysql_processlist"
+	TypeMySQLLockWait          = "mysql_lock_wait"
+	TypeMySQLIndexAdvice       = "mysql_index_advice"
+	TypeGenericQuery           = "generic_query"
+	TypeAliRdsMySQLSlowLog     = "ali_rds_mysql_slow_log"
+	TypeAliRdsMySQLAuditLog    = "ali_rds_mysql_audit_log"
+	TypeHuaweiRdsMySQLSlowLog  = "huawei_rds_mysql_slow_log"
+	TypeOracleTopSQL           = "oracle_top_sql"
+	TypeAllAppExtract          = "all_app_extract"
+	TypeBaiduRdsMySQLSlowLog   = "baidu_rds_mysql_slow_log"
+	TypeTencentCdbMySQLSlowLog = "tencent_cdb_mysql_slow_log"
+	TypeAwsRdsMySQLSlowLog     = "aws_rds_mysql_slow_log"
+	TypeSQLFile                = scannerCmd.TypeSQLFile
 )
 
 const (
@@ -86,6 +91,21 @@ var MetaBuilderList = []MetaBuilder{
 		Desc:          locale.ApMetaMySQLProcesslist,
 		TaskHandlerFn: NewMySQLProcessListTaskV2Fn(),
 	},
+	{
+		Type:          TypeMySQLLockWait,
+		Desc:          locale.ApMetaMySQLLockWait,
+		TaskHandlerFn: NewMySQLLockWaitTaskV2Fn(),
+	},
+	{
+		Type:          TypeMySQLIndexAdvice,
+		Desc:          locale.ApMetaMySQLIndexAdvice,
+		TaskHandlerFn: NewMySQLIndexAdviceTaskV2Fn(),
+	},
+	{
+		Type:          TypeGenericQuery,
+		Desc:          locale.ApMetaGenericQuery,
+		TaskHandlerFn: NewGenericQueryTaskV2Fn(),
+	},
 	{
 		Type:          TypeAliRdsMySQLSlowLog,
 		Desc:          locale.ApMetaAliRdsMySQLSlowLog,
@@ -106,6 +126,16 @@ var MetaBuilderList = []MetaBuilder{
 		Desc:          locale.ApMetaHuaweiRdsMySQLSlowLog,
 		TaskHandlerFn: NewMySQLSlowLogHuaweiTaskV2Fn(),
 	},
+	{
+		Type:          TypeTencentCdbMySQLSlowLog,
+		Desc:          locale.ApMetaTencentCdbMySQLSlowLog,
+		TaskHandlerFn: NewMySQLSlowLogTencentTaskV2Fn(),
+	},
+	{
+		Type:          TypeAwsRdsMySQLSlowLog,
+		Desc:          locale.ApMetaAwsRdsMySQLSlowLog,
+		TaskHandlerFn: NewMySQLSlowLogAwsTaskV2Fn(),
+	},
 	{
 		Type:          TypeOracleTopSQL,
 		Desc:          locale.ApMetaOracleTopSQL,
@@ -140,7 +170,7 @@ func buildMeta(b MetaBuilder) Meta {
 			return taskMeta.Params(instanceId...)
 		},
 		HighPriorityParams: taskMeta.HighPriorityParams(),
-		Metrics:            taskMeta.Metrics(),
+		Metrics:            append(taskMeta.Metrics(), sqlAttributionMetrics...),
 		Handler:            handler,
 		CreateTask:         NewTaskWrap(b.TaskHandlerFn),
 	}
@@ -174,6 +204,20 @@ func GetMeta(typ string) (Meta, error) {
 	}, nil
 }
 
+// ValidateParams checks the params of the audit plan type on the instance of the db type before it is saved, the params
+// of the type which doesn't implement AuditPlanParamsValidator are always valid.
+func ValidateParams(typ, dbType string, ps params.Params) error {
+	meta, err := GetMeta(typ)
+	if err != nil {
+		return err
+	}
+	validator, ok := meta.Handler.(AuditPlanParamsValidator)
+	if !ok {
+		return nil
+	}
+	return validator.ValidateParams(dbType, ps)
+}
+
 var supportedCmdTypeList = map[string]struct{}{
 	TypeMySQLSlowLog:  {},
 	TypeAllAppExtract: {},
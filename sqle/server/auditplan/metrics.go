@@ -9,11 +9,26 @@ const MetricNameLastReceiveTimestamp string = "last_receive_timestamp"
 const MetricNameQueryTimeAvg string = "query_time_avg"     // 平均执行时间
 const MetricNameQueryTimeMax string = "query_time_max"     // 最大执行时间
 const MetricNameRowExaminedAvg string = "row_examined_avg" // 平均扫描行数
+const MetricNameLockTimeAvg string = "lock_time_avg"       // 平均锁等待时间
 const MetricNameFirstQueryAt string = "first_query_at"
 const MetricNameDBUser string = "db_user"
 const MetricNameEndpoints string = "endpoints"
 const MetricNameStartTimeOfLastScrapedSQL string = "start_time_of_last_scraped_sql" // 抓取sql的开始时间
 
+const MetricNameApplication string = "application" // 下发SQL的应用
+const MetricNameRoute string = "route"             // 下发SQL的应用内请求路由
+
+const MetricNameLockType string = "lock_type"                 // 锁事件类型：行锁等待、元数据锁等待或长事务
+const MetricNameLockObject string = "lock_object"             // 等待的锁所在的表
+const MetricNameBlockerSQL string = "blocker_sql"             // 持有锁阻塞当前SQL的会话正在或最后执行的SQL
+const MetricNameLockWaitTimeMax string = "lock_wait_time_max" // 最长锁等待时间，长事务为最长持续时间，单位秒
+
+const MetricNameTableName string = "table_name"              // 索引所在的表
+const MetricNameIndexName string = "index_name"              // 建议删除的索引
+const MetricNameIndexAdviceType string = "index_advice_type" // 索引建议类型：未使用、冗余或重复
+const MetricNameCoveringIndex string = "covering_index"      // 可以替代冗余索引的索引
+const MetricNameIndexSize string = "index_size"              // 索引大小，即删除后预估节省的空间，单位字节
+
 const MetricNameMetaName string = "schema_meta_name"    // 表或者视图的名字
 const MetricNameMetaType string = "schema_meta_type"    // 表或者视图等等
 const MetricNameRecordDeleted string = "record_deleted" // 标记记录是否被删除掉
@@ -44,9 +59,21 @@ var ALLMetric = map[string]MetricType{
 	MetricNameQueryTimeAvg:              MetricTypeFloat,  // MySQL slow log
 	MetricNameQueryTimeMax:              MetricTypeFloat,  // MySQL slow log
 	MetricNameRowExaminedAvg:            MetricTypeFloat,  // MySQL slow log
+	MetricNameLockTimeAvg:               MetricTypeFloat,  // MySQL slow log
 	MetricNameFirstQueryAt:              MetricTypeString, // MySQL slow log, 好像没用上 | OB MySQL TOP SQL
 	MetricNameDBUser:                    MetricTypeString, // MySQL slow log
 	MetricNameEndpoints:                 MetricTypeArray,  // MySQL slow log
+	MetricNameApplication:               MetricTypeString, // all types
+	MetricNameRoute:                     MetricTypeString, // all types
+	MetricNameLockType:                  MetricTypeString, // MySQL lock wait
+	MetricNameLockObject:                MetricTypeString, // MySQL lock wait
+	MetricNameBlockerSQL:                MetricTypeString, // MySQL lock wait
+	MetricNameLockWaitTimeMax:           MetricTypeInt,    // MySQL lock wait
+	MetricNameTableName:                 MetricTypeString, // MySQL index advice
+	MetricNameIndexName:                 MetricTypeString, // MySQL index advice
+	MetricNameIndexAdviceType:           MetricTypeString, // MySQL index advice
+	MetricNameCoveringIndex:             MetricTypeString, // MySQL index advice
+	MetricNameIndexSize:                 MetricTypeInt,    // MySQL index advice
 	MetricNameStartTimeOfLastScrapedSQL: MetricTypeString, // MySQL slow log
 	MetricNameMetaName:                  MetricTypeString, // MySQL schema meta
 	MetricNameMetaType:                  MetricTypeString, // MySQL schema meta
@@ -0,0 +1,494 @@
+package auditplan
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	driverV2 "github.com/actiontech/sqle/sqle/driver/v2"
+)
+
+// PriorityExpression is the condition of the priority rule of the managed SQL, e.g.
+//
+//	audit_level >= warn AND (query_time_avg > 1 OR row_examined_avg BETWEEN 10000 AND 1000000)
+//
+// The operands are the metrics of the SQL, audit_level and regression_factor. The comparisons on the metric
+// not collected are unknown like NULL in SQL, and the SQL is matched only if the expression is true, so
+// neither the comparison nor its negation matches the SQL without the metric.
+type PriorityExpression struct {
+	raw  string
+	root priorityExprNode
+}
+
+func (e *PriorityExpression) String() string {
+	return e.raw
+}
+
+// priorityExprEnv provides the values of the operands, false is returned if the value is not collected.
+type priorityExprEnv interface {
+	value(name string) (string, bool, error)
+}
+
+// priorityExprResult is the three-valued result of the expression, AND is the minimum of the results and OR
+// is the maximum.
+type priorityExprResult int
+
+const (
+	priorityExprFalse priorityExprResult = iota
+	priorityExprUnknown
+	priorityExprTrue
+)
+
+func newPriorityExprResult(b bool) priorityExprResult {
+	if b {
+		return priorityExprTrue
+	}
+	return priorityExprFalse
+}
+
+type priorityExprNode interface {
+	eval(env priorityExprEnv) (priorityExprResult, error)
+}
+
+type priorityExprAnd struct{ left, right priorityExprNode }
+
+func (n *priorityExprAnd) eval(env priorityExprEnv) (priorityExprResult, error) {
+	left, err := n.left.eval(env)
+	if err != nil || left == priorityExprFalse {
+		return priorityExprFalse, err
+	}
+	right, err := n.right.eval(env)
+	if err != nil {
+		return priorityExprFalse, err
+	}
+	if right < left {
+		return right, nil
+	}
+	return left, nil
+}
+
+type priorityExprOr struct{ left, right priorityExprNode }
+
+func (n *priorityExprOr) eval(env priorityExprEnv) (priorityExprResult, error) {
+	left, err := n.left.eval(env)
+	if err != nil || left == priorityExprTrue {
+		return left, err
+	}
+	right, err := n.right.eval(env)
+	if err != nil {
+		return priorityExprFalse, err
+	}
+	if right > left {
+		return right, nil
+	}
+	return left, nil
+}
+
+type priorityExprNot struct{ node priorityExprNode }
+
+func (n *priorityExprNot) eval(env priorityExprEnv) (priorityExprResult, error) {
+	result, err := n.node.eval(env)
+	if err != nil {
+		return priorityExprFalse, err
+	}
+	// NOT unknown is still unknown
+	return priorityExprTrue - result, nil
+}
+
+type priorityExprCompare struct {
+	name  string
+	op    string
+	value string
+}
+
+func (n *priorityExprCompare) eval(env priorityExprEnv) (priorityExprResult, error) {
+	v, exist, err := env.value(n.name)
+	if err != nil {
+		return priorityExprFalse, err
+	}
+	if !exist {
+		return priorityExprUnknown, nil
+	}
+	return newPriorityExprResult(comparePriorityValue(n.name, v, n.op, n.value)), nil
+}
+
+type priorityExprBetween struct {
+	name      string
+	low, high string
+}
+
+func (n *priorityExprBetween) eval(env priorityExprEnv) (priorityExprResult, error) {
+	v, exist, err := env.value(n.name)
+	if err != nil {
+		return priorityExprFalse, err
+	}
+	if !exist {
+		return priorityExprUnknown, nil
+	}
+	return newPriorityExprResult(comparePriorityValue(n.name, v, ">=", n.low) && comparePriorityValue(n.name, v, "<=", n.high)), nil
+}
+
+type priorityExprIn struct {
+	name   string
+	values []string
+}
+
+func (n *priorityExprIn) eval(env priorityExprEnv) (priorityExprResult, error) {
+	v, exist, err := env.value(n.name)
+	if err != nil {
+		return priorityExprFalse, err
+	}
+	if !exist {
+		return priorityExprUnknown, nil
+	}
+	for _, value := range n.values {
+		if comparePriorityValue(n.name, v, "=", value) {
+			return priorityExprTrue, nil
+		}
+	}
+	return priorityExprFalse, nil
+}
+
+var auditLevelRanks = map[string]string{
+	string(driverV2.RuleLevelNull):   "0",
+	string(driverV2.RuleLevelNormal): "0",
+	string(driverV2.RuleLevelNotice): "1",
+	string(driverV2.RuleLevelWarn):   "2",
+	string(driverV2.RuleLevelError):  "3",
+}
+
+// comparePriorityValue compares the values as numbers if both are numbers, otherwise only = and != are true
+// when matched. The audit levels are compared by their severity.
+func comparePriorityValue(name, left, op, right string) bool {
+	if name == OperationParamAuditLevel {
+		left, right = auditLevelRanks[strings.ToLower(left)], auditLevelRanks[strings.ToLower(right)]
+	}
+	l, lErr := strconv.ParseFloat(left, 64)
+	r, rErr := strconv.ParseFloat(right, 64)
+	if lErr == nil && rErr == nil {
+		switch op {
+		case "=":
+			return l == r
+		case "!=":
+			return l != r
+		case ">":
+			return l > r
+		case ">=":
+			return l >= r
+		case "<":
+			return l < r
+		case "<=":
+			return l <= r
+		}
+	}
+	switch op {
+	case "=":
+		return left == right
+	case "!=":
+		return left != right
+	}
+	return false
+}
+
+// ParsePriorityExpression parses the expression, the operands must be audit_level, regression_factor or the metrics.
+func ParsePriorityExpression(expression string) (*PriorityExpression, error) {
+	tokens, err := tokenizePriorityExpression(expression)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("priority expression is empty")
+	}
+	p := &priorityExprParser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q in priority expression", p.tokens[p.pos].text)
+	}
+	return &PriorityExpression{raw: expression, root: root}, nil
+}
+
+// eval returns true only if the expression is true, the unknown result is not matched.
+func (e *PriorityExpression) eval(env priorityExprEnv) (bool, error) {
+	result, err := e.root.eval(env)
+	return result == priorityExprTrue, err
+}
+
+type priorityExprTokenType int
+
+const (
+	priorityTokenWord priorityExprTokenType = iota
+	priorityTokenString
+	priorityTokenOperator
+	priorityTokenLeftParen
+	priorityTokenRightParen
+	priorityTokenComma
+)
+
+type priorityExprToken struct {
+	typ  priorityExprTokenType
+	text string
+}
+
+func (t priorityExprToken) isKeyword(keyword string) bool {
+	return t.typ == priorityTokenWord && strings.EqualFold(t.text, keyword)
+}
+
+func tokenizePriorityExpression(expression string) ([]priorityExprToken, error) {
+	tokens := []priorityExprToken{}
+	runes := []rune(expression)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, priorityExprToken{typ: priorityTokenLeftParen, text: "("})
+			i++
+		case r == ')':
+			tokens = append(tokens, priorityExprToken{typ: priorityTokenRightParen, text: ")"})
+			i++
+		case r == ',':
+			tokens = append(tokens, priorityExprToken{typ: priorityTokenComma, text: ","})
+			i++
+		case r == '\'' || r == '"':
+			end := i + 1
+			for end < len(runes) && runes[end] != r {
+				end++
+			}
+			if end >= len(runes) {
+				return nil, fmt.Errorf("unterminated string in priority expression")
+			}
+			tokens = append(tokens, priorityExprToken{typ: priorityTokenString, text: string(runes[i+1 : end])})
+			i = end + 1
+		case strings.ContainsRune("<>=!", r):
+			op, width := string(r), 1
+			if i+1 < len(runes) {
+				switch two := string(runes[i : i+2]); two {
+				case ">=", "<=", "!=":
+					op, width = two, 2
+				case "==":
+					op, width = "=", 2
+				case "<>":
+					op, width = "!=", 2
+				}
+			}
+			if op == "!" {
+				return nil, fmt.Errorf("unexpected ! in priority expression")
+			}
+			i += width
+			tokens = append(tokens, priorityExprToken{typ: priorityTokenOperator, text: op})
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.' || r == '-':
+			end := i
+			for end < len(runes) && (unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end]) || strings.ContainsRune("_.-", runes[end])) {
+				end++
+			}
+			tokens = append(tokens, priorityExprToken{typ: priorityTokenWord, text: string(runes[i:end])})
+			i = end
+		default:
+			return nil, fmt.Errorf("unexpected %q in priority expression", r)
+		}
+	}
+	return tokens, nil
+}
+
+type priorityExprParser struct {
+	tokens []priorityExprToken
+	pos    int
+}
+
+func (p *priorityExprParser) peek() (priorityExprToken, bool) {
+	if p.pos >= len(p.tokens) {
+		return priorityExprToken{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *priorityExprParser) next() (priorityExprToken, error) {
+	t, ok := p.peek()
+	if !ok {
+		return t, fmt.Errorf("priority expression ends unexpectedly")
+	}
+	p.pos++
+	return t, nil
+}
+
+func (p *priorityExprParser) acceptKeyword(keyword string) bool {
+	if t, ok := p.peek(); ok && t.isKeyword(keyword) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *priorityExprParser) parseOr() (priorityExprNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("OR") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &priorityExprOr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *priorityExprParser) parseAnd() (priorityExprNode, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("AND") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &priorityExprAnd{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *priorityExprParser) parseNot() (priorityExprNode, error) {
+	if p.acceptKeyword("NOT") {
+		node, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &priorityExprNot{node: node}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *priorityExprParser) parsePrimary() (priorityExprNode, error) {
+	t, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	if t.typ == priorityTokenLeftParen {
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if t, err := p.next(); err != nil || t.typ != priorityTokenRightParen {
+			return nil, fmt.Errorf("missing ) in priority expression")
+		}
+		return node, nil
+	}
+	if t.typ != priorityTokenWord {
+		return nil, fmt.Errorf("unexpected %q in priority expression", t.text)
+	}
+	name := strings.ToLower(t.text)
+	if !isPriorityOperand(name) {
+		return nil, fmt.Errorf("unknown operand %q in priority expression", t.text)
+	}
+
+	switch {
+	case p.acceptKeyword("BETWEEN"):
+		low, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		if !p.acceptKeyword("AND") {
+			return nil, fmt.Errorf("missing AND of BETWEEN in priority expression")
+		}
+		high, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		for _, value := range []string{low, high} {
+			if err := checkPriorityOperandValue(name, "BETWEEN", value); err != nil {
+				return nil, err
+			}
+		}
+		return &priorityExprBetween{name: name, low: low, high: high}, nil
+	case p.acceptKeyword("IN"):
+		if t, err := p.next(); err != nil || t.typ != priorityTokenLeftParen {
+			return nil, fmt.Errorf("missing ( of IN in priority expression")
+		}
+		values := []string{}
+		for {
+			value, err := p.parseValue()
+			if err != nil {
+				return nil, err
+			}
+			if err := checkPriorityOperandValue(name, "IN", value); err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+			t, err := p.next()
+			if err != nil {
+				return nil, err
+			}
+			if t.typ == priorityTokenRightParen {
+				break
+			}
+			if t.typ != priorityTokenComma {
+				return nil, fmt.Errorf("unexpected %q in priority expression", t.text)
+			}
+		}
+		return &priorityExprIn{name: name, values: values}, nil
+	}
+
+	op, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+	if op.typ != priorityTokenOperator {
+		return nil, fmt.Errorf("missing comparison operator after %q in priority expression", t.text)
+	}
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPriorityOperandValue(name, op.text, value); err != nil {
+		return nil, err
+	}
+	return &priorityExprCompare{name: name, op: op.text, value: value}, nil
+}
+
+func (p *priorityExprParser) parseValue() (string, error) {
+	t, err := p.next()
+	if err != nil {
+		return "", err
+	}
+	if t.typ != priorityTokenWord && t.typ != priorityTokenString {
+		return "", fmt.Errorf("unexpected %q in priority expression", t.text)
+	}
+	return t.text, nil
+}
+
+func isPriorityOperand(name string) bool {
+	if name == OperationParamAuditLevel || name == OperationParamRegressionFactor {
+		return true
+	}
+	_, ok := ALLMetric[name]
+	return ok
+}
+
+// checkPriorityOperandValue checks the value can be compared with the operand by the operator, the audit level
+// must be known, the numeric operand must be compared with numbers and the others can't be ordered.
+func checkPriorityOperandValue(name, op, value string) error {
+	if name == OperationParamAuditLevel {
+		if _, ok := auditLevelRanks[strings.ToLower(value)]; !ok {
+			return fmt.Errorf("unknown audit level %q in priority expression", value)
+		}
+		return nil
+	}
+	if name == OperationParamRegressionFactor || ALLMetric[name] == MetricTypeInt || ALLMetric[name] == MetricTypeFloat {
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return fmt.Errorf("%v must be compared with a number in priority expression, but got %q", name, value)
+		}
+		return nil
+	}
+	switch op {
+	case "=", "!=", "IN":
+		return nil
+	}
+	return fmt.Errorf("%v can't be compared by %v in priority expression", name, op)
+}
@@ -0,0 +1,138 @@
+package auditplan
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/actiontech/sqle/sqle/model"
+	"github.com/actiontech/sqle/sqle/pkg/params"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mapPriorityExprEnv map[string]string
+
+func (e mapPriorityExprEnv) value(name string) (string, bool, error) {
+	v, ok := e[name]
+	return v, ok, nil
+}
+
+func TestParsePriorityExpression(t *testing.T) {
+	env := mapPriorityExprEnv{
+		OperationParamAuditLevel: "warn",
+		MetricNameQueryTimeAvg:   "1.5",
+		MetricNameRowExaminedAvg: "20000",
+		MetricNameDBUser:         "app",
+	}
+	cases := []struct {
+		expression string
+		expected   bool
+	}{
+		{"audit_level >= warn", true},
+		{"audit_level > warn", false},
+		{"AUDIT_LEVEL = 'warn'", true},
+		{"query_time_avg > 1 AND row_examined_avg < 10000", false},
+		{"query_time_avg > 1 OR row_examined_avg < 10000", true},
+		{"audit_level = error OR query_time_avg > 1 AND row_examined_avg >= 20000", true},
+		{"(audit_level = error OR query_time_avg > 1) AND row_examined_avg > 20000", false},
+		{"row_examined_avg BETWEEN 10000 AND 100000", true},
+		{"NOT row_examined_avg BETWEEN 10000 AND 100000", false},
+		{"db_user IN ('root', \"app\")", true},
+		{"db_user != app", false},
+		{"db_user <> root", true},
+		{"query_time_avg == 1.5", true},
+		// the metric not collected
+		{"lock_time_avg > 0", false},
+		{"NOT lock_time_avg > 0", false},
+		{"NOT (lock_time_avg > 0 AND query_time_avg > 1)", false},
+		{"NOT (lock_time_avg > 0 AND query_time_avg > 2)", true},
+		{"lock_time_avg > 0 OR query_time_avg > 1", true},
+		{"NOT (lock_time_avg > 0 OR query_time_avg > 2)", false},
+	}
+	for _, c := range cases {
+		expression, err := ParsePriorityExpression(c.expression)
+		assert.NoError(t, err, c.expression)
+		matched, err := expression.eval(env)
+		assert.NoError(t, err, c.expression)
+		assert.Equal(t, c.expected, matched, c.expression)
+	}
+
+	for _, invalid := range []string{
+		"",
+		"unknown_metric > 1",
+		"query_time_avg >",
+		"query_time_avg 1",
+		"(query_time_avg > 1",
+		"query_time_avg > 1 AND",
+		"query_time_avg BETWEEN 1 2",
+		"db_user IN (a b)",
+		"db_user = 'a",
+		"query_time_avg ! 1",
+		// the strings can't be ordered
+		"db_user > a",
+		"db_user BETWEEN a AND b",
+		"query_time_avg > abc",
+		"row_examined_avg IN (1, a)",
+		"audit_level >= fatal",
+	} {
+		_, err := ParsePriorityExpression(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestGetSQLPriorityByRules(t *testing.T) {
+	info, err := json.Marshal(map[string]interface{}{MetricNameQueryTimeAvg: 3})
+	assert.NoError(t, err)
+	sql := &model.SQLManageRecord{AuditLevel: "notice", Info: info}
+	rules := []*model.SQLManagePriorityRule{
+		{Priority: model.PriorityP0, Expression: "audit_level = error"},
+		{Priority: model.PriorityP1, Expression: "query_time_avg > 2"},
+		{Priority: model.PriorityP2, Expression: "audit_level >= notice"},
+	}
+	priority, reasons, err := getSQLPriorityByRules(rules, sql)
+	assert.NoError(t, err)
+	assert.Equal(t, model.PriorityP1, priority)
+	assert.Len(t, reasons, 1)
+
+	sql.Info, err = json.Marshal(map[string]interface{}{})
+	assert.NoError(t, err)
+	sql.AuditLevel = "normal"
+	priority, _, err = getSQLPriorityByRules(rules, sql)
+	assert.NoError(t, err)
+	assert.Equal(t, "", priority)
+}
+
+func TestGetSQLPriorityWithReasons(t *testing.T) {
+	info, err := json.Marshal(map[string]interface{}{MetricNameQueryTimeAvg: 3})
+	assert.NoError(t, err)
+	sql := &model.SQLManageRecord{AuditLevel: "warn", Info: info}
+	auditPlan := &model.AuditPlanV2{HighPriorityParams: params.ParamsWithOperator{
+		{
+			Param:    params.Param{Key: OperationParamAuditLevel, Value: "2", Type: params.ParamTypeInt},
+			Operator: params.Operator{Value: params.EqualToOperator},
+		},
+	}}
+	rules := []*model.SQLManagePriorityRule{
+		{Priority: model.PriorityP1, Expression: "query_time_avg > 2"},
+	}
+
+	// both the rule and the high priority params are matched
+	priority, reasons, err := getSQLPriorityWithReasons(context.TODO(), auditPlan, rules, sql)
+	assert.NoError(t, err)
+	assert.Equal(t, model.PriorityP1, priority)
+	assert.Len(t, reasons, 2)
+
+	// only the high priority params are matched
+	rules[0].Expression = "query_time_avg > 5"
+	priority, reasons, err = getSQLPriorityWithReasons(context.TODO(), auditPlan, rules, sql)
+	assert.NoError(t, err)
+	assert.Equal(t, model.PriorityHigh, priority)
+	assert.Len(t, reasons, 1)
+
+	sql.AuditLevel = "notice"
+	priority, reasons, err = getSQLPriorityWithReasons(context.TODO(), auditPlan, rules, sql)
+	assert.NoError(t, err)
+	assert.Equal(t, "", priority)
+	assert.Len(t, reasons, 0)
+}
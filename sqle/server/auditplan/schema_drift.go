@@ -0,0 +1,638 @@
+package auditplan
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/actiontech/sqle/sqle/driver/mysql/util"
+	"github.com/actiontech/sqle/sqle/model"
+	"github.com/actiontech/sqle/sqle/notification"
+
+	"github.com/pingcap/parser/ast"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	SchemaMetaChangeObjectColumn       = "column"
+	SchemaMetaChangeObjectIndex        = "index"
+	SchemaMetaChangeObjectConstraint   = "constraint"
+	SchemaMetaChangeObjectTableOptions = "table_options"
+	SchemaMetaChangeObjectDefinition   = "definition"
+
+	SchemaMetaChangeActionAdded    = "added"
+	SchemaMetaChangeActionDropped  = "dropped"
+	SchemaMetaChangeActionModified = "modified"
+)
+
+// SchemaMetaChange is a structural change of the object between two snapshots.
+type SchemaMetaChange struct {
+	Object string `json:"object"`
+	Name   string `json:"name"`
+	Action string `json:"action"`
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+}
+
+// the AUTO_INCREMENT table option changes with the data, it is not a part of the structure
+var schemaMetaAutoIncrementRegexp = regexp.MustCompile(`(?i)\s+AUTO_INCREMENT=\d+`)
+
+func normalizeSchemaMetaContent(content string) string {
+	return strings.TrimSpace(schemaMetaAutoIncrementRegexp.ReplaceAllString(content, ""))
+}
+
+var (
+	schemaMetaColumnRegexp     = regexp.MustCompile("^`((?:[^`]|``)+)`")
+	schemaMetaIndexRegexp      = regexp.MustCompile("^(?:(?:UNIQUE|FULLTEXT|SPATIAL)\\s+)?KEY\\s+`((?:[^`]|``)+)`")
+	schemaMetaConstraintRegexp = regexp.MustCompile("^CONSTRAINT\\s+`((?:[^`]|``)+)`")
+)
+
+type schemaMetaElement struct {
+	object     string
+	name       string
+	definition string
+}
+
+func (e *schemaMetaElement) key() string {
+	return e.object + "\x00" + e.name
+}
+
+// parseTableDefinition splits the output of SHOW CREATE TABLE into the columns, the indexes, the constraints and the
+// table options, every column, index and constraint is in one line of the output.
+func parseTableDefinition(content string) []*schemaMetaElement {
+	elements := []*schemaMetaElement{}
+	options := []string{}
+	closed := false
+	for i, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if i == 0 || line == "" {
+			// the first line is "CREATE TABLE `name` ("
+			continue
+		}
+		if !closed && strings.HasPrefix(line, ")") {
+			closed = true
+			line = strings.TrimSpace(strings.TrimPrefix(line, ")"))
+		}
+		if closed {
+			if line != "" {
+				options = append(options, line)
+			}
+			continue
+		}
+		definition := strings.TrimSuffix(line, ",")
+		element := &schemaMetaElement{object: SchemaMetaChangeObjectConstraint, name: definition, definition: definition}
+		if strings.HasPrefix(definition, "PRIMARY KEY") {
+			element.object, element.name = SchemaMetaChangeObjectIndex, "PRIMARY"
+		} else if m := schemaMetaColumnRegexp.FindStringSubmatch(definition); m != nil {
+			element.object, element.name = SchemaMetaChangeObjectColumn, m[1]
+		} else if m := schemaMetaIndexRegexp.FindStringSubmatch(definition); m != nil {
+			element.object, element.name = SchemaMetaChangeObjectIndex, m[1]
+		} else if m := schemaMetaConstraintRegexp.FindStringSubmatch(definition); m != nil {
+			element.name = m[1]
+		}
+		elements = append(elements, element)
+	}
+	if len(options) > 0 {
+		elements = append(elements, &schemaMetaElement{
+			object:     SchemaMetaChangeObjectTableOptions,
+			definition: strings.Join(options, " "),
+		})
+	}
+	return elements
+}
+
+// diffSchemaMeta returns the structural changes from the before definition to the after definition of the object,
+// the definitions of the views are compared as a whole.
+func diffSchemaMeta(metaType, before, after string) []*SchemaMetaChange {
+	if before == after {
+		return nil
+	}
+	if metaType != "table" {
+		return []*SchemaMetaChange{{
+			Object: SchemaMetaChangeObjectDefinition,
+			Action: SchemaMetaChangeActionModified,
+			Before: before,
+			After:  after,
+		}}
+	}
+	changes := []*SchemaMetaChange{}
+	beforeElements := parseTableDefinition(before)
+	beforeIndex := make(map[string]*schemaMetaElement, len(beforeElements))
+	for _, element := range beforeElements {
+		beforeIndex[element.key()] = element
+	}
+	afterElements := parseTableDefinition(after)
+	afterIndex := make(map[string]*schemaMetaElement, len(afterElements))
+	for _, element := range afterElements {
+		afterIndex[element.key()] = element
+		origin, ok := beforeIndex[element.key()]
+		switch {
+		case !ok:
+			changes = append(changes, &SchemaMetaChange{
+				Object: element.object,
+				Name:   element.name,
+				Action: SchemaMetaChangeActionAdded,
+				After:  element.definition,
+			})
+		case origin.definition != element.definition:
+			changes = append(changes, &SchemaMetaChange{
+				Object: element.object,
+				Name:   element.name,
+				Action: SchemaMetaChangeActionModified,
+				Before: origin.definition,
+				After:  element.definition,
+			})
+		}
+	}
+	for _, element := range beforeElements {
+		if _, ok := afterIndex[element.key()]; !ok {
+			changes = append(changes, &SchemaMetaChange{
+				Object: element.object,
+				Name:   element.name,
+				Action: SchemaMetaChangeActionDropped,
+				Before: element.definition,
+			})
+		}
+	}
+	return changes
+}
+
+func schemaMetaKey(schemaName, metaType, metaName string) string {
+	return strings.Join([]string{schemaName, metaType, metaName}, "\x00")
+}
+
+func schemaObjectKey(schemaName, name string) string {
+	return strings.ToLower(schemaName + "." + name)
+}
+
+// schemaChangeAnyName is the name of the element which means any element of the object type, it is used when the
+// name of the element changed by the DDL is generated by the database.
+const schemaChangeAnyName = "*"
+
+func schemaChangeElementKey(object, name string) string {
+	return object + "\x00" + strings.ToLower(name)
+}
+
+type schemaChangeExecution struct {
+	taskId     uint
+	executedAt time.Time
+	// elements is the keys of the elements changed by the DDL, nil means the DDL creates, drops or renames the whole
+	// object
+	elements map[string]struct{}
+}
+
+func (e *schemaChangeExecution) covers(change *SchemaMetaChange) bool {
+	if e.elements == nil {
+		return true
+	}
+	for _, name := range []string{change.Name, schemaChangeAnyName} {
+		if _, ok := e.elements[schemaChangeElementKey(change.Object, name)]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// schemaChangeIndex indexes the tables and the views changed by the DDLs executed through the workflows.
+type schemaChangeIndex struct {
+	objects map[string][]*schemaChangeExecution
+	// schemas is the schemas dropped by the DDLs, every object in the schema is dropped
+	schemas map[string][]*schemaChangeExecution
+}
+
+func newSchemaChangeIndex(sqls []*model.InstanceExecutedSQL) *schemaChangeIndex {
+	index := &schemaChangeIndex{
+		objects: map[string][]*schemaChangeExecution{},
+		schemas: map[string][]*schemaChangeExecution{},
+	}
+	var currentTask uint
+	var currentSchema string
+	for _, sql := range sqls {
+		if sql.TaskId != currentTask {
+			currentTask, currentSchema = sql.TaskId, sql.Schema
+		}
+		stmt, err := util.ParseOneSql(sql.Content)
+		if err != nil {
+			// the SQL not parsed can't be matched
+			continue
+		}
+		switch stmt := stmt.(type) {
+		case *ast.UseStmt:
+			currentSchema = stmt.DBName
+			continue
+		case *ast.DropDatabaseStmt:
+			schema := strings.ToLower(stmt.Name)
+			index.schemas[schema] = append(index.schemas[schema], &schemaChangeExecution{taskId: sql.TaskId, executedAt: sql.ExecutedAt})
+			continue
+		}
+		for _, change := range schemaObjectsChangedByDDL(currentSchema, stmt) {
+			index.objects[change.object] = append(index.objects[change.object], &schemaChangeExecution{
+				taskId:     sql.TaskId,
+				executedAt: sql.ExecutedAt,
+				elements:   change.elements,
+			})
+		}
+	}
+	return index
+}
+
+// match returns the tasks which changed the object of the drift after the time, and whether all the changes of the
+// drift are made by the tasks. The created or the dropped object is made by the tasks only if the tasks create, drop
+// or rename the whole object, the altered object is made by the tasks only if every changed element is changed by the
+// tasks.
+func (i *schemaChangeIndex) match(drift *model.SchemaMetaDrift, changes []*SchemaMetaChange, after time.Time) ([]uint, bool) {
+	executions := []*schemaChangeExecution{}
+	candidates := append([]*schemaChangeExecution{}, i.objects[schemaObjectKey(drift.SchemaName, drift.MetaName)]...)
+	if drift.ChangeType == model.SchemaMetaDriftChangeTypeDropped {
+		candidates = append(candidates, i.schemas[strings.ToLower(drift.SchemaName)]...)
+	}
+	for _, execution := range candidates {
+		if !execution.executedAt.Before(after) {
+			executions = append(executions, execution)
+		}
+	}
+
+	taskIds := []uint{}
+	seen := map[uint]struct{}{}
+	for _, execution := range executions {
+		if _, ok := seen[execution.taskId]; ok {
+			continue
+		}
+		seen[execution.taskId] = struct{}{}
+		taskIds = append(taskIds, execution.taskId)
+	}
+	sort.Slice(taskIds, func(a, b int) bool { return taskIds[a] < taskIds[b] })
+
+	covered := func(change *SchemaMetaChange) bool {
+		for _, execution := range executions {
+			if execution.covers(change) {
+				return true
+			}
+		}
+		return false
+	}
+	if drift.ChangeType != model.SchemaMetaDriftChangeTypeAltered {
+		for _, execution := range executions {
+			if execution.elements == nil {
+				return taskIds, true
+			}
+		}
+		return taskIds, false
+	}
+	for _, change := range changes {
+		if !covered(change) {
+			return taskIds, false
+		}
+	}
+	return taskIds, len(taskIds) > 0
+}
+
+type schemaObjectChange struct {
+	// object is the key of the table or the view
+	object string
+	// elements is the keys of the elements changed, nil means the whole object is changed
+	elements map[string]struct{}
+}
+
+// schemaObjectsChangedByDDL returns the tables and the views changed by the DDL.
+func schemaObjectsChangedByDDL(defaultSchema string, stmt ast.StmtNode) []*schemaObjectChange {
+	keyOf := func(table *ast.TableName) string {
+		schema := table.Schema.O
+		if schema == "" {
+			schema = defaultSchema
+		}
+		return schemaObjectKey(schema, table.Name.O)
+	}
+	whole := func(table *ast.TableName) *schemaObjectChange {
+		return &schemaObjectChange{object: keyOf(table)}
+	}
+	objects := []*schemaObjectChange{}
+	switch stmt := stmt.(type) {
+	case *ast.CreateTableStmt:
+		objects = append(objects, whole(stmt.Table))
+	case *ast.AlterTableStmt:
+		renamed := []*schemaObjectChange{}
+		elements := map[string]struct{}{}
+		for _, spec := range stmt.Specs {
+			if spec.Tp == ast.AlterTableRenameTable {
+				renamed = append(renamed, whole(spec.NewTable))
+				continue
+			}
+			addElementsChangedByAlterTableSpec(elements, spec)
+		}
+		if len(renamed) > 0 {
+			// the old table is dropped and the new table is created
+			objects = append(objects, whole(stmt.Table))
+			objects = append(objects, renamed...)
+		} else {
+			objects = append(objects, &schemaObjectChange{object: keyOf(stmt.Table), elements: elements})
+		}
+	case *ast.DropTableStmt:
+		for _, table := range stmt.Tables {
+			objects = append(objects, whole(table))
+		}
+	case *ast.RenameTableStmt:
+		for _, t := range stmt.TableToTables {
+			objects = append(objects, whole(t.OldTable), whole(t.NewTable))
+		}
+	case *ast.CreateIndexStmt:
+		objects = append(objects, &schemaObjectChange{
+			object:   keyOf(stmt.Table),
+			elements: map[string]struct{}{schemaChangeElementKey(SchemaMetaChangeObjectIndex, stmt.IndexName): {}},
+		})
+	case *ast.DropIndexStmt:
+		objects = append(objects, &schemaObjectChange{
+			object:   keyOf(stmt.Table),
+			elements: map[string]struct{}{schemaChangeElementKey(SchemaMetaChangeObjectIndex, stmt.IndexName): {}},
+		})
+	case *ast.CreateViewStmt:
+		objects = append(objects, whole(stmt.ViewName))
+	}
+	return objects
+}
+
+// addElementsChangedByAlterTableSpec adds the keys of the columns, the indexes, the constraints and the table options
+// changed by the spec of ALTER TABLE.
+func addElementsChangedByAlterTableSpec(elements map[string]struct{}, spec *ast.AlterTableSpec) {
+	add := func(object, name string) {
+		elements[schemaChangeElementKey(object, name)] = struct{}{}
+	}
+	addOrAny := func(object, name string) {
+		if name == "" {
+			name = schemaChangeAnyName
+		}
+		add(object, name)
+	}
+	addColumn := func(column *ast.ColumnDef) {
+		add(SchemaMetaChangeObjectColumn, column.Name.Name.O)
+		for _, option := range column.Options {
+			switch option.Tp {
+			case ast.ColumnOptionPrimaryKey:
+				add(SchemaMetaChangeObjectIndex, "PRIMARY")
+			case ast.ColumnOptionUniqKey:
+				// the unique index is named by the database
+				add(SchemaMetaChangeObjectIndex, schemaChangeAnyName)
+			case ast.ColumnOptionCheck:
+				add(SchemaMetaChangeObjectConstraint, schemaChangeAnyName)
+			}
+		}
+	}
+	// the indexes and the constraints on the column are changed with the column
+	addColumnReferences := func() {
+		add(SchemaMetaChangeObjectIndex, schemaChangeAnyName)
+		add(SchemaMetaChangeObjectConstraint, schemaChangeAnyName)
+	}
+
+	switch spec.Tp {
+	case ast.AlterTableAddColumns, ast.AlterTableModifyColumn, ast.AlterTableAlterColumn:
+		for _, column := range spec.NewColumns {
+			addColumn(column)
+		}
+	case ast.AlterTableChangeColumn:
+		add(SchemaMetaChangeObjectColumn, spec.OldColumnName.Name.O)
+		for _, column := range spec.NewColumns {
+			addColumn(column)
+		}
+		addColumnReferences()
+	case ast.AlterTableRenameColumn:
+		add(SchemaMetaChangeObjectColumn, spec.OldColumnName.Name.O)
+		add(SchemaMetaChangeObjectColumn, spec.NewColumnName.Name.O)
+		addColumnReferences()
+	case ast.AlterTableDropColumn:
+		add(SchemaMetaChangeObjectColumn, spec.OldColumnName.Name.O)
+		addColumnReferences()
+	case ast.AlterTableAddConstraint:
+		constraint := spec.Constraint
+		switch constraint.Tp {
+		case ast.ConstraintPrimaryKey:
+			add(SchemaMetaChangeObjectIndex, "PRIMARY")
+		case ast.ConstraintForeignKey:
+			// the index of the foreign key is created if it doesn't exist
+			addOrAny(SchemaMetaChangeObjectConstraint, constraint.Name)
+			addOrAny(SchemaMetaChangeObjectIndex, constraint.Name)
+		case ast.ConstraintCheck:
+			addOrAny(SchemaMetaChangeObjectConstraint, constraint.Name)
+		default:
+			addOrAny(SchemaMetaChangeObjectIndex, constraint.Name)
+		}
+	case ast.AlterTableDropPrimaryKey:
+		add(SchemaMetaChangeObjectIndex, "PRIMARY")
+	case ast.AlterTableDropIndex, ast.AlterTableIndexInvisible:
+		add(SchemaMetaChangeObjectIndex, spec.Name)
+	case ast.AlterTableDropForeignKey, ast.AlterTableDropCheck, ast.AlterTableAlterCheck:
+		add(SchemaMetaChangeObjectConstraint, spec.Name)
+	case ast.AlterTableRenameIndex:
+		add(SchemaMetaChangeObjectIndex, spec.FromKey.O)
+		add(SchemaMetaChangeObjectIndex, spec.ToKey.O)
+	case ast.AlterTableOption:
+		add(SchemaMetaChangeObjectTableOptions, "")
+		for _, option := range spec.Options {
+			if option.Tp == ast.TableOptionCharset || option.Tp == ast.TableOptionCollate {
+				// CONVERT TO CHARACTER SET changes the charset of the columns
+				add(SchemaMetaChangeObjectColumn, schemaChangeAnyName)
+			}
+		}
+	default:
+		// the other specs, such as the partitions, change the table options only
+		add(SchemaMetaChangeObjectTableOptions, "")
+	}
+}
+
+// detectSchemaDrift saves a new snapshot version for every object changed since the last collection and records the
+// drift between the versions, the drift is approved if the object is changed by the DDLs executed through the
+// workflows on the instance. The first collection only saves the baseline.
+func detectSchemaDrift(logger *logrus.Entry, ap *AuditPlan, persist *model.Storage, sqls []*SchemaMetaSQL) {
+	latest, err := persist.GetLatestSchemaMetaSnapshots(ap.ID)
+	if err != nil {
+		logger.Errorf("get latest schema meta snapshots failed, error: %v", err)
+		return
+	}
+	baseline := len(latest) == 0
+	latestIndex := make(map[string]*model.SchemaMetaSnapshot, len(latest))
+	for _, snapshot := range latest {
+		latestIndex[schemaMetaKey(snapshot.SchemaName, snapshot.MetaType, snapshot.MetaName)] = snapshot
+	}
+
+	now := time.Now()
+	snapshots := []*model.SchemaMetaSnapshot{}
+	drifts := []*model.SchemaMetaDrift{}
+	// the previous versions of the drifts, nil if the object is never collected
+	previous := []*model.SchemaMetaSnapshot{}
+	// the structural changes of the drifts, nil if the object is created or dropped
+	changes := [][]*SchemaMetaChange{}
+	newDrift := func(snapshot *model.SchemaMetaSnapshot, changeType string, diff []*SchemaMetaChange) *model.SchemaMetaDrift {
+		drift := &model.SchemaMetaDrift{
+			AuditPlanId: ap.ID,
+			ProjectId:   ap.ProjectId,
+			InstanceId:  ap.InstanceID,
+			SchemaName:  snapshot.SchemaName,
+			MetaType:    snapshot.MetaType,
+			MetaName:    snapshot.MetaName,
+			ChangeType:  changeType,
+			FromVersion: snapshot.Version - 1,
+			ToVersion:   snapshot.Version,
+			DetectedAt:  now,
+		}
+		if len(diff) > 0 {
+			data, err := json.Marshal(diff)
+			if err != nil {
+				logger.Warnf("marshal diff of %v.%v failed, error: %v", snapshot.SchemaName, snapshot.MetaName, err)
+			} else {
+				drift.Diff = data
+			}
+		}
+		return drift
+	}
+
+	collected := map[string]struct{}{}
+	for _, sql := range sqls {
+		key := schemaMetaKey(sql.SchemaName, sql.MetaType, sql.MetaName)
+		collected[key] = struct{}{}
+		content := normalizeSchemaMetaContent(sql.SQLContent)
+		sum := md5.Sum([]byte(content))
+		contentMD5 := hex.EncodeToString(sum[:])
+
+		origin := latestIndex[key]
+		if origin != nil && !origin.Dropped && origin.ContentMD5 == contentMD5 {
+			continue
+		}
+		snapshot := &model.SchemaMetaSnapshot{
+			AuditPlanId: ap.ID,
+			ProjectId:   ap.ProjectId,
+			InstanceId:  ap.InstanceID,
+			SchemaName:  sql.SchemaName,
+			MetaType:    sql.MetaType,
+			MetaName:    sql.MetaName,
+			Version:     1,
+			Content:     content,
+			ContentMD5:  contentMD5,
+		}
+		if origin != nil {
+			snapshot.Version = origin.Version + 1
+		}
+		snapshots = append(snapshots, snapshot)
+		switch {
+		case baseline:
+		case origin == nil || origin.Dropped:
+			drifts = append(drifts, newDrift(snapshot, model.SchemaMetaDriftChangeTypeCreated, nil))
+			previous = append(previous, origin)
+			changes = append(changes, nil)
+		default:
+			diff := diffSchemaMeta(sql.MetaType, origin.Content, content)
+			drifts = append(drifts, newDrift(snapshot, model.SchemaMetaDriftChangeTypeAltered, diff))
+			previous = append(previous, origin)
+			changes = append(changes, diff)
+		}
+	}
+	collectView := ap.Params.GetParam("collect_view").Bool()
+	for key, origin := range latestIndex {
+		if _, ok := collected[key]; ok || origin.Dropped {
+			continue
+		}
+		if origin.MetaType == "view" && !collectView {
+			// the views are not collected rather than dropped
+			continue
+		}
+		snapshot := &model.SchemaMetaSnapshot{
+			AuditPlanId: ap.ID,
+			ProjectId:   ap.ProjectId,
+			InstanceId:  ap.InstanceID,
+			SchemaName:  origin.SchemaName,
+			MetaType:    origin.MetaType,
+			MetaName:    origin.MetaName,
+			Version:     origin.Version + 1,
+			Dropped:     true,
+		}
+		snapshots = append(snapshots, snapshot)
+		drifts = append(drifts, newDrift(snapshot, model.SchemaMetaDriftChangeTypeDropped, nil))
+		previous = append(previous, origin)
+		changes = append(changes, nil)
+	}
+
+	if len(drifts) > 0 {
+		approveSchemaDrifts(logger, ap, persist, drifts, previous, changes)
+	}
+	if err := persist.SaveSchemaMetaSnapshotsAndDrifts(snapshots, drifts); err != nil {
+		logger.Errorf("save schema meta snapshots failed, error: %v", err)
+		return
+	}
+	unapproved := []*model.SchemaMetaDrift{}
+	for _, drift := range drifts {
+		if !drift.Approved {
+			unapproved = append(unapproved, drift)
+		}
+	}
+	if len(unapproved) > 0 {
+		notifySchemaDrift(logger, ap, unapproved)
+	}
+}
+
+// approveSchemaDrifts approves the drifts made by the DDLs executed through the workflows after the previous versions
+// are collected, the last collection time is used if the object is created. The drift is partially approved if only
+// some of its changes are made by the DDLs.
+func approveSchemaDrifts(logger *logrus.Entry, ap *AuditPlan, persist *model.Storage, drifts []*model.SchemaMetaDrift, previous []*model.SchemaMetaSnapshot, changes [][]*SchemaMetaChange) {
+	instanceId, err := strconv.ParseUint(ap.InstanceID, 10, 64)
+	if err != nil {
+		logger.Warnf("parse instance id %v failed, error: %v", ap.InstanceID, err)
+		return
+	}
+	var lastCollectionTime time.Time
+	auditPlan, exist, err := persist.GetAuditPlanByID(int(ap.ID))
+	if err != nil {
+		logger.Errorf("get audit plan failed, error: %v", err)
+		return
+	}
+	if exist && auditPlan.AuditPlanTaskInfo != nil && auditPlan.AuditPlanTaskInfo.LastCollectionTime != nil {
+		lastCollectionTime = *auditPlan.AuditPlanTaskInfo.LastCollectionTime
+	}
+
+	afters := make([]time.Time, len(drifts))
+	earliest := lastCollectionTime
+	for i, origin := range previous {
+		afters[i] = lastCollectionTime
+		if origin != nil {
+			afters[i] = origin.CreatedAt
+		}
+		if afters[i].Before(earliest) {
+			earliest = afters[i]
+		}
+	}
+	executedSQLs, err := persist.GetInstanceExecutedSQLs(instanceId, earliest)
+	if err != nil {
+		logger.Errorf("get executed sqls of instance failed, error: %v", err)
+		return
+	}
+	index := newSchemaChangeIndex(executedSQLs)
+	for i, drift := range drifts {
+		taskIds, covered := index.match(drift, changes[i], afters[i])
+		if len(taskIds) == 0 {
+			continue
+		}
+		ids := make([]string, 0, len(taskIds))
+		for _, id := range taskIds {
+			ids = append(ids, strconv.FormatUint(uint64(id), 10))
+		}
+		drift.Approved = covered
+		drift.PartiallyApproved = !covered
+		drift.MatchedTaskIds = strings.Join(ids, ",")
+	}
+}
+
+func notifySchemaDrift(logger *logrus.Entry, ap *AuditPlan, drifts []*model.SchemaMetaDrift) {
+	if ap.CreateUserID == "" {
+		return
+	}
+	instanceName := ap.InstanceID
+	if ap.Instance != nil {
+		instanceName = ap.Instance.Name
+	}
+	n := notification.NewSchemaMetaDriftNotification(instanceName, drifts)
+	if err := notification.Notify(n, []string{ap.CreateUserID}); err != nil {
+		logger.Errorf("notify schema drift failed, error: %v", err)
+	}
+}
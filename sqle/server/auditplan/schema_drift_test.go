@@ -0,0 +1,112 @@
+package auditplan
+
+import (
+	"testing"
+	"time"
+
+	"github.com/actiontech/sqle/sqle/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffSchemaMeta(t *testing.T) {
+	before := normalizeSchemaMetaContent("CREATE TABLE `t1` (\n" +
+		"  `id` int NOT NULL AUTO_INCREMENT,\n" +
+		"  `name` varchar(32) DEFAULT NULL,\n" +
+		"  `age` int DEFAULT NULL,\n" +
+		"  PRIMARY KEY (`id`),\n" +
+		"  KEY `idx_name` (`name`)\n" +
+		") ENGINE=InnoDB AUTO_INCREMENT=12 DEFAULT CHARSET=utf8mb4")
+	// only the auto increment value is changed
+	assert.Empty(t, diffSchemaMeta("table", before, normalizeSchemaMetaContent("CREATE TABLE `t1` (\n"+
+		"  `id` int NOT NULL AUTO_INCREMENT,\n"+
+		"  `name` varchar(32) DEFAULT NULL,\n"+
+		"  `age` int DEFAULT NULL,\n"+
+		"  PRIMARY KEY (`id`),\n"+
+		"  KEY `idx_name` (`name`)\n"+
+		") ENGINE=InnoDB AUTO_INCREMENT=15 DEFAULT CHARSET=utf8mb4")))
+
+	after := normalizeSchemaMetaContent("CREATE TABLE `t1` (\n" +
+		"  `id` int NOT NULL AUTO_INCREMENT,\n" +
+		"  `name` varchar(64) DEFAULT NULL,\n" +
+		"  `email` varchar(64) DEFAULT NULL,\n" +
+		"  PRIMARY KEY (`id`),\n" +
+		"  UNIQUE KEY `uniq_email` (`email`),\n" +
+		"  CONSTRAINT `fk_1` FOREIGN KEY (`id`) REFERENCES `t2` (`id`)\n" +
+		") ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='user'")
+	assert.Equal(t, []*SchemaMetaChange{
+		{Object: SchemaMetaChangeObjectColumn, Name: "name", Action: SchemaMetaChangeActionModified, Before: "`name` varchar(32) DEFAULT NULL", After: "`name` varchar(64) DEFAULT NULL"},
+		{Object: SchemaMetaChangeObjectColumn, Name: "email", Action: SchemaMetaChangeActionAdded, After: "`email` varchar(64) DEFAULT NULL"},
+		{Object: SchemaMetaChangeObjectIndex, Name: "uniq_email", Action: SchemaMetaChangeActionAdded, After: "UNIQUE KEY `uniq_email` (`email`)"},
+		{Object: SchemaMetaChangeObjectConstraint, Name: "fk_1", Action: SchemaMetaChangeActionAdded, After: "CONSTRAINT `fk_1` FOREIGN KEY (`id`) REFERENCES `t2` (`id`)"},
+		{Object: SchemaMetaChangeObjectTableOptions, Action: SchemaMetaChangeActionModified, Before: "ENGINE=InnoDB DEFAULT CHARSET=utf8mb4", After: "ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COMMENT='user'"},
+		{Object: SchemaMetaChangeObjectColumn, Name: "age", Action: SchemaMetaChangeActionDropped, Before: "`age` int DEFAULT NULL"},
+		{Object: SchemaMetaChangeObjectIndex, Name: "idx_name", Action: SchemaMetaChangeActionDropped, Before: "KEY `idx_name` (`name`)"},
+	}, diffSchemaMeta("table", before, after))
+
+	assert.Equal(t, []*SchemaMetaChange{
+		{Object: SchemaMetaChangeObjectDefinition, Action: SchemaMetaChangeActionModified, Before: "CREATE VIEW `v1` AS select 1", After: "CREATE VIEW `v1` AS select 2"},
+	}, diffSchemaMeta("view", "CREATE VIEW `v1` AS select 1", "CREATE VIEW `v1` AS select 2"))
+}
+
+func TestSchemaChangeIndex(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	index := newSchemaChangeIndex([]*model.InstanceExecutedSQL{
+		{TaskId: 1, Schema: "db1", Content: "ALTER TABLE t1 ADD COLUMN c1 int", ExecutedAt: base},
+		{TaskId: 1, Schema: "db1", Content: "USE db2", ExecutedAt: base},
+		{TaskId: 1, Schema: "db1", Content: "CREATE INDEX idx_1 ON T2(c1)", ExecutedAt: base},
+		{TaskId: 2, Schema: "db1", Content: "RENAME TABLE t3 TO db3.t4", ExecutedAt: base.Add(time.Hour)},
+		{TaskId: 2, Schema: "db1", Content: "INSERT INTO t5 VALUES (1)", ExecutedAt: base.Add(time.Hour)},
+		{TaskId: 3, Schema: "db1", Content: "CREATE VIEW v1 AS SELECT 1", ExecutedAt: base.Add(2 * time.Hour)},
+		{TaskId: 3, Schema: "db1", Content: "not a sql", ExecutedAt: base.Add(2 * time.Hour)},
+		{TaskId: 4, Schema: "db1", Content: "ALTER TABLE t6 DROP COLUMN c1, ADD UNIQUE KEY uniq_c2 (c2), COMMENT 'x'", ExecutedAt: base},
+		{TaskId: 5, Schema: "db1", Content: "DROP DATABASE db4", ExecutedAt: base},
+	})
+	altered := func(schemaName, metaName string) *model.SchemaMetaDrift {
+		return &model.SchemaMetaDrift{SchemaName: schemaName, MetaName: metaName, ChangeType: model.SchemaMetaDriftChangeTypeAltered}
+	}
+	changed := func(object, name string) *SchemaMetaChange {
+		return &SchemaMetaChange{Object: object, Name: name, Action: SchemaMetaChangeActionModified}
+	}
+	created := &model.SchemaMetaDrift{SchemaName: "db3", MetaName: "t4", ChangeType: model.SchemaMetaDriftChangeTypeCreated}
+	dropped := func(schemaName, metaName string) *model.SchemaMetaDrift {
+		return &model.SchemaMetaDrift{SchemaName: schemaName, MetaName: metaName, ChangeType: model.SchemaMetaDriftChangeTypeDropped}
+	}
+
+	cases := []struct {
+		name    string
+		drift   *model.SchemaMetaDrift
+		changes []*SchemaMetaChange
+		after   time.Time
+		taskIds []uint
+		covered bool
+	}{
+		{"the added column", altered("db1", "t1"), []*SchemaMetaChange{changed(SchemaMetaChangeObjectColumn, "C1")}, base, []uint{1}, true},
+		{"the column not added by the task", altered("db1", "t1"),
+			[]*SchemaMetaChange{changed(SchemaMetaChangeObjectColumn, "c1"), changed(SchemaMetaChangeObjectColumn, "c2")}, base, []uint{1}, false},
+		{"the index created in the used schema", altered("DB2", "t2"), []*SchemaMetaChange{changed(SchemaMetaChangeObjectIndex, "idx_1")}, base, []uint{1}, true},
+		{"the renamed table is dropped", dropped("db1", "t3"), nil, base, []uint{2}, true},
+		{"the renamed table is created", created, nil, base, []uint{2}, true},
+		{"the view", altered("db1", "v1"), []*SchemaMetaChange{changed(SchemaMetaChangeObjectDefinition, "")}, base, []uint{3}, true},
+		{"the indexes changed with the dropped column", altered("db1", "t6"), []*SchemaMetaChange{
+			changed(SchemaMetaChangeObjectColumn, "c1"),
+			changed(SchemaMetaChangeObjectIndex, "idx_c1_c3"),
+			changed(SchemaMetaChangeObjectIndex, "uniq_c2"),
+			changed(SchemaMetaChangeObjectTableOptions, ""),
+		}, base, []uint{4}, true},
+		{"the column not dropped by the task", altered("db1", "t6"), []*SchemaMetaChange{changed(SchemaMetaChangeObjectColumn, "c3")}, base, []uint{4}, false},
+		{"the altered table is not dropped", dropped("db1", "t6"), nil, base, []uint{4}, false},
+		{"the table of the dropped schema", dropped("db4", "t1"), nil, base, []uint{5}, true},
+		// the DML doesn't change the schema
+		{"the DML", altered("db1", "t5"), []*SchemaMetaChange{changed(SchemaMetaChangeObjectColumn, "c1")}, base, []uint{}, false},
+		// the task is executed before the previous version is collected
+		{"the task executed before", altered("db1", "t1"), []*SchemaMetaChange{changed(SchemaMetaChangeObjectColumn, "c1")}, base.Add(time.Minute), []uint{}, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			taskIds, covered := index.match(c.drift, c.changes, c.after)
+			assert.Equal(t, c.taskIds, taskIds)
+			assert.Equal(t, c.covered, covered)
+		})
+	}
+}
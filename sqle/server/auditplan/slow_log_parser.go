@@ -0,0 +1,236 @@
+package auditplan
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/actiontech/sqle/sqle/driver/mysql/util"
+	"github.com/sirupsen/logrus"
+)
+
+// slowLogEntry is a SQL in the slow log file of MySQL.
+type slowLogEntry struct {
+	sql                string
+	schema             string
+	user               string
+	host               string
+	executionStartTime time.Time
+	// executionEndTime is the time the entry is written to the slow log, that is the "# Time:" line
+	executionEndTime time.Time
+	// seconds
+	queryTime    float64
+	lockTime     float64
+	rowsExamined int64
+}
+
+var (
+	slowLogUserHostPattern = regexp.MustCompile(`^# User@Host:\s*(\S*?)\[[^\]]*\]\s*@\s*(\S*)\s*\[([^\]]*)\]`)
+	slowLogMetricPattern   = regexp.MustCompile(`(\w+):\s+(\S+)`)
+	slowLogUsePattern      = regexp.MustCompile("(?i)^use\\s+`?([^`;]+)`?;\\s*$")
+	slowLogTimestampRegex  = regexp.MustCompile(`(?i)^SET\s+timestamp\s*=\s*(\d+);\s*$`)
+)
+
+// the time of MySQL 5.7 and later, and the time of MySQL 5.6 and earlier
+var slowLogTimeLayouts = []string{time.RFC3339Nano, "060102 15:04:05", "060102  15:04:05"}
+
+// parseMySQLSlowLog parses the slow log file of MySQL, which is the format of the slow_query_log_file. The schema
+// changed by the USE statement is kept for the following SQLs, and the SQLs without the Query_time are skipped.
+func parseMySQLSlowLog(r io.Reader) ([]*slowLogEntry, error) {
+	entries := []*slowLogEntry{}
+	schema := ""
+	// MySQL 5.6 and earlier omit the "# Time:" line of the entry written in the same second as the previous one
+	lastTime := time.Time{}
+	current := &slowLogEntry{}
+	hasHeader := false
+	sqlLines := []string{}
+
+	flush := func() {
+		sql := strings.TrimSpace(strings.Join(sqlLines, "\n"))
+		if hasHeader && sql != "" {
+			current.sql = sql
+			current.schema = schema
+			if current.executionEndTime.IsZero() {
+				current.executionEndTime = lastTime
+			}
+			if current.executionEndTime.IsZero() && !current.executionStartTime.IsZero() {
+				current.executionEndTime = current.executionStartTime.Add(time.Duration(current.queryTime * float64(time.Second)))
+			}
+			if current.executionStartTime.IsZero() {
+				current.executionStartTime = current.executionEndTime
+			}
+			entries = append(entries, current)
+		}
+		current = &slowLogEntry{}
+		hasHeader = false
+		sqlLines = []string{}
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		switch {
+		case strings.HasPrefix(line, "# Time:"):
+			flush()
+			value := strings.TrimSpace(strings.TrimPrefix(line, "# Time:"))
+			for _, layout := range slowLogTimeLayouts {
+				if t, err := time.Parse(layout, value); err == nil {
+					current.executionEndTime = t
+					lastTime = t
+					break
+				}
+			}
+		case strings.HasPrefix(line, "# User@Host:"):
+			if len(sqlLines) > 0 {
+				flush()
+			}
+			if matches := slowLogUserHostPattern.FindStringSubmatch(line); len(matches) == 4 {
+				current.user = matches[1]
+				current.host = matches[3]
+				if current.host == "" {
+					current.host = matches[2]
+				}
+			}
+		case strings.HasPrefix(line, "# Query_time:"):
+			if len(sqlLines) > 0 {
+				flush()
+			}
+			hasHeader = true
+			for _, matches := range slowLogMetricPattern.FindAllStringSubmatch(line, -1) {
+				switch matches[1] {
+				case "Query_time":
+					current.queryTime, _ = strconv.ParseFloat(matches[2], 64)
+				case "Lock_time":
+					current.lockTime, _ = strconv.ParseFloat(matches[2], 64)
+				case "Rows_examined":
+					current.rowsExamined, _ = strconv.ParseInt(matches[2], 10, 64)
+				}
+			}
+		case strings.HasPrefix(line, "#"):
+			// the other comments, e.g. "# administrator command: Quit;"
+		case len(sqlLines) == 0 && slowLogUsePattern.MatchString(line):
+			schema = slowLogUsePattern.FindStringSubmatch(line)[1]
+		case len(sqlLines) == 0 && slowLogTimestampRegex.MatchString(line):
+			if ts, err := strconv.ParseInt(slowLogTimestampRegex.FindStringSubmatch(line)[1], 10, 64); err == nil {
+				current.executionStartTime = time.Unix(ts, 0).UTC()
+			}
+		case !hasHeader:
+			// the header of the file, e.g. "/usr/sbin/mysqld, Version: 8.0.32 (Source distribution). started with:"
+		default:
+			sqlLines = append(sqlLines, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+	return entries, nil
+}
+
+// slowLogWatermark is the end time of the last collected slow log entry. The entries are collected by the end time
+// rather than the start time, since the slow query started before the watermark may be written after it. The end
+// time of MySQL 5.6 and earlier is only precise to the second, so the entries ending at the watermark are collected
+// again and de-duplicated by the entries collected at the watermark.
+type slowLogWatermark struct {
+	time *time.Time
+	// keys is the entries ending at the watermark
+	keys map[string]struct{}
+}
+
+func (w *slowLogWatermark) isFirst() bool {
+	return w.time == nil
+}
+
+func slowLogEntryKey(entry *slowLogEntry) string {
+	return fmt.Sprintf("%d|%s|%s|%s|%v|%s", entry.executionEndTime.UnixNano(), entry.user, entry.host, entry.schema, entry.queryTime, entry.sql)
+}
+
+// collect returns the entries not collected ending since the watermark or the since time if it is the first
+// collection, and advances the watermark. The duplicate entries in the overlapped slow log files are collected once.
+func (w *slowLogWatermark) collect(entries []*slowLogEntry, since time.Time) []*slowLogEntry {
+	if w.time != nil {
+		since = *w.time
+	}
+	collected := map[string]struct{}{}
+	for key := range w.keys {
+		collected[key] = struct{}{}
+	}
+	watermark := since
+	res := make([]*slowLogEntry, 0, len(entries))
+	for _, entry := range entries {
+		if entry.executionEndTime.Before(since) {
+			continue
+		}
+		key := slowLogEntryKey(entry)
+		if _, ok := collected[key]; ok {
+			continue
+		}
+		collected[key] = struct{}{}
+		res = append(res, entry)
+		if entry.executionEndTime.After(watermark) {
+			watermark = entry.executionEndTime
+		}
+	}
+
+	keys := map[string]struct{}{}
+	if w.time != nil && watermark.Equal(*w.time) {
+		keys = w.keys
+	}
+	for _, entry := range res {
+		if entry.executionEndTime.Equal(watermark) {
+			keys[slowLogEntryKey(entry)] = struct{}{}
+		}
+	}
+	w.time, w.keys = &watermark, keys
+	return res
+}
+
+// convertSlowLogEntriesToSQLs converts the entries to the SQLs and aggregates them by the handler.
+func convertSlowLogEntriesToSQLs(logger *logrus.Entry, ap *AuditPlan, handler AuditPlanHandler, entries []*slowLogEntry) []*SQLV2 {
+	cache := NewSQLV2Cache()
+	for _, entry := range entries {
+		sqlV2 := &SQLV2{
+			Source:      ap.Type,
+			SourceId:    strconv.FormatUint(uint64(ap.InstanceAuditPlanId), 10),
+			AuditPlanId: strconv.FormatUint(uint64(ap.ID), 10),
+			ProjectId:   ap.ProjectId,
+			InstanceID:  ap.InstanceID,
+			SchemaName:  entry.schema,
+			SQLContent:  entry.sql,
+		}
+		fp, err := util.Fingerprint(entry.sql, true)
+		if err != nil {
+			logger.Warnf("get sql finger print failed, err: %v, sql: %s", err, entry.sql)
+			fp = entry.sql
+		} else if fp == "" {
+			logger.Warn("get sql finger print failed, fp is empty")
+			fp = entry.sql
+		}
+		sqlV2.Fingerprint = fp
+
+		info := NewMetrics()
+		info.SetInt(MetricNameCounter, 1)
+		info.SetString(MetricNameLastReceiveTimestamp, time.Now().Format(time.RFC3339))
+		if entry.user != "" {
+			info.SetString(MetricNameDBUser, entry.user)
+		}
+		if entry.host != "" {
+			info.SetStringArray(MetricNameEndpoints, []string{entry.host})
+		}
+		setSlowLogMetrics(info, entry.queryTime, entry.lockTime, entry.rowsExamined)
+
+		sqlV2.Info = info
+		sqlV2.GenSQLId()
+		if err = handler.AggregateSQL(cache, sqlV2); err != nil {
+			logger.Warnf("aggregate sql failed, error: %v", err)
+			continue
+		}
+		mergeSQLApplication(cache, sqlV2)
+	}
+	return cache.GetSQLs()
+}
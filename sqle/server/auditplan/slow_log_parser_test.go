@@ -0,0 +1,167 @@
+package auditplan
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/actiontech/sqle/sqle/log"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testMySQL57SlowLog = `/rdsdbbin/mysql/bin/mysqld, Version: 8.0.32 (Source distribution). started with:
+Tcp port: 3306  Unix socket: /tmp/mysql.sock
+Time                 Id Command    Argument
+# Time: 2024-05-10T08:00:01.123456Z
+# User@Host: app[app] @  [10.0.0.1]  Id:    10
+# Query_time: 2.500000  Lock_time: 0.000100 Rows_sent: 1  Rows_examined: 1000
+use db1;
+SET timestamp=1715328001;
+SELECT * FROM t1
+WHERE a = 1;
+# Time: 2024-05-10T08:00:05.000000Z
+# User@Host: root[root] @ localhost []  Id:    11
+# Query_time: 1.000000  Lock_time: 0.000000 Rows_sent: 0  Rows_examined: 20
+SET timestamp=1715328005;
+UPDATE t2 SET b = 2 WHERE id = 3;
+# administrator command: Quit;
+`
+
+const testMySQL56SlowLog = `# Time: 240510  8:00:10
+# User@Host: app[app] @ app-host [10.0.0.2]
+# Query_time: 3.000000  Lock_time: 0.000000 Rows_sent: 1  Rows_examined: 50
+use ` + "`db2`" + `;
+SET timestamp=1715328010;
+SELECT 1;
+`
+
+func TestParseMySQLSlowLog(t *testing.T) {
+	entries, err := parseMySQLSlowLog(strings.NewReader(testMySQL57SlowLog + testMySQL56SlowLog))
+	assert.NoError(t, err)
+	assert.Len(t, entries, 3)
+
+	assert.Equal(t, "SELECT * FROM t1\nWHERE a = 1;", entries[0].sql)
+	assert.Equal(t, "db1", entries[0].schema)
+	assert.Equal(t, "app", entries[0].user)
+	assert.Equal(t, "10.0.0.1", entries[0].host)
+	assert.Equal(t, 2.5, entries[0].queryTime)
+	assert.Equal(t, 0.0001, entries[0].lockTime)
+	assert.Equal(t, int64(1000), entries[0].rowsExamined)
+	assert.Equal(t, time.Unix(1715328001, 0).UTC(), entries[0].executionStartTime)
+	assert.Equal(t, time.Date(2024, 5, 10, 8, 0, 1, 123456000, time.UTC), entries[0].executionEndTime)
+
+	// the schema is kept for the following SQLs
+	assert.Equal(t, "UPDATE t2 SET b = 2 WHERE id = 3;", entries[1].sql)
+	assert.Equal(t, "db1", entries[1].schema)
+	assert.Equal(t, "root", entries[1].user)
+	assert.Equal(t, "localhost", entries[1].host)
+
+	assert.Equal(t, "SELECT 1;", entries[2].sql)
+	assert.Equal(t, "db2", entries[2].schema)
+	assert.Equal(t, "10.0.0.2", entries[2].host)
+	assert.Equal(t, int64(50), entries[2].rowsExamined)
+	assert.Equal(t, time.Date(2024, 5, 10, 8, 0, 10, 0, time.UTC), entries[2].executionEndTime)
+}
+
+func TestParseMySQLSlowLogWithoutTimestamp(t *testing.T) {
+	entries, err := parseMySQLSlowLog(strings.NewReader(`# Time: 240510  8:00:10
+# Query_time: 3.000000  Lock_time: 0.000000 Rows_sent: 1  Rows_examined: 50
+SELECT 1;
+# Time: 2024-05-10T08:00:11.000000Z
+# Query_time: 1.000000  Lock_time: 0.000000 Rows_sent: 1  Rows_examined: 1
+SELECT 2;
+`))
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, time.Date(2024, 5, 10, 8, 0, 10, 0, time.UTC), entries[0].executionStartTime)
+	assert.Equal(t, time.Date(2024, 5, 10, 8, 0, 11, 0, time.UTC), entries[1].executionStartTime)
+}
+
+func TestParseMySQLSlowLogWithoutTimeLine(t *testing.T) {
+	// MySQL 5.6 omits the "# Time:" line of the entry written in the same second as the previous one
+	entries, err := parseMySQLSlowLog(strings.NewReader(`# Time: 240510  8:00:10
+# Query_time: 3.000000  Lock_time: 0.000000 Rows_sent: 1  Rows_examined: 50
+SET timestamp=1715328007;
+SELECT 1;
+# Query_time: 1.000000  Lock_time: 0.000000 Rows_sent: 1  Rows_examined: 1
+SET timestamp=1715328009;
+SELECT 2;
+`))
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+	assert.Equal(t, time.Date(2024, 5, 10, 8, 0, 10, 0, time.UTC), entries[1].executionEndTime)
+	assert.Equal(t, time.Unix(1715328009, 0).UTC(), entries[1].executionStartTime)
+}
+
+func TestSlowLogWatermark(t *testing.T) {
+	base := time.Date(2024, 5, 10, 8, 0, 0, 0, time.UTC)
+	newEntry := func(sql string, start, end time.Time) *slowLogEntry {
+		return &slowLogEntry{sql: sql, executionStartTime: start, executionEndTime: end}
+	}
+	w := slowLogWatermark{}
+	assert.True(t, w.isFirst())
+
+	entries := w.collect([]*slowLogEntry{
+		newEntry("SELECT 0", base.Add(-2*time.Hour), base.Add(-2*time.Hour)),
+		newEntry("SELECT 1", base, base.Add(time.Second)),
+		newEntry("SELECT 2", base, base.Add(10*time.Second)),
+	}, base.Add(-time.Hour))
+	assert.Len(t, entries, 2)
+	assert.Equal(t, base.Add(10*time.Second), *w.time)
+
+	// the slow log file is collected again with the new entries
+	entries = w.collect([]*slowLogEntry{
+		newEntry("SELECT 1", base, base.Add(time.Second)),
+		newEntry("SELECT 2", base, base.Add(10*time.Second)),
+		// the entry ending in the same second as the watermark
+		newEntry("SELECT 3", base.Add(9*time.Second), base.Add(10*time.Second)),
+		// the slow query started before the watermark and finished after it
+		newEntry("SELECT 4", base.Add(5*time.Second), base.Add(20*time.Second)),
+		newEntry("SELECT 4", base.Add(5*time.Second), base.Add(20*time.Second)),
+	}, base.Add(-time.Hour))
+	assert.Len(t, entries, 2)
+	assert.Equal(t, "SELECT 3", entries[0].sql)
+	assert.Equal(t, "SELECT 4", entries[1].sql)
+	assert.Equal(t, base.Add(20*time.Second), *w.time)
+
+	entries = w.collect([]*slowLogEntry{
+		newEntry("SELECT 4", base.Add(5*time.Second), base.Add(20*time.Second)),
+		newEntry("SELECT 5", base.Add(19*time.Second), base.Add(20*time.Second)),
+	}, base.Add(-time.Hour))
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "SELECT 5", entries[0].sql)
+	assert.Len(t, w.keys, 2)
+
+	assert.Len(t, w.collect(nil, base.Add(-time.Hour)), 0)
+	assert.Equal(t, base.Add(20*time.Second), *w.time)
+}
+
+func TestConvertSlowLogEntriesToSQLs(t *testing.T) {
+	newEntry := func(host string) *slowLogEntry {
+		return &slowLogEntry{sql: "SELECT * FROM t1 WHERE id = 1", schema: "db1", user: "app", host: host, queryTime: 1, rowsExamined: 10}
+	}
+	ap := &AuditPlan{ID: 1, InstanceAuditPlanId: 1, Type: TypeAwsRdsMySQLSlowLog}
+	sqls := convertSlowLogEntriesToSQLs(log.NewEntry(), ap, &MySQLSlowLogAwsTaskV2{}, []*slowLogEntry{
+		newEntry("10.0.0.1"), newEntry("10.0.0.2"), newEntry("10.0.0.1"), newEntry(""),
+	})
+	assert.Len(t, sqls, 1)
+	assert.Equal(t, int64(4), sqls[0].Info.Get(MetricNameCounter).Int())
+	// the endpoints are used to attribute the SQL to the applications
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, sqls[0].Info.Get(MetricNameEndpoints).StringArray())
+}
+
+// newTestSlowLog generates the slow log of the SQLs finished at the times, the SQLs take 2 seconds.
+func newTestSlowLog(sql string, times ...time.Time) string {
+	buf := strings.Builder{}
+	for _, t := range times {
+		fmt.Fprintf(&buf, "# Time: %s\n", t.UTC().Format(time.RFC3339Nano))
+		buf.WriteString("# User@Host: app[app] @  [10.0.0.1]  Id:    10\n")
+		buf.WriteString("# Query_time: 2.000000  Lock_time: 0.000000 Rows_sent: 1  Rows_examined: 100\n")
+		buf.WriteString("use db1;\n")
+		fmt.Fprintf(&buf, "SET timestamp=%d;\n", t.Add(-2*time.Second).Unix())
+		buf.WriteString(sql + "\n")
+	}
+	return buf.String()
+}
@@ -0,0 +1,225 @@
+package auditplan
+
+import (
+	"encoding/json"
+	"net"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/actiontech/sqle/sqle/model"
+	"github.com/actiontech/sqle/sqle/utils"
+)
+
+// sqlApplicationMetrics are the application identity of the SQLs.
+var sqlApplicationMetrics = []string{MetricNameApplication, MetricNameRoute}
+
+// sqlAttributionMetrics are kept for all audit plan types to attribute the SQLs to the applications.
+var sqlAttributionMetrics = append([]string{MetricNameEndpoints}, sqlApplicationMetrics...)
+
+// the tag keys of the SQL comments naming the application and the route, the former is preferred
+var (
+	sqlApplicationTagKeys = []string{"application", "app", "service", "service_name"}
+	sqlRouteTagKeys       = []string{"route", "controller"}
+)
+
+var sqlCommentRegexp = regexp.MustCompile(`(?s)/\*(.*?)\*/`)
+
+// ParseSQLCommentTags parses the key='value' tags of the comments in the SQL, which is the format of sqlcommenter,
+// e.g. /*application='order',route='%2Forders'*/. The keys and values are URL decoded, the comments which are not
+// composed of tags are ignored.
+func ParseSQLCommentTags(sql string) map[string]string {
+	tags := map[string]string{}
+	for _, match := range sqlCommentRegexp.FindAllStringSubmatch(sql, -1) {
+		commentTags, ok := parseSQLCommentTags(strings.TrimSpace(match[1]))
+		if !ok {
+			continue
+		}
+		for k, v := range commentTags {
+			tags[k] = v
+		}
+	}
+	return tags
+}
+
+func parseSQLCommentTags(comment string) (map[string]string, bool) {
+	if comment == "" {
+		return nil, false
+	}
+	tags := map[string]string{}
+	for comment != "" {
+		eq := strings.Index(comment, "=")
+		if eq <= 0 {
+			return nil, false
+		}
+		key, err := url.QueryUnescape(strings.TrimSpace(comment[:eq]))
+		if err != nil || key == "" {
+			return nil, false
+		}
+		rest := strings.TrimSpace(comment[eq+1:])
+		if !strings.HasPrefix(rest, "'") {
+			return nil, false
+		}
+		// the quote in the value is escaped by backslash
+		end := -1
+		for i := 1; i < len(rest); i++ {
+			if rest[i] == '\\' {
+				i++
+				continue
+			}
+			if rest[i] == '\'' {
+				end = i
+				break
+			}
+		}
+		if end < 0 {
+			return nil, false
+		}
+		value, err := url.QueryUnescape(strings.ReplaceAll(rest[1:end], `\'`, "'"))
+		if err != nil {
+			return nil, false
+		}
+		tags[strings.ToLower(key)] = value
+
+		comment = strings.TrimSpace(rest[end+1:])
+		if comment == "" {
+			break
+		}
+		if !strings.HasPrefix(comment, ",") {
+			return nil, false
+		}
+		comment = strings.TrimSpace(comment[1:])
+	}
+	return tags, true
+}
+
+func firstSQLCommentTag(tags map[string]string, keys []string) string {
+	for _, key := range keys {
+		if v := tags[key]; v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+type sqlApplicationMapping struct {
+	application string
+	ip          net.IP
+	cidr        *net.IPNet
+	host        *regexp.Regexp
+}
+
+// sqlApplicationMapper maps the client endpoints of the SQLs to the applications by the mappings of the project.
+type sqlApplicationMapper struct {
+	mappings []*sqlApplicationMapping
+}
+
+func newSQLApplicationMapper(mappings []*model.SQLManageApplicationMapping) *sqlApplicationMapper {
+	m := &sqlApplicationMapper{}
+	for _, mapping := range mappings {
+		entry := &sqlApplicationMapping{application: mapping.Application}
+		if ip := net.ParseIP(mapping.Endpoint); ip != nil {
+			entry.ip = ip
+		} else if _, cidr, err := net.ParseCIDR(mapping.Endpoint); err == nil {
+			entry.cidr = cidr
+		} else {
+			entry.host = utils.FullFuzzySearchRegexp(mapping.Endpoint)
+		}
+		m.mappings = append(m.mappings, entry)
+	}
+	return m
+}
+
+// match returns the application of the first mapping matched by any of the endpoints.
+func (m *sqlApplicationMapper) match(endpoints []string) string {
+	for _, mapping := range m.mappings {
+		for _, endpoint := range endpoints {
+			host := endpoint
+			if h, _, err := net.SplitHostPort(endpoint); err == nil {
+				host = h
+			}
+			ip := net.ParseIP(host)
+			switch {
+			case mapping.ip != nil:
+				if ip != nil && mapping.ip.Equal(ip) {
+					return mapping.application
+				}
+			case mapping.cidr != nil:
+				if ip != nil && mapping.cidr.Contains(ip) {
+					return mapping.application
+				}
+			default:
+				if mapping.host.MatchString(host) {
+					return mapping.application
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// attributeSQLApplication sets the application and the route of the SQL by the tags of the SQL comments, the
+// application is mapped from the endpoints if it is not tagged.
+func attributeSQLApplication(sql *model.SQLManageQueue, mapper *sqlApplicationMapper) error {
+	info, err := sql.Info.OriginValue()
+	if err != nil {
+		return err
+	}
+	tags := ParseSQLCommentTags(sql.SqlText)
+	application := firstSQLCommentTag(tags, sqlApplicationTagKeys)
+	route := firstSQLCommentTag(tags, sqlRouteTagKeys)
+	if application == "" && mapper != nil {
+		metrics := LoadMetrics(info, []string{MetricNameEndpoints})
+		application = mapper.match(metrics.Get(MetricNameEndpoints).StringArray())
+	}
+	if application == "" && route == "" {
+		return nil
+	}
+	if application != "" {
+		info[MetricNameApplication] = application
+	}
+	if route != "" {
+		info[MetricNameRoute] = route
+	}
+	data, err := json.Marshal(info)
+	if err != nil {
+		return err
+	}
+	sql.Info = data
+	return nil
+}
+
+func (at *TaskWrapper) attributeSQLsApplication(sqls []*model.SQLManageQueue, ap *AuditPlan) {
+	mappings, err := at.persist.GetSQLManageApplicationMappings(ap.ProjectId)
+	if err != nil {
+		at.logger.Errorf("get application mappings failed, error: %v", err)
+	}
+	mapper := newSQLApplicationMapper(mappings)
+	for _, sql := range sqls {
+		if err := attributeSQLApplication(sql, mapper); err != nil {
+			at.logger.Warnf("attribute application of sql %v failed, error: %v", sql.SQLID, err)
+		}
+	}
+}
+
+// mergeSQLApplication keeps the latest application and route of the SQL collected, and all the endpoints of the SQL.
+func mergeSQLApplication(cache SQLV2Cacher, sql *SQLV2) {
+	originSQL, exist, err := cache.GetSQL(sql.SQLId)
+	if err != nil || !exist || originSQL == sql {
+		return
+	}
+	for _, name := range sqlApplicationMetrics {
+		if v := sql.Info.Get(name).String(); v != "" {
+			originSQL.Info.SetString(name, v)
+		}
+	}
+	endpoints := originSQL.Info.Get(MetricNameEndpoints).StringArray()
+	for _, endpoint := range sql.Info.Get(MetricNameEndpoints).StringArray() {
+		if !utils.StringsContains(endpoints, endpoint) {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	if len(endpoints) > 0 {
+		originSQL.Info.SetStringArray(MetricNameEndpoints, endpoints)
+	}
+}
@@ -0,0 +1,79 @@
+package auditplan
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/actiontech/sqle/sqle/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSQLCommentTags(t *testing.T) {
+	cases := []struct {
+		sql      string
+		expected map[string]string
+	}{
+		{
+			sql:      "SELECT * FROM t1 /*application='order-service',route='%2Forders%2F%7Bid%7D'*/",
+			expected: map[string]string{"application": "order-service", "route": "/orders/{id}"},
+		},
+		{
+			sql:      "/* App='billing' , controller='invoice\\'s' */ SELECT 1",
+			expected: map[string]string{"app": "billing", "controller": "invoice's"},
+		},
+		{
+			// the comments which are not tags and the optimizer hints are ignored
+			sql:      "SELECT /*+ MAX_EXECUTION_TIME(1000) */ * FROM t1 /* just a comment */",
+			expected: map[string]string{},
+		},
+		{
+			sql:      "SELECT * FROM t1 /*application='a',route*/",
+			expected: map[string]string{},
+		},
+		{
+			sql:      "SELECT * FROM t1",
+			expected: map[string]string{},
+		},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.expected, ParseSQLCommentTags(c.sql), c.sql)
+	}
+}
+
+func TestAttributeSQLApplication(t *testing.T) {
+	mapper := newSQLApplicationMapper([]*model.SQLManageApplicationMapping{
+		{Endpoint: "10.0.0.1", Application: "ip-app"},
+		{Endpoint: "10.1.0.0/16", Application: "cidr-app"},
+		{Endpoint: "report", Application: "host-app"},
+	})
+	assert.Equal(t, "ip-app", mapper.match([]string{"10.0.0.1"}))
+	assert.Equal(t, "cidr-app", mapper.match([]string{"192.168.0.1", "10.1.2.3:3306"}))
+	assert.Equal(t, "host-app", mapper.match([]string{"report-01.example.com"}))
+	assert.Equal(t, "", mapper.match([]string{"192.168.0.1"}))
+
+	newQueue := func(sql string, endpoints []string) *model.SQLManageQueue {
+		info, err := json.Marshal(map[string]interface{}{MetricNameEndpoints: endpoints})
+		assert.NoError(t, err)
+		return &model.SQLManageQueue{SqlText: sql, Info: info}
+	}
+	attributed := func(sql *model.SQLManageQueue) Metrics {
+		info, err := sql.Info.OriginValue()
+		assert.NoError(t, err)
+		return LoadMetrics(info, sqlApplicationMetrics)
+	}
+
+	// the tagged application is preferred
+	sql := newQueue("SELECT 1 /*application='tagged',route='%2Fa'*/", []string{"10.0.0.1"})
+	assert.NoError(t, attributeSQLApplication(sql, mapper))
+	assert.Equal(t, "tagged", attributed(sql).Get(MetricNameApplication).String())
+	assert.Equal(t, "/a", attributed(sql).Get(MetricNameRoute).String())
+
+	sql = newQueue("SELECT 1", []string{"10.1.0.5"})
+	assert.NoError(t, attributeSQLApplication(sql, mapper))
+	assert.Equal(t, "cidr-app", attributed(sql).Get(MetricNameApplication).String())
+
+	sql = newQueue("SELECT 1", []string{"172.16.0.1"})
+	assert.NoError(t, attributeSQLApplication(sql, mapper))
+	assert.Nil(t, attributed(sql).Get(MetricNameApplication))
+}
@@ -0,0 +1,309 @@
+package auditplan
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/actiontech/sqle/sqle/driver/mysql/util"
+	"github.com/actiontech/sqle/sqle/model"
+	"github.com/actiontech/sqle/sqle/server"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+const sqlManageLifecycleBatchSize = 1000
+
+// SQLManageLifecycleJob assigns the unassigned SQLs by the assignment rules of the project, and marks the SQLs
+// not collected for the days configured as solved.
+type SQLManageLifecycleJob struct {
+	server.BaseJob
+}
+
+func NewSQLManageLifecycleJob(entry *logrus.Entry) server.ServerJob {
+	entry = entry.WithField("job", "sql_manage_lifecycle")
+	j := &SQLManageLifecycleJob{}
+	j.BaseJob = *server.NewBaseJob(entry, 5*time.Minute, j.HandleLifecycle)
+	return j
+}
+
+func (j *SQLManageLifecycleJob) HandleLifecycle(entry *logrus.Entry) {
+	s := model.GetStorage()
+	projectIds, err := s.GetProjectIdsWithSQLManageAssignmentRules()
+	if err != nil {
+		entry.Errorf("get projects with assignment rules failed: %v", err)
+	}
+	for _, projectId := range projectIds {
+		if err := assignSQLManageRecords(entry, s, projectId); err != nil {
+			entry.Errorf("assign sqls of project %v failed: %v", projectId, err)
+		}
+	}
+
+	configs, err := s.GetSQLManageLifecycleConfigs()
+	if err != nil {
+		entry.Errorf("get sql manage lifecycle configs failed: %v", err)
+		return
+	}
+	for _, config := range configs {
+		if config.AutoSolveUnseenDays == 0 {
+			continue
+		}
+		if err := autoSolveUnseenSQLManageRecords(entry, s, config, time.Now()); err != nil {
+			entry.Errorf("auto solve sqls of project %v failed: %v", config.ProjectId, err)
+		}
+	}
+}
+
+func assignSQLManageRecords(entry *logrus.Entry, s *model.Storage, projectId string) error {
+	rules, err := s.GetSQLManageAssignmentRules(projectId)
+	if err != nil || len(rules) == 0 {
+		return err
+	}
+	// the SQLs matching no rule are kept unassigned, page by the id to reach the SQLs after them
+	var lastId uint
+	for {
+		records, err := s.GetUnassignedSQLManageRecords(projectId, lastId, sqlManageLifecycleBatchSize)
+		if err != nil {
+			return err
+		}
+		for _, record := range records {
+			lastId = record.ID
+			rule := matchSQLManageAssignmentRule(rules, record)
+			if rule == nil {
+				continue
+			}
+			activity := &model.SQLManageRecordActivity{
+				SQLManageRecordID: record.ID,
+				Action:            model.SQLManageActivityActionAssigned,
+				Operator:          model.SQLManageActivityOperatorSystem,
+				FromAssignees:     record.SQLManager.Assignees,
+				ToAssignees:       rule.Assignees,
+				Detail:            fmt.Sprintf("matched assignment rule: %v %v", rule.MatchType, rule.Pattern),
+			}
+			if err := s.UpdateSQLManageRecordProcessWithActivity(activity, map[string]interface{}{"assignees": rule.Assignees}); err != nil {
+				entry.Errorf("assign sql %v failed: %v", record.SQLID, err)
+			}
+		}
+		if len(records) < sqlManageLifecycleBatchSize {
+			return nil
+		}
+	}
+}
+
+func autoSolveUnseenSQLManageRecords(entry *logrus.Entry, s *model.Storage, config *model.SQLManageLifecycleConfig, now time.Time) error {
+	since := now.AddDate(0, 0, -int(config.AutoSolveUnseenDays))
+	records, err := s.GetSQLManageRecordsUnseenSince(config.ProjectId, since, sqlManageLifecycleBatchSize)
+	if err != nil {
+		return err
+	}
+	for _, record := range records {
+		activity := &model.SQLManageRecordActivity{
+			SQLManageRecordID: record.ID,
+			Action:            model.SQLManageActivityActionAutoSolved,
+			Operator:          model.SQLManageActivityOperatorSystem,
+			FromStatus:        string(record.SQLManager.Status),
+			ToStatus:          model.ProcessStatusSolved,
+			Detail:            fmt.Sprintf("not collected since %v", sqlManageRecordLastSeen(record)),
+		}
+		if err := s.UpdateSQLManageRecordProcessWithActivity(activity, map[string]interface{}{"status": model.ProcessStatusSolved}); err != nil {
+			entry.Errorf("auto solve sql %v failed: %v", record.SQLID, err)
+		}
+	}
+	return nil
+}
+
+// sqlManageRecordLastSeen returns the time the SQL is last collected, the update time is returned if the
+// info has no last_receive_timestamp.
+func sqlManageRecordLastSeen(record *model.SQLManageRecord) string {
+	info, err := record.Info.OriginValue()
+	if err == nil {
+		if lastSeen, ok := info[MetricNameLastReceiveTimestamp].(string); ok && lastSeen != "" {
+			return lastSeen
+		}
+	}
+	return record.UpdatedAt.Format(time.RFC3339)
+}
+
+// matchSQLManageAssignmentRule returns the first rule matched by the SQL.
+func matchSQLManageAssignmentRule(rules []*model.SQLManageAssignmentRule, record *model.SQLManageRecord) *model.SQLManageAssignmentRule {
+	var tables []string
+	var endpoints []string
+	var parsed bool
+	for _, rule := range rules {
+		var candidates []string
+		switch rule.MatchType {
+		case model.AssignmentMatchTypeSchema:
+			candidates = []string{record.SchemaName}
+		case model.AssignmentMatchTypeTable:
+			if !parsed {
+				tables, endpoints = sqlManageRecordTables(record), sqlManageRecordEndpoints(record)
+				parsed = true
+			}
+			candidates = tables
+		case model.AssignmentMatchTypeEndpoint:
+			if !parsed {
+				tables, endpoints = sqlManageRecordTables(record), sqlManageRecordEndpoints(record)
+				parsed = true
+			}
+			candidates = endpoints
+		}
+		for _, candidate := range candidates {
+			if matchAssignmentPattern(rule.Pattern, candidate) {
+				return rule
+			}
+		}
+	}
+	return nil
+}
+
+func matchAssignmentPattern(pattern, value string) bool {
+	if value == "" {
+		return false
+	}
+	matched, err := path.Match(strings.ToLower(pattern), strings.ToLower(value))
+	return err == nil && matched
+}
+
+// sqlManageRecordTables returns the tables of the SQL both with and without the schema, only the SQL which can be
+// parsed by the MySQL parser is supported.
+func sqlManageRecordTables(record *model.SQLManageRecord) []string {
+	stmt, err := util.ParseOneSql(record.SqlText)
+	if err != nil {
+		return nil
+	}
+	extractor := &util.TableNameListExtractor{}
+	stmt.Accept(extractor)
+	tables := []string{}
+	for _, table := range extractor.TableNames {
+		schema := table.Schema.O
+		if schema == "" {
+			schema = record.SchemaName
+		}
+		tables = append(tables, table.Name.O)
+		if schema != "" {
+			tables = append(tables, schema+"."+table.Name.O)
+		}
+	}
+	return tables
+}
+
+func sqlManageRecordEndpoints(record *model.SQLManageRecord) []string {
+	info, err := record.Info.OriginValue()
+	if err != nil {
+		return nil
+	}
+	endpoints := []string{}
+	if values, ok := info[MetricNameEndpoints].([]interface{}); ok {
+		for _, v := range values {
+			endpoints = append(endpoints, fmt.Sprintf("%v", v))
+		}
+	}
+	return endpoints
+}
+
+// sqlManageReopenMetrics are the metrics compared when the closed SQL is collected again.
+var sqlManageReopenMetrics = []string{MetricNameQueryTimeAvg, MetricNameRowExaminedAvg}
+
+// sqlManageReopenReason returns the reason to reopen the solved or ignored SQL collected again, empty if it
+// should be kept closed. The SQL is reopened if any metric collected exceeds the factor times of the metric
+// before it was closed. The solved SQL without the metrics to compare is reopened since it appears again,
+// while the ignored one is kept ignored.
+func sqlManageReopenReason(record *model.SQLManageRecord, collected []Metrics, factor float64) (string, error) {
+	info, err := record.Info.OriginValue()
+	if err != nil {
+		return "", err
+	}
+	if factor <= 0 {
+		factor = 1
+	}
+	origin := LoadMetrics(info, sqlManageReopenMetrics)
+	compared := false
+	for _, name := range sqlManageReopenMetrics {
+		originMetric, ok := origin[name]
+		if !ok {
+			continue
+		}
+		for _, metrics := range collected {
+			metric, ok := metrics[name]
+			if !ok {
+				continue
+			}
+			compared = true
+			if metric.Float() > originMetric.Float()*factor {
+				return fmt.Sprintf("%v regressed from %v to %v", name, originMetric.Float(), metric.Float()), nil
+			}
+		}
+	}
+	if !compared && record.SQLManager.Status == model.ProcessStatusSolved {
+		return "the solved sql is collected again", nil
+	}
+	return "", nil
+}
+
+// sqlManageRecordsToReopen returns the activities to reopen the closed SQLs collected in the queues, the projects
+// which don't enable reopening are skipped.
+func sqlManageRecordsToReopen(s *model.Storage, queues []*model.SQLManageQueue) ([]*model.SQLManageRecordActivity, error) {
+	collected := map[string][]Metrics{}
+	sqlIds := []string{}
+	for _, queue := range queues {
+		if _, ok := collected[queue.SQLID]; !ok {
+			sqlIds = append(sqlIds, queue.SQLID)
+		}
+		info, err := queue.Info.OriginValue()
+		if err != nil {
+			return nil, err
+		}
+		collected[queue.SQLID] = append(collected[queue.SQLID], LoadMetrics(info, sqlManageReopenMetrics))
+	}
+	records, err := s.GetClosedSQLManageRecordsBySQLIds(sqlIds)
+	if err != nil || len(records) == 0 {
+		return nil, err
+	}
+
+	configs := map[string]*model.SQLManageLifecycleConfig{}
+	activities := []*model.SQLManageRecordActivity{}
+	for _, record := range records {
+		config, ok := configs[record.ProjectId]
+		if !ok {
+			config, _, err = s.GetSQLManageLifecycleConfig(record.ProjectId)
+			if err != nil {
+				return nil, err
+			}
+			configs[record.ProjectId] = config
+		}
+		if config == nil || !config.ReopenEnabled {
+			continue
+		}
+		reason, err := sqlManageReopenReason(record, collected[record.SQLID], config.ReopenMetricFactor)
+		if err != nil {
+			return nil, err
+		}
+		if reason == "" {
+			continue
+		}
+		activities = append(activities, &model.SQLManageRecordActivity{
+			SQLManageRecordID: record.ID,
+			Action:            model.SQLManageActivityActionReopened,
+			Operator:          model.SQLManageActivityOperatorSystem,
+			FromStatus:        string(record.SQLManager.Status),
+			ToStatus:          model.ProcessStatusUnhandled,
+			Detail:            reason,
+		})
+	}
+	return activities, nil
+}
+
+func reopenSQLManageRecords(tx *gorm.DB, activities []*model.SQLManageRecordActivity) error {
+	for _, activity := range activities {
+		err := model.UpdateSQLManageRecordProcessWithActivity(tx, activity, map[string]interface{}{
+			"status":       model.ProcessStatusUnhandled,
+			"escalated_at": nil,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package auditplan
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/actiontech/sqle/sqle/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchSQLManageAssignmentRule(t *testing.T) {
+	info, err := json.Marshal(map[string]interface{}{MetricNameEndpoints: []string{"order-service"}})
+	assert.NoError(t, err)
+	record := &model.SQLManageRecord{
+		SchemaName: "Shop",
+		SqlText:    "SELECT * FROM orders o JOIN crm.customers c ON o.cid = c.id",
+		Info:       info,
+	}
+	rules := []*model.SQLManageAssignmentRule{
+		{MatchType: model.AssignmentMatchTypeSchema, Pattern: "finance_*", Assignees: "a"},
+		{MatchType: model.AssignmentMatchTypeTable, Pattern: "crm.cust*", Assignees: "b"},
+		{MatchType: model.AssignmentMatchTypeSchema, Pattern: "shop", Assignees: "c"},
+	}
+	assert.Equal(t, "b", matchSQLManageAssignmentRule(rules, record).Assignees)
+
+	rules[1].Pattern = "shop.orders"
+	assert.Equal(t, "b", matchSQLManageAssignmentRule(rules, record).Assignees)
+
+	rules[1].Pattern = "payments"
+	assert.Equal(t, "c", matchSQLManageAssignmentRule(rules, record).Assignees)
+
+	rules = []*model.SQLManageAssignmentRule{
+		{MatchType: model.AssignmentMatchTypeEndpoint, Pattern: "order-*", Assignees: "d"},
+	}
+	assert.Equal(t, "d", matchSQLManageAssignmentRule(rules, record).Assignees)
+
+	rules[0].Pattern = "user-*"
+	assert.Nil(t, matchSQLManageAssignmentRule(rules, record))
+
+	// the table rules are skipped if the SQL can't be parsed
+	record.SqlText = "SELECT * FROM"
+	rules[0] = &model.SQLManageAssignmentRule{MatchType: model.AssignmentMatchTypeTable, Pattern: "*", Assignees: "e"}
+	assert.Nil(t, matchSQLManageAssignmentRule(rules, record))
+}
+
+func TestSQLManageRecordTables(t *testing.T) {
+	tables := sqlManageRecordTables(&model.SQLManageRecord{
+		SchemaName: "shop",
+		SqlText:    "SELECT * FROM a.t JOIN b.t ON a.t.id = b.t.id JOIN orders ON orders.id = a.t.id",
+	})
+	assert.ElementsMatch(t, []string{"t", "a.t", "t", "b.t", "orders", "shop.orders"}, tables)
+
+	assert.Empty(t, sqlManageRecordTables(&model.SQLManageRecord{SqlText: "SELECT * FROM"}))
+}
+
+func TestSQLManageReopenReason(t *testing.T) {
+	info, err := json.Marshal(map[string]interface{}{MetricNameQueryTimeAvg: 1.0, MetricNameRowExaminedAvg: 100.0})
+	assert.NoError(t, err)
+	record := &model.SQLManageRecord{Info: info}
+	record.SQLManager.Status = model.ProcessStatusIgnored
+
+	collected := []Metrics{LoadMetrics(map[string]interface{}{MetricNameQueryTimeAvg: 1.5}, sqlManageReopenMetrics)}
+	reason, err := sqlManageReopenReason(record, collected, 2)
+	assert.NoError(t, err)
+	assert.Empty(t, reason)
+
+	reason, err = sqlManageReopenReason(record, collected, 1)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, reason)
+
+	// the metrics can't be compared
+	collected = []Metrics{LoadMetrics(map[string]interface{}{}, sqlManageReopenMetrics)}
+	reason, err = sqlManageReopenReason(record, collected, 1)
+	assert.NoError(t, err)
+	assert.Empty(t, reason)
+
+	record.SQLManager.Status = model.ProcessStatusSolved
+	reason, err = sqlManageReopenReason(record, collected, 1)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, reason)
+}
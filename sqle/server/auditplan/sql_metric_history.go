@@ -0,0 +1,265 @@
+package auditplan
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/actiontech/sqle/sqle/locale"
+	"github.com/actiontech/sqle/sqle/log"
+	"github.com/actiontech/sqle/sqle/model"
+	"github.com/actiontech/sqle/sqle/notification"
+	"github.com/actiontech/sqle/sqle/pkg/params"
+)
+
+// OperationParamRegressionFactor is the high priority param of the SQL whose query time or rows examined regresses,
+// the SQL is regressed when the metric collected is more than the factor times of its baseline.
+const OperationParamRegressionFactor = "regression_factor"
+
+const (
+	// the baseline of the metric is the average of the metric collected in the days before
+	sqlMetricBaselineDays = 7
+	// the regression isn't detected until the SQL has been collected enough times
+	sqlMetricBaselineMinRecords = 3
+)
+
+// SQLMetricHistoryMetrics are the metrics saved for every collection of the SQL, the execution count of the
+// collection is saved as the execution count of the record.
+var SQLMetricHistoryMetrics = []string{
+	MetricNameQueryTimeAvg,
+	MetricNameQueryTimeMax,
+	MetricNameRowExaminedAvg,
+	MetricNameLockTimeAvg,
+}
+
+// sqlRegressionMetrics are the metrics checked for regression.
+var sqlRegressionMetrics = map[string]struct{}{
+	MetricNameQueryTimeAvg:   {},
+	MetricNameRowExaminedAvg: {},
+}
+
+var regressionFactorOperateParams = &params.ParamWithOperator{
+	Param: params.Param{
+		Key:      OperationParamRegressionFactor,
+		Value:    "2",
+		Type:     params.ParamTypeFloat64,
+		I18nDesc: locale.Bundle.LocalizeAll(locale.OperationParamRegressionFactor),
+	},
+	Operator: params.Operator{
+		Value: params.GreaterThanOperator,
+		EnumsValue: []params.EnumsValue{
+			{
+				Value:    string(params.GreaterThanOperator),
+				I18nDesc: locale.Bundle.LocalizeAll(locale.OperatorGreaterThan),
+			},
+		},
+	},
+}
+
+// setSlowLogMetrics sets the metrics of one execution of the slow SQL, the times are in seconds.
+func setSlowLogMetrics(info Metrics, queryTime, lockTime float64, rowsExamined int64) {
+	info.SetFloat(MetricNameQueryTimeAvg, queryTime)
+	info.SetFloat(MetricNameQueryTimeMax, queryTime)
+	info.SetFloat(MetricNameRowExaminedAvg, float64(rowsExamined))
+	info.SetFloat(MetricNameLockTimeAvg, lockTime)
+}
+
+// mergeSlowLogMetrics merges the averages weighted by the counters, it must be called before the counters are merged.
+func mergeSlowLogMetrics(origin, merged Metrics) {
+	if _, ok := merged[MetricNameQueryTimeAvg]; !ok {
+		return
+	}
+	originCounter := origin.Get(MetricNameCounter).Int()
+	mergedCounter := merged.Get(MetricNameCounter).Int()
+	for _, name := range []string{MetricNameQueryTimeAvg, MetricNameRowExaminedAvg, MetricNameLockTimeAvg} {
+		origin.SetFloat(name, weightedAvg(origin.Get(name).Float(), originCounter, merged.Get(name).Float(), mergedCounter))
+	}
+	if max := merged.Get(MetricNameQueryTimeMax).Float(); max > origin.Get(MetricNameQueryTimeMax).Float() {
+		origin.SetFloat(MetricNameQueryTimeMax, max)
+	}
+}
+
+func weightedAvg(a float64, aWeight int64, b float64, bWeight int64) float64 {
+	if aWeight+bWeight <= 0 {
+		return b
+	}
+	return (a*float64(aWeight) + b*float64(bWeight)) / float64(aWeight+bWeight)
+}
+
+// newSqlManageMetricRecord builds the metric record of the SQL collected from recordBeginAt to recordEndAt,
+// nil is returned if none of SQLMetricHistoryMetrics is collected.
+func newSqlManageMetricRecord(sql *model.SQLManageQueue, recordBeginAt, recordEndAt time.Time) (*model.SqlManageMetricRecord, error) {
+	value, err := sql.Info.OriginValue()
+	if err != nil {
+		return nil, err
+	}
+	info := LoadMetrics(value, append([]string{MetricNameCounter}, SQLMetricHistoryMetrics...))
+	record := &model.SqlManageMetricRecord{
+		SQLID:          sql.SQLID,
+		ExecutionCount: int(info.Get(MetricNameCounter).Int()),
+		RecordBeginAt:  recordBeginAt,
+		RecordEndAt:    recordEndAt,
+	}
+	if record.ExecutionCount <= 0 {
+		record.ExecutionCount = 1
+	}
+	for _, name := range SQLMetricHistoryMetrics {
+		metric, ok := info[name]
+		if !ok {
+			continue
+		}
+		record.MetricValues = append(record.MetricValues, &model.SqlManageMetricValue{
+			MetricName:  name,
+			MetricValue: metric.Float(),
+		})
+	}
+	if len(record.MetricValues) == 0 {
+		return nil, nil
+	}
+	return record, nil
+}
+
+// sqlRegressionRatio returns the ratio of the metric collected to its baseline, false is returned if the SQL
+// doesn't have enough history to compare with.
+func sqlRegressionRatio(baseline float64, baselineRecords int64, current float64) (float64, bool) {
+	if baselineRecords < sqlMetricBaselineMinRecords || baseline <= 0 {
+		return 0, false
+	}
+	return current / baseline, true
+}
+
+func formatRegressionRatio(ratio float64) string {
+	return strconv.FormatFloat(ratio, 'f', 2, 64)
+}
+
+// detectSQLRegressions compares the metrics of the record with their baselines by the regression factor configured
+// in the high priority params, the regressions detected are saved and the new ones are returned. The regressions
+// not detected any more are resolved.
+func detectSQLRegressions(persist *model.Storage, record *model.SqlManageMetricRecord, highPriorityParams params.ParamsWithOperator) ([]*model.SQLManageRegression, error) {
+	if highPriorityParams.GetParam(OperationParamRegressionFactor) == nil {
+		return nil, nil
+	}
+	newRegressions := []*model.SQLManageRegression{}
+	for _, value := range record.MetricValues {
+		if _, ok := sqlRegressionMetrics[value.MetricName]; !ok {
+			continue
+		}
+		baseline, count, err := persist.GetSqlManageMetricWeightedAvg(record.SQLID, value.MetricName,
+			record.RecordEndAt.AddDate(0, 0, -sqlMetricBaselineDays), record.RecordEndAt)
+		if err != nil {
+			return nil, err
+		}
+		ratio, ok := sqlRegressionRatio(baseline, count, value.MetricValue)
+		if !ok {
+			continue
+		}
+		regressed, err := highPriorityParams.CompareParamValue(OperationParamRegressionFactor, formatRegressionRatio(ratio))
+		if err != nil {
+			return nil, err
+		}
+		regression, exist, err := persist.GetSQLManageRegression(record.SQLID, value.MetricName)
+		if err != nil {
+			return nil, err
+		}
+		if !regressed {
+			if exist && regression.ResolvedAt == nil {
+				if err := persist.ResolveSQLManageRegression(record.SQLID, value.MetricName, record.RecordEndAt); err != nil {
+					return nil, err
+				}
+			}
+			continue
+		}
+		isNew := !exist || regression.ResolvedAt != nil
+		if !exist {
+			regression = &model.SQLManageRegression{SQLID: record.SQLID, MetricName: value.MetricName}
+		}
+		if isNew {
+			regression.DetectedAt = record.RecordEndAt
+			regression.ResolvedAt = nil
+		}
+		regression.BaselineValue = baseline
+		regression.CurrentValue = value.MetricValue
+		regression.Ratio = ratio
+		if err := persist.Save(regression); err != nil {
+			return nil, err
+		}
+		if isNew {
+			newRegressions = append(newRegressions, regression)
+		}
+	}
+	return newRegressions, nil
+}
+
+// getSQLRegressionRatio returns the max ratio of the regressions not resolved of the SQL.
+func getSQLRegressionRatio(persist *model.Storage, sqlId string) (float64, bool, error) {
+	regressions, err := persist.GetUnresolvedSQLManageRegressions(sqlId)
+	if err != nil {
+		return 0, false, err
+	}
+	var max float64
+	for _, regression := range regressions {
+		if regression.Ratio > max {
+			max = regression.Ratio
+		}
+	}
+	return max, len(regressions) > 0, nil
+}
+
+// recordSQLMetricHistory saves the metrics of the SQLs collected in this collection cycle and notifies the creator
+// of the audit plan of the new regressions. The errors are only logged, they don't stop the SQLs being pushed.
+func (at *TaskWrapper) recordSQLMetricHistory(sqls []*model.SQLManageQueue, ap *AuditPlan) {
+	recordEndAt := time.Now()
+	recordBeginAt := recordEndAt
+	if at.loopInterval != nil {
+		recordBeginAt = recordEndAt.Add(-at.loopInterval())
+	}
+
+	var highPriorityParams params.ParamsWithOperator
+	var loaded bool
+	for _, sql := range sqls {
+		record, err := newSqlManageMetricRecord(sql, recordBeginAt, recordEndAt)
+		if err != nil {
+			at.logger.Errorf("build metric record of sql %v failed: %v", sql.SQLID, err)
+			continue
+		}
+		if record == nil {
+			continue
+		}
+		if !loaded {
+			auditPlan, exist, err := at.persist.GetAuditPlanByInstanceIdAndType(strconv.Itoa(int(ap.InstanceAuditPlanId)), ap.Type)
+			if err != nil {
+				at.logger.Errorf("get audit plan failed: %v", err)
+				return
+			}
+			if exist {
+				highPriorityParams = auditPlan.HighPriorityParams
+			}
+			loaded = true
+		}
+		if err := at.persist.CreateSqlManageMetricRecordWithValues(record); err != nil {
+			at.logger.Errorf("save metric record of sql %v failed: %v", sql.SQLID, err)
+			continue
+		}
+		regressions, err := detectSQLRegressions(at.persist, record, highPriorityParams)
+		if err != nil {
+			at.logger.Errorf("detect regressions of sql %v failed: %v", sql.SQLID, err)
+			continue
+		}
+		for _, regression := range regressions {
+			notifySQLRegression(ap, sql, regression)
+		}
+	}
+}
+
+func notifySQLRegression(ap *AuditPlan, sql *model.SQLManageQueue, regression *model.SQLManageRegression) {
+	if ap.CreateUserID == "" {
+		return
+	}
+	instanceName := ap.InstanceID
+	if ap.Instance != nil {
+		instanceName = ap.Instance.Name
+	}
+	n := notification.NewSQLRegressionNotification(instanceName, ap.Type, sql.SQLID, sql.SqlFingerprint, regression)
+	if err := notification.Notify(n, []string{ap.CreateUserID}); err != nil {
+		log.Logger().Errorf("notify regression of sql %v failed: %v", sql.SQLID, err)
+	}
+}
@@ -0,0 +1,83 @@
+package auditplan
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/actiontech/sqle/sqle/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeSlowLogMetrics(t *testing.T) {
+	origin := NewMetrics()
+	setSlowLogMetrics(origin, 2, 0.5, 100)
+	origin.SetInt(MetricNameCounter, 3)
+
+	merged := NewMetrics()
+	setSlowLogMetrics(merged, 6, 1.5, 500)
+	merged.SetInt(MetricNameCounter, 1)
+
+	mergeSlowLogMetrics(origin, merged)
+	assert.InDelta(t, 3, origin.Get(MetricNameQueryTimeAvg).Float(), 0.0001)
+	assert.InDelta(t, 6, origin.Get(MetricNameQueryTimeMax).Float(), 0.0001)
+	assert.InDelta(t, 200, origin.Get(MetricNameRowExaminedAvg).Float(), 0.0001)
+	assert.InDelta(t, 0.75, origin.Get(MetricNameLockTimeAvg).Float(), 0.0001)
+	// the counter is merged by the caller
+	assert.Equal(t, int64(3), origin.Get(MetricNameCounter).Int())
+
+	// the SQL collected without the slow log metrics is not merged
+	withoutMetrics := NewMetrics()
+	withoutMetrics.SetInt(MetricNameCounter, 10)
+	mergeSlowLogMetrics(origin, withoutMetrics)
+	assert.InDelta(t, 3, origin.Get(MetricNameQueryTimeAvg).Float(), 0.0001)
+}
+
+func TestNewSqlManageMetricRecord(t *testing.T) {
+	begin := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := begin.Add(time.Hour)
+
+	info := NewMetrics()
+	setSlowLogMetrics(info, 1.5, 0.1, 20)
+	info.SetInt(MetricNameCounter, 4)
+	data, err := json.Marshal(info.ToMap())
+	assert.NoError(t, err)
+
+	record, err := newSqlManageMetricRecord(&model.SQLManageQueue{SQLID: "sql_1", Info: data}, begin, end)
+	assert.NoError(t, err)
+	assert.NotNil(t, record)
+	assert.Equal(t, "sql_1", record.SQLID)
+	assert.Equal(t, 4, record.ExecutionCount)
+	assert.Equal(t, begin, record.RecordBeginAt)
+	assert.Equal(t, end, record.RecordEndAt)
+	assert.Len(t, record.MetricValues, len(SQLMetricHistoryMetrics))
+	values := map[string]float64{}
+	for _, value := range record.MetricValues {
+		values[value.MetricName] = value.MetricValue
+	}
+	assert.InDelta(t, 1.5, values[MetricNameQueryTimeAvg], 0.0001)
+	assert.InDelta(t, 20, values[MetricNameRowExaminedAvg], 0.0001)
+
+	// no record for the SQL without the metrics
+	info = NewMetrics()
+	info.SetInt(MetricNameCounter, 4)
+	data, err = json.Marshal(info.ToMap())
+	assert.NoError(t, err)
+	record, err = newSqlManageMetricRecord(&model.SQLManageQueue{SQLID: "sql_2", Info: data}, begin, end)
+	assert.NoError(t, err)
+	assert.Nil(t, record)
+}
+
+func TestSqlRegressionRatio(t *testing.T) {
+	ratio, ok := sqlRegressionRatio(2, sqlMetricBaselineMinRecords, 5)
+	assert.True(t, ok)
+	assert.InDelta(t, 2.5, ratio, 0.0001)
+	assert.Equal(t, "2.50", formatRegressionRatio(ratio))
+
+	_, ok = sqlRegressionRatio(2, sqlMetricBaselineMinRecords-1, 5)
+	assert.False(t, ok)
+
+	_, ok = sqlRegressionRatio(0, sqlMetricBaselineMinRecords, 5)
+	assert.False(t, ok)
+}
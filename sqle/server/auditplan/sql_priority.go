@@ -0,0 +1,55 @@
+package auditplan
+
+import (
+	"fmt"
+
+	"github.com/actiontech/sqle/sqle/model"
+)
+
+// sqlPriorityExprEnv provides the values of the managed SQL for the priority expressions.
+type sqlPriorityExprEnv struct {
+	sql  *model.SQLManageRecord
+	info map[string]interface{}
+}
+
+func (e *sqlPriorityExprEnv) value(name string) (string, bool, error) {
+	switch name {
+	case OperationParamAuditLevel:
+		return e.sql.AuditLevel, true, nil
+	case OperationParamRegressionFactor:
+		ratio, exist, err := getSQLRegressionRatio(model.GetStorage(), e.sql.SQLID)
+		if err != nil || !exist {
+			return "", false, err
+		}
+		return formatRegressionRatio(ratio), true, nil
+	}
+	v, ok := e.info[name]
+	if !ok || v == nil {
+		return "", false, nil
+	}
+	return fmt.Sprintf("%v", v), true, nil
+}
+
+// getSQLPriorityByRules returns the priority of the first rule matched by the SQL, the rules are in the order of
+// model.SQLManagePriorities. The rule which is not valid any more is skipped.
+func getSQLPriorityByRules(rules []*model.SQLManagePriorityRule, sql *model.SQLManageRecord) (string, []string, error) {
+	info, err := sql.Info.OriginValue()
+	if err != nil {
+		return "", nil, err
+	}
+	env := &sqlPriorityExprEnv{sql: sql, info: info}
+	for _, rule := range rules {
+		expression, err := ParsePriorityExpression(rule.Expression)
+		if err != nil {
+			continue
+		}
+		matched, err := expression.eval(env)
+		if err != nil {
+			return "", nil, err
+		}
+		if matched {
+			return rule.Priority, []string{fmt.Sprintf("【%v: %v】", rule.Priority, expression)}, nil
+		}
+	}
+	return "", []string{}, nil
+}
@@ -62,10 +62,17 @@ func GetSqlManagerMetricTips(logger *logrus.Entry, auditPlanId uint, persist *mo
 }
 
 func GetSqlManagerPriorityTips(ctx context.Context, logger *logrus.Entry) []FilterTip {
-	return []FilterTip{
+	tips := []FilterTip{
 		{
 			Value: model.PriorityHigh,
 			Desc:  locale.Bundle.LocalizeMsgByCtx(ctx, locale.ApPriorityHigh),
 		},
 	}
+	for _, priority := range model.SQLManagePriorities {
+		tips = append(tips, FilterTip{
+			Value: priority,
+			Desc:  priority,
+		})
+	}
+	return tips
 }
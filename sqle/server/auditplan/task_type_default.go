@@ -87,6 +87,9 @@ func (at *DefaultTaskV2) mergeSQL(originSQL, mergedSQL *SQLV2) {
 		return
 	}
 	originSQL.SQLContent = mergedSQL.SQLContent
+	// the averages are weighted by the counters before merged
+	mergeSlowLogMetrics(originSQL.Info, mergedSQL.Info)
+
 	// counter
 	originCounter := originSQL.Info.Get(MetricNameCounter).Int()
 	mergedCounter := mergedSQL.Info.Get(MetricNameCounter).Int()
@@ -144,6 +147,10 @@ func (at *DefaultTaskV2) Head(ap *AuditPlan) []Head {
 			Desc: locale.ApMetricNameLastReceiveTimestamp,
 			Type: "time",
 		},
+		{
+			Name: MetricNameApplication,
+			Desc: locale.ApMetricNameApplication,
+		},
 	}
 }
 
@@ -168,6 +175,13 @@ func (at *DefaultTaskV2) Filters(ctx context.Context, logger *logrus.Entry, ap *
 			FilterInputType: FilterInputTypeString,
 			FilterOpType:    FilterOpTypeEqual,
 			FilterTips:      GetSqlManagerPriorityTips(ctx, logger),
+		},
+		{
+			Name:            MetricNameApplication,
+			Desc:            locale.ApMetricNameApplication,
+			FilterInputType: FilterInputTypeString,
+			FilterOpType:    FilterOpTypeEqual,
+			FilterTips:      GetSqlManagerMetricTips(logger, ap.ID, persist, MetricNameApplication),
 		}}
 }
 
@@ -184,6 +198,18 @@ func genArgsByFilters(filters []Filter) map[model.FilterName]interface{} {
 		case "rule_name":
 			args[model.FilterRuleName] = filter.FilterComparisonValue
 
+		case MetricNameApplication:
+			args[model.FilterApplication] = filter.FilterComparisonValue
+
+		case MetricNameLockType:
+			args[model.FilterLockType] = filter.FilterComparisonValue
+
+		case MetricNameLockObject:
+			args[model.FilterLockObject] = filter.FilterComparisonValue
+
+		case MetricNameIndexAdviceType:
+			args[model.FilterIndexAdviceType] = filter.FilterComparisonValue
+
 		case "schema_name":
 			args[model.FilterSchemaName] = filter.FilterComparisonValue
 
@@ -231,6 +257,9 @@ func (at *DefaultTaskV2) GetSQLData(ctx context.Context, ap *AuditPlan, persist
 
 		case "rule_name":
 			args[model.FilterRuleName] = filter.FilterComparisonValue
+
+		case MetricNameApplication:
+			args[model.FilterApplication] = filter.FilterComparisonValue
 		}
 	}
 	auditPlanSQLs, count, err := persist.GetInstanceAuditPlanSQLsByReqV2(ap.ID, ap.Type, limit, offset, checkAndGetOrderByName(at.Head(ap), orderBy), isAsc, args)
@@ -243,7 +272,7 @@ func (at *DefaultTaskV2) GetSQLData(ctx context.Context, ap *AuditPlan, persist
 		if err != nil {
 			return nil, 0, err
 		}
-		info := LoadMetrics(data, at.Metrics())
+		info := LoadMetrics(data, append(at.Metrics(), sqlApplicationMetrics...))
 		rows = append(rows, map[string]string{
 			"sql":                          sql.SQLContent,
 			"fingerprint":                  sql.Fingerprint,
@@ -251,6 +280,7 @@ func (at *DefaultTaskV2) GetSQLData(ctx context.Context, ap *AuditPlan, persist
 			"priority":                     sql.Priority.String,
 			MetricNameCounter:              strconv.Itoa(int(info.Get(MetricNameCounter).Int())),
 			MetricNameLastReceiveTimestamp: info.Get(MetricNameLastReceiveTimestamp).String(),
+			MetricNameApplication:          info.Get(MetricNameApplication).String(),
 			model.AuditResultName:          sql.AuditResult.GetAuditJsonStrByLangTag(locale.Bundle.GetLangTagFromCtx(ctx)),
 			model.AuditStatus:              sql.AuditStatus,
 		})
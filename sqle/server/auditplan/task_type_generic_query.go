@@ -0,0 +1,536 @@
+package auditplan
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/actiontech/sqle/sqle/common"
+	"github.com/actiontech/sqle/sqle/dms"
+	"github.com/actiontech/sqle/sqle/driver"
+	driverV2 "github.com/actiontech/sqle/sqle/driver/v2"
+	"github.com/actiontech/sqle/sqle/errors"
+	"github.com/actiontech/sqle/sqle/locale"
+	"github.com/actiontech/sqle/sqle/log"
+	"github.com/actiontech/sqle/sqle/model"
+	"github.com/actiontech/sqle/sqle/pkg/params"
+	"github.com/actiontech/sqle/sqle/utils"
+	"github.com/robfig/cron/v3"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	paramKeyCollectCron = "collect_cron"
+	paramKeyCollectSQL  = "collect_sql"
+
+	paramKeyColumnSQLText        = "column_sql_text"
+	paramKeyColumnSchema         = "column_schema"
+	paramKeyColumnCounter        = "column_counter"
+	paramKeyColumnQueryTimeAvg   = "column_query_time_avg"
+	paramKeyColumnQueryTimeMax   = "column_query_time_max"
+	paramKeyColumnRowExaminedAvg = "column_row_examined_avg"
+	paramKeyColumnDBUser         = "column_db_user"
+)
+
+const genericQueryTimeoutSecond = 60
+
+// GenericQueryTaskV2 collects the SQLs by the collection SQL configured in the params, which is executed through the
+// Query of the plugin on a cron schedule. The columns of the result are mapped to the SQL and the metrics by the
+// params, so any plugin implementing the optional module Query can feed the SQL management.
+type GenericQueryTaskV2 struct {
+	DefaultTaskV2
+}
+
+func NewGenericQueryTaskV2Fn() func() interface{} {
+	return func() interface{} {
+		return &GenericQueryTaskV2{}
+	}
+}
+
+func (at *GenericQueryTaskV2) InstanceType() string {
+	return InstanceTypeAll
+}
+
+func (at *GenericQueryTaskV2) Params(instanceId ...string) params.Params {
+	return []*params.Param{
+		{
+			Key:      paramKeyCollectCron,
+			Value:    "*/30 * * * *",
+			Type:     params.ParamTypeString,
+			I18nDesc: locale.Bundle.LocalizeAll(locale.ParamCollectCron),
+		},
+		{
+			Key:      paramKeyCollectSQL,
+			Value:    "",
+			Type:     params.ParamTypeString,
+			I18nDesc: locale.Bundle.LocalizeAll(locale.ParamCollectSQL),
+		},
+		{
+			Key:      paramKeyColumnSQLText,
+			Value:    "sql_text",
+			Type:     params.ParamTypeString,
+			I18nDesc: locale.Bundle.LocalizeAll(locale.ParamColumnSQLText),
+		},
+		{
+			Key:      paramKeyColumnSchema,
+			Value:    "",
+			Type:     params.ParamTypeString,
+			I18nDesc: locale.Bundle.LocalizeAll(locale.ParamColumnSchema),
+		},
+		{
+			Key:      paramKeyColumnCounter,
+			Value:    "",
+			Type:     params.ParamTypeString,
+			I18nDesc: locale.Bundle.LocalizeAll(locale.ParamColumnCounter),
+		},
+		{
+			Key:      paramKeyColumnQueryTimeAvg,
+			Value:    "",
+			Type:     params.ParamTypeString,
+			I18nDesc: locale.Bundle.LocalizeAll(locale.ParamColumnQueryTimeAvg),
+		},
+		{
+			Key:      paramKeyColumnQueryTimeMax,
+			Value:    "",
+			Type:     params.ParamTypeString,
+			I18nDesc: locale.Bundle.LocalizeAll(locale.ParamColumnQueryTimeMax),
+		},
+		{
+			Key:      paramKeyColumnRowExaminedAvg,
+			Value:    "",
+			Type:     params.ParamTypeString,
+			I18nDesc: locale.Bundle.LocalizeAll(locale.ParamColumnRowExaminedAvg),
+		},
+		{
+			Key:      paramKeyColumnDBUser,
+			Value:    "",
+			Type:     params.ParamTypeString,
+			I18nDesc: locale.Bundle.LocalizeAll(locale.ParamColumnDBUser),
+		},
+	}
+}
+
+func (at *GenericQueryTaskV2) Metrics() []string {
+	return []string{
+		MetricNameCounter,
+		MetricNameLastReceiveTimestamp,
+		MetricNameQueryTimeAvg,
+		MetricNameQueryTimeMax,
+		MetricNameRowExaminedAvg,
+		MetricNameDBUser,
+	}
+}
+
+// CronExpression schedules the collection by the param collect_cron.
+func (at *GenericQueryTaskV2) CronExpression(ap *AuditPlan) string {
+	return strings.TrimSpace(ap.Params.GetParam(paramKeyCollectCron).String())
+}
+
+// ValidateParams checks the cron expression and the collection sql, they are only used by the scheduled collection
+// so the invalid one won't be found until the audit plan is running. The collection sql is parsed by the plugin of the
+// db type.
+func (at *GenericQueryTaskV2) ValidateParams(dbType string, ps params.Params) error {
+	expr := strings.TrimSpace(ps.GetParam(paramKeyCollectCron).String())
+	if expr == "" {
+		return fmt.Errorf("the collection cron expression is required")
+	}
+	if _, err := cron.ParseStandard(expr); err != nil {
+		return fmt.Errorf("the collection cron expression %s is invalid: %v", expr, err)
+	}
+	collectSQL := strings.TrimSpace(ps.GetParam(paramKeyCollectSQL).String())
+	if collectSQL == "" {
+		return fmt.Errorf("the collection sql is required")
+	}
+	if strings.TrimSpace(ps.GetParam(paramKeyColumnSQLText).String()) == "" {
+		return fmt.Errorf("the column of the sql text is required")
+	}
+	plugin, err := common.NewDriverManagerWithoutCfg(log.NewEntry(), dbType)
+	if err != nil {
+		return fmt.Errorf("open plugin failed, error: %v", err)
+	}
+	defer plugin.Close(context.Background())
+	return checkGenericQueryCollectSQL(plugin, collectSQL)
+}
+
+// checkGenericQueryCollectSQL requires the collection sql to be exactly one query, the sql is executed periodically
+// by the account of the instance and it isn't audited.
+func checkGenericQueryCollectSQL(plugin driver.Plugin, collectSQL string) error {
+	nodes, err := plugin.Parse(context.Background(), collectSQL)
+	if err != nil {
+		return fmt.Errorf("parse the collection sql failed, error: %v", err)
+	}
+	if len(nodes) != 1 {
+		return fmt.Errorf("the collection sql should be exactly one statement, but got %d", len(nodes))
+	}
+	if nodes[0].Type != driverV2.SQLTypeDQL {
+		return fmt.Errorf("the collection sql should be a query, but got a %s statement", nodes[0].Type)
+	}
+	return nil
+}
+
+// genericQueryColumns is the column names of the result which are mapped to the SQL and the metrics, the empty column
+// name means the metric isn't collected.
+type genericQueryColumns struct {
+	sqlText        string
+	schema         string
+	counter        string
+	queryTimeAvg   string
+	queryTimeMax   string
+	rowExaminedAvg string
+	dbUser         string
+}
+
+func newGenericQueryColumns(ap *AuditPlan) *genericQueryColumns {
+	get := func(key string) string {
+		return strings.TrimSpace(ap.Params.GetParam(key).String())
+	}
+	return &genericQueryColumns{
+		sqlText:        get(paramKeyColumnSQLText),
+		schema:         get(paramKeyColumnSchema),
+		counter:        get(paramKeyColumnCounter),
+		queryTimeAvg:   get(paramKeyColumnQueryTimeAvg),
+		queryTimeMax:   get(paramKeyColumnQueryTimeMax),
+		rowExaminedAvg: get(paramKeyColumnRowExaminedAvg),
+		dbUser:         get(paramKeyColumnDBUser),
+	}
+}
+
+// genericQuerySQL is a row of the result of the collection SQL.
+type genericQuerySQL struct {
+	sql            string
+	schema         string
+	dbUser         string
+	counter        int64
+	queryTimeAvg   *float64
+	queryTimeMax   *float64
+	rowExaminedAvg *float64
+}
+
+// convertGenericQueryResult converts the rows of the result to the SQLs by the column mapping, the column names are
+// case-insensitive. The rows without SQL text are skipped, and the unparsable numbers are taken as not collected.
+func convertGenericQueryResult(result *driverV2.QueryResult, columns *genericQueryColumns) ([]*genericQuerySQL, error) {
+	if columns.sqlText == "" {
+		return nil, fmt.Errorf("the column of sql text is not configured")
+	}
+	columnIndex := map[string]int{}
+	for i, column := range result.Column {
+		columnIndex[strings.ToLower(column.Key)] = i
+	}
+	for _, name := range []string{columns.sqlText, columns.schema, columns.counter, columns.queryTimeAvg,
+		columns.queryTimeMax, columns.rowExaminedAvg, columns.dbUser} {
+		if _, ok := columnIndex[strings.ToLower(name)]; name != "" && !ok {
+			return nil, fmt.Errorf("column %s is not found in the result of the collection sql", name)
+		}
+	}
+	value := func(row *driverV2.QueryResultRow, name string) string {
+		i, ok := columnIndex[strings.ToLower(name)]
+		if name == "" || !ok || i >= len(row.Values) || row.Values[i] == nil {
+			return ""
+		}
+		return strings.TrimSpace(row.Values[i].Value)
+	}
+	floatValue := func(row *driverV2.QueryResultRow, name string) *float64 {
+		v, err := strconv.ParseFloat(value(row, name), 64)
+		if err != nil {
+			return nil
+		}
+		return &v
+	}
+
+	sqls := make([]*genericQuerySQL, 0, len(result.Rows))
+	for _, row := range result.Rows {
+		sql := &genericQuerySQL{
+			sql:            value(row, columns.sqlText),
+			schema:         value(row, columns.schema),
+			dbUser:         value(row, columns.dbUser),
+			counter:        1,
+			queryTimeAvg:   floatValue(row, columns.queryTimeAvg),
+			queryTimeMax:   floatValue(row, columns.queryTimeMax),
+			rowExaminedAvg: floatValue(row, columns.rowExaminedAvg),
+		}
+		if sql.sql == "" {
+			continue
+		}
+		if counter := floatValue(row, columns.counter); counter != nil && *counter > 0 {
+			sql.counter = int64(*counter)
+		}
+		if sql.queryTimeMax == nil {
+			sql.queryTimeMax = sql.queryTimeAvg
+		}
+		sqls = append(sqls, sql)
+	}
+	return sqls, nil
+}
+
+func (at *GenericQueryTaskV2) ExtractSQL(logger *logrus.Entry, ap *AuditPlan, persist *model.Storage) ([]*SQLV2, error) {
+	if ap.InstanceID == "" {
+		return nil, fmt.Errorf("instance is not configured")
+	}
+	collectSQL := strings.TrimSpace(ap.Params.GetParam(paramKeyCollectSQL).String())
+	if collectSQL == "" {
+		return nil, fmt.Errorf("the collection sql is not configured")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*20)
+	defer cancel()
+	inst, exist, err := dms.GetInstancesById(ctx, ap.InstanceID)
+	if err != nil {
+		return nil, fmt.Errorf("get instance fail, error: %v", err)
+	}
+	if !exist {
+		return nil, errors.NewInstanceNoExistErr()
+	}
+	if !driver.GetPluginManager().IsOptionalModuleEnabled(inst.DbType, driverV2.OptionalModuleQuery) {
+		return nil, driver.NewErrPluginAPINotImplement(driverV2.OptionalModuleQuery)
+	}
+	dsn, err := common.NewDSN(inst, "")
+	if err != nil {
+		return nil, err
+	}
+	plugin, err := driver.GetPluginManager().OpenPlugin(logger, inst.DbType, &driverV2.Config{DSN: dsn})
+	if err != nil {
+		return nil, fmt.Errorf("open plugin failed, error: %v", err)
+	}
+	defer plugin.Close(context.Background())
+	if err := checkGenericQueryCollectSQL(plugin, collectSQL); err != nil {
+		return nil, err
+	}
+
+	result, err := plugin.Query(context.Background(), collectSQL, &driverV2.QueryConf{TimeOutSecond: genericQueryTimeoutSecond})
+	if err != nil {
+		return nil, fmt.Errorf("execute collection sql failed, error: %v", err)
+	}
+	sqls, err := convertGenericQueryResult(result, newGenericQueryColumns(ap))
+	if err != nil {
+		return nil, err
+	}
+
+	cache := NewSQLV2Cache()
+	now := time.Now().Format(time.RFC3339)
+	for _, sql := range sqls {
+		sqlV2 := &SQLV2{
+			Source:      ap.Type,
+			SourceId:    strconv.FormatUint(uint64(ap.InstanceAuditPlanId), 10),
+			AuditPlanId: strconv.FormatUint(uint64(ap.ID), 10),
+			ProjectId:   ap.ProjectId,
+			InstanceID:  ap.InstanceID,
+			SchemaName:  sql.schema,
+			SQLContent:  sql.sql,
+			Fingerprint: sql.sql,
+		}
+		// the fingerprint is generated by the plugin, the SQL is used if the plugin can't parse it
+		nodes, err := plugin.Parse(context.Background(), sql.sql)
+		if err != nil {
+			logger.Warnf("parse sql failed, error: %v, sql: %s", err, sql.sql)
+		} else if len(nodes) > 0 && nodes[0].Fingerprint != "" {
+			sqlV2.Fingerprint = nodes[0].Fingerprint
+		}
+
+		info := NewMetrics()
+		info.SetInt(MetricNameCounter, sql.counter)
+		info.SetString(MetricNameLastReceiveTimestamp, now)
+		if sql.queryTimeAvg != nil {
+			info.SetFloat(MetricNameQueryTimeAvg, *sql.queryTimeAvg)
+		}
+		if sql.queryTimeMax != nil {
+			info.SetFloat(MetricNameQueryTimeMax, *sql.queryTimeMax)
+		}
+		if sql.rowExaminedAvg != nil {
+			info.SetFloat(MetricNameRowExaminedAvg, *sql.rowExaminedAvg)
+		}
+		if sql.dbUser != "" {
+			info.SetString(MetricNameDBUser, sql.dbUser)
+		}
+		sqlV2.Info = info
+		sqlV2.GenSQLId()
+		if err = at.AggregateSQL(cache, sqlV2); err != nil {
+			logger.Warnf("aggregate sql failed error : %v", err)
+			continue
+		}
+	}
+	return cache.GetSQLs(), nil
+}
+
+// mergeSQL accumulates the metrics, so the collection SQL should return the statistics in the collection period. The
+// metrics not mapped to any column are not merged.
+func (at *GenericQueryTaskV2) mergeSQL(originSQL, mergedSQL *SQLV2) {
+	if originSQL.SQLId != mergedSQL.SQLId {
+		return
+	}
+	originSQL.SQLContent = mergedSQL.SQLContent
+
+	originCounter := originSQL.Info.Get(MetricNameCounter).Int()
+	mergedCounter := mergedSQL.Info.Get(MetricNameCounter).Int()
+	for _, name := range []string{MetricNameQueryTimeAvg, MetricNameRowExaminedAvg} {
+		if _, ok := mergedSQL.Info[name]; !ok {
+			continue
+		}
+		if _, ok := originSQL.Info[name]; !ok {
+			originSQL.Info.SetFloat(name, mergedSQL.Info.Get(name).Float())
+			continue
+		}
+		originSQL.Info.SetFloat(name, weightedAvg(originSQL.Info.Get(name).Float(), originCounter, mergedSQL.Info.Get(name).Float(), mergedCounter))
+	}
+	if _, ok := mergedSQL.Info[MetricNameQueryTimeMax]; ok {
+		if max := mergedSQL.Info.Get(MetricNameQueryTimeMax).Float(); max > originSQL.Info.Get(MetricNameQueryTimeMax).Float() {
+			originSQL.Info.SetFloat(MetricNameQueryTimeMax, max)
+		}
+	}
+	originSQL.Info.SetInt(MetricNameCounter, originCounter+mergedCounter)
+	originSQL.Info.SetString(MetricNameLastReceiveTimestamp, mergedSQL.Info.Get(MetricNameLastReceiveTimestamp).String())
+	if dbUser := mergedSQL.Info.Get(MetricNameDBUser).String(); dbUser != "" {
+		originSQL.Info.SetString(MetricNameDBUser, dbUser)
+	}
+}
+
+func (at *GenericQueryTaskV2) AggregateSQL(cache SQLV2Cacher, sql *SQLV2) error {
+	originSQL, exist, err := cache.GetSQL(sql.SQLId)
+	if err != nil {
+		return err
+	}
+	if !exist {
+		cache.CacheSQL(sql)
+		return nil
+	}
+	at.mergeSQL(originSQL, sql)
+	return nil
+}
+
+func (at *GenericQueryTaskV2) Audit(sqls []*model.SQLManageRecord) (*AuditResultResp, error) {
+	return auditSQLs(sqls)
+}
+
+func (at *GenericQueryTaskV2) Head(ap *AuditPlan) []Head {
+	return []Head{
+		{
+			Name: "fingerprint",
+			Desc: locale.ApSQLFingerprint,
+			Type: "sql",
+		},
+		{
+			Name: "sql",
+			Desc: locale.ApLastSQL,
+			Type: "sql",
+		},
+		{
+			Name: "priority",
+			Desc: locale.ApPriority,
+		},
+		{
+			Name: model.AuditResultName,
+			Desc: model.AuditResultDesc,
+		},
+		{
+			Name: "schema_name",
+			Desc: locale.ApSchema,
+		},
+		{
+			Name:     MetricNameCounter,
+			Desc:     locale.ApMetricNameCounter,
+			Sortable: true,
+		},
+		{
+			Name:     MetricNameQueryTimeAvg,
+			Desc:     locale.ApMetricQueryTimeAvg,
+			Sortable: true,
+		},
+		{
+			Name:     MetricNameQueryTimeMax,
+			Desc:     locale.ApMetricNameMaxQueryTime,
+			Sortable: true,
+		},
+		{
+			Name:     MetricNameRowExaminedAvg,
+			Desc:     locale.ApMetricNameRowExaminedAvg,
+			Sortable: true,
+		},
+		{
+			Name: MetricNameDBUser,
+			Desc: locale.ApMetricNameDBUser,
+		},
+		{
+			Name:     MetricNameLastReceiveTimestamp,
+			Desc:     locale.ApMetricNameLastReceiveTimestamp,
+			Type:     "time",
+			Sortable: true,
+		},
+	}
+}
+
+func (at *GenericQueryTaskV2) Filters(ctx context.Context, logger *logrus.Entry, ap *AuditPlan, persist *model.Storage) []FilterMeta {
+	return []FilterMeta{
+		{
+			Name:            "sql", // 模糊筛选
+			Desc:            locale.ApSQLStatement,
+			FilterInputType: FilterInputTypeString,
+			FilterOpType:    FilterOpTypeEqual,
+		},
+		{
+			Name:            "schema_name",
+			Desc:            locale.ApSchema,
+			FilterInputType: FilterInputTypeString,
+			FilterOpType:    FilterOpTypeEqual,
+			FilterTips:      GetSqlManagerSchemaNameTips(logger, ap.ID, persist),
+		},
+		{
+			Name:            MetricNameDBUser,
+			Desc:            locale.ApMetricNameDBUser,
+			FilterInputType: FilterInputTypeString,
+			FilterOpType:    FilterOpTypeEqual,
+			FilterTips:      GetSqlManagerMetricTips(logger, ap.ID, persist, MetricNameDBUser),
+		},
+		{
+			Name:            "rule_name",
+			Desc:            locale.ApRuleName,
+			FilterInputType: FilterInputTypeString,
+			FilterOpType:    FilterOpTypeEqual,
+			FilterTips:      GetSqlManagerRuleTips(ctx, logger, ap.ID, persist),
+		},
+		{
+			Name:            "priority",
+			Desc:            locale.ApPriority,
+			FilterInputType: FilterInputTypeString,
+			FilterOpType:    FilterOpTypeEqual,
+			FilterTips:      GetSqlManagerPriorityTips(ctx, logger),
+		},
+		{
+			Name:            MetricNameLastReceiveTimestamp,
+			Desc:            locale.ApMetricNameLastReceiveTimestamp,
+			FilterInputType: FilterInputTypeDateTime,
+			FilterOpType:    FilterOpTypeBetween,
+		},
+	}
+}
+
+func (at *GenericQueryTaskV2) GetSQLData(ctx context.Context, ap *AuditPlan, persist *model.Storage, filters []Filter, orderBy string, isAsc bool, limit, offset int) ([]map[string] /* head name */ string, uint64, error) {
+	auditPlanSQLs, count, err := persist.GetInstanceAuditPlanSQLsByReqV2(ap.ID, ap.Type, limit, offset, checkAndGetOrderByName(at.Head(ap), orderBy), isAsc, genArgsByFilters(filters))
+	if err != nil {
+		return nil, count, err
+	}
+	rows := make([]map[string]string, 0, len(auditPlanSQLs))
+	for _, sql := range auditPlanSQLs {
+		data, err := sql.Info.OriginValue()
+		if err != nil {
+			return nil, 0, err
+		}
+		info := LoadMetrics(data, at.Metrics())
+		rows = append(rows, map[string]string{
+			"sql":                          sql.SQLContent,
+			"fingerprint":                  sql.Fingerprint,
+			"id":                           sql.AuditPlanSqlId,
+			"priority":                     sql.Priority.String,
+			"schema_name":                  sql.Schema,
+			MetricNameCounter:              strconv.FormatInt(info.Get(MetricNameCounter).Int(), 10),
+			MetricNameQueryTimeAvg:         fmt.Sprintf("%v", utils.Round(info.Get(MetricNameQueryTimeAvg).Float(), 4)),
+			MetricNameQueryTimeMax:         fmt.Sprintf("%v", utils.Round(info.Get(MetricNameQueryTimeMax).Float(), 4)),
+			MetricNameRowExaminedAvg:       fmt.Sprintf("%v", utils.Round(info.Get(MetricNameRowExaminedAvg).Float(), 4)),
+			MetricNameDBUser:               info.Get(MetricNameDBUser).String(),
+			MetricNameLastReceiveTimestamp: info.Get(MetricNameLastReceiveTimestamp).String(),
+			model.AuditResultName:          sql.AuditResult.GetAuditJsonStrByLangTag(locale.Bundle.GetLangTagFromCtx(ctx)),
+			model.AuditStatus:              sql.AuditStatus,
+		})
+	}
+	return rows, count, nil
+}
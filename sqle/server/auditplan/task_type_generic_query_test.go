@@ -0,0 +1,162 @@
+package auditplan
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/actiontech/sqle/sqle/driver"
+	_ "github.com/actiontech/sqle/sqle/driver/mysql"
+	driverV2 "github.com/actiontech/sqle/sqle/driver/v2"
+	"github.com/actiontech/sqle/sqle/pkg/params"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestQueryResult(columns []string, rows [][]string) *driverV2.QueryResult {
+	result := &driverV2.QueryResult{Column: params.Params{}}
+	for _, column := range columns {
+		result.Column = append(result.Column, &params.Param{Key: column, Value: column})
+	}
+	for _, row := range rows {
+		r := &driverV2.QueryResultRow{}
+		for _, v := range row {
+			r.Values = append(r.Values, &driverV2.QueryResultValue{Value: v})
+		}
+		result.Rows = append(result.Rows, r)
+	}
+	return result
+}
+
+func TestConvertGenericQueryResult(t *testing.T) {
+	result := newTestQueryResult(
+		[]string{"QUERY", "DATNAME", "CALLS", "MEAN_TIME", "ROWS_AVG"},
+		[][]string{
+			{"SELECT * FROM t1 WHERE id = $1", "db1", "10", "0.5", "100"},
+			// the unparsable numbers are taken as not collected
+			{"SELECT * FROM t2", "db2", "", "n/a", ""},
+			// the rows without sql text are skipped
+			{"", "db1", "1", "1", "1"},
+		})
+
+	sqls, err := convertGenericQueryResult(result, &genericQueryColumns{
+		sqlText:        "query",
+		schema:         "datname",
+		counter:        "calls",
+		queryTimeAvg:   "mean_time",
+		rowExaminedAvg: "rows_avg",
+	})
+	assert.NoError(t, err)
+	assert.Len(t, sqls, 2)
+
+	assert.Equal(t, "SELECT * FROM t1 WHERE id = $1", sqls[0].sql)
+	assert.Equal(t, "db1", sqls[0].schema)
+	assert.Equal(t, int64(10), sqls[0].counter)
+	assert.Equal(t, 0.5, *sqls[0].queryTimeAvg)
+	// the max is the average if the column isn't mapped
+	assert.Equal(t, 0.5, *sqls[0].queryTimeMax)
+	assert.Equal(t, float64(100), *sqls[0].rowExaminedAvg)
+
+	assert.Equal(t, int64(1), sqls[1].counter)
+	assert.Nil(t, sqls[1].queryTimeAvg)
+	assert.Nil(t, sqls[1].queryTimeMax)
+	assert.Nil(t, sqls[1].rowExaminedAvg)
+
+	_, err = convertGenericQueryResult(result, &genericQueryColumns{sqlText: "sql_text"})
+	assert.Error(t, err)
+	_, err = convertGenericQueryResult(result, &genericQueryColumns{sqlText: "query", dbUser: "usename"})
+	assert.Error(t, err)
+	_, err = convertGenericQueryResult(result, &genericQueryColumns{})
+	assert.Error(t, err)
+}
+
+func TestGenericQueryTaskV2AggregateSQL(t *testing.T) {
+	at := &GenericQueryTaskV2{}
+	newSQL := func(counter int64, queryTimeAvg float64, dbUser string) *SQLV2 {
+		sql := &SQLV2{
+			AuditPlanId: "1",
+			SQLContent:  "SELECT * FROM t1 WHERE id = 1",
+			Fingerprint: "SELECT * FROM t1 WHERE id = ?",
+			Info:        NewMetrics(),
+		}
+		sql.Info.SetInt(MetricNameCounter, counter)
+		sql.Info.SetFloat(MetricNameQueryTimeAvg, queryTimeAvg)
+		sql.Info.SetFloat(MetricNameQueryTimeMax, queryTimeAvg)
+		sql.Info.SetString(MetricNameDBUser, dbUser)
+		sql.GenSQLId()
+		return sql
+	}
+
+	cache := NewSQLV2Cache()
+	assert.NoError(t, at.AggregateSQL(cache, newSQL(1, 4, "app")))
+	assert.NoError(t, at.AggregateSQL(cache, newSQL(3, 2, "")))
+
+	sqls := cache.GetSQLs()
+	assert.Len(t, sqls, 1)
+	assert.Equal(t, int64(4), sqls[0].Info.Get(MetricNameCounter).Int())
+	assert.Equal(t, 2.5, sqls[0].Info.Get(MetricNameQueryTimeAvg).Float())
+	assert.Equal(t, float64(4), sqls[0].Info.Get(MetricNameQueryTimeMax).Float())
+	assert.Equal(t, "app", sqls[0].Info.Get(MetricNameDBUser).String())
+	// the row examined average isn't mapped
+	_, ok := sqls[0].Info[MetricNameRowExaminedAvg]
+	assert.False(t, ok)
+}
+
+// startTestPluginManager registers the built-in MySQL plugin which parses the SQLs without connection.
+var (
+	testPluginManagerOnce sync.Once
+	testPluginManagerErr  error
+)
+
+func startTestPluginManager() error {
+	testPluginManagerOnce.Do(func() {
+		testPluginManagerErr = driver.GetPluginManager().Start("", nil)
+	})
+	return testPluginManagerErr
+}
+
+func TestGenericQueryTaskV2_ValidateParams(t *testing.T) {
+	assert.NoError(t, startTestPluginManager())
+	newParams := func(cron, collectSQL string) params.Params {
+		ps := (&GenericQueryTaskV2{}).Params()
+		assert.NoError(t, ps.SetParamValue(paramKeyCollectCron, cron))
+		assert.NoError(t, ps.SetParamValue(paramKeyCollectSQL, collectSQL))
+		return ps
+	}
+	at := &GenericQueryTaskV2{}
+	const collectSQL = "SELECT sql_text FROM performance_schema.events_statements_summary_by_digest"
+
+	assert.NoError(t, at.ValidateParams(driverV2.DriverTypeMySQL, newParams("*/30 * * * *", collectSQL)))
+	assert.NoError(t, at.ValidateParams(driverV2.DriverTypeMySQL, newParams(" @every 10m ", collectSQL)))
+
+	assert.Error(t, at.ValidateParams(driverV2.DriverTypeMySQL, newParams("", collectSQL)))
+	assert.Error(t, at.ValidateParams(driverV2.DriverTypeMySQL, newParams("*/30 * *", collectSQL)))
+	assert.Error(t, at.ValidateParams(driverV2.DriverTypeMySQL, newParams("61 * * * *", collectSQL)))
+	assert.Error(t, at.ValidateParams(driverV2.DriverTypeMySQL, newParams("*/30 * * * *", " ")))
+
+	// the collection sql is executed by the account of the instance, only one query is allowed
+	for _, sql := range []string{
+		"DELETE FROM t1",
+		"DROP TABLE t1",
+		"SELECT 1; DROP TABLE t1",
+		"DELETE FROM t1; DROP TABLE t1",
+		"UPDATE t1 SET a = 1",
+	} {
+		assert.Error(t, at.ValidateParams(driverV2.DriverTypeMySQL, newParams("*/30 * * * *", sql)), sql)
+	}
+	// the plugin of the db type is required to parse the collection sql
+	assert.Error(t, at.ValidateParams("unknown", newParams("*/30 * * * *", collectSQL)))
+}
+
+func TestValidateParams(t *testing.T) {
+	assert.NoError(t, startTestPluginManager())
+	ps := (&GenericQueryTaskV2{}).Params()
+	assert.Error(t, ValidateParams(TypeGenericQuery, driverV2.DriverTypeMySQL, ps))
+	assert.NoError(t, ps.SetParamValue(paramKeyCollectSQL, "DROP TABLE t1"))
+	assert.Error(t, ValidateParams(TypeGenericQuery, driverV2.DriverTypeMySQL, ps))
+	assert.NoError(t, ps.SetParamValue(paramKeyCollectSQL, "SELECT sql_text FROM performance_schema.events_statements_summary_by_digest"))
+	assert.NoError(t, ValidateParams(TypeGenericQuery, driverV2.DriverTypeMySQL, ps))
+
+	// the params of the audit plan type without the validator are not checked
+	assert.NoError(t, ValidateParams(TypeDefault, driverV2.DriverTypeMySQL, nil))
+	assert.Error(t, ValidateParams("unknown", driverV2.DriverTypeMySQL, nil))
+}
@@ -0,0 +1,636 @@
+package auditplan
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/actiontech/sqle/sqle/dms"
+	"github.com/actiontech/sqle/sqle/driver/mysql/executor"
+	driverV2 "github.com/actiontech/sqle/sqle/driver/v2"
+	"github.com/actiontech/sqle/sqle/errors"
+	"github.com/actiontech/sqle/sqle/locale"
+	"github.com/actiontech/sqle/sqle/model"
+	"github.com/actiontech/sqle/sqle/pkg/params"
+	"github.com/actiontech/sqle/sqle/utils"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"github.com/sirupsen/logrus"
+)
+
+const paramKeyUnusedIndexObservationDays = "unused_index_observation_days"
+
+const (
+	IndexAdviceTypeUnused    = "unused"
+	IndexAdviceTypeRedundant = "redundant"
+	IndexAdviceTypeDuplicate = "duplicate"
+)
+
+var indexAdviceTypeDesc = map[string]*i18n.Message{
+	IndexAdviceTypeUnused:    locale.ApIndexAdviceTypeUnused,
+	IndexAdviceTypeRedundant: locale.ApIndexAdviceTypeRedundant,
+	IndexAdviceTypeDuplicate: locale.ApIndexAdviceTypeDuplicate,
+}
+
+// MySQLIndexAdviceTaskV2 finds the unused indexes and the redundant or duplicate indexes of MySQL, and suggests
+// dropping them with the estimated space savings.
+type MySQLIndexAdviceTaskV2 struct {
+	DefaultTaskV2
+}
+
+func NewMySQLIndexAdviceTaskV2Fn() func() interface{} {
+	return func() interface{} {
+		return &MySQLIndexAdviceTaskV2{}
+	}
+}
+
+func (at *MySQLIndexAdviceTaskV2) InstanceType() string {
+	return InstanceTypeMySQL
+}
+
+func (at *MySQLIndexAdviceTaskV2) Params(instanceId ...string) params.Params {
+	return []*params.Param{
+		{
+			Key:      paramKeyCollectIntervalMinute,
+			Value:    "1440",
+			Type:     params.ParamTypeInt,
+			I18nDesc: locale.Bundle.LocalizeAll(locale.ParamCollectIntervalMinute),
+		},
+		{
+			Key:      paramKeyUnusedIndexObservationDays,
+			Value:    "7",
+			Type:     params.ParamTypeInt,
+			I18nDesc: locale.Bundle.LocalizeAll(locale.ParamUnusedIndexObservationDays),
+		},
+	}
+}
+
+func (at *MySQLIndexAdviceTaskV2) Metrics() []string {
+	return []string{
+		MetricNameLastReceiveTimestamp,
+		MetricNameTableName,
+		MetricNameIndexName,
+		MetricNameIndexAdviceType,
+		MetricNameCoveringIndex,
+		MetricNameIndexSize,
+		MetricNameRecordDeleted,
+	}
+}
+
+func (at *MySQLIndexAdviceTaskV2) Audit(sqls []*model.SQLManageRecord) (*AuditResultResp, error) {
+	return auditSQLs(sqls)
+}
+
+type indexColumn struct {
+	name string
+	// subPart is the length of the prefix index, 0 means the whole column is indexed
+	subPart int
+}
+
+type tableIndex struct {
+	name      string
+	unique    bool
+	indexType string
+	columns   []*indexColumn
+	// the functional index of MySQL 8.0 has the expression instead of the column
+	hasExpression bool
+}
+
+func (i *tableIndex) isPrimary() bool {
+	return strings.ToUpper(i.name) == "PRIMARY"
+}
+
+func (i *tableIndex) String() string {
+	columns := make([]string, 0, len(i.columns))
+	for _, column := range i.columns {
+		if column.subPart > 0 {
+			columns = append(columns, fmt.Sprintf("%s(%d)", column.name, column.subPart))
+		} else {
+			columns = append(columns, column.name)
+		}
+	}
+	return fmt.Sprintf("%s(%s)", i.name, strings.Join(columns, ","))
+}
+
+// groupTableIndexes groups the rows of SHOW INDEX by the index, the order of the indexes is kept.
+func groupTableIndexes(infos []*executor.TableIndexesInfo) []*tableIndex {
+	indexes := []*tableIndex{}
+	indexMap := map[string]*tableIndex{}
+	seqs := map[*indexColumn]int{}
+	for _, info := range infos {
+		index, ok := indexMap[info.KeyName]
+		if !ok {
+			index = &tableIndex{
+				name:      info.KeyName,
+				unique:    info.NonUnique == "0",
+				indexType: strings.ToUpper(info.IndexType),
+			}
+			indexMap[info.KeyName] = index
+			indexes = append(indexes, index)
+		}
+		if info.ColumnName == "" {
+			index.hasExpression = true
+		}
+		subPart, _ := strconv.Atoi(info.SubPart)
+		column := &indexColumn{name: info.ColumnName, subPart: subPart}
+		seqs[column], _ = strconv.Atoi(info.SeqInIndex)
+		index.columns = append(index.columns, column)
+	}
+	for _, index := range indexes {
+		sort.SliceStable(index.columns, func(i, j int) bool {
+			return seqs[index.columns[i]] < seqs[index.columns[j]]
+		})
+	}
+	return indexes
+}
+
+// coversPrefix checks if the columns of the index are the leftmost prefix of the columns of the other index.
+func (i *tableIndex) coversPrefix(other *tableIndex) bool {
+	if len(i.columns) > len(other.columns) {
+		return false
+	}
+	for n, column := range i.columns {
+		otherColumn := other.columns[n]
+		if !strings.EqualFold(column.name, otherColumn.name) {
+			return false
+		}
+		if column.subPart == otherColumn.subPart {
+			continue
+		}
+		// only the last column of the index can be shorter than the column of the other index
+		if n != len(i.columns)-1 || column.subPart == 0 || (otherColumn.subPart != 0 && otherColumn.subPart < column.subPart) {
+			return false
+		}
+	}
+	return true
+}
+
+func (i *tableIndex) sameColumns(other *tableIndex) bool {
+	return len(i.columns) == len(other.columns) && i.coversPrefix(other) && other.coversPrefix(i)
+}
+
+// redundantIndex is the index which can be replaced by the covering index.
+type redundantIndex struct {
+	index         *tableIndex
+	coveringIndex *tableIndex
+	// duplicate means the columns of the two indexes are the same
+	duplicate bool
+}
+
+// findRedundantIndexes finds the indexes of a table which are the leftmost prefix of other indexes. The primary key,
+// the unique indexes which are not duplicated, the full text and spatial indexes and the functional indexes are
+// never reported. Only one of the duplicate indexes is reported.
+func findRedundantIndexes(indexes []*tableIndex) []*redundantIndex {
+	isCandidate := func(index *tableIndex) bool {
+		return !index.hasExpression && index.indexType != "FULLTEXT" && index.indexType != "SPATIAL"
+	}
+	// prefer keeping the primary key, the unique index and the index which has the smaller name
+	preferred := func(index, other *tableIndex) bool {
+		if index.isPrimary() != other.isPrimary() {
+			return index.isPrimary()
+		}
+		if index.unique != other.unique {
+			return index.unique
+		}
+		return index.name < other.name
+	}
+
+	redundantIndexes := []*redundantIndex{}
+	for _, index := range indexes {
+		if index.isPrimary() || !isCandidate(index) {
+			continue
+		}
+		for _, other := range indexes {
+			if other == index || !isCandidate(other) || other.indexType != index.indexType {
+				continue
+			}
+			if index.sameColumns(other) {
+				if preferred(index, other) {
+					continue
+				}
+				redundantIndexes = append(redundantIndexes, &redundantIndex{index: index, coveringIndex: other, duplicate: true})
+				break
+			}
+			// the unique index is a constraint, it can't be replaced by the longer index
+			if !index.unique && index.coversPrefix(other) {
+				redundantIndexes = append(redundantIndexes, &redundantIndex{index: index, coveringIndex: other})
+				break
+			}
+		}
+	}
+	return redundantIndexes
+}
+
+// indexAdvice is the suggestion of dropping an index.
+type indexAdvice struct {
+	schema        string
+	table         string
+	index         string
+	adviceType    string
+	coveringIndex string
+	size          int64
+}
+
+func (a *indexAdvice) sql() string {
+	return fmt.Sprintf("ALTER TABLE %s.%s DROP INDEX %s;",
+		utils.SupplementalQuotationMarks(a.schema),
+		utils.SupplementalQuotationMarks(a.table),
+		utils.SupplementalQuotationMarks(a.index))
+}
+
+func indexKey(schema, table, index string) string {
+	return fmt.Sprintf("%s.%s.%s", schema, table, index)
+}
+
+const (
+	// the same as the sys.schema_unused_indexes, the indexes which are never used since the server started
+	mysqlUnusedIndexQuery = `SELECT OBJECT_SCHEMA AS schema_name, OBJECT_NAME AS table_name, INDEX_NAME AS index_name
+FROM performance_schema.table_io_waits_summary_by_index_usage
+WHERE INDEX_NAME IS NOT NULL AND INDEX_NAME <> 'PRIMARY' AND COUNT_STAR = 0
+AND OBJECT_SCHEMA NOT IN ('mysql', 'performance_schema', 'information_schema', 'sys')
+ORDER BY OBJECT_SCHEMA, OBJECT_NAME`
+
+	mysqlIndexSizeQuery = `SELECT database_name AS schema_name, table_name, index_name, stat_value AS pages
+FROM mysql.innodb_index_stats WHERE stat_name = 'size'`
+
+	mysqlUptimeQuery = `SHOW GLOBAL STATUS LIKE 'Uptime'`
+)
+
+// indexTable is the table which the indexes belong to.
+type indexTable struct {
+	schema string
+	table  string
+}
+
+// buildIndexAdvices builds the advices from the indexes of the tables and the unused indexes. The redundant
+// advice wins if an index is both redundant and unused, and the unique indexes are never reported as unused.
+func buildIndexAdvices(tableIndexes map[indexTable][]*tableIndex, unusedIndexes []*indexAdvice, sizes map[string]int64) []*indexAdvice {
+	advices := []*indexAdvice{}
+	advised := map[string]struct{}{}
+	candidates := map[string]struct{}{}
+
+	tables := make([]indexTable, 0, len(tableIndexes))
+	for table := range tableIndexes {
+		tables = append(tables, table)
+	}
+	sort.Slice(tables, func(i, j int) bool {
+		if tables[i].schema != tables[j].schema {
+			return tables[i].schema < tables[j].schema
+		}
+		return tables[i].table < tables[j].table
+	})
+	for _, table := range tables {
+		for _, index := range tableIndexes[table] {
+			if !index.isPrimary() && !index.unique {
+				candidates[indexKey(table.schema, table.table, index.name)] = struct{}{}
+			}
+		}
+		for _, redundant := range findRedundantIndexes(tableIndexes[table]) {
+			advice := &indexAdvice{
+				schema:        table.schema,
+				table:         table.table,
+				index:         redundant.index.name,
+				adviceType:    IndexAdviceTypeRedundant,
+				coveringIndex: redundant.coveringIndex.String(),
+				size:          sizes[indexKey(table.schema, table.table, redundant.index.name)],
+			}
+			if redundant.duplicate {
+				advice.adviceType = IndexAdviceTypeDuplicate
+			}
+			advised[indexKey(advice.schema, advice.table, advice.index)] = struct{}{}
+			advices = append(advices, advice)
+		}
+	}
+	for _, unused := range unusedIndexes {
+		key := indexKey(unused.schema, unused.table, unused.index)
+		if _, ok := advised[key]; ok {
+			continue
+		}
+		// the index may be dropped or the table may be skipped after the usage is collected
+		if _, ok := candidates[key]; !ok {
+			continue
+		}
+		advised[key] = struct{}{}
+		unused.adviceType = IndexAdviceTypeUnused
+		unused.size = sizes[key]
+		advices = append(advices, unused)
+	}
+	return advices
+}
+
+func (at *MySQLIndexAdviceTaskV2) extractIndexAdvices(logger *logrus.Entry, ap *AuditPlan) ([]*indexAdvice, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*20)
+	defer cancel()
+
+	instance, exist, err := dms.GetInstancesById(ctx, ap.InstanceID)
+	if err != nil {
+		return nil, fmt.Errorf("get instance fail, error: %v", err)
+	}
+	if !exist {
+		return nil, errors.NewInstanceNoExistErr()
+	}
+
+	db, err := executor.NewExecutor(logger, &driverV2.DSN{
+		Host:             instance.Host,
+		Port:             instance.Port,
+		User:             instance.User,
+		Password:         instance.Password,
+		AdditionalParams: instance.AdditionalParams,
+	}, "")
+	if err != nil {
+		return nil, fmt.Errorf("connect to instance fail, error: %v", err)
+	}
+	defer db.Db.Close()
+
+	schemas, err := db.ShowDatabases(true)
+	if err != nil {
+		return nil, fmt.Errorf("show databases failed, error: %v", err)
+	}
+	tableIndexes := map[indexTable][]*tableIndex{}
+	for _, schema := range schemas {
+		tables, err := db.ShowSchemaTables(schema)
+		if err != nil {
+			return nil, fmt.Errorf("show tables of schema %s failed, error: %v", schema, err)
+		}
+		for _, table := range tables {
+			infos, err := db.GetTableIndexesInfo(schema, table)
+			if err != nil {
+				// the table may be dropped after it is listed
+				logger.Warnf("get indexes of table %s.%s failed, error: %v", schema, table, err)
+				continue
+			}
+			tableIndexes[indexTable{schema: schema, table: table}] = groupTableIndexes(infos)
+		}
+	}
+
+	// the usage of the indexes is reset when the server restarts, so the unused indexes are reported only if the
+	// server has been running for the whole observation window
+	unusedIndexes := []*indexAdvice{}
+	observationDays := ap.Params.GetParam(paramKeyUnusedIndexObservationDays).Int()
+	uptime := int64(0)
+	rows, err := db.Db.Query(mysqlUptimeQuery)
+	if err != nil {
+		logger.Warnf("query uptime failed, error: %v", err)
+	} else if len(rows) > 0 {
+		uptime, _ = strconv.ParseInt(rows[0]["Value"].String, 10, 64)
+	}
+	if uptime >= int64(observationDays)*24*60*60 {
+		rows, err = db.Db.Query(mysqlUnusedIndexQuery)
+		if err != nil {
+			// the performance schema may be disabled
+			logger.Warnf("query unused indexes failed, error: %v", err)
+		}
+		for _, row := range rows {
+			unusedIndexes = append(unusedIndexes, &indexAdvice{
+				schema: row["schema_name"].String,
+				table:  row["table_name"].String,
+				index:  row["index_name"].String,
+			})
+		}
+	} else {
+		logger.Infof("the uptime %d seconds is shorter than the observation window of %d days, skip detecting unused indexes", uptime, observationDays)
+	}
+
+	sizes := map[string]int64{}
+	pageSize := int64(0)
+	rows, err = db.Db.Query("SELECT @@innodb_page_size AS page_size")
+	if err != nil {
+		logger.Warnf("query innodb page size failed, error: %v", err)
+	} else if len(rows) > 0 {
+		pageSize, _ = strconv.ParseInt(rows[0]["page_size"].String, 10, 64)
+	}
+	if pageSize > 0 {
+		rows, err = db.Db.Query(mysqlIndexSizeQuery)
+		if err != nil {
+			logger.Warnf("query index sizes failed, error: %v", err)
+		}
+		for _, row := range rows {
+			table := row["table_name"].String
+			// the partitions of the table are named like t1#P#p0
+			if n := strings.Index(strings.ToUpper(table), "#P#"); n >= 0 {
+				table = table[:n]
+			}
+			pages, _ := strconv.ParseInt(row["pages"].String, 10, 64)
+			sizes[indexKey(row["schema_name"].String, table, row["index_name"].String)] += pages * pageSize
+		}
+	}
+
+	return buildIndexAdvices(tableIndexes, unusedIndexes, sizes), nil
+}
+
+func (at *MySQLIndexAdviceTaskV2) ExtractSQL(logger *logrus.Entry, ap *AuditPlan, persist *model.Storage) ([]*SQLV2, error) {
+	if ap.InstanceID == "" {
+		logger.Warnf("instance is not configured")
+		return nil, nil
+	}
+
+	advices, err := at.extractIndexAdvices(logger, ap)
+	if err != nil {
+		return nil, err
+	}
+
+	// the same as the schema meta, the advices which are not found again are marked as deleted, e.g. the index is
+	// dropped or used
+	cache := NewSQLV2Cache()
+	originSQLV2, err := persist.GetManagerSQLListByAuditPlanId(ap.ID)
+	if err != nil {
+		logger.Errorf("get manager sql failed, error: %v", err)
+		return nil, err
+	}
+	for _, sql := range originSQLV2 {
+		sqlV2 := ConvertMangerSQLToSQLV2(sql)
+		if !sqlV2.Info.Get(MetricNameRecordDeleted).Bool() {
+			sqlV2.Info.SetBool(MetricNameRecordDeleted, true)
+			cache.CacheSQL(sqlV2)
+		}
+	}
+	now := time.Now().Format(time.RFC3339)
+	for _, advice := range advices {
+		sqlV2 := &SQLV2{
+			Source:      ap.Type,
+			SourceId:    strconv.FormatUint(uint64(ap.InstanceAuditPlanId), 10),
+			AuditPlanId: strconv.FormatUint(uint64(ap.ID), 10),
+			ProjectId:   ap.ProjectId,
+			InstanceID:  ap.InstanceID,
+			SchemaName:  advice.schema,
+			SQLContent:  advice.sql(),
+			Fingerprint: advice.sql(), // DDL不能参数化
+			Info:        NewMetrics(),
+		}
+		sqlV2.Info.SetString(MetricNameLastReceiveTimestamp, now)
+		sqlV2.Info.SetString(MetricNameTableName, advice.table)
+		sqlV2.Info.SetString(MetricNameIndexName, advice.index)
+		sqlV2.Info.SetString(MetricNameIndexAdviceType, advice.adviceType)
+		sqlV2.Info.SetString(MetricNameCoveringIndex, advice.coveringIndex)
+		sqlV2.Info.SetInt(MetricNameIndexSize, advice.size)
+		sqlV2.Info.SetBool(MetricNameRecordDeleted, false)
+		sqlV2.GenSQLId()
+		if err := at.AggregateSQL(cache, sqlV2); err != nil {
+			logger.Errorf("aggregate sql failed, error: %v", err)
+			continue
+		}
+	}
+	return cache.GetSQLs(), nil
+}
+
+func (at *MySQLIndexAdviceTaskV2) mergeSQL(originSQL, mergedSQL *SQLV2) {
+	if originSQL.SQLId != mergedSQL.SQLId {
+		return
+	}
+	originSQL.SQLContent = mergedSQL.SQLContent
+	originSQL.Fingerprint = mergedSQL.Fingerprint
+
+	originSQL.Info.SetString(MetricNameLastReceiveTimestamp, mergedSQL.Info.Get(MetricNameLastReceiveTimestamp).String())
+	for _, name := range []string{MetricNameTableName, MetricNameIndexName, MetricNameIndexAdviceType, MetricNameCoveringIndex} {
+		originSQL.Info.SetString(name, mergedSQL.Info.Get(name).String())
+	}
+	originSQL.Info.SetInt(MetricNameIndexSize, mergedSQL.Info.Get(MetricNameIndexSize).Int())
+	originSQL.Info.SetBool(MetricNameRecordDeleted, mergedSQL.Info.Get(MetricNameRecordDeleted).Bool())
+}
+
+func (at *MySQLIndexAdviceTaskV2) AggregateSQL(cache SQLV2Cacher, sql *SQLV2) error {
+	originSQL, exist, err := cache.GetSQL(sql.SQLId)
+	if err != nil {
+		return err
+	}
+	if !exist {
+		cache.CacheSQL(sql)
+		return nil
+	}
+	at.mergeSQL(originSQL, sql)
+	return nil
+}
+
+func (at *MySQLIndexAdviceTaskV2) Head(ap *AuditPlan) []Head {
+	return []Head{
+		{
+			Name: "sql",
+			Desc: locale.ApSQLStatement,
+			Type: "sql",
+		},
+		{
+			Name: "priority",
+			Desc: locale.ApPriority,
+		},
+		{
+			Name: model.AuditResultName,
+			Desc: model.AuditResultDesc,
+		},
+		{
+			Name: "schema_name",
+			Desc: locale.ApSchema,
+		},
+		{
+			Name: MetricNameTableName,
+			Desc: locale.ApMetricNameTableName,
+		},
+		{
+			Name: MetricNameIndexName,
+			Desc: locale.ApMetricNameIndexName,
+		},
+		{
+			Name: MetricNameIndexAdviceType,
+			Desc: locale.ApMetricNameIndexAdviceType,
+		},
+		{
+			Name: MetricNameCoveringIndex,
+			Desc: locale.ApMetricNameCoveringIndex,
+		},
+		{
+			Name:     MetricNameIndexSize,
+			Desc:     locale.ApMetricNameIndexSize,
+			Sortable: true,
+		},
+		{
+			Name:     MetricNameLastReceiveTimestamp,
+			Desc:     locale.ApMetricNameLastReceiveTimestamp,
+			Type:     "time",
+			Sortable: true,
+		},
+	}
+}
+
+func (at *MySQLIndexAdviceTaskV2) Filters(ctx context.Context, logger *logrus.Entry, ap *AuditPlan, persist *model.Storage) []FilterMeta {
+	adviceTypeTips := make([]FilterTip, 0, len(indexAdviceTypeDesc))
+	for _, adviceType := range []string{IndexAdviceTypeUnused, IndexAdviceTypeRedundant, IndexAdviceTypeDuplicate} {
+		adviceTypeTips = append(adviceTypeTips, FilterTip{
+			Value: adviceType,
+			Desc:  locale.Bundle.LocalizeMsgByCtx(ctx, indexAdviceTypeDesc[adviceType]),
+		})
+	}
+	return []FilterMeta{
+		{
+			Name:            "sql", // 模糊筛选
+			Desc:            locale.ApSQLStatement,
+			FilterInputType: FilterInputTypeString,
+			FilterOpType:    FilterOpTypeEqual,
+		},
+		{
+			Name:            "schema_name",
+			Desc:            locale.ApSchema,
+			FilterInputType: FilterInputTypeString,
+			FilterOpType:    FilterOpTypeEqual,
+			FilterTips:      GetSqlManagerSchemaNameTips(logger, ap.ID, persist),
+		},
+		{
+			Name:            MetricNameIndexAdviceType,
+			Desc:            locale.ApMetricNameIndexAdviceType,
+			FilterInputType: FilterInputTypeString,
+			FilterOpType:    FilterOpTypeEqual,
+			FilterTips:      adviceTypeTips,
+		},
+		{
+			Name:            "rule_name",
+			Desc:            locale.ApRuleName,
+			FilterInputType: FilterInputTypeString,
+			FilterOpType:    FilterOpTypeEqual,
+			FilterTips:      GetSqlManagerRuleTips(ctx, logger, ap.ID, persist),
+		},
+		{
+			Name:            "priority",
+			Desc:            locale.ApPriority,
+			FilterInputType: FilterInputTypeString,
+			FilterOpType:    FilterOpTypeEqual,
+			FilterTips:      GetSqlManagerPriorityTips(ctx, logger),
+		},
+	}
+}
+
+func (at *MySQLIndexAdviceTaskV2) GetSQLData(ctx context.Context, ap *AuditPlan, persist *model.Storage, filters []Filter, orderBy string, isAsc bool, limit, offset int) ([]map[string] /* head name */ string, uint64, error) {
+	args := genArgsByFilters(filters)
+	// the advices of the dropped or used indexes are only visible in the SQL management
+	args[model.FilterExcludeRecordDeleted] = true
+	auditPlanSQLs, count, err := persist.GetInstanceAuditPlanSQLsByReqV2(ap.ID, ap.Type, limit, offset, checkAndGetOrderByName(at.Head(ap), orderBy), isAsc, args)
+	if err != nil {
+		return nil, count, err
+	}
+	rows := make([]map[string]string, 0, len(auditPlanSQLs))
+	for _, sql := range auditPlanSQLs {
+		data, err := sql.Info.OriginValue()
+		if err != nil {
+			return nil, 0, err
+		}
+		info := LoadMetrics(data, at.Metrics())
+		adviceType := info.Get(MetricNameIndexAdviceType).String()
+		if desc, ok := indexAdviceTypeDesc[adviceType]; ok {
+			adviceType = locale.Bundle.LocalizeMsgByCtx(ctx, desc)
+		}
+		rows = append(rows, map[string]string{
+			"sql":                          sql.SQLContent,
+			"id":                           sql.AuditPlanSqlId,
+			"priority":                     sql.Priority.String,
+			"schema_name":                  sql.Schema,
+			MetricNameTableName:            info.Get(MetricNameTableName).String(),
+			MetricNameIndexName:            info.Get(MetricNameIndexName).String(),
+			MetricNameIndexAdviceType:      adviceType,
+			MetricNameCoveringIndex:        info.Get(MetricNameCoveringIndex).String(),
+			MetricNameIndexSize:            fmt.Sprintf("%.2f", float64(info.Get(MetricNameIndexSize).Int())/1024/1024),
+			MetricNameLastReceiveTimestamp: info.Get(MetricNameLastReceiveTimestamp).String(),
+			model.AuditResultName:          sql.AuditResult.GetAuditJsonStrByLangTag(locale.Bundle.GetLangTagFromCtx(ctx)),
+			model.AuditStatus:              sql.AuditStatus,
+		})
+	}
+	return rows, count, nil
+}
@@ -0,0 +1,120 @@
+package auditplan
+
+import (
+	"testing"
+
+	"github.com/actiontech/sqle/sqle/driver/mysql/executor"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestTableIndexes(rows [][]string) []*tableIndex {
+	infos := make([]*executor.TableIndexesInfo, 0, len(rows))
+	for _, row := range rows {
+		// key name, column name, seq in index, non unique, sub part, index type
+		infos = append(infos, &executor.TableIndexesInfo{
+			KeyName:    row[0],
+			ColumnName: row[1],
+			SeqInIndex: row[2],
+			NonUnique:  row[3],
+			SubPart:    row[4],
+			IndexType:  row[5],
+		})
+	}
+	return groupTableIndexes(infos)
+}
+
+func TestGroupTableIndexes(t *testing.T) {
+	indexes := newTestTableIndexes([][]string{
+		{"PRIMARY", "id", "1", "0", "", "BTREE"},
+		{"idx_a_b", "b", "2", "1", "10", "BTREE"},
+		{"idx_a_b", "a", "1", "1", "", "BTREE"},
+		{"idx_func", "", "1", "1", "", "BTREE"},
+	})
+	assert.Len(t, indexes, 3)
+	assert.Equal(t, "PRIMARY(id)", indexes[0].String())
+	assert.True(t, indexes[0].isPrimary())
+	assert.True(t, indexes[0].unique)
+	assert.Equal(t, "idx_a_b(a,b(10))", indexes[1].String())
+	assert.False(t, indexes[1].unique)
+	assert.True(t, indexes[2].hasExpression)
+}
+
+func TestFindRedundantIndexes(t *testing.T) {
+	indexes := newTestTableIndexes([][]string{
+		{"PRIMARY", "id", "1", "0", "", "BTREE"},
+		// redundant to idx_a_b
+		{"idx_a", "a", "1", "1", "", "BTREE"},
+		{"idx_a_b", "a", "1", "1", "", "BTREE"},
+		{"idx_a_b", "b", "2", "1", "", "BTREE"},
+		// duplicate of the unique index uk_c
+		{"idx_c", "c", "1", "1", "", "BTREE"},
+		{"uk_c", "c", "1", "0", "", "BTREE"},
+		// the unique index is a constraint even if it is the prefix of the other index
+		{"uk_d", "d", "1", "0", "", "BTREE"},
+		{"idx_d_e", "d", "1", "1", "", "BTREE"},
+		{"idx_d_e", "e", "2", "1", "", "BTREE"},
+		// the prefix index is covered by the longer prefix
+		{"idx_f_10", "f", "1", "1", "10", "BTREE"},
+		{"idx_f_20", "f", "1", "1", "20", "BTREE"},
+		// the longer prefix isn't covered by the shorter prefix
+		{"idx_g_20", "g", "1", "1", "20", "BTREE"},
+		{"idx_g_10_h", "g", "1", "1", "10", "BTREE"},
+		{"idx_g_10_h", "h", "2", "1", "", "BTREE"},
+		// only the one of the same indexes is reported
+		{"idx_i_1", "i", "1", "1", "", "BTREE"},
+		{"idx_i_2", "i", "1", "1", "", "BTREE"},
+		// the full text index is skipped
+		{"ft_a", "a", "1", "1", "", "FULLTEXT"},
+		// the index in the different type isn't compared
+		{"idx_id_hash", "id", "1", "1", "", "HASH"},
+	})
+	redundantIndexes := findRedundantIndexes(indexes)
+	result := map[string]string{}
+	duplicates := []string{}
+	for _, redundant := range redundantIndexes {
+		result[redundant.index.name] = redundant.coveringIndex.name
+		if redundant.duplicate {
+			duplicates = append(duplicates, redundant.index.name)
+		}
+	}
+	assert.Equal(t, map[string]string{
+		"idx_a":    "idx_a_b",
+		"idx_c":    "uk_c",
+		"idx_f_10": "idx_f_20",
+		"idx_i_2":  "idx_i_1",
+	}, result)
+	assert.Equal(t, []string{"idx_c", "idx_i_2"}, duplicates)
+}
+
+func TestBuildIndexAdvices(t *testing.T) {
+	tableIndexes := map[indexTable][]*tableIndex{
+		{schema: "db1", table: "t1"}: newTestTableIndexes([][]string{
+			{"PRIMARY", "id", "1", "0", "", "BTREE"},
+			{"idx_a", "a", "1", "1", "", "BTREE"},
+			{"idx_a_b", "a", "1", "1", "", "BTREE"},
+			{"idx_a_b", "b", "2", "1", "", "BTREE"},
+			{"uk_c", "c", "1", "0", "", "BTREE"},
+			{"idx_d", "d", "1", "1", "", "BTREE"},
+		}),
+	}
+	unusedIndexes := []*indexAdvice{
+		// the redundant advice wins
+		{schema: "db1", table: "t1", index: "idx_a"},
+		{schema: "db1", table: "t1", index: "idx_d"},
+		// the unique index isn't reported
+		{schema: "db1", table: "t1", index: "uk_c"},
+		// the index doesn't exist anymore
+		{schema: "db1", table: "t1", index: "idx_e"},
+	}
+	sizes := map[string]int64{
+		"db1.t1.idx_a": 16384,
+		"db1.t1.idx_d": 32768,
+	}
+	advices := buildIndexAdvices(tableIndexes, unusedIndexes, sizes)
+	assert.Equal(t, []*indexAdvice{
+		{schema: "db1", table: "t1", index: "idx_a", adviceType: IndexAdviceTypeRedundant, coveringIndex: "idx_a_b(a,b)", size: 16384},
+		{schema: "db1", table: "t1", index: "idx_d", adviceType: IndexAdviceTypeUnused, size: 32768},
+	}, advices)
+	assert.Equal(t, "ALTER TABLE `db1`.`t1` DROP INDEX `idx_a`;", advices[0].sql())
+}
@@ -0,0 +1,537 @@
+package auditplan
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/actiontech/sqle/sqle/dms"
+	"github.com/actiontech/sqle/sqle/driver/mysql/executor"
+	"github.com/actiontech/sqle/sqle/driver/mysql/util"
+	driverV2 "github.com/actiontech/sqle/sqle/driver/v2"
+	"github.com/actiontech/sqle/sqle/errors"
+	"github.com/actiontech/sqle/sqle/locale"
+	"github.com/actiontech/sqle/sqle/model"
+	"github.com/actiontech/sqle/sqle/pkg/params"
+	"github.com/actiontech/sqle/sqle/utils"
+	"github.com/nicksnyder/go-i18n/v2/i18n"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	paramKeyLockWaitMinSecond = "lock_wait_min_second"
+	paramKeyLongTrxMinSecond  = "long_trx_min_second"
+)
+
+const (
+	LockTypeRowLockWait      = "row_lock_wait"
+	LockTypeMetadataLockWait = "metadata_lock_wait"
+	LockTypeLongTransaction  = "long_transaction"
+)
+
+var lockTypeDesc = map[string]*i18n.Message{
+	LockTypeRowLockWait:      locale.ApLockTypeRowLockWait,
+	LockTypeMetadataLockWait: locale.ApLockTypeMetadataLockWait,
+	LockTypeLongTransaction:  locale.ApLockTypeLongTransaction,
+}
+
+// MySQLLockWaitTaskV2 samples the row lock waits, the metadata lock waits and the long transactions of MySQL, the SQL
+// of the waiter or the transaction is collected with the SQL of the blocker.
+type MySQLLockWaitTaskV2 struct {
+	DefaultTaskV2
+}
+
+func NewMySQLLockWaitTaskV2Fn() func() interface{} {
+	return func() interface{} {
+		return &MySQLLockWaitTaskV2{}
+	}
+}
+
+func (at *MySQLLockWaitTaskV2) InstanceType() string {
+	return InstanceTypeMySQL
+}
+
+func (at *MySQLLockWaitTaskV2) Params(instanceId ...string) params.Params {
+	return []*params.Param{
+		{
+			Key:      paramKeyCollectIntervalSecond,
+			Value:    "60",
+			Type:     params.ParamTypeInt,
+			I18nDesc: locale.Bundle.LocalizeAll(locale.ParamCollectIntervalSecond),
+		},
+		{
+			Key:      paramKeyLockWaitMinSecond,
+			Value:    "1",
+			Type:     params.ParamTypeInt,
+			I18nDesc: locale.Bundle.LocalizeAll(locale.ParamLockWaitMinSecond),
+		},
+		{
+			Key:      paramKeyLongTrxMinSecond,
+			Value:    "60",
+			Type:     params.ParamTypeInt,
+			I18nDesc: locale.Bundle.LocalizeAll(locale.ParamLongTrxMinSecond),
+		},
+	}
+}
+
+var lockWaitTimeMaxOperateParams = &params.ParamWithOperator{
+	Param: params.Param{
+		Key:      MetricNameLockWaitTimeMax,
+		Value:    "60",
+		Type:     params.ParamTypeInt,
+		I18nDesc: locale.Bundle.LocalizeAll(locale.ApMetricNameLockWaitTimeMax),
+	},
+	Operator: params.Operator{
+		Value:      ">",
+		EnumsValue: defaultOperatorEnums,
+	},
+}
+
+func (at *MySQLLockWaitTaskV2) HighPriorityParams() params.ParamsWithOperator {
+	return append(at.DefaultTaskV2.HighPriorityParams(), lockWaitTimeMaxOperateParams)
+}
+
+func (at *MySQLLockWaitTaskV2) Metrics() []string {
+	return []string{
+		MetricNameCounter,
+		MetricNameLastReceiveTimestamp,
+		MetricNameLockType,
+		MetricNameLockObject,
+		MetricNameBlockerSQL,
+		MetricNameLockWaitTimeMax,
+		MetricNameDBUser,
+		MetricNameEndpoints,
+	}
+}
+
+func (at *MySQLLockWaitTaskV2) Audit(sqls []*model.SQLManageRecord) (*AuditResultResp, error) {
+	return auditSQLs(sqls)
+}
+
+// lockEvent is a lock wait or a long transaction sampled at a time.
+type lockEvent struct {
+	lockType   string
+	schema     string
+	sql        string
+	user       string
+	host       string
+	seconds    int64
+	lockObject string
+	blockerSQL string
+}
+
+// the columns of the lock wait queries are aliased to the same names
+const (
+	// MySQL 8.0
+	mysqlRowLockWaitQuery = `SELECT r.trx_mysql_thread_id AS thread_id, r.trx_query AS query,
+TIMESTAMPDIFF(SECOND, r.trx_wait_started, NOW()) AS seconds,
+CONCAT(l.OBJECT_SCHEMA, '.', l.OBJECT_NAME) AS lock_object,
+b.trx_mysql_thread_id AS blocker_thread_id, b.trx_query AS blocker_query,
+p.DB AS db, p.USER AS user, p.HOST AS host
+FROM performance_schema.data_lock_waits w
+JOIN information_schema.innodb_trx r ON r.trx_id = w.REQUESTING_ENGINE_TRANSACTION_ID
+JOIN information_schema.innodb_trx b ON b.trx_id = w.BLOCKING_ENGINE_TRANSACTION_ID
+JOIN performance_schema.data_locks l ON l.ENGINE_LOCK_ID = w.REQUESTING_ENGINE_LOCK_ID
+LEFT JOIN information_schema.processlist p ON p.ID = r.trx_mysql_thread_id
+WHERE r.trx_wait_started <= NOW() - INTERVAL ? SECOND`
+
+	// MySQL 5.7, the lock waits are in information_schema
+	mysql57RowLockWaitQuery = `SELECT r.trx_mysql_thread_id AS thread_id, r.trx_query AS query,
+TIMESTAMPDIFF(SECOND, r.trx_wait_started, NOW()) AS seconds,
+REPLACE(l.lock_table, '` + "`" + `', '') AS lock_object,
+b.trx_mysql_thread_id AS blocker_thread_id, b.trx_query AS blocker_query,
+p.DB AS db, p.USER AS user, p.HOST AS host
+FROM information_schema.innodb_lock_waits w
+JOIN information_schema.innodb_trx r ON r.trx_id = w.requesting_trx_id
+JOIN information_schema.innodb_trx b ON b.trx_id = w.blocking_trx_id
+JOIN information_schema.innodb_locks l ON l.lock_id = w.requested_lock_id
+LEFT JOIN information_schema.processlist p ON p.ID = r.trx_mysql_thread_id
+WHERE r.trx_wait_started <= NOW() - INTERVAL ? SECOND`
+
+	// the metadata lock waits need the instrument wait/lock/metadata/sql/mdl which is disabled by default in MySQL 5.7
+	mysqlMetadataLockWaitQuery = `SELECT wt.PROCESSLIST_ID AS thread_id, wt.PROCESSLIST_INFO AS query,
+wt.PROCESSLIST_TIME AS seconds,
+CONCAT(w.OBJECT_SCHEMA, '.', w.OBJECT_NAME) AS lock_object,
+bt.PROCESSLIST_ID AS blocker_thread_id, bt.PROCESSLIST_INFO AS blocker_query,
+wt.PROCESSLIST_DB AS db, wt.PROCESSLIST_USER AS user, wt.PROCESSLIST_HOST AS host
+FROM performance_schema.metadata_locks w
+JOIN performance_schema.threads wt ON wt.THREAD_ID = w.OWNER_THREAD_ID
+JOIN performance_schema.metadata_locks g ON g.OBJECT_TYPE = w.OBJECT_TYPE AND g.OBJECT_SCHEMA = w.OBJECT_SCHEMA
+AND g.OBJECT_NAME = w.OBJECT_NAME AND g.LOCK_STATUS = 'GRANTED' AND g.OWNER_THREAD_ID <> w.OWNER_THREAD_ID
+JOIN performance_schema.threads bt ON bt.THREAD_ID = g.OWNER_THREAD_ID
+WHERE w.LOCK_STATUS = 'PENDING' AND w.OBJECT_TYPE = 'TABLE' AND wt.PROCESSLIST_TIME >= ?`
+
+	mysqlLongTransactionQuery = `SELECT t.trx_mysql_thread_id AS thread_id, t.trx_query AS query,
+TIMESTAMPDIFF(SECOND, t.trx_started, NOW()) AS seconds,
+p.DB AS db, p.USER AS user, p.HOST AS host
+FROM information_schema.innodb_trx t
+LEFT JOIN information_schema.processlist p ON p.ID = t.trx_mysql_thread_id
+WHERE t.trx_started <= NOW() - INTERVAL ? SECOND`
+
+	// the last statements of the sessions, which are used if the session is idle in the transaction
+	mysqlLastStatementQuery = `SELECT t.PROCESSLIST_ID AS thread_id, s.SQL_TEXT AS query
+FROM performance_schema.threads t
+JOIN performance_schema.events_statements_current s ON s.THREAD_ID = t.THREAD_ID
+WHERE t.PROCESSLIST_ID IS NOT NULL AND s.SQL_TEXT IS NOT NULL`
+)
+
+func (at *MySQLLockWaitTaskV2) ExtractSQL(logger *logrus.Entry, ap *AuditPlan, persist *model.Storage) ([]*SQLV2, error) {
+	if ap.InstanceID == "" {
+		return nil, fmt.Errorf("instance is not configured")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*20)
+	defer cancel()
+
+	instance, exist, err := dms.GetInstancesById(ctx, ap.InstanceID)
+	if err != nil {
+		return nil, fmt.Errorf("get instance fail, error: %v", err)
+	}
+	if !exist {
+		return nil, errors.NewInstanceNoExistErr()
+	}
+
+	db, err := executor.NewExecutor(logger, &driverV2.DSN{
+		Host:             instance.Host,
+		Port:             instance.Port,
+		User:             instance.User,
+		Password:         instance.Password,
+		AdditionalParams: instance.AdditionalParams,
+	}, "")
+	if err != nil {
+		return nil, fmt.Errorf("connect to instance fail, error: %v", err)
+	}
+	defer db.Db.Close()
+
+	lockWaitMinSecond := ap.Params.GetParam(paramKeyLockWaitMinSecond).Int()
+	longTrxMinSecond := ap.Params.GetParam(paramKeyLongTrxMinSecond).Int()
+	if longTrxMinSecond <= 0 {
+		longTrxMinSecond = 60
+	}
+
+	rowLockWaits, err := db.Db.Query(mysqlRowLockWaitQuery, lockWaitMinSecond)
+	if err != nil {
+		logger.Infof("query data_lock_waits failed, fallback to innodb_lock_waits of MySQL 5.7, error: %v", err)
+		rowLockWaits, err = db.Db.Query(mysql57RowLockWaitQuery, lockWaitMinSecond)
+		if err != nil {
+			return nil, fmt.Errorf("query row lock waits failed, error: %v", err)
+		}
+	}
+	metadataLockWaits, err := db.Db.Query(mysqlMetadataLockWaitQuery, lockWaitMinSecond)
+	if err != nil {
+		// the performance schema or the instrument may be disabled
+		logger.Warnf("query metadata lock waits failed, error: %v", err)
+	}
+	longTransactions, err := db.Db.Query(mysqlLongTransactionQuery, longTrxMinSecond)
+	if err != nil {
+		return nil, fmt.Errorf("query long transactions failed, error: %v", err)
+	}
+	lastStatements := map[string]string{}
+	if len(rowLockWaits) > 0 || len(metadataLockWaits) > 0 || len(longTransactions) > 0 {
+		rows, err := db.Db.Query(mysqlLastStatementQuery)
+		if err != nil {
+			logger.Warnf("query last statements of sessions failed, error: %v", err)
+		}
+		for _, row := range rows {
+			lastStatements[row["thread_id"].String] = row["query"].String
+		}
+	}
+
+	events := convertLockEvents(LockTypeRowLockWait, rowLockWaits, lastStatements, db.Db.GetConnectionID())
+	events = append(events, convertLockEvents(LockTypeMetadataLockWait, metadataLockWaits, lastStatements, db.Db.GetConnectionID())...)
+	events = append(events, convertLockEvents(LockTypeLongTransaction, longTransactions, lastStatements, db.Db.GetConnectionID())...)
+
+	cache := NewSQLV2Cache()
+	now := time.Now().Format(time.RFC3339)
+	for _, event := range events {
+		sqlV2 := &SQLV2{
+			Source:      ap.Type,
+			SourceId:    strconv.FormatUint(uint64(ap.InstanceAuditPlanId), 10),
+			AuditPlanId: strconv.FormatUint(uint64(ap.ID), 10),
+			ProjectId:   ap.ProjectId,
+			InstanceID:  ap.InstanceID,
+			SchemaName:  event.schema,
+			SQLContent:  event.sql,
+		}
+		fp, err := util.Fingerprint(event.sql, true)
+		if err != nil || fp == "" {
+			logger.Warnf("get sql finger print failed, err: %v, sql: %s", err, event.sql)
+			fp = event.sql
+		}
+		sqlV2.Fingerprint = fp
+
+		info := NewMetrics()
+		info.SetInt(MetricNameCounter, 1)
+		info.SetString(MetricNameLastReceiveTimestamp, now)
+		info.SetString(MetricNameLockType, event.lockType)
+		info.SetString(MetricNameLockObject, event.lockObject)
+		info.SetString(MetricNameBlockerSQL, event.blockerSQL)
+		info.SetInt(MetricNameLockWaitTimeMax, event.seconds)
+		info.SetString(MetricNameDBUser, event.user)
+		if event.host != "" {
+			info.SetStringArray(MetricNameEndpoints, []string{event.host})
+		}
+		sqlV2.Info = info
+		sqlV2.SQLId = at.genSQLId(sqlV2)
+		if err = at.AggregateSQL(cache, sqlV2); err != nil {
+			logger.Warnf("aggregate sql failed error : %v", err)
+			continue
+		}
+	}
+	return cache.GetSQLs(), nil
+}
+
+// convertLockEvents converts the rows of the lock wait queries to the events, the last statement of the session is
+// used if the session is idle. The events without SQL and the events of the current connection are skipped.
+func convertLockEvents(lockType string, rows []map[string]sql.NullString, lastStatements map[string]string, connID string) []*lockEvent {
+	events := make([]*lockEvent, 0, len(rows))
+	for _, row := range rows {
+		threadId := row["thread_id"].String
+		if threadId == connID {
+			continue
+		}
+		query := row["query"].String
+		if query == "" {
+			query = lastStatements[threadId]
+		}
+		if query == "" {
+			continue
+		}
+		blockerSQL := row["blocker_query"].String
+		if blockerSQL == "" && row["blocker_thread_id"].Valid {
+			blockerSQL = lastStatements[row["blocker_thread_id"].String]
+		}
+		seconds, _ := strconv.ParseInt(row["seconds"].String, 10, 64)
+		host := row["host"].String
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		events = append(events, &lockEvent{
+			lockType:   lockType,
+			schema:     row["db"].String,
+			sql:        query,
+			user:       row["user"].String,
+			host:       host,
+			seconds:    seconds,
+			lockObject: row["lock_object"].String,
+			blockerSQL: blockerSQL,
+		})
+	}
+	return events
+}
+
+// genSQLId separates the same SQL in the different lock types.
+func (at *MySQLLockWaitTaskV2) genSQLId(sql *SQLV2) string {
+	md5Json, err := json.Marshal(
+		struct {
+			ProjectId   string
+			Fingerprint string
+			Schema      string
+			InstID      string
+			Source      string
+			AuditPlanID string
+			LockType    string
+		}{
+			ProjectId:   sql.ProjectId,
+			Fingerprint: sql.Fingerprint,
+			Schema:      sql.SchemaName,
+			InstID:      sql.InstanceID,
+			Source:      sql.Source,
+			AuditPlanID: sql.AuditPlanId,
+			LockType:    sql.Info.Get(MetricNameLockType).String(),
+		},
+	)
+	if err != nil {
+		return utils.Md5String(fmt.Sprintf("%s:%s:%s", sql.AuditPlanId, sql.Fingerprint, sql.Info.Get(MetricNameLockType).String()))
+	}
+	return utils.Md5String(string(md5Json))
+}
+
+func (at *MySQLLockWaitTaskV2) mergeSQL(originSQL, mergedSQL *SQLV2) {
+	if originSQL.SQLId != mergedSQL.SQLId {
+		return
+	}
+	originSQL.SQLContent = mergedSQL.SQLContent
+
+	originSQL.Info.SetInt(MetricNameCounter, originSQL.Info.Get(MetricNameCounter).Int()+mergedSQL.Info.Get(MetricNameCounter).Int())
+	originSQL.Info.SetString(MetricNameLastReceiveTimestamp, mergedSQL.Info.Get(MetricNameLastReceiveTimestamp).String())
+
+	if mergedSQL.Info.Get(MetricNameLockWaitTimeMax).Int() > originSQL.Info.Get(MetricNameLockWaitTimeMax).Int() {
+		originSQL.Info.SetInt(MetricNameLockWaitTimeMax, mergedSQL.Info.Get(MetricNameLockWaitTimeMax).Int())
+	}
+	// the latest lock object, blocker and user are kept
+	for _, name := range []string{MetricNameLockObject, MetricNameBlockerSQL, MetricNameDBUser} {
+		if v := mergedSQL.Info.Get(name).String(); v != "" {
+			originSQL.Info.SetString(name, v)
+		}
+	}
+	endpoints := originSQL.Info.Get(MetricNameEndpoints).StringArray()
+	for _, endpoint := range mergedSQL.Info.Get(MetricNameEndpoints).StringArray() {
+		if !utils.StringsContains(endpoints, endpoint) {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	if len(endpoints) > 0 {
+		originSQL.Info.SetStringArray(MetricNameEndpoints, endpoints)
+	}
+}
+
+func (at *MySQLLockWaitTaskV2) AggregateSQL(cache SQLV2Cacher, sql *SQLV2) error {
+	originSQL, exist, err := cache.GetSQL(sql.SQLId)
+	if err != nil {
+		return err
+	}
+	if !exist {
+		cache.CacheSQL(sql)
+		return nil
+	}
+	at.mergeSQL(originSQL, sql)
+	return nil
+}
+
+func (at *MySQLLockWaitTaskV2) Head(ap *AuditPlan) []Head {
+	return []Head{
+		{
+			Name: "sql",
+			Desc: locale.ApSQLStatement,
+			Type: "sql",
+		},
+		{
+			Name: "priority",
+			Desc: locale.ApPriority,
+		},
+		{
+			Name: model.AuditResultName,
+			Desc: model.AuditResultDesc,
+		},
+		{
+			Name: MetricNameLockType,
+			Desc: locale.ApMetricNameLockType,
+		},
+		{
+			Name: MetricNameLockObject,
+			Desc: locale.ApMetricNameLockObject,
+		},
+		{
+			Name: MetricNameBlockerSQL,
+			Desc: locale.ApMetricNameBlockerSQL,
+			Type: "sql",
+		},
+		{
+			Name:     MetricNameLockWaitTimeMax,
+			Desc:     locale.ApMetricNameLockWaitTimeMax,
+			Sortable: true,
+		},
+		{
+			Name:     MetricNameCounter,
+			Desc:     locale.ApMetricNameCounter,
+			Sortable: true,
+		},
+		{
+			Name: MetricNameDBUser,
+			Desc: locale.ApMetricNameDBUser,
+		},
+		{
+			Name:     MetricNameLastReceiveTimestamp,
+			Desc:     locale.ApMetricNameLastReceiveTimestamp,
+			Type:     "time",
+			Sortable: true,
+		},
+	}
+}
+
+func (at *MySQLLockWaitTaskV2) Filters(ctx context.Context, logger *logrus.Entry, ap *AuditPlan, persist *model.Storage) []FilterMeta {
+	lockTypeTips := make([]FilterTip, 0, len(lockTypeDesc))
+	for _, lockType := range []string{LockTypeRowLockWait, LockTypeMetadataLockWait, LockTypeLongTransaction} {
+		lockTypeTips = append(lockTypeTips, FilterTip{
+			Value: lockType,
+			Desc:  locale.Bundle.LocalizeMsgByCtx(ctx, lockTypeDesc[lockType]),
+		})
+	}
+	return []FilterMeta{
+		{
+			Name:            "sql", // 模糊筛选
+			Desc:            locale.ApSQLStatement,
+			FilterInputType: FilterInputTypeString,
+			FilterOpType:    FilterOpTypeEqual,
+		},
+		{
+			Name:            MetricNameLockType,
+			Desc:            locale.ApMetricNameLockType,
+			FilterInputType: FilterInputTypeString,
+			FilterOpType:    FilterOpTypeEqual,
+			FilterTips:      lockTypeTips,
+		},
+		{
+			Name:            MetricNameLockObject,
+			Desc:            locale.ApMetricNameLockObject,
+			FilterInputType: FilterInputTypeString,
+			FilterOpType:    FilterOpTypeEqual,
+			FilterTips:      GetSqlManagerMetricTips(logger, ap.ID, persist, MetricNameLockObject),
+		},
+		{
+			Name:            MetricNameDBUser,
+			Desc:            locale.ApMetricNameDBUser,
+			FilterInputType: FilterInputTypeString,
+			FilterOpType:    FilterOpTypeEqual,
+			FilterTips:      GetSqlManagerMetricTips(logger, ap.ID, persist, MetricNameDBUser),
+		},
+		{
+			Name:            "rule_name",
+			Desc:            locale.ApRuleName,
+			FilterInputType: FilterInputTypeString,
+			FilterOpType:    FilterOpTypeEqual,
+			FilterTips:      GetSqlManagerRuleTips(ctx, logger, ap.ID, persist),
+		},
+		{
+			Name:            "priority",
+			Desc:            locale.ApPriority,
+			FilterInputType: FilterInputTypeString,
+			FilterOpType:    FilterOpTypeEqual,
+			FilterTips:      GetSqlManagerPriorityTips(ctx, logger),
+		},
+		{
+			Name:            MetricNameLastReceiveTimestamp,
+			Desc:            locale.ApMetricNameLastReceiveTimestamp,
+			FilterInputType: FilterInputTypeDateTime,
+			FilterOpType:    FilterOpTypeBetween,
+		},
+	}
+}
+
+func (at *MySQLLockWaitTaskV2) GetSQLData(ctx context.Context, ap *AuditPlan, persist *model.Storage, filters []Filter, orderBy string, isAsc bool, limit, offset int) ([]map[string] /* head name */ string, uint64, error) {
+	auditPlanSQLs, count, err := persist.GetInstanceAuditPlanSQLsByReqV2(ap.ID, ap.Type, limit, offset, checkAndGetOrderByName(at.Head(ap), orderBy), isAsc, genArgsByFilters(filters))
+	if err != nil {
+		return nil, count, err
+	}
+	rows := make([]map[string]string, 0, len(auditPlanSQLs))
+	for _, sql := range auditPlanSQLs {
+		data, err := sql.Info.OriginValue()
+		if err != nil {
+			return nil, 0, err
+		}
+		info := LoadMetrics(data, at.Metrics())
+		lockType := info.Get(MetricNameLockType).String()
+		if desc, ok := lockTypeDesc[lockType]; ok {
+			lockType = locale.Bundle.LocalizeMsgByCtx(ctx, desc)
+		}
+		rows = append(rows, map[string]string{
+			"sql":                          sql.SQLContent,
+			"id":                           sql.AuditPlanSqlId,
+			"priority":                     sql.Priority.String,
+			MetricNameLockType:             lockType,
+			MetricNameLockObject:           info.Get(MetricNameLockObject).String(),
+			MetricNameBlockerSQL:           info.Get(MetricNameBlockerSQL).String(),
+			MetricNameLockWaitTimeMax:      strconv.FormatInt(info.Get(MetricNameLockWaitTimeMax).Int(), 10),
+			MetricNameCounter:              strconv.FormatInt(info.Get(MetricNameCounter).Int(), 10),
+			MetricNameDBUser:               info.Get(MetricNameDBUser).String(),
+			MetricNameLastReceiveTimestamp: info.Get(MetricNameLastReceiveTimestamp).String(),
+			model.AuditResultName:          sql.AuditResult.GetAuditJsonStrByLangTag(locale.Bundle.GetLangTagFromCtx(ctx)),
+			model.AuditStatus:              sql.AuditStatus,
+		})
+	}
+	return rows, count, nil
+}
@@ -0,0 +1,103 @@
+package auditplan
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
+
+func TestConvertLockEvents(t *testing.T) {
+	rows := []map[string]sql.NullString{
+		{
+			"thread_id": nullString("10"), "query": nullString("UPDATE t1 SET c1 = 1 WHERE id = 1"), "seconds": nullString("12"),
+			"lock_object": nullString("db1.t1"), "blocker_thread_id": nullString("11"), "blocker_query": nullString(""),
+			"db": nullString("db1"), "user": nullString("app"), "host": nullString("10.0.0.1:52341"),
+		},
+		{
+			// the session idle in the transaction is collected with its last statement
+			"thread_id": nullString("12"), "query": nullString(""), "seconds": nullString("3"),
+			"db": nullString("db1"), "user": nullString("app"), "host": nullString("localhost"),
+		},
+		{
+			// the session without any statement is skipped
+			"thread_id": nullString("13"), "query": nullString(""), "seconds": nullString("3"),
+		},
+		{
+			// the current connection is skipped
+			"thread_id": nullString("99"), "query": nullString("SELECT 1"), "seconds": nullString("3"),
+		},
+	}
+	lastStatements := map[string]string{
+		"11": "DELETE FROM t1 WHERE id = 1",
+		"12": "SELECT * FROM t2 FOR UPDATE",
+	}
+	events := convertLockEvents(LockTypeRowLockWait, rows, lastStatements, "99")
+	assert.Equal(t, []*lockEvent{
+		{
+			lockType:   LockTypeRowLockWait,
+			schema:     "db1",
+			sql:        "UPDATE t1 SET c1 = 1 WHERE id = 1",
+			user:       "app",
+			host:       "10.0.0.1",
+			seconds:    12,
+			lockObject: "db1.t1",
+			blockerSQL: "DELETE FROM t1 WHERE id = 1",
+		},
+		{
+			lockType: LockTypeRowLockWait,
+			schema:   "db1",
+			sql:      "SELECT * FROM t2 FOR UPDATE",
+			user:     "app",
+			host:     "localhost",
+			seconds:  3,
+		},
+	}, events)
+}
+
+func TestMySQLLockWaitTaskV2AggregateSQL(t *testing.T) {
+	at := &MySQLLockWaitTaskV2{}
+	newSQL := func(lockType string, seconds int64, blocker, host string) *SQLV2 {
+		sql := &SQLV2{
+			AuditPlanId: "1",
+			SchemaName:  "db1",
+			SQLContent:  "UPDATE t1 SET c1 = 1 WHERE id = 1",
+			Fingerprint: "UPDATE `t1` SET `c1`=? WHERE `id`=?",
+			Info:        NewMetrics(),
+		}
+		sql.Info.SetInt(MetricNameCounter, 1)
+		sql.Info.SetString(MetricNameLockType, lockType)
+		sql.Info.SetInt(MetricNameLockWaitTimeMax, seconds)
+		sql.Info.SetString(MetricNameBlockerSQL, blocker)
+		sql.Info.SetStringArray(MetricNameEndpoints, []string{host})
+		sql.SQLId = at.genSQLId(sql)
+		return sql
+	}
+
+	cache := NewSQLV2Cache()
+	assert.NoError(t, at.AggregateSQL(cache, newSQL(LockTypeRowLockWait, 12, "DELETE FROM t1", "10.0.0.1")))
+	assert.NoError(t, at.AggregateSQL(cache, newSQL(LockTypeRowLockWait, 5, "", "10.0.0.2")))
+	// the same SQL in the other lock type is collected separately
+	assert.NoError(t, at.AggregateSQL(cache, newSQL(LockTypeLongTransaction, 100, "", "10.0.0.1")))
+
+	sqls := cache.GetSQLs()
+	assert.Len(t, sqls, 2)
+	for _, sql := range sqls {
+		switch sql.Info.Get(MetricNameLockType).String() {
+		case LockTypeRowLockWait:
+			assert.Equal(t, int64(2), sql.Info.Get(MetricNameCounter).Int())
+			assert.Equal(t, int64(12), sql.Info.Get(MetricNameLockWaitTimeMax).Int())
+			assert.Equal(t, "DELETE FROM t1", sql.Info.Get(MetricNameBlockerSQL).String())
+			assert.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, sql.Info.Get(MetricNameEndpoints).StringArray())
+		case LockTypeLongTransaction:
+			assert.Equal(t, int64(1), sql.Info.Get(MetricNameCounter).Int())
+			assert.Equal(t, int64(100), sql.Info.Get(MetricNameLockWaitTimeMax).Int())
+		default:
+			t.Fatalf("unexpected lock type %v", sql.Info.Get(MetricNameLockType).String())
+		}
+	}
+}
@@ -216,6 +216,8 @@ func (at *BaseSchemaMetaTaskV2) ExtractSQL(logger *logrus.Entry, ap *AuditPlan,
 	if err != nil {
 		return nil, err
 	}
+	detectSchemaDrift(logger, ap, persist, sqls)
+
 	cache := NewSQLV2Cache()
 	// 1. 取出当期任务的存量数据，先将所有存量数据都标记为 MetricNameRecordDeleted = true(表示在扫描任务界面删除、但SQL管控内还可见)
 	// 2. 再将采集的数据和存量数据进行合并，所有还存在的数据还会被标记为 MetricNameRecordDeleted = false
@@ -68,10 +68,14 @@ func (at *MySQLSlowLogAliTaskV2) Params(instanceId ...string) params.Params {
 }
 
 func (at *MySQLSlowLogAliTaskV2) Metrics() []string {
-	return []string{
+	return append([]string{
 		MetricNameCounter,
 		MetricNameLastReceiveTimestamp,
-	}
+	}, SQLMetricHistoryMetrics...)
+}
+
+func (at *MySQLSlowLogAliTaskV2) HighPriorityParams() params.ParamsWithOperator {
+	return append(at.DefaultTaskV2.HighPriorityParams(), regressionFactorOperateParams)
 }
 
 func (at *MySQLSlowLogAliTaskV2) Audit(sqls []*model.SQLManageRecord) (*AuditResultResp, error) {
@@ -177,6 +181,8 @@ func (at *MySQLSlowLogAliTaskV2) ExtractSQL(logger *logrus.Entry, ap *AuditPlan,
 		// latest query time, todo: 是否可以从数据库取
 		info.SetString(MetricNameLastReceiveTimestamp, time.Now().Format(time.RFC3339))
 
+		setSlowLogMetrics(info, sql.queryTime, sql.lockTime, sql.rowsExamined)
+
 		sqlV2.Info = info
 		sqlV2.GenSQLId()
 		err = at.AggregateSQL(cache, sqlV2)
@@ -194,6 +200,10 @@ type AliSlowSqlRes struct {
 	sql                string
 	executionStartTime time.Time
 	schema             string
+	// seconds
+	queryTime    float64
+	lockTime     float64
+	rowsExamined int64
 }
 
 func (at *MySQLSlowLogAliTaskV2) CreateClient(rdsPath string, accessKeyId *string, accessKeySecret *string) (_result *rds20140815.Client, _err error) {
@@ -258,6 +268,13 @@ func (at *MySQLSlowLogAliTaskV2) pullSlowLogs(client *rds20140815.Client, DBInst
 			sql:                utils.NvlString(slowRecord.SQLText),
 			executionStartTime: execStartTime,
 			schema:             utils.NvlString(slowRecord.DBName),
+			queryTime:          float64(tea.Int64Value(slowRecord.QueryTimes)),
+			lockTime:           float64(tea.Int64Value(slowRecord.LockTimes)),
+			rowsExamined:       tea.Int64Value(slowRecord.ParseRowCounts),
+		}
+		// the query time in milliseconds is more accurate
+		if slowRecord.QueryTimeMS != nil {
+			sqls[i].queryTime = float64(*slowRecord.QueryTimeMS) / 1000
 		}
 	}
 	return sqls, nil
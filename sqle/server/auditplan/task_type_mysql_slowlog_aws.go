@@ -0,0 +1,293 @@
+package auditplan
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/actiontech/sqle/sqle/locale"
+	"github.com/actiontech/sqle/sqle/model"
+	"github.com/actiontech/sqle/sqle/pkg/params"
+	"github.com/sirupsen/logrus"
+)
+
+type MySQLSlowLogAwsTaskV2 struct {
+	DefaultTaskV2
+	watermark slowLogWatermark
+}
+
+func NewMySQLSlowLogAwsTaskV2Fn() func() interface{} {
+	return func() interface{} {
+		return &MySQLSlowLogAwsTaskV2{}
+	}
+}
+
+func (at *MySQLSlowLogAwsTaskV2) InstanceType() string {
+	return InstanceTypeMySQL
+}
+
+func (at *MySQLSlowLogAwsTaskV2) Params(instanceId ...string) params.Params {
+	return []*params.Param{
+		{
+			Key:      paramKeyDBInstanceId,
+			Value:    "",
+			Type:     params.ParamTypeString,
+			I18nDesc: locale.Bundle.LocalizeAll(locale.ParamDBInstanceId),
+		},
+		{
+			Key:      paramKeyAccessKeyId,
+			Value:    "",
+			Type:     params.ParamTypePassword,
+			I18nDesc: locale.Bundle.LocalizeAll(locale.ParamAccessKeyId),
+		},
+		{
+			Key:      paramKeyAccessKeySecret,
+			Value:    "",
+			Type:     params.ParamTypePassword,
+			I18nDesc: locale.Bundle.LocalizeAll(locale.ParamAccessKeySecret),
+		},
+		{
+			Key:      paramKeyRegion,
+			Value:    "",
+			Type:     params.ParamTypeString,
+			I18nDesc: locale.Bundle.LocalizeAll(locale.ParamAwsRegion),
+		},
+		{
+			Key: paramKeyFirstSqlsScrappedInLastPeriodHours,
+			// RDS的日志文件默认保留3天
+			Value:    "24",
+			Type:     params.ParamTypeInt,
+			I18nDesc: locale.Bundle.LocalizeAllWithArgs(locale.ParamFirstCollectDurationWithMaxDays, 3),
+		},
+		{
+			Key:      paramKeyRdsPath,
+			Value:    "",
+			Type:     params.ParamTypeString,
+			I18nDesc: locale.Bundle.LocalizeAll(locale.ParamAwsRdsPath),
+		},
+	}
+}
+
+func (at *MySQLSlowLogAwsTaskV2) Metrics() []string {
+	return append(at.DefaultTaskV2.Metrics(), SQLMetricHistoryMetrics...)
+}
+
+func (at *MySQLSlowLogAwsTaskV2) HighPriorityParams() params.ParamsWithOperator {
+	return append(at.DefaultTaskV2.HighPriorityParams(), regressionFactorOperateParams)
+}
+
+func (at *MySQLSlowLogAwsTaskV2) Audit(sqls []*model.SQLManageRecord) (*AuditResultResp, error) {
+	return auditSQLs(sqls)
+}
+
+func (at *MySQLSlowLogAwsTaskV2) ExtractSQL(logger *logrus.Entry, ap *AuditPlan, persist *model.Storage) ([]*SQLV2, error) {
+	instanceId := ap.Params.GetParam(paramKeyDBInstanceId).String()
+	if instanceId == "" {
+		return nil, fmt.Errorf("db instance id is not configured")
+	}
+	accessKeyId := ap.Params.GetParam(paramKeyAccessKeyId).String()
+	if accessKeyId == "" {
+		return nil, fmt.Errorf("aws access key id is not configured")
+	}
+	secretAccessKey := ap.Params.GetParam(paramKeyAccessKeySecret).String()
+	if secretAccessKey == "" {
+		return nil, fmt.Errorf("aws secret access key is not configured")
+	}
+	region := ap.Params.GetParam(paramKeyRegion).String()
+	if region == "" {
+		return nil, fmt.Errorf("aws region is not configured")
+	}
+	rdsPath := ap.Params.GetParam(paramKeyRdsPath).String()
+	if rdsPath == "" {
+		rdsPath = fmt.Sprintf("rds.%s.amazonaws.com", region)
+	}
+	endpoint, err := parseCloudAPIEndpoint(rdsPath)
+	if err != nil {
+		return nil, fmt.Errorf("aws rds api endpoint is invalid: %v", err)
+	}
+	firstScrapInLastHours, err := strconv.Atoi(ap.Params.GetParam(paramKeyFirstSqlsScrappedInLastPeriodHours).String())
+	if err != nil {
+		return nil, fmt.Errorf("convert first sqls scrapped in last period hours failed: %v", err)
+	}
+	if firstScrapInLastHours == 0 {
+		firstScrapInLastHours = 24
+	}
+	theMaxSupportedDays := 3 // 支持往前查看慢日志的最大天数
+	hoursDuringADay := 24
+	if firstScrapInLastHours > theMaxSupportedDays*hoursDuringADay {
+		logger.Warnf("Can not get slow logs from so early time. firstScrapInLastHours=%v", firstScrapInLastHours)
+		return nil, nil
+	}
+
+	var startTime time.Time
+	if at.watermark.isFirst() {
+		startTime = time.Now().Add(time.Duration(-1*firstScrapInLastHours) * time.Hour)
+	} else {
+		startTime = *at.watermark.time
+	}
+
+	client := &awsRdsClient{
+		endpoint:        endpoint.String(),
+		region:          region,
+		accessKeyId:     accessKeyId,
+		secretAccessKey: secretAccessKey,
+	}
+	// 慢日志文件的最后写入时间在开始时间之后，才可能包含开始时间之后的慢日志
+	files, err := client.describeSlowLogFiles(instanceId, startTime)
+	if err != nil {
+		return nil, fmt.Errorf("describe aws rds slow log files failed: %v", err)
+	}
+
+	entries := []*slowLogEntry{}
+	for _, file := range files {
+		content, err := client.downloadLogFile(instanceId, file.LogFileName)
+		if err != nil {
+			return nil, fmt.Errorf("download aws rds slow log %s failed: %v", file.LogFileName, err)
+		}
+		fileEntries, err := parseMySQLSlowLog(strings.NewReader(content))
+		if err != nil {
+			return nil, fmt.Errorf("parse aws rds slow log %s failed: %v", file.LogFileName, err)
+		}
+		entries = append(entries, fileEntries...)
+	}
+
+	// 按慢日志的写入时间缓存采集时间点
+	entries = at.watermark.collect(entries, startTime)
+	return convertSlowLogEntriesToSQLs(logger, ap, at, entries), nil
+}
+
+type awsDBLogFile struct {
+	LogFileName string `xml:"LogFileName"`
+	// milliseconds since the epoch
+	LastWritten int64 `xml:"LastWritten"`
+	Size        int64 `xml:"Size"`
+}
+
+type awsDescribeDBLogFilesResponse struct {
+	XMLName xml.Name `xml:"DescribeDBLogFilesResponse"`
+	Result  struct {
+		Files  []*awsDBLogFile `xml:"DescribeDBLogFiles>DescribeDBLogFilesDetails"`
+		Marker string          `xml:"Marker"`
+	} `xml:"DescribeDBLogFilesResult"`
+}
+
+type awsDownloadDBLogFilePortionResponse struct {
+	XMLName xml.Name `xml:"DownloadDBLogFilePortionResponse"`
+	Result  struct {
+		LogFileData           string `xml:"LogFileData"`
+		Marker                string `xml:"Marker"`
+		AdditionalDataPending bool   `xml:"AdditionalDataPending"`
+	} `xml:"DownloadDBLogFilePortionResult"`
+}
+
+type awsErrorResponse struct {
+	Error struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	} `xml:"Error"`
+	RequestId string `xml:"RequestId"`
+}
+
+// awsRdsClient requests the query API (version 2014-10-31) of amazon RDS, which is also the API of aurora.
+type awsRdsClient struct {
+	endpoint        string
+	region          string
+	accessKeyId     string
+	secretAccessKey string
+}
+
+const (
+	awsRdsService    = "rds"
+	awsRdsAPIVersion = "2014-10-31"
+	// the slow log files of MySQL on RDS are named as slowquery/mysql-slowquery.log*
+	awsSlowLogFileNameContains = "slowquery"
+)
+
+// describeSlowLogFiles returns the slow log files written after the time, sorted by the last written time.
+func (c *awsRdsClient) describeSlowLogFiles(instanceId string, writtenAfter time.Time) ([]*awsDBLogFile, error) {
+	files := []*awsDBLogFile{}
+	marker := ""
+	for {
+		form := url.Values{}
+		form.Set("DBInstanceIdentifier", instanceId)
+		form.Set("FilenameContains", awsSlowLogFileNameContains)
+		form.Set("FileLastWritten", strconv.FormatInt(writtenAfter.UnixNano()/int64(time.Millisecond), 10))
+		if marker != "" {
+			form.Set("Marker", marker)
+		}
+		resp := &awsDescribeDBLogFilesResponse{}
+		if err := c.call("DescribeDBLogFiles", form, resp); err != nil {
+			return nil, err
+		}
+		files = append(files, resp.Result.Files...)
+		marker = resp.Result.Marker
+		if marker == "" {
+			break
+		}
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		return files[i].LastWritten < files[j].LastWritten
+	})
+	return files, nil
+}
+
+// downloadLogFile downloads the whole log file by portions.
+func (c *awsRdsClient) downloadLogFile(instanceId, fileName string) (string, error) {
+	content := strings.Builder{}
+	marker := "0"
+	for {
+		form := url.Values{}
+		form.Set("DBInstanceIdentifier", instanceId)
+		form.Set("LogFileName", fileName)
+		form.Set("Marker", marker)
+		resp := &awsDownloadDBLogFilePortionResponse{}
+		if err := c.call("DownloadDBLogFilePortion", form, resp); err != nil {
+			return "", err
+		}
+		content.WriteString(resp.Result.LogFileData)
+		if !resp.Result.AdditionalDataPending || resp.Result.Marker == "" || resp.Result.Marker == marker {
+			break
+		}
+		marker = resp.Result.Marker
+	}
+	return content.String(), nil
+}
+
+func (c *awsRdsClient) call(action string, form url.Values, response interface{}) error {
+	form.Set("Action", action)
+	form.Set("Version", awsRdsAPIVersion)
+	body := []byte(form.Encode())
+	req, err := http.NewRequest(http.MethodPost, c.endpoint, strings.NewReader(string(body)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	signAWSV4(req, body, awsRdsService, c.region, c.accessKeyId, c.secretAccessKey, time.Now())
+
+	resp, err := cloudAPIClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		errResp := &awsErrorResponse{}
+		if xml.Unmarshal(respBody, errResp) == nil && errResp.Error.Code != "" {
+			return fmt.Errorf("request %s failed, code: %s, message: %s, request id: %s", action, errResp.Error.Code, errResp.Error.Message, errResp.RequestId)
+		}
+		return fmt.Errorf("request %s failed, status: %s, body: %s", action, resp.Status, respBody)
+	}
+	if err := xml.Unmarshal(respBody, response); err != nil {
+		return fmt.Errorf("unmarshal the response of %s failed: %v", action, err)
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package auditplan
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/actiontech/sqle/sqle/log"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// awsRdsStandIn is a local stand-in of the amazon RDS query API, the log files are downloaded by the portions of the
+// portion size.
+type awsRdsStandIn struct {
+	mu          sync.Mutex
+	files       map[string]string // file name -> slow log
+	lastWritten map[string]time.Time
+	portionSize int
+}
+
+func (s *awsRdsStandIn) addFile(name string, lastWritten time.Time, content string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files[name] = content
+	s.lastWritten[name] = lastWritten
+}
+
+func (s *awsRdsStandIn) handler(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=ak/"))
+		assert.True(t, strings.Contains(r.Header.Get("Authorization"), "/us-east-1/rds/aws4_request"))
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, awsRdsAPIVersion, r.PostForm.Get("Version"))
+		if r.PostForm.Get("DBInstanceIdentifier") != "database-1" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`<ErrorResponse><Error><Code>DBInstanceNotFound</Code><Message>DBInstance database-2 not found.</Message></Error><RequestId>1</RequestId></ErrorResponse>`))
+			return
+		}
+
+		switch r.PostForm.Get("Action") {
+		case "DescribeDBLogFiles":
+			assert.Equal(t, awsSlowLogFileNameContains, r.PostForm.Get("FilenameContains"))
+			writtenAfter, err := strconv.ParseInt(r.PostForm.Get("FileLastWritten"), 10, 64)
+			assert.NoError(t, err)
+			resp := &awsDescribeDBLogFilesResponse{}
+			for name := range s.files {
+				lastWritten := s.lastWritten[name].UnixNano() / int64(time.Millisecond)
+				if lastWritten < writtenAfter {
+					continue
+				}
+				resp.Result.Files = append(resp.Result.Files, &awsDBLogFile{
+					LogFileName: name,
+					LastWritten: lastWritten,
+					Size:        int64(len(s.files[name])),
+				})
+			}
+			assert.NoError(t, xml.NewEncoder(w).Encode(resp))
+		case "DownloadDBLogFilePortion":
+			content := s.files[r.PostForm.Get("LogFileName")]
+			start, err := strconv.Atoi(r.PostForm.Get("Marker"))
+			assert.NoError(t, err)
+			end := start + s.portionSize
+			if end > len(content) {
+				end = len(content)
+			}
+			resp := &awsDownloadDBLogFilePortionResponse{}
+			resp.Result.LogFileData = content[start:end]
+			resp.Result.Marker = strconv.Itoa(end)
+			resp.Result.AdditionalDataPending = end < len(content)
+			assert.NoError(t, xml.NewEncoder(w).Encode(resp))
+		default:
+			t.Errorf("unexpected action %s", r.PostForm.Get("Action"))
+		}
+	}
+}
+
+func TestMySQLSlowLogAwsTaskV2ExtractSQL(t *testing.T) {
+	standIn := &awsRdsStandIn{files: map[string]string{}, lastWritten: map[string]time.Time{}, portionSize: 100}
+	server := httptest.NewServer(standIn.handler(t))
+	defer server.Close()
+
+	now := time.Now().Truncate(time.Second)
+	standIn.addFile("slowquery/mysql-slowquery.log.1", now.Add(-30*time.Hour), newTestSlowLog("SELECT * FROM t1 WHERE id = 0;", now.Add(-31*time.Hour)))
+	standIn.addFile("slowquery/mysql-slowquery.log", now.Add(-time.Hour), newTestSlowLog("SELECT * FROM t1 WHERE id = 1;",
+		// the SQL before the first collect duration is skipped
+		now.Add(-25*time.Hour), now.Add(-3*time.Hour), now.Add(-2*time.Hour)))
+
+	at := &MySQLSlowLogAwsTaskV2{}
+	ap := newTestCloudSlowLogAuditPlan(t, TypeAwsRdsMySQLSlowLog, at.Params(), map[string]string{
+		paramKeyDBInstanceId:    "database-1",
+		paramKeyAccessKeyId:     "ak",
+		paramKeyAccessKeySecret: "sk",
+		paramKeyRegion:          "us-east-1",
+		paramKeyRdsPath:         server.URL,
+	})
+	logger := log.NewEntry()
+
+	sqls, err := at.ExtractSQL(logger, ap, nil)
+	assert.NoError(t, err)
+	assert.Len(t, sqls, 1)
+	assert.Equal(t, "SELECT * FROM t1 WHERE id = 1;", sqls[0].SQLContent)
+	assert.Equal(t, "db1", sqls[0].SchemaName)
+	assert.Equal(t, int64(2), sqls[0].Info.Get(MetricNameCounter).Int())
+	assert.Equal(t, float64(100), sqls[0].Info.Get(MetricNameRowExaminedAvg).Float())
+	assert.Equal(t, []string{"10.0.0.1"}, sqls[0].Info.Get(MetricNameEndpoints).StringArray())
+	assert.Equal(t, now.Add(-2*time.Hour).UTC(), *at.watermark.time)
+
+	// the active log file is written continuously, only the SQLs after the last collected one are extracted
+	standIn.addFile("slowquery/mysql-slowquery.log", now, newTestSlowLog("SELECT * FROM t1 WHERE id = 1;",
+		now.Add(-3*time.Hour), now.Add(-2*time.Hour), now.Add(-time.Minute)))
+	sqls, err = at.ExtractSQL(logger, ap, nil)
+	assert.NoError(t, err)
+	assert.Len(t, sqls, 1)
+	assert.Equal(t, int64(1), sqls[0].Info.Get(MetricNameCounter).Int())
+	assert.Equal(t, now.Add(-time.Minute).UTC(), *at.watermark.time)
+
+	assert.NoError(t, ap.Params.SetParamValue(paramKeyDBInstanceId, "database-2"))
+	_, err = at.ExtractSQL(logger, ap, nil)
+	assert.ErrorContains(t, err, "DBInstanceNotFound")
+}
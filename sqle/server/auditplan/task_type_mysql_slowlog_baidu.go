@@ -67,6 +67,14 @@ func (at *MySQLSlowLogBaiduTaskV2) Params(instanceId ...string) params.Params {
 	}
 }
 
+func (at *MySQLSlowLogBaiduTaskV2) Metrics() []string {
+	return append(at.DefaultTaskV2.Metrics(), SQLMetricHistoryMetrics...)
+}
+
+func (at *MySQLSlowLogBaiduTaskV2) HighPriorityParams() params.ParamsWithOperator {
+	return append(at.DefaultTaskV2.HighPriorityParams(), regressionFactorOperateParams)
+}
+
 func (at *MySQLSlowLogBaiduTaskV2) Audit(sqls []*model.SQLManageRecord) (*AuditResultResp, error) {
 	return auditSQLs(sqls)
 }
@@ -175,6 +183,8 @@ func (at *MySQLSlowLogBaiduTaskV2) ExtractSQL(logger *logrus.Entry, ap *AuditPla
 		// latest query time, todo: 是否可以从数据库取
 		info.SetString(MetricNameLastReceiveTimestamp, time.Now().Format(time.RFC3339))
 
+		setSlowLogMetrics(info, sql.queryTime, sql.lockTime, sql.rowsExamined)
+
 		sqlV2.Info = info
 		sqlV2.GenSQLId()
 		err = at.AggregateSQL(cache, sqlV2)
@@ -192,6 +202,10 @@ type BaiduSlowSqlRes struct {
 	sql                string
 	executionStartTime time.Time
 	schema             string
+	// seconds
+	queryTime    float64
+	lockTime     float64
+	rowsExamined int64
 }
 
 type slowLogReq struct {
@@ -265,6 +279,9 @@ func (at *MySQLSlowLogBaiduTaskV2) pullSlowLogs(client *rds.Client, instanceID s
 			sql:                slowLog.Sql,
 			executionStartTime: execStartTime,
 			schema:             slowLog.DbName,
+			queryTime:          slowLog.QueryTime,
+			lockTime:           slowLog.LockTime,
+			rowsExamined:       int64(slowLog.RowsExamined),
 		}
 	}
 
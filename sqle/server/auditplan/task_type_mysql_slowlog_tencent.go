@@ -0,0 +1,290 @@
+package auditplan
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/actiontech/sqle/sqle/locale"
+	"github.com/actiontech/sqle/sqle/model"
+	"github.com/actiontech/sqle/sqle/pkg/params"
+	"github.com/sirupsen/logrus"
+)
+
+type MySQLSlowLogTencentTaskV2 struct {
+	DefaultTaskV2
+	watermark slowLogWatermark
+}
+
+func NewMySQLSlowLogTencentTaskV2Fn() func() interface{} {
+	return func() interface{} {
+		return &MySQLSlowLogTencentTaskV2{}
+	}
+}
+
+func (at *MySQLSlowLogTencentTaskV2) InstanceType() string {
+	return InstanceTypeMySQL
+}
+
+func (at *MySQLSlowLogTencentTaskV2) Params(instanceId ...string) params.Params {
+	return []*params.Param{
+		{
+			Key:      paramKeyDBInstanceId,
+			Value:    "",
+			Type:     params.ParamTypeString,
+			I18nDesc: locale.Bundle.LocalizeAll(locale.ParamDBInstanceId),
+		},
+		{
+			Key:      paramKeyAccessKeyId,
+			Value:    "",
+			Type:     params.ParamTypePassword,
+			I18nDesc: locale.Bundle.LocalizeAll(locale.ParamAccessKeyId),
+		},
+		{
+			Key:      paramKeyAccessKeySecret,
+			Value:    "",
+			Type:     params.ParamTypePassword,
+			I18nDesc: locale.Bundle.LocalizeAll(locale.ParamAccessKeySecret),
+		},
+		{
+			Key:      paramKeyRegion,
+			Value:    "",
+			Type:     params.ParamTypeString,
+			I18nDesc: locale.Bundle.LocalizeAll(locale.ParamTencentCloudRegion),
+		},
+		{
+			Key: paramKeyFirstSqlsScrappedInLastPeriodHours,
+			// 只拉取最近7天备份的慢日志文件
+			Value:    "24",
+			Type:     params.ParamTypeInt,
+			I18nDesc: locale.Bundle.LocalizeAllWithArgs(locale.ParamFirstCollectDurationWithMaxDays, 7),
+		},
+		{
+			Key:      paramKeyRdsPath,
+			Value:    "cdb.tencentcloudapi.com",
+			Type:     params.ParamTypeString,
+			I18nDesc: locale.Bundle.LocalizeAll(locale.ParamRdsPath),
+		},
+	}
+}
+
+func (at *MySQLSlowLogTencentTaskV2) Metrics() []string {
+	return append(at.DefaultTaskV2.Metrics(), SQLMetricHistoryMetrics...)
+}
+
+func (at *MySQLSlowLogTencentTaskV2) HighPriorityParams() params.ParamsWithOperator {
+	return append(at.DefaultTaskV2.HighPriorityParams(), regressionFactorOperateParams)
+}
+
+func (at *MySQLSlowLogTencentTaskV2) Audit(sqls []*model.SQLManageRecord) (*AuditResultResp, error) {
+	return auditSQLs(sqls)
+}
+
+func (at *MySQLSlowLogTencentTaskV2) ExtractSQL(logger *logrus.Entry, ap *AuditPlan, persist *model.Storage) ([]*SQLV2, error) {
+	instanceId := ap.Params.GetParam(paramKeyDBInstanceId).String()
+	if instanceId == "" {
+		return nil, fmt.Errorf("db instance id is not configured")
+	}
+	secretId := ap.Params.GetParam(paramKeyAccessKeyId).String()
+	if secretId == "" {
+		return nil, fmt.Errorf("tencent cloud secret id is not configured")
+	}
+	secretKey := ap.Params.GetParam(paramKeyAccessKeySecret).String()
+	if secretKey == "" {
+		return nil, fmt.Errorf("tencent cloud secret key is not configured")
+	}
+	region := ap.Params.GetParam(paramKeyRegion).String()
+	if region == "" {
+		return nil, fmt.Errorf("tencent cloud region is not configured")
+	}
+	endpoint, err := parseCloudAPIEndpoint(ap.Params.GetParam(paramKeyRdsPath).String())
+	if err != nil {
+		return nil, fmt.Errorf("tencent cloud api endpoint is invalid: %v", err)
+	}
+	firstScrapInLastHours, err := strconv.Atoi(ap.Params.GetParam(paramKeyFirstSqlsScrappedInLastPeriodHours).String())
+	if err != nil {
+		return nil, fmt.Errorf("convert first sqls scrapped in last period hours failed: %v", err)
+	}
+	if firstScrapInLastHours == 0 {
+		firstScrapInLastHours = 24
+	}
+	theMaxSupportedDays := 7 // 支持往前查看慢日志的最大天数
+	hoursDuringADay := 24
+	if firstScrapInLastHours > theMaxSupportedDays*hoursDuringADay {
+		logger.Warnf("Can not get slow logs from so early time. firstScrapInLastHours=%v", firstScrapInLastHours)
+		return nil, nil
+	}
+
+	var startTime time.Time
+	if at.watermark.isFirst() {
+		startTime = time.Now().Add(time.Duration(-1*firstScrapInLastHours) * time.Hour)
+	} else {
+		startTime = *at.watermark.time
+	}
+
+	client := &tencentCdbClient{
+		endpoint:  endpoint.String(),
+		region:    region,
+		secretId:  secretId,
+		secretKey: secretKey,
+	}
+	files, err := client.describeSlowLogs(instanceId)
+	if err != nil {
+		return nil, fmt.Errorf("describe tencent cloud cdb slow logs failed: %v", err)
+	}
+
+	// 慢日志文件的时间是文件的备份时间，文件中的慢日志都在这个时间之前，所以只需要下载在开始时间之后备份的文件
+	entries := []*slowLogEntry{}
+	for _, file := range filterTencentSlowLogFiles(files, startTime) {
+		fileEntries, err := at.downloadSlowLog(file)
+		if err != nil {
+			return nil, fmt.Errorf("download tencent cloud cdb slow log %s failed: %v", file.Name, err)
+		}
+		entries = append(entries, fileEntries...)
+	}
+
+	// 按慢日志的写入时间缓存采集时间点
+	entries = at.watermark.collect(entries, startTime)
+	return convertSlowLogEntriesToSQLs(logger, ap, at, entries), nil
+}
+
+func (at *MySQLSlowLogTencentTaskV2) downloadSlowLog(file *tencentSlowLogFile) ([]*slowLogEntry, error) {
+	fileURL := file.InternetUrl
+	if fileURL == "" {
+		fileURL = file.IntranetUrl
+	}
+	if fileURL == "" {
+		return nil, fmt.Errorf("download url is empty")
+	}
+	body, err := downloadCloudFile(fileURL)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return parseMySQLSlowLog(body)
+}
+
+// the time of the tencent cloud api is in Beijing time
+var tencentCloudTimeLocation = time.FixedZone("CST", 8*60*60)
+
+const tencentCloudTimeFormat = "2006-01-02 15:04:05"
+
+type tencentSlowLogFile struct {
+	Name        string `json:"Name"`
+	Size        int64  `json:"Size"`
+	Date        string `json:"Date"`
+	IntranetUrl string `json:"IntranetUrl"`
+	InternetUrl string `json:"InternetUrl"`
+	Type        string `json:"Type"`
+
+	date time.Time
+}
+
+// filterTencentSlowLogFiles keeps the files backed up after the start time, and sorts them by the backup time.
+func filterTencentSlowLogFiles(files []*tencentSlowLogFile, startTime time.Time) []*tencentSlowLogFile {
+	res := []*tencentSlowLogFile{}
+	for _, file := range files {
+		if file.date.After(startTime) {
+			res = append(res, file)
+		}
+	}
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].date.Before(res[j].date)
+	})
+	return res
+}
+
+type tencentCloudError struct {
+	Code    string `json:"Code"`
+	Message string `json:"Message"`
+}
+
+type tencentDescribeSlowLogsResponse struct {
+	Response struct {
+		TotalCount int64                 `json:"TotalCount"`
+		Items      []*tencentSlowLogFile `json:"Items"`
+		Error      *tencentCloudError    `json:"Error"`
+		RequestId  string                `json:"RequestId"`
+	} `json:"Response"`
+}
+
+// tencentCdbClient requests the cloud database API (version 2017-03-20) of tencent cloud.
+type tencentCdbClient struct {
+	endpoint  string
+	region    string
+	secretId  string
+	secretKey string
+}
+
+const (
+	tencentCdbService           = "cdb"
+	tencentCdbAPIVersion        = "2017-03-20"
+	tencentDescribeSlowLogsSize = 100
+)
+
+func (c *tencentCdbClient) describeSlowLogs(instanceId string) ([]*tencentSlowLogFile, error) {
+	files := []*tencentSlowLogFile{}
+	for offset := 0; ; offset += tencentDescribeSlowLogsSize {
+		resp := &tencentDescribeSlowLogsResponse{}
+		err := c.call("DescribeSlowLogs", map[string]interface{}{
+			"InstanceId": instanceId,
+			"Offset":     offset,
+			"Limit":      tencentDescribeSlowLogsSize,
+		}, resp)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range resp.Response.Items {
+			file.date, err = time.ParseInLocation(tencentCloudTimeFormat, file.Date, tencentCloudTimeLocation)
+			if err != nil {
+				return nil, fmt.Errorf("parse the date of slow log %s failed: %v", file.Name, err)
+			}
+			files = append(files, file)
+		}
+		if len(resp.Response.Items) < tencentDescribeSlowLogsSize || int64(len(files)) >= resp.Response.TotalCount {
+			break
+		}
+	}
+	return files, nil
+}
+
+func (c *tencentCdbClient) call(action string, request interface{}, response *tencentDescribeSlowLogsResponse) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("X-TC-Action", action)
+	req.Header.Set("X-TC-Version", tencentCdbAPIVersion)
+	req.Header.Set("X-TC-Region", c.region)
+	signTencentCloudTC3(req, body, tencentCdbService, c.secretId, c.secretKey, time.Now())
+
+	resp, err := cloudAPIClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("request %s failed, status: %s, body: %s", action, resp.Status, respBody)
+	}
+	if err := json.Unmarshal(respBody, response); err != nil {
+		return fmt.Errorf("unmarshal the response of %s failed: %v", action, err)
+	}
+	if e := response.Response.Error; e != nil {
+		return fmt.Errorf("request %s failed, code: %s, message: %s, request id: %s", action, e.Code, e.Message, response.Response.RequestId)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package auditplan
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/actiontech/sqle/sqle/log"
+	"github.com/actiontech/sqle/sqle/pkg/params"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// tencentCdbStandIn is a local stand-in of the tencent cloud cdb API and the slow log download service.
+type tencentCdbStandIn struct {
+	mu    sync.Mutex
+	files map[string]string // file name -> slow log
+	dates map[string]time.Time
+}
+
+func (s *tencentCdbStandIn) addFile(name string, date time.Time, content string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files[name] = content
+	s.dates[name] = date
+}
+
+func (s *tencentCdbStandIn) handler(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if r.Method == http.MethodGet {
+			content, ok := s.files[strings.TrimPrefix(r.URL.Path, "/download/")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			_, _ = io.WriteString(w, content)
+			return
+		}
+
+		assert.Equal(t, "DescribeSlowLogs", r.Header.Get("X-TC-Action"))
+		assert.Equal(t, tencentCdbAPIVersion, r.Header.Get("X-TC-Version"))
+		assert.Equal(t, "ap-guangzhou", r.Header.Get("X-TC-Region"))
+		assert.True(t, strings.HasPrefix(r.Header.Get("Authorization"), "TC3-HMAC-SHA256 Credential=ak/"))
+		req := map[string]interface{}{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&req))
+		if req["InstanceId"] != "cdb-1" {
+			_, _ = io.WriteString(w, `{"Response":{"Error":{"Code":"InvalidParameter","Message":"instance not found"},"RequestId":"1"}}`)
+			return
+		}
+
+		resp := &tencentDescribeSlowLogsResponse{}
+		resp.Response.TotalCount = int64(len(s.files))
+		for name := range s.files {
+			resp.Response.Items = append(resp.Response.Items, &tencentSlowLogFile{
+				Name:        name,
+				Date:        s.dates[name].In(tencentCloudTimeLocation).Format(tencentCloudTimeFormat),
+				InternetUrl: "http://" + r.Host + "/download/" + name,
+				Type:        "slowlog",
+			})
+		}
+		assert.NoError(t, json.NewEncoder(w).Encode(resp))
+	}
+}
+
+func newTestCloudSlowLogAuditPlan(t *testing.T, apType string, ps params.Params, values map[string]string) *AuditPlan {
+	for key, value := range values {
+		assert.NoError(t, ps.SetParamValue(key, value))
+	}
+	return &AuditPlan{
+		ID:                  1,
+		ProjectId:           "1",
+		InstanceID:          "1",
+		Type:                apType,
+		Params:              ps,
+		InstanceAuditPlanId: 1,
+	}
+}
+
+func TestMySQLSlowLogTencentTaskV2ExtractSQL(t *testing.T) {
+	standIn := &tencentCdbStandIn{files: map[string]string{}, dates: map[string]time.Time{}}
+	server := httptest.NewServer(standIn.handler(t))
+	defer server.Close()
+
+	now := time.Now().Truncate(time.Second)
+	standIn.addFile("slow-1.log", now.Add(-30*time.Hour), newTestSlowLog("SELECT * FROM t1 WHERE id = 0;", now.Add(-31*time.Hour)))
+	standIn.addFile("slow-2.log", now.Add(-time.Hour), newTestSlowLog("SELECT * FROM t1 WHERE id = 1;",
+		// the SQL before the first collect duration is skipped
+		now.Add(-25*time.Hour), now.Add(-3*time.Hour), now.Add(-2*time.Hour)))
+
+	at := &MySQLSlowLogTencentTaskV2{}
+	ap := newTestCloudSlowLogAuditPlan(t, TypeTencentCdbMySQLSlowLog, at.Params(), map[string]string{
+		paramKeyDBInstanceId:    "cdb-1",
+		paramKeyAccessKeyId:     "ak",
+		paramKeyAccessKeySecret: "sk",
+		paramKeyRegion:          "ap-guangzhou",
+		paramKeyRdsPath:         server.URL,
+	})
+	logger := log.NewEntry()
+
+	sqls, err := at.ExtractSQL(logger, ap, nil)
+	assert.NoError(t, err)
+	assert.Len(t, sqls, 1)
+	assert.Equal(t, "SELECT * FROM t1 WHERE id = 1;", sqls[0].SQLContent)
+	assert.Equal(t, "db1", sqls[0].SchemaName)
+	assert.Equal(t, int64(2), sqls[0].Info.Get(MetricNameCounter).Int())
+	assert.Equal(t, float64(2), sqls[0].Info.Get(MetricNameQueryTimeAvg).Float())
+	assert.Equal(t, "app", sqls[0].Info.Get(MetricNameDBUser).String())
+	assert.Equal(t, now.Add(-2*time.Hour).UTC(), *at.watermark.time)
+
+	// only the SQLs not collected are extracted, the SQL ending at the same time as the last collected one is extracted
+	standIn.addFile("slow-3.log", now, newTestSlowLog("SELECT * FROM t2 WHERE id = 1;", now.Add(-3*time.Hour), now.Add(-2*time.Hour), now.Add(-time.Minute)))
+	sqls, err = at.ExtractSQL(logger, ap, nil)
+	assert.NoError(t, err)
+	assert.Len(t, sqls, 1)
+	assert.Equal(t, int64(2), sqls[0].Info.Get(MetricNameCounter).Int())
+	assert.Equal(t, now.Add(-time.Minute).UTC(), *at.watermark.time)
+
+	sqls, err = at.ExtractSQL(logger, ap, nil)
+	assert.NoError(t, err)
+	assert.Len(t, sqls, 0)
+
+	assert.NoError(t, ap.Params.SetParamValue(paramKeyDBInstanceId, "cdb-2"))
+	_, err = at.ExtractSQL(logger, ap, nil)
+	assert.ErrorContains(t, err, "InvalidParameter")
+}
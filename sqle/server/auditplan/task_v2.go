@@ -94,6 +94,17 @@ type AuditPlanCollector interface {
 	ExtractSQL(logger *logrus.Entry, ap *AuditPlan, persist *model.Storage) ([]*SQLV2, error) // 处理 server 采集的数据
 }
 
+// AuditPlanCronCollector is implemented by the collector which is scheduled by a cron expression instead of the
+// collect interval, the collect interval is used if the expression is empty.
+type AuditPlanCronCollector interface {
+	CronExpression(ap *AuditPlan) string
+}
+
+// AuditPlanParamsValidator is implemented by the task whose params must be checked before the audit plan is saved.
+type AuditPlanParamsValidator interface {
+	ValidateParams(dbType string, ps params.Params) error
+}
+
 type AuditPlanHandler interface {
 	AggregateSQL(cache SQLV2Cacher, sql *SQLV2) error // 数据聚合
 	Audit([]*model.SQLManageRecord) (*AuditResultResp, error)
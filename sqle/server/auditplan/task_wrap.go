@@ -3,6 +3,7 @@ package auditplan
 import (
 	"context"
 	e "errors"
+	"fmt"
 	"net"
 	"regexp"
 	"strconv"
@@ -13,6 +14,7 @@ import (
 	"github.com/actiontech/sqle/sqle/driver"
 	driverV2 "github.com/actiontech/sqle/sqle/driver/v2"
 	"github.com/pkg/errors"
+	"github.com/robfig/cron/v3"
 
 	"github.com/actiontech/sqle/sqle/dms"
 	sqleErr "github.com/actiontech/sqle/sqle/errors"
@@ -102,6 +104,8 @@ type TaskWrapper struct {
 	isStarted    bool
 	cancel       chan struct{}
 	loopInterval func() time.Duration
+	// loopCron is the cron expression of the collection, it overrides the loopInterval if it is not empty
+	loopCron func() string
 }
 
 func NewTaskWrap(taskFn func() interface{}) func(entry *logrus.Entry, ap *AuditPlan) Task {
@@ -129,6 +133,11 @@ func NewTaskWrap(taskFn func() interface{}) func(entry *logrus.Entry, ap *AuditP
 				}
 				return time.Minute * time.Duration(60)
 			}
+			if cronCollect, ok := task.(AuditPlanCronCollector); ok {
+				tw.loopCron = func() string {
+					return cronCollect.CronExpression(ap)
+				}
+			}
 		}
 		if handler, ok := task.(AuditPlanHandler); ok {
 			tw.handler = handler
@@ -203,6 +212,22 @@ func (at *TaskWrapper) startCollect() error {
 	if at.isStarted {
 		return nil
 	}
+	if at.loopCron != nil {
+		if expr := at.loopCron(); expr != "" {
+			schedule, err := cron.ParseStandard(expr)
+			if err != nil {
+				return fmt.Errorf("invalid cron expression %s of task(%v), error: %v", expr, at.ap.Name, err)
+			}
+			at.WaitGroup.Add(1)
+			go func() {
+				at.isStarted = true
+				at.logger.Infof("start task with cron expression %s", expr)
+				at.loopByCron(at.cancel, schedule)
+				at.WaitGroup.Done()
+			}()
+			return nil
+		}
+	}
 	interval := at.loopInterval()
 
 	at.WaitGroup.Add(1)
@@ -228,15 +253,33 @@ func (at *TaskWrapper) stopCollect() error {
 
 func (at *TaskWrapper) extractSQL() {
 	var err error
+	run := NewCollectionRun(at.ap)
 	defer func() {
-		ProcessAuditPlanStatusAndLogError(at.logger, at.ap.ID, at.ap.InstanceID, at.ap.Type, err)
+		run.Finish(at.logger, err)
 	}()
+	// 管控SQL队列积压时，按配置推迟采集或者丢弃采集到的SQL
+	depth, err := checkSQLManageQueueDepth(at.persist)
+	run.SetQueueDepth(depth)
+	if e.Is(err, ErrSQLManageQueueFull) {
+		if getAuditPlanCollectionConfig().QueueFullPolicy == QueueFullPolicyDefer {
+			at.logger.Warnf("defer the collection, %v", err)
+			run.Defer()
+			err = nil
+			return
+		}
+		// 采集到的SQL在推送到队列时丢弃
+		err = nil
+	} else if err != nil {
+		at.logger.Errorf("check sql manage queue depth failed, %v", err)
+		return
+	}
 	collectionTime := time.Now()
 	sqls, err := at.collect.ExtractSQL(at.logger, at.ap, at.persist)
 	if err != nil {
 		at.logger.Errorf("extract sql failed, %v", err)
 		return
 	}
+	run.SetSQLCount(len(sqls))
 	// todo: 对于mysql慢日志类型，采集来源是scannerd的任务的时间不应该在此处更新
 	err = at.persist.UpdateAuditPlanLastCollectionTime(at.ap.ID, collectionTime)
 	if err != nil {
@@ -291,10 +334,30 @@ func (at *TaskWrapper) loop(cancel chan struct{}, interval time.Duration) {
 	}
 }
 
+func (at *TaskWrapper) loopByCron(cancel chan struct{}, schedule cron.Schedule) {
+	for {
+		tm := time.NewTimer(time.Until(schedule.Next(time.Now())))
+		select {
+		case <-cancel:
+			tm.Stop()
+			return
+		case <-tm.C:
+			at.logger.Infof("tick %s", at.ap.Name)
+			at.extractSQL()
+		}
+	}
+}
+
+// pushSQLToManagerSQLQueue pushes the SQLs to the queue. If the queue is full, the SQLs are dropped under the drop
+// policy. They are still pushed under the defer policy since they are already collected or uploaded, the queue is
+// drained by deferring the next collections.
 func (at *TaskWrapper) pushSQLToManagerSQLQueue(sqlList []*model.SQLManageQueue, ap *AuditPlan) error {
 	if len(sqlList) == 0 {
 		return nil
 	}
+	if err := checkSQLManageQueueBeforePush(at.logger, at.persist, len(sqlList)); err != nil {
+		return err
+	}
 
 	matchedCount, SqlQueueList, err := at.filterSqlManageQueue(sqlList)
 	if err != nil {
@@ -313,6 +376,8 @@ func (at *TaskWrapper) pushSQLToManagerSQLQueue(sqlList []*model.SQLManageQueue,
 			log.Logger().Errorf("createSqlManageCostMetricRecord: %v", err)
 		}
 	}
+	at.attributeSQLsApplication(SqlQueueList, ap)
+	at.recordSQLMetricHistory(SqlQueueList, ap)
 	err = at.persist.PushSQLToManagerSQLQueue(SqlQueueList)
 	if err != nil {
 		return err
@@ -0,0 +1,71 @@
+package server
+
+import (
+	"github.com/actiontech/sqle/sqle/driver/mysql/executor"
+	driverV2 "github.com/actiontech/sqle/sqle/driver/v2"
+	"github.com/actiontech/sqle/sqle/model"
+
+	"github.com/sirupsen/logrus"
+)
+
+// binlogRecorder records the binlog coordinates and GTID set of the MySQL instance before and after
+// the SQLs are executed. It uses a separate connection, so the coordinates of a statement may cover
+// the changes of the other sessions. It does nothing for the other databases or when the binlog is disabled.
+type binlogRecorder struct {
+	entry *logrus.Entry
+	conn  *executor.Executor
+}
+
+func newBinlogRecorder(entry *logrus.Entry, task *model.Task) *binlogRecorder {
+	if task.DBType != driverV2.DriverTypeMySQL || task.Instance == nil {
+		return nil
+	}
+	inst := task.Instance
+	conn, err := executor.NewExecutor(entry, &driverV2.DSN{
+		Host:             inst.Host,
+		Port:             inst.Port,
+		User:             inst.User,
+		Password:         inst.Password,
+		AdditionalParams: inst.AdditionalParams,
+	}, "")
+	if err != nil {
+		entry.Warnf("connect to instance to record binlog coordinates failed: %v", err)
+		return nil
+	}
+	return &binlogRecorder{entry: entry, conn: conn}
+}
+
+// status returns nil if the binlog coordinates can't be fetched.
+func (r *binlogRecorder) status() *executor.BinlogStatus {
+	if r == nil {
+		return nil
+	}
+	status, err := r.conn.FetchMasterBinlogStatus()
+	if err != nil {
+		r.entry.Warnf("fetch binlog coordinates failed: %v", err)
+		return nil
+	}
+	return status
+}
+
+// record fills the binlog coordinates of the SQLs executed between start and end.
+func (r *binlogRecorder) record(start, end *executor.BinlogStatus, sqls ...*model.ExecuteSQL) {
+	if start == nil || end == nil {
+		return
+	}
+	for _, sql := range sqls {
+		sql.StartBinlogFile = start.File
+		sql.StartBinlogPos = start.Pos
+		sql.StartGtidSet = start.GtidSet
+		sql.EndBinlogFile = end.File
+		sql.EndBinlogPos = end.Pos
+		sql.EndGtidSet = end.GtidSet
+	}
+}
+
+func (r *binlogRecorder) close() {
+	if r == nil {
+		return
+	}
+	r.conn.Db.Close()
+}
@@ -0,0 +1,400 @@
+package server
+
+import (
+	"context"
+	_errors "errors"
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/actiontech/sqle/sqle/config"
+	"github.com/actiontech/sqle/sqle/driver/mysql/executor"
+	"github.com/actiontech/sqle/sqle/driver/mysql/util"
+	driverV2 "github.com/actiontech/sqle/sqle/driver/v2"
+	"github.com/actiontech/sqle/sqle/errors"
+	"github.com/actiontech/sqle/sqle/model"
+
+	"github.com/go-mysql-org/go-mysql/mysql"
+	"github.com/go-mysql-org/go-mysql/replication"
+	"github.com/sirupsen/logrus"
+)
+
+const defaultBinlogReadTimeout = 30 * time.Second
+
+// BinlogFlashback is the inverse DML of the row events written to binlog by the executed SQLs. The SQLs executed
+// in one transaction share the binlog coordinates, so they are flashed back together.
+type BinlogFlashback struct {
+	ExecuteSQLs  []*model.ExecuteSQL
+	FlashbackSQL []string
+}
+
+// GenerateBinlogFlashback reads the row events in the binlog coordinates recorded for the executed SQLs of the MySQL task,
+// and generates the inverse DML in reverse order. Only the row events of the tables used by the SQLs are flashed back,
+// but the changes of the other sessions on the same tables at the same time can't be told apart.
+func GenerateBinlogFlashback(l *logrus.Entry, task *model.Task) ([]*BinlogFlashback, error) {
+	if task.DBType != driverV2.DriverTypeMySQL || task.Instance == nil {
+		return nil, errors.New(errors.DataInvalid, fmt.Errorf("binlog flashback only supports MySQL"))
+	}
+	flashbacks := groupSQLsByBinlogCoordinates(task.ExecuteSQLs)
+	if len(flashbacks) == 0 {
+		return flashbacks, nil
+	}
+
+	inst := task.Instance
+	conn, err := executor.NewExecutor(l, &driverV2.DSN{
+		Host:             inst.Host,
+		Port:             inst.Port,
+		User:             inst.User,
+		Password:         inst.Password,
+		AdditionalParams: inst.AdditionalParams,
+	}, "")
+	if err != nil {
+		return nil, errors.New(errors.ConnectRemoteDatabaseError, err)
+	}
+	defer conn.Db.Close()
+
+	reader := newBinlogReader(inst)
+	columns := newBinlogColumnNames(conn)
+	for _, flashback := range flashbacks {
+		tables, err := getBinlogFlashbackTables(flashback.ExecuteSQLs, task.Schema)
+		if err != nil {
+			return nil, err
+		}
+		first := flashback.ExecuteSQLs[0]
+		var sqls []string
+		err = reader.readRowsEvents(first.StartBinlogFile, first.StartBinlogPos, first.EndBinlogFile, first.EndBinlogPos,
+			func(typ replication.EventType, event *replication.RowsEvent) error {
+				schema, table := string(event.Table.Schema), string(event.Table.Table)
+				if _, ok := tables[strings.ToLower(schema+"."+table)]; !ok {
+					return nil
+				}
+				names, err := columns.get(event.Table)
+				if err != nil {
+					return err
+				}
+				inverse, err := generateInverseDML(typ, schema, table, names, event)
+				if err != nil {
+					return err
+				}
+				sqls = append(sqls, inverse...)
+				return nil
+			})
+		if err != nil {
+			return nil, fmt.Errorf("read binlog from %v:%v to %v:%v failed: %v",
+				first.StartBinlogFile, first.StartBinlogPos, first.EndBinlogFile, first.EndBinlogPos, err)
+		}
+		// the changes are flashed back in reverse order
+		for i, j := 0, len(sqls)-1; i < j; i, j = i+1, j-1 {
+			sqls[i], sqls[j] = sqls[j], sqls[i]
+		}
+		flashback.FlashbackSQL = sqls
+	}
+	return flashbacks, nil
+}
+
+func groupSQLsByBinlogCoordinates(executeSQLs []*model.ExecuteSQL) []*BinlogFlashback {
+	flashbacks := []*BinlogFlashback{}
+	index := map[string]*BinlogFlashback{}
+	for _, sql := range executeSQLs {
+		if sql.StartBinlogFile == "" || sql.EndBinlogFile == "" || sql.ExecStatus != model.SQLExecuteStatusSucceeded {
+			continue
+		}
+		key := fmt.Sprintf("%v:%v-%v:%v", sql.StartBinlogFile, sql.StartBinlogPos, sql.EndBinlogFile, sql.EndBinlogPos)
+		if flashback, ok := index[key]; ok {
+			flashback.ExecuteSQLs = append(flashback.ExecuteSQLs, sql)
+			continue
+		}
+		flashback := &BinlogFlashback{ExecuteSQLs: []*model.ExecuteSQL{sql}, FlashbackSQL: []string{}}
+		index[key] = flashback
+		flashbacks = append(flashbacks, flashback)
+	}
+	return flashbacks
+}
+
+// getBinlogFlashbackTables returns the lower case "schema.table" used by the SQLs.
+func getBinlogFlashbackTables(executeSQLs []*model.ExecuteSQL, defaultSchema string) (map[string]struct{}, error) {
+	tables := map[string]struct{}{}
+	for _, sql := range executeSQLs {
+		stmt, err := util.ParseOneSql(sql.Content)
+		if err != nil {
+			return nil, fmt.Errorf("parse SQL %d failed: %v", sql.Number, err)
+		}
+		schema := defaultSchema
+		if sql.Schema != "" {
+			schema = sql.Schema
+		}
+		extractor := util.TableNameListExtractor{}
+		stmt.Accept(&extractor)
+		for _, table := range extractor.TableNames {
+			tableSchema := schema
+			if table.Schema.O != "" {
+				tableSchema = table.Schema.O
+			}
+			tables[strings.ToLower(tableSchema+"."+table.Name.O)] = struct{}{}
+		}
+	}
+	return tables, nil
+}
+
+// binlogColumnNames gets the column names of the table from the binlog metadata, or from the instance
+// if the binlog_row_metadata of instance is not FULL.
+type binlogColumnNames struct {
+	conn  *executor.Executor
+	cache map[string][]string
+}
+
+func newBinlogColumnNames(conn *executor.Executor) *binlogColumnNames {
+	return &binlogColumnNames{conn: conn, cache: map[string][]string{}}
+}
+
+func (c *binlogColumnNames) get(table *replication.TableMapEvent) ([]string, error) {
+	if names := table.ColumnNameString(); len(names) == int(table.ColumnCount) {
+		return names, nil
+	}
+	schema, name := string(table.Schema), string(table.Table)
+	key := schema + "." + name
+	if names, ok := c.cache[key]; ok {
+		return names, nil
+	}
+	infos, err := c.conn.GetTableColumnsInfo(schema, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(infos) != int(table.ColumnCount) {
+		return nil, fmt.Errorf("the columns of table %v have been changed since the SQL was executed", key)
+	}
+	names := make([]string, 0, len(infos))
+	for _, info := range infos {
+		names = append(names, info.ColumnName)
+	}
+	c.cache[key] = names
+	return names, nil
+}
+
+// generateInverseDML generates the SQLs to revert the row changes of the event.
+func generateInverseDML(typ replication.EventType, schema, table string, columns []string, event *replication.RowsEvent) ([]string, error) {
+	for _, skipped := range event.SkippedColumns {
+		if len(skipped) > 0 {
+			return nil, fmt.Errorf("binlog_row_image of the instance should be FULL")
+		}
+	}
+	tableName := fmt.Sprintf("`%s`.`%s`", schema, table)
+	sqls := []string{}
+	switch typ {
+	case replication.WRITE_ROWS_EVENTv0, replication.WRITE_ROWS_EVENTv1, replication.WRITE_ROWS_EVENTv2:
+		for _, row := range event.Rows {
+			sqls = append(sqls, fmt.Sprintf("DELETE FROM %s WHERE %s LIMIT 1;", tableName, binlogRowCondition(columns, row)))
+		}
+	case replication.DELETE_ROWS_EVENTv0, replication.DELETE_ROWS_EVENTv1, replication.DELETE_ROWS_EVENTv2:
+		for _, row := range event.Rows {
+			names := make([]string, 0, len(row))
+			values := make([]string, 0, len(row))
+			for i, value := range row {
+				names = append(names, fmt.Sprintf("`%s`", columns[i]))
+				values = append(values, formatBinlogValue(value))
+			}
+			sqls = append(sqls, fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s);", tableName, strings.Join(names, ", "), strings.Join(values, ", ")))
+		}
+	case replication.UPDATE_ROWS_EVENTv0, replication.UPDATE_ROWS_EVENTv1, replication.UPDATE_ROWS_EVENTv2:
+		// the rows of update event are pairs of the row images before and after update
+		for i := 0; i+1 < len(event.Rows); i += 2 {
+			before, after := event.Rows[i], event.Rows[i+1]
+			sets := make([]string, 0, len(before))
+			for j, value := range before {
+				sets = append(sets, fmt.Sprintf("`%s` = %s", columns[j], formatBinlogValue(value)))
+			}
+			sqls = append(sqls, fmt.Sprintf("UPDATE %s SET %s WHERE %s LIMIT 1;", tableName, strings.Join(sets, ", "), binlogRowCondition(columns, after)))
+		}
+	}
+	return sqls, nil
+}
+
+func binlogRowCondition(columns []string, row []interface{}) string {
+	conditions := make([]string, 0, len(row))
+	for i, value := range row {
+		if value == nil {
+			conditions = append(conditions, fmt.Sprintf("`%s` IS NULL", columns[i]))
+			continue
+		}
+		conditions = append(conditions, fmt.Sprintf("`%s` = %s", columns[i], formatBinlogValue(value)))
+	}
+	return strings.Join(conditions, " AND ")
+}
+
+func formatBinlogValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "NULL"
+	case int8, int16, int32, int64, int, uint8, uint16, uint32, uint64, uint, float32, float64:
+		return fmt.Sprintf("%v", v)
+	case []byte:
+		return quoteBinlogString(string(v))
+	case string:
+		return quoteBinlogString(v)
+	default:
+		return quoteBinlogString(fmt.Sprintf("%v", v))
+	}
+}
+
+func quoteBinlogString(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
+
+type binlogRowsEventFunc func(typ replication.EventType, event *replication.RowsEvent) error
+
+// binlogReader reads the rows events between the binlog coordinates, the coordinates are the positions
+// of the events end, as they are shown by "SHOW MASTER STATUS".
+type binlogReader interface {
+	readRowsEvents(startFile string, startPos int64, endFile string, endPos int64, fn binlogRowsEventFunc) error
+}
+
+func newBinlogReader(inst *model.Instance) binlogReader {
+	opts := config.GetOptions().SqleOptions.Service.BinlogFlashback
+	timeout := defaultBinlogReadTimeout
+	if opts.ReadTimeoutSeconds > 0 {
+		timeout = time.Duration(opts.ReadTimeoutSeconds) * time.Second
+	}
+	if opts.LocalBinlogDir != "" {
+		return &localBinlogReader{dir: filepath.Join(opts.LocalBinlogDir, inst.Name)}
+	}
+	return &remoteBinlogReader{inst: inst, timeout: timeout}
+}
+
+var errBinlogReadFinished = _errors.New("binlog read finished")
+
+// binlogEventHandler dispatches the rows events before the end coordinate, and reports errBinlogReadFinished when
+// the end coordinate is reached.
+type binlogEventHandler struct {
+	file    string
+	endFile string
+	endPos  int64
+	fn      binlogRowsEventFunc
+}
+
+func (h *binlogEventHandler) handle(ev *replication.BinlogEvent) error {
+	if rotate, ok := ev.Event.(*replication.RotateEvent); ok {
+		h.file = string(rotate.NextLogName)
+		return nil
+	}
+	if ev.Header.LogPos == 0 {
+		// the fake events sent by replication protocol
+		return nil
+	}
+	if h.file == h.endFile && int64(ev.Header.LogPos) > h.endPos {
+		return errBinlogReadFinished
+	}
+	if rows, ok := ev.Event.(*replication.RowsEvent); ok {
+		if err := h.fn(ev.Header.EventType, rows); err != nil {
+			return err
+		}
+	}
+	if h.file == h.endFile && int64(ev.Header.LogPos) == h.endPos {
+		return errBinlogReadFinished
+	}
+	return nil
+}
+
+// remoteBinlogReader reads binlog from the instance as a replica.
+type remoteBinlogReader struct {
+	inst    *model.Instance
+	timeout time.Duration
+}
+
+func (r *remoteBinlogReader) readRowsEvents(startFile string, startPos int64, endFile string, endPos int64, fn binlogRowsEventFunc) error {
+	port, err := strconv.ParseUint(r.inst.Port, 10, 16)
+	if err != nil {
+		return fmt.Errorf("invalid port %v: %v", r.inst.Port, err)
+	}
+	syncer := replication.NewBinlogSyncer(replication.BinlogSyncerConfig{
+		// the server id should be unique in the replication topology
+		ServerID: uint32(rand.Int31n(1<<30)) + 1<<30,
+		Flavor:   mysql.MySQLFlavor,
+		Host:     r.inst.Host,
+		Port:     uint16(port),
+		User:     r.inst.User,
+		Password: r.inst.Password,
+	})
+	defer syncer.Close()
+
+	streamer, err := syncer.StartSync(mysql.Position{Name: startFile, Pos: uint32(startPos)})
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), r.timeout)
+	defer cancel()
+	handler := &binlogEventHandler{file: startFile, endFile: endFile, endPos: endPos, fn: fn}
+	for {
+		ev, err := streamer.GetEvent(ctx)
+		if err != nil {
+			return err
+		}
+		if err := handler.handle(ev); err == errBinlogReadFinished {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+// localBinlogReader reads the binlog files copied from the instance, the binlog files are named by sequence
+// number, such as mysql-bin.000001.
+type localBinlogReader struct {
+	dir string
+}
+
+func (r *localBinlogReader) readRowsEvents(startFile string, startPos int64, endFile string, endPos int64, fn binlogRowsEventFunc) error {
+	files, err := getBinlogFilesBetween(startFile, endFile)
+	if err != nil {
+		return err
+	}
+	handler := &binlogEventHandler{endFile: endFile, endPos: endPos, fn: fn}
+	parser := replication.NewBinlogParser()
+	for i, file := range files {
+		handler.file = file
+		offset := int64(4)
+		if i == 0 {
+			offset = startPos
+		}
+		err := parser.ParseFile(filepath.Join(r.dir, file), offset, handler.handle)
+		if err == errBinlogReadFinished || _errors.Is(err, errBinlogReadFinished) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func getBinlogFilesBetween(startFile, endFile string) ([]string, error) {
+	parse := func(file string) (string, int, int, error) {
+		idx := strings.LastIndex(file, ".")
+		if idx < 0 {
+			return "", 0, 0, fmt.Errorf("invalid binlog file name %v", file)
+		}
+		seq, err := strconv.Atoi(file[idx+1:])
+		if err != nil {
+			return "", 0, 0, fmt.Errorf("invalid binlog file name %v", file)
+		}
+		return file[:idx], seq, len(file) - idx - 1, nil
+	}
+	prefix, start, width, err := parse(startFile)
+	if err != nil {
+		return nil, err
+	}
+	endPrefix, end, _, err := parse(endFile)
+	if err != nil {
+		return nil, err
+	}
+	if prefix != endPrefix || end < start {
+		return nil, fmt.Errorf("invalid binlog files from %v to %v", startFile, endFile)
+	}
+	files := make([]string, 0, end-start+1)
+	for seq := start; seq <= end; seq++ {
+		files = append(files, fmt.Sprintf("%s.%0*d", prefix, width, seq))
+	}
+	return files, nil
+}
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/actiontech/sqle/sqle/model"
+
+	"github.com/go-mysql-org/go-mysql/replication"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateInverseDML(t *testing.T) {
+	columns := []string{"id", "name"}
+
+	sqls, err := generateInverseDML(replication.WRITE_ROWS_EVENTv2, "db1", "t1", columns, &replication.RowsEvent{
+		Rows: [][]interface{}{{int32(1), "a'b"}, {int32(2), nil}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"DELETE FROM `db1`.`t1` WHERE `id` = 1 AND `name` = 'a\\'b' LIMIT 1;",
+		"DELETE FROM `db1`.`t1` WHERE `id` = 2 AND `name` IS NULL LIMIT 1;",
+	}, sqls)
+
+	sqls, err = generateInverseDML(replication.DELETE_ROWS_EVENTv2, "db1", "t1", columns, &replication.RowsEvent{
+		Rows: [][]interface{}{{int32(1), []byte("a")}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"INSERT INTO `db1`.`t1` (`id`, `name`) VALUES (1, 'a');"}, sqls)
+
+	sqls, err = generateInverseDML(replication.UPDATE_ROWS_EVENTv2, "db1", "t1", columns, &replication.RowsEvent{
+		Rows: [][]interface{}{{int32(1), "a"}, {int32(1), "b"}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"UPDATE `db1`.`t1` SET `id` = 1, `name` = 'a' WHERE `id` = 1 AND `name` = 'b' LIMIT 1;"}, sqls)
+
+	_, err = generateInverseDML(replication.WRITE_ROWS_EVENTv2, "db1", "t1", columns, &replication.RowsEvent{
+		Rows:           [][]interface{}{{int32(1), nil}},
+		SkippedColumns: [][]int{{1}},
+	})
+	assert.Error(t, err)
+}
+
+func TestGroupSQLsByBinlogCoordinates(t *testing.T) {
+	newSQL := func(id uint, start, end int64, status string) *model.ExecuteSQL {
+		sql := &model.ExecuteSQL{BaseSQL: model.BaseSQL{
+			StartBinlogFile: "mysql-bin.000001", StartBinlogPos: start,
+			EndBinlogFile: "mysql-bin.000001", EndBinlogPos: end,
+			ExecStatus: status,
+		}}
+		sql.ID = id
+		return sql
+	}
+	flashbacks := groupSQLsByBinlogCoordinates([]*model.ExecuteSQL{
+		newSQL(1, 4, 100, model.SQLExecuteStatusSucceeded),
+		newSQL(2, 4, 100, model.SQLExecuteStatusSucceeded),
+		newSQL(3, 100, 200, model.SQLExecuteStatusSucceeded),
+		newSQL(4, 200, 300, model.SQLExecuteStatusFailed),
+		{},
+	})
+	assert.Len(t, flashbacks, 2)
+	assert.Len(t, flashbacks[0].ExecuteSQLs, 2)
+	assert.Equal(t, uint(3), flashbacks[1].ExecuteSQLs[0].ID)
+}
+
+func TestGetBinlogFlashbackTables(t *testing.T) {
+	tables, err := getBinlogFlashbackTables([]*model.ExecuteSQL{
+		{BaseSQL: model.BaseSQL{Number: 1, Content: "UPDATE a.t JOIN b.t ON a.t.id = b.t.id SET a.t.v = b.t.v"}},
+		{BaseSQL: model.BaseSQL{Number: 2, Content: "DELETE FROM T2"}},
+		{BaseSQL: model.BaseSQL{Number: 3, Content: "INSERT INTO t2 VALUES (1)", Schema: "c"}},
+	}, "db1")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]struct{}{
+		"a.t":    {},
+		"b.t":    {},
+		"db1.t2": {},
+		"c.t2":   {},
+	}, tables)
+
+	_, err = getBinlogFlashbackTables([]*model.ExecuteSQL{{BaseSQL: model.BaseSQL{Content: "UPDATE"}}}, "db1")
+	assert.Error(t, err)
+}
+
+func TestGetBinlogFilesBetween(t *testing.T) {
+	files, err := getBinlogFilesBetween("mysql-bin.000009", "mysql-bin.000011")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"mysql-bin.000009", "mysql-bin.000010", "mysql-bin.000011"}, files)
+
+	_, err = getBinlogFilesBetween("mysql-bin.000011", "mysql-bin.000009")
+	assert.Error(t, err)
+}
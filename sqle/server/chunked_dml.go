@@ -0,0 +1,537 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	_errors "errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/actiontech/sqle/sqle/driver/mysql/executor"
+	"github.com/actiontech/sqle/sqle/driver/mysql/util"
+	driverV2 "github.com/actiontech/sqle/sqle/driver/v2"
+	"github.com/actiontech/sqle/sqle/model"
+
+	"github.com/pingcap/parser/ast"
+	"github.com/pingcap/parser/format"
+)
+
+var errDMLChunkExecutionTerminated = _errors.New("the chunked execution is terminated")
+
+// chunkableDML is a single table UPDATE/DELETE without ORDER BY and LIMIT, so it can be executed in chunks of
+// primary key range by appending the range to the WHERE clause, which is the last clause of the statement.
+type chunkableDML struct {
+	schema string
+	table  string
+	// prefix is the statement without WHERE clause, where is the WHERE condition, it is empty if there is no WHERE clause.
+	prefix string
+	where  string
+	// assignedColumns is the columns assigned in the SET list of UPDATE
+	assignedColumns []string
+}
+
+// assignsColumn returns true if the column is assigned by the UPDATE. The UPDATE assigning the primary key can't be
+// executed in chunks, because the updated rows may move into the later chunks and be updated again.
+func (d *chunkableDML) assignsColumn(column string) bool {
+	for _, assigned := range d.assignedColumns {
+		if strings.EqualFold(assigned, column) {
+			return true
+		}
+	}
+	return false
+}
+
+func restoreNode(node ast.Node) (string, error) {
+	buf := new(bytes.Buffer)
+	if err := node.Restore(format.NewRestoreCtx(format.DefaultRestoreFlags, buf)); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// parseChunkableDML returns false if the SQL can't be executed in chunks.
+func parseChunkableDML(sql, defaultSchema string) (*chunkableDML, bool) {
+	stmt, err := util.ParseOneSql(sql)
+	if err != nil {
+		return nil, false
+	}
+	var refs *ast.TableRefsClause
+	var where *ast.ExprNode
+	assignedColumns := []string{}
+	switch stmt := stmt.(type) {
+	case *ast.DeleteStmt:
+		if stmt.IsMultiTable || stmt.Order != nil || stmt.Limit != nil {
+			return nil, false
+		}
+		refs, where = stmt.TableRefs, &stmt.Where
+	case *ast.UpdateStmt:
+		if stmt.MultipleTable || stmt.Order != nil || stmt.Limit != nil {
+			return nil, false
+		}
+		refs, where = stmt.TableRefs, &stmt.Where
+		for _, assignment := range stmt.List {
+			assignedColumns = append(assignedColumns, assignment.Column.Name.O)
+		}
+	default:
+		return nil, false
+	}
+	if refs == nil || refs.TableRefs == nil || refs.TableRefs.Right != nil {
+		return nil, false
+	}
+	source, ok := refs.TableRefs.Left.(*ast.TableSource)
+	if !ok {
+		return nil, false
+	}
+	tableName, ok := source.Source.(*ast.TableName)
+	if !ok {
+		return nil, false
+	}
+	if *where != nil && util.WhereStmtHasSubQuery(*where) {
+		return nil, false
+	}
+
+	dml := &chunkableDML{schema: defaultSchema, table: tableName.Name.O, assignedColumns: assignedColumns}
+	if tableName.Schema.O != "" {
+		dml.schema = tableName.Schema.O
+	}
+	if *where != nil {
+		if dml.where, err = restoreNode(*where); err != nil {
+			return nil, false
+		}
+	}
+	*where = nil
+	if dml.prefix, err = restoreNode(stmt); err != nil {
+		return nil, false
+	}
+	return dml, dml.schema != ""
+}
+
+// chunkSQL returns the SQL of the primary key range (lower, upper], there is no bound if lower or upper is empty.
+func (d *chunkableDML) chunkSQL(pk, lower, upper string) string {
+	conditions := []string{}
+	if d.where != "" {
+		conditions = append(conditions, fmt.Sprintf("(%s)", d.where))
+	}
+	if lower != "" {
+		conditions = append(conditions, fmt.Sprintf("`%s` > %s", pk, lower))
+	}
+	if upper != "" {
+		conditions = append(conditions, fmt.Sprintf("`%s` <= %s", pk, upper))
+	}
+	if len(conditions) == 0 {
+		return d.prefix
+	}
+	return fmt.Sprintf("%s WHERE %s", d.prefix, strings.Join(conditions, " AND "))
+}
+
+// dmlChunkPlan executes the chunkable DML by the single column primary key of the table.
+type dmlChunkPlan struct {
+	dml       *chunkableDML
+	pk        string
+	numericPK bool
+	conn      *executor.Executor
+}
+
+// planChunkedDML returns nil if the SQL is not executed in chunks.
+func (a *action) planChunkedDML(executeSQL *model.ExecuteSQL) (*dmlChunkPlan, error) {
+	if !a.task.ExecPolicy.ChunkedDML || a.task.DBType != driverV2.DriverTypeMySQL || a.task.Instance == nil {
+		return nil, nil
+	}
+	dml, ok := parseChunkableDML(executeSQL.Content, a.task.Schema)
+	if !ok {
+		return nil, nil
+	}
+	inst := a.task.Instance
+	conn, err := executor.NewExecutor(a.entry, &driverV2.DSN{
+		Host:             inst.Host,
+		Port:             inst.Port,
+		User:             inst.User,
+		Password:         inst.Password,
+		AdditionalParams: inst.AdditionalParams,
+	}, "")
+	if err != nil {
+		return nil, fmt.Errorf("connect to instance to plan chunked execution failed: %v", err)
+	}
+	indexes, err := conn.GetTableIndexesInfo(dml.schema, dml.table)
+	if err != nil {
+		conn.Db.Close()
+		return nil, err
+	}
+	pks := []string{}
+	for _, index := range indexes {
+		if index.KeyName == "PRIMARY" {
+			pks = append(pks, index.ColumnName)
+		}
+	}
+	if len(pks) != 1 || dml.assignsColumn(pks[0]) {
+		// only the table with single column primary key can be executed in chunks, and the primary key must not be updated
+		conn.Db.Close()
+		return nil, nil
+	}
+	plan := &dmlChunkPlan{dml: dml, pk: pks[0], conn: conn}
+	columns, err := conn.GetTableColumnsInfo(dml.schema, dml.table)
+	if err != nil {
+		conn.Db.Close()
+		return nil, err
+	}
+	for _, column := range columns {
+		if strings.EqualFold(column.ColumnName, plan.pk) {
+			plan.numericPK = isNumericColumnType(column.ColumnType)
+		}
+	}
+	return plan, nil
+}
+
+func isNumericColumnType(columnType string) bool {
+	columnType = strings.ToLower(columnType)
+	for _, typ := range []string{"int", "decimal", "float", "double", "numeric"} {
+		if strings.Contains(columnType, typ) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *dmlChunkPlan) close() {
+	p.conn.Db.Close()
+}
+
+// literal formats the primary key value read from the table as SQL literal.
+func (p *dmlChunkPlan) literal(key string) string {
+	if key == "" {
+		return ""
+	}
+	if _, err := strconv.ParseFloat(key, 64); err == nil && p.numericPK {
+		return key
+	}
+	return quoteBinlogString(key)
+}
+
+// nextUpperKey returns the primary key of the last row in the chunk after lower, it is empty if the chunk is the last one.
+func (p *dmlChunkPlan) nextUpperKey(lower string, chunkSize uint) (string, error) {
+	table := fmt.Sprintf("`%s`.`%s`", p.dml.schema, p.dml.table)
+	query := fmt.Sprintf("SELECT `%s` AS upper_key FROM %s", p.pk, table)
+	args := []interface{}{}
+	if lower != "" {
+		query += fmt.Sprintf(" WHERE `%s` > ?", p.pk)
+		args = append(args, lower)
+	}
+	query += fmt.Sprintf(" ORDER BY `%s` LIMIT 1 OFFSET %d", p.pk, chunkSize-1)
+	result, err := p.conn.Db.Query(query, args...)
+	if err != nil {
+		return "", err
+	}
+	if len(result) == 0 {
+		return "", nil
+	}
+	return result[0]["upper_key"].String, nil
+}
+
+// execChunkedDML executes the DML chunk by chunk and records the progress, the execution can be paused,
+// resumed and terminated between chunks.
+func (a *action) execChunkedDML(executeSQL *model.ExecuteSQL, plan *dmlChunkPlan) error {
+	st := model.GetStorage()
+	policy := a.task.ExecPolicy
+	execution := &model.DMLChunkExecution{
+		TaskId:       a.task.ID,
+		ExecuteSQLId: executeSQL.ID,
+		Schema:       plan.dml.schema,
+		Table:        plan.dml.table,
+		PrimaryKey:   plan.pk,
+		ChunkSize:    policy.DMLChunkSize(),
+		Status:       model.DMLChunkExecutionStatusRunning,
+	}
+	if err := st.CreateDMLChunkExecution(execution); err != nil {
+		return err
+	}
+	lag := newReplicationLagChecker(a, plan.conn)
+	defer lag.close()
+
+	execErr := func() error {
+		lower := ""
+		for {
+			if err := a.waitDMLChunkExecutionRunnable(execution); err != nil {
+				return err
+			}
+			upper, err := plan.nextUpperKey(lower, execution.ChunkSize)
+			if err != nil {
+				return fmt.Errorf("get the upper bound of chunk failed: %v", err)
+			}
+			if err := a.execDMLChunk(executeSQL, plan, execution, lower, upper); err != nil {
+				return err
+			}
+			if upper == "" {
+				return nil
+			}
+			lower = upper
+			time.Sleep(time.Duration(policy.ChunkSleepMilliseconds) * time.Millisecond)
+			if err := lag.wait(execution); err != nil {
+				return err
+			}
+		}
+	}()
+
+	attrs := map[string]interface{}{"status": model.DMLChunkExecutionStatusSucceeded}
+	switch {
+	case _errors.Is(execErr, errDMLChunkExecutionTerminated):
+		attrs["status"] = model.DMLChunkExecutionStatusTerminated
+	case execErr != nil:
+		attrs["status"] = model.DMLChunkExecutionStatusFailed
+	}
+	if execErr != nil {
+		attrs["exec_result"] = execErr.Error()
+	}
+	if err := st.UpdateDMLChunkExecution(execution, attrs); err != nil {
+		return err
+	}
+	executeSQL.RowAffects = execution.RowAffects
+	return execErr
+}
+
+func (a *action) execDMLChunk(executeSQL *model.ExecuteSQL, plan *dmlChunkPlan, execution *model.DMLChunkExecution, lower, upper string) error {
+	st := model.GetStorage()
+	start := time.Now()
+	chunk := &model.DMLChunk{
+		DMLChunkExecutionId: execution.ID,
+		Number:              execution.ChunkCount + 1,
+		LowerKey:            lower,
+		UpperKey:            upper,
+		ExecStartAt:         &start,
+	}
+	sql := plan.dml.chunkSQL(plan.pk, plan.literal(lower), plan.literal(upper))
+	execErr := a.execWithPolicy(func() error {
+		result, err := a.plugin.Exec(context.TODO(), sql)
+		if err == nil && result != nil {
+			chunk.RowAffects, _ = result.RowsAffected()
+		}
+		return err
+	})
+	end := time.Now()
+	chunk.ExecEndAt = &end
+	chunk.ExecStatus, chunk.ExecResult = model.SQLExecuteStatusSucceeded, model.TaskExecResultOK
+	if execErr != nil {
+		chunk.ExecStatus, chunk.ExecResult = model.SQLExecuteStatusFailed, execErr.Error()
+	}
+	if err := st.SaveDMLChunk(chunk); err != nil {
+		return err
+	}
+	if execErr != nil {
+		return fmt.Errorf("execute chunk %d of primary key range (%v, %v] failed: %w", chunk.Number, lower, upper, execErr)
+	}
+
+	execution.ChunkCount++
+	execution.RowAffects += chunk.RowAffects
+	if upper != "" {
+		execution.LastKey = upper
+	}
+	return st.UpdateDMLChunkExecution(execution, map[string]interface{}{
+		"chunk_count": execution.ChunkCount,
+		"row_affects": execution.RowAffects,
+		"last_key":    execution.LastKey,
+	})
+}
+
+// pauseDMLChunkExecution pauses the running execution with the reason, it continues after it is resumed.
+func (a *action) pauseDMLChunkExecution(execution *model.DMLChunkExecution, reason string) error {
+	st := model.GetStorage()
+	paused, err := st.UpdateDMLChunkExecutionStatus(execution, []string{model.DMLChunkExecutionStatusRunning}, model.DMLChunkExecutionStatusPaused)
+	if err != nil || !paused {
+		return err
+	}
+	return st.UpdateDMLChunkExecution(execution, map[string]interface{}{"exec_result": reason})
+}
+
+// waitDMLChunkExecutionRunnable waits while the execution is paused, and returns errDMLChunkExecutionTerminated
+// if the execution or the task is terminated.
+func (a *action) waitDMLChunkExecutionRunnable(execution *model.DMLChunkExecution) error {
+	st := model.GetStorage()
+	for {
+		if a.hasTermination() {
+			return errDMLChunkExecutionTerminated
+		}
+		status, err := st.GetDMLChunkExecutionStatus(execution.ID)
+		if err != nil {
+			return err
+		}
+		switch status {
+		case model.DMLChunkExecutionStatusTerminating:
+			return errDMLChunkExecutionTerminated
+		case model.DMLChunkExecutionStatusPaused:
+			time.Sleep(time.Second)
+		default:
+			return nil
+		}
+	}
+}
+
+// replicationLagChecker connects to the replicas in the execution policy, or the replicas registered to the instance
+// with the account of the instance. The chunked execution is paused if the replication lag can't be checked.
+type replicationLagChecker struct {
+	a    *action
+	conn *executor.Executor
+	max  time.Duration
+	// replicas is nil until the replicas are connected
+	replicas map[string]*executor.Executor
+}
+
+func newReplicationLagChecker(a *action, conn *executor.Executor) *replicationLagChecker {
+	return &replicationLagChecker{
+		a:    a,
+		conn: conn,
+		max:  time.Duration(a.task.ExecPolicy.MaxReplicationLagSeconds) * time.Second,
+	}
+}
+
+// wait waits until the replication lag of all replicas is not more than the max lag. If the replication lag can't
+// be checked, the execution is paused and the lag is checked again after the execution is resumed.
+func (c *replicationLagChecker) wait(execution *model.DMLChunkExecution) error {
+	if c.max == 0 {
+		return nil
+	}
+	paused := false
+	for {
+		lagging, err := c.check()
+		switch {
+		case err != nil:
+			c.a.entry.Warnf("check replication lag failed, pause the chunked execution: %v", err)
+			if err := c.a.pauseDMLChunkExecution(execution, fmt.Sprintf("check replication lag failed: %v", err)); err != nil {
+				return err
+			}
+			paused = true
+		case lagging != "":
+			c.a.entry.Infof("%v, waiting", lagging)
+			time.Sleep(time.Second)
+		case paused:
+			return model.GetStorage().UpdateDMLChunkExecution(execution, map[string]interface{}{"exec_result": ""})
+		default:
+			return nil
+		}
+		if err := c.a.waitDMLChunkExecutionRunnable(execution); err != nil {
+			return err
+		}
+	}
+}
+
+// check returns the description of the replica whose replication lag is more than the max lag, it is empty if
+// all replicas are not lagging.
+func (c *replicationLagChecker) check() (string, error) {
+	if err := c.connect(); err != nil {
+		return "", err
+	}
+	for addr, replica := range c.replicas {
+		lag, err := replicationLag(replica)
+		if err != nil {
+			// reconnect to the replicas in the next check
+			c.close()
+			return "", fmt.Errorf("check replication lag of replica %v failed: %v", addr, err)
+		}
+		if lag > c.max {
+			return fmt.Sprintf("replication lag of replica %v is %v", addr, lag), nil
+		}
+	}
+	return "", nil
+}
+
+func (c *replicationLagChecker) connect() error {
+	if c.replicas != nil {
+		return nil
+	}
+	addresses := c.a.task.ExecPolicy.ReplicaAddresses
+	if len(addresses) == 0 {
+		var err error
+		if addresses, err = discoverReplicaAddresses(c.conn); err != nil {
+			return err
+		}
+	}
+	inst := c.a.task.Instance
+	replicas := map[string]*executor.Executor{}
+	for _, addr := range addresses {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			c.closeReplicas(replicas)
+			return fmt.Errorf("invalid replica address %v: %v", addr, err)
+		}
+		replica, err := executor.NewExecutor(c.a.entry, &driverV2.DSN{
+			Host:     host,
+			Port:     port,
+			User:     inst.User,
+			Password: inst.Password,
+		}, "")
+		if err != nil {
+			c.closeReplicas(replicas)
+			return fmt.Errorf("connect to replica %v failed: %v", addr, err)
+		}
+		replicas[addr] = replica
+	}
+	c.replicas = replicas
+	return nil
+}
+
+// discoverReplicaAddresses returns the addresses of the replicas registered to the instance. The replica reports its
+// host only if report_host is set, the replica addresses should be configured in the execution policy otherwise.
+func discoverReplicaAddresses(conn *executor.Executor) ([]string, error) {
+	result, err := conn.Db.Query("SHOW REPLICAS")
+	if err != nil {
+		// SHOW REPLICAS is supported since MySQL 8.0.22
+		if result, err = conn.Db.Query("SHOW SLAVE HOSTS"); err != nil {
+			return nil, fmt.Errorf("find replicas of instance failed: %v", err)
+		}
+	}
+	addresses := []string{}
+	for _, row := range result {
+		host, port := row["Host"].String, row["Port"].String
+		if host == "" || port == "" {
+			serverId := row["Server_Id"].String
+			if serverId == "" {
+				serverId = row["Server_id"].String
+			}
+			return nil, fmt.Errorf("the replica of server id %v doesn't report its address, configure the replica addresses in the execution policy", serverId)
+		}
+		addresses = append(addresses, net.JoinHostPort(host, port))
+	}
+	return addresses, nil
+}
+
+func replicationLag(replica *executor.Executor) (time.Duration, error) {
+	result, err := replica.Db.Query("SHOW REPLICA STATUS")
+	if err != nil {
+		// SHOW REPLICA STATUS is supported since MySQL 8.0.22, and SHOW SLAVE STATUS is removed in MySQL 8.4
+		if result, err = replica.Db.Query("SHOW SLAVE STATUS"); err != nil {
+			return 0, err
+		}
+	}
+	if len(result) == 0 {
+		return 0, fmt.Errorf("the instance is not a replica")
+	}
+	return parseReplicationLag(result[0])
+}
+
+func parseReplicationLag(status map[string]sql.NullString) (time.Duration, error) {
+	seconds, ok := status["Seconds_Behind_Source"]
+	if !ok {
+		seconds = status["Seconds_Behind_Master"]
+	}
+	if !seconds.Valid {
+		return 0, fmt.Errorf("the replication is not running")
+	}
+	lag, err := strconv.ParseInt(seconds.String, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(lag) * time.Second, nil
+}
+
+func (c *replicationLagChecker) close() {
+	c.closeReplicas(c.replicas)
+	c.replicas = nil
+}
+
+func (c *replicationLagChecker) closeReplicas(replicas map[string]*executor.Executor) {
+	for _, replica := range replicas {
+		replica.Db.Close()
+	}
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseChunkableDML(t *testing.T) {
+	dml, ok := parseChunkableDML("DELETE FROM t1 WHERE a = 1 OR b = 2", "db1")
+	assert.True(t, ok)
+	assert.Equal(t, "db1", dml.schema)
+	assert.Equal(t, "t1", dml.table)
+	assert.Equal(t, "DELETE FROM `t1` WHERE (`a`=1 OR `b`=2) AND `id` > 10 AND `id` <= 20", dml.chunkSQL("id", "10", "20"))
+	assert.Equal(t, "DELETE FROM `t1` WHERE (`a`=1 OR `b`=2) AND `id` > 10", dml.chunkSQL("id", "10", ""))
+
+	dml, ok = parseChunkableDML("UPDATE db2.t1 SET a = 1", "db1")
+	assert.True(t, ok)
+	assert.Equal(t, "db2", dml.schema)
+	assert.Equal(t, "UPDATE `db2`.`t1` SET `a`=1 WHERE `id` <= 'k'", dml.chunkSQL("id", "", "'k'"))
+	assert.Equal(t, "UPDATE `db2`.`t1` SET `a`=1", dml.chunkSQL("id", "", ""))
+	assert.False(t, dml.assignsColumn("id"))
+
+	// the UPDATE assigning the primary key is not executed in chunks
+	dml, ok = parseChunkableDML("UPDATE t1 SET a = 1, ID = id + 1000 WHERE a > 1", "db1")
+	assert.True(t, ok)
+	assert.True(t, dml.assignsColumn("id"))
+	assert.False(t, dml.assignsColumn("b"))
+	dml, ok = parseChunkableDML("DELETE FROM t1 WHERE id > 1", "db1")
+	assert.True(t, ok)
+	assert.False(t, dml.assignsColumn("id"))
+
+	for _, sql := range []string{
+		"DELETE FROM t1 WHERE a = 1 LIMIT 10",
+		"UPDATE t1 SET a = 1 ORDER BY id",
+		"DELETE t1, t2 FROM t1 JOIN t2 ON t1.id = t2.id",
+		"UPDATE t1 JOIN t2 ON t1.id = t2.id SET t1.a = 1",
+		"DELETE FROM t1 WHERE id IN (SELECT id FROM t2)",
+		"INSERT INTO t1 VALUES (1)",
+	} {
+		_, ok = parseChunkableDML(sql, "db1")
+		assert.False(t, ok, sql)
+	}
+
+	// the schema is required to read the primary key of the table
+	_, ok = parseChunkableDML("DELETE FROM t1", "")
+	assert.False(t, ok)
+}
+
+func TestDMLChunkPlan_literal(t *testing.T) {
+	plan := &dmlChunkPlan{numericPK: true}
+	assert.Equal(t, "", plan.literal(""))
+	assert.Equal(t, "100", plan.literal("100"))
+
+	plan.numericPK = false
+	assert.Equal(t, "'100'", plan.literal("100"))
+	assert.Equal(t, `'a\'b'`, plan.literal("a'b"))
+}
+
+func TestIsNumericColumnType(t *testing.T) {
+	assert.True(t, isNumericColumnType("bigint(20) unsigned"))
+	assert.True(t, isNumericColumnType("DECIMAL(10,2)"))
+	assert.False(t, isNumericColumnType("varchar(64)"))
+}
+
+func TestParseReplicationLag(t *testing.T) {
+	lag, err := parseReplicationLag(map[string]sql.NullString{"Seconds_Behind_Source": {String: "3", Valid: true}})
+	assert.NoError(t, err)
+	assert.Equal(t, 3*time.Second, lag)
+
+	lag, err = parseReplicationLag(map[string]sql.NullString{"Seconds_Behind_Master": {String: "0", Valid: true}})
+	assert.NoError(t, err)
+	assert.Equal(t, time.Duration(0), lag)
+
+	_, err = parseReplicationLag(map[string]sql.NullString{"Seconds_Behind_Source": {}})
+	assert.Error(t, err)
+	_, err = parseReplicationLag(map[string]sql.NullString{})
+	assert.Error(t, err)
+}
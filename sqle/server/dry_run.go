@@ -0,0 +1,288 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/actiontech/sqle/sqle/driver/mysql/executor"
+	"github.com/actiontech/sqle/sqle/driver/mysql/util"
+	driverV2 "github.com/actiontech/sqle/sqle/driver/v2"
+	"github.com/actiontech/sqle/sqle/errors"
+	"github.com/actiontech/sqle/sqle/model"
+
+	"github.com/pingcap/parser/ast"
+	_model "github.com/pingcap/parser/model"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	DryRunSQLStatusSucceeded = "succeeded"
+	DryRunSQLStatusFailed    = "failed"
+	// the SQL is not a DDL or DML, such as GRANT and SET GLOBAL, so it is not executed on the sandbox instance
+	DryRunSQLStatusSkipped = "skipped"
+)
+
+type DryRunSQLResult struct {
+	ExecuteSQL *model.ExecuteSQL
+	Status     string
+	ExecResult string
+}
+
+type DryRunResult struct {
+	// the cloned tables in format of schema.table, the schema is the one of the task instance
+	ClonedTables []string
+	SQLs         []*DryRunSQLResult
+}
+
+// DryRunTask clones the structures of the tables used by the SQLs of the MySQL task into temporary shadow schemas
+// on the sandbox instance, and executes the SQLs there in order. The shadow schemas are dropped afterwards.
+//
+// Only the structures of the existing base tables are cloned, so the DML runs on empty tables, and the foreign key
+// checks are disabled on the sandbox session to avoid the false failures caused by the missing parent rows.
+func DryRunTask(l *logrus.Entry, task *model.Task, sandbox *model.Instance) (*DryRunResult, error) {
+	if task.DBType != driverV2.DriverTypeMySQL || task.Instance == nil {
+		return nil, errors.New(errors.DataInvalid, fmt.Errorf("dry run only supports MySQL"))
+	}
+	if sandbox.DbType != driverV2.DriverTypeMySQL {
+		return nil, errors.New(errors.DataInvalid, fmt.Errorf("the sandbox instance %v is not MySQL", sandbox.Name))
+	}
+	if sandbox.ID == task.Instance.ID {
+		return nil, errors.New(errors.DataInvalid, fmt.Errorf("the sandbox instance can't be the instance of the task"))
+	}
+
+	rewriter := newShadowSchemaRewriter(fmt.Sprintf("sqle_dry_run_%d_%d", task.ID, time.Now().Unix()))
+	stmts := make([]ast.StmtNode, 0, len(task.ExecuteSQLs))
+	schemas := []string{}
+	tables := []*dryRunTable{}
+	visitedSchemas := map[string]struct{}{}
+	visitedTables := map[string]struct{}{}
+	addSchema := func(schema string) {
+		key := strings.ToLower(schema)
+		if _, ok := visitedSchemas[key]; schema != "" && !ok {
+			visitedSchemas[key] = struct{}{}
+			schemas = append(schemas, schema)
+		}
+	}
+	schema := task.Schema
+	addSchema(schema)
+	for _, sql := range task.ExecuteSQLs {
+		stmt, err := util.ParseOneSql(sql.Content)
+		if err != nil {
+			return nil, errors.New(errors.DataParseFail, fmt.Errorf("parse SQL %d failed: %v", sql.Number, err))
+		}
+		stmts = append(stmts, stmt)
+		if use, ok := stmt.(*ast.UseStmt); ok {
+			schema = use.DBName
+			addSchema(schema)
+			continue
+		}
+		extractor := &dryRunTableExtractor{defaultSchema: schema}
+		stmt.Accept(extractor)
+		for _, table := range extractor.tables {
+			addSchema(table.schema)
+			key := strings.ToLower(table.schema + "." + table.name)
+			if _, ok := visitedTables[key]; !ok {
+				visitedTables[key] = struct{}{}
+				tables = append(tables, table)
+			}
+		}
+	}
+
+	source, err := newMySQLExecutor(l, task.Instance)
+	if err != nil {
+		return nil, err
+	}
+	defer source.Db.Close()
+	target, err := newMySQLExecutor(l, sandbox)
+	if err != nil {
+		return nil, err
+	}
+	defer target.Db.Close()
+	defer func() {
+		for _, shadow := range rewriter.shadows {
+			if _, err := target.Db.Exec(fmt.Sprintf("DROP DATABASE IF EXISTS `%s`", shadow)); err != nil {
+				l.Errorf("drop shadow schema %v of dry run failed: %v", shadow, err)
+			}
+		}
+	}()
+
+	if _, err := target.Db.Exec("SET SESSION foreign_key_checks = 0"); err != nil {
+		return nil, err
+	}
+	for _, schema := range schemas {
+		if err := createShadowSchema(source, target, rewriter, schema); err != nil {
+			return nil, fmt.Errorf("create shadow schema of %v on sandbox instance failed: %v", schema, err)
+		}
+	}
+	result := &DryRunResult{ClonedTables: []string{}}
+	for _, table := range tables {
+		cloned, err := cloneTableStructure(source, target, rewriter, table)
+		if err != nil {
+			return nil, fmt.Errorf("clone table %v.%v to sandbox instance failed: %v", table.schema, table.name, err)
+		}
+		if cloned {
+			result.ClonedTables = append(result.ClonedTables, fmt.Sprintf("%s.%s", table.schema, table.name))
+		}
+	}
+
+	if task.Schema != "" {
+		// the error is the same as executing on the task instance if the schema doesn't exist, so it is reported by the SQLs
+		_ = target.UseSchema(rewriter.shadow(task.Schema))
+	}
+	for i, stmt := range stmts {
+		sqlResult := &DryRunSQLResult{ExecuteSQL: task.ExecuteSQLs[i], Status: DryRunSQLStatusSkipped}
+		result.SQLs = append(result.SQLs, sqlResult)
+		if !isDryRunStmt(stmt) {
+			continue
+		}
+		stmt.Accept(rewriter)
+		query, err := restoreNode(stmt)
+		if err == nil {
+			_, err = target.Db.Exec(query)
+		}
+		if err != nil {
+			sqlResult.Status = DryRunSQLStatusFailed
+			sqlResult.ExecResult = err.Error()
+			continue
+		}
+		sqlResult.Status = DryRunSQLStatusSucceeded
+	}
+	return result, nil
+}
+
+func newMySQLExecutor(l *logrus.Entry, inst *model.Instance) (*executor.Executor, error) {
+	return executor.NewExecutor(l, &driverV2.DSN{
+		Host:             inst.Host,
+		Port:             inst.Port,
+		User:             inst.User,
+		Password:         inst.Password,
+		AdditionalParams: inst.AdditionalParams,
+	}, "")
+}
+
+func isDryRunStmt(stmt ast.StmtNode) bool {
+	switch stmt.(type) {
+	case ast.DDLNode, ast.DMLNode, *ast.UseStmt:
+		return true
+	}
+	return false
+}
+
+// createShadowSchema creates the shadow schema of the schema on the sandbox instance with the same character set,
+// it does nothing if the schema doesn't exist on the task instance, such as the schema created by the SQLs.
+func createShadowSchema(source, target *executor.Executor, rewriter *shadowSchemaRewriter, schema string) error {
+	rows, err := source.Db.Query("SELECT DEFAULT_CHARACTER_SET_NAME, DEFAULT_COLLATION_NAME FROM information_schema.SCHEMATA WHERE SCHEMA_NAME = ?", schema)
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	shadow := rewriter.shadow(schema)
+	_, err = target.Db.Exec(fmt.Sprintf("CREATE DATABASE `%s` DEFAULT CHARACTER SET %s COLLATE %s", shadow,
+		rows[0]["DEFAULT_CHARACTER_SET_NAME"].String, rows[0]["DEFAULT_COLLATION_NAME"].String))
+	return err
+}
+
+// cloneTableStructure creates the table in the shadow schema of the sandbox instance, it returns false if the table
+// is not a base table of the task instance, such as the table created by the SQLs.
+func cloneTableStructure(source, target *executor.Executor, rewriter *shadowSchemaRewriter, table *dryRunTable) (bool, error) {
+	rows, err := source.Db.Query("SELECT TABLE_TYPE FROM information_schema.TABLES WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ?",
+		table.schema, table.name)
+	if err != nil {
+		return false, err
+	}
+	if len(rows) == 0 || rows[0]["TABLE_TYPE"].String != "BASE TABLE" {
+		return false, nil
+	}
+	createTable, err := source.ShowCreateTable(fmt.Sprintf("`%s`", table.schema), fmt.Sprintf("`%s`", table.name))
+	if err != nil {
+		return false, err
+	}
+	if err := target.UseSchema(rewriter.shadow(table.schema)); err != nil {
+		return false, err
+	}
+	if _, err := target.Db.Exec(createTable); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+type dryRunTable struct {
+	schema string
+	name   string
+}
+
+// dryRunTableExtractor implements ast.Visitor interface, it extracts the tables with schema used by the SQL.
+type dryRunTableExtractor struct {
+	defaultSchema string
+	tables        []*dryRunTable
+}
+
+func (e *dryRunTableExtractor) Enter(in ast.Node) (node ast.Node, skipChildren bool) {
+	if table, ok := in.(*ast.TableName); ok {
+		schema := table.Schema.O
+		if schema == "" {
+			schema = e.defaultSchema
+		}
+		if schema != "" {
+			e.tables = append(e.tables, &dryRunTable{schema: schema, name: table.Name.O})
+		}
+	}
+	return in, false
+}
+
+func (e *dryRunTableExtractor) Leave(in ast.Node) (node ast.Node, ok bool) {
+	return in, true
+}
+
+// shadowSchemaRewriter implements ast.Visitor interface, it replaces the schemas used by the SQL with the shadow schemas.
+// The table names without schema are left unchanged, they are resolved by the current shadow schema of the session.
+type shadowSchemaRewriter struct {
+	prefix  string
+	shadows map[string]string
+}
+
+func newShadowSchemaRewriter(prefix string) *shadowSchemaRewriter {
+	return &shadowSchemaRewriter{prefix: prefix, shadows: map[string]string{}}
+}
+
+// shadow returns the shadow schema of the schema, the shadow schema is named by sequence to avoid exceeding the length limit.
+func (r *shadowSchemaRewriter) shadow(schema string) string {
+	key := strings.ToLower(schema)
+	if shadow, ok := r.shadows[key]; ok {
+		return shadow
+	}
+	shadow := fmt.Sprintf("%s_%d", r.prefix, len(r.shadows)+1)
+	r.shadows[key] = shadow
+	return shadow
+}
+
+func (r *shadowSchemaRewriter) Enter(in ast.Node) (node ast.Node, skipChildren bool) {
+	switch stmt := in.(type) {
+	case *ast.TableName:
+		if stmt.Schema.O != "" {
+			stmt.Schema = _model.NewCIStr(r.shadow(stmt.Schema.O))
+		}
+	case *ast.ColumnName:
+		if stmt.Schema.O != "" {
+			stmt.Schema = _model.NewCIStr(r.shadow(stmt.Schema.O))
+		}
+	case *ast.UseStmt:
+		stmt.DBName = r.shadow(stmt.DBName)
+	case *ast.CreateDatabaseStmt:
+		stmt.Name = r.shadow(stmt.Name)
+	case *ast.DropDatabaseStmt:
+		stmt.Name = r.shadow(stmt.Name)
+	case *ast.AlterDatabaseStmt:
+		if stmt.Name != "" {
+			stmt.Name = r.shadow(stmt.Name)
+		}
+	}
+	return in, false
+}
+
+func (r *shadowSchemaRewriter) Leave(in ast.Node) (node ast.Node, ok bool) {
+	return in, true
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/actiontech/sqle/sqle/driver/mysql/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShadowSchemaRewriter(t *testing.T) {
+	rewriter := newShadowSchemaRewriter("sqle_dry_run_1_100")
+	for _, c := range []struct {
+		sql    string
+		expect string
+	}{
+		{"USE db1", "USE `sqle_dry_run_1_100_1`"},
+		{"ALTER TABLE t1 ADD COLUMN c1 INT", "ALTER TABLE `t1` ADD COLUMN `c1` INT"},
+		{"UPDATE db2.t1 SET db2.t1.a = 1 WHERE id = 1", "UPDATE `sqle_dry_run_1_100_2`.`t1` SET `sqle_dry_run_1_100_2`.`t1`.`a`=1 WHERE `id`=1"},
+		{"INSERT INTO DB1.t2 SELECT * FROM db2.t1", "INSERT INTO `sqle_dry_run_1_100_1`.`t2` SELECT * FROM `sqle_dry_run_1_100_2`.`t1`"},
+		{"CREATE DATABASE db3", "CREATE DATABASE `sqle_dry_run_1_100_3`"},
+		{"DROP DATABASE db3", "DROP DATABASE `sqle_dry_run_1_100_3`"},
+	} {
+		stmt, err := util.ParseOneSql(c.sql)
+		assert.NoError(t, err)
+		stmt.Accept(rewriter)
+		query, err := restoreNode(stmt)
+		assert.NoError(t, err)
+		assert.Equal(t, c.expect, query)
+	}
+	assert.Len(t, rewriter.shadows, 3)
+}
+
+func TestDryRunTableExtractor(t *testing.T) {
+	stmt, err := util.ParseOneSql("INSERT INTO t1 SELECT * FROM db2.t2 JOIN t3 ON t2.id = t3.id")
+	assert.NoError(t, err)
+	extractor := &dryRunTableExtractor{defaultSchema: "db1"}
+	stmt.Accept(extractor)
+	assert.ElementsMatch(t, []*dryRunTable{
+		{schema: "db1", name: "t1"},
+		{schema: "db2", name: "t2"},
+		{schema: "db1", name: "t3"},
+	}, extractor.tables)
+
+	extractor = &dryRunTableExtractor{}
+	stmt.Accept(extractor)
+	assert.Len(t, extractor.tables, 1)
+}
+
+func TestIsDryRunStmt(t *testing.T) {
+	for sql, expect := range map[string]bool{
+		"CREATE TABLE t1 (id INT)":               true,
+		"DELETE FROM t1":                         true,
+		"USE db1":                                true,
+		"SET GLOBAL max_connections = 100":       false,
+		"GRANT SELECT ON db1.* TO 'u1'@'%'":      false,
+		"CREATE USER 'u1'@'%' IDENTIFIED BY 'p'": false,
+	} {
+		stmt, err := util.ParseOneSql(sql)
+		assert.NoError(t, err)
+		assert.Equal(t, expect, isDryRunStmt(stmt), sql)
+	}
+}
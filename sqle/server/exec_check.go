@@ -0,0 +1,293 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/actiontech/sqle/sqle/common"
+	"github.com/actiontech/sqle/sqle/config"
+	"github.com/actiontech/sqle/sqle/driver"
+	driverV2 "github.com/actiontech/sqle/sqle/driver/v2"
+	"github.com/actiontech/sqle/sqle/log"
+	"github.com/actiontech/sqle/sqle/model"
+)
+
+const (
+	execCheckQueryTimeoutSeconds = 60
+	execCheckWebhookTimeout      = 30 * time.Second
+)
+
+// runExecChecks runs the checks of the stage attached to the workflow of the task and records the results,
+// the check status of the task is updated if any check is run, and a failed post check may require rolling back the task.
+func (a *action) runExecChecks(stage string) (passed bool, err error) {
+	st := model.GetStorage()
+	workflowId, exist, err := st.GetWorkflowIdByTaskId(a.task.ID)
+	if err != nil || !exist {
+		return true, err
+	}
+	checks, err := st.GetWorkflowExecChecksByStage(workflowId, stage)
+	if err != nil {
+		return false, err
+	}
+
+	passed = true
+	for _, check := range checks {
+		if check.InstanceName != "" && (a.task.Instance == nil || a.task.Instance.Name != check.InstanceName) {
+			continue
+		}
+		checkErr := a.runExecCheck(workflowId, check)
+		result := &model.WorkflowExecCheckResult{
+			WorkflowExecCheckId: check.ID,
+			TaskId:              a.task.ID,
+			Stage:               check.Stage,
+			Type:                check.Type,
+			Name:                check.Name,
+			Passed:              checkErr == nil,
+			CheckedAt:           time.Now(),
+		}
+		if checkErr != nil {
+			a.entry.Warnf("%v check %v of workflow %v failed: %v", stage, check.Name, workflowId, checkErr)
+			result.Message = checkErr.Error()
+			passed = false
+			if stage == model.WorkflowExecCheckStagePost && check.RollbackOnFailure {
+				a.rollbackAfterExecution = true
+			}
+		}
+		if err := st.CreateWorkflowExecCheckResult(result); err != nil {
+			return false, err
+		}
+		if a.task.ExecCheckStatus == "" {
+			a.task.ExecCheckStatus = model.TaskExecCheckStatusPassed
+		}
+	}
+	if !passed {
+		a.task.ExecCheckStatus = model.TaskExecCheckStatusPreCheckFailed
+		if stage == model.WorkflowExecCheckStagePost {
+			a.task.ExecCheckStatus = model.TaskExecCheckStatusPostCheckFailed
+		}
+	}
+	return passed, nil
+}
+
+// runExecCheck returns the reason if the check is not passed.
+func (a *action) runExecCheck(workflowId string, check *model.WorkflowExecCheck) error {
+	switch check.Type {
+	case model.WorkflowExecCheckTypeSQLAssertion:
+		return a.checkSQLAssertion(check)
+	case model.WorkflowExecCheckTypeWebhook:
+		return a.checkWebhook(workflowId, check)
+	case model.WorkflowExecCheckTypeLongTransaction:
+		return a.checkLongTransaction(check)
+	default:
+		return fmt.Errorf("unknown check type %v", check.Type)
+	}
+}
+
+// CheckExecCheckTypeSupported returns error if the check type can't be run on the instance of the db type.
+func CheckExecCheckTypeSupported(checkType, dbType string) error {
+	switch checkType {
+	case model.WorkflowExecCheckTypeSQLAssertion:
+		if !driver.GetPluginManager().IsOptionalModuleEnabled(dbType, driverV2.OptionalModuleQuery) {
+			return fmt.Errorf("sql assertion check is not supported by %v", dbType)
+		}
+	case model.WorkflowExecCheckTypeLongTransaction:
+		if dbType != driverV2.DriverTypeMySQL {
+			return fmt.Errorf("long transaction check only supports MySQL")
+		}
+	}
+	return nil
+}
+
+// CheckExecCheckSQLAssertion returns error if the sql of the sql assertion check is not exactly one query of the db type.
+func CheckExecCheckSQLAssertion(dbType, sql string) error {
+	plugin, err := common.NewDriverManagerWithoutCfg(log.NewEntry(), dbType)
+	if err != nil {
+		return err
+	}
+	defer plugin.Close(context.TODO())
+	return checkExecCheckSQL(plugin, sql)
+}
+
+// checkExecCheckSQL requires the sql of the sql assertion check to be exactly one query, the sql is run on the
+// instance of the workflow without audit.
+func checkExecCheckSQL(plugin driver.Plugin, sql string) error {
+	nodes, err := plugin.Parse(context.TODO(), sql)
+	if err != nil {
+		return fmt.Errorf("parse the sql failed: %v", err)
+	}
+	if len(nodes) != 1 {
+		return fmt.Errorf("the sql should be exactly one statement, but got %d", len(nodes))
+	}
+	if nodes[0].Type != driverV2.SQLTypeDQL {
+		return fmt.Errorf("the sql should be a query, but got a %s statement", nodes[0].Type)
+	}
+	return nil
+}
+
+// CheckExecCheckWebhookURL returns error if the host of the webhook URL is not in the allowed hosts of the config.
+func CheckExecCheckWebhookURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("invalid webhook url: %v", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("the scheme of webhook url must be http or https")
+	}
+	hostname := strings.ToLower(u.Hostname())
+	host := strings.ToLower(u.Host)
+	for _, allowed := range config.GetOptions().SqleOptions.Service.WorkflowExecCheck.WebhookAllowedHosts {
+		allowed = strings.ToLower(strings.TrimSpace(allowed))
+		switch {
+		case allowed == "":
+		case allowed == hostname || allowed == host:
+			return nil
+		case strings.HasPrefix(allowed, "*.") && strings.HasSuffix(hostname, allowed[1:]):
+			return nil
+		}
+	}
+	return fmt.Errorf("the host %v of webhook url is not in the allowed hosts of the config", u.Host)
+}
+
+func (a *action) checkSQLAssertion(check *model.WorkflowExecCheck) error {
+	if err := CheckExecCheckTypeSupported(check.Type, a.task.DBType); err != nil {
+		return err
+	}
+	if err := checkExecCheckSQL(a.plugin, check.SQL); err != nil {
+		return err
+	}
+	result, err := a.plugin.Query(context.TODO(), check.SQL, &driverV2.QueryConf{TimeOutSecond: execCheckQueryTimeoutSeconds})
+	if err != nil {
+		return fmt.Errorf("query failed: %v", err)
+	}
+	actual := ""
+	if len(result.Rows) > 0 && len(result.Rows[0].Values) > 0 {
+		actual = result.Rows[0].Values[0].Value
+	}
+	ok, err := compareExecCheckValue(actual, check.Operator, check.Expected)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("the query result %q is not %v %q", actual, check.Operator, check.Expected)
+	}
+	return nil
+}
+
+// compareExecCheckValue compares the values as numbers if both are numeric, otherwise as strings.
+func compareExecCheckValue(actual, operator, expected string) (bool, error) {
+	cmp := strings.Compare(actual, expected)
+	actualNum, actualErr := strconv.ParseFloat(actual, 64)
+	expectedNum, expectedErr := strconv.ParseFloat(expected, 64)
+	if actualErr == nil && expectedErr == nil {
+		switch {
+		case actualNum < expectedNum:
+			cmp = -1
+		case actualNum > expectedNum:
+			cmp = 1
+		default:
+			cmp = 0
+		}
+	}
+	switch operator {
+	case "=":
+		return cmp == 0, nil
+	case "!=":
+		return cmp != 0, nil
+	case ">":
+		return cmp > 0, nil
+	case ">=":
+		return cmp >= 0, nil
+	case "<":
+		return cmp < 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	default:
+		return false, fmt.Errorf("unknown operator %v", operator)
+	}
+}
+
+type execCheckWebhookBody struct {
+	Event        string `json:"event"`
+	Stage        string `json:"stage"`
+	CheckName    string `json:"check_name"`
+	WorkflowId   string `json:"workflow_id"`
+	TaskId       uint   `json:"task_id"`
+	InstanceName string `json:"instance_name"`
+	Schema       string `json:"schema"`
+	Timestamp    string `json:"timestamp"` // time.RFC3339
+}
+
+func (a *action) checkWebhook(workflowId string, check *model.WorkflowExecCheck) error {
+	// the allowed hosts may be changed after the check is saved
+	if err := CheckExecCheckWebhookURL(check.URL); err != nil {
+		return err
+	}
+	body := &execCheckWebhookBody{
+		Event:      "workflow_exec_check",
+		Stage:      check.Stage,
+		CheckName:  check.Name,
+		WorkflowId: workflowId,
+		TaskId:     a.task.ID,
+		Schema:     a.task.Schema,
+		Timestamp:  time.Now().Format(time.RFC3339),
+	}
+	if a.task.Instance != nil {
+		body.InstanceName = a.task.Instance.Name
+	}
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	client := &http.Client{
+		Timeout: execCheckWebhookTimeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return fmt.Errorf("stopped after 10 redirects")
+			}
+			return CheckExecCheckWebhookURL(req.URL.String())
+		},
+	}
+	resp, err := client.Post(check.URL, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("request webhook failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responds status %v", resp.Status)
+	}
+	return nil
+}
+
+func (a *action) checkLongTransaction(check *model.WorkflowExecCheck) error {
+	if err := CheckExecCheckTypeSupported(check.Type, a.task.DBType); err != nil {
+		return err
+	}
+	if a.task.Instance == nil {
+		return fmt.Errorf("the instance of task is not found")
+	}
+	conn, err := newMySQLExecutor(a.entry, a.task.Instance)
+	if err != nil {
+		return fmt.Errorf("connect to instance failed: %v", err)
+	}
+	defer conn.Db.Close()
+	sessions, err := conn.ShowBlockingSessions(check.MaxTransactionSeconds)
+	if err != nil {
+		return fmt.Errorf("check long transactions failed: %v", err)
+	}
+	if len(sessions) == 0 {
+		return nil
+	}
+	ids := make([]string, 0, len(sessions))
+	for _, s := range sessions {
+		ids = append(ids, s.Id)
+	}
+	return fmt.Errorf("there are %d sessions whose transaction or query has lasted at least %d seconds, session ids: %v",
+		len(sessions), check.MaxTransactionSeconds, strings.Join(ids, ","))
+}
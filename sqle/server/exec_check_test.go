@@ -0,0 +1,100 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/actiontech/sqle/sqle/config"
+	"github.com/actiontech/sqle/sqle/driver"
+	driverV2 "github.com/actiontech/sqle/sqle/driver/v2"
+	"github.com/actiontech/sqle/sqle/log"
+	"github.com/actiontech/sqle/sqle/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompareExecCheckValue(t *testing.T) {
+	for _, c := range []struct {
+		actual   string
+		operator string
+		expected string
+		ok       bool
+	}{
+		{"100", "=", "100.0", true},
+		{"9", "<", "10", true},
+		{"9", ">", "10", false},
+		{"10", ">=", "10", true},
+		{"abc", "=", "abc", true},
+		{"abc", "!=", "abd", true},
+		{"", "=", "0", false},
+		{"b", "<=", "a", false},
+	} {
+		ok, err := compareExecCheckValue(c.actual, c.operator, c.expected)
+		assert.NoError(t, err)
+		assert.Equal(t, c.ok, ok, "%v %v %v", c.actual, c.operator, c.expected)
+	}
+
+	_, err := compareExecCheckValue("1", "<>", "1")
+	assert.Error(t, err)
+}
+
+func TestCheckExecCheckWebhookURL(t *testing.T) {
+	origin := config.GetOptions().SqleOptions.Service.WorkflowExecCheck
+	defer func() {
+		config.GetOptions().SqleOptions.Service.WorkflowExecCheck = origin
+	}()
+
+	// the webhook checks are not allowed if the allowed hosts are not configured
+	config.GetOptions().SqleOptions.Service.WorkflowExecCheck.WebhookAllowedHosts = nil
+	assert.Error(t, CheckExecCheckWebhookURL("https://hooks.example.com/check"))
+
+	config.GetOptions().SqleOptions.Service.WorkflowExecCheck.WebhookAllowedHosts = []string{"Hooks.Example.com", "10.0.0.1:8080", "*.ci.example.com"}
+	for _, u := range []string{
+		"https://hooks.example.com/check",
+		"http://hooks.example.com:8443/check",
+		"http://10.0.0.1:8080/check",
+		"https://a.ci.example.com/check",
+	} {
+		assert.NoError(t, CheckExecCheckWebhookURL(u), u)
+	}
+	for _, u := range []string{
+		"http://10.0.0.1/check",
+		"http://169.254.169.254/latest/meta-data",
+		"https://evil-ci.example.com/check",
+		"https://ci.example.com/check",
+		"ftp://hooks.example.com/check",
+		"hooks.example.com/check",
+	} {
+		assert.Error(t, CheckExecCheckWebhookURL(u), u)
+	}
+}
+
+func TestCheckExecCheckTypeSupported(t *testing.T) {
+	assert.NoError(t, CheckExecCheckTypeSupported(model.WorkflowExecCheckTypeLongTransaction, driverV2.DriverTypeMySQL))
+	assert.Error(t, CheckExecCheckTypeSupported(model.WorkflowExecCheckTypeLongTransaction, driverV2.DriverTypePostgreSQL))
+	assert.NoError(t, CheckExecCheckTypeSupported(model.WorkflowExecCheckTypeWebhook, driverV2.DriverTypePostgreSQL))
+	// no plugin supports query in the test
+	assert.Error(t, CheckExecCheckTypeSupported(model.WorkflowExecCheckTypeSQLAssertion, driverV2.DriverTypeMySQL))
+}
+
+func TestCheckExecCheckSQL(t *testing.T) {
+	// open the built-in MySQL plugin without registering it, the registration changes the other tests of the package.
+	plugin, err := driver.BuiltInPluginProcessors[driverV2.DriverTypeMySQL].Open(log.NewEntry(), &driverV2.Config{})
+	assert.NoError(t, err)
+	defer plugin.Close(context.TODO())
+	for _, sql := range []string{
+		"SELECT COUNT(*) FROM t1",
+		"SELECT 1",
+	} {
+		assert.NoError(t, checkExecCheckSQL(plugin, sql), sql)
+	}
+	for _, sql := range []string{
+		"DELETE FROM t1",
+		"UPDATE t1 SET a = 1",
+		"DROP TABLE t1",
+		"SELECT 1; DROP TABLE t1",
+		"SELECT 1; SELECT 2",
+	} {
+		assert.Error(t, checkExecCheckSQL(plugin, sql), sql)
+	}
+	assert.Error(t, CheckExecCheckSQLAssertion("unknown", "SELECT 1"))
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"github.com/actiontech/sqle/sqle/driver"
+	"github.com/actiontech/sqle/sqle/model"
+)
+
+const (
+	// ResultPreviewMaxRows is the max number of rows saved for the executed SQL returning rows.
+	ResultPreviewMaxRows = 100
+	// resultPreviewMaxValueLength is the max length of a value saved in the result preview.
+	resultPreviewMaxValueLength = 1024
+)
+
+func newSQLResultPreview(result *driver.QueryPreviewResult) model.SQLResultPreview {
+	preview := model.SQLResultPreview{
+		Columns:   result.Columns,
+		Rows:      make([][]string, 0, len(result.Rows)),
+		Truncated: result.Truncated,
+	}
+	for _, row := range result.Rows {
+		values := make([]string, 0, len(row))
+		for _, value := range row {
+			if len(value) > resultPreviewMaxValueLength {
+				value = value[:resultPreviewMaxValueLength] + "..."
+			}
+			values = append(values, value)
+		}
+		preview.Rows = append(preview.Rows, values)
+	}
+	return preview
+}
+
+// collectExecWarnings saves the warnings of the SQL, it must be called right after the SQL is executed on the session of the plugin.
+func (a *action) collectExecWarnings(collector driver.ExecOutputCollector, executeSQL *model.ExecuteSQL) {
+	warnings, err := collector.ShowWarnings(context.TODO())
+	if err != nil {
+		a.entry.Warnf("show warnings of SQL %v failed: %v", executeSQL.Number, err)
+		return
+	}
+	setExecWarnings(executeSQL, warnings)
+}
+
+// setExecWarningsOfSQLs saves the warnings of the SQLs executed in a transaction or batch, the warnings are in the
+// order of the SQLs executed.
+func setExecWarningsOfSQLs(executeSQLs []*model.ExecuteSQL, warnings [][]*driver.ExecWarning) {
+	for idx, executeSQL := range executeSQLs {
+		if idx >= len(warnings) {
+			return
+		}
+		if warnings[idx] != nil {
+			setExecWarnings(executeSQL, warnings[idx])
+		}
+	}
+}
+
+func setExecWarnings(executeSQL *model.ExecuteSQL, warnings []*driver.ExecWarning) {
+	executeSQL.ExecWarnings = make(model.ExecWarnings, 0, len(warnings))
+	for _, warning := range warnings {
+		executeSQL.ExecWarnings = append(executeSQL.ExecWarnings, model.ExecWarning{
+			Level:   warning.Level,
+			Code:    warning.Code,
+			Message: warning.Message,
+		})
+	}
+}
+
+// setExecDuration sets the duration of the SQLs executed in a transaction or batch, they share the duration.
+func setExecDuration(executeSQLs []*model.ExecuteSQL, duration time.Duration) {
+	for _, executeSQL := range executeSQLs {
+		executeSQL.ExecDuration = uint64(duration.Milliseconds())
+	}
+}
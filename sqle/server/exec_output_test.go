@@ -0,0 +1,55 @@
+package server
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/actiontech/sqle/sqle/driver"
+	"github.com/actiontech/sqle/sqle/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSQLResultPreview(t *testing.T) {
+	long := strings.Repeat("a", resultPreviewMaxValueLength+10)
+	preview := newSQLResultPreview(&driver.QueryPreviewResult{
+		Columns:   []string{"id", "name"},
+		Rows:      [][]string{{"1", "a"}, {"2", long}},
+		Truncated: true,
+	})
+	assert.Equal(t, []string{"id", "name"}, preview.Columns)
+	assert.Equal(t, []string{"1", "a"}, preview.Rows[0])
+	assert.Equal(t, long[:resultPreviewMaxValueLength]+"...", preview.Rows[1][1])
+	assert.True(t, preview.Truncated)
+}
+
+func TestSetExecDuration(t *testing.T) {
+	sqls := []*model.ExecuteSQL{{}, {}}
+	setExecDuration(sqls, 1500*time.Millisecond)
+	for _, sql := range sqls {
+		assert.Equal(t, uint64(1500), sql.ExecDuration)
+	}
+}
+
+func TestSetExecWarningsOfSQLs(t *testing.T) {
+	sqls := []*model.ExecuteSQL{{}, {}, {}}
+	setExecWarningsOfSQLs(sqls, [][]*driver.ExecWarning{
+		{{Level: "Warning", Code: "1265", Message: "Data truncated for column 'c1' at row 1"}},
+		nil,
+	})
+	assert.Equal(t, model.ExecWarnings{{Level: "Warning", Code: "1265", Message: "Data truncated for column 'c1' at row 1"}}, sqls[0].ExecWarnings)
+	assert.Nil(t, sqls[1].ExecWarnings)
+	assert.Nil(t, sqls[2].ExecWarnings)
+}
+
+func TestExecOutputString(t *testing.T) {
+	warnings := model.ExecWarnings{
+		{Level: "Warning", Code: "1265", Message: "Data truncated for column 'c1' at row 1"},
+		{Level: "Note", Code: "1051", Message: "Unknown table 'db1.t2'"},
+	}
+	assert.Equal(t, "Warning 1265: Data truncated for column 'c1' at row 1\nNote 1051: Unknown table 'db1.t2'", warnings.String())
+
+	preview := model.SQLResultPreview{Columns: []string{"id", "name"}, Rows: [][]string{{"1", "a"}}, Truncated: true}
+	assert.Equal(t, "id\tname\n1\ta\n...", preview.String())
+	assert.Equal(t, "", model.SQLResultPreview{}.String())
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/actiontech/sqle/sqle/errors"
+	"github.com/actiontech/sqle/sqle/model"
+)
+
+// WorkflowExecutionFreeze is the freeze calendars which block the execution of the workflow at the time.
+type WorkflowExecutionFreeze struct {
+	Calendars []*model.ExecutionFreezeCalendar
+	// instance names of the tasks blocked by the freeze calendars
+	InstanceNames []string
+	Override      *model.WorkflowFreezeOverride
+}
+
+func (f *WorkflowExecutionFreeze) IsFrozen() bool {
+	return len(f.Calendars) > 0
+}
+
+// IsBlocked reports whether the execution is blocked, the execution is not blocked if the emergency override is approved.
+func (f *WorkflowExecutionFreeze) IsBlocked() bool {
+	return f.IsFrozen() && !f.Override.IsApproved()
+}
+
+func (f *WorkflowExecutionFreeze) Reason() string {
+	names := make([]string, 0, len(f.Calendars))
+	for _, calendar := range f.Calendars {
+		names = append(names, calendar.Name)
+	}
+	return fmt.Sprintf("the execution of instances [%v] is frozen by [%v], please execute after the freeze or apply for an emergency override",
+		strings.Join(f.InstanceNames, ","), strings.Join(names, ","))
+}
+
+// maxScheduleBlockedDuration limits how long the scheduled execution waits for the freeze to end, the freeze calendars
+// may be changed or the emergency override may be approved before the end.
+const maxScheduleBlockedDuration = 10 * time.Minute
+
+// ScheduleRetryAt returns the time to retry the scheduled execution blocked at the time, it is the end of the latest
+// blocking freeze period but no later than maxScheduleBlockedDuration.
+func (f *WorkflowExecutionFreeze) ScheduleRetryAt(t time.Time) time.Time {
+	retryAt := t
+	for _, calendar := range f.Calendars {
+		if end := calendar.FrozenUntil(t); end.After(retryAt) {
+			retryAt = end
+		}
+	}
+	if limit := t.Add(maxScheduleBlockedDuration); retryAt.After(limit) {
+		retryAt = limit
+	}
+	return retryAt
+}
+
+// GetWorkflowExecutionFreeze returns the freeze calendars blocking the tasks of the workflow at the time,
+// all tasks of the workflow are checked if taskIds is nil.
+func GetWorkflowExecutionFreeze(workflow *model.Workflow, taskIds map[uint]string, t time.Time) (*WorkflowExecutionFreeze, error) {
+	s := model.GetStorage()
+	freeze := &WorkflowExecutionFreeze{}
+	calendars, err := s.GetExecutionFreezeCalendarsByProject(workflow.ProjectId)
+	if err != nil {
+		return nil, err
+	}
+	blocking := map[uint]struct{}{}
+	for _, ir := range workflow.Record.InstanceRecords {
+		if _, ok := taskIds[ir.TaskId]; taskIds != nil && !ok {
+			continue
+		}
+		frozen := false
+		for _, calendar := range calendars {
+			if !calendar.AppliesTo(ir.Instance) || !calendar.IsFrozen(t) {
+				continue
+			}
+			frozen = true
+			if _, ok := blocking[calendar.ID]; !ok {
+				blocking[calendar.ID] = struct{}{}
+				freeze.Calendars = append(freeze.Calendars, calendar)
+			}
+		}
+		if frozen && ir.Instance != nil {
+			freeze.InstanceNames = append(freeze.InstanceNames, ir.Instance.Name)
+		}
+	}
+
+	override, exist, err := s.GetWorkflowFreezeOverride(workflow.WorkflowId, workflow.Record.ID)
+	if err != nil {
+		return nil, err
+	}
+	if exist {
+		freeze.Override = override
+	}
+	return freeze, nil
+}
+
+// CheckWorkflowExecutionFreeze returns error if the execution of the tasks is blocked by the freeze calendars at the time.
+func CheckWorkflowExecutionFreeze(workflow *model.Workflow, taskIds map[uint]string, t time.Time) error {
+	freeze, err := GetWorkflowExecutionFreeze(workflow, taskIds, t)
+	if err != nil {
+		return err
+	}
+	if freeze.IsBlocked() {
+		return errors.New(errors.TaskActionInvalid, fmt.Errorf("%v", freeze.Reason()))
+	}
+	return nil
+}
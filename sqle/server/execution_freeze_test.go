@@ -0,0 +1,26 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/actiontech/sqle/sqle/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkflowExecutionFreeze_ScheduleRetryAt(t *testing.T) {
+	now := time.Date(2024, 3, 25, 12, 0, 0, 0, time.Local)
+	start := now.Add(-time.Hour)
+	soon := now.Add(5 * time.Minute)
+	later := now.Add(24 * time.Hour)
+
+	freeze := &WorkflowExecutionFreeze{Calendars: []*model.ExecutionFreezeCalendar{
+		{StartTime: &start, EndTime: &soon, Enabled: true},
+	}}
+	assert.Equal(t, soon, freeze.ScheduleRetryAt(now))
+
+	// the retry time is limited even if the freeze ends later
+	freeze.Calendars = append(freeze.Calendars, &model.ExecutionFreezeCalendar{StartTime: &start, EndTime: &later, Enabled: true})
+	assert.Equal(t, now.Add(maxScheduleBlockedDuration), freeze.ScheduleRetryAt(now))
+}
@@ -0,0 +1,167 @@
+package server
+
+import (
+	"context"
+	_errors "errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/actiontech/sqle/sqle/driver/mysql/executor"
+	driverV2 "github.com/actiontech/sqle/sqle/driver/v2"
+	"github.com/actiontech/sqle/sqle/model"
+	"github.com/actiontech/sqle/sqle/utils/retry"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+const (
+	mysqlErrLockWaitTimeout = 1205
+	mysqlErrLockDeadlock    = 1213
+)
+
+// the errors of plugin may be passed as text, so the MySQL error number is also matched by the message
+var mysqlLockConflictErrRegexp = regexp.MustCompile(`Error (1205|1213)\b`)
+
+// isLockConflictError reports whether the SQL failed by deadlock or lock wait timeout, the SQL can be retried.
+func isLockConflictError(err error) bool {
+	var mysqlErr *mysql.MySQLError
+	if _errors.As(err, &mysqlErr) {
+		return mysqlErr.Number == mysqlErrLockWaitTimeout || mysqlErr.Number == mysqlErrLockDeadlock
+	}
+	return mysqlLockConflictErrRegexp.MatchString(err.Error())
+}
+
+// applyExecSessionVariables overrides the lock wait timeout of the session which executes the SQLs of the task.
+func (a *action) applyExecSessionVariables() error {
+	if a.task.DBType != driverV2.DriverTypeMySQL {
+		return nil
+	}
+	for _, sql := range a.task.ExecPolicy.SessionVariableSQLs() {
+		if _, err := a.plugin.Exec(context.TODO(), sql); err != nil {
+			return fmt.Errorf("apply execution policy failed, sql: %v, err: %v", sql, err)
+		}
+	}
+	return nil
+}
+
+// execWithPolicy executes fn in the statement timeout, and retries fn if it fails by lock conflict.
+// fn should be safe to retry, such as a single SQL or a transaction.
+func (a *action) execWithPolicy(fn func() error) error {
+	policy := a.task.ExecPolicy
+	exec := func() error {
+		return a.execWithTimeout(fn)
+	}
+	if policy.RetryAttempts == 0 {
+		return exec()
+	}
+	return retry.Do(exec, nil,
+		retry.Attempts(policy.RetryAttempts+1),
+		retry.Delay(policy.RetryDelay()),
+		retry.BackOff(),
+		retry.RetryIf(func(err error) bool {
+			if a.hasTermination() || a.killedByTimeoutErr != nil || !isLockConflictError(err) {
+				return false
+			}
+			a.entry.Warnf("execution failed by lock conflict, retrying, err: %v", err)
+			return true
+		}),
+	)
+}
+
+// execWithTimeout kills the execution if fn doesn't finish in the statement timeout. The timeout covers the whole
+// call of fn, that is a single SQL, a transaction or a batch of SQLs. The kill is performed only while fn is running,
+// and fn doesn't return until the kill finishes, so the kill never lands on the following SQL. The connection is
+// unusable after the kill, the following executions fail without executing.
+func (a *action) execWithTimeout(fn func() error) error {
+	if a.killedByTimeoutErr != nil {
+		return a.killedByTimeoutErr
+	}
+	timeout := a.task.ExecPolicy.StatementTimeout()
+	if timeout == 0 {
+		return fn()
+	}
+	var mu sync.Mutex
+	finished, killed := false, false
+	timer := time.AfterFunc(timeout, func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if finished {
+			return
+		}
+		killed = true
+		a.entry.Warnf("execution exceeds the statement timeout %v, killing", timeout)
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		if err := a.terminateExecution(ctx); err != nil {
+			a.entry.Errorf("kill the execution exceeding the statement timeout failed: %v", err)
+		}
+	})
+	err := fn()
+	timer.Stop()
+	// wait for the kill in progress
+	mu.Lock()
+	finished = true
+	mu.Unlock()
+	if !killed {
+		return err
+	}
+	a.killedByTimeoutErr = fmt.Errorf("the connection is killed since the execution exceeded the statement timeout %v", timeout)
+	if err != nil {
+		return fmt.Errorf("execution exceeds the statement timeout %v: %w", timeout, err)
+	}
+	// fn finished while it was being killed
+	return nil
+}
+
+// checkBlockingSessions returns an error listing the sessions which may block the DDL by metadata lock,
+// the DDL waiting for metadata lock blocks all the following queries on the table.
+func (a *action) checkBlockingSessions(executeSQLs ...*model.ExecuteSQL) error {
+	minSeconds := a.task.ExecPolicy.BlockingSessionSeconds
+	if minSeconds == 0 || a.task.DBType != driverV2.DriverTypeMySQL || a.task.Instance == nil {
+		return nil
+	}
+	hasDDL := false
+	for _, executeSQL := range executeSQLs {
+		nodes, err := a.plugin.Parse(context.TODO(), executeSQL.Content)
+		if err != nil {
+			return err
+		}
+		if len(nodes) > 0 && nodes[0].Type == driverV2.SQLTypeDDL {
+			hasDDL = true
+			break
+		}
+	}
+	if !hasDDL {
+		return nil
+	}
+
+	inst := a.task.Instance
+	conn, err := executor.NewExecutor(a.entry, &driverV2.DSN{
+		Host:             inst.Host,
+		Port:             inst.Port,
+		User:             inst.User,
+		Password:         inst.Password,
+		AdditionalParams: inst.AdditionalParams,
+	}, "")
+	if err != nil {
+		return fmt.Errorf("connect to instance to check blocking sessions failed: %v", err)
+	}
+	defer conn.Db.Close()
+	sessions, err := conn.ShowBlockingSessions(minSeconds)
+	if err != nil {
+		return fmt.Errorf("check blocking sessions failed: %v", err)
+	}
+	if len(sessions) == 0 {
+		return nil
+	}
+	descs := make([]string, 0, len(sessions))
+	for _, s := range sessions {
+		descs = append(descs, fmt.Sprintf("id=%v user=%v host=%v db=%v command=%v time=%v state=%v trx_started=%v info=%v",
+			s.Id, s.User, s.Host, s.DB, s.Command, s.Time, s.State, s.TrxStarted, s.Info))
+	}
+	return fmt.Errorf("the DDL is not executed, there are %d sessions may block it by metadata lock: %v",
+		len(sessions), strings.Join(descs, "; "))
+}
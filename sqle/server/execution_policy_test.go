@@ -0,0 +1,56 @@
+package server
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	driverV2 "github.com/actiontech/sqle/sqle/driver/v2"
+	"github.com/actiontech/sqle/sqle/log"
+	"github.com/actiontech/sqle/sqle/model"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsLockConflictError(t *testing.T) {
+	assert.True(t, isLockConflictError(&mysql.MySQLError{Number: 1213, Message: "Deadlock found when trying to get lock"}))
+	assert.True(t, isLockConflictError(fmt.Errorf("exec failed: %w", &mysql.MySQLError{Number: 1205})))
+	assert.True(t, isLockConflictError(fmt.Errorf("rpc error: Error 1205: Lock wait timeout exceeded; try restarting transaction")))
+	assert.False(t, isLockConflictError(&mysql.MySQLError{Number: 1062, Message: "Duplicate entry"}))
+	assert.False(t, isLockConflictError(fmt.Errorf("Error 12050: unknown")))
+	assert.False(t, isLockConflictError(mysql.ErrInvalidConn))
+}
+
+func TestTaskExecPolicy_SessionVariableSQLs(t *testing.T) {
+	assert.Empty(t, model.TaskExecPolicy{}.SessionVariableSQLs())
+	assert.Equal(t, []string{
+		"SET SESSION lock_wait_timeout = 5",
+		"SET SESSION innodb_lock_wait_timeout = 10",
+	}, model.TaskExecPolicy{LockWaitTimeoutSeconds: 5, InnodbLockWaitTimeoutSeconds: 10}.SessionVariableSQLs())
+}
+
+func TestAction_execWithTimeout(t *testing.T) {
+	a := &action{
+		task:  &model.Task{DBType: driverV2.DriverTypeMySQL, ExecPolicy: model.TaskExecPolicy{StatementTimeoutSeconds: 1}},
+		entry: log.NewEntry(),
+	}
+	assert.NoError(t, a.execWithTimeout(func() error { return nil }))
+	assert.NoError(t, a.killedByTimeoutErr)
+
+	err := a.execWithTimeout(func() error {
+		time.Sleep(1500 * time.Millisecond)
+		return mysql.ErrInvalidConn
+	})
+	assert.ErrorIs(t, err, mysql.ErrInvalidConn)
+	assert.Error(t, a.killedByTimeoutErr)
+
+	// the connection is unusable after the kill
+	executed := false
+	err = a.execWithTimeout(func() error {
+		executed = true
+		return nil
+	})
+	assert.Equal(t, a.killedByTimeoutErr, err)
+	assert.False(t, executed)
+}
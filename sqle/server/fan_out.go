@@ -0,0 +1,145 @@
+package server
+
+import (
+	"crypto/md5"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	driverV2 "github.com/actiontech/sqle/sqle/driver/v2"
+	"github.com/actiontech/sqle/sqle/model"
+
+	"github.com/sirupsen/logrus"
+)
+
+// MatchSchemas returns the schemas matching the pattern, the pattern uses the syntax of LIKE,
+// '%' matches any characters and '_' matches one character.
+func MatchSchemas(pattern string, schemas []string) ([]string, error) {
+	expr := strings.Builder{}
+	expr.WriteString("^")
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			expr.WriteString(".*")
+		case '_':
+			expr.WriteString(".")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	expr.WriteString("$")
+	re, err := regexp.Compile(expr.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema pattern %v: %v", pattern, err)
+	}
+	matched := []string{}
+	for _, schema := range schemas {
+		if re.MatchString(schema) {
+			matched = append(matched, schema)
+		}
+	}
+	sort.Strings(matched)
+	return matched, nil
+}
+
+// GetSchemaShapes returns the fingerprints of the table structures of the schemas, the schemas with the same
+// tables, columns and indexes have the same fingerprint. Only MySQL is supported, the fingerprints of the schemas
+// of other databases are empty.
+func GetSchemaShapes(l *logrus.Entry, inst *model.Instance, schemas []string) (map[string]string, error) {
+	shapes := make(map[string]string, len(schemas))
+	if inst.DbType != driverV2.DriverTypeMySQL || len(schemas) == 0 {
+		return shapes, nil
+	}
+	conn, err := newMySQLExecutor(l, inst)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Db.Close()
+
+	lines := make(map[string][]string, len(schemas))
+	args := make([]interface{}, 0, len(schemas))
+	for _, schema := range schemas {
+		args = append(args, schema)
+		lines[schema] = []string{}
+	}
+	in := strings.TrimSuffix(strings.Repeat("?,", len(schemas)), ",")
+	for _, query := range []struct {
+		sql     string
+		columns []string
+	}{
+		{
+			sql: fmt.Sprintf("SELECT TABLE_SCHEMA, TABLE_NAME, COLUMN_NAME, COLUMN_TYPE, IS_NULLABLE, COLUMN_DEFAULT "+
+				"FROM information_schema.COLUMNS WHERE TABLE_SCHEMA IN (%s) ORDER BY TABLE_SCHEMA, TABLE_NAME, ORDINAL_POSITION", in),
+			columns: []string{"TABLE_NAME", "COLUMN_NAME", "COLUMN_TYPE", "IS_NULLABLE", "COLUMN_DEFAULT"},
+		},
+		{
+			sql: fmt.Sprintf("SELECT TABLE_SCHEMA, TABLE_NAME, INDEX_NAME, NON_UNIQUE, SEQ_IN_INDEX, COLUMN_NAME "+
+				"FROM information_schema.STATISTICS WHERE TABLE_SCHEMA IN (%s) ORDER BY TABLE_SCHEMA, TABLE_NAME, INDEX_NAME, SEQ_IN_INDEX", in),
+			columns: []string{"TABLE_NAME", "INDEX_NAME", "NON_UNIQUE", "SEQ_IN_INDEX", "COLUMN_NAME"},
+		},
+	} {
+		rows, err := conn.Db.Query(query.sql, args...)
+		if err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			values := make([]string, 0, len(query.columns))
+			for _, column := range query.columns {
+				values = append(values, row[column].String)
+			}
+			schema := row["TABLE_SCHEMA"].String
+			lines[schema] = append(lines[schema], strings.Join(values, "\t"))
+		}
+		for schema := range lines {
+			lines[schema] = append(lines[schema], "")
+		}
+	}
+	for schema, schemaLines := range lines {
+		shapes[schema] = fmt.Sprintf("%x", md5.Sum([]byte(strings.Join(schemaLines, "\n"))))
+	}
+	return shapes, nil
+}
+
+// CanShareAuditResult checks whether the audit result of the task can be used by the other tasks with the same
+// schema shape. The task creating backup tasks in audit must be audited by itself.
+func CanShareAuditResult(task *model.Task) bool {
+	return task.SchemaShape != "" && !task.EnableBackup
+}
+
+// CopyTaskAuditResult copies the audit result of the audited task to the task with the same SQLs and schema shape.
+func CopyTaskAuditResult(from, to *model.Task) error {
+	if len(from.ExecuteSQLs) != len(to.ExecuteSQLs) {
+		return fmt.Errorf("the SQLs of task %v and task %v are different", from.ID, to.ID)
+	}
+	audited := make(map[uint]*model.ExecuteSQL, len(from.ExecuteSQLs))
+	for _, sql := range from.ExecuteSQLs {
+		audited[sql.Number] = sql
+	}
+	for _, sql := range to.ExecuteSQLs {
+		origin, ok := audited[sql.Number]
+		if !ok || origin.Content != sql.Content {
+			return fmt.Errorf("the SQLs of task %v and task %v are different", from.ID, to.ID)
+		}
+		sql.AuditStatus = origin.AuditStatus
+		sql.AuditResults = origin.AuditResults
+		sql.AuditFingerprint = origin.AuditFingerprint
+		sql.AuditLevel = origin.AuditLevel
+		sql.SQLType = origin.SQLType
+	}
+	to.PassRate = from.PassRate
+	to.AuditLevel = from.AuditLevel
+	to.Score = from.Score
+	to.Status = from.Status
+
+	st := model.GetStorage()
+	if err := st.UpdateExecuteSQLs(to.ExecuteSQLs); err != nil {
+		return err
+	}
+	return st.UpdateTask(to, map[string]interface{}{
+		"pass_rate":   to.PassRate,
+		"audit_level": to.AuditLevel,
+		"status":      to.Status,
+		"score":       to.Score,
+	})
+}
@@ -0,0 +1,48 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/actiontech/sqle/sqle/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchSchemas(t *testing.T) {
+	schemas := []string{"tenant_2", "tenant_1", "tenant_10", "tenantx1", "tenant.1", "other"}
+
+	matched, err := MatchSchemas("tenant_%", schemas)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"tenant.1", "tenant_1", "tenant_10", "tenant_2", "tenantx1"}, matched)
+
+	matched, err = MatchSchemas("tenant_1", schemas)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"tenant.1", "tenant_1", "tenantx1"}, matched)
+
+	matched, err = MatchSchemas("shop_%", schemas)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{}, matched)
+
+	matched, err = MatchSchemas("tenant._", schemas)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"tenant.1"}, matched)
+
+	matched, err = MatchSchemas("%", schemas)
+	assert.NoError(t, err)
+	assert.Len(t, matched, len(schemas))
+}
+
+func TestCopyTaskAuditResultWithDifferentSQLs(t *testing.T) {
+	newSQL := func(number uint, content string) *model.ExecuteSQL {
+		return &model.ExecuteSQL{BaseSQL: model.BaseSQL{Number: number, Content: content}}
+	}
+	from := &model.Task{ExecuteSQLs: []*model.ExecuteSQL{newSQL(1, "select 1"), newSQL(2, "select 2")}}
+
+	assert.Error(t, CopyTaskAuditResult(from, &model.Task{ExecuteSQLs: []*model.ExecuteSQL{newSQL(1, "select 1")}}))
+	assert.Error(t, CopyTaskAuditResult(from, &model.Task{ExecuteSQLs: []*model.ExecuteSQL{newSQL(1, "select 1"), newSQL(2, "select 3")}}))
+}
+
+func TestCanShareAuditResult(t *testing.T) {
+	assert.False(t, CanShareAuditResult(&model.Task{}))
+	assert.True(t, CanShareAuditResult(&model.Task{SchemaShape: "d41d8cd98f00b204e9800998ecf8427e"}))
+	assert.False(t, CanShareAuditResult(&model.Task{SchemaShape: "d41d8cd98f00b204e9800998ecf8427e", EnableBackup: true}))
+}
@@ -19,6 +19,7 @@ var OnlyRunOnLeaderJobs = []func(entry *logrus.Entry) ServerJob{
 	NewFeishuJob,
 	NewWechatJob,
 	NewReportPushJob,
+	NewWorkflowStepEscalationJob,
 }
 
 var RunOnAllJobs = []func(entry *logrus.Entry) ServerJob{
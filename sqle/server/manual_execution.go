@@ -0,0 +1,293 @@
+package server
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/actiontech/sqle/sqle/driver/mysql/util"
+	driverV2 "github.com/actiontech/sqle/sqle/driver/v2"
+	"github.com/actiontech/sqle/sqle/errors"
+	"github.com/actiontech/sqle/sqle/model"
+
+	"github.com/pingcap/parser/ast"
+	"github.com/sirupsen/logrus"
+)
+
+// schemaFact is the state of a schema object expected after the DDLs of the task are executed.
+type schemaFact struct {
+	schema string
+	table  string
+	column string
+	index  string
+	exists bool
+}
+
+func (f *schemaFact) key() string {
+	return strings.Join([]string{f.schema, f.table, f.column, f.index}, "\x00")
+}
+
+func (f *schemaFact) String() string {
+	var object string
+	switch {
+	case f.column != "":
+		object = fmt.Sprintf("column %v.%v.%v", f.schema, f.table, f.column)
+	case f.index != "":
+		object = fmt.Sprintf("index %v on %v.%v", f.index, f.schema, f.table)
+	case f.table != "":
+		object = fmt.Sprintf("table %v.%v", f.schema, f.table)
+	default:
+		object = fmt.Sprintf("schema %v", f.schema)
+	}
+	if f.exists {
+		return object + " should exist"
+	}
+	return object + " should not exist"
+}
+
+// schemaFactCollector collects the expected states of the schema objects changed by the DDLs,
+// the later DDL overrides the state expected by the former DDL.
+type schemaFactCollector struct {
+	currentSchema string
+	facts         []*schemaFact
+	index         map[string]int
+}
+
+func newSchemaFactCollector(defaultSchema string) *schemaFactCollector {
+	return &schemaFactCollector{currentSchema: defaultSchema, index: map[string]int{}}
+}
+
+func (c *schemaFactCollector) add(fact *schemaFact) {
+	if fact.schema == "" {
+		return
+	}
+	if i, ok := c.index[fact.key()]; ok {
+		c.facts[i] = fact
+		return
+	}
+	c.index[fact.key()] = len(c.facts)
+	c.facts = append(c.facts, fact)
+}
+
+// remove removes the facts matched and returns them.
+func (c *schemaFactCollector) remove(match func(fact *schemaFact) bool) []*schemaFact {
+	removed := []*schemaFact{}
+	facts := make([]*schemaFact, 0, len(c.facts))
+	c.index = map[string]int{}
+	for _, fact := range c.facts {
+		if match(fact) {
+			removed = append(removed, fact)
+			continue
+		}
+		c.index[fact.key()] = len(facts)
+		facts = append(facts, fact)
+	}
+	c.facts = facts
+	return removed
+}
+
+// removeTableObjects removes the facts of the columns and indexes of the table, they are
+// not expected on the table any more after the table is dropped or renamed.
+func (c *schemaFactCollector) removeTableObjects(table *ast.TableName) []*schemaFact {
+	schema, name := c.schemaOf(table), table.Name.O
+	return c.remove(func(fact *schemaFact) bool {
+		return fact.schema == schema && fact.table == name && (fact.column != "" || fact.index != "")
+	})
+}
+
+func (c *schemaFactCollector) dropTable(table *ast.TableName) {
+	c.removeTableObjects(table)
+	c.addTable(table, false)
+}
+
+// renameTable moves the facts of the columns and indexes of the old table to the new table.
+func (c *schemaFactCollector) renameTable(oldTable, newTable *ast.TableName) {
+	objects := c.removeTableObjects(oldTable)
+	c.removeTableObjects(newTable)
+	c.addTable(oldTable, false)
+	c.addTable(newTable, true)
+	for _, object := range objects {
+		object.schema, object.table = c.schemaOf(newTable), newTable.Name.O
+		c.add(object)
+	}
+}
+
+func (c *schemaFactCollector) schemaOf(table *ast.TableName) string {
+	if table.Schema.O != "" {
+		return table.Schema.O
+	}
+	return c.currentSchema
+}
+
+func (c *schemaFactCollector) addTable(table *ast.TableName, exists bool) {
+	c.add(&schemaFact{schema: c.schemaOf(table), table: table.Name.O, exists: exists})
+}
+
+func (c *schemaFactCollector) addColumn(table *ast.TableName, column string, exists bool) {
+	c.add(&schemaFact{schema: c.schemaOf(table), table: table.Name.O, column: column, exists: exists})
+}
+
+func (c *schemaFactCollector) addIndex(table *ast.TableName, index string, exists bool) {
+	if index == "" {
+		// the name of the index is generated by the database
+		return
+	}
+	c.add(&schemaFact{schema: c.schemaOf(table), table: table.Name.O, index: index, exists: exists})
+}
+
+func constraintIndexName(constraint *ast.Constraint) string {
+	switch constraint.Tp {
+	case ast.ConstraintPrimaryKey:
+		return "PRIMARY"
+	case ast.ConstraintKey, ast.ConstraintIndex, ast.ConstraintUniq, ast.ConstraintUniqKey, ast.ConstraintUniqIndex, ast.ConstraintFulltext:
+		return constraint.Name
+	}
+	return ""
+}
+
+func (c *schemaFactCollector) collect(stmt ast.StmtNode) {
+	switch stmt := stmt.(type) {
+	case *ast.UseStmt:
+		c.currentSchema = stmt.DBName
+	case *ast.CreateDatabaseStmt:
+		c.add(&schemaFact{schema: stmt.Name, exists: true})
+	case *ast.DropDatabaseStmt:
+		// the tables in the dropped schema are not expected any more
+		c.remove(func(fact *schemaFact) bool { return fact.schema == stmt.Name })
+		c.add(&schemaFact{schema: stmt.Name, exists: false})
+	case *ast.CreateTableStmt:
+		if stmt.IsTemporary {
+			return
+		}
+		c.addTable(stmt.Table, true)
+		for _, col := range stmt.Cols {
+			c.addColumn(stmt.Table, col.Name.Name.O, true)
+			for _, option := range col.Options {
+				switch option.Tp {
+				case ast.ColumnOptionPrimaryKey:
+					c.addIndex(stmt.Table, "PRIMARY", true)
+				case ast.ColumnOptionUniqKey:
+					// the unique index defined in the column is named by the column
+					c.addIndex(stmt.Table, col.Name.Name.O, true)
+				}
+			}
+		}
+		for _, constraint := range stmt.Constraints {
+			c.addIndex(stmt.Table, constraintIndexName(constraint), true)
+		}
+	case *ast.DropTableStmt:
+		if stmt.IsView {
+			return
+		}
+		for _, table := range stmt.Tables {
+			c.dropTable(table)
+		}
+	case *ast.RenameTableStmt:
+		for _, t := range stmt.TableToTables {
+			c.renameTable(t.OldTable, t.NewTable)
+		}
+	case *ast.CreateIndexStmt:
+		c.addIndex(stmt.Table, stmt.IndexName, true)
+	case *ast.DropIndexStmt:
+		c.addIndex(stmt.Table, stmt.IndexName, false)
+	case *ast.AlterTableStmt:
+		table := stmt.Table
+		for _, spec := range stmt.Specs {
+			switch spec.Tp {
+			case ast.AlterTableAddColumns:
+				for _, col := range spec.NewColumns {
+					c.addColumn(table, col.Name.Name.O, true)
+				}
+			case ast.AlterTableDropColumn:
+				c.addColumn(table, spec.OldColumnName.Name.O, false)
+			case ast.AlterTableChangeColumn:
+				if len(spec.NewColumns) > 0 && !strings.EqualFold(spec.OldColumnName.Name.O, spec.NewColumns[0].Name.Name.O) {
+					c.addColumn(table, spec.OldColumnName.Name.O, false)
+				}
+				for _, col := range spec.NewColumns {
+					c.addColumn(table, col.Name.Name.O, true)
+				}
+			case ast.AlterTableRenameColumn:
+				c.addColumn(table, spec.OldColumnName.Name.O, false)
+				c.addColumn(table, spec.NewColumnName.Name.O, true)
+			case ast.AlterTableAddConstraint:
+				c.addIndex(table, constraintIndexName(spec.Constraint), true)
+			case ast.AlterTableDropIndex:
+				c.addIndex(table, spec.Name, false)
+			case ast.AlterTableDropPrimaryKey:
+				c.addIndex(table, "PRIMARY", false)
+			case ast.AlterTableRenameIndex:
+				c.addIndex(table, spec.FromKey.O, false)
+				c.addIndex(table, spec.ToKey.O, true)
+			case ast.AlterTableRenameTable:
+				c.renameTable(table, spec.NewTable)
+				table = spec.NewTable
+			}
+		}
+	}
+}
+
+// VerifyManualExecution checks the schema objects changed by the DDLs of the manually executed task are in the
+// expected states, only MySQL is supported. The task is skipped if it has no DDL to verify.
+func VerifyManualExecution(l *logrus.Entry, task *model.Task) (status string, result string, err error) {
+	if task.DBType != driverV2.DriverTypeMySQL || task.Instance == nil {
+		return "", "", errors.New(errors.DataInvalid, fmt.Errorf("only support verifying the task of MySQL"))
+	}
+	collector := newSchemaFactCollector(task.Schema)
+	for _, executeSQL := range task.ExecuteSQLs {
+		stmt, err := util.ParseOneSql(executeSQL.Content)
+		if err != nil {
+			// the SQL not parsed can't be verified
+			continue
+		}
+		collector.collect(stmt)
+	}
+	if len(collector.facts) == 0 {
+		return model.ManualExecutionVerifyStatusSkipped, "there is no schema change to verify", nil
+	}
+
+	conn, err := newMySQLExecutor(l, task.Instance)
+	if err != nil {
+		return "", "", err
+	}
+	defer conn.Db.Close()
+
+	mismatches := []string{}
+	for _, fact := range collector.facts {
+		exists, err := schemaObjectExists(conn.Db.Query, fact)
+		if err != nil {
+			return "", "", err
+		}
+		if exists != fact.exists {
+			mismatches = append(mismatches, fact.String())
+		}
+	}
+	if len(mismatches) > 0 {
+		return model.ManualExecutionVerifyStatusFailed, strings.Join(mismatches, "\n"), nil
+	}
+	return model.ManualExecutionVerifyStatusPassed, fmt.Sprintf("%v schema changes are verified", len(collector.facts)), nil
+}
+
+func schemaObjectExists(query func(string, ...interface{}) ([]map[string]sql.NullString, error), fact *schemaFact) (bool, error) {
+	var q string
+	var args []interface{}
+	switch {
+	case fact.column != "":
+		q = "SELECT 1 FROM information_schema.COLUMNS WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? AND COLUMN_NAME = ? LIMIT 1"
+		args = []interface{}{fact.schema, fact.table, fact.column}
+	case fact.index != "":
+		q = "SELECT 1 FROM information_schema.STATISTICS WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? AND INDEX_NAME = ? LIMIT 1"
+		args = []interface{}{fact.schema, fact.table, fact.index}
+	case fact.table != "":
+		q = "SELECT 1 FROM information_schema.TABLES WHERE TABLE_SCHEMA = ? AND TABLE_NAME = ? LIMIT 1"
+		args = []interface{}{fact.schema, fact.table}
+	default:
+		q = "SELECT 1 FROM information_schema.SCHEMATA WHERE SCHEMA_NAME = ? LIMIT 1"
+		args = []interface{}{fact.schema}
+	}
+	rows, err := query(q, args...)
+	if err != nil {
+		return false, err
+	}
+	return len(rows) > 0, nil
+}
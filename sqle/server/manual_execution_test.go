@@ -0,0 +1,107 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/actiontech/sqle/sqle/driver/mysql/util"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchemaFactCollector(t *testing.T) {
+	collector := newSchemaFactCollector("db1")
+	for _, sql := range []string{
+		"CREATE TABLE t1 (id INT PRIMARY KEY, name VARCHAR(32), KEY idx_name (name))",
+		"ALTER TABLE t1 ADD COLUMN age INT, DROP COLUMN name, DROP INDEX idx_name",
+		"INSERT INTO t1 (id) VALUES (1)",
+		"USE db2",
+		"CREATE INDEX idx_a ON t2 (a)",
+		"ALTER TABLE t3 RENAME COLUMN a TO b",
+		"RENAME TABLE t4 TO t5",
+		"DROP TABLE db1.t6",
+		"CREATE DATABASE db3",
+	} {
+		stmt, err := util.ParseOneSql(sql)
+		assert.NoError(t, err)
+		collector.collect(stmt)
+	}
+
+	facts := []string{}
+	for _, fact := range collector.facts {
+		facts = append(facts, fact.String())
+	}
+	assert.Equal(t, []string{
+		"table db1.t1 should exist",
+		"column db1.t1.id should exist",
+		"index PRIMARY on db1.t1 should exist",
+		"column db1.t1.name should not exist",
+		"index idx_name on db1.t1 should not exist",
+		"column db1.t1.age should exist",
+		"index idx_a on db2.t2 should exist",
+		"column db2.t3.a should not exist",
+		"column db2.t3.b should exist",
+		"table db2.t4 should not exist",
+		"table db2.t5 should exist",
+		"table db1.t6 should not exist",
+		"schema db3 should exist",
+	}, facts)
+}
+
+func TestSchemaFactCollector_DropAndRenameTable(t *testing.T) {
+	cases := []struct {
+		sqls  []string
+		facts []string
+	}{
+		{
+			sqls: []string{"CREATE TABLE t1 (id INT PRIMARY KEY)", "DROP TABLE t1"},
+			facts: []string{
+				"table db1.t1 should not exist",
+			},
+		},
+		{
+			sqls: []string{"CREATE TABLE t1 (id INT, KEY idx_id (id))", "RENAME TABLE t1 TO t2"},
+			facts: []string{
+				"table db1.t1 should not exist",
+				"table db1.t2 should exist",
+				"column db1.t2.id should exist",
+				"index idx_id on db1.t2 should exist",
+			},
+		},
+		{
+			sqls: []string{"ALTER TABLE t1 ADD COLUMN a INT", "ALTER TABLE t1 RENAME TO t2", "ALTER TABLE t2 DROP COLUMN a"},
+			facts: []string{
+				"table db1.t1 should not exist",
+				"table db1.t2 should exist",
+				"column db1.t2.a should not exist",
+			},
+		},
+		{
+			sqls: []string{"CREATE TABLE db2.t1 (id INT)", "ALTER TABLE t1 ADD COLUMN a INT", "DROP DATABASE db2"},
+			facts: []string{
+				"column db1.t1.a should exist",
+				"schema db2 should not exist",
+			},
+		},
+	}
+	for _, c := range cases {
+		collector := newSchemaFactCollector("db1")
+		for _, sql := range c.sqls {
+			stmt, err := util.ParseOneSql(sql)
+			assert.NoError(t, err)
+			collector.collect(stmt)
+		}
+		facts := []string{}
+		for _, fact := range collector.facts {
+			facts = append(facts, fact.String())
+		}
+		assert.Equal(t, c.facts, facts, c.sqls)
+	}
+}
+
+func TestSchemaFactCollector_NoDefaultSchema(t *testing.T) {
+	collector := newSchemaFactCollector("")
+	stmt, err := util.ParseOneSql("CREATE TABLE t1 (id INT)")
+	assert.NoError(t, err)
+	collector.collect(stmt)
+	assert.Empty(t, collector.facts)
+}
@@ -44,7 +44,7 @@ func (j *ReportPushJob) SyncReportPushJob(entry *logrus.Entry) {
 
 		// 重新启动任务
 		if config.Enabled {
-			pushTask, err := newPushJob(config)
+			pushTask, err := newReportPushJob(config)
 			if err != nil {
 				logger.Errorf("new push job failed: %v", err)
 				continue
@@ -65,3 +65,10 @@ type PushJob interface {
 	Cron() string
 	Run()
 }
+
+func newReportPushJob(p *model.ReportPushConfig) (PushJob, error) {
+	if p.Type == model.TypeSQLManageSLA {
+		return newSQLManageSLAPushJob(p), nil
+	}
+	return newPushJob(p)
+}
@@ -0,0 +1,271 @@
+package server
+
+import (
+	"context"
+	sqlDriver "database/sql/driver"
+	"fmt"
+	"sort"
+	"time"
+
+	driverV2 "github.com/actiontech/sqle/sqle/driver/v2"
+	"github.com/actiontech/sqle/sqle/errors"
+	"github.com/actiontech/sqle/sqle/model"
+)
+
+// AddRollbackTask rolls back the selected rollback SQLs of the executed task, all the rollback SQLs are
+// rolled back if rollbackSQLIds is empty. The rollback is recorded as a rollback run of the task.
+func (s *Sqled) AddRollbackTask(projectId string, taskId string, rollbackSQLIds []uint, userId string) error {
+	_, err := s.addTask(projectId, taskId, ActionTypeRollback, func(a *action) {
+		a.rollbackSQLIds = rollbackSQLIds
+		a.userId = userId
+	})
+	return err
+}
+
+// selectRollbackSQLs returns the selected rollback SQLs in the reverse order of execution.
+func (a *action) selectRollbackSQLs(task *model.Task) ([]*model.RollbackSQL, error) {
+	selected := make([]*model.RollbackSQL, 0, len(task.RollbackSQLs))
+	if len(a.rollbackSQLIds) == 0 {
+		selected = append(selected, task.RollbackSQLs...)
+	} else {
+		rollbackSQLs := make(map[uint]*model.RollbackSQL, len(task.RollbackSQLs))
+		for _, rollbackSQL := range task.RollbackSQLs {
+			rollbackSQLs[rollbackSQL.ID] = rollbackSQL
+		}
+		for _, id := range a.rollbackSQLIds {
+			rollbackSQL, ok := rollbackSQLs[id]
+			if !ok {
+				return nil, errors.New(errors.DataNotExist, fmt.Errorf("rollback SQL %v not exist in task", id))
+			}
+			selected = append(selected, rollbackSQL)
+		}
+	}
+
+	executeSQLNumbers := make(map[uint]uint, len(task.ExecuteSQLs))
+	for _, executeSQL := range task.ExecuteSQLs {
+		executeSQLNumbers[executeSQL.ID] = executeSQL.Number
+	}
+	sort.SliceStable(selected, func(i, j int) bool {
+		return executeSQLNumbers[selected[i].ExecuteSQLId] > executeSQLNumbers[selected[j].ExecuteSQLId]
+	})
+	return selected, nil
+}
+
+func (a *action) rollback() (err error) {
+	st := model.GetStorage()
+	task := a.task
+	a.entry.Info("start rollback SQL")
+
+	rollbackSQLs, err := a.selectRollbackSQLs(task)
+	if err != nil {
+		return err
+	}
+	executeSQLs := make(map[uint]*model.ExecuteSQL, len(task.ExecuteSQLs))
+	for _, executeSQL := range task.ExecuteSQLs {
+		executeSQLs[executeSQL.ID] = executeSQL
+	}
+
+	now := time.Now()
+	run := &model.RollbackRun{
+		TaskId:       task.ID,
+		CreateUserId: a.userId,
+		ExecMode:     task.ExecMode,
+		Status:       model.RollbackRunStatusRunning,
+		StartAt:      &now,
+	}
+	var planErr error
+	for _, rollbackSQL := range rollbackSQLs {
+		if rollbackSQL.Content == "" {
+			continue
+		}
+		nodes, err := a.plugin.Parse(context.TODO(), rollbackSQL.Content)
+		if err != nil {
+			planErr = fmt.Errorf("parse rollback SQL %v failed: %v", rollbackSQL.ID, err)
+			break
+		}
+		var batchId uint64
+		if executeSQL, ok := executeSQLs[rollbackSQL.ExecuteSQLId]; ok {
+			batchId = executeSQL.ExecBatchId
+		}
+		for _, node := range nodes {
+			run.SQLs = append(run.SQLs, &model.RollbackRunSQL{
+				RollbackSQLId: rollbackSQL.ID,
+				Number:        uint(len(run.SQLs) + 1),
+				Content:       node.Text,
+				SQLType:       node.Type,
+				ExecBatchId:   batchId,
+				ExecStatus:    model.SQLExecuteStatusInitialized,
+			})
+		}
+	}
+	if planErr != nil {
+		// nothing is executed if the rollback SQLs can't be parsed
+		run.SQLs = nil
+		run.Status = model.RollbackRunStatusFailed
+		run.ExecResult = planErr.Error()
+		run.EndAt = &now
+		if err := st.CreateRollbackRun(run); err != nil {
+			return err
+		}
+		return planErr
+	}
+	if err := st.CreateRollbackRun(run); err != nil {
+		return err
+	}
+	for _, rollbackSQL := range rollbackSQLs {
+		if err := st.UpdateRollbackSqlStatus(&rollbackSQL.BaseSQL, model.SQLExecuteStatusDoing, ""); err != nil {
+			return err
+		}
+	}
+
+	execErr := a.applyExecSessionVariables()
+	if execErr == nil {
+		execErr = a.execRollbackRunSQLs(run.SQLs)
+	}
+	if err := st.SaveRollbackRunSQLs(run.SQLs); err != nil {
+		return err
+	}
+	if err := updateRollbackSQLsByRun(st, rollbackSQLs, run.SQLs); err != nil {
+		return err
+	}
+
+	run.Status = model.RollbackRunStatusSucceeded
+	if execErr != nil {
+		run.Status = model.RollbackRunStatusFailed
+		run.ExecResult = execErr.Error()
+		a.entry.Errorf("rollback SQL error:%v", execErr)
+	} else {
+		a.entry.Info("rollback SQL finished")
+	}
+	if err := st.UpdateRollbackRun(run, map[string]interface{}{
+		"status":      run.Status,
+		"exec_result": run.ExecResult,
+		"end_at":      time.Now(),
+	}); err != nil {
+		return err
+	}
+	return execErr
+}
+
+const (
+	rollbackExecSingle = iota
+	rollbackExecTx
+	rollbackExecBatch
+)
+
+// rollbackRunSQLGroup is executed by one call of plugin.
+type rollbackRunSQLGroup struct {
+	typ  int
+	sqls []*model.RollbackRunSQL
+}
+
+// groupRollbackRunSQLs groups the SQLs like the execution of task: the SQLs of the same batch are executed in batch
+// in sql file mode, the adjacent DMLs are executed in one transaction in sqls mode.
+func groupRollbackRunSQLs(execMode string, sqls []*model.RollbackRunSQL) []*rollbackRunSQLGroup {
+	groups := []*rollbackRunSQLGroup{}
+	var last *rollbackRunSQLGroup
+	for _, sql := range sqls {
+		typ := rollbackExecSingle
+		switch {
+		case execMode == model.ExecModeSqlFile:
+			typ = rollbackExecBatch
+		case sql.SQLType == driverV2.SQLTypeDML || sql.SQLType == driverV2.SQLTypeDQL:
+			typ = rollbackExecTx
+		}
+		sameGroup := last != nil && last.typ == typ &&
+			(typ == rollbackExecTx || (typ == rollbackExecBatch && last.sqls[0].ExecBatchId == sql.ExecBatchId))
+		if sameGroup {
+			last.sqls = append(last.sqls, sql)
+			continue
+		}
+		last = &rollbackRunSQLGroup{typ: typ, sqls: []*model.RollbackRunSQL{sql}}
+		groups = append(groups, last)
+	}
+	return groups
+}
+
+// execRollbackRunSQLs stops at the first failed group, the SQLs after it are not executed.
+func (a *action) execRollbackRunSQLs(sqls []*model.RollbackRunSQL) error {
+	if a.task.ExecMode == model.ExecModeSqlFile {
+		checker, err := NewModuleStatusChecker(a.task.DBType, executeSqlFileMode)
+		if err != nil {
+			return err
+		}
+		if !checker.CheckIsSupport() {
+			return fmt.Errorf("plugin %v does not support execute sql file", a.task.DBType)
+		}
+	}
+	for _, group := range groupRollbackRunSQLs(a.task.ExecMode, sqls) {
+		qs := make([]string, 0, len(group.sqls))
+		for _, sql := range group.sqls {
+			qs = append(qs, sql.Content)
+		}
+		var results []sqlDriver.Result
+		var execErr error
+		switch group.typ {
+		case rollbackExecTx:
+			execErr = a.execWithPolicy(func() (err error) {
+				results, err = a.plugin.Tx(context.TODO(), qs...)
+				return err
+			})
+		case rollbackExecBatch:
+			execErr = a.execWithTimeout(func() (err error) {
+				results, err = a.plugin.ExecBatch(context.TODO(), qs...)
+				return err
+			})
+		default:
+			execErr = a.execWithPolicy(func() error {
+				result, err := a.plugin.Exec(context.TODO(), qs[0])
+				results = []sqlDriver.Result{result}
+				return err
+			})
+		}
+		for idx, sql := range group.sqls {
+			if execErr != nil {
+				sql.ExecStatus = model.SQLExecuteStatusFailed
+				sql.ExecResult = execErr.Error()
+				continue
+			}
+			sql.ExecStatus = model.SQLExecuteStatusSucceeded
+			sql.ExecResult = model.TaskExecResultOK
+			if idx < len(results) && results[idx] != nil {
+				sql.RowAffects, _ = results[idx].RowsAffected()
+			}
+		}
+		if execErr != nil {
+			return execErr
+		}
+	}
+	return nil
+}
+
+// updateRollbackSQLsByRun updates the status of rollback SQLs by the result of their SQLs in the run,
+// the rollback SQLs which are not executed are reset to be rolled back again.
+func updateRollbackSQLsByRun(st *model.Storage, rollbackSQLs []*model.RollbackSQL, runSQLs []*model.RollbackRunSQL) error {
+	runSQLsOfRollbackSQL := map[uint][]*model.RollbackRunSQL{}
+	for _, sql := range runSQLs {
+		runSQLsOfRollbackSQL[sql.RollbackSQLId] = append(runSQLsOfRollbackSQL[sql.RollbackSQLId], sql)
+	}
+	for _, rollbackSQL := range rollbackSQLs {
+		status, result := model.SQLExecuteStatusSucceeded, model.TaskExecResultOK
+		sqls := runSQLsOfRollbackSQL[rollbackSQL.ID]
+		for _, sql := range sqls {
+			if sql.ExecStatus == model.SQLExecuteStatusFailed {
+				status, result = model.SQLExecuteStatusFailed, sql.ExecResult
+				break
+			}
+			if sql.ExecStatus == model.SQLExecuteStatusInitialized {
+				status, result = model.SQLExecuteStatusInitialized, ""
+			}
+		}
+		if len(sqls) == 0 && rollbackSQL.Content != "" {
+			status, result = model.SQLExecuteStatusInitialized, ""
+		}
+		attrs := map[string]interface{}{"exec_status": status, "exec_result": result}
+		if err := st.UpdateRollbackSQLById(fmt.Sprintf("%v", rollbackSQL.ID), attrs); err != nil {
+			return err
+		}
+		rollbackSQL.ExecStatus, rollbackSQL.ExecResult = status, result
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package server
+
+import (
+	"testing"
+
+	driverV2 "github.com/actiontech/sqle/sqle/driver/v2"
+	"github.com/actiontech/sqle/sqle/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroupRollbackRunSQLs(t *testing.T) {
+	newSQL := func(typ string, batchId uint64) *model.RollbackRunSQL {
+		return &model.RollbackRunSQL{SQLType: typ, ExecBatchId: batchId}
+	}
+	sqls := []*model.RollbackRunSQL{
+		newSQL(driverV2.SQLTypeDML, 1),
+		newSQL(driverV2.SQLTypeDML, 1),
+		newSQL(driverV2.SQLTypeDDL, 2),
+		newSQL(driverV2.SQLTypeDDL, 2),
+		newSQL(driverV2.SQLTypeDML, 3),
+	}
+
+	groups := groupRollbackRunSQLs(model.ExecModeSqls, sqls)
+	assert.Len(t, groups, 4)
+	assert.Equal(t, rollbackExecTx, groups[0].typ)
+	assert.Len(t, groups[0].sqls, 2)
+	assert.Equal(t, rollbackExecSingle, groups[1].typ)
+	assert.Equal(t, rollbackExecSingle, groups[2].typ)
+	assert.Equal(t, rollbackExecTx, groups[3].typ)
+
+	groups = groupRollbackRunSQLs(model.ExecModeSqlFile, sqls)
+	assert.Len(t, groups, 3)
+	for _, group := range groups {
+		assert.Equal(t, rollbackExecBatch, group.typ)
+	}
+	assert.Len(t, groups[1].sqls, 2)
+}
+
+func TestAction_selectRollbackSQLs(t *testing.T) {
+	newExecuteSQL := func(id, number uint) *model.ExecuteSQL {
+		sql := &model.ExecuteSQL{BaseSQL: model.BaseSQL{Number: number}}
+		sql.ID = id
+		return sql
+	}
+	newRollbackSQL := func(id, executeSQLId uint, status string) *model.RollbackSQL {
+		sql := &model.RollbackSQL{BaseSQL: model.BaseSQL{ExecStatus: status}, ExecuteSQLId: executeSQLId}
+		sql.ID = id
+		return sql
+	}
+	task := &model.Task{
+		ExecuteSQLs: []*model.ExecuteSQL{
+			newExecuteSQL(1, 1),
+			newExecuteSQL(2, 2),
+			newExecuteSQL(3, 3),
+		},
+		RollbackSQLs: []*model.RollbackSQL{
+			newRollbackSQL(11, 1, model.SQLExecuteStatusInitialized),
+			newRollbackSQL(12, 2, model.SQLExecuteStatusSucceeded),
+			newRollbackSQL(13, 3, model.SQLExecuteStatusFailed),
+		},
+	}
+
+	// rolled back in the reverse order of execution
+	sqls, err := (&action{}).selectRollbackSQLs(task)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(13), sqls[0].ID)
+	assert.Equal(t, uint(11), sqls[2].ID)
+
+	sqls, err = (&action{rollbackSQLIds: []uint{11, 13}}).selectRollbackSQLs(task)
+	assert.NoError(t, err)
+	assert.Len(t, sqls, 2)
+	assert.Equal(t, uint(13), sqls[0].ID)
+
+	_, err = (&action{rollbackSQLIds: []uint{14}}).selectRollbackSQLs(task)
+	assert.Error(t, err)
+
+	// the succeeded rollback SQL can't be rolled back again, the failed one can
+	task.ExecuteSQLs[0].ExecStatus = model.SQLExecuteStatusSucceeded
+	assert.NoError(t, (&action{typ: ActionTypeRollback, rollbackSQLIds: []uint{11, 13}}).validation(task))
+	assert.EqualError(t, (&action{typ: ActionTypeRollback, rollbackSQLIds: []uint{12}}).validation(task), ErrActionRollbackOnRollbackedTask.Error())
+}
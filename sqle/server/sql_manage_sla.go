@@ -0,0 +1,100 @@
+package server
+
+import (
+	"strings"
+	"time"
+
+	"github.com/actiontech/sqle/sqle/log"
+	"github.com/actiontech/sqle/sqle/model"
+	"github.com/actiontech/sqle/sqle/notification"
+)
+
+// sqlManageSLAPushJob escalates the unhandled SQLs whose priority SLA is breached to the users configured in the
+// report push config and the assignees of the SQLs, every SQL is escalated only once.
+type sqlManageSLAPushJob struct {
+	config *model.ReportPushConfig
+}
+
+func newSQLManageSLAPushJob(config *model.ReportPushConfig) *sqlManageSLAPushJob {
+	return &sqlManageSLAPushJob{config: config}
+}
+
+func (j *sqlManageSLAPushJob) Cron() string {
+	if j.config.PushFrequencyCron == "" {
+		return model.DefaultSQLManageSLAPushCron
+	}
+	return j.config.PushFrequencyCron
+}
+
+func (j *sqlManageSLAPushJob) Run() {
+	logger := log.NewEntry().WithField("job", "sql_manage_sla_push")
+	s := model.GetStorage()
+	rules, err := s.GetSQLManagePriorityRulesByProjectId(j.config.ProjectId)
+	if err != nil {
+		logger.Errorf("get priority rules of project %v failed: %v", j.config.ProjectId, err)
+		return
+	}
+
+	now := time.Now()
+	pushed := false
+	for _, rule := range rules {
+		if rule.SLAMinutes == 0 {
+			continue
+		}
+		deadline := now.Add(-time.Duration(rule.SLAMinutes) * time.Minute)
+		records, err := s.GetSQLManageRecordsBreachingSLA(j.config.ProjectId, rule.Priority, deadline)
+		if err != nil {
+			logger.Errorf("get sqls breaching sla of priority %v failed: %v", rule.Priority, err)
+			continue
+		}
+		if len(records) == 0 {
+			continue
+		}
+		users := sqlManageSLAPushUsers(j.config.PushUserList, records)
+		if len(users) > 0 {
+			n := notification.NewSQLManageSLANotification(rule.Priority, rule.SLAMinutes, records)
+			if err := notification.Notify(n, users); err != nil {
+				logger.Errorf("notify sqls breaching sla of priority %v failed: %v", rule.Priority, err)
+				continue
+			}
+			pushed = true
+		}
+		ids := make([]uint, 0, len(records))
+		for _, record := range records {
+			ids = append(ids, record.ID)
+		}
+		if err := s.UpdateManageSQLProcessByManageIDs(ids, map[string]interface{}{"escalated_at": now}); err != nil {
+			logger.Errorf("mark sqls escalated failed: %v", err)
+		}
+	}
+	if !pushed {
+		return
+	}
+	// 更新最新推送时间
+	j.config.ReportPushConfigRecord.ReportPushConfigID = j.config.ID
+	j.config.ReportPushConfigRecord.LastPushTime = now
+	if err := s.Save(&j.config.ReportPushConfigRecord); err != nil {
+		logger.Errorf("update report push config time failed: %v", err)
+	}
+}
+
+func sqlManageSLAPushUsers(pushUsers []string, records []*model.SQLManageRecord) []string {
+	users := []string{}
+	exists := map[string]struct{}{}
+	add := func(user string) {
+		if _, ok := exists[user]; ok || user == "" {
+			return
+		}
+		exists[user] = struct{}{}
+		users = append(users, user)
+	}
+	for _, user := range pushUsers {
+		add(user)
+	}
+	for _, record := range records {
+		for _, user := range strings.Split(record.SQLManager.Assignees, ",") {
+			add(strings.TrimSpace(user))
+		}
+	}
+	return users
+}
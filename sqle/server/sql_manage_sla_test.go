@@ -0,0 +1,19 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/actiontech/sqle/sqle/model"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSqlManageSLAPushUsers(t *testing.T) {
+	records := []*model.SQLManageRecord{
+		{SQLManager: model.SQLManageRecordProcess{Assignees: "2,3"}},
+		{SQLManager: model.SQLManageRecordProcess{Assignees: ""}},
+		{SQLManager: model.SQLManageRecordProcess{Assignees: "3, 4"}},
+	}
+	assert.Equal(t, []string{"1", "2", "3", "4"}, sqlManageSLAPushUsers([]string{"1", "2"}, records))
+	assert.Equal(t, []string{}, sqlManageSLAPushUsers(nil, records[1:2]))
+}
@@ -2,6 +2,7 @@ package server
 
 import (
 	"context"
+	sqlDriver "database/sql/driver"
 	_errors "errors"
 	"fmt"
 	"sort"
@@ -62,7 +63,7 @@ func (s *Sqled) HasTask(taskId string) bool {
 
 // addTask receive taskId and action type, using taskId and typ to create an action;
 // action will be validated, and sent to Sqled.queue.
-func (s *Sqled) addTask(projectId string, taskId string, typ int) (*action, error) {
+func (s *Sqled) addTask(projectId string, taskId string, typ int, opts ...func(*action)) (*action, error) {
 	var err error
 	var p driver.Plugin
 	var rules []*model.Rule
@@ -76,6 +77,9 @@ func (s *Sqled) addTask(projectId string, taskId string, typ int) (*action, erro
 		entry: entry,
 		done:  make(chan struct{}),
 	}
+	for _, opt := range opts {
+		opt(action)
+	}
 
 	s.Lock()
 	_, taskRunning := s.currentTask[taskId]
@@ -194,6 +198,14 @@ func (s *Sqled) do(action *action) error {
 	delete(s.currentTask, taskId)
 	s.Unlock()
 
+	if action.rollbackAfterExecution && len(action.task.RollbackSQLs) == 0 {
+		action.entry.Warn("the post-execution check requires rolling back the task, but the task has no rollback SQLs")
+	} else if action.rollbackAfterExecution {
+		if rollbackErr := s.AddRollbackTask(action.projectId, taskId, nil, ""); rollbackErr != nil {
+			action.entry.Errorf("roll back task after the post-execution check failed: %v", rollbackErr)
+		}
+	}
+
 	utils.TryClose(action.done)
 
 	return err
@@ -224,6 +236,23 @@ type action struct {
 
 	customRules []*model.CustomRule
 	rules       []*model.Rule
+
+	// binlog records the binlog coordinates of the executed SQLs, it is nil if not supported.
+	binlog *binlogRecorder
+
+	// rollbackSQLIds are the rollback SQLs selected to roll back, all the rollback SQLs are rolled back if it is empty.
+	rollbackSQLIds []uint
+	userId         string
+
+	// rollbackAfterExecution is true if a failed post-execution check requires rolling back the executed task.
+	rollbackAfterExecution bool
+
+	// resume re-executes the SQLs of the failed task which are not executed successfully.
+	resume bool
+
+	// killedByTimeoutErr is set when the connection of the plugin is killed by the statement timeout, the following
+	// SQLs are not executed because the session variables of the connection are lost.
+	killedByTimeoutErr error
 }
 
 const (
@@ -263,6 +292,8 @@ var (
 	ErrActionRollbackOnRollbackedTask    = _errors.New("task has been rollbacked, can not do rollback on it")
 	ErrActionRollbackOnExecuteFailedTask = _errors.New("task has been executed failed, can not do rollback on it")
 	ErrActionRollbackOnNonExecutedTask   = _errors.New("task has not been executed, can not do rollback on it")
+	ErrActionExecutePreCheckFailed       = _errors.New("the pre-execution checks of the workflow failed, the task is not executed")
+	ErrActionResumeOnNonFailedTask       = _errors.New("task has not been executed failed, can not resume it")
 )
 
 // validation validate whether task can do action type(a.typ) or not.
@@ -272,6 +303,12 @@ func (a *action) validation(task *model.Task) error {
 		// audit sql allowed at all times
 		return nil
 	case ActionTypeExecute:
+		if a.resume {
+			if task.Status != model.TaskStatusExecuteFailed {
+				return errors.New(errors.TaskActionInvalid, ErrActionResumeOnNonFailedTask)
+			}
+			return nil
+		}
 		if task.HasDoingExecute() {
 			return errors.New(errors.TaskActionDone, ErrActionExecuteOnExecutedTask)
 		}
@@ -279,8 +316,15 @@ func (a *action) validation(task *model.Task) error {
 			return errors.New(errors.TaskActionInvalid, ErrActionExecuteOnNonAuditedTask)
 		}
 	case ActionTypeRollback:
-		if task.HasDoingRollback() {
-			return errors.New(errors.TaskActionDone, ErrActionRollbackOnRollbackedTask)
+		rollbackSQLs, err := a.selectRollbackSQLs(task)
+		if err != nil {
+			return err
+		}
+		// the failed rollback SQLs can be rolled back again
+		for _, rollbackSQL := range rollbackSQLs {
+			if rollbackSQL.ExecStatus == model.SQLExecuteStatusDoing || rollbackSQL.ExecStatus == model.SQLExecuteStatusSucceeded {
+				return errors.New(errors.TaskActionDone, ErrActionRollbackOnRollbackedTask)
+			}
 		}
 		if task.IsExecuteFailed() {
 			return errors.New(errors.TaskActionInvalid, ErrActionRollbackOnExecuteFailedTask)
@@ -295,7 +339,8 @@ func (a *action) validation(task *model.Task) error {
 func (a *action) audit() (err error) {
 	st := model.GetStorage()
 
-	err = audit(a.projectId, a.entry, a.task, a.plugin, a.customRules)
+	opener := newAuditPluginOpener(a.entry, a.task.Instance, a.task.Schema, a.task.DBType, modifyRulesWithBackupMaxRows(a.rules, a.task.DBType, a.task.BackupMaxRows))
+	err = hookAudit(a.entry, a.task, a.plugin, opener, &EmptyAuditHook{}, a.projectId, a.customRules)
 	if err != nil {
 		return err
 	}
@@ -342,6 +387,16 @@ func (a *action) execute() (err error) {
 
 	a.entry.Info("start execution...")
 
+	if a.resume {
+		unfinished := make([]*model.ExecuteSQL, 0, len(task.ExecuteSQLs))
+		for _, sql := range task.ExecuteSQLs {
+			if sql.ExecStatus != model.SQLExecuteStatusSucceeded {
+				unfinished = append(unfinished, sql)
+			}
+		}
+		task.ExecuteSQLs = unfinished
+	}
+
 	attrs := map[string]interface{}{
 		"status":        model.TaskStatusExecuting,
 		"exec_start_at": time.Now(),
@@ -350,6 +405,9 @@ func (a *action) execute() (err error) {
 		return err
 	}
 
+	a.binlog = newBinlogRecorder(a.entry, task)
+	defer a.binlog.close()
+
 	exeErrChan := make(chan error)
 	terminateErrChan := make(chan error)
 
@@ -424,14 +482,21 @@ func (a *action) execute() (err error) {
 
 	}
 
+	if taskStatus == model.TaskStatusExecuteSucceeded {
+		if _, checkErr := a.runExecChecks(model.WorkflowExecCheckStagePost); checkErr != nil {
+			a.entry.Errorf("run post-execution checks failed: %v", checkErr)
+		}
+	}
+
 	a.entry.WithField("task_status", taskStatus).
 		Infof("execution is completed, err:%v", err)
 
 	a.task.Status = taskStatus
 
 	attrs = map[string]interface{}{
-		"status":      taskStatus,
-		"exec_end_at": time.Now(),
+		"status":            taskStatus,
+		"exec_end_at":       time.Now(),
+		"exec_check_status": task.ExecCheckStatus,
 	}
 	return st.UpdateTask(task, attrs)
 }
@@ -446,6 +511,16 @@ func (a *action) GetTaskStatus(st *model.Storage) string {
 }
 
 func (a *action) execTask() (err error) {
+	passed, err := a.runExecChecks(model.WorkflowExecCheckStagePre)
+	if err != nil {
+		return err
+	}
+	if !passed {
+		return ErrActionExecutePreCheckFailed
+	}
+	if err = a.applyExecSessionVariables(); err != nil {
+		return err
+	}
 	svc := BackupService{}
 	if svc.CheckCanTaskBackup(a.task) {
 		err = a.backupAndExecSql()
@@ -511,8 +586,13 @@ func (a *action) execSqlSqlMode() error {
 			return err
 		}
 
-		switch nodes[0].Type {
-		case driverV2.SQLTypeDML, driverV2.SQLTypeDQL:
+		isChunkableDML := false
+		if a.task.ExecPolicy.ChunkedDML && task.DBType == driverV2.DriverTypeMySQL {
+			_, isChunkableDML = parseChunkableDML(executeSQL.Content, task.Schema)
+		}
+
+		switch {
+		case (nodes[0].Type == driverV2.SQLTypeDML || nodes[0].Type == driverV2.SQLTypeDQL) && !isChunkableDML:
 			txSQLs = append(txSQLs, executeSQL)
 			if i == len(task.ExecuteSQLs)-1 {
 				if err = a.execSQLs(txSQLs); err != nil {
@@ -638,7 +718,26 @@ func (a *action) executeSQLBatch(executeSQLs []*model.ExecuteSQL) error {
 		sqls = append(sqls, sql.Content)
 	}
 
-	results, execErr := a.plugin.ExecBatch(context.TODO(), sqls...)
+	var results []sqlDriver.Result
+	execErr := a.checkBlockingSessions(executeSQLs...)
+	if execErr == nil {
+		// the batch may fail after some SQLs have been executed, so it is not retried
+		binlogStart := a.binlog.status()
+		collector, canCollect := a.plugin.(driver.ExecOutputCollector)
+		var warnings [][]*driver.ExecWarning
+		start := time.Now()
+		execErr = a.execWithTimeout(func() (err error) {
+			if canCollect {
+				results, warnings, err = collector.ExecBatchWithWarnings(context.TODO(), sqls...)
+				return err
+			}
+			results, err = a.plugin.ExecBatch(context.TODO(), sqls...)
+			return err
+		})
+		setExecDuration(executeSQLs, time.Since(start))
+		setExecWarningsOfSQLs(executeSQLs, warnings)
+		a.binlog.record(binlogStart, a.binlog.status(), executeSQLs...)
+	}
 	if execErr != nil {
 		for idx, executeSQL := range executeSQLs {
 			executeSQL.ExecStatus = model.SQLExecuteStatusFailed
@@ -676,11 +775,15 @@ func (a *action) execSQL(executeSQL *model.ExecuteSQL) error {
 		return err
 	}
 
-	_, execErr := a.plugin.Exec(context.TODO(), executeSQL.Content)
+	execErr := a.checkBlockingSessions(executeSQL)
+	if execErr == nil {
+		execErr = a.execSingleSQL(executeSQL)
+	}
 	if execErr != nil {
 		executeSQL.ExecStatus = model.SQLExecuteStatusFailed
 		executeSQL.ExecResult = execErr.Error()
-		if a.hasTermination() && _errors.Is(mysql.ErrInvalidConn, execErr) {
+		if a.hasTermination() && _errors.Is(mysql.ErrInvalidConn, execErr) ||
+			_errors.Is(execErr, errDMLChunkExecutionTerminated) {
 			executeSQL.ExecStatus = model.SQLExecuteStatusTerminateSucc
 		}
 	} else {
@@ -696,6 +799,46 @@ func (a *action) execSQL(executeSQL *model.ExecuteSQL) error {
 	return nil
 }
 
+func (a *action) execSingleSQL(executeSQL *model.ExecuteSQL) error {
+	plan, err := a.planChunkedDML(executeSQL)
+	if err != nil {
+		return err
+	}
+	binlogStart := a.binlog.status()
+	defer func() {
+		a.binlog.record(binlogStart, a.binlog.status(), executeSQL)
+	}()
+	if plan != nil {
+		defer plan.close()
+		return a.execChunkedDML(executeSQL, plan)
+	}
+	collector, canCollect := a.plugin.(driver.ExecOutputCollector)
+	start := time.Now()
+	defer func() {
+		executeSQL.ExecDuration = uint64(time.Since(start).Milliseconds())
+	}()
+	err = a.execWithPolicy(func() error {
+		if canCollect && executeSQL.SQLType == driverV2.SQLTypeDQL {
+			preview, err := collector.QueryPreview(context.TODO(), executeSQL.Content, ResultPreviewMaxRows)
+			if err != nil {
+				return err
+			}
+			executeSQL.ResultPreview = newSQLResultPreview(preview)
+			executeSQL.RowAffects = int64(len(preview.Rows))
+			return nil
+		}
+		result, err := a.plugin.Exec(context.TODO(), executeSQL.Content)
+		if err == nil && result != nil {
+			executeSQL.RowAffects, _ = result.RowsAffected()
+		}
+		return err
+	})
+	if canCollect {
+		a.collectExecWarnings(collector, executeSQL)
+	}
+	return err
+}
+
 // execSQLs execute SQLs and update SQLs' executed status to storage.
 func (a *action) execSQLs(executeSQLs []*model.ExecuteSQL) error {
 	st := model.GetStorage()
@@ -712,7 +855,26 @@ func (a *action) execSQLs(executeSQLs []*model.ExecuteSQL) error {
 		qs = append(qs, executeSQL.Content)
 	}
 
-	results, txErr := a.plugin.Tx(context.TODO(), qs...)
+	// the changes of a transaction are written to binlog when committed, so the SQLs share the coordinates
+	binlogStart := a.binlog.status()
+	var results []sqlDriver.Result
+	var warnings [][]*driver.ExecWarning
+	collector, canCollect := a.plugin.(driver.ExecOutputCollector)
+	start := time.Now()
+	txErr := a.execWithPolicy(func() (err error) {
+		if canCollect {
+			results, warnings, err = collector.TxWithWarnings(context.TODO(), qs...)
+			return err
+		}
+		results, err = a.plugin.Tx(context.TODO(), qs...)
+		return err
+	})
+	setExecDuration(executeSQLs, time.Since(start))
+	if txErr == nil {
+		// the warnings of the rolled back transaction are meaningless
+		setExecWarningsOfSQLs(executeSQLs, warnings)
+	}
+	a.binlog.record(binlogStart, a.binlog.status(), executeSQLs...)
 	for idx, executeSQL := range executeSQLs {
 		if txErr != nil {
 			executeSQL.ExecStatus = model.SQLExecuteStatusFailed
@@ -744,53 +906,6 @@ func (a *action) execSQLs(executeSQLs []*model.ExecuteSQL) error {
 	return nil
 }
 
-func (a *action) rollback() (err error) {
-	task := a.task
-	a.entry.Info("start rollback SQL")
-
-	var execErr error
-	st := model.GetStorage()
-ExecSQLs:
-	for _, rollbackSQL := range task.RollbackSQLs {
-		if rollbackSQL.Content == "" {
-			continue
-		}
-		if err = st.UpdateRollbackSqlStatus(&rollbackSQL.BaseSQL, model.SQLExecuteStatusDoing, ""); err != nil {
-			return err
-		}
-
-		nodes, err := a.plugin.Parse(context.TODO(), rollbackSQL.Content)
-		if err != nil {
-			return err
-		}
-		// todo: execute in transaction
-		for _, node := range nodes {
-			currentSQL := model.RollbackSQL{BaseSQL: model.BaseSQL{
-				TaskId:  rollbackSQL.TaskId,
-				Content: node.Text,
-			}, ExecuteSQLId: rollbackSQL.ExecuteSQLId}
-			_, execErr := a.plugin.Exec(context.TODO(), node.Text)
-			if execErr != nil {
-				currentSQL.ExecStatus = model.SQLExecuteStatusFailed
-				currentSQL.ExecResult = execErr.Error()
-			} else {
-				currentSQL.ExecStatus = model.SQLExecuteStatusSucceeded
-				currentSQL.ExecResult = model.TaskExecResultOK
-			}
-			if execErr := st.Save(currentSQL); execErr != nil {
-				break ExecSQLs
-			}
-		}
-	}
-
-	if execErr != nil {
-		a.entry.Errorf("rollback SQL error:%v", execErr)
-	} else {
-		a.entry.Error("rollback SQL finished")
-	}
-	return execErr
-}
-
 func newDriverManagerWithAudit(l *logrus.Entry, inst *model.Instance, database string, dbType string, modelRules []*model.Rule) (driver.Plugin, error) {
 	if inst == nil && dbType == "" {
 		return nil, xerrors.Errorf("instance is nil and dbType is nil")
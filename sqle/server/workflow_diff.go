@@ -0,0 +1,396 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/actiontech/dms/pkg/dms-common/i18nPkg"
+	"github.com/actiontech/sqle/sqle/driver"
+	"github.com/actiontech/sqle/sqle/errors"
+	"github.com/actiontech/sqle/sqle/model"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	SQLDiffTypeAdded     = "added"
+	SQLDiffTypeRemoved   = "removed"
+	SQLDiffTypeModified  = "modified"
+	SQLDiffTypeUnchanged = "unchanged"
+)
+
+// maxLCSCells limits the memory used by the LCS matching of two SQL lists,
+// larger lists are matched greedily by fingerprint in order.
+const maxLCSCells = 4 * 1024 * 1024
+
+type WorkflowRecordDiff struct {
+	BaseRecordId   uint
+	TargetRecordId uint
+	Tasks          []*TaskAuditDiff
+}
+
+type TaskAuditDiff struct {
+	InstanceId  uint64
+	Schema      string
+	BaseTask    *model.Task
+	TargetTask  *model.Task
+	SQLs        []*SQLAuditDiff
+	DiffSummary SQLAuditDiffSummary
+}
+
+type SQLAuditDiffSummary struct {
+	Added                  int
+	Removed                int
+	Modified               int
+	Unchanged              int
+	IntroducedAuditResults int
+	ResolvedAuditResults   int
+}
+
+type SQLAuditDiff struct {
+	DiffType               string
+	BaseSQL                *model.ExecuteSQL
+	TargetSQL              *model.ExecuteSQL
+	IntroducedAuditResults model.AuditResults
+	ResolvedAuditResults   model.AuditResults
+}
+
+// DiffWorkflowRecords compares two records of the workflow statement by statement. The tasks of the
+// records are paired by instance and schema, the SQLs of the paired tasks are matched by fingerprint
+// and position, and the audit results which were introduced or resolved are reported for each SQL.
+func DiffWorkflowRecords(l *logrus.Entry, workflow *model.Workflow, baseRecordId, targetRecordId uint) (*WorkflowRecordDiff, error) {
+	s := model.GetStorage()
+	recordIds, err := s.GetWorkflowRecordIds(workflow)
+	if err != nil {
+		return nil, err
+	}
+	if targetRecordId == 0 {
+		targetRecordId = recordIds[len(recordIds)-1]
+	}
+	if baseRecordId == 0 {
+		for i, id := range recordIds {
+			if id == targetRecordId && i > 0 {
+				baseRecordId = recordIds[i-1]
+			}
+		}
+		if baseRecordId == 0 {
+			return nil, errors.New(errors.DataInvalid, fmt.Errorf("workflow %s has no previous record to compare with", workflow.WorkflowId))
+		}
+	}
+	for _, id := range []uint{baseRecordId, targetRecordId} {
+		var found bool
+		for _, recordId := range recordIds {
+			if recordId == id {
+				found = true
+			}
+		}
+		if !found {
+			return nil, errors.New(errors.DataNotExist, fmt.Errorf("record %d does not belong to workflow %s", id, workflow.WorkflowId))
+		}
+	}
+
+	baseRecord, exist, err := s.GetWorkflowRecordWithTasksById(baseRecordId)
+	if err != nil {
+		return nil, err
+	}
+	if !exist {
+		return nil, errors.New(errors.DataNotExist, fmt.Errorf("workflow record %d not exist", baseRecordId))
+	}
+	targetRecord, exist, err := s.GetWorkflowRecordWithTasksById(targetRecordId)
+	if err != nil {
+		return nil, err
+	}
+	if !exist {
+		return nil, errors.New(errors.DataNotExist, fmt.Errorf("workflow record %d not exist", targetRecordId))
+	}
+
+	fingerprinter := newSQLFingerprinter(l)
+	defer fingerprinter.close()
+
+	diff := &WorkflowRecordDiff{
+		BaseRecordId:   baseRecordId,
+		TargetRecordId: targetRecordId,
+	}
+	for _, pair := range pairRecordTasks(baseRecord, targetRecord) {
+		taskDiff := &TaskAuditDiff{BaseTask: pair[0], TargetTask: pair[1]}
+		var baseSQLs, targetSQLs []*model.ExecuteSQL
+		var dbType string
+		if pair[0] != nil {
+			taskDiff.InstanceId, taskDiff.Schema, dbType = pair[0].InstanceId, pair[0].Schema, pair[0].DBType
+			baseSQLs = sortedExecuteSQLs(pair[0].ExecuteSQLs)
+		}
+		if pair[1] != nil {
+			taskDiff.InstanceId, taskDiff.Schema, dbType = pair[1].InstanceId, pair[1].Schema, pair[1].DBType
+			targetSQLs = sortedExecuteSQLs(pair[1].ExecuteSQLs)
+		}
+		fingerprint := func(sql *model.ExecuteSQL) string {
+			return fingerprinter.fingerprint(dbType, sql.Content)
+		}
+		taskDiff.SQLs = diffExecuteSQLs(baseSQLs, targetSQLs, fingerprint)
+		taskDiff.DiffSummary = summarizeSQLAuditDiffs(taskDiff.SQLs)
+		diff.Tasks = append(diff.Tasks, taskDiff)
+	}
+	return diff, nil
+}
+
+// pairRecordTasks pairs the tasks of two workflow records by instance and schema,
+// the task which has no pair is returned with nil.
+func pairRecordTasks(base, target *model.WorkflowRecord) [][2]*model.Task {
+	key := func(task *model.Task) string {
+		return fmt.Sprintf("%d:%s", task.InstanceId, task.Schema)
+	}
+	baseTasks := map[string][]*model.Task{}
+	for _, ir := range base.InstanceRecords {
+		if ir.Task == nil {
+			continue
+		}
+		baseTasks[key(ir.Task)] = append(baseTasks[key(ir.Task)], ir.Task)
+	}
+
+	pairs := [][2]*model.Task{}
+	for _, ir := range target.InstanceRecords {
+		if ir.Task == nil {
+			continue
+		}
+		k := key(ir.Task)
+		var baseTask *model.Task
+		if len(baseTasks[k]) > 0 {
+			baseTask = baseTasks[k][0]
+			baseTasks[k] = baseTasks[k][1:]
+		}
+		pairs = append(pairs, [2]*model.Task{baseTask, ir.Task})
+	}
+	for _, ir := range base.InstanceRecords {
+		if ir.Task == nil {
+			continue
+		}
+		for _, task := range baseTasks[key(ir.Task)] {
+			if task == ir.Task {
+				pairs = append(pairs, [2]*model.Task{ir.Task, nil})
+			}
+		}
+	}
+	return pairs
+}
+
+func sortedExecuteSQLs(sqls []*model.ExecuteSQL) []*model.ExecuteSQL {
+	sorted := append([]*model.ExecuteSQL{}, sqls...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Number < sorted[j].Number
+	})
+	return sorted
+}
+
+// diffExecuteSQLs matches the SQLs of two versions. SQLs with the same fingerprint are matched keeping
+// their relative order, the matched SQLs are unchanged if the content is the same, or modified otherwise.
+// The unmatched SQLs at the same position between two matched SQLs are paired as modified, and the rest
+// are added or removed.
+func diffExecuteSQLs(base, target []*model.ExecuteSQL, fingerprint func(*model.ExecuteSQL) string) []*SQLAuditDiff {
+	baseFps := make([]string, len(base))
+	for i := range base {
+		baseFps[i] = fingerprint(base[i])
+	}
+	targetFps := make([]string, len(target))
+	for i := range target {
+		targetFps[i] = fingerprint(target[i])
+	}
+
+	diffs := []*SQLAuditDiff{}
+	appendGap := func(bs, ts []*model.ExecuteSQL) {
+		i := 0
+		for ; i < len(bs) && i < len(ts); i++ {
+			diffs = append(diffs, newSQLAuditDiff(bs[i], ts[i], false))
+		}
+		for ; i < len(bs); i++ {
+			diffs = append(diffs, newSQLAuditDiff(bs[i], nil, false))
+		}
+		for j := i; j < len(ts); j++ {
+			diffs = append(diffs, newSQLAuditDiff(nil, ts[j], false))
+		}
+	}
+
+	lastBase, lastTarget := 0, 0
+	for _, match := range matchFingerprints(baseFps, targetFps) {
+		appendGap(base[lastBase:match[0]], target[lastTarget:match[1]])
+		diffs = append(diffs, newSQLAuditDiff(base[match[0]], target[match[1]], true))
+		lastBase, lastTarget = match[0]+1, match[1]+1
+	}
+	appendGap(base[lastBase:], target[lastTarget:])
+	return diffs
+}
+
+// matchFingerprints returns the index pairs of the longest common subsequence of the two fingerprint lists.
+func matchFingerprints(base, target []string) [][2]int {
+	matches := [][2]int{}
+
+	// the common prefix and suffix are matched directly, it is the most case of a resubmitted workflow.
+	prefix := 0
+	for prefix < len(base) && prefix < len(target) && base[prefix] == target[prefix] {
+		matches = append(matches, [2]int{prefix, prefix})
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(base)-prefix && suffix < len(target)-prefix &&
+		base[len(base)-1-suffix] == target[len(target)-1-suffix] {
+		suffix++
+	}
+
+	b, t := base[prefix:len(base)-suffix], target[prefix:len(target)-suffix]
+	if len(b)*len(t) <= maxLCSCells {
+		// lcs[i][j] is the length of LCS of b[i:] and t[j:]
+		lcs := make([][]int32, len(b)+1)
+		for i := range lcs {
+			lcs[i] = make([]int32, len(t)+1)
+		}
+		for i := len(b) - 1; i >= 0; i-- {
+			for j := len(t) - 1; j >= 0; j-- {
+				if b[i] == t[j] {
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				} else if lcs[i+1][j] >= lcs[i][j+1] {
+					lcs[i][j] = lcs[i+1][j]
+				} else {
+					lcs[i][j] = lcs[i][j+1]
+				}
+			}
+		}
+		for i, j := 0, 0; i < len(b) && j < len(t); {
+			if b[i] == t[j] {
+				matches = append(matches, [2]int{prefix + i, prefix + j})
+				i++
+				j++
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				i++
+			} else {
+				j++
+			}
+		}
+	} else {
+		// greedy matching in order, each target SQL is matched to the next base SQL with the same fingerprint.
+		positions := map[string][]int{}
+		for i, fp := range b {
+			positions[fp] = append(positions[fp], i)
+		}
+		last := -1
+		for j, fp := range t {
+			candidates := positions[fp]
+			for len(candidates) > 0 && candidates[0] <= last {
+				candidates = candidates[1:]
+			}
+			positions[fp] = candidates
+			if len(candidates) == 0 {
+				continue
+			}
+			last = candidates[0]
+			positions[fp] = candidates[1:]
+			matches = append(matches, [2]int{prefix + last, prefix + j})
+		}
+	}
+
+	for i := suffix; i > 0; i-- {
+		matches = append(matches, [2]int{len(base) - i, len(target) - i})
+	}
+	return matches
+}
+
+func newSQLAuditDiff(base, target *model.ExecuteSQL, sameFingerprint bool) *SQLAuditDiff {
+	diff := &SQLAuditDiff{BaseSQL: base, TargetSQL: target}
+	switch {
+	case base == nil:
+		diff.DiffType = SQLDiffTypeAdded
+		diff.IntroducedAuditResults = target.AuditResults
+	case target == nil:
+		diff.DiffType = SQLDiffTypeRemoved
+		diff.ResolvedAuditResults = base.AuditResults
+	default:
+		diff.DiffType = SQLDiffTypeModified
+		if sameFingerprint && strings.TrimSpace(base.Content) == strings.TrimSpace(target.Content) {
+			diff.DiffType = SQLDiffTypeUnchanged
+		}
+		diff.IntroducedAuditResults = subtractAuditResults(target.AuditResults, base.AuditResults)
+		diff.ResolvedAuditResults = subtractAuditResults(base.AuditResults, target.AuditResults)
+	}
+	return diff
+}
+
+func auditResultKey(ar model.AuditResult) string {
+	if ar.RuleName != "" {
+		return fmt.Sprintf("%s:%s", ar.Level, ar.RuleName)
+	}
+	return fmt.Sprintf("%s:%s", ar.Level, ar.GetAuditMsgByLangTag(i18nPkg.DefaultLang))
+}
+
+// subtractAuditResults returns the audit results in a but not in b.
+func subtractAuditResults(a, b model.AuditResults) model.AuditResults {
+	exist := map[string]struct{}{}
+	for _, ar := range b {
+		exist[auditResultKey(ar)] = struct{}{}
+	}
+	results := model.AuditResults{}
+	for _, ar := range a {
+		if _, ok := exist[auditResultKey(ar)]; !ok {
+			results = append(results, ar)
+		}
+	}
+	return results
+}
+
+func summarizeSQLAuditDiffs(diffs []*SQLAuditDiff) SQLAuditDiffSummary {
+	summary := SQLAuditDiffSummary{}
+	for _, diff := range diffs {
+		switch diff.DiffType {
+		case SQLDiffTypeAdded:
+			summary.Added++
+		case SQLDiffTypeRemoved:
+			summary.Removed++
+		case SQLDiffTypeModified:
+			summary.Modified++
+		case SQLDiffTypeUnchanged:
+			summary.Unchanged++
+		}
+		summary.IntroducedAuditResults += len(diff.IntroducedAuditResults)
+		summary.ResolvedAuditResults += len(diff.ResolvedAuditResults)
+	}
+	return summary
+}
+
+// sqlFingerprinter computes SQL fingerprint by the plugin of the db type, the plugins are opened on demand
+// without instance. If the plugin can not parse the SQL, the normalized SQL text is used as fingerprint.
+type sqlFingerprinter struct {
+	l       *logrus.Entry
+	plugins map[string]driver.Plugin
+}
+
+func newSQLFingerprinter(l *logrus.Entry) *sqlFingerprinter {
+	return &sqlFingerprinter{l: l, plugins: map[string]driver.Plugin{}}
+}
+
+func (f *sqlFingerprinter) fingerprint(dbType, sql string) string {
+	normalized := strings.ToUpper(strings.Join(strings.Fields(strings.TrimRight(strings.TrimSpace(sql), ";")), " "))
+	p, ok := f.plugins[dbType]
+	if !ok {
+		var err error
+		p, err = newDriverManagerWithAudit(f.l, nil, "", dbType, nil)
+		if err != nil {
+			f.l.Warnf("open plugin %s for sql fingerprint failed, use normalized sql instead, error: %v", dbType, err)
+		}
+		f.plugins[dbType] = p
+	}
+	if p == nil {
+		return normalized
+	}
+	node, err := parse(f.l, p, sql)
+	if err != nil || node.Fingerprint == "" {
+		return normalized
+	}
+	return node.Fingerprint
+}
+
+func (f *sqlFingerprinter) close() {
+	for _, p := range f.plugins {
+		if p != nil {
+			p.Close(context.TODO())
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/actiontech/sqle/sqle/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func newDiffTestSQLs(contents ...string) []*model.ExecuteSQL {
+	sqls := make([]*model.ExecuteSQL, 0, len(contents))
+	for i, content := range contents {
+		sqls = append(sqls, &model.ExecuteSQL{BaseSQL: model.BaseSQL{Number: uint(i + 1), Content: content}})
+	}
+	return sqls
+}
+
+// testFingerprint replaces the digits of SQL, it simulates the fingerprint of plugin.
+func testFingerprint(sql *model.ExecuteSQL) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return '?'
+		}
+		return r
+	}, sql.Content)
+}
+
+func TestDiffExecuteSQLs(t *testing.T) {
+	base := newDiffTestSQLs(
+		"create table t1(id int)",
+		"insert into t1 values(1)",
+		"update t1 set id=2",
+		"delete from t1",
+	)
+	target := newDiffTestSQLs(
+		"create table t1(id int)",
+		"insert into t1 values(10)",
+		"alter table t1 add column c1 int",
+		"update t1 set c1=2",
+		"delete from t1",
+		"select * from t1",
+	)
+	base[2].AuditResults = model.AuditResults{{Level: "warn", RuleName: "dml_check_where_is_invalid"}}
+	target[3].AuditResults = model.AuditResults{{Level: "error", RuleName: "dml_check_where_is_invalid"}}
+	target[2].AuditResults = model.AuditResults{{Level: "notice", RuleName: "ddl_check_column_without_default"}}
+
+	diffs := diffExecuteSQLs(base, target, testFingerprint)
+	types := []string{}
+	for _, diff := range diffs {
+		types = append(types, diff.DiffType)
+	}
+	assert.Equal(t, []string{
+		SQLDiffTypeUnchanged,
+		SQLDiffTypeModified,
+		SQLDiffTypeModified,
+		SQLDiffTypeAdded,
+		SQLDiffTypeUnchanged,
+		SQLDiffTypeAdded,
+	}, types)
+
+	// "update t1 set id=2" is modified to "alter table ..." at the same position
+	assert.Equal(t, base[2], diffs[2].BaseSQL)
+	assert.Equal(t, target[2], diffs[2].TargetSQL)
+	assert.Len(t, diffs[2].IntroducedAuditResults, 1)
+	assert.Len(t, diffs[2].ResolvedAuditResults, 1)
+
+	summary := summarizeSQLAuditDiffs(diffs)
+	assert.Equal(t, SQLAuditDiffSummary{
+		Added: 2, Modified: 2, Unchanged: 2, IntroducedAuditResults: 2, ResolvedAuditResults: 1,
+	}, summary)
+}
+
+func TestMatchFingerprints(t *testing.T) {
+	assert.Equal(t, [][2]int{{0, 0}, {2, 1}, {3, 3}}, matchFingerprints(
+		[]string{"a", "b", "c", "d"},
+		[]string{"a", "c", "e", "d"},
+	))
+	assert.Equal(t, [][2]int{}, matchFingerprints([]string{"a"}, []string{"b"}))
+	assert.Equal(t, [][2]int{{0, 1}}, matchFingerprints([]string{"a"}, []string{"b", "a"}))
+}
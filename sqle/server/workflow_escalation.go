@@ -0,0 +1,103 @@
+package server
+
+import (
+	"strings"
+	"time"
+
+	"github.com/actiontech/sqle/sqle/dms"
+	"github.com/actiontech/sqle/sqle/model"
+	"github.com/actiontech/sqle/sqle/notification"
+
+	"github.com/sirupsen/logrus"
+)
+
+// WorkflowStepEscalationJob hands the review steps over to the delegates of the assignees who are away,
+// and escalates the review steps waiting too long to the fallback users of the step template.
+type WorkflowStepEscalationJob struct {
+	BaseJob
+}
+
+func NewWorkflowStepEscalationJob(entry *logrus.Entry) ServerJob {
+	entry = entry.WithField("job", "workflow_step_escalation")
+	j := &WorkflowStepEscalationJob{}
+	j.BaseJob = *NewBaseJob(entry, time.Minute, j.EscalateWorkflowSteps)
+	return j
+}
+
+func (j *WorkflowStepEscalationJob) EscalateWorkflowSteps(entry *logrus.Entry) {
+	st := model.GetStorage()
+	workflows, err := st.GetWorkflowsWaitForAudit()
+	if err != nil {
+		entry.Errorf("get workflows wait for audit from storage error: %v", err)
+		return
+	}
+
+	for _, workflow := range workflows {
+		w, err := dms.GetWorkflowDetailByWorkflowId(string(workflow.ProjectId), workflow.WorkflowId, st.GetWorkflowDetailWithoutInstancesByWorkflowID)
+		if err != nil {
+			entry.Errorf("get workflow %s from storage error: %v", workflow.WorkflowId, err)
+			continue
+		}
+		if err := delegateAndEscalateWorkflowStep(entry, st, w, time.Now()); err != nil {
+			entry.Errorf("delegate or escalate workflow %s error: %v", w.Subject, err)
+		}
+	}
+}
+
+func delegateAndEscalateWorkflowStep(entry *logrus.Entry, st *model.Storage, w *model.Workflow, now time.Time) error {
+	step := w.CurrentStep()
+	if step == nil || step.Template == nil || step.Template.Typ != model.WorkflowStepTypeSQLReview || step.OperationUserId != "" {
+		return nil
+	}
+	originAssignees := step.Assignees
+	events := []*model.WorkflowStepEvent{}
+	notifyTypes := []notification.WorkflowNotifyType{}
+
+	assignees := w.CurrentAssigneeUser()
+	delegates, err := st.GetActiveWorkflowApprovalDelegates(w.ProjectId, assignees, now)
+	if err != nil {
+		return err
+	}
+	for _, user := range assignees {
+		delegateUser, ok := delegates[user]
+		if !ok || !step.AddDelegate(user, delegateUser) {
+			continue
+		}
+		entry.Infof("workflow %s step %d is delegated from user %s to user %s", w.Subject, step.ID, user, delegateUser)
+		events = append(events, newWorkflowStepEvent(w, step, model.WorkflowStepEventTypeDelegate, []string{user}, []string{delegateUser}))
+	}
+	if len(events) > 0 {
+		notifyTypes = append(notifyTypes, notification.WorkflowNotifyTypeDelegate)
+	}
+
+	timeout := time.Duration(step.Template.EscalationTimeoutMinutes) * time.Minute
+	if timeout > 0 && step.EscalatedAt == nil && now.Sub(w.CurrentStepStartTime()) > timeout {
+		escalationUsers := strings.Split(step.Template.EscalationUsers, ",")
+		step.Escalate(escalationUsers, now)
+		entry.Infof("workflow %s step %d is escalated to users %v", w.Subject, step.ID, escalationUsers)
+		events = append(events, newWorkflowStepEvent(w, step, model.WorkflowStepEventTypeEscalate, strings.Split(originAssignees, ","), escalationUsers))
+		notifyTypes = append(notifyTypes, notification.WorkflowNotifyTypeEscalate)
+	}
+
+	if len(events) == 0 {
+		return nil
+	}
+	if err := st.UpdateWorkflowStepAssignees(step, originAssignees, events); err != nil {
+		return err
+	}
+	for _, notifyType := range notifyTypes {
+		go notification.NotifyWorkflow(string(w.ProjectId), w.WorkflowId, notifyType)
+	}
+	return nil
+}
+
+func newWorkflowStepEvent(w *model.Workflow, step *model.WorkflowStep, typ string, from, to []string) *model.WorkflowStepEvent {
+	return &model.WorkflowStepEvent{
+		WorkflowId:       w.WorkflowId,
+		WorkflowRecordId: w.Record.ID,
+		WorkflowStepId:   step.ID,
+		Type:             typ,
+		FromUsers:        strings.Join(from, ","),
+		ToUsers:          strings.Join(to, ","),
+	}
+}
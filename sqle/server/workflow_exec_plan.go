@@ -0,0 +1,261 @@
+package server
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/actiontech/sqle/sqle/errors"
+	"github.com/actiontech/sqle/sqle/log"
+	"github.com/actiontech/sqle/sqle/model"
+	"github.com/actiontech/sqle/sqle/notification"
+)
+
+// AddResumeTaskWaitResult re-executes the SQLs of the failed task which are not executed successfully.
+func (s *Sqled) AddResumeTaskWaitResult(projectId string, taskId string) (*model.Task, error) {
+	action, err := s.addTask(projectId, taskId, ActionTypeExecute, func(a *action) {
+		a.resume = true
+	})
+	if err != nil {
+		return nil, err
+	}
+	<-action.done
+	return action.task, action.err
+}
+
+// ValidateWorkflowExecPlan checks the tasks of the plan belong to the current record of the workflow,
+// and the dependencies have no cycle.
+func ValidateWorkflowExecPlan(workflow *model.Workflow, plan *model.WorkflowExecPlan) error {
+	taskIds := make([]uint, 0, len(workflow.Record.InstanceRecords))
+	tasks := make(map[uint]struct{}, len(workflow.Record.InstanceRecords))
+	for _, record := range workflow.Record.InstanceRecords {
+		taskIds = append(taskIds, record.TaskId)
+		tasks[record.TaskId] = struct{}{}
+	}
+	for _, dependency := range plan.TaskDependencies {
+		if _, ok := tasks[dependency.TaskId]; !ok {
+			return errors.New(errors.DataInvalid, fmt.Errorf("task %v is not in the workflow, the execution plan may be outdated", dependency.TaskId))
+		}
+		for _, dep := range dependency.DependsOn {
+			if _, ok := tasks[dep]; !ok {
+				return errors.New(errors.DataInvalid, fmt.Errorf("task %v depends on task %v which is not in the workflow", dependency.TaskId, dep))
+			}
+		}
+	}
+	if _, err := sortTasksByDependencies(taskIds, plan); err != nil {
+		return errors.New(errors.DataInvalid, err)
+	}
+	return nil
+}
+
+// sortTasksByDependencies returns the tasks in topological order of the dependencies,
+// the tasks without dependencies between them are in the order of task id.
+func sortTasksByDependencies(taskIds []uint, plan *model.WorkflowExecPlan) ([]uint, error) {
+	sorted := make([]uint, 0, len(taskIds))
+	visited := make(map[uint]bool, len(taskIds))
+	ids := append([]uint{}, taskIds...)
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	for len(sorted) < len(ids) {
+		progressed := false
+		for _, id := range ids {
+			if visited[id] {
+				continue
+			}
+			ready := true
+			for _, dep := range plan.DependsOn(id) {
+				if !visited[dep] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				visited[id] = true
+				sorted = append(sorted, id)
+				progressed = true
+			}
+		}
+		if !progressed {
+			return nil, fmt.Errorf("the dependencies of the tasks have a cycle")
+		}
+	}
+	return sorted, nil
+}
+
+// ResumeWorkflowExecution re-executes the failed tasks of the workflow from the failed SQLs, and executes the
+// tasks not started in the last execution by the execution plan.
+func ResumeWorkflowExecution(workflow *model.Workflow, user *model.User) (chan string, error) {
+	s := model.GetStorage()
+	plan, exist, err := s.GetWorkflowExecPlan(workflow.WorkflowId)
+	if err != nil {
+		return nil, err
+	}
+	if !exist {
+		return nil, errors.New(errors.DataNotExist, fmt.Errorf("the workflow has no execution plan"))
+	}
+	if workflow.Record.Status != model.WorkflowStatusExecFailed && workflow.Record.Status != model.WorkflowStatusWaitForExecution {
+		return nil, errors.New(errors.DataInvalid, fmt.Errorf("workflow status is %s, not allow resume it", workflow.Record.Status))
+	}
+
+	taskIds := map[uint]string{}
+	resumeTaskIds := map[uint]struct{}{}
+	for _, record := range workflow.Record.InstanceRecords {
+		switch {
+		case record.IsSQLExecuted && record.Task != nil && record.Task.Status == model.TaskStatusExecuteFailed:
+			resumeTaskIds[record.TaskId] = struct{}{}
+			taskIds[record.TaskId] = user.GetIDStr()
+		case !record.IsSQLExecuted && record.ScheduledAt == nil:
+			taskIds[record.TaskId] = user.GetIDStr()
+		}
+	}
+	if len(resumeTaskIds) == 0 {
+		return nil, errors.New(errors.DataInvalid, fmt.Errorf("there is no failed task to resume"))
+	}
+	if err := CheckWorkflowExecutionFreeze(workflow, taskIds, time.Now()); err != nil {
+		return nil, err
+	}
+	if err := checkTasksConnectable(taskIds); err != nil {
+		return nil, err
+	}
+	return executeWorkflowByPlan(workflow, plan, taskIds, resumeTaskIds)
+}
+
+type planTaskResult struct {
+	taskId    uint
+	succeeded bool
+}
+
+// executeWorkflowByPlan starts the tasks whose dependencies are executed successfully, at most MaxConcurrency
+// tasks are executed at the same time. The tasks depending on the failed task are not started, and no more task
+// is started after a task fails if StopOnFailure is set, they can be executed by resuming the workflow.
+func executeWorkflowByPlan(workflow *model.Workflow, plan *model.WorkflowExecPlan, taskIdToUserId map[uint]string, resumeTaskIds map[uint]struct{}) (chan string, error) {
+	s := model.GetStorage()
+	l := log.NewEntry().WithField("workflow_id", workflow.WorkflowId)
+	if err := ValidateWorkflowExecPlan(workflow, plan); err != nil {
+		return nil, err
+	}
+
+	records := make(map[uint]*model.WorkflowInstanceRecord, len(workflow.Record.InstanceRecords))
+	succeeded := map[uint]bool{}
+	for _, record := range workflow.Record.InstanceRecords {
+		records[record.TaskId] = record
+		if record.Task != nil && record.Task.Status == model.TaskStatusExecuteSucceeded {
+			succeeded[record.TaskId] = true
+		}
+	}
+	pending := make(map[uint]struct{}, len(taskIdToUserId))
+	taskIds := make([]uint, 0, len(taskIdToUserId))
+	for taskId := range taskIdToUserId {
+		for _, dep := range plan.DependsOn(taskId) {
+			if _, ok := taskIdToUserId[dep]; !ok && !succeeded[dep] {
+				return nil, errors.New(errors.TaskActionInvalid,
+					fmt.Errorf("task %v depends on task %v which is not executed successfully", taskId, dep))
+			}
+		}
+		pending[taskId] = struct{}{}
+		taskIds = append(taskIds, taskId)
+	}
+	order, err := sortTasksByDependencies(taskIds, plan)
+	if err != nil {
+		return nil, err
+	}
+
+	currentStep := workflow.CurrentStep()
+	workflow.Record.Status = model.WorkflowStatusExecuting
+	if err := s.UpdateWorkflowExecInstanceRecord(workflow, nil, nil); err != nil {
+		return nil, err
+	}
+
+	// start marks the task executed in the workflow, the SQL execute step is approved when all the tasks are executed.
+	start := func(taskId uint) error {
+		record := records[taskId]
+		if record.IsSQLExecuted {
+			return nil
+		}
+		record.IsSQLExecuted = true
+		record.ExecutionUserId = taskIdToUserId[taskId]
+		var operateStep *model.WorkflowStep
+		allTaskHasExecuted := true
+		for _, inst := range workflow.Record.InstanceRecords {
+			if !inst.IsSQLExecuted {
+				allTaskHasExecuted = false
+			}
+		}
+		if allTaskHasExecuted && currentStep != nil {
+			currentStep.State = model.WorkflowStepStateApprove
+			workflow.Record.CurrentWorkflowStepId = 0
+			operateStep = currentStep
+		}
+		return s.UpdateWorkflowExecInstanceRecord(workflow, operateStep, []*model.WorkflowInstanceRecord{record})
+	}
+
+	concurrency := int(plan.MaxConcurrency)
+	if concurrency == 0 {
+		concurrency = len(order)
+	}
+	workflowStatusChan := make(chan string, 1)
+	results := make(chan planTaskResult)
+	go func() {
+		running, failed := 0, false
+		for {
+			for _, taskId := range order {
+				if running >= concurrency || (failed && plan.StopOnFailure) {
+					break
+				}
+				if _, ok := pending[taskId]; !ok || !dependenciesSucceeded(plan, taskId, succeeded) {
+					continue
+				}
+				if err := start(taskId); err != nil {
+					l.Errorf("start task %v of the execution plan failed: %v", taskId, err)
+					failed = true
+					break
+				}
+				delete(pending, taskId)
+				running++
+				_, resume := resumeTaskIds[taskId]
+				go func(taskId uint, resume bool) {
+					var task *model.Task
+					var err error
+					if resume {
+						task, err = GetSqled().AddResumeTaskWaitResult(string(workflow.ProjectId), strconv.Itoa(int(taskId)))
+					} else {
+						task, err = GetSqled().AddTaskWaitResult(string(workflow.ProjectId), strconv.Itoa(int(taskId)), ActionTypeExecute)
+					}
+					if err != nil {
+						l.Errorf("execute task %v of the execution plan failed: %v", taskId, err)
+					}
+					results <- planTaskResult{taskId: taskId, succeeded: err == nil && task.Status == model.TaskStatusExecuteSucceeded}
+				}(taskId, resume)
+			}
+			if running == 0 {
+				break
+			}
+
+			result := <-results
+			running--
+			if result.succeeded {
+				succeeded[result.taskId] = true
+				go notification.NotifyWorkflow(string(workflow.ProjectId), workflow.WorkflowId, notification.WorkflowNotifyTypeExecuteSuccess)
+			} else {
+				failed = true
+				go notification.NotifyWorkflow(string(workflow.ProjectId), workflow.WorkflowId, notification.WorkflowNotifyTypeExecuteFail)
+			}
+			if running > 0 || len(pending) > 0 {
+				updateStatus(s, workflow, l, nil, pending)
+			}
+		}
+		// the tasks still pending are not started, so they are waiting for execution
+		updateStatus(s, workflow, l, workflowStatusChan, nil)
+	}()
+
+	return workflowStatusChan, nil
+}
+
+func dependenciesSucceeded(plan *model.WorkflowExecPlan, taskId uint, succeeded map[uint]bool) bool {
+	for _, dep := range plan.DependsOn(taskId) {
+		if !succeeded[dep] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/actiontech/sqle/sqle/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestWorkflow(taskIds ...uint) *model.Workflow {
+	records := make([]*model.WorkflowInstanceRecord, 0, len(taskIds))
+	for _, id := range taskIds {
+		records = append(records, &model.WorkflowInstanceRecord{TaskId: id})
+	}
+	return &model.Workflow{Record: &model.WorkflowRecord{InstanceRecords: records}}
+}
+
+func TestSortTasksByDependencies(t *testing.T) {
+	plan := &model.WorkflowExecPlan{TaskDependencies: model.WorkflowTaskDependencies{
+		{TaskId: 1, DependsOn: []uint{3}},
+		{TaskId: 2, DependsOn: []uint{1, 4}},
+	}}
+	order, err := sortTasksByDependencies([]uint{4, 3, 2, 1}, plan)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint{3, 4, 1, 2}, order)
+
+	plan.TaskDependencies = append(plan.TaskDependencies, model.WorkflowTaskDependency{TaskId: 3, DependsOn: []uint{2}})
+	_, err = sortTasksByDependencies([]uint{1, 2, 3, 4}, plan)
+	assert.Error(t, err)
+}
+
+func TestValidateWorkflowExecPlan(t *testing.T) {
+	workflow := newTestWorkflow(1, 2, 3)
+
+	assert.NoError(t, ValidateWorkflowExecPlan(workflow, &model.WorkflowExecPlan{TaskDependencies: model.WorkflowTaskDependencies{
+		{TaskId: 2, DependsOn: []uint{1}},
+		{TaskId: 3, DependsOn: []uint{1, 2}},
+	}}))
+	// the task is not in the workflow
+	assert.Error(t, ValidateWorkflowExecPlan(workflow, &model.WorkflowExecPlan{TaskDependencies: model.WorkflowTaskDependencies{
+		{TaskId: 4, DependsOn: []uint{1}},
+	}}))
+	assert.Error(t, ValidateWorkflowExecPlan(workflow, &model.WorkflowExecPlan{TaskDependencies: model.WorkflowTaskDependencies{
+		{TaskId: 1, DependsOn: []uint{4}},
+	}}))
+	// cycle
+	assert.Error(t, ValidateWorkflowExecPlan(workflow, &model.WorkflowExecPlan{TaskDependencies: model.WorkflowTaskDependencies{
+		{TaskId: 1, DependsOn: []uint{1}},
+	}}))
+}
+
+func TestDependenciesSucceeded(t *testing.T) {
+	plan := &model.WorkflowExecPlan{TaskDependencies: model.WorkflowTaskDependencies{
+		{TaskId: 2, DependsOn: []uint{1, 3}},
+	}}
+	assert.True(t, dependenciesSucceeded(plan, 1, map[uint]bool{}))
+	assert.False(t, dependenciesSucceeded(plan, 2, map[uint]bool{1: true}))
+	assert.True(t, dependenciesSucceeded(plan, 2, map[uint]bool{1: true, 3: true}))
+}
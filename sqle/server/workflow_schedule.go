@@ -51,6 +51,15 @@ func (j *WorkflowScheduleJob) WorkflowSchedule(entry *logrus.Entry) {
 			return
 		}
 
+		blocked, err := checkScheduledWorkflowFreeze(entry, st, w, needExecuteTaskIds)
+		if err != nil {
+			entry.Errorf("check execution freeze of scheduled workflow %s error: %v", w.Subject, err)
+			return
+		}
+		if blocked {
+			continue
+		}
+
 		entry.Infof("start to execute scheduled workflow %s", w.Subject)
 		if len(needExecuteTaskIds) == 0 {
 			entry.Warnf("workflow %s need to execute scheduled, but no task find", w.Subject)
@@ -64,40 +73,51 @@ func (j *WorkflowScheduleJob) WorkflowSchedule(entry *logrus.Entry) {
 	}
 }
 
+// checkScheduledWorkflowFreeze records the blocked state on the workflow record if the scheduled execution is blocked
+// by the freeze calendars, the workflow isn't scheduled again until the retry time. The blocked state is cleared when
+// the workflow is executed.
+func checkScheduledWorkflowFreeze(entry *logrus.Entry, st *model.Storage, workflow *model.Workflow, taskIds map[uint]string) (bool, error) {
+	now := time.Now()
+	freeze, err := GetWorkflowExecutionFreeze(workflow, taskIds, now)
+	if err != nil {
+		return false, err
+	}
+	if !freeze.IsBlocked() {
+		return false, nil
+	}
+	retryAt := freeze.ScheduleRetryAt(now)
+	if err := st.UpdateWorkflowScheduleBlocked(workflow.Record.ID, freeze.Reason(), &retryAt); err != nil {
+		return false, err
+	}
+	entry.Infof("scheduled workflow %s is blocked by the execution freeze, retry at %v", workflow.Subject, retryAt.Format(time.RFC3339))
+	return true, nil
+}
+
 func ExecuteWorkflow(workflow *model.Workflow, needExecTaskIdToUserId map[uint]string) (chan string, error) {
 	s := model.GetStorage()
 	l := log.NewEntry()
+	if err := CheckWorkflowExecutionFreeze(workflow, needExecTaskIdToUserId, time.Now()); err != nil {
+		return nil, err
+	}
+	if workflow.Record.ScheduleBlockedReason != "" {
+		if err := s.UpdateWorkflowScheduleBlocked(workflow.Record.ID, "", nil); err != nil {
+			l.Errorf("clear the blocked state of scheduled workflow error: %v", err)
+		}
+	}
 	err := s.UpdateStageWorkflowExecTimeIfNeed(workflow.WorkflowId)
 	if err != nil {
 		l.Errorf("update workflow execute time for version stage error: %v", err)
 	}
-	// get task and check connection before to execute it.
-	for taskId := range needExecTaskIdToUserId {
-		taskId := fmt.Sprintf("%d", taskId)
-		task, exist, err := s.GetTaskDetailById(taskId)
-		if err != nil {
-			return nil, err
-		}
-		if !exist {
-			return nil, errors.New(errors.DataNotExist, fmt.Errorf("task is not exist. taskID=%v", taskId))
-		}
-		instance, exist, err := dms.GetInstancesById(context.Background(), fmt.Sprintf("%d", task.InstanceId))
-		if err != nil {
-			return nil, err
-		}
-		if !exist {
-			return nil, errors.New(errors.DataNotExist, fmt.Errorf("instance is not exist. instanceId=%v", task.InstanceId))
-		}
-		task.Instance = instance
-		if task.Instance == nil {
-			return nil, errors.New(errors.DataNotExist, fmt.Errorf("instance is not exist"))
-		}
+	if err := checkTasksConnectable(needExecTaskIdToUserId); err != nil {
+		return nil, err
+	}
 
-		// if instance is not connectable, exec sql must be failed;
-		// commit action unable to retry, so don't to exec it.
-		if err = common.CheckInstanceIsConnectable(task.Instance); err != nil {
-			return nil, errors.New(errors.ConnectRemoteDatabaseError, err)
-		}
+	plan, hasPlan, err := s.GetWorkflowExecPlan(workflow.WorkflowId)
+	if err != nil {
+		return nil, err
+	}
+	if hasPlan {
+		return executeWorkflowByPlan(workflow, plan, needExecTaskIdToUserId, nil)
 	}
 
 	currentStep := workflow.CurrentStep()
@@ -146,7 +166,7 @@ func ExecuteWorkflow(workflow *model.Workflow, needExecTaskIdToUserId map[uint]s
 
 			{ // NOTE: Update the workflow status before sending notifications to ensure that the notification content reflects the latest information.
 				lock.Lock()
-				updateStatus(s, workflow, l, workflowStatusChan)
+				updateStatus(s, workflow, l, workflowStatusChan, nil)
 				lock.Unlock()
 			}
 
@@ -162,7 +182,42 @@ func ExecuteWorkflow(workflow *model.Workflow, needExecTaskIdToUserId map[uint]s
 	return workflowStatusChan, nil
 }
 
-func updateStatus(s *model.Storage, workflow *model.Workflow, l *logrus.Entry, workflowStatusChan chan string) {
+// checkTasksConnectable gets task and check connection before to execute it.
+func checkTasksConnectable(taskIds map[uint]string) error {
+	s := model.GetStorage()
+	for taskId := range taskIds {
+		taskId := fmt.Sprintf("%d", taskId)
+		task, exist, err := s.GetTaskDetailById(taskId)
+		if err != nil {
+			return err
+		}
+		if !exist {
+			return errors.New(errors.DataNotExist, fmt.Errorf("task is not exist. taskID=%v", taskId))
+		}
+		instance, exist, err := dms.GetInstancesById(context.Background(), fmt.Sprintf("%d", task.InstanceId))
+		if err != nil {
+			return err
+		}
+		if !exist {
+			return errors.New(errors.DataNotExist, fmt.Errorf("instance is not exist. instanceId=%v", task.InstanceId))
+		}
+		task.Instance = instance
+		if task.Instance == nil {
+			return errors.New(errors.DataNotExist, fmt.Errorf("instance is not exist"))
+		}
+
+		// if instance is not connectable, exec sql must be failed;
+		// commit action unable to retry, so don't to exec it.
+		if err = common.CheckInstanceIsConnectable(task.Instance); err != nil {
+			return errors.New(errors.ConnectRemoteDatabaseError, err)
+		}
+	}
+	return nil
+}
+
+// updateStatus updates the workflow status by its tasks, the tasks in pendingTaskIds are waiting for their
+// dependencies in the execution plan, so they are regarded as executing.
+func updateStatus(s *model.Storage, workflow *model.Workflow, l *logrus.Entry, workflowStatusChan chan string, pendingTaskIds map[uint]struct{}) {
 	tasks, err := s.GetTasksByWorkFlowRecordID(workflow.Record.ID)
 	if err != nil {
 		l.Errorf("get tasks by workflow record id error: %v", err)
@@ -175,6 +230,10 @@ func updateStatus(s *model.Storage, workflow *model.Workflow, l *logrus.Entry, w
 	var hasWaitExecute bool
 
 	for _, task := range tasks {
+		if _, ok := pendingTaskIds[task.ID]; ok {
+			hasExecuting = true
+			continue
+		}
 		if task.Status == model.TaskStatusExecuting {
 			hasExecuting = true
 		}
@@ -224,6 +283,19 @@ func ApproveWorkflowProcess(workflow *model.Workflow, user *model.User, s *model
 			fmt.Errorf("workflow has been approved, you should to execute it"))
 	}
 
+	if currentStep.HasApproved(user.GetIDStr()) {
+		return errors.New(errors.DataInvalid,
+			fmt.Errorf("you have approved the workflow step"))
+	}
+	// 需要多人或多个审批组审批的步骤，未完成审批前仅记录审批人，工单停留在当前步骤
+	originApprovedUsers := currentStep.ApprovedUsers
+	if !currentStep.AddApproval(user.GetIDStr()) {
+		if err := s.UpdateWorkflowStepApprovedUsers(currentStep, originApprovedUsers); err != nil {
+			return fmt.Errorf("update workflow step failed, %v", err)
+		}
+		return nil
+	}
+
 	currentStep.State = model.WorkflowStepStateApprove
 	now := time.Now()
 	currentStep.OperateAt = &now
@@ -0,0 +1,117 @@
+package server
+
+import (
+	"context"
+	"strings"
+
+	"github.com/actiontech/sqle/sqle/driver"
+	driverV2 "github.com/actiontech/sqle/sqle/driver/v2"
+	"github.com/actiontech/sqle/sqle/model"
+
+	"github.com/sirupsen/logrus"
+)
+
+// maxEstimateAffectedRowsSQLs limits the number of DML SQLs of a task to be estimated when creating workflow,
+// the affected rows of the task with more DML SQLs is regarded as unknown.
+const maxEstimateAffectedRowsSQLs = 200
+
+// GetWorkflowConditionInfo collects the facts of the tasks which the conditions of the review steps are evaluated on.
+func GetWorkflowConditionInfo(l *logrus.Entry, tasks []*model.Task, stepTemplates []*model.WorkflowStepTemplate) (*model.WorkflowConditionInfo, error) {
+	info := &model.WorkflowConditionInfo{
+		SQLTypes:                map[string]struct{}{},
+		InstanceEnvironmentTags: map[string]struct{}{},
+	}
+	if !hasStepCondition(stepTemplates) {
+		return info, nil
+	}
+
+	taskIds := make([]uint, 0, len(tasks))
+	for _, task := range tasks {
+		taskIds = append(taskIds, task.ID)
+		if driverV2.RuleLevel(task.AuditLevel).More(driverV2.RuleLevel(info.AuditLevel)) {
+			info.AuditLevel = task.AuditLevel
+		}
+		if task.Instance != nil && task.Instance.Business != "" {
+			info.InstanceEnvironmentTags[strings.ToLower(task.Instance.Business)] = struct{}{}
+		}
+	}
+
+	s := model.GetStorage()
+	sqlTypes, err := s.GetSQLTypesByTaskIds(taskIds)
+	if err != nil {
+		return nil, err
+	}
+	for _, sqlType := range sqlTypes {
+		info.SQLTypes[strings.ToLower(sqlType)] = struct{}{}
+	}
+
+	if needEstimateAffectedRows(stepTemplates) {
+		info.AffectedRows, info.AffectedRowsUnknown, err = estimateTasksAffectedRows(l, s, tasks)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return info, nil
+}
+
+func hasStepCondition(stepTemplates []*model.WorkflowStepTemplate) bool {
+	for _, st := range stepTemplates {
+		if st.Condition != nil && !st.Condition.IsEmpty() {
+			return true
+		}
+	}
+	return false
+}
+
+func needEstimateAffectedRows(stepTemplates []*model.WorkflowStepTemplate) bool {
+	for _, st := range stepTemplates {
+		if st.Condition != nil && st.Condition.AffectedRowsMoreThan != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// estimateTasksAffectedRows sums the estimated affected rows of the DML SQLs of the tasks. The result is
+// unknown if any SQL can't be estimated, e.g. the plugin doesn't support estimating or the instance is unreachable.
+func estimateTasksAffectedRows(l *logrus.Entry, s *model.Storage, tasks []*model.Task) (rows int64, unknown bool, err error) {
+	for _, task := range tasks {
+		sqls, err := s.GetExecuteSQLsByTaskIdsAndSQLTypes([]uint{task.ID}, []string{driverV2.SQLTypeDML})
+		if err != nil {
+			return 0, false, err
+		}
+		if len(sqls) == 0 {
+			continue
+		}
+		if task.Instance == nil || len(sqls) > maxEstimateAffectedRowsSQLs ||
+			!driver.GetPluginManager().IsOptionalModuleEnabled(task.Instance.DbType, driverV2.OptionalModuleEstimateSQLAffectRows) {
+			unknown = true
+			continue
+		}
+
+		taskRows, taskUnknown := estimateTaskAffectedRows(l, task, sqls)
+		rows += taskRows
+		unknown = unknown || taskUnknown
+	}
+	return rows, unknown, nil
+}
+
+func estimateTaskAffectedRows(l *logrus.Entry, task *model.Task, sqls []*model.ExecuteSQL) (rows int64, unknown bool) {
+	plugin, err := newDriverManagerWithAudit(l, task.Instance, task.Schema, task.DBType, nil)
+	if err != nil {
+		l.Warnf("open plugin to estimate affected rows of task %d failed: %v", task.ID, err)
+		return 0, true
+	}
+	defer plugin.Close(context.TODO())
+
+	for _, sql := range sqls {
+		res, err := plugin.EstimateSQLAffectRows(context.TODO(), sql.Content)
+		if err != nil || res == nil || res.ErrMessage != "" {
+			l.Warnf("estimate affected rows of task %d SQL %d failed: %v", task.ID, sql.Number, err)
+			unknown = true
+			continue
+		}
+		rows += res.Count
+	}
+	return rows, unknown
+}
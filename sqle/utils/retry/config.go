@@ -12,6 +12,8 @@ const (
 type Config struct {
 	attempts uint
 	delay    time.Duration
+	backoff  bool
+	retryIf  func(error) bool
 }
 
 type Option func(*Config)
@@ -30,6 +32,20 @@ func Delay(delay time.Duration) Option {
 	}
 }
 
+// Option: BackOff doubles the delay after each attempt
+func BackOff() Option {
+	return func(c *Config) {
+		c.backoff = true
+	}
+}
+
+// Option: RetryIf stops retrying and returns the error if it is not retryable
+func RetryIf(retryIf func(error) bool) Option {
+	return func(c *Config) {
+		c.retryIf = retryIf
+	}
+}
+
 func NewDefaultRetryConfig() *Config {
 	return &Config{
 		attempts: defaultAttempts,
@@ -32,6 +32,7 @@ func Do(retryableFunc RetryableFunc, doneChan chan struct{}, ops ...Option) erro
 
 	var idx uint = 0
 	errList := errListType{}
+	delay := cfg.delay
 
 	// cfg.attempts can not be 0.
 	for idx < cfg.attempts {
@@ -40,10 +41,19 @@ func Do(retryableFunc RetryableFunc, doneChan chan struct{}, ops ...Option) erro
 			utils.TryClose(doneChan)
 			return nil
 		}
+		if cfg.retryIf != nil && !cfg.retryIf(err) {
+			return err
+		}
 
 		idx++
 		errList = errList.AppendError(err.Error())
-		time.Sleep(cfg.delay)
+		if idx == cfg.attempts {
+			break
+		}
+		time.Sleep(delay)
+		if cfg.backoff {
+			delay *= 2
+		}
 	}
 
 	if len(errList) == 0 {
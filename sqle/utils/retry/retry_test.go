@@ -87,3 +87,28 @@ func RetryDo(t *testing.T, expectedRetryCount int, isFuncResErr bool) (doneErr e
 		}
 	}
 }
+
+func TestRetryIf(t *testing.T) {
+	errRetryable := errors.New("retryable")
+	errFatal := errors.New("fatal")
+	retryIf := RetryIf(func(err error) bool { return err == errRetryable })
+
+	count := 0
+	err := Do(func() error {
+		count++
+		if count < 3 {
+			return errRetryable
+		}
+		return nil
+	}, nil, Attempts(3), Delay(time.Millisecond), BackOff(), retryIf)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	count = 0
+	err = Do(func() error {
+		count++
+		return errFatal
+	}, nil, Attempts(3), Delay(time.Millisecond), retryIf)
+	assert.Equal(t, errFatal, err)
+	assert.Equal(t, 1, count)
+}